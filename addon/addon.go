@@ -3,22 +3,31 @@ package addon
 import (
 	"context"
 	"embed"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
+	"k8s.io/utils/ptr"
 	"open-cluster-management.io/addon-framework/pkg/addonfactory"
 	"open-cluster-management.io/addon-framework/pkg/addonmanager"
 	"open-cluster-management.io/addon-framework/pkg/agent"
@@ -26,6 +35,8 @@ import (
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/features"
 	appsubutils "open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -37,8 +48,16 @@ const (
 	ChartDir = "manifests/chart"
 
 	AgentImageEnv = "OPERAND_IMAGE_MULTICLUSTER_OPERATORS_SUBSCRIPTION"
+
+	// the key of the agent image in the chart values
+	agentImageKey = "global.imageOverrides.multicluster_operators_subscription"
+
+	// the agent is unhealthy if it didn't renew its lease for this number of lease durations
+	leaseDurationTimes = 5
 )
 
+var monitoringGroupVersion = schema.GroupVersion{Group: "monitoring.coreos.com", Version: "v1"}
+
 const (
 	// AnnotationKlusterletDeployMode is the annotation key of klusterlet deploy mode, it describes the
 	// klusterlet deploy mode when importing a managed cluster.
@@ -94,6 +113,7 @@ type GlobalValues struct {
 type Values struct {
 	OnHubCluster      bool         `json:"onHubCluster"`      // single hub cluster
 	OnMulticlusterHub bool         `json:"onMulticlusterHub"` // regional hub cluster
+	HostedMode        bool         `json:"hostedMode"`        // agent running on the hosting cluster
 	GlobalValues      GlobalValues `json:"global"`
 }
 
@@ -122,6 +142,12 @@ func getValue(cluster *clusterv1.ManagedCluster,
 		addonValues.OnHubCluster = true
 	}
 
+	// in Hosted mode, the agent runs in the klusterlet-{cluster name} namespace of the hosting cluster and reaches the
+	// managed cluster with the managedKubeConfigSecret kubeconfig
+	if mode, _ := HostedClusterInfo(addon, cluster); mode == "Hosted" {
+		addonValues.HostedMode = true
+	}
+
 	annotations := cluster.GetAnnotations()
 
 	// set OnMulticlusterHub to true for regional hub clusters, so that 3 addon crds won't be cleaned up when the regional hub is detached.
@@ -134,9 +160,12 @@ func getValue(cluster *clusterv1.ManagedCluster,
 	return addonfactory.JsonStructToValues(addonValues)
 }
 
+// toAddonResources transforms the memory and CPU customized variables of the AddOnDeploymentConfig into values, the
+// quantities are validated so an invalid value doesn't break the agent deployment
 func toAddonResources(config addonapiv1alpha1.AddOnDeploymentConfig) (addonfactory.Values, error) {
 	type resource struct {
 		Memory string `json:"memory"`
+		CPU    string `json:"cpu,omitempty"`
 	}
 
 	type resources struct {
@@ -158,13 +187,26 @@ func toAddonResources(config addonapiv1alpha1.AddOnDeploymentConfig) (addonfacto
 	}
 
 	for _, variable := range config.Spec.CustomizedVariables {
-		if variable.Name == "RequestMemory" {
-			jsonStruct.Resources.Requests.Memory = variable.Value
+		var value *string
+
+		switch variable.Name {
+		case "RequestMemory":
+			value = &jsonStruct.Resources.Requests.Memory
+		case "LimitsMemory":
+			value = &jsonStruct.Resources.Limits.Memory
+		case "RequestCPU":
+			value = &jsonStruct.Resources.Requests.CPU
+		case "LimitsCPU":
+			value = &jsonStruct.Resources.Limits.CPU
+		default:
+			continue
 		}
 
-		if variable.Name == "LimitsMemory" {
-			jsonStruct.Resources.Limits.Memory = variable.Value
+		if _, err := apiresource.ParseQuantity(variable.Value); err != nil {
+			return nil, fmt.Errorf("invalid %v %q: %w", variable.Name, variable.Value, err)
 		}
+
+		*value = variable.Value
 	}
 
 	values, err := addonfactory.JsonStructToValues(jsonStruct)
@@ -175,6 +217,145 @@ func toAddonResources(config addonapiv1alpha1.AddOnDeploymentConfig) (addonfacto
 	return values, nil
 }
 
+// toAddonScheduling transforms the scheduling customized variables of the AddOnDeploymentConfig into values. The
+// Tolerations and TopologySpreadConstraints variables are JSON lists, Tolerations overrides the tolerations of
+// spec.nodePlacement.
+func toAddonScheduling(config addonapiv1alpha1.AddOnDeploymentConfig) (addonfactory.Values, error) {
+	jsonStruct := struct {
+		Replicas                  *int32                            `json:"replicas,omitempty"`
+		PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+		Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+		TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	}{}
+
+	for _, variable := range config.Spec.CustomizedVariables {
+		switch variable.Name {
+		case "Replicas":
+			replicas, err := strconv.ParseInt(variable.Value, 10, 32)
+			if err != nil || replicas < 1 {
+				return nil, fmt.Errorf("invalid Replicas %q, it must be a positive integer", variable.Value)
+			}
+
+			jsonStruct.Replicas = ptr.To(int32(replicas))
+		case "PriorityClassName":
+			jsonStruct.PriorityClassName = variable.Value
+		case "Tolerations":
+			if err := json.Unmarshal([]byte(variable.Value), &jsonStruct.Tolerations); err != nil {
+				return nil, fmt.Errorf("invalid Tolerations %q, it must be a JSON list of tolerations: %w", variable.Value, err)
+			}
+		case "TopologySpreadConstraints":
+			if err := json.Unmarshal([]byte(variable.Value), &jsonStruct.TopologySpreadConstraints); err != nil {
+				return nil, fmt.Errorf("invalid TopologySpreadConstraints %q, it must be a JSON list of topology spread "+
+					"constraints: %w", variable.Value, err)
+			}
+		}
+	}
+
+	return addonfactory.JsonStructToValues(jsonStruct)
+}
+
+// toAddonAutoscaling transforms the autoscaling customized variables of the AddOnDeploymentConfig into values. The
+// HorizontalPodAutoscaler of the agent deployment is enabled by AutoscalingMaxReplicas, it scales on the CPU
+// utilization so RequestCPU is required.
+func toAddonAutoscaling(config addonapiv1alpha1.AddOnDeploymentConfig) (addonfactory.Values, error) {
+	type autoscaling struct {
+		Enabled                        bool  `json:"enabled"`
+		MinReplicas                    int32 `json:"minReplicas"`
+		MaxReplicas                    int32 `json:"maxReplicas"`
+		TargetCPUUtilizationPercentage int32 `json:"targetCPUUtilizationPercentage"`
+	}
+
+	jsonStruct := struct {
+		Autoscaling autoscaling `json:"autoscaling"`
+	}{
+		Autoscaling: autoscaling{
+			MinReplicas:                    1,
+			TargetCPUUtilizationPercentage: 80,
+		},
+	}
+
+	requestCPU := false
+
+	for _, variable := range config.Spec.CustomizedVariables {
+		var value *int32
+
+		switch variable.Name {
+		case "AutoscalingMinReplicas":
+			value = &jsonStruct.Autoscaling.MinReplicas
+		case "AutoscalingMaxReplicas":
+			value = &jsonStruct.Autoscaling.MaxReplicas
+		case "AutoscalingTargetCPUUtilization":
+			value = &jsonStruct.Autoscaling.TargetCPUUtilizationPercentage
+		case "RequestCPU":
+			requestCPU = true
+			continue
+		default:
+			continue
+		}
+
+		i, err := strconv.ParseInt(variable.Value, 10, 32)
+		if err != nil || i < 1 {
+			return nil, fmt.Errorf("invalid %v %q, it must be a positive integer", variable.Name, variable.Value)
+		}
+
+		*value = int32(i)
+	}
+
+	if jsonStruct.Autoscaling.MaxReplicas == 0 {
+		return nil, nil
+	}
+
+	if jsonStruct.Autoscaling.MaxReplicas < jsonStruct.Autoscaling.MinReplicas {
+		return nil, fmt.Errorf("invalid AutoscalingMaxReplicas %v, it must be at least AutoscalingMinReplicas %v",
+			jsonStruct.Autoscaling.MaxReplicas, jsonStruct.Autoscaling.MinReplicas)
+	}
+
+	if !requestCPU {
+		return nil, fmt.Errorf("RequestCPU is required by the autoscaling of the agent deployment")
+	}
+
+	jsonStruct.Autoscaling.Enabled = true
+
+	return addonfactory.JsonStructToValues(jsonStruct)
+}
+
+// toAddonAgentArgs transforms the LogLevel, FeatureGates and LeaseDuration customized variables of the
+// AddOnDeploymentConfig into values of the agent container args
+func toAddonAgentArgs(config addonapiv1alpha1.AddOnDeploymentConfig) (addonfactory.Values, error) {
+	jsonStruct := struct {
+		LogLevel             *int32 `json:"logLevel,omitempty"`
+		FeatureGates         string `json:"featureGates,omitempty"`
+		LeaseDurationSeconds *int32 `json:"leaseDurationSeconds,omitempty"`
+	}{}
+
+	for _, variable := range config.Spec.CustomizedVariables {
+		switch variable.Name {
+		case "LogLevel":
+			level, err := strconv.ParseInt(variable.Value, 10, 32)
+			if err != nil || level < 0 || level > 10 {
+				return nil, fmt.Errorf("invalid LogLevel %q, it must be an integer between 0 and 10", variable.Value)
+			}
+
+			jsonStruct.LogLevel = ptr.To(int32(level))
+		case "FeatureGates":
+			if err := features.ValidateFeatureGates(variable.Value); err != nil {
+				return nil, fmt.Errorf("invalid FeatureGates %q: %w", variable.Value, err)
+			}
+
+			jsonStruct.FeatureGates = variable.Value
+		case "LeaseDuration":
+			duration, err := strconv.ParseInt(variable.Value, 10, 32)
+			if err != nil || duration < 1 {
+				return nil, fmt.Errorf("invalid LeaseDuration %q, it must be a positive number of seconds", variable.Value)
+			}
+
+			jsonStruct.LeaseDurationSeconds = ptr.To(int32(duration))
+		}
+	}
+
+	return addonfactory.JsonStructToValues(jsonStruct)
+}
+
 func newRegistrationOption(kubeClient *kubernetes.Clientset, addonName string) *agent.RegistrationOption {
 	return &agent.RegistrationOption{
 		CSRConfigurations: agent.KubeClientSignerConfigurations(addonName, addonName),
@@ -249,20 +430,31 @@ func NewAddonManager(mgr manager.Manager, kubeConfig *rest.Config, agentImage st
 	addonGetter := addonfactory.NewAddOnDeploymentConfigGetter(addonClient)
 
 	agentFactory := addonfactory.NewAgentAddonFactory(AppMgrAddonName, ChartFS, ChartDir).
+		WithScheme(NewAgentScheme()).
 		// register the supported configuration types
 		WithConfigGVRs(
 			schema.GroupVersionResource{Group: "addon.open-cluster-management.io", Version: "v1alpha1", Resource: "addondeploymentconfigs"},
 		).
 		WithGetValuesFuncs(
 			getValue,
+			// override the agent image with the registries of the AddOnDeploymentConfig, or of the
+			// open-cluster-management.io/image-registries annotation of the managed cluster
+			addonfactory.GetAgentImageValues(addonGetter, agentImageKey, AppMgrImage),
 			addonfactory.GetValuesFromAddonAnnotation,
 			// get the AddOnDeloymentConfig object and transform nodeSelector and toleration defined in spec.NodePlacement to Values object
-			// transform request/limit memory defined in Spec.CustomizedVariables to values object
+			// transform request/limit memory and cpu defined in Spec.CustomizedVariables to values object
+			// transform autoscaling defined in Spec.CustomizedVariables to values object
+			// transform replicas, priorityClassName, tolerations and topologySpreadConstraints defined in
+			// Spec.CustomizedVariables to values object
+			// transform log level, feature gates and lease duration defined in Spec.CustomizedVariables to values object
 			// transform proxyConfig to values object
 			addonfactory.GetAddOnDeploymentConfigValues(
 				addonGetter,
 				addonfactory.ToAddOnNodePlacementValues,
 				toAddonResources,
+				toAddonScheduling,
+				toAddonAutoscaling,
+				toAddonAgentArgs,
 				addonfactory.ToAddOnProxyConfigValues,
 				addonfactory.ToAddOnResourceRequirementsValues,
 			),
@@ -270,6 +462,9 @@ func NewAddonManager(mgr manager.Manager, kubeConfig *rest.Config, agentImage st
 		WithAgentInstallNamespace(AddonInstallNamespaceFunc(
 			utils.NewAddOnDeploymentConfigGetter(addonClient), mgr.GetClient())).
 		WithAgentRegistrationOption(newRegistrationOption(kubeClient, AppMgrAddonName)).
+		WithAgentHealthProber(newHealthProber()).
+		WithAgentHostedModeEnabledOption().
+		WithAgentHostedInfoFn(HostedClusterInfo).
 		WithAgentDeployTriggerClusterFilter(func(old, new *clusterv1.ManagedCluster) bool {
 			return !equality.Semantic.DeepEqual(old.Annotations, new.Annotations)
 		})
@@ -280,11 +475,147 @@ func NewAddonManager(mgr manager.Manager, kubeConfig *rest.Config, agentImage st
 		return addonMgr, err
 	}
 
-	err = addonMgr.AddAgent(agentAddon)
+	err = addonMgr.AddAgent(&appMgrAgentAddon{AgentAddon: agentAddon})
 
 	return addonMgr, err
 }
 
+// NewAgentScheme returns the scheme decoding the agent manifests. The ServiceMonitor and PodMonitor kinds of the
+// Prometheus operator are decoded as unstructured objects, the manifests of unknown kinds are skipped by the addon
+// framework.
+func NewAgentScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+
+	for _, kind := range []string{"ServiceMonitor", "PodMonitor"} {
+		s.AddKnownTypeWithName(monitoringGroupVersion.WithKind(kind), &unstructured.Unstructured{})
+	}
+
+	return s
+}
+
+// appMgrAgentAddon creates the agent lease with the agent manifests, the lease is then only renewed by the agent
+type appMgrAgentAddon struct {
+	agent.AgentAddon
+}
+
+func (a *appMgrAgentAddon) GetAgentAddonOptions() agent.AgentAddonOptions {
+	options := a.AgentAddon.GetAgentAddonOptions()
+	options.Updaters = append(options.Updaters, agent.Updater{
+		ResourceIdentifier: workapiv1.ResourceIdentifier{
+			Group:     coordinationv1.GroupName,
+			Resource:  "leases",
+			Name:      AppMgrAddonName,
+			Namespace: "*",
+		},
+		UpdateStrategy: workapiv1.UpdateStrategy{
+			Type: workapiv1.UpdateStrategyTypeCreateOnly,
+		},
+	})
+
+	return options
+}
+
+// newHealthProber returns the health prober of the agent, based on the status feedback of the agent deployment and of
+// the agent lease
+func newHealthProber() *agent.HealthProber {
+	return &agent.HealthProber{
+		Type: agent.HealthProberTypeWork,
+		WorkProber: &agent.WorkHealthProber{
+			ProbeFields: []agent.ProbeField{
+				{
+					ResourceIdentifier: workapiv1.ResourceIdentifier{
+						Group:     appsv1.GroupName,
+						Resource:  "deployments",
+						Name:      AppMgrAddonName,
+						Namespace: "*",
+					},
+					ProbeRules: []workapiv1.FeedbackRule{
+						{
+							Type: workapiv1.WellKnownStatusType,
+						},
+					},
+				},
+				{
+					ResourceIdentifier: workapiv1.ResourceIdentifier{
+						Group:     coordinationv1.GroupName,
+						Resource:  "leases",
+						Name:      AppMgrAddonName,
+						Namespace: "*",
+					},
+					ProbeRules: []workapiv1.FeedbackRule{
+						{
+							Type: workapiv1.JSONPathsType,
+							JsonPaths: []workapiv1.JsonPath{
+								{Name: "renewTime", Path: ".spec.renewTime"},
+								{Name: "leaseDurationSeconds", Path: ".spec.leaseDurationSeconds"},
+							},
+						},
+					},
+				},
+			},
+			HealthChecker: agentHealthChecker,
+		},
+	}
+}
+
+// agentHealthChecker checks that the agent deployment has a ready replica, so a crash looping agent is unavailable,
+// and that the agent renews its lease. The lease isn't probed in Hosted mode.
+func agentHealthChecker(results []agent.FieldResult,
+	_ *clusterv1.ManagedCluster, _ *addonapiv1alpha1.ManagedClusterAddOn) error {
+	deploymentProbed := false
+
+	for _, result := range results {
+		switch result.ResourceIdentifier.Resource {
+		case "deployments":
+			deploymentProbed = true
+
+			if err := utils.WorkloadAvailabilityHealthCheck(result.ResourceIdentifier, result.FeedbackResult); err != nil {
+				return err
+			}
+		case "leases":
+			if err := checkAgentLease(result.FeedbackResult, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !deploymentProbed {
+		return fmt.Errorf("the %v deployment is not probed yet", AppMgrAddonName)
+	}
+
+	return nil
+}
+
+// checkAgentLease returns an error if the agent lease wasn't renewed within leaseDurationTimes lease durations
+func checkAgentLease(result workapiv1.StatusFeedbackResult, now time.Time) error {
+	renewTime := ""
+	leaseDurationSeconds := int64(60)
+
+	for _, value := range result.Values {
+		switch {
+		case value.Name == "renewTime" && value.Value.String != nil:
+			renewTime = *value.Value.String
+		case value.Name == "leaseDurationSeconds" && value.Value.Integer != nil:
+			leaseDurationSeconds = *value.Value.Integer
+		}
+	}
+
+	if renewTime == "" {
+		return fmt.Errorf("the %v lease is not renewed yet", AppMgrAddonName)
+	}
+
+	renewed, err := time.Parse(time.RFC3339, renewTime)
+	if err != nil {
+		return fmt.Errorf("invalid renew time %q of the %v lease: %w", renewTime, AppMgrAddonName, err)
+	}
+
+	if now.Sub(renewed) > time.Duration(leaseDurationTimes*leaseDurationSeconds)*time.Second {
+		return fmt.Errorf("the %v lease is not renewed since %v", AppMgrAddonName, renewTime)
+	}
+
+	return nil
+}
+
 func GetMchImage(kubeConfig *rest.Config) (string, error) {
 	kubeClient, err := client.New(kubeConfig, client.Options{})
 	if err != nil {
@@ -351,6 +682,11 @@ func GetMchImage(kubeConfig *rest.Config) (string, error) {
 
 	klog.Infof("MCH appsubimage: %v", image)
 
+	// the ImageContentSourcePolicy mirrors of the disconnected managed clusters only apply to images pulled by digest
+	if image != "" && !strings.Contains(image, "@sha256:") {
+		klog.Warningf("MCH appsubimage %v is not pinned by digest, the ImageContentSourcePolicy mirrors do not apply to it", image)
+	}
+
 	return image, nil
 }
 
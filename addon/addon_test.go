@@ -2,10 +2,19 @@ package addon
 
 import (
 	"context"
+	"sort"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"open-cluster-management.io/addon-framework/pkg/addonfactory"
@@ -13,6 +22,7 @@ import (
 	"open-cluster-management.io/addon-framework/pkg/utils"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -89,7 +99,7 @@ func TestManifest(t *testing.T) {
 			addon:             newAddon(AppMgrAddonName, "cluster1", "", `{"global":{"nodeSelector":{"node-role.kubernetes.io/infra":""},"imageOverrides":{"multicluster_operators_subscription":"quay.io/test/multicluster_operators_subscription:test"}}}`),
 			expectedNamespace: "open-cluster-management-agent-addon",
 			expectedImage:     "quay.io/test/multicluster_operators_subscription:test",
-			expectedCount:     10,
+			expectedCount:     11,
 		},
 		{
 			name:              "case_2",
@@ -97,7 +107,7 @@ func TestManifest(t *testing.T) {
 			addon:             newAddon(AppMgrAddonName, "local-cluster", "test", ""),
 			expectedNamespace: "test",
 			expectedImage:     "quay.io/open-cluster-management/multicluster_operators_subscription:latest",
-			expectedCount:     6,
+			expectedCount:     7,
 		},
 	}
 	AppMgrImage = "quay.io/open-cluster-management/multicluster_operators_subscription:latest"
@@ -217,3 +227,652 @@ func TestAddonInstallNamespaceFunc(t *testing.T) {
 		})
 	}
 }
+
+func newAddonConfigWithVariables(variables map[string]string) addonapiv1alpha1.AddOnDeploymentConfig {
+	config := addonapiv1alpha1.AddOnDeploymentConfig{}
+
+	for name, value := range variables {
+		config.Spec.CustomizedVariables = append(config.Spec.CustomizedVariables,
+			addonapiv1alpha1.CustomizedVariable{Name: name, Value: value})
+	}
+
+	return config
+}
+
+func TestToAddonScheduling(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		expected  addonfactory.Values
+		expectErr bool
+	}{
+		{
+			name:     "no variables",
+			expected: addonfactory.Values{},
+		},
+		{
+			name: "all variables",
+			variables: map[string]string{
+				"Replicas":                  "2",
+				"PriorityClassName":         "system-cluster-critical",
+				"Tolerations":               `[{"key":"node-role.kubernetes.io/infra","operator":"Exists","effect":"NoSchedule"}]`,
+				"TopologySpreadConstraints": `[{"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"ScheduleAnyway"}]`,
+			},
+			expected: addonfactory.Values{
+				"replicas":          float64(2),
+				"priorityClassName": "system-cluster-critical",
+				"tolerations": []interface{}{
+					map[string]interface{}{"key": "node-role.kubernetes.io/infra", "operator": "Exists", "effect": "NoSchedule"},
+				},
+				"topologySpreadConstraints": []interface{}{
+					map[string]interface{}{
+						"maxSkew": float64(1), "topologyKey": "kubernetes.io/hostname", "whenUnsatisfiable": "ScheduleAnyway",
+					},
+				},
+			},
+		},
+		{
+			name:      "invalid replicas",
+			variables: map[string]string{"Replicas": "0"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid tolerations",
+			variables: map[string]string{"Tolerations": "infra"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := toAddonScheduling(newAddonConfigWithVariables(test.variables))
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got values %v", values)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+
+			if !equality.Semantic.DeepEqual(values, test.expected) {
+				t.Errorf("expected values %v, got %v", test.expected, values)
+			}
+		})
+	}
+}
+
+func TestManifestScheduling(t *testing.T) {
+	AppMgrImage = "quay.io/open-cluster-management/multicluster_operators_subscription:latest"
+	agentAddon := newAgentAddon(t)
+
+	addon := newAddon(AppMgrAddonName, "cluster1", "",
+		`{"replicas":2,"priorityClassName":"system-cluster-critical",`+
+			`"topologySpreadConstraints":[{"maxSkew":1,"topologyKey":"kubernetes.io/hostname","whenUnsatisfiable":"ScheduleAnyway"}]}`)
+
+	objects, err := agentAddon.Manifests(newCluster("cluster1"), addon)
+	if err != nil {
+		t.Fatalf("failed to get manifests with error %v", err)
+	}
+
+	found := false
+
+	for _, o := range objects {
+		deployment, ok := o.(*appsv1.Deployment)
+		if !ok {
+			continue
+		}
+
+		found = true
+
+		if *deployment.Spec.Replicas != 2 {
+			t.Errorf("expected 2 replicas, got %v", *deployment.Spec.Replicas)
+		}
+
+		if deployment.Spec.Template.Spec.PriorityClassName != "system-cluster-critical" {
+			t.Errorf("expected priority class system-cluster-critical, got %v", deployment.Spec.Template.Spec.PriorityClassName)
+		}
+
+		if len(deployment.Spec.Template.Spec.TopologySpreadConstraints) != 1 {
+			t.Errorf("expected 1 topology spread constraint, got %v", deployment.Spec.Template.Spec.TopologySpreadConstraints)
+		}
+
+		// the default tolerations of the chart are kept
+		if len(deployment.Spec.Template.Spec.Tolerations) != 2 {
+			t.Errorf("expected the 2 default tolerations, got %v", deployment.Spec.Template.Spec.Tolerations)
+		}
+	}
+
+	if !found {
+		t.Errorf("the agent deployment is not found")
+	}
+}
+
+func TestToAddonResources(t *testing.T) {
+	values, err := toAddonResources(newAddonConfigWithVariables(map[string]string{
+		"RequestMemory": "512Mi",
+		"RequestCPU":    "100m",
+		"LimitsCPU":     "2",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := addonfactory.Values{
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"memory": "512Mi", "cpu": "100m"},
+			"limits":   map[string]interface{}{"memory": "2Gi", "cpu": "2"},
+		},
+	}
+
+	if !equality.Semantic.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+
+	if _, err := toAddonResources(newAddonConfigWithVariables(map[string]string{"LimitsCPU": "two"})); err == nil {
+		t.Errorf("expected an error for an invalid CPU limit")
+	}
+}
+
+func TestToAddonAutoscaling(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		expected  addonfactory.Values
+		expectErr bool
+	}{
+		{
+			name:      "autoscaling disabled",
+			variables: map[string]string{"RequestCPU": "100m"},
+		},
+		{
+			name: "autoscaling enabled",
+			variables: map[string]string{
+				"RequestCPU":                      "100m",
+				"AutoscalingMaxReplicas":          "3",
+				"AutoscalingTargetCPUUtilization": "60",
+			},
+			expected: addonfactory.Values{
+				"autoscaling": map[string]interface{}{
+					"enabled":                        true,
+					"minReplicas":                    float64(1),
+					"maxReplicas":                    float64(3),
+					"targetCPUUtilizationPercentage": float64(60),
+				},
+			},
+		},
+		{
+			name:      "cpu request missing",
+			variables: map[string]string{"AutoscalingMaxReplicas": "3"},
+			expectErr: true,
+		},
+		{
+			name:      "max replicas lower than min replicas",
+			variables: map[string]string{"RequestCPU": "100m", "AutoscalingMinReplicas": "3", "AutoscalingMaxReplicas": "2"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid max replicas",
+			variables: map[string]string{"RequestCPU": "100m", "AutoscalingMaxReplicas": "many"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := toAddonAutoscaling(newAddonConfigWithVariables(test.variables))
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got values %v", values)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+
+			if !equality.Semantic.DeepEqual(values, test.expected) {
+				t.Errorf("expected values %v, got %v", test.expected, values)
+			}
+		})
+	}
+}
+
+func TestManifestAutoscaling(t *testing.T) {
+	AppMgrImage = "quay.io/open-cluster-management/multicluster_operators_subscription:latest"
+	agentAddon := newAgentAddon(t)
+
+	addon := newAddon(AppMgrAddonName, "cluster1", "",
+		`{"resources":{"requests":{"cpu":"100m","memory":"128Mi"}},`+
+			`"autoscaling":{"enabled":true,"minReplicas":1,"maxReplicas":3,"targetCPUUtilizationPercentage":80}}`)
+
+	objects, err := agentAddon.Manifests(newCluster("cluster1"), addon)
+	if err != nil {
+		t.Fatalf("failed to get manifests with error %v", err)
+	}
+
+	foundHPA := false
+
+	for _, o := range objects {
+		switch object := o.(type) {
+		case *appsv1.Deployment:
+			// the replicas are managed by the autoscaler
+			if object.Spec.Replicas != nil {
+				t.Errorf("expected no replicas, got %v", *object.Spec.Replicas)
+			}
+
+			if object.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().String() != "100m" {
+				t.Errorf("expected cpu request 100m, got %v", object.Spec.Template.Spec.Containers[0].Resources.Requests)
+			}
+		case *autoscalingv2.HorizontalPodAutoscaler:
+			foundHPA = true
+
+			if object.Spec.MaxReplicas != 3 {
+				t.Errorf("expected 3 max replicas, got %v", object.Spec.MaxReplicas)
+			}
+		}
+	}
+
+	if !foundHPA {
+		t.Errorf("the agent HorizontalPodAutoscaler is not found")
+	}
+}
+
+func TestManifestImageMirrors(t *testing.T) {
+	AppMgrImage = "quay.io/open-cluster-management/multicluster_operators_subscription@sha256:abc"
+
+	config := &addonapiv1alpha1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test"},
+		Spec: addonapiv1alpha1.AddOnDeploymentConfigSpec{
+			Registries: []addonapiv1alpha1.ImageMirror{
+				{Source: "quay.io/open-cluster-management", Mirror: "config.registry.local/ocm"},
+			},
+		},
+	}
+
+	annotatedCluster := newCluster("cluster1")
+	annotatedCluster.SetAnnotations(map[string]string{
+		"open-cluster-management.io/image-registries": `{"registries":[{"source":"quay.io/open-cluster-management","mirror":"cluster.registry.local/ocm"}]}`,
+	})
+
+	tests := []struct {
+		name          string
+		cluster       *clusterv1.ManagedCluster
+		addon         *addonapiv1alpha1.ManagedClusterAddOn
+		expectedImage string
+	}{
+		{
+			name:          "no mirror",
+			cluster:       newCluster("cluster1"),
+			addon:         newAddon(AppMgrAddonName, "cluster1", "", ""),
+			expectedImage: "quay.io/open-cluster-management/multicluster_operators_subscription@sha256:abc",
+		},
+		{
+			name:    "values mirror",
+			cluster: newCluster("cluster1"),
+			addon: newAddon(AppMgrAddonName, "cluster1", "",
+				`{"global":{"imageMirrors":[{"source":"quay.io/other","mirror":"other.registry.local"},`+
+					`{"source":"quay.io/open-cluster-management","mirror":"registry.local/ocm"}]}}`),
+			expectedImage: "registry.local/ocm/multicluster_operators_subscription@sha256:abc",
+		},
+		{
+			name:          "addon deployment config registries",
+			cluster:       newCluster("cluster1"),
+			addon:         newAddonWithConfig(AppMgrAddonName, "cluster1", "", config),
+			expectedImage: "config.registry.local/ocm/multicluster_operators_subscription@sha256:abc",
+		},
+		{
+			name:          "cluster registries annotation",
+			cluster:       annotatedCluster,
+			addon:         newAddon(AppMgrAddonName, "cluster1", "", ""),
+			expectedImage: "cluster.registry.local/ocm/multicluster_operators_subscription@sha256:abc",
+		},
+	}
+
+	agentAddon, err := addonfactory.NewAgentAddonFactory(AppMgrAddonName, ChartFS, ChartDir).
+		WithScheme(scheme).
+		WithGetValuesFuncs(getValue,
+			addonfactory.GetAgentImageValues(newTestConfigGetter(config), agentImageKey, AppMgrImage),
+			addonfactory.GetValuesFromAddonAnnotation).
+		WithAgentRegistrationOption(newRegistrationOption(nil, AppMgrAddonName)).
+		BuildHelmAgentAddon()
+	if err != nil {
+		t.Fatalf("failed to build agent %v", err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objects, err := agentAddon.Manifests(test.cluster, test.addon)
+			if err != nil {
+				t.Fatalf("failed to get manifests with error %v", err)
+			}
+
+			for _, o := range objects {
+				var image string
+
+				switch object := o.(type) {
+				case *appsv1.Deployment:
+					image = object.Spec.Template.Spec.Containers[0].Image
+				case *batchv1.Job:
+					image = object.Spec.Template.Spec.Containers[0].Image
+				default:
+					continue
+				}
+
+				if image != test.expectedImage {
+					t.Errorf("expected image of %T is %s, but got %s", o, test.expectedImage, image)
+				}
+			}
+		})
+	}
+}
+
+func TestManifestHostedMode(t *testing.T) {
+	AppMgrImage = "quay.io/open-cluster-management/multicluster_operators_subscription:latest"
+	agentAddon := newAgentAddon(t)
+
+	cluster := newCluster("cluster1")
+	cluster.SetAnnotations(map[string]string{
+		AnnotationEnableHostedModeAddons:       "true",
+		AnnotationKlusterletDeployMode:         "Hosted",
+		AnnotationKlusterletHostingClusterName: "hosting-cluster",
+	})
+
+	objects, err := agentAddon.Manifests(cluster, newAddon(AppMgrAddonName, "cluster1", "klusterlet-cluster1", ""))
+	if err != nil {
+		t.Fatalf("failed to get manifests with error %v", err)
+	}
+
+	location := func(o metav1.Object) string {
+		return o.GetAnnotations()[addonapiv1alpha1.HostedManifestLocationAnnotationKey]
+	}
+
+	foundDeployment := false
+
+	for _, o := range objects {
+		switch object := o.(type) {
+		case *appsv1.Deployment:
+			foundDeployment = true
+
+			if location(object) != addonapiv1alpha1.HostedManifestLocationHostingValue {
+				t.Errorf("expected the deployment on the hosting cluster, got location %q", location(object))
+			}
+
+			args := object.Spec.Template.Spec.Containers[0].Args
+			if !equality.Semantic.DeepEqual(args[len(args)-2:],
+				[]string{"--hosted-mode", "--kubeconfig=/var/run/managed-kubeconfig/kubeconfig"}) {
+				t.Errorf("expected the hosted mode args, got %v", args)
+			}
+
+			foundVolume := false
+
+			for _, v := range object.Spec.Template.Spec.Volumes {
+				if v.Secret != nil && v.Secret.SecretName == "application-manager-managed-kubeconfig" {
+					foundVolume = true
+				}
+			}
+
+			if !foundVolume {
+				t.Errorf("expected the managed kubeconfig volume, got %v", object.Spec.Template.Spec.Volumes)
+			}
+		case *batchv1.Job, *corev1.ServiceAccount, *corev1.Service:
+			if location(o.(metav1.Object)) != addonapiv1alpha1.HostedManifestLocationHostingValue {
+				t.Errorf("expected %T on the hosting cluster, got location %q", o, location(o.(metav1.Object)))
+			}
+		case *coordinationv1.Lease:
+			t.Errorf("expected no agent lease in Hosted mode")
+		case *rbacv1.ClusterRole:
+			if object.Name == "aggregate-appsub-admin" {
+				if location(object) != "" {
+					t.Errorf("expected the aggregated cluster role on the managed cluster, got location %q", location(object))
+				}
+			} else if location(object) != addonapiv1alpha1.HostedManifestLocationNoneValue {
+				t.Errorf("expected no agent cluster role in Hosted mode, got location %q", location(object))
+			}
+		}
+	}
+
+	if !foundDeployment {
+		t.Errorf("the agent deployment is not found")
+	}
+}
+
+func TestAgentHealthChecker(t *testing.T) {
+	deployment := func(ready int64) agent.FieldResult {
+		replicas := int64(1)
+
+		return agent.FieldResult{
+			ResourceIdentifier: workapiv1.ResourceIdentifier{
+				Group: "apps", Resource: "deployments", Name: AppMgrAddonName, Namespace: "open-cluster-management-agent-addon",
+			},
+			FeedbackResult: workapiv1.StatusFeedbackResult{Values: []workapiv1.FeedbackValue{
+				{Name: "Replicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: &replicas}},
+				{Name: "ReadyReplicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: &ready}},
+			}},
+		}
+	}
+
+	lease := func(renewTime string) agent.FieldResult {
+		duration := int64(60)
+		result := agent.FieldResult{
+			ResourceIdentifier: workapiv1.ResourceIdentifier{
+				Group: "coordination.k8s.io", Resource: "leases", Name: AppMgrAddonName, Namespace: "open-cluster-management-agent-addon",
+			},
+			FeedbackResult: workapiv1.StatusFeedbackResult{Values: []workapiv1.FeedbackValue{
+				{Name: "leaseDurationSeconds", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: &duration}},
+			}},
+		}
+
+		if renewTime != "" {
+			result.FeedbackResult.Values = append(result.FeedbackResult.Values, workapiv1.FeedbackValue{
+				Name: "renewTime", Value: workapiv1.FieldValue{Type: workapiv1.String, String: &renewTime},
+			})
+		}
+
+		return result
+	}
+
+	recent := time.Now().Add(-time.Minute).UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	expired := time.Now().Add(-10 * time.Minute).UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+
+	tests := []struct {
+		name      string
+		results   []agent.FieldResult
+		expectErr bool
+	}{
+		{name: "not probed", results: nil, expectErr: true},
+		{name: "available", results: []agent.FieldResult{deployment(1), lease(recent)}},
+		{name: "crash looping", results: []agent.FieldResult{deployment(0), lease(recent)}, expectErr: true},
+		{name: "lease not renewed yet", results: []agent.FieldResult{deployment(1), lease("")}, expectErr: true},
+		{name: "lease expired", results: []agent.FieldResult{deployment(1), lease(expired)}, expectErr: true},
+		{name: "hosted mode without lease", results: []agent.FieldResult{deployment(1)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := agentHealthChecker(test.results, newCluster("cluster1"), newAddon(AppMgrAddonName, "cluster1", "", ""))
+			if (err != nil) != test.expectErr {
+				t.Errorf("expected error %v, got %v", test.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestAgentLeaseUpdater(t *testing.T) {
+	agentAddon := &appMgrAgentAddon{AgentAddon: newAgentAddon(t)}
+
+	updaters := agentAddon.GetAgentAddonOptions().Updaters
+	if len(updaters) != 1 || updaters[0].ResourceIdentifier.Group != coordinationv1.GroupName ||
+		updaters[0].UpdateStrategy.Type != workapiv1.UpdateStrategyTypeCreateOnly {
+		t.Errorf("expected the agent lease to be created only, got %v", updaters)
+	}
+}
+
+func TestToAddonAgentArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		expected  addonfactory.Values
+		expectErr bool
+	}{
+		{
+			name:     "no variables",
+			expected: addonfactory.Values{},
+		},
+		{
+			name: "all variables",
+			variables: map[string]string{
+				"LogLevel":      "4",
+				"FeatureGates":  "PersistSubscriberState=false",
+				"LeaseDuration": "120",
+			},
+			expected: addonfactory.Values{
+				"logLevel":             float64(4),
+				"featureGates":         "PersistSubscriberState=false",
+				"leaseDurationSeconds": float64(120),
+			},
+		},
+		{
+			name:      "invalid log level",
+			variables: map[string]string{"LogLevel": "debug"},
+			expectErr: true,
+		},
+		{
+			name:      "unknown feature gate",
+			variables: map[string]string{"FeatureGates": "Unknown=true"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid lease duration",
+			variables: map[string]string{"LeaseDuration": "-1"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := toAddonAgentArgs(newAddonConfigWithVariables(test.variables))
+			if test.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got values %v", values)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+
+			if !equality.Semantic.DeepEqual(values, test.expected) {
+				t.Errorf("expected values %v, got %v", test.expected, values)
+			}
+		})
+	}
+}
+
+func TestManifestAgentArgs(t *testing.T) {
+	AppMgrImage = "quay.io/open-cluster-management/multicluster_operators_subscription:latest"
+	agentAddon := newAgentAddon(t)
+
+	addon := newAddon(AppMgrAddonName, "cluster1", "",
+		`{"logLevel":4,"featureGates":"PersistSubscriberState=false","leaseDurationSeconds":120}`)
+
+	objects, err := agentAddon.Manifests(newCluster("cluster1"), addon)
+	if err != nil {
+		t.Fatalf("failed to get manifests with error %v", err)
+	}
+
+	for _, o := range objects {
+		switch object := o.(type) {
+		case *appsv1.Deployment:
+			args := map[string]bool{}
+			for _, arg := range object.Spec.Template.Spec.Containers[0].Args {
+				args[arg] = true
+			}
+
+			for _, arg := range []string{"--v=4", "--feature-gates=PersistSubscriberState=false", "--lease-duration=120"} {
+				if !args[arg] {
+					t.Errorf("expected the agent arg %v, got %v", arg, object.Spec.Template.Spec.Containers[0].Args)
+				}
+			}
+		case *coordinationv1.Lease:
+			if *object.Spec.LeaseDurationSeconds != 120 {
+				t.Errorf("expected the lease duration 120, got %v", *object.Spec.LeaseDurationSeconds)
+			}
+		}
+	}
+}
+
+func TestManifestMetrics(t *testing.T) {
+	AppMgrImage = "quay.io/open-cluster-management/multicluster_operators_subscription:latest"
+
+	agentAddon, err := addonfactory.NewAgentAddonFactory(AppMgrAddonName, ChartFS, ChartDir).
+		WithScheme(NewAgentScheme()).
+		WithGetValuesFuncs(getValue, addonfactory.GetValuesFromAddonAnnotation).
+		WithAgentRegistrationOption(newRegistrationOption(nil, AppMgrAddonName)).
+		BuildHelmAgentAddon()
+	if err != nil {
+		t.Fatalf("failed to build agent %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		values        string
+		expectedKinds []string
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:          "service monitor",
+			values:        `{"metrics":{"serviceMonitor":{"enabled":true,"labels":{"prometheus":"k8s"}}}}`,
+			expectedKinds: []string{"ServiceMonitor"},
+		},
+		{
+			name:          "service and pod monitors",
+			values:        `{"metrics":{"serviceMonitor":{"enabled":true},"podMonitor":{"enabled":true}}}`,
+			expectedKinds: []string{"PodMonitor", "ServiceMonitor"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objects, err := agentAddon.Manifests(newCluster("cluster1"), newAddon(AppMgrAddonName, "cluster1", "", test.values))
+			if err != nil {
+				t.Fatalf("failed to get manifests with error %v", err)
+			}
+
+			kinds := []string{}
+
+			for _, o := range objects {
+				object, ok := o.(*unstructured.Unstructured)
+				if !ok || object.GroupVersionKind().Group != "monitoring.coreos.com" {
+					continue
+				}
+
+				kinds = append(kinds, object.GetKind())
+
+				if object.GetNamespace() != "open-cluster-management-agent-addon" {
+					t.Errorf("expected the %v in the agent namespace, got %v", object.GetKind(), object.GetNamespace())
+				}
+
+				if object.GetKind() == "ServiceMonitor" && test.name == "service monitor" &&
+					object.GetLabels()["prometheus"] != "k8s" {
+					t.Errorf("expected the ServiceMonitor labels, got %v", object.GetLabels())
+				}
+			}
+
+			sort.Strings(kinds)
+
+			if len(kinds) != len(test.expectedKinds) || (len(kinds) > 0 && !equality.Semantic.DeepEqual(kinds, test.expectedKinds)) {
+				t.Errorf("expected the monitors %v, got %v", test.expectedKinds, kinds)
+			}
+		})
+	}
+}
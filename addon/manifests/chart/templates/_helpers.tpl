@@ -50,3 +50,17 @@ Create chart name and version as used by the chart label.
 {{- end -}}
 {{- end -}}
 {{- end -}}
+
+{{/*
+The agent image, with the registry of the first matching global.imageMirrors source replaced by its mirror.
+*/}}
+{{- define "application-manager.image" -}}
+{{- $image := .Values.global.imageOverrides.multicluster_operators_subscription -}}
+{{- range .Values.global.imageMirrors -}}
+{{- if hasPrefix .source $image -}}
+{{- $image = printf "%s%s" .mirror (trimPrefix .source $image) -}}
+{{- break -}}
+{{- end -}}
+{{- end -}}
+{{- $image -}}
+{{- end -}}
@@ -26,10 +26,10 @@ import (
 	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	managedClusterView "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/view/v1beta1"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/controller"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	k8swebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -43,7 +43,10 @@ var (
 
 // RunManager starts the actual manager.
 func RunManager() {
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	if err := logging.Setup(options.LogFormat); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
 
 	enableLeaderElection := false
 
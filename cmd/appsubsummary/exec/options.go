@@ -28,6 +28,7 @@ type AppSubStatusCMDOptions struct {
 	LeaderElectionLeaseDuration time.Duration
 	LeaderElectionRenewDeadline time.Duration
 	LeaderElectionRetryPeriod   time.Duration
+	LogFormat                   string
 }
 
 var options = AppSubStatusCMDOptions{
@@ -64,6 +65,13 @@ func ProcessFlags() {
 		"The kube config that points to a external api server.",
 	)
 
+	flag.StringVar(
+		&options.LogFormat,
+		"log-format",
+		options.LogFormat,
+		"The log format, text or json. With json, the controller logs are written as one JSON object per line.",
+	)
+
 	flag.DurationVar(
 		&options.LeaderElectionLeaseDuration,
 		"leader-election-lease-duration",
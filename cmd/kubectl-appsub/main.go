@@ -0,0 +1,36 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/klog"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/appsubcli"
+)
+
+func main() {
+	klog.InitFlags(nil)
+
+	cmd := appsubcli.NewCommand(genericiooptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	cmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -24,7 +24,6 @@ import (
 	"strings"
 	"time"
 
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
@@ -32,10 +31,11 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
 	addonV1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 
@@ -48,13 +48,24 @@ import (
 	ansiblejob "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/ansible/v1alpha1"
 	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/controller"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/controller/standalonetarget"
 	leasectrl "open-cluster-management.io/multicloud-operators-subscription/pkg/controller/subscription"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/health"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/profiling"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber"
+	ghsub "open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber/git"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer"
+	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/tracing"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/webhook"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/webhook/listener"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/webhook/mutating"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/webhook/validating"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	k8swebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	k8sconversion "sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 )
 
 // Change below variables to serve metrics on different host or port.
@@ -66,10 +77,68 @@ var (
 const (
 	AddonName               = "application-manager"
 	leaseUpdateJitterFactor = 0.25
+
+	// names of the hub components that can be disabled besides the hub controllers
+	addonManagerName    = "addon-manager"
+	webhookListenerName = "webhook-listener"
 )
 
 func RunManager() {
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	if err := logging.Setup(Options.LogFormat); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
+
+	if err := utils.SetKustomizeAllowedOptions(Options.KustomizeAllowedOptions); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
+
+	// the approvals are signed by the mutating webhook, the hub controller only trusts the signed approvals
+	if len(Options.ClusterAdminApproverGroups) > 0 && !Options.EnableMutatingWebhook {
+		klog.Error("--cluster-admin-approver-groups requires --enable-mutating-webhook")
+		os.Exit(1)
+	}
+
+	utils.SetClusterAdminApproverGroups(Options.ClusterAdminApproverGroups)
+
+	utils.SetFIPSMode(Options.FIPSMode)
+
+	if err := listener.SetTLSOptions(listener.TLSOptions{
+		CertProvider: Options.WebhookCertProvider,
+		CertIssuer:   Options.WebhookCertIssuer,
+		DNSNames:     Options.WebhookCertDNSNames,
+		MinVersion:   Options.WebhookTLSMinVersion,
+		CipherSuites: Options.WebhookTLSCipherSuites,
+	}); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
+
+	if err := listener.SetTriggerOptions(listener.TriggerOptions{
+		DebounceWindow: Options.WebhookDebounceWindow,
+		MinInterval:    Options.WebhookMinTriggerInterval,
+	}); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
+
+	if utils.IsFIPSMode() {
+		klog.Info("FIPS mode is enabled")
+	}
+
+	kubesynchronizer.SetStatusUpdateInterval(Options.StatusUpdateInterval)
+
+	ghsub.SetSyncOnStart(Options.SyncOnStart)
+
+	utils.SetMaxConcurrentReconciles(Options.MaxConcurrentReconciles)
+
+	kubesynchronizer.SetShutdownTimeout(Options.GracefulShutdownTimeout)
+
+	if err := utils.SetSubscriptionShard(Options.Shard, Options.Shards); err != nil {
+		klog.Error("Invalid subscription shard, error:", err)
+		os.Exit(1)
+	}
 
 	enableLeaderElection := false
 
@@ -83,15 +152,31 @@ func RunManager() {
 
 	// for hub subcription pod
 	leaderElectionID := "multicloud-operators-hub-subscription-leader.open-cluster-management.io"
+	tracingServiceName := "multicluster-operators-hub-subscription"
 
 	if Options.Standalone {
 		// for standalone subcription pod
 		leaderElectionID = "multicloud-operators-standalone-subscription-leader.open-cluster-management.io"
 		metricsPort = 8389
+		tracingServiceName = "multicluster-operators-standalone-subscription"
 	} else if !strings.EqualFold(Options.ClusterName, "") {
 		// for managed cluster pod appmgr. It could run on hub if hub is self-managed cluster
 		metricsPort = 8388
 		leaderElectionID = "multicloud-operators-remote-subscription-leader.open-cluster-management.io"
+		tracingServiceName = "application-manager"
+	} else if Options.Shard > 0 {
+		// each shard of the hub subscription pod elects its own leader
+		leaderElectionID = fmt.Sprintf("multicloud-operators-hub-subscription-shard-%d-leader.open-cluster-management.io", Options.Shard)
+	}
+
+	if Options.LeaderElectionID != "" {
+		leaderElectionID = Options.LeaderElectionID
+	}
+
+	disabledControllers, err := getDisabledHubControllers(Options.DisabledControllers)
+	if err != nil {
+		klog.Error("Invalid disabled controllers, error:", err)
+		os.Exit(1)
 	}
 
 	klog.Info("kubeconfig:" + Options.KubeConfig)
@@ -99,7 +184,9 @@ func RunManager() {
 	// increase the dafault QPS(5) to 100, only sends 5 requests to API server
 	// seems to be unrealistic. Reading some other projects, it seems QPS 100 is
 	// a pretty common practice
-	var err error
+	if Options.HostedMode && !Options.Standalone && Options.ClusterName != "" {
+		reportHostedKubeConfig()
+	}
 
 	cfg := ctrl.GetConfigOrDie()
 
@@ -120,12 +207,20 @@ func RunManager() {
 		"renewDeadline", Options.LeaderElectionRenewDeadline,
 		"retryPeriod", Options.LeaderElectionRetryPeriod)
 
-	webhookOption := k8swebhook.Options{}
+	webhookOption := k8swebhook.Options{CertDir: Options.WebhookCertDir}
 	webhookOption.TLSOpts = append(webhookOption.TLSOpts, func(config *tls.Config) {
 		config.MinVersion = appsubv1.TLSMinVersionInt
 	})
 	webhookServer := k8swebhook.NewServer(webhookOption)
 
+	// the managed cluster agent only caches the objects it owns, see utils.GetAgentCacheOptions
+	isAgent := !Options.Standalone && !strings.EqualFold(Options.ClusterName, "")
+
+	cacheOptions := cache.Options{}
+	if isAgent {
+		cacheOptions = utils.GetAgentCacheOptions()
+	}
+
 	// Create a new Cmd to provide shared dependencies and start components
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Metrics: metricsserver.Options{
@@ -133,14 +228,16 @@ func RunManager() {
 		},
 		LeaderElection:          enableLeaderElection,
 		LeaderElectionID:        leaderElectionID,
-		LeaderElectionNamespace: "kube-system",
+		LeaderElectionNamespace: Options.LeaderElectionNamespace,
 		LeaseDuration:           &Options.LeaderElectionLeaseDuration,
 		RenewDeadline:           &Options.LeaderElectionRenewDeadline,
 		RetryPeriod:             &Options.LeaderElectionRetryPeriod,
 		WebhookServer:           webhookServer,
+		GracefulShutdownTimeout: &Options.GracefulShutdownTimeout,
+		Cache:                   cacheOptions,
 		Client: client.Options{
 			Cache: &client.CacheOptions{
-				DisableFor: []client.Object{&corev1.Secret{}, &corev1.ServiceAccount{}},
+				DisableFor: utils.GetCacheDisabledObjects(isAgent),
 			},
 		},
 	})
@@ -202,12 +299,59 @@ func RunManager() {
 		}
 
 		// Setup all Hub Controllers
-		if err := controller.AddHubToManager(mgr); err != nil {
+		if err := controller.AddHubToManager(mgr, disabledControllers); err != nil {
 			klog.Error(err, "")
 			os.Exit(1)
 		}
 
-		if !Options.Debug {
+		// the key the webhook signs the user identity of the subscriptions with, the controllers only trust the
+		// signed identities
+		identityClient, err := client.New(cfg, client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			klog.Error(err, "")
+			os.Exit(1)
+		}
+
+		identityKey, err := utils.LoadUserIdentityKey(context.TODO(), identityClient, utils.GetComponentNamespace())
+		if err != nil {
+			klog.Error("Failed to load the user identity key with error:", err)
+			os.Exit(1)
+		}
+
+		utils.SetUserIdentityKey(identityKey)
+
+		if Options.EnableMutatingWebhook {
+			// Setup the subscription mutating webhook
+			if err := mutating.Add(mgr); err != nil {
+				klog.Error("Failed to register the subscription mutating webhook with error:", err)
+				os.Exit(1)
+			}
+
+			// the approval annotations checked by the webhook can't be trusted if the subscriptions are admitted
+			// while the webhook is unavailable
+			if utils.IsClusterAdminApprovalRequired() {
+				if err := mutating.AddFailClosed(mgr); err != nil {
+					klog.Error("Failed to set the subscription mutating webhook fail closed with error:", err)
+					os.Exit(1)
+				}
+			}
+		}
+
+		if Options.EnableValidatingWebhook {
+			// Setup the subscription validating webhook, its configuration is fail closed
+			if err := validating.Add(mgr); err != nil {
+				klog.Error("Failed to register the subscription validating webhook with error:", err)
+				os.Exit(1)
+			}
+		}
+
+		if Options.EnableConversionWebhook {
+			// Setup the conversion webhook between the v1 and v1beta1 subscription API
+			klog.Info("registering subscription conversion webhook on path: /convert")
+			mgr.GetWebhookServer().Register("/convert", k8sconversion.NewWebhookHandler(mgr.GetScheme()))
+		}
+
+		if !Options.Debug && !disabledControllers[webhookListenerName] {
 			// Setup Webhook listener
 			if err := webhook.AddToManager(mgr, hubconfig, Options.TLSKeyFilePathName, Options.TLSCrtFilePathName, Options.DisableTLS, true); err != nil {
 				klog.Error("Failed to initialize WebHook listener with error:", err)
@@ -264,14 +408,20 @@ func RunManager() {
 		go wait.JitterUntilWithContext(context.TODO(), leaseReconciler.Reconcile,
 			time.Duration(Options.LeaseDurationSeconds)*time.Second, leaseUpdateJitterFactor, true)
 
-		// add liveness probe server
-		cc, err := addonutils.NewConfigChecker("managed-serviceaccount-agent", "/var/run/klusterlet/kubeconfig")
+		// add liveness probe server, the agent restarts when the hub kubeconfig, or the managed cluster kubeconfig in
+		// Hosted mode, is rotated
+		configFiles := []string{"/var/run/klusterlet/kubeconfig"}
+		if Options.HostedMode {
+			configFiles = append(configFiles, Options.KubeConfig)
+		}
+
+		cc, err := addonutils.NewConfigChecker("managed-serviceaccount-agent", configFiles...)
 		if err != nil {
 			klog.Fatalf("unable to create config checker for application-manager addon, err: %v", err)
 		}
 
 		go func() {
-			if err = serveHealthProbes(":8000", cc.Check); err != nil {
+			if err = serveHealthProbes(":8000", cc.Check, cacheSyncChecker(mgr)); err != nil {
 				klog.Fatal(err)
 			}
 		}()
@@ -280,8 +430,22 @@ func RunManager() {
 		os.Exit(1)
 	}
 
+	if Options.ProfilingBindAddress != "" {
+		go func() {
+			if err := serveProfiling(Options.ProfilingBindAddress); err != nil {
+				klog.Error("Failed to serve the profiling endpoint, error: ", err)
+			}
+		}()
+	}
+
 	sig := signals.SetupSignalHandler()
 
+	shutdownTracer, err := tracing.InitTracer(sig, tracingServiceName, Options.TracingEndpoint, Options.TracingInsecure)
+	if err != nil {
+		klog.Error("Failed to setup tracing, error:", err)
+		os.Exit(1)
+	}
+
 	// Only detect if the placementDecsion API is ready on the hub cluster
 	if !Options.Standalone && Options.ClusterName == "" {
 		klog.Info("Detecting ACM Placement Decision API on the hub...")
@@ -307,8 +471,8 @@ func RunManager() {
 
 	klog.Info("Starting the Cmd.")
 
-	// Start addon manager
-	if !Options.Standalone && Options.ClusterName == "" {
+	// Start addon manager, only by the first shard of the hub subscription pod
+	if !Options.Standalone && Options.ClusterName == "" && Options.Shard == 0 && !disabledControllers[addonManagerName] {
 		klog.Info("Starting addon manager")
 
 		agentImage, err := agentaddon.GetMchImage(cfg)
@@ -344,13 +508,35 @@ func RunManager() {
 	}
 
 	// Start the Cmd
-	if err := mgr.Start(sig); err != nil {
+	err = mgr.Start(sig)
+
+	if shutdownErr := shutdownTracer(context.Background()); shutdownErr != nil {
+		klog.Error("Failed to flush traces, error:", shutdownErr)
+	}
+
+	if err != nil {
 		klog.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
 }
 
 func setupStandalone(mgr manager.Manager, hubconfig *rest.Config, id *types.NamespacedName, standalone bool) error {
+	// the key the subscriber signs the secret provider annotations of the HelmReleases with, the HelmRelease
+	// controller only resolves the signed annotations
+	keyClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		return err
+	}
+
+	secretProviderKey, err := utils.LoadSecretProviderKey(context.TODO(), keyClient, utils.GetComponentNamespace())
+	if err != nil {
+		klog.Error("Failed to load the secret provider key with error:", err)
+
+		return err
+	}
+
+	utils.SetSecretProviderKey(secretProviderKey)
+
 	// Setup Synchronizer
 	isHub := utils.IsHub(mgr.GetConfig())
 	if err := synchronizer.AddToManager(mgr, hubconfig, id, Options.SyncInterval, isHub, standalone); err != nil {
@@ -373,6 +559,15 @@ func setupStandalone(mgr manager.Manager, hubconfig *rest.Config, id *types.Name
 		return err
 	}
 
+	// Setup the controllers of the remote clusters managed by the standalone subscription pod
+	if standalone && Options.StandaloneTargets {
+		if err := standalonetarget.Add(mgr, id, Options.SyncInterval); err != nil {
+			klog.Error("Failed to initialize the standalone targets with error:", err)
+
+			return err
+		}
+	}
+
 	if standalone && !Options.Debug {
 		// Setup Webhook listener
 		if err := webhook.AddToManager(mgr, hubconfig, Options.TLSKeyFilePathName, Options.TLSCrtFilePathName, Options.DisableTLS, false); err != nil {
@@ -385,12 +580,21 @@ func setupStandalone(mgr manager.Manager, hubconfig *rest.Config, id *types.Name
 	return nil
 }
 
-// serveHealthProbes serves health probes and configchecker.
-func serveHealthProbes(healthProbeBindAddress string, configCheck healthz.Checker) error {
+// serveHealthProbes serves health probes and configchecker. The liveness probe fails if a subscriber goroutine
+// or the synchronizer is stalled, the readiness probe also waits for the manager cache to be synced.
+func serveHealthProbes(healthProbeBindAddress string, configCheck, cacheSyncCheck healthz.Checker) error {
+	stallCheck := health.Checker(Options.HealthProbeStallTimeout)
+
 	mux := http.NewServeMux()
 	mux.Handle("/healthz", http.StripPrefix("/healthz", &healthz.Handler{Checks: map[string]healthz.Checker{
-		"healthz-ping": healthz.Ping,
-		"configz-ping": configCheck,
+		"healthz-ping":        healthz.Ping,
+		"configz-ping":        configCheck,
+		"subscriber-watchdog": stallCheck,
+	}}))
+	mux.Handle("/readyz", http.StripPrefix("/readyz", &healthz.Handler{Checks: map[string]healthz.Checker{
+		"healthz-ping":        healthz.Ping,
+		"cache-sync":          cacheSyncCheck,
+		"subscriber-watchdog": stallCheck,
 	}}))
 
 	server := http.Server{
@@ -406,3 +610,78 @@ func serveHealthProbes(healthProbeBindAddress string, configCheck healthz.Checke
 
 	return server.ListenAndServe()
 }
+
+// serveProfiling serves the Go pprof profiles and the reconcile profiles of the subscriptions
+func serveProfiling(profilingBindAddress string) error {
+	server := http.Server{
+		Handler:           profiling.NewServeMux(),
+		ReadHeaderTimeout: 5 * time.Second,
+		Addr:              profilingBindAddress,
+	}
+
+	klog.Infof("profiling server is running on %v...", profilingBindAddress)
+
+	return server.ListenAndServe()
+}
+
+// reportHostedKubeConfig sets the HostedKubeconfigValid condition of the agent ManagedClusterAddOn on the hub, the
+// agent exits if the managed cluster kubeconfig is invalid
+func reportHostedKubeConfig() {
+	kubeconfigErr := utils.CheckKubeConfig(Options.KubeConfig)
+
+	hubconfig, err := clientcmd.BuildConfigFromFlags("", Options.HubConfigFilePathName)
+	if err != nil {
+		klog.Error("Failed to build config to hub cluster with the pathname provided ", Options.HubConfigFilePathName, " err:", err)
+		os.Exit(1)
+	}
+
+	addonClient, err := addonv1alpha1client.NewForConfig(hubconfig)
+	if err != nil {
+		klog.Error("Failed to create hub cluster addon client.", err)
+		os.Exit(1)
+	}
+
+	if err := utils.SetHostedKubeConfigCondition(context.TODO(), addonClient, Options.ClusterName, AddonName, kubeconfigErr); err != nil {
+		klog.Error("Failed to set the hosted kubeconfig condition of the ManagedClusterAddOn, error:", err)
+	}
+
+	if kubeconfigErr != nil {
+		klog.Error("Invalid managed cluster kubeconfig in Hosted mode, error:", kubeconfigErr)
+		os.Exit(1)
+	}
+}
+
+// cacheSyncChecker returns a checker that fails until the manager cache is synced
+func cacheSyncChecker(mgr manager.Manager) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), time.Second)
+		defer cancel()
+
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return fmt.Errorf("the manager cache is not synced")
+		}
+
+		return nil
+	}
+}
+
+// getDisabledHubControllers returns the set of the disabled hub controllers, it fails if a controller is unknown
+func getDisabledHubControllers(names []string) (map[string]bool, error) {
+	known := map[string]bool{addonManagerName: true, webhookListenerName: true}
+	for _, name := range controller.HubControllerNames() {
+		known[name] = true
+	}
+
+	disabled := map[string]bool{}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown hub controller %q", name)
+		}
+
+		disabled[name] = true
+	}
+
+	return disabled, nil
+}
@@ -18,6 +18,8 @@ import (
 	"time"
 
 	pflag "github.com/spf13/pflag"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/features"
 )
 
 // SubscriptionCMDOptions for command line flag parsing
@@ -28,6 +30,13 @@ type SubscriptionCMDOptions struct {
 	HubConfigFilePathName       string
 	TLSKeyFilePathName          string
 	TLSCrtFilePathName          string
+	WebhookCertProvider         string
+	WebhookCertIssuer           string
+	WebhookCertDNSNames         []string
+	WebhookTLSMinVersion        string
+	WebhookTLSCipherSuites      []string
+	WebhookDebounceWindow       time.Duration
+	WebhookMinTriggerInterval   time.Duration
 	SyncInterval                int
 	DisableTLS                  bool
 	Standalone                  bool
@@ -36,7 +45,30 @@ type SubscriptionCMDOptions struct {
 	LeaderElectionLeaseDuration time.Duration
 	LeaderElectionRenewDeadline time.Duration
 	LeaderElectionRetryPeriod   time.Duration
+	LeaderElectionID            string
+	LeaderElectionNamespace     string
+	DisabledControllers         []string
 	Debug                       bool
+	EnableMutatingWebhook       bool
+	EnableValidatingWebhook     bool
+	ClusterAdminApproverGroups  []string
+	EnableConversionWebhook     bool
+	WebhookCertDir              string
+	TracingEndpoint             string
+	TracingInsecure             bool
+	LogFormat                   string
+	HealthProbeStallTimeout     time.Duration
+	KustomizeAllowedOptions     []string
+	Shards                      int
+	Shard                       int
+	StatusUpdateInterval        time.Duration
+	StandaloneTargets           bool
+	HostedMode                  bool
+	FIPSMode                    bool
+	SyncOnStart                 bool
+	MaxConcurrentReconciles     int
+	GracefulShutdownTimeout     time.Duration
+	ProfilingBindAddress        string
 }
 
 var Options = SubscriptionCMDOptions{
@@ -47,9 +79,19 @@ var Options = SubscriptionCMDOptions{
 	LeaderElectionLeaseDuration: 137 * time.Second,
 	LeaderElectionRenewDeadline: 107 * time.Second,
 	LeaderElectionRetryPeriod:   26 * time.Second,
+	LeaderElectionNamespace:     "kube-system",
 	Standalone:                  false,
 	AgentImage:                  "quay.io/open-cluster-management/multicloud-operators-subscription:latest",
 	Debug:                       false,
+	HealthProbeStallTimeout:     20 * time.Minute,
+	KustomizeAllowedOptions:     []string{"helm", "load-restrictions-none"},
+	Shards:                      1,
+	Shard:                       0,
+	SyncOnStart:                 true,
+	GracefulShutdownTimeout:     25 * time.Second,
+	WebhookTLSMinVersion:        "1.2",
+	WebhookDebounceWindow:       10 * time.Second,
+	WebhookMinTriggerInterval:   time.Minute,
 }
 
 // ProcessFlags parses command line parameters into Options
@@ -126,6 +168,29 @@ func ProcessFlags() {
 			"of a leadership. This is only applicable if leader election is enabled.",
 	)
 
+	flag.StringVar(
+		&Options.LeaderElectionID,
+		"leader-election-id",
+		Options.LeaderElectionID,
+		"The name of the leader election lease. By default, it is derived from the mode and the shard of the controller. "+
+			"The hub deployments running different controllers must use different leases.",
+	)
+
+	flag.StringVar(
+		&Options.LeaderElectionNamespace,
+		"leader-election-namespace",
+		Options.LeaderElectionNamespace,
+		"The namespace of the leader election lease.",
+	)
+
+	flag.StringSliceVar(
+		&Options.DisabledControllers,
+		"disabled-controllers",
+		Options.DisabledControllers,
+		"The hub controllers that are not started: subscription, subscriptionset, janitor, addon-manager and "+
+			"webhook-listener. Each hub deployment of a split deployment runs the controllers the others disable.",
+	)
+
 	flag.BoolVar(
 		&Options.Standalone,
 		"standalone",
@@ -154,6 +219,60 @@ func ProcessFlags() {
 		"WebHook event listener TLS cert file path.",
 	)
 
+	flag.StringVar(
+		&Options.WebhookCertProvider,
+		"webhook-cert-provider",
+		Options.WebhookCertProvider,
+		"Provisions the WebHook event listener certificate in the secret of the listener service instead of the "+
+			"TLS key and cert files: cert-manager or openshift-service-ca. The certificate is reloaded when it is renewed.",
+	)
+
+	flag.StringVar(
+		&Options.WebhookCertIssuer,
+		"webhook-cert-issuer",
+		Options.WebhookCertIssuer,
+		"The cert-manager issuer of the WebHook event listener certificate, ClusterIssuer/<name> or Issuer/<name>.",
+	)
+
+	flag.StringSliceVar(
+		&Options.WebhookCertDNSNames,
+		"webhook-cert-dns-names",
+		Options.WebhookCertDNSNames,
+		"The DNS names the WebHook event listener certificate must be valid for, like the host of the listener route. "+
+			"A certificate that is not valid for them is rejected.",
+	)
+
+	flag.StringVar(
+		&Options.WebhookTLSMinVersion,
+		"webhook-tls-min-version",
+		Options.WebhookTLSMinVersion,
+		"The minimum TLS version of the WebHook event listener, 1.2 or 1.3.",
+	)
+
+	flag.StringSliceVar(
+		&Options.WebhookTLSCipherSuites,
+		"webhook-tls-cipher-suites",
+		Options.WebhookTLSCipherSuites,
+		"The TLS 1.2 cipher suites of the WebHook event listener, like TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. The Go "+
+			"defaults are used if empty.",
+	)
+
+	flag.DurationVar(
+		&Options.WebhookDebounceWindow,
+		"webhook-debounce-window",
+		Options.WebhookDebounceWindow,
+		"The Git WebHook events of a subscription received within the window are coalesced into a single reconcile.",
+	)
+
+	flag.DurationVar(
+		&Options.WebhookMinTriggerInterval,
+		"webhook-min-trigger-interval",
+		Options.WebhookMinTriggerInterval,
+		"The minimum interval between two reconciles of a subscription triggered by the Git WebHook events. The "+
+			"events received meanwhile are coalesced into a reconcile once the interval has elapsed. The events "+
+			"trigger the reconciles immediately if both this and --webhook-debounce-window are 0.",
+	)
+
 	flag.BoolVar(
 		&Options.Debug,
 		"debug",
@@ -161,6 +280,168 @@ func ProcessFlags() {
 		"if debug is true, hub github webhook listener will be disabled",
 	)
 
+	flag.BoolVar(
+		&Options.EnableMutatingWebhook,
+		"enable-mutating-webhook",
+		Options.EnableMutatingWebhook,
+		"Enable the hub mutating webhook that normalizes subscription annotations.",
+	)
+
+	flag.BoolVar(
+		&Options.EnableValidatingWebhook,
+		"enable-validating-webhook",
+		Options.EnableValidatingWebhook,
+		"Enable the hub validating webhook that denies the invalid subscriptions, the subscriptions whose channel is "+
+			"not allowed, the approvals of the users who are not approvers and the subscriptions beyond the quota.",
+	)
+
+	flag.StringSliceVar(
+		&Options.ClusterAdminApproverGroups,
+		"cluster-admin-approver-groups",
+		Options.ClusterAdminApproverGroups,
+		"The user groups permitted to approve the cluster admin access of the subscriptions created by subscription "+
+			"admins. If set, the access is granted once a user of these groups sets the cluster-admin-approved-by "+
+			"annotation. It requires the hub mutating webhook.",
+	)
+
+	flag.BoolVar(
+		&Options.FIPSMode,
+		"fips-mode",
+		Options.FIPSMode,
+		"Reject the credentials and algorithms disallowed in FIPS mode, like the ed25519 SSH keys and the SHA-1 SSH host "+
+			"key and webhook signatures. It is always enabled in the binaries built with the fips build tag.",
+	)
+
+	flag.BoolVar(
+		&Options.EnableConversionWebhook,
+		"enable-conversion-webhook",
+		Options.EnableConversionWebhook,
+		"Enable the hub conversion webhook that serves the v1beta1 subscription API.",
+	)
+
+	flag.StringVar(
+		&Options.WebhookCertDir,
+		"webhook-cert-dir",
+		Options.WebhookCertDir,
+		"The directory that contains the mutating webhook server key and certificate.",
+	)
+
+	flag.StringVar(
+		&Options.LogFormat,
+		"log-format",
+		Options.LogFormat,
+		"The log format, text or json. With json, the controller logs are written as one JSON object per line.",
+	)
+
+	flag.StringVar(
+		&Options.TracingEndpoint,
+		"tracing-endpoint",
+		Options.TracingEndpoint,
+		"The OTLP gRPC endpoint (host:port) the OpenTelemetry traces are exported to. Tracing is disabled if it is empty.",
+	)
+
+	flag.BoolVar(
+		&Options.TracingInsecure,
+		"tracing-insecure",
+		Options.TracingInsecure,
+		"Export the OpenTelemetry traces without TLS.",
+	)
+
+	flag.StringVar(
+		&Options.ProfilingBindAddress,
+		"profiling-bind-address",
+		Options.ProfilingBindAddress,
+		"The address, like localhost:6060, the Go pprof profiles and the phase durations of the last reconcile of each "+
+			"subscription are served on, under /debug/pprof/ and /debug/subscriptions. Profiling is disabled if it is empty.",
+	)
+
+	flag.DurationVar(
+		&Options.HealthProbeStallTimeout,
+		"health-probe-stall-timeout",
+		Options.HealthProbeStallTimeout,
+		"The health probe fails if a subscriber or the synchronizer is processing a subscription for longer than this "+
+			"duration, for example a blocked Git clone, so the agent is restarted. Set to 0 to disable the check.",
+	)
+
+	flag.StringSliceVar(
+		&Options.KustomizeAllowedOptions,
+		"kustomize-allowed-options",
+		Options.KustomizeAllowedOptions,
+		"The kustomize build options the subscriptions are permitted to enable with annotations: helm and "+
+			"load-restrictions-none. Without load-restrictions-none, the kustomizations can't load files "+
+			"outside of their root directory. The exec KRM functions are not supported.",
+	)
+
+	flag.IntVar(
+		&Options.Shards,
+		"shards",
+		Options.Shards,
+		"The number of shards of the hub subscription controller. Each shard reconciles the subscriptions of the "+
+			"namespaces hashed to it, or labeled with its shard index, and elects its own leader. The other hub "+
+			"controllers are only run by the first shard.",
+	)
+
+	flag.IntVar(
+		&Options.Shard,
+		"shard",
+		Options.Shard,
+		"The index of the shard reconciled by this hub subscription controller, from 0 to shards - 1.",
+	)
+
+	flag.DurationVar(
+		&Options.StatusUpdateInterval,
+		"status-update-interval",
+		Options.StatusUpdateInterval,
+		"The minimum interval between two updates of the cluster SubscriptionReport on the hub by the managed cluster "+
+			"agent. The subscription results are coalesced and written in a single update per interval, with jitter. "+
+			"The results are written right away if it is 0.",
+	)
+
+	flag.BoolVar(
+		&Options.StandaloneTargets,
+		"standalone-targets",
+		Options.StandaloneTargets,
+		"Also manage the remote clusters defined by the kubeconfig secrets labeled with "+
+			"apps.open-cluster-management.io/standalone-target in the pod namespace. Only used with --standalone.",
+	)
+
+	flag.BoolVar(
+		&Options.HostedMode,
+		"hosted-mode",
+		Options.HostedMode,
+		"The managed cluster agent runs in Hosted mode, outside of the managed cluster it reaches with --kubeconfig. "+
+			"The validity of the kubeconfig is reported by the HostedKubeconfigValid condition of the agent "+
+			"ManagedClusterAddOn, and the agent restarts when the kubeconfig is rotated.",
+	)
+
+	flag.BoolVar(
+		&Options.SyncOnStart,
+		"sync-on-start",
+		Options.SyncOnStart,
+		"Apply the resources of the Git subscriptions that are not reconciled periodically, with the channel webhook "+
+			"enabled or the reconcile rate off, again when the managed cluster agent starts, so the drift while the "+
+			"agent was down is repaired.",
+	)
+
+	flag.IntVar(
+		&Options.MaxConcurrentReconciles,
+		"max-concurrent-reconciles",
+		Options.MaxConcurrentReconciles,
+		"The maximum number of subscriptions reconciled at the same time by the managed cluster agent, the other "+
+			"reconciles wait in a queue. The reconciles are not limited if it is 0.",
+	)
+
+	flag.DurationVar(
+		&Options.GracefulShutdownTimeout,
+		"graceful-shutdown-timeout",
+		Options.GracefulShutdownTimeout,
+		"The maximum time the controllers take to stop on termination. The managed cluster agent lets the in-flight "+
+			"subscription applies complete and writes the pending status updates within it. It must be shorter than "+
+			"the terminationGracePeriodSeconds of the pod.",
+	)
+
+	features.DefaultMutableFeatureGate.AddFlag(flag)
+
 	flag.BoolVar(
 		&Options.DisableTLS,
 		"disable-tls",
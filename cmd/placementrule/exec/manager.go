@@ -22,7 +22,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
 	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/placementrule/controller"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/placementrule/controller/placementrule"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/placementrule/utils"
 	appsubutils "open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 
@@ -30,7 +32,6 @@ import (
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	k8swebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -44,7 +45,20 @@ var (
 
 // RunManager starts the actual manager
 func RunManager() {
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	if err := logging.Setup(options.LogFormat); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
+
+	if err := placementrule.SetDecisionWebhook(placementrule.DecisionWebhookConfig{
+		URL:           options.DecisionWebhookURL,
+		CAFile:        options.DecisionWebhookCAFile,
+		Timeout:       options.DecisionWebhookTimeout,
+		FailurePolicy: options.DecisionWebhookFailure,
+	}); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
 
 	enableLeaderElection := false
 
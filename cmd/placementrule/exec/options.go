@@ -27,6 +27,11 @@ type PlacementRuleCMDOptions struct {
 	LeaderElectionLeaseDuration time.Duration
 	LeaderElectionRenewDeadline time.Duration
 	LeaderElectionRetryPeriod   time.Duration
+	LogFormat                   string
+	DecisionWebhookURL          string
+	DecisionWebhookCAFile       string
+	DecisionWebhookTimeout      time.Duration
+	DecisionWebhookFailure      string
 }
 
 var options = PlacementRuleCMDOptions{
@@ -35,6 +40,8 @@ var options = PlacementRuleCMDOptions{
 	LeaderElectionLeaseDuration: 137 * time.Second,
 	LeaderElectionRenewDeadline: 107 * time.Second,
 	LeaderElectionRetryPeriod:   26 * time.Second,
+	DecisionWebhookTimeout:      10 * time.Second,
+	DecisionWebhookFailure:      "Fail",
 }
 
 // ProcessFlags parses command line parameters into options
@@ -55,6 +62,13 @@ func ProcessFlags() {
 		"The kube config that points to a external api server.",
 	)
 
+	flag.StringVar(
+		&options.LogFormat,
+		"log-format",
+		options.LogFormat,
+		"The log format, text or json. With json, the controller logs are written as one JSON object per line.",
+	)
+
 	flag.DurationVar(
 		&options.LeaderElectionLeaseDuration,
 		"leader-election-lease-duration",
@@ -82,4 +96,34 @@ func ProcessFlags() {
 		"The duration the clients should wait between attempting acquisition and renewal "+
 			"of a leadership. This is only applicable if leader election is enabled.",
 	)
+
+	flag.StringVar(
+		&options.DecisionWebhookURL,
+		"decision-webhook-url",
+		options.DecisionWebhookURL,
+		"The URL of the webhook called to veto or reorder the candidate clusters of the placementrules before their "+
+			"decisions are made. The webhook is disabled if it is empty.",
+	)
+
+	flag.StringVar(
+		&options.DecisionWebhookCAFile,
+		"decision-webhook-ca-file",
+		options.DecisionWebhookCAFile,
+		"The PEM file of the CA certificates of the decision webhook server. The system CAs are used if it is empty.",
+	)
+
+	flag.DurationVar(
+		&options.DecisionWebhookTimeout,
+		"decision-webhook-timeout",
+		options.DecisionWebhookTimeout,
+		"The timeout of the decision webhook calls.",
+	)
+
+	flag.StringVar(
+		&options.DecisionWebhookFailure,
+		"decision-webhook-failure-policy",
+		options.DecisionWebhookFailure,
+		"The policy when the decision webhook fails, Fail to keep the previous decisions of the placementrule or Ignore "+
+			"to make its decisions without the webhook.",
+	)
 }
@@ -18,6 +18,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog"
 
+	addonV1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	placement "open-cluster-management.io/api/cluster/v1beta1"
 	workV1 "open-cluster-management.io/api/work/v1"
@@ -41,6 +42,11 @@ func AddToScheme(s *runtime.Scheme) error {
 		return err
 	}
 
+	err = addonV1alpha1.AddToScheme(s)
+	if err != nil {
+		return err
+	}
+
 	err = workV1.AddToScheme(s)
 	if err != nil {
 		return err
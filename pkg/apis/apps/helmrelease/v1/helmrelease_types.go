@@ -135,6 +135,8 @@ func (repo HelmReleaseRepo) Clone() HelmReleaseRepo {
 		InsecureSkipVerify:            repo.InsecureSkipVerify,
 		Source:                        repo.Source,
 		WatchNamespaceScopedResources: repo.WatchNamespaceScopedResources,
+		Timeout:                       repo.Timeout,
+		Atomic:                        repo.Atomic,
 	}
 }
 
@@ -144,6 +146,8 @@ func (repo HelmReleaseRepo) AltSourceToSource() HelmReleaseRepo {
 		Version:                       repo.Version,
 		Digest:                        repo.Digest,
 		WatchNamespaceScopedResources: repo.WatchNamespaceScopedResources,
+		Timeout:                       repo.Timeout,
+		Atomic:                        repo.Atomic,
 		AltSource:                     repo.AltSource,
 		SecretRef:                     repo.AltSource.SecretRef,
 		ConfigMapRef:                  repo.AltSource.ConfigMapRef,
@@ -178,6 +182,12 @@ type HelmReleaseRepo struct {
 	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
 	// WatchNamespaceScopedResources is used to enable watching namespace scope Helm chart resources
 	WatchNamespaceScopedResources bool `json:"watchNamespaceScopedResources,omitempty"`
+	// Timeout is the time to wait for the install, upgrade and rollback of the release, the release resources are
+	// waited for until they are ready when it is set
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Atomic uninstalls the failed installs and rolls back the failed upgrades, the release resources are waited for
+	// until they are ready
+	Atomic bool `json:"atomic,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -186,6 +196,10 @@ type HelmReleaseRepo struct {
 // +k8s:openapi-gen=true
 // +kubebuilder:resource:scope=Namespaced
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Chart",type="string",JSONPath=".repo.chartName"
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".repo.version"
+// +kubebuilder:printcolumn:name="Deployed",type="string",JSONPath=".status.conditions[?(@.type==\"Deployed\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type HelmRelease struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
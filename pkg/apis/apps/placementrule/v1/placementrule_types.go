@@ -110,6 +110,10 @@ type PlacementRuleSpec struct {
 	// +optional
 	// Set Policy Filters
 	Policies []corev1.ObjectReference `json:"policies,omitempty"`
+	// +optional
+	// Names of the ManagedClusterAddOns, like application-manager, that must be Available on a cluster for it to be
+	// selected
+	RequiredAddOns []string `json:"requiredAddOns,omitempty"`
 }
 
 // PlacementDecision defines the decision made by controller
@@ -118,11 +122,61 @@ type PlacementDecision struct {
 	ClusterNamespace string `json:"clusterNamespace,omitempty"`
 }
 
+// DecisionChangeType is the type of a change of the placementrule decisions
+type DecisionChangeType string
+
+const (
+	// DecisionChangeAdded means the cluster is added to the decisions
+	DecisionChangeAdded DecisionChangeType = "Added"
+	// DecisionChangeRemoved means the cluster is removed from the decisions
+	DecisionChangeRemoved DecisionChangeType = "Removed"
+)
+
+// Reasons of the changes of the placementrule decisions
+const (
+	// DecisionReasonClusterSelected means the cluster matches the placementrule
+	DecisionReasonClusterSelected = "ClusterSelected"
+	// DecisionReasonClusterNotSelected means the cluster is deleted or no longer matches the clusters, the cluster
+	// selector or the cluster labels of the placementrule
+	DecisionReasonClusterNotSelected = "ClusterNotSelected"
+	// DecisionReasonClusterConditions means the cluster no longer matches the cluster conditions
+	DecisionReasonClusterConditions = "ClusterConditions"
+	// DecisionReasonUserPermission means the user of the placementrule is no longer allowed to deploy to the cluster
+	DecisionReasonUserPermission = "UserPermission"
+	// DecisionReasonRequiredAddOns means one of the required addons is no longer Available on the cluster
+	DecisionReasonRequiredAddOns = "RequiredAddOns"
+	// DecisionReasonPolicies means the cluster no longer matches the policies
+	DecisionReasonPolicies = "Policies"
+	// DecisionReasonDecisionWebhook means the cluster is vetoed by the decision webhook
+	DecisionReasonDecisionWebhook = "DecisionWebhook"
+	// DecisionReasonClusterReplicas means the cluster is beyond the cluster replicas
+	DecisionReasonClusterReplicas = "ClusterReplicas"
+
+	// MaxDecisionHistory is the number of decision changes kept in the placementrule status
+	MaxDecisionHistory = 20
+)
+
+// DecisionChange is a cluster added to or removed from the placementrule decisions
+type DecisionChange struct {
+	ClusterName string             `json:"clusterName"`
+	Type        DecisionChangeType `json:"type"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// the time of the change
+	Time metav1.Time `json:"time"`
+}
+
 // PlacementRuleStatus defines the observed state of PlacementRule
 type PlacementRuleStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 	Decisions []PlacementDecision `json:"decisions,omitempty"`
+	// +optional
+	// the generation of the placementrule spec observed by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	// the last changes of the decisions, the oldest first
+	DecisionHistory []DecisionChange `json:"decisionHistory,omitempty"`
 }
 
 // +genclient
@@ -133,6 +187,7 @@ type PlacementRuleStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".spec.clusterReplicas"
+// +kubebuilder:printcolumn:name="Decisions",type="string",JSONPath=".status.decisions[*].clusterName",priority=1
 type PlacementRule struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
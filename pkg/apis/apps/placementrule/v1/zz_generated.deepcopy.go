@@ -40,6 +40,23 @@ func (in *ClusterConditionFilter) DeepCopy() *ClusterConditionFilter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecisionChange) DeepCopyInto(out *DecisionChange) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecisionChange.
+func (in *DecisionChange) DeepCopy() *DecisionChange {
+	if in == nil {
+		return nil
+	}
+	out := new(DecisionChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GenericClusterReference) DeepCopyInto(out *GenericClusterReference) {
 	*out = *in
@@ -210,6 +227,11 @@ func (in *PlacementRuleSpec) DeepCopyInto(out *PlacementRuleSpec) {
 		*out = make([]corev1.ObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.RequiredAddOns != nil {
+		in, out := &in.RequiredAddOns, &out.RequiredAddOns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -231,6 +253,13 @@ func (in *PlacementRuleStatus) DeepCopyInto(out *PlacementRuleStatus) {
 		*out = make([]PlacementDecision, len(*in))
 		copy(*out, *in)
 	}
+	if in.DecisionHistory != nil {
+		in, out := &in.DecisionHistory, &out.DecisionHistory
+		*out = make([]DecisionChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -0,0 +1,59 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeploymentWindowSpec defines the time window shared by the subscriptions referencing the deployment window
+type DeploymentWindowSpec struct {
+	// The time window of the subscriptions referencing the deployment window
+	TimeWindow TimeWindow `json:"timewindow"`
+
+	// If true, the deployment window takes precedence over the timewindow of the subscriptions referencing it.
+	// Otherwise the deployment window only applies to the subscriptions without timewindow
+	// +optional
+	Enforced bool `json:"enforced,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+
+// DeploymentWindow is the Schema for the deploymentwindows API, a time window shared by subscriptions
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Time window",type="string",JSONPath=".spec.timewindow.windowtype"
+// +kubebuilder:printcolumn:name="Enforced",type="boolean",JSONPath=".spec.enforced"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:shortName=deploywindow
+type DeploymentWindow struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeploymentWindowSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DeploymentWindowList contains a list of DeploymentWindow
+type DeploymentWindowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeploymentWindow `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeploymentWindow{}, &DeploymentWindowList{})
+}
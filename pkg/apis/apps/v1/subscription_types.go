@@ -35,7 +35,8 @@ var (
 	AnnotationRollingUpdateMaxUnavailable = SchemeGroupVersion.Group + "/rollingupdate-maxunavaialble"
 	// AnnotationDeployables defines all deployables subscribed by the subscription
 	AnnotationDeployables = SchemeGroupVersion.Group + "/deployables"
-	// AnnotationTopo list all resources will create by the subscription
+	// AnnotationTopo list all resources will create by the subscription.
+	// It is kept for compatibility, the SubscriptionTopology of the subscription lists the deployed resources.
 	AnnotationTopo = SchemeGroupVersion.Group + "/topo"
 	// AnnotationHosting defines the subscription hosting the resource
 	AnnotationHosting = SchemeGroupVersion.Group + "/hosting-subscription"
@@ -47,6 +48,9 @@ var (
 	AnnotationWebhookEventCount = SchemeGroupVersion.Group + "/webhook-event-count"
 	// AnnotationWebhookSecret defines webhook secret
 	AnnotationWebhookSecret = SchemeGroupVersion.Group + "/webhook-secret"
+	// AnnotationWebhookPaths are the comma separated paths of the Git repo, in addition to the git-path, whose changes
+	// trigger the subscription on a webhook event, like the kustomize bases outside the git-path
+	AnnotationWebhookPaths = SchemeGroupVersion.Group + "/webhook-paths"
 	// AnnotationGithubPath defines webhook secret
 	AnnotationGithubPath = SchemeGroupVersion.Group + "/github-path"
 	// AnnotationGithubBranch defines webhook secret
@@ -59,37 +63,146 @@ var (
 	AnnotationGitBranch = SchemeGroupVersion.Group + "/git-branch"
 	// AnnotationGitCommit defines currently deployed Git repo commit ID
 	AnnotationGitCommit = SchemeGroupVersion.Group + "/git-current-commit"
+	// AnnotationCurrentRevision defines the revision (Git commit, chart version or object etag) being applied by the
+	// managed cluster subscriber. It is set in memory only and reported in the appsubstatus
+	AnnotationCurrentRevision = SchemeGroupVersion.Group + "/current-revision"
+	// AnnotationCurrentSource defines the URL of the Git repo, Helm repo or object bucket the revision being applied
+	// comes from. It is set in memory only and reported in the provenance of the appsubstatus
+	AnnotationCurrentSource = SchemeGroupVersion.Group + "/current-source"
 	// AnnotationGitCloneDepth defines Git repo clone depth to be able to check out previous commits
 	AnnotationGitCloneDepth = SchemeGroupVersion.Group + "/git-clone-depth"
+	// AnnotationGitPartialClone set to true clones the Git repo without its blobs, like git clone --filter=blob:none,
+	// and fetches the files under the git-path only
+	AnnotationGitPartialClone = SchemeGroupVersion.Group + "/git-partial-clone"
 	// AnnotationGitTargetCommit defines Git repo commit to be deployed
 	AnnotationGitTargetCommit = SchemeGroupVersion.Group + "/git-desired-commit"
 	// AnnotationGitTag defines Git repo revision tag
 	AnnotationGitTag = SchemeGroupVersion.Group + "/git-tag"
 	// AnnotationClusterAdmin indicates the subscription has cluster admin access
 	AnnotationClusterAdmin = SchemeGroupVersion.Group + "/cluster-admin"
+	// AnnotationClusterAdminApprovedBy is the user who approved the cluster admin access of the subscription. It is
+	// set by the subscription mutating webhook when a user of a cluster admin approver group adds it
+	AnnotationClusterAdminApprovedBy = SchemeGroupVersion.Group + "/cluster-admin-approved-by"
+	// AnnotationClusterAdminApprovedSpec is the hash of the subscription content approved by the cluster admin
+	// approver. It is set by the subscription mutating webhook along with the cluster-admin-approved-by annotation
+	AnnotationClusterAdminApprovedSpec = SchemeGroupVersion.Group + "/cluster-admin-approved-spec"
 	// AnnotationChannelType indicates the channel type for subscription
 	AnnotationChannelType = SchemeGroupVersion.Group + "/channel-type"
 	// AnnotationUserGroup is subscription user group
 	AnnotationUserGroup = "open-cluster-management.io/user-group"
 	// AnnotationUserIdentity is subscription user id
 	AnnotationUserIdentity = "open-cluster-management.io/user-identity"
+	// AnnotationUserIdentitySignature is the signature of the user identity and group annotations of the subscription,
+	// set by the subscription mutating webhook. The annotations without a valid signature were not set by the webhook
+	AnnotationUserIdentitySignature = SchemeGroupVersion.Group + "/user-identity-signature"
 	// AnnotationResourceReconcileOption is for reconciling existing resource
 	AnnotationResourceReconcileOption   = SchemeGroupVersion.Group + "/reconcile-option"
 	AnnotationResourceDoNotDeleteOption = SchemeGroupVersion.Group + "/do-not-delete"
+	// FinalizerResourceCleanup is the finalizer of the subscriptions with a deletion policy on the managed clusters,
+	// it is removed once the resources are deleted or orphaned
+	FinalizerResourceCleanup = SchemeGroupVersion.Group + "/resource-cleanup"
+	// FinalizerManifestWorkCleanup is the finalizer of the hub subscriptions with a deletion policy, it is removed
+	// once the manifestWorks of the managed clusters are gone
+	FinalizerManifestWorkCleanup = SchemeGroupVersion.Group + "/manifestwork-cleanup"
+	// AnnotationResourceUpdateStrategy is the strategy to update the resource on the managed cluster. It follows the
+	// ocm work API updateStrategy types: Update (default), CreateOnly, ServerSideApply and ReadOnly
+	AnnotationResourceUpdateStrategy = SchemeGroupVersion.Group + "/update-strategy"
 	// AnnotationResourceReconcileLevel is for resource reconciliation frequency
 	AnnotationResourceReconcileLevel = SchemeGroupVersion.Group + "/reconcile-rate"
+	// AnnotationReconcileInterval is the channel annotation of the default reconcile interval of its subscriptions, a
+	// duration like 30s or 6h
+	AnnotationReconcileInterval = SchemeGroupVersion.Group + "/reconcile-interval"
+	// AnnotationExpireAt is the RFC3339 time the subscription is deleted with its deployed resources
+	AnnotationExpireAt = SchemeGroupVersion.Group + "/expire-at"
+	// AnnotationReconcileProfile set to true records the durations of the reconcile phases of the subscription, like the
+	// clone, kustomize, helm and apply phases, in a ReconcileProfiled event after each reconcile on the managed cluster
+	AnnotationReconcileProfile = SchemeGroupVersion.Group + "/reconcile-profile"
 	// AnnotationManualReconcileTime is the time user triggers a manual resource reconcile
 	AnnotationManualReconcileTime = SchemeGroupVersion.Group + "/manual-refresh-time"
+	// AnnotationKustomizeEnableHelm enables the inflation of the kustomize helmCharts, like kustomize build --enable-helm
+	AnnotationKustomizeEnableHelm = SchemeGroupVersion.Group + "/kustomize-enable-helm"
+	// AnnotationKustomizeLoadRestrictor is the kustomize file load restrictor, LoadRestrictionsRootOnly or
+	// LoadRestrictionsNone to load the files outside of the kustomization root like kustomize build --load-restrictor
+	AnnotationKustomizeLoadRestrictor = SchemeGroupVersion.Group + "/kustomize-load-restrictor"
+	// AnnotationKustomizeEnableExec is refused, the exec KRM function plugins like kustomize build --enable-exec would run
+	// unsandboxed with the privileges of the subscription pod
+	AnnotationKustomizeEnableExec = SchemeGroupVersion.Group + "/kustomize-enable-exec"
+	// AnnotationValidateManifests enables the validation of the subscription manifests against the API schemas of
+	// each managed cluster before the propagation
+	AnnotationValidateManifests = SchemeGroupVersion.Group + "/validate-manifests"
+	// AnnotationRegionalPlacement is set on a global hub subscription propagated to regional hubs. It is the name
+	// of the Placement selecting the managed clusters of each regional hub, the regional hubs re-propagate the
+	// subscription with this placement
+	AnnotationRegionalPlacement = SchemeGroupVersion.Group + "/regional-placement"
+	// AnnotationReconcilePriority is the reconcile priority of the subscription, critical, normal or low. The
+	// subscriptions with a higher priority are reconciled first by the hub and managed cluster controllers
+	AnnotationReconcilePriority = SchemeGroupVersion.Group + "/reconcile-priority"
+	// AnnotationFluxPassthrough deploys the Flux kustomizations, Helm releases and sources of the subscription
+	// unmodified, their namespace is kept for the cluster admin subscriptions
+	AnnotationFluxPassthrough = SchemeGroupVersion.Group + "/flux-passthrough"
+	// AnnotationFluxHealthCheck reports the Flux custom resources that are not ready as failed in the subscription
+	// status, it requires the flux-passthrough annotation
+	AnnotationFluxHealthCheck = SchemeGroupVersion.Group + "/flux-health-check"
+	// AnnotationHelmTimeout is the time to wait for the install, upgrade and rollback of the Helm charts of the
+	// subscription, as a duration like 15m. Their resources are waited for until they are ready
+	AnnotationHelmTimeout = SchemeGroupVersion.Group + "/helm-timeout"
+	// AnnotationHelmAtomic uninstalls the failed installs and rolls back the failed upgrades of the Helm charts of
+	// the subscription, including the releases whose resources are not ready before the timeout
+	AnnotationHelmAtomic = SchemeGroupVersion.Group + "/helm-atomic"
+	// AnnotationHelmReleaseName is the name template of the HelmRelease CRs of the Helm charts of the subscription,
+	// like {{chart}}-{{subscription}}. The {{chart}}, {{subscription}}, {{namespace}} and {{hash}} placeholders are
+	// replaced by the chart name, the subscription name and namespace, and a hash of them
+	AnnotationHelmReleaseName = SchemeGroupVersion.Group + "/helm-release-name"
+	// AnnotationTargetNamespaces is set by the hub on the propagated subscriptions, the comma separated target
+	// namespaces of the subscription validated against the RBAC of its creator
+	AnnotationTargetNamespaces = SchemeGroupVersion.Group + "/target-namespaces"
+	// AnnotationPolicyCheck evaluates the resources of the subscription against the admission policies of the
+	// managed cluster, like the Kyverno and Gatekeeper policies, with a dry run before they are applied. None of the
+	// resources are applied if one of them violates a policy
+	AnnotationPolicyCheck = SchemeGroupVersion.Group + "/policy-check"
+	// AnnotationImageResolution pins the mutable tags of the container images of the subscription resources to their
+	// digests when they are applied, the resolved images are recorded in the package statuses
+	AnnotationImageResolution = SchemeGroupVersion.Group + "/image-resolution"
+	// AnnotationImageUpdatePolicy is the semver constraint of the image tags, like ~1.25 or >=1.2.0 <2.0.0. The
+	// container images of the subscription resources are updated to the latest registry tag matching it when they
+	// are applied
+	AnnotationImageUpdatePolicy = SchemeGroupVersion.Group + "/image-update-policy"
+	// AnnotationImagePullSecret is the name of a kubernetes.io/dockerconfigjson secret of the subscription namespace,
+	// with the credentials of the registries resolving the images of the subscription
+	AnnotationImagePullSecret = SchemeGroupVersion.Group + "/image-pull-secret"
+	// AnnotationImageScanInterval is the interval of the registry scans of the images of the Git subscriptions with
+	// an image resolution or update policy, like 10m. The subscription is reconciled when a new image is found
+	AnnotationImageScanInterval = SchemeGroupVersion.Group + "/image-scan-interval"
 	//LabelSubscriptionPause sits in subscription label to identify if the subscription is paused or not
 	LabelSubscriptionPause = "subscription-pause"
+	// AnnotationClusterTimezone sits in the managed cluster annotations, gives the TZ identifier of the cluster time
+	// zone. It is not a label, the label values can't hold the "/" of the TZ identifiers like America/Toronto
+	AnnotationClusterTimezone = "timezone.open-cluster-management.io"
+	// ClusterClaimTimezone is the managed cluster claim giving the TZ identifier of the cluster time zone
+	ClusterClaimTimezone = "timezone.open-cluster-management.io"
 	//LabelSubscriptionName is the subscription name
 	LabelSubscriptionName = SchemeGroupVersion.Group + "/subscription"
+	// LabelSubscriptionShard assigns the subscription to a shard of the hub subscription controller, it overrides
+	// the shard computed from the subscription namespace
+	LabelSubscriptionShard = SchemeGroupVersion.Group + "/shard"
+	// LabelStandaloneTarget marks the kubeconfig secrets of the remote clusters managed by the standalone
+	// subscription controller
+	LabelStandaloneTarget = SchemeGroupVersion.Group + "/standalone-target"
 	// AnnotationHookType defines ansible hook job type - prehook/posthook
 	AnnotationHookType = SchemeGroupVersion.Group + "/hook-type"
+	// AnnotationTraceParent defines the W3C trace parent of the hub propagation, to link the managed cluster spans
+	AnnotationTraceParent = SchemeGroupVersion.Group + "/trace-parent"
+	// AnnotationTraceState defines the W3C trace state of the hub propagation
+	AnnotationTraceState = SchemeGroupVersion.Group + "/trace-state"
+	// AnnotationLogLevel defines the klog verbosity of the subscription reconcile logs, for example "4"
+	AnnotationLogLevel = SchemeGroupVersion.Group + "/log-level"
 	// AnnotationHookTemplate defines ansible hook job template namespaced name
 	AnnotationHookTemplate = SchemeGroupVersion.Group + "/hook-template"
 	// AnnotationBucketPath defines s3 object bucket subfolder path
 	AnnotationBucketPath = SchemeGroupVersion.Group + "/bucket-path"
+	// AnnotationCosignPublicKey sits in an object bucket channel, it is the PEM encoded cosign public key verifying the
+	// <key>.sig signature object of each manifest object. The unsigned manifests are not deployed.
+	AnnotationCosignPublicKey = SchemeGroupVersion.Group + "/cosign-public-key"
 	// AnnotationManagedCluster identifies this is a deployable for managed cluster
 	AnnotationManagedCluster = SchemeGroupVersion.Group + "/managed-cluster"
 	// AnnotationHostingDeployable sits in templated resource, gives name of hosting deployable, legacy annotation
@@ -98,6 +211,46 @@ var (
 	AnnotationCurrentNamespaceScoped = SchemeGroupVersion.Group + "/current-namespace-scoped"
 	// AnnotationSkipHubValidation indicates the hub subscription should skip the "dry-run" validations and proceed to propagation phase
 	AnnotationSkipHubValidation = SchemeGroupVersion.Group + "/skip-hub-validation"
+	// AnnotationRollback requests the hub to roll the subscription back to the last commit successfully deployed on
+	// all the managed clusters, by pinning the git-desired-commit annotation
+	AnnotationRollback = SchemeGroupVersion.Group + "/rollback"
+	// AnnotationDeployRetryCount sits in the appsub manifestWork, gives the number of retries done for a failed cluster deployment
+	AnnotationDeployRetryCount = SchemeGroupVersion.Group + "/deploy-retry-count"
+	// AnnotationDeployRetryFor sits in the appsub manifestWork, gives the appsub generation and commit the retries are
+	// counted for, the retries are reset when the appsub or its commit changes
+	AnnotationDeployRetryFor = SchemeGroupVersion.Group + "/deploy-retry-for"
+	// AnnotationDeployRetryTime sits in the appsub manifestWork, gives the time of the last retry of a failed cluster deployment
+	AnnotationDeployRetryTime = SchemeGroupVersion.Group + "/deploy-retry-time"
+	// AnnotationDeployNextRetryTime sits in the appsub manifestWork, gives the time the next retry of a failed cluster deployment is due
+	AnnotationDeployNextRetryTime = SchemeGroupVersion.Group + "/deploy-next-retry-time"
+	// AnnotationEmergencyDeploy bypasses the subscription time window and deployment window, its value is the reason
+	// of the emergency deployment recorded in the audit event
+	AnnotationEmergencyDeploy = SchemeGroupVersion.Group + "/emergency-deploy"
+	// AnnotationLocalPlacement sits in the local subscription created on the hub for a subscription with both a local
+	// and a remote placement, gives the name of the hub subscription
+	AnnotationLocalPlacement = SchemeGroupVersion.Group + "/local-placement"
+	// AnnotationChannelReferencesVersion is set by the hub on the propagated subscription, it is a hash of the secrets
+	// and configmaps referenced by the subscription channels so the managed clusters refresh the channel credentials
+	// when they change
+	AnnotationChannelReferencesVersion = SchemeGroupVersion.Group + "/channel-references-version"
+	// AnnotationSecretProvider is the channel annotation of the external secret manager holding the channel
+	// credentials: vault, aws-secrets-manager or azure-key-vault
+	AnnotationSecretProvider = SchemeGroupVersion.Group + "/secret-provider"
+	// AnnotationSecretProviderRef is the channel annotation of the secret in the external secret manager
+	AnnotationSecretProviderRef = SchemeGroupVersion.Group + "/secret-provider-ref"
+	// AnnotationSecretProviderAddress is refused, the address of the external secret manager is configured in the
+	// environment of the application manager so its credentials are not sent to an address chosen by a channel creator
+	AnnotationSecretProviderAddress = SchemeGroupVersion.Group + "/secret-provider-address"
+	// AnnotationSecretProviderRefreshInterval is the channel annotation of how long the credentials of the external
+	// secret manager are cached, a duration like 1m or 1h
+	AnnotationSecretProviderRefreshInterval = SchemeGroupVersion.Group + "/secret-provider-refresh-interval"
+	// AnnotationSecretProviderSignature sits in the HelmRelease of a channel with an external secret manager, it is
+	// the signature of the secret provider annotations and of the repo of the HelmRelease set by the subscriber. The
+	// secret provider annotations of the HelmReleases without a valid signature are ignored.
+	AnnotationSecretProviderSignature = SchemeGroupVersion.Group + "/secret-provider-signature"
+	// AnnotationRestoreName sits in the hub subscription, gives the name of the last Velero restore of the
+	// subscription reconciled by the hub. It is set by the hub, the restore is reconciled once.
+	AnnotationRestoreName = SchemeGroupVersion.Group + "/restore-name"
 )
 
 const (
@@ -117,6 +270,12 @@ const (
 	SubscriptionNameSuffix = ""
 	// ChannelCertificateData is the configmap data spec field containing trust certificates
 	ChannelCertificateData = "caCerts"
+	// ChannelKnownHostsData is the configmap data spec field containing the SSH host keys of the Git server, in the
+	// known_hosts format
+	ChannelKnownHostsData = "knownHosts"
+	// ChannelStrictHostKeyCheckingData is the configmap data spec field that disables the strict SSH host key checking
+	// if set to false, the host keys scanned from the Git server are trusted if there is no known hosts
+	ChannelStrictHostKeyCheckingData = "sshStrictHostKeyChecking"
 	// TLS minimum version as integer
 	TLSMinVersionInt = tls.VersionTLS12
 	// TLS minimum version as string
@@ -146,25 +305,94 @@ type PackageOverride struct {
 	runtime.RawExtension `json:",inline"`
 }
 
+// PatchType defines how the package overrides are applied to a resource
+type PatchType string
+
+const (
+	// PatchTypeStrategicMerge applies each package override as a strategic merge patch. The custom resources
+	// are patched with a JSON merge patch
+	PatchTypeStrategicMerge PatchType = "strategicMerge"
+	// PatchTypeJSON6902 applies the package overrides as the operations of a RFC 6902 JSON patch
+	PatchTypeJSON6902 PatchType = "json6902"
+	// PatchTypeMerge applies each package override as a RFC 7386 JSON merge patch
+	PatchTypeMerge PatchType = "merge"
+)
+
+// OverrideTarget selects the resources a package override applies to, an empty field matches all the resources
+type OverrideTarget struct {
+	// APIVersion of the resources, for example apps/v1
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the resources
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the resources
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Namespace of the resources
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // Overrides defines a list of contents that will be overridden to a given resource
 type Overrides struct {
 	// PackageAlias defines the alias of the package name that will be onverriden
 	PackageAlias string `json:"packageAlias,omitempty"`
 
-	// PackageName defines the package name that will be onverriden
-	PackageName string `json:"packageName"`
+	// PackageName defines the package name that will be onverriden. Optional if the target is set
+	// +optional
+	PackageName string `json:"packageName,omitempty"`
+
+	// Target selects the resources to override by apiVersion, kind, name and namespace
+	// +optional
+	Target *OverrideTarget `json:"target,omitempty"`
+
+	// PatchType defines how the package overrides are applied. If not set, each package override sets
+	// the value of a path
+	// +kubebuilder:validation:Enum=strategicMerge;json6902;merge
+	// +optional
+	PatchType PatchType `json:"patchType,omitempty"`
 
 	// PackageOverrides defines a list of content for override
 	PackageOverrides []PackageOverride `json:"packageOverrides,omitempty"`
+
+	// PackageOverridesFrom references the ConfigMap or Secret keys in the subscription namespace whose content
+	// is appended to the package overrides. The subscription is reconciled when the referenced objects change
+	// +optional
+	PackageOverridesFrom []PackageOverridesReference `json:"packageOverridesFrom,omitempty"`
+}
+
+// PackageOverridesReference references a ConfigMap or Secret key whose content is a YAML list of package overrides,
+// or a single package override
+type PackageOverridesReference struct {
+	// Kind of the referenced object, ConfigMap or Secret
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+
+	// Name of the referenced object
+	Name string `json:"name"`
+
+	// Key of the referenced object data holding the package overrides
+	Key string `json:"key"`
 }
 
 // AllowDenyItem defines a group of resources allowed or denied for deployment
 type AllowDenyItem struct {
-	// APIVersion specifies the API version for the group of resources
+	// APIVersion specifies the API version for the group of resources. "*" matches all the API versions, "<group>/*"
+	// matches all the versions of the group and "*.<group>" matches all the versions of the group and its subgroups,
+	// e.g. "batch/*" or "*.apps"
 	APIVersion string `json:"apiVersion,omitempty"`
 
-	// Kinds specifies a list of kinds under the same API version for the group of resources
+	// Kinds specifies a list of kinds under the same API version for the group of resources. "*" matches all the kinds
 	Kinds []string `json:"kinds,omitempty"`
+
+	// Namespaces restricts the group of resources to the resources in these namespaces. The group includes the
+	// resources in all the namespaces and the cluster scoped resources if it is empty
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 // TimeWindow defines a time window for the subscription to run or be blocked
@@ -177,11 +405,51 @@ type TimeWindow struct {
 	// time zone location, refer to TZ identifier in https://en.wikipedia.org/wiki/List_of_tz_database_time_zones
 	Location string `json:"location,omitempty"`
 
+	// If true, the time window is evaluated in the time zone of each managed cluster, set by the
+	// timezone.open-cluster-management.io annotation or cluster claim of the managed cluster. The location is used
+	// for the managed clusters without time zone. Hub use only
+	// +optional
+	ClusterTimezone bool `json:"clusterTimezone,omitempty"`
+
 	// A list of days of a week, valid values include: Sunday, Monday, Tuesday, Wednesday, Thursday, Friday, Saturday
 	Daysofweek []string `json:"daysofweek,omitempty"`
 
 	// A list of hour ranges
 	Hours []HourRange `json:"hours,omitempty"`
+
+	// A list of cron windows. If set, the time window is the union of the cron windows,
+	// the daysofweek and hours are ignored
+	// +optional
+	Schedules []CronWindow `json:"schedules,omitempty"`
+}
+
+// CronWindow defines a window starting at each activation of a cron schedule
+type CronWindow struct {
+	// Standard 5 fields cron schedule of the window start times, evaluated in the time window location.
+	// Use 6#1 in the day of week field for the first Saturday of the month, 5L for the last Friday
+	Schedule string `json:"schedule"`
+
+	// Duration of the window, for example 2h
+	Duration metav1.Duration `json:"duration"`
+}
+
+// DeploymentWindowReference refers to a deployment window
+type DeploymentWindowReference struct {
+	// Name of the deployment window
+	Name string `json:"name"`
+
+	// Namespace of the deployment window, defaults to the subscription namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// KustomizeOverlay maps the clusters matching a label selector to a kustomize overlay of the Git repository
+type KustomizeOverlay struct {
+	// ClusterSelector selects the managed clusters by their labels
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector"`
+
+	// Path of the kustomize overlay directory in the Git repository
+	Path string `json:"path"`
 }
 
 // HourRange defines the time format, refer to https://golang.org/pkg/time/#pkg-constants
@@ -214,6 +482,10 @@ type SubscriptionSpec struct {
 	Channel string `json:"channel"`
 	// The secondary channel will be applied if the primary channel fails to connect
 	SecondaryChannel string `json:"secondaryChannel,omitempty"`
+	// The fallback channels are tried in order if neither the primary nor the secondary channel can be connected.
+	// Their format is "<channel NameSpace>/<channel Name>". Git channels only
+	// +optional
+	FallbackChannels []string `json:"fallbackChannels,omitempty"`
 	// Subscribe a package by its package name
 	Package string `json:"name,omitempty"`
 	// Subscribe packages by a package filter
@@ -224,8 +496,16 @@ type SubscriptionSpec struct {
 	Placement *plrv1alpha1.Placement `json:"placement,omitempty"`
 	// Specify overrides when applied to clusters. Hub use only
 	Overrides []ClusterOverrides `json:"overrides,omitempty"`
+	// Specify the kustomize overlay deployed to the clusters matching a label selector. The path of the first
+	// matching overlay replaces the Git path of the subscription on the cluster. Hub use only
+	// +optional
+	KustomizeOverlays []KustomizeOverlay `json:"kustomizeOverlays,omitempty"`
 	// Specify a time window to indicate when the subscription is handled
 	TimeWindow *TimeWindow `json:"timewindow,omitempty"`
+	// Specify a shared deployment window to indicate when the subscription is handled. The timewindow of the
+	// subscription takes precedence over the deployment window, unless the deployment window is enforced. Hub use only
+	// +optional
+	DeploymentWindowRef *DeploymentWindowReference `json:"deploymentWindowRef,omitempty"`
 
 	// Specify a secret reference used in Ansible job integration authentication
 	// +optional
@@ -239,8 +519,79 @@ type SubscriptionSpec struct {
 
 	// WatchHelmNamespaceScopedResources is used to enable watching namespace scope Helm chart resources
 	WatchHelmNamespaceScopedResources bool `json:"watchHelmNamespaceScopedResources,omitempty"`
+
+	// Specify the interval of the periodic reconcile of the subscription resources on the managed clusters, at least
+	// 15s. It overrides the reconcile-interval annotation of the channel and the interval of the reconcile rate, the
+	// reconcile rate off still disables the periodic reconcile
+	// +optional
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+
+	// Specify what happens to the resources deployed on the managed clusters when the subscription is deleted. They
+	// are deleted with Delete and kept with Orphan, whatever the channel type, and the subscription is only removed
+	// once the managed clusters completed the cleanup. The cleanup depends on the channel type if it is not set
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Specify the time to live of the subscription from its creation, the subscription is deleted with its deployed
+	// resources once it expires. The expire-at annotation sets an absolute expiry time instead
+	// +optional
+	ExpireAfter *metav1.Duration `json:"expireAfter,omitempty"`
+
+	// Specify whether the namespaces of the deployed resources are created on the managed clusters if they don't
+	// exist. They are created with Auto, the resources of a missing namespace fail to deploy with Never. Auto by default
+	// +kubebuilder:validation:Enum=Auto;Never
+	// +optional
+	NamespaceCreation NamespaceCreationPolicy `json:"namespaceCreation,omitempty"`
+
+	// Specify the labels and annotations of the namespaces created by the subscription on the managed clusters, like
+	// the pod security admission labels
+	// +optional
+	NamespaceTemplate *NamespaceTemplate `json:"namespaceTemplate,omitempty"`
+
+	// Specify the service account in the subscription namespace on the managed clusters that applies the deployed
+	// resources, instead of the application manager. The ServiceAccounts, Roles and RoleBindings of the subscription
+	// namespace are applied by the application manager first, so the service account and its RBAC can be deployed
+	// with the application
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Specify the namespaces other than the subscription namespace that the resources of a subscription that is not a
+	// cluster admin can be deployed to. The creator of the subscription must be allowed to create subscriptions in each
+	// namespace, the resources of the other namespaces are deployed to the subscription namespace
+	// +optional
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+}
+
+// NamespaceCreationPolicy defines whether the namespaces of the resources deployed by a subscription are created
+type NamespaceCreationPolicy string
+
+const (
+	// NamespaceCreationAuto creates the namespaces that don't exist
+	NamespaceCreationAuto NamespaceCreationPolicy = "Auto"
+	// NamespaceCreationNever never creates the namespaces
+	NamespaceCreationNever NamespaceCreationPolicy = "Never"
+)
+
+// NamespaceTemplate is the metadata of the namespaces created by a subscription
+type NamespaceTemplate struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
+// DeletionPolicy defines what happens to the resources deployed by a subscription when it is deleted
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the resources deployed by the subscription
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the resources deployed by the subscription
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
 // SubscriptionPhase defines the phasing of a Subscription
 type SubscriptionPhase string
 
@@ -258,6 +609,25 @@ const (
 	PreHookSucessful              SubscriptionPhase = "PreHookSucessful"
 )
 
+const (
+	// SubscriptionConditionReady is true when the subscription is deployed and not blocked
+	SubscriptionConditionReady = "Ready"
+	// SubscriptionConditionSynced is true when the subscription resources are applied on the managed cluster
+	SubscriptionConditionSynced = "Synced"
+	// SubscriptionConditionPropagated is true when the subscription is propagated to the managed clusters by the hub
+	SubscriptionConditionPropagated = "Propagated"
+	// SubscriptionConditionHooksCompleted is true when the ansible jobs of the subscription are completed
+	SubscriptionConditionHooksCompleted = "HooksCompleted"
+	// SubscriptionConditionBlocked is true when the deployment is blocked by the subscription time window
+	SubscriptionConditionBlocked = "Blocked"
+	// SubscriptionConditionClusterAdminApproved is true when the cluster admin access of the subscription is approved.
+	// It is only set if the hub requires the approval
+	SubscriptionConditionClusterAdminApproved = "ClusterAdminApproved"
+	// SubscriptionConditionExpiring is true when the subscription has an expiry time, it is only set if the
+	// subscription expires
+	SubscriptionConditionExpiring = "Expiring"
+)
+
 // SubscriptionUnitStatus defines status of each package in a subscription
 type SubscriptionUnitStatus struct {
 	// Phase of the deployment package (Propagated/Subscribed/Failed/PropagationFailed/PreHookSucessful).
@@ -299,6 +669,63 @@ type AnsibleJobsStatus struct {
 	PosthookJobsHistory []string `json:"posthookjobshistory,omitempty"`
 }
 
+// SubscriptionClusterRolloutStatus defines the rollout status of the subscription on a single managed cluster
+type SubscriptionClusterRolloutStatus struct {
+	// Name of the managed cluster
+	Cluster string `json:"cluster"`
+
+	// Result of the subscription on the managed cluster (deployed/failed/propagationFailed/inProgress)
+	Result string `json:"result,omitempty"`
+
+	// The commit (Git commit, chart version or object etag) most recently applied on the managed cluster
+	Commit string `json:"commit,omitempty"`
+}
+
+// SubscriptionRolloutSummary defines the aggregated rollout status of the subscription on all the managed clusters.
+// Hub use only
+type SubscriptionRolloutSummary struct {
+	// Clusters is the count of all managed clusters selected by the subscription placement
+	Clusters int `json:"clusters"`
+
+	// Propagated is the count of managed clusters the subscription is propagated to
+	Propagated int `json:"propagated"`
+
+	// Deployed is the count of managed clusters where the subscription is deployed successfully
+	Deployed int `json:"deployed"`
+
+	// Failed is the count of managed clusters where the subscription failed to deploy
+	Failed int `json:"failed"`
+
+	// PropagationFailed is the count of managed clusters the subscription failed to propagate to
+	PropagationFailed int `json:"propagationFailed"`
+
+	// InProgress is the count of managed clusters where the subscription is still being deployed
+	InProgress int `json:"inProgress"`
+
+	// OutOfSync is the count of managed clusters that have not applied the current commit of the subscription
+	OutOfSync int `json:"outOfSync"`
+
+	// The current commit of the subscription on the hub
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// The rollout status per managed cluster, sorted by cluster name
+	// +optional
+	ClusterStatuses []SubscriptionClusterRolloutStatus `json:"clusterStatuses,omitempty"`
+
+	// Timestamp of when the rollout summary was last updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// SubscriptionCommitRecord defines a commit successfully deployed on all the managed clusters
+type SubscriptionCommitRecord struct {
+	// The commit (Git commit, chart version or object etag)
+	Commit string `json:"commit"`
+
+	// Timestamp of when the commit was found deployed on all the managed clusters
+	DeployedTime metav1.Time `json:"deployedTime,omitempty"`
+}
+
 // SubscriptionStatus defines the observed status of a subscription
 type SubscriptionStatus struct {
 	// Phase of the subscription deployment
@@ -313,13 +740,38 @@ type SubscriptionStatus struct {
 	// additional error output of the subscription deployment
 	Reason string `json:"reason,omitempty"`
 
+	// The generation of the subscription spec observed by the controller that last updated the status
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// Timestamp of when the subscription status was last updated.
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 
+	// The channel serving the subscription, the channel the resources were last deployed from. Its format is
+	// "<channel NameSpace>/<channel Name>". Set on the managed cluster
+	// +optional
+	ServingChannel string `json:"servingChannel,omitempty"`
+
 	// +optional
 	AnsibleJobsStatus AnsibleJobsStatus `json:"ansiblejobs,omitempty"`
 
 	Statuses SubscriptionClusterStatusMap `json:"statuses,omitempty"`
+
+	// Aggregated rollout status of the subscription on all the managed clusters. Hub use only
+	// +optional
+	Summary *SubscriptionRolloutSummary `json:"summary,omitempty"`
+
+	// Bounded history of the commits successfully deployed on all the managed clusters, most recent first.
+	// Used to roll the subscription back. Hub use only
+	// +optional
+	CommitHistory []SubscriptionCommitRecord `json:"commitHistory,omitempty"`
+
+	// Conditions of the subscription (Ready, Synced, Propagated, HooksCompleted and Blocked).
+	// Propagated and HooksCompleted are set on the hub, Synced on the managed cluster
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -330,10 +782,17 @@ type SubscriptionStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="SubscriptionState",type="string",JSONPath=".status.phase",description="subscription state"
 // +kubebuilder:printcolumn:name="AppstatusReference",type="string",JSONPath=".status.appstatusReference",description="subscription status reference"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Clusters",type="integer",JSONPath=".status.summary.clusters",priority=1
+// +kubebuilder:printcolumn:name="Deployed",type="integer",JSONPath=".status.summary.deployed",priority=1
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.summary.failed",priority=1
+// +kubebuilder:printcolumn:name="OutOfSync",type="integer",JSONPath=".status.summary.outOfSync",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:printcolumn:name="Updated",type="date",JSONPath=".status.lastUpdateTime"
 // +kubebuilder:printcolumn:name="Local placement",type="boolean",JSONPath=".spec.placement.local"
 // +kubebuilder:printcolumn:name="Time window",type="string",JSONPath=".spec.timewindow.windowtype"
+// +kubebuilder:printcolumn:name="Channel",type="string",JSONPath=".spec.channel",priority=1
+// +kubebuilder:printcolumn:name="LastCommit",type="string",JSONPath=".status.commitHistory[0].commit",priority=1
 // +kubebuilder:resource:shortName=appsub
 type Subscription struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -364,6 +823,14 @@ type SubscriberItem struct {
 	SecondaryChannel          *chnv1alpha1.Channel
 	SecondaryChannelSecret    *corev1.Secret
 	SecondaryChannelConfigMap *corev1.ConfigMap
+	FallbackChannels          []SubscriberChannel
+}
+
+// SubscriberChannel defines a channel of a subscriber item with its referenced secret and configmap
+type SubscriberChannel struct {
+	Channel   *chnv1alpha1.Channel
+	Secret    *corev1.Secret
+	ConfigMap *corev1.ConfigMap
 }
 
 // Subscriber efines common interface of different channel types
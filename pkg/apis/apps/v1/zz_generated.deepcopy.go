@@ -15,6 +15,16 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AllowDenyItem) DeepCopyInto(out *AllowDenyItem) {
 	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowDenyItem.
@@ -90,6 +100,111 @@ func (in *ClusterOverrides) DeepCopy() *ClusterOverrides {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CronWindow) DeepCopyInto(out *CronWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CronWindow.
+func (in *CronWindow) DeepCopy() *CronWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(CronWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentWindow) DeepCopyInto(out *DeploymentWindow) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentWindow.
+func (in *DeploymentWindow) DeepCopy() *DeploymentWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentWindow) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentWindowList) DeepCopyInto(out *DeploymentWindowList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DeploymentWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentWindowList.
+func (in *DeploymentWindowList) DeepCopy() *DeploymentWindowList {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentWindowList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentWindowList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentWindowReference) DeepCopyInto(out *DeploymentWindowReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentWindowReference.
+func (in *DeploymentWindowReference) DeepCopy() *DeploymentWindowReference {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentWindowReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentWindowSpec) DeepCopyInto(out *DeploymentWindowSpec) {
+	*out = *in
+	in.TimeWindow.DeepCopyInto(&out.TimeWindow)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentWindowSpec.
+func (in *DeploymentWindowSpec) DeepCopy() *DeploymentWindowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentWindowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HourRange) DeepCopyInto(out *HourRange) {
 	*out = *in
@@ -105,9 +220,78 @@ func (in *HourRange) DeepCopy() *HourRange {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KustomizeOverlay) DeepCopyInto(out *KustomizeOverlay) {
+	*out = *in
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizeOverlay.
+func (in *KustomizeOverlay) DeepCopy() *KustomizeOverlay {
+	if in == nil {
+		return nil
+	}
+	out := new(KustomizeOverlay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceTemplate) DeepCopyInto(out *NamespaceTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceTemplate.
+func (in *NamespaceTemplate) DeepCopy() *NamespaceTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverrideTarget) DeepCopyInto(out *OverrideTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverrideTarget.
+func (in *OverrideTarget) DeepCopy() *OverrideTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(OverrideTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Overrides) DeepCopyInto(out *Overrides) {
 	*out = *in
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(OverrideTarget)
+		**out = **in
+	}
 	if in.PackageOverrides != nil {
 		in, out := &in.PackageOverrides, &out.PackageOverrides
 		*out = make([]PackageOverride, len(*in))
@@ -115,6 +299,11 @@ func (in *Overrides) DeepCopyInto(out *Overrides) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PackageOverridesFrom != nil {
+		in, out := &in.PackageOverridesFrom, &out.PackageOverridesFrom
+		*out = make([]PackageOverridesReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Overrides.
@@ -175,6 +364,51 @@ func (in *PackageOverride) DeepCopy() *PackageOverride {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageOverridesReference) DeepCopyInto(out *PackageOverridesReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageOverridesReference.
+func (in *PackageOverridesReference) DeepCopy() *PackageOverridesReference {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageOverridesReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriberChannel) DeepCopyInto(out *SubscriberChannel) {
+	*out = *in
+	if in.Channel != nil {
+		in, out := &in.Channel, &out.Channel
+		*out = new(apisappsv1.Channel)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(corev1.Secret)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(corev1.ConfigMap)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriberChannel.
+func (in *SubscriberChannel) DeepCopy() *SubscriberChannel {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriberChannel)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubscriberItem) DeepCopyInto(out *SubscriberItem) {
 	*out = *in
@@ -218,6 +452,13 @@ func (in *SubscriberItem) DeepCopyInto(out *SubscriberItem) {
 		*out = new(corev1.ConfigMap)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FallbackChannels != nil {
+		in, out := &in.FallbackChannels, &out.FallbackChannels
+		*out = make([]SubscriberChannel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriberItem.
@@ -257,6 +498,21 @@ func (in *Subscription) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriptionClusterRolloutStatus) DeepCopyInto(out *SubscriptionClusterRolloutStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionClusterRolloutStatus.
+func (in *SubscriptionClusterRolloutStatus) DeepCopy() *SubscriptionClusterRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriptionClusterRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in SubscriptionClusterStatusMap) DeepCopyInto(out *SubscriptionClusterStatusMap) {
 	{
@@ -286,6 +542,22 @@ func (in SubscriptionClusterStatusMap) DeepCopy() SubscriptionClusterStatusMap {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriptionCommitRecord) DeepCopyInto(out *SubscriptionCommitRecord) {
+	*out = *in
+	in.DeployedTime.DeepCopyInto(&out.DeployedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionCommitRecord.
+func (in *SubscriptionCommitRecord) DeepCopy() *SubscriptionCommitRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriptionCommitRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubscriptionList) DeepCopyInto(out *SubscriptionList) {
 	*out = *in
@@ -348,9 +620,35 @@ func (in *SubscriptionPerClusterStatus) DeepCopy() *SubscriptionPerClusterStatus
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriptionRolloutSummary) DeepCopyInto(out *SubscriptionRolloutSummary) {
+	*out = *in
+	if in.ClusterStatuses != nil {
+		in, out := &in.ClusterStatuses, &out.ClusterStatuses
+		*out = make([]SubscriptionClusterRolloutStatus, len(*in))
+		copy(*out, *in)
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionRolloutSummary.
+func (in *SubscriptionRolloutSummary) DeepCopy() *SubscriptionRolloutSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriptionRolloutSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 	*out = *in
+	if in.FallbackChannels != nil {
+		in, out := &in.FallbackChannels, &out.FallbackChannels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.PackageFilter != nil {
 		in, out := &in.PackageFilter, &out.PackageFilter
 		*out = new(PackageFilter)
@@ -379,11 +677,23 @@ func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.KustomizeOverlays != nil {
+		in, out := &in.KustomizeOverlays, &out.KustomizeOverlays
+		*out = make([]KustomizeOverlay, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.TimeWindow != nil {
 		in, out := &in.TimeWindow, &out.TimeWindow
 		*out = new(TimeWindow)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DeploymentWindowRef != nil {
+		in, out := &in.DeploymentWindowRef, &out.DeploymentWindowRef
+		*out = new(DeploymentWindowReference)
+		**out = **in
+	}
 	if in.HookSecretRef != nil {
 		in, out := &in.HookSecretRef, &out.HookSecretRef
 		*out = new(corev1.ObjectReference)
@@ -411,6 +721,26 @@ func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 			}
 		}
 	}
+	if in.ReconcileInterval != nil {
+		in, out := &in.ReconcileInterval, &out.ReconcileInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ExpireAfter != nil {
+		in, out := &in.ExpireAfter, &out.ExpireAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NamespaceTemplate != nil {
+		in, out := &in.NamespaceTemplate, &out.NamespaceTemplate
+		*out = new(NamespaceTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TargetNamespaces != nil {
+		in, out := &in.TargetNamespaces, &out.TargetNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionSpec.
@@ -443,6 +773,25 @@ func (in *SubscriptionStatus) DeepCopyInto(out *SubscriptionStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = new(SubscriptionRolloutSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CommitHistory != nil {
+		in, out := &in.CommitHistory, &out.CommitHistory
+		*out = make([]SubscriptionCommitRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionStatus.
@@ -489,6 +838,11 @@ func (in *TimeWindow) DeepCopyInto(out *TimeWindow) {
 		*out = make([]HourRange, len(*in))
 		copy(*out, *in)
 	}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]CronWindow, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeWindow.
@@ -0,0 +1,71 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChannelRule matches the channels a subscription may subscribe to
+type ChannelRule struct {
+	// The channel type to match (git, helmrepo, objectbucket). Empty matches all channel types
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// A glob pattern matched against the channel pathname, for example https://github.com/my-org/*.
+	// Empty matches all channel pathnames
+	// +optional
+	Pathname string `json:"pathname,omitempty"`
+}
+
+// AllowedSubscriptionChannelsSpec defines the channels the subscriptions in the selected namespaces may subscribe to
+type AllowedSubscriptionChannelsSpec struct {
+	// Select the namespaces the policy applies to. The policy applies to all namespaces if it is not specified
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// The subscriptions may only subscribe to the channels matching one of the allow rules.
+	// All channels are allowed if no allow rule is specified
+	// +optional
+	Allow []ChannelRule `json:"allow,omitempty"`
+
+	// The subscriptions may not subscribe to the channels matching one of the deny rules. Deny rules take
+	// precedence over allow rules
+	// +optional
+	Deny []ChannelRule `json:"deny,omitempty"`
+}
+
+// AllowedSubscriptionChannels restricts the channels the subscriptions in the selected namespaces may subscribe to.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope="Cluster"
+// +kubebuilder:resource:shortName=appsubchannelpolicy
+type AllowedSubscriptionChannels struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AllowedSubscriptionChannelsSpec `json:"spec"`
+}
+
+// AllowedSubscriptionChannelsList contains a list of AllowedSubscriptionChannels
+// +kubebuilder:object:root=true
+type AllowedSubscriptionChannelsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AllowedSubscriptionChannels `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AllowedSubscriptionChannels{}, &AllowedSubscriptionChannelsList{})
+}
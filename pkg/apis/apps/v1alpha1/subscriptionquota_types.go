@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SubscriptionQuotaSpec defines the limits of the subscriptions in a namespace
+type SubscriptionQuotaSpec struct {
+	// The maximum number of subscriptions in the namespace
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSubscriptions *int32 `json:"maxSubscriptions,omitempty"`
+
+	// The maximum number of resources deployed by all the subscriptions in the namespace, counted once per target cluster
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxDeployedResources *int32 `json:"maxDeployedResources,omitempty"`
+
+	// The maximum number of clusters a subscription in the namespace may target
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxClusters *int32 `json:"maxClusters,omitempty"`
+}
+
+// SubscriptionQuota limits the subscriptions in a namespace and the resources they deploy.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope="Namespaced"
+// +kubebuilder:resource:shortName=appsubquota
+// +kubebuilder:printcolumn:name="MaxSubscriptions",type=integer,JSONPath=`.spec.maxSubscriptions`
+// +kubebuilder:printcolumn:name="MaxDeployedResources",type=integer,JSONPath=`.spec.maxDeployedResources`
+// +kubebuilder:printcolumn:name="MaxClusters",type=integer,JSONPath=`.spec.maxClusters`
+type SubscriptionQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SubscriptionQuotaSpec `json:"spec"`
+}
+
+// SubscriptionQuotaList contains a list of SubscriptionQuota
+// +kubebuilder:object:root=true
+type SubscriptionQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SubscriptionQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SubscriptionQuota{}, &SubscriptionQuotaList{})
+}
@@ -0,0 +1,224 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// LabelSubscriptionSet is the name of the subscription set that generated a subscription or a namespace
+	LabelSubscriptionSet = "apps.open-cluster-management.io/subscription-set"
+	// LabelSubscriptionSetNamespace is the namespace of the subscription set that generated a subscription or a namespace
+	LabelSubscriptionSetNamespace = "apps.open-cluster-management.io/subscription-set-namespace"
+	// AnnotationSubscriptionSetBranch is the Git branch or pull request a subscription is generated for
+	AnnotationSubscriptionSetBranch = "apps.open-cluster-management.io/subscription-set-branch"
+	// FinalizerSubscriptionSetCleanup deletes the generated subscriptions and namespaces with the subscription set
+	FinalizerSubscriptionSetCleanup = "apps.open-cluster-management.io/subscription-set-cleanup"
+)
+
+// GitBranchesGenerator generates a parameter set per branch or pull request of a Git repository
+type GitBranchesGenerator struct {
+	// The namespaced name of the Git channel of the repository, for example ns-ch/git-channel
+	Channel string `json:"channel"`
+
+	// A glob pattern matched against the branch names, for example feature/*. All branches match if it is empty
+	// +optional
+	BranchPattern string `json:"branchPattern,omitempty"`
+
+	// Generate a parameter set per open GitHub pull request (refs/pull/<number>/head) or GitLab merge request
+	// (refs/merge-requests/<number>/head) as well
+	// +optional
+	PullRequests bool `json:"pullRequests,omitempty"`
+
+	// How often the repository is polled for new and deleted branches, 3m by default
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+}
+
+// ListGenerator generates a parameter set per element of a literal list
+type ListGenerator struct {
+	// The parameter sets, the keys of an element are the names of its parameters. The name key of an element
+	// identifies it, its index otherwise
+	// +kubebuilder:validation:MinItems=1
+	Elements []map[string]string `json:"elements"`
+}
+
+// ClustersGenerator generates a parameter set per managed cluster matching a label selector
+type ClustersGenerator struct {
+	// The label selector of the managed clusters, all the managed clusters match if it is empty
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Additional parameters of all the clusters, {{values.<key>}} in the template
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// GitDirectory is a glob pattern of the directories of a Git repository
+type GitDirectory struct {
+	// A glob pattern matched against the directory paths relative to the root of the repository, for example apps/*.
+	// * doesn't match /
+	Path string `json:"path"`
+
+	// Exclude the directories matching the pattern, even if they match another pattern
+	// +optional
+	Exclude bool `json:"exclude,omitempty"`
+}
+
+// GitDirectoriesGenerator generates a parameter set per directory of a Git repository
+type GitDirectoriesGenerator struct {
+	// The namespaced name of the Git channel of the repository, for example ns-ch/git-channel
+	Channel string `json:"channel"`
+
+	// The branch the directories are listed from, the default branch of the repository if it is empty
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// The patterns of the directories
+	// +kubebuilder:validation:MinItems=1
+	Directories []GitDirectory `json:"directories"`
+
+	// How often the repository is polled for new and deleted directories, 3m by default
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+}
+
+// SubscriptionSetGenerator generates the parameter sets the subscription template is rendered with. Exactly one
+// generator is specified
+type SubscriptionSetGenerator struct {
+	// Generate a parameter set per branch or pull request of a Git repository
+	// +optional
+	GitBranches *GitBranchesGenerator `json:"gitBranches,omitempty"`
+
+	// Generate a parameter set per element of a list
+	// +optional
+	List *ListGenerator `json:"list,omitempty"`
+
+	// Generate a parameter set per managed cluster matching a label selector
+	// +optional
+	Clusters *ClustersGenerator `json:"clusters,omitempty"`
+
+	// Generate a parameter set per directory of a Git repository
+	// +optional
+	GitDirectories *GitDirectoriesGenerator `json:"gitDirectories,omitempty"`
+}
+
+// SubscriptionTemplateMeta is the metadata of the generated subscriptions
+type SubscriptionTemplateMeta struct {
+	// The name of the generated subscriptions, the subscription set name by default
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// The namespace of the generated subscriptions, created if it doesn't exist. <set name>-<parameter set slug> by
+	// default, like <set name>-{{branch_slug}}
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// SubscriptionTemplate is the template of the generated subscriptions. The parameters of the generators, like
+// {{branch}}, {{cluster}} or {{path}}, are replaced in all its strings
+type SubscriptionTemplate struct {
+	// +optional
+	Metadata SubscriptionTemplateMeta `json:"metadata,omitempty"`
+
+	Spec appv1.SubscriptionSpec `json:"spec"`
+}
+
+// SubscriptionSetSpec defines the generators and the template of the generated subscriptions
+type SubscriptionSetSpec struct {
+	// +kubebuilder:validation:MinItems=1
+	Generators []SubscriptionSetGenerator `json:"generators"`
+
+	Template SubscriptionTemplate `json:"template"`
+}
+
+// GeneratedSubscription is a subscription generated by the subscription set
+type GeneratedSubscription struct {
+	// The Git branch or pull request head reference the subscription is generated for
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// The Git directory the subscription is generated for
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// The managed cluster the subscription is generated for
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// The name or the index of the list element the subscription is generated for
+	// +optional
+	Element string `json:"element,omitempty"`
+
+	// The latest commit of the branch or of the directory revision
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	Namespace string `json:"namespace"`
+
+	Name string `json:"name"`
+}
+
+// SubscriptionSetStatus lists the generated subscriptions
+type SubscriptionSetStatus struct {
+	// +optional
+	Subscriptions []GeneratedSubscription `json:"subscriptions,omitempty"`
+
+	// The errors of the last generation
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// SubscriptionSet generates a subscription per parameter set of its generators, for example a preview environment
+// per branch of a Git repository. The generated subscriptions are deleted when their parameter set is gone.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope="Namespaced"
+// +kubebuilder:resource:shortName=appsubset
+// +kubebuilder:printcolumn:name="Updated",type="date",JSONPath=`.status.lastUpdateTime`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SubscriptionSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SubscriptionSetSpec `json:"spec"`
+
+	// +optional
+	Status SubscriptionSetStatus `json:"status,omitempty"`
+}
+
+// SubscriptionSetList contains a list of SubscriptionSet
+// +kubebuilder:object:root=true
+type SubscriptionSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SubscriptionSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SubscriptionSet{}, &SubscriptionSetList{})
+}
@@ -24,6 +24,10 @@ import (
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope="Namespaced"
 // +kubebuilder:resource:shortName=appsubstatus
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.statuses.subscription.phase`
+// +kubebuilder:printcolumn:name="Commit",type=string,JSONPath=`.statuses.subscription.commit`,priority=1
+// +kubebuilder:printcolumn:name="Updated",type="date",JSONPath=`.statuses.subscription.lastUpdateTime`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type SubscriptionStatus struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -44,6 +48,84 @@ type SubscriptionClusterStatusMap struct {
 	SubscriptionPackageStatus []SubscriptionUnitStatus `json:"packages,omitempty"`
 
 	SubscriptionStatus SubscriptionOverallStatus `json:"subscription,omitempty"`
+
+	// History of the changes applied on the managed cluster, most recent first. The history is capped.
+	// +optional
+	History []SubscriptionAuditRecord `json:"history,omitempty"`
+
+	// Provenance of the resources most recently deployed on the managed cluster without errors.
+	// +optional
+	Provenance *SubscriptionProvenance `json:"provenance,omitempty"`
+}
+
+// SubscriptionProvenance records where the resources deployed by a subscription on the managed cluster come from, for
+// the supply chain audits.
+type SubscriptionProvenance struct {
+	// Source is the URL of the Git repository, Helm repository or object bucket the resources are deployed from.
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// Revision is the Git commit, chart version or object etag deployed.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Charts are the Helm charts deployed, as "<chart name>:<chart version>".
+	// +optional
+	Charts []string `json:"charts,omitempty"`
+
+	// Images are the container images of the deployed resources.
+	// +optional
+	Images []string `json:"images,omitempty"`
+
+	// Digest is the sha256 digest of the rendered resources deployed, as "sha256:<hex>".
+	Digest string `json:"digest"`
+
+	// Timestamp of when the provenance last changed.
+	Time metav1.Time `json:"time"`
+}
+
+// SubscriptionTrigger defines what triggered a subscription reconcile. The supported triggers are "Interval",
+// "Webhook" and "Manual".
+type SubscriptionTrigger string
+
+const (
+	// TriggerInterval represents a periodic reconcile or a subscription change
+	TriggerInterval SubscriptionTrigger = "Interval"
+
+	// TriggerWebhook represents a reconcile triggered by a Git webhook event
+	TriggerWebhook SubscriptionTrigger = "Webhook"
+
+	// TriggerManual represents a reconcile triggered by the manual refresh annotation
+	TriggerManual SubscriptionTrigger = "Manual"
+)
+
+// SubscriptionAuditRecord records the changes applied by a subscription reconcile on the managed cluster.
+// The resources are listed as "<kind> <namespace>/<name>".
+type SubscriptionAuditRecord struct {
+	// Commit is the Git commit, chart version or object etag applied.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// Trigger of the reconcile (Interval/Webhook/Manual).
+	Trigger SubscriptionTrigger `json:"trigger,omitempty"`
+
+	// Phase of the overall subscription status after the reconcile (deployed/failed).
+	Phase SubscriptionPhase `json:"phase,omitempty"`
+
+	// Resources created by the reconcile.
+	// +optional
+	Added []string `json:"added,omitempty"`
+
+	// Resources re-applied for a new commit.
+	// +optional
+	Changed []string `json:"changed,omitempty"`
+
+	// Resources removed from the managed cluster because they are no longer in the subscription.
+	// +optional
+	Pruned []string `json:"pruned,omitempty"`
+
+	// Timestamp of the reconcile.
+	Time metav1.Time `json:"time"`
 }
 
 // SubscriptionUnitStatus provides the status of a single deployment package.
@@ -66,8 +148,21 @@ type SubscriptionUnitStatus struct {
 	// Informational message or error output from the deployment of the package.
 	Message string `json:"message,omitempty"`
 
+	// Field is the path of the fields of the deployment package the error is about, when the API server reports them.
+	// +optional
+	Field string `json:"field,omitempty"`
+
+	// Images are the container images of the deployment package resolved with the registries, updated by the image
+	// update policy or pinned to their digests, like quay.io/org/app:1.2.0@sha256:<digest>.
+	// +optional
+	Images []string `json:"images,omitempty"`
+
 	// Timestamp of when the deployment package was last updated.
 	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
+
+	// Timestamp of when the phase of the deployment package last changed.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // SubscriptionOverallStatus provides the overall status of the subscription. It is computed using the status of
@@ -79,12 +174,16 @@ type SubscriptionOverallStatus struct {
 	// Informational message or error output from the overall subscription status.
 	Message string `json:"message,omitempty"`
 
+	// Commit is the Git commit, chart version or object etag most recently applied on the managed cluster.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
 	// Timestamp of when the overall subscription status was last updated.
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
-// PackagePhase defines the phase of a deployment package. The supported phases are "", "Deployed", "Failed", and
-// "PropagationFailed".
+// PackagePhase defines the phase of a deployment package. The supported phases are "", "Deployed", "Failed",
+// "PropagationFailed" and "Skipped".
 type PackagePhase string
 
 const (
@@ -99,6 +198,10 @@ const (
 
 	// PackagePropagationFailed represents the status of a package that failed to propagate to the managed cluster
 	PackagePropagationFailed PackagePhase = "PropagationFailed"
+
+	// PackageSkipped represents the status of a package that is not deployed because of the allow and deny lists of
+	// the subscription, or because another package of the subscription violates the admission policies of the cluster
+	PackageSkipped PackagePhase = "Skipped"
 )
 
 // SubscriptionPhase defines the phase of the overall subscription. The supported phases are "", "Deployed", and "Failed".
@@ -163,6 +266,10 @@ type SubscriptionReportResult struct {
 
 	// Result indicates the outcome (deployed/failed/propagationFailed) of the subscription deployment.
 	Result SubscriptionResult `json:"result,omitempty"`
+
+	// Commit is the Git commit, chart version or object etag most recently applied on the managed cluster.
+	// +optional
+	Commit string `json:"commit,omitempty"`
 }
 
 // SubscriptionReportType indicates the type of the subscription report. It could have one of the following values:
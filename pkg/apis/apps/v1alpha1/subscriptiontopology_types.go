@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TopologyHealth indicates the health of a deployed resource. It could have one of the following values:
+//   - Healthy: the resource is deployed on the cluster
+//   - Degraded: the resource failed to deploy or to propagate to the cluster
+//   - Progressing: the resource is being deployed on the cluster
+//   - Unknown: the health of the resource is not reported
+//
+// +kubebuilder:validation:Enum=Healthy;Degraded;Progressing;Unknown
+type TopologyHealth string
+
+const (
+	// TopologyHealthy means the resource is deployed on the cluster
+	TopologyHealthy TopologyHealth = "Healthy"
+	// TopologyDegraded means the resource failed to deploy or to propagate to the cluster
+	TopologyDegraded TopologyHealth = "Degraded"
+	// TopologyProgressing means the resource is being deployed on the cluster
+	TopologyProgressing TopologyHealth = "Progressing"
+	// TopologyUnknown means the health of the resource is not reported
+	TopologyUnknown TopologyHealth = "Unknown"
+)
+
+// SubscriptionTopologyEntry is a resource deployed by the subscription on a cluster.
+type SubscriptionTopologyEntry struct {
+	// APIVersion of the resource
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the resource
+	Kind string `json:"kind"`
+
+	// Name of the resource
+	Name string `json:"name"`
+
+	// Namespace of the resource, empty for the cluster scoped resources
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Cluster the resource is deployed to, empty for the hook resources deployed on the hub
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// Health of the resource on the cluster
+	Health TopologyHealth `json:"health"`
+}
+
+// SubscriptionTopology lists the resources deployed by a subscription and their health on every cluster. The topology
+// of a subscription is split in pages, the first page is named after the subscription and the next pages are named
+// <subscription>.<page>.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Subscription",type=string,JSONPath=`.subscription`
+// +kubebuilder:printcolumn:name="Page",type=integer,JSONPath=`.page`
+// +kubebuilder:printcolumn:name="TotalPages",type=integer,JSONPath=`.totalPages`
+// +kubebuilder:printcolumn:name="TotalEntries",type=integer,JSONPath=`.totalEntries`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:shortName=appsubtopo
+type SubscriptionTopology struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Subscription is the name of the subscription in the same namespace
+	Subscription string `json:"subscription"`
+
+	// Page is the number of this page, starting at 1
+	Page int32 `json:"page"`
+
+	// TotalPages is the number of pages of the subscription topology
+	TotalPages int32 `json:"totalPages"`
+
+	// TotalEntries is the number of entries in all the pages of the subscription topology
+	TotalEntries int32 `json:"totalEntries"`
+
+	// Entries of this page, sorted by cluster, kind, namespace and name
+	// +optional
+	Entries []SubscriptionTopologyEntry `json:"entries,omitempty"`
+}
+
+// SubscriptionTopologyList contains a list of SubscriptionTopologies.
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SubscriptionTopologyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SubscriptionTopology `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SubscriptionTopology{}, &SubscriptionTopologyList{})
+}
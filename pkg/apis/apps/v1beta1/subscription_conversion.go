@@ -0,0 +1,183 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// ConvertTo converts the v1beta1 subscription to the v1 hub version. The typed fields are stored as annotations.
+func (src *Subscription) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*appv1.Subscription)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+	dst.Status = in.Status
+
+	dst.Spec = appv1.SubscriptionSpec{
+		Channel:                           in.Spec.Channel,
+		SecondaryChannel:                  in.Spec.SecondaryChannel,
+		FallbackChannels:                  in.Spec.FallbackChannels,
+		Package:                           in.Spec.Package,
+		PackageFilter:                     in.Spec.PackageFilter,
+		PackageOverrides:                  in.Spec.PackageOverrides,
+		Placement:                         in.Spec.Placement,
+		Overrides:                         in.Spec.Overrides,
+		KustomizeOverlays:                 in.Spec.KustomizeOverlays,
+		TimeWindow:                        in.Spec.TimeWindow,
+		DeploymentWindowRef:               in.Spec.DeploymentWindowRef,
+		HookSecretRef:                     in.Spec.HookSecretRef,
+		Allow:                             in.Spec.Allow,
+		Deny:                              in.Spec.Deny,
+		WatchHelmNamespaceScopedResources: in.Spec.WatchHelmNamespaceScopedResources,
+		ReconcileInterval:                 in.Spec.ReconcileInterval,
+		DeletionPolicy:                    in.Spec.DeletionPolicy,
+		ExpireAfter:                       in.Spec.ExpireAfter,
+		NamespaceCreation:                 in.Spec.NamespaceCreation,
+		NamespaceTemplate:                 in.Spec.NamespaceTemplate,
+		ServiceAccountName:                in.Spec.ServiceAccountName,
+		TargetNamespaces:                  in.Spec.TargetNamespaces,
+	}
+
+	annotations := dst.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	if git := in.Spec.Git; git != nil {
+		setAnnotation(annotations, appv1.AnnotationGitBranch, git.Branch)
+		setAnnotation(annotations, appv1.AnnotationGitPath, git.Path)
+		setAnnotation(annotations, appv1.AnnotationGitTargetCommit, git.DesiredCommit)
+		setAnnotation(annotations, appv1.AnnotationGitTag, git.DesiredTag)
+
+		if git.CloneDepth > 0 {
+			annotations[appv1.AnnotationGitCloneDepth] = strconv.Itoa(git.CloneDepth)
+		}
+	}
+
+	setAnnotation(annotations, appv1.AnnotationResourceReconcileLevel, in.Spec.ReconcileRate)
+	setAnnotation(annotations, appv1.AnnotationResourceReconcileOption, in.Spec.ReconcileOption)
+
+	if in.Spec.ClusterAdmin {
+		annotations[appv1.AnnotationClusterAdmin] = "true"
+	}
+
+	if len(annotations) > 0 {
+		dst.SetAnnotations(annotations)
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the v1 hub version to the v1beta1 subscription. The annotations promoted to typed fields
+// are removed from the metadata.
+func (dst *Subscription) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*appv1.Subscription)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+	dst.Status = in.Status
+
+	dst.Spec = SubscriptionSpec{
+		Channel:                           in.Spec.Channel,
+		SecondaryChannel:                  in.Spec.SecondaryChannel,
+		FallbackChannels:                  in.Spec.FallbackChannels,
+		Package:                           in.Spec.Package,
+		PackageFilter:                     in.Spec.PackageFilter,
+		PackageOverrides:                  in.Spec.PackageOverrides,
+		Placement:                         in.Spec.Placement,
+		Overrides:                         in.Spec.Overrides,
+		KustomizeOverlays:                 in.Spec.KustomizeOverlays,
+		TimeWindow:                        in.Spec.TimeWindow,
+		DeploymentWindowRef:               in.Spec.DeploymentWindowRef,
+		HookSecretRef:                     in.Spec.HookSecretRef,
+		Allow:                             in.Spec.Allow,
+		Deny:                              in.Spec.Deny,
+		WatchHelmNamespaceScopedResources: in.Spec.WatchHelmNamespaceScopedResources,
+		ReconcileInterval:                 in.Spec.ReconcileInterval,
+		DeletionPolicy:                    in.Spec.DeletionPolicy,
+		ExpireAfter:                       in.Spec.ExpireAfter,
+		NamespaceCreation:                 in.Spec.NamespaceCreation,
+		NamespaceTemplate:                 in.Spec.NamespaceTemplate,
+		ServiceAccountName:                in.Spec.ServiceAccountName,
+		TargetNamespaces:                  in.Spec.TargetNamespaces,
+	}
+
+	annotations := dst.GetAnnotations()
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	git := &GitSubscription{
+		Branch:        popAnnotation(annotations, appv1.AnnotationGitBranch, appv1.AnnotationGithubBranch),
+		Path:          popAnnotation(annotations, appv1.AnnotationGitPath, appv1.AnnotationGithubPath),
+		DesiredCommit: popAnnotation(annotations, appv1.AnnotationGitTargetCommit),
+		DesiredTag:    popAnnotation(annotations, appv1.AnnotationGitTag),
+	}
+
+	// an invalid clone depth is kept as an annotation so that it survives a round trip
+	if depth, err := strconv.Atoi(annotations[appv1.AnnotationGitCloneDepth]); err == nil && depth > 0 {
+		git.CloneDepth = depth
+
+		delete(annotations, appv1.AnnotationGitCloneDepth)
+	}
+
+	if *git != (GitSubscription{}) {
+		dst.Spec.Git = git
+	}
+
+	dst.Spec.ReconcileRate = strings.ToLower(popAnnotation(annotations, appv1.AnnotationResourceReconcileLevel))
+	dst.Spec.ReconcileOption = popAnnotation(annotations, appv1.AnnotationResourceReconcileOption)
+
+	if strings.EqualFold(annotations[appv1.AnnotationClusterAdmin], "true") {
+		dst.Spec.ClusterAdmin = true
+
+		delete(annotations, appv1.AnnotationClusterAdmin)
+	}
+
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	dst.SetAnnotations(annotations)
+
+	return nil
+}
+
+func setAnnotation(annotations map[string]string, key, value string) {
+	if value != "" {
+		annotations[key] = value
+	}
+}
+
+// popAnnotation removes the keys from the annotations and returns the first non empty value
+func popAnnotation(annotations map[string]string, keys ...string) string {
+	value := ""
+
+	for _, key := range keys {
+		if value == "" {
+			value = annotations[key]
+		}
+
+		delete(annotations, key)
+	}
+
+	return value
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestConvertFromV1(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	src := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "appsub",
+			Namespace: "default",
+			Annotations: map[string]string{
+				appv1.AnnotationGithubBranch:            "main",
+				appv1.AnnotationGitPath:                 "examples/git-simple-sub",
+				appv1.AnnotationGitTargetCommit:         "e1f4a3c",
+				appv1.AnnotationGitCloneDepth:           "20",
+				appv1.AnnotationResourceReconcileLevel:  "Low",
+				appv1.AnnotationResourceReconcileOption: appv1.ReplaceReconcile,
+				appv1.AnnotationClusterAdmin:            "true",
+				appv1.AnnotationHosting:                 "default/hosting",
+			},
+		},
+		Spec: appv1.SubscriptionSpec{
+			Channel: "ns-ch/git",
+		},
+	}
+
+	dst := &Subscription{}
+	g.Expect(dst.ConvertFrom(src)).To(gomega.Succeed())
+
+	g.Expect(dst.Spec.Channel).To(gomega.Equal("ns-ch/git"))
+	g.Expect(dst.Spec.Git).To(gomega.Equal(&GitSubscription{
+		Branch:        "main",
+		Path:          "examples/git-simple-sub",
+		DesiredCommit: "e1f4a3c",
+		CloneDepth:    20,
+	}))
+	g.Expect(dst.Spec.ReconcileRate).To(gomega.Equal("low"))
+	g.Expect(dst.Spec.ReconcileOption).To(gomega.Equal(appv1.ReplaceReconcile))
+	g.Expect(dst.Spec.ClusterAdmin).To(gomega.BeTrue())
+	g.Expect(dst.GetAnnotations()).To(gomega.Equal(map[string]string{appv1.AnnotationHosting: "default/hosting"}))
+
+	// the source is not modified
+	g.Expect(src.GetAnnotations()).To(gomega.HaveLen(8))
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	src := &Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "appsub",
+			Namespace:   "default",
+			Annotations: map[string]string{appv1.AnnotationGitCloneDepth: "invalid"},
+		},
+		Spec: SubscriptionSpec{
+			Channel: "ns-ch/git",
+			Git: &GitSubscription{
+				Branch:     "main",
+				DesiredTag: "v1.0.0",
+			},
+			ReconcileRate:      "high",
+			ReconcileInterval:  &metav1.Duration{Duration: 30 * time.Second},
+			DeletionPolicy:     appv1.DeletionPolicyOrphan,
+			ExpireAfter:        &metav1.Duration{Duration: time.Hour},
+			NamespaceCreation:  appv1.NamespaceCreationNever,
+			ServiceAccountName: "app-deployer",
+			TargetNamespaces:   []string{"payments-db"},
+		},
+	}
+
+	hub := &appv1.Subscription{}
+	g.Expect(src.ConvertTo(hub)).To(gomega.Succeed())
+	g.Expect(hub.Spec.ReconcileInterval).To(gomega.Equal(&metav1.Duration{Duration: 30 * time.Second}))
+	g.Expect(hub.Spec.DeletionPolicy).To(gomega.Equal(appv1.DeletionPolicyOrphan))
+	g.Expect(hub.Spec.ExpireAfter).To(gomega.Equal(&metav1.Duration{Duration: time.Hour}))
+	g.Expect(hub.Spec.NamespaceCreation).To(gomega.Equal(appv1.NamespaceCreationNever))
+	g.Expect(hub.Spec.ServiceAccountName).To(gomega.Equal("app-deployer"))
+	g.Expect(hub.Spec.TargetNamespaces).To(gomega.Equal([]string{"payments-db"}))
+
+	g.Expect(hub.GetAnnotations()).To(gomega.Equal(map[string]string{
+		appv1.AnnotationGitBranch:              "main",
+		appv1.AnnotationGitTag:                 "v1.0.0",
+		appv1.AnnotationGitCloneDepth:          "invalid",
+		appv1.AnnotationResourceReconcileLevel: "high",
+	}))
+
+	dst := &Subscription{}
+	g.Expect(dst.ConvertFrom(hub)).To(gomega.Succeed())
+	g.Expect(dst).To(gomega.Equal(src))
+}
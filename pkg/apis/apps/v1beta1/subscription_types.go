@@ -0,0 +1,185 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	plrv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// GitSubscription defines the Git repository settings of a subscription
+type GitSubscription struct {
+	// The Git branch to subscribe to. Replaces the apps.open-cluster-management.io/git-branch annotation
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// The path of the resources in the Git repository. Replaces the apps.open-cluster-management.io/git-path annotation
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// The Git commit to deploy. Replaces the apps.open-cluster-management.io/git-desired-commit annotation
+	// +optional
+	DesiredCommit string `json:"desiredCommit,omitempty"`
+
+	// The Git tag to deploy. Replaces the apps.open-cluster-management.io/git-tag annotation
+	// +optional
+	DesiredTag string `json:"desiredTag,omitempty"`
+
+	// The Git clone depth used to check out previous commits. Replaces the apps.open-cluster-management.io/git-clone-depth annotation
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CloneDepth int `json:"cloneDepth,omitempty"`
+}
+
+// SubscriptionSpec defines the desired state of a subscription
+type SubscriptionSpec struct {
+	// The primary channel namespaced name used by the subscription. Its format is "<channel NameSpace>/<channel Name>"
+	Channel string `json:"channel"`
+	// The secondary channel will be applied if the primary channel fails to connect
+	SecondaryChannel string `json:"secondaryChannel,omitempty"`
+	// The fallback channels are tried in order if neither the primary nor the secondary channel can be connected.
+	// Their format is "<channel NameSpace>/<channel Name>". Git channels only
+	// +optional
+	FallbackChannels []string `json:"fallbackChannels,omitempty"`
+	// Subscribe a package by its package name
+	Package string `json:"name,omitempty"`
+	// Subscribe packages by a package filter
+	PackageFilter *appv1.PackageFilter `json:"packageFilter,omitempty"`
+	// Override packages
+	PackageOverrides []*appv1.Overrides `json:"packageOverrides,omitempty"`
+	// Specify a placement reference for selecting clusters. Hub use only
+	Placement *plrv1alpha1.Placement `json:"placement,omitempty"`
+	// Specify overrides when applied to clusters. Hub use only
+	Overrides []appv1.ClusterOverrides `json:"overrides,omitempty"`
+	// Specify the kustomize overlay deployed to the clusters matching a label selector. The path of the first
+	// matching overlay replaces the Git path of the subscription on the cluster. Hub use only
+	// +optional
+	KustomizeOverlays []appv1.KustomizeOverlay `json:"kustomizeOverlays,omitempty"`
+	// Specify a time window to indicate when the subscription is handled
+	TimeWindow *appv1.TimeWindow `json:"timewindow,omitempty"`
+	// Specify a shared deployment window to indicate when the subscription is handled. The timewindow of the
+	// subscription takes precedence over the deployment window, unless the deployment window is enforced. Hub use only
+	// +optional
+	DeploymentWindowRef *appv1.DeploymentWindowReference `json:"deploymentWindowRef,omitempty"`
+
+	// Specify a secret reference used in Ansible job integration authentication
+	// +optional
+	HookSecretRef *corev1.ObjectReference `json:"hooksecretref,omitempty"`
+
+	// Specify a list of resources allowed for deployment
+	Allow []*appv1.AllowDenyItem `json:"allow,omitempty"`
+
+	// Specify a list of resources denied for deployment
+	Deny []*appv1.AllowDenyItem `json:"deny,omitempty"`
+
+	// WatchHelmNamespaceScopedResources is used to enable watching namespace scope Helm chart resources
+	WatchHelmNamespaceScopedResources bool `json:"watchHelmNamespaceScopedResources,omitempty"`
+
+	// Specify the Git repository settings of the subscription
+	// +optional
+	Git *GitSubscription `json:"git,omitempty"`
+
+	// Specify how often the subscription reconciles the channel. Replaces the apps.open-cluster-management.io/reconcile-rate annotation
+	// +kubebuilder:validation:Enum=off;low;medium;high
+	// +optional
+	ReconcileRate string `json:"reconcileRate,omitempty"`
+
+	// Specify the interval of the periodic reconcile of the subscription resources on the managed clusters, at least
+	// 15s. It overrides the reconcile-interval annotation of the channel and the interval of the reconcile rate, the
+	// reconcile rate off still disables the periodic reconcile
+	// +optional
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+
+	// Specify what happens to the resources deployed on the managed clusters when the subscription is deleted. They
+	// are deleted with Delete and kept with Orphan, whatever the channel type, and the subscription is only removed
+	// once the managed clusters completed the cleanup. The cleanup depends on the channel type if it is not set
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	DeletionPolicy appv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Specify the time to live of the subscription from its creation, the subscription is deleted with its deployed
+	// resources once it expires. The expire-at annotation sets an absolute expiry time instead
+	// +optional
+	ExpireAfter *metav1.Duration `json:"expireAfter,omitempty"`
+
+	// Specify whether the namespaces of the deployed resources are created on the managed clusters if they don't
+	// exist. They are created with Auto, the resources of a missing namespace fail to deploy with Never. Auto by default
+	// +kubebuilder:validation:Enum=Auto;Never
+	// +optional
+	NamespaceCreation appv1.NamespaceCreationPolicy `json:"namespaceCreation,omitempty"`
+
+	// Specify the labels and annotations of the namespaces created by the subscription on the managed clusters, like
+	// the pod security admission labels
+	// +optional
+	NamespaceTemplate *appv1.NamespaceTemplate `json:"namespaceTemplate,omitempty"`
+
+	// Specify the service account in the subscription namespace on the managed clusters that applies the deployed
+	// resources, instead of the application manager. The ServiceAccounts, Roles and RoleBindings of the subscription
+	// namespace are applied by the application manager first, so the service account and its RBAC can be deployed
+	// with the application
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Specify the namespaces other than the subscription namespace that the resources of a subscription that is not a
+	// cluster admin can be deployed to. The creator of the subscription must be allowed to create subscriptions in each
+	// namespace, the resources of the other namespaces are deployed to the subscription namespace
+	// +optional
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+
+	// Specify how the deployed resources are reconciled. Replaces the apps.open-cluster-management.io/reconcile-option annotation
+	// +kubebuilder:validation:Enum=merge;replace;mergeAndOwn
+	// +optional
+	ReconcileOption string `json:"reconcileOption,omitempty"`
+
+	// Specify the subscription deploys resources with cluster admin access. Replaces the apps.open-cluster-management.io/cluster-admin annotation
+	// +optional
+	ClusterAdmin bool `json:"clusterAdmin,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// Subscription is the Schema for the subscriptions API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SubscriptionState",type="string",JSONPath=".status.phase",description="subscription state"
+// +kubebuilder:printcolumn:name="AppstatusReference",type="string",JSONPath=".status.appstatusReference",description="subscription status reference"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:printcolumn:name="Updated",type="date",JSONPath=".status.lastUpdateTime"
+// +kubebuilder:printcolumn:name="Local placement",type="boolean",JSONPath=".spec.placement.local"
+// +kubebuilder:printcolumn:name="Time window",type="string",JSONPath=".spec.timewindow.windowtype"
+// +kubebuilder:resource:shortName=appsub
+type Subscription struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SubscriptionSpec         `json:"spec"`
+	Status appv1.SubscriptionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SubscriptionList contains a list of Subscription
+type SubscriptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Subscription `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Subscription{}, &SubscriptionList{})
+}
@@ -0,0 +1,237 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubcli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func newTestSubscription() *appv1.Subscription {
+	return &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "appsub",
+			Namespace: "default",
+		},
+		Spec: appv1.SubscriptionSpec{
+			Channel: "ch-ns/ch-git",
+		},
+	}
+}
+
+func TestPrintStatus(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := newTestSubscription()
+	sub.Status.Phase = appv1.SubscriptionPropagated
+	sub.Status.Summary = &appv1.SubscriptionRolloutSummary{
+		Clusters: 2,
+		Deployed: 1,
+		Failed:   1,
+		ClusterStatuses: []appv1.SubscriptionClusterRolloutStatus{
+			{Cluster: "cluster2", Result: "failed", Commit: "bbb"},
+			{Cluster: "cluster1", Result: "deployed", Commit: "aaa"},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	g.Expect(PrintStatus(out, sub, nil)).To(gomega.Succeed())
+	g.Expect(out.String()).To(gomega.ContainSubstring("default/appsub"))
+	g.Expect(out.String()).To(gomega.ContainSubstring("2 (deployed: 1, in progress: 0, failed: 1"))
+	g.Expect(out.String()).To(gomega.MatchRegexp(`(?s)cluster1\s+deployed\s+aaa\s+cluster2\s+failed\s+bbb`))
+
+	// the subscription report is used until the hub sets the rollout summary
+	sub.Status.Summary = nil
+	report := &appv1alpha1.SubscriptionReport{
+		Summary: appv1alpha1.SubscriptionReportSummary{Clusters: "1", Deployed: "1"},
+		Results: []*appv1alpha1.SubscriptionReportResult{
+			{Source: "cluster3", Result: "deployed", Commit: "ccc"},
+		},
+	}
+
+	out.Reset()
+	g.Expect(PrintStatus(out, sub, report)).To(gomega.Succeed())
+	g.Expect(out.String()).To(gomega.ContainSubstring("1 (deployed: 1"))
+	g.Expect(out.String()).To(gomega.MatchRegexp(`cluster3\s+deployed\s+ccc`))
+
+	out.Reset()
+	g.Expect(PrintStatus(out, sub, nil)).To(gomega.Succeed())
+	g.Expect(out.String()).To(gomega.ContainSubstring("no status reported yet"))
+}
+
+func TestDiffResource(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	rendered := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "cm", "namespace": "default"},
+		"data":       map[string]interface{}{"key": "value"},
+	}}
+
+	// the fields set by the cluster are ignored
+	live := rendered.DeepCopy()
+	live.SetUID(types.UID("1234"))
+	live.SetResourceVersion("1")
+	live.Object["data"].(map[string]interface{})["extra"] = "ignored"
+
+	diff, err := DiffResource(rendered, live)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(diff).To(gomega.BeEmpty())
+
+	live.Object["data"].(map[string]interface{})["key"] = "changed"
+
+	diff, err = DiffResource(rendered, live)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(diff).To(gomega.ContainSubstring("--- live"))
+	g.Expect(diff).To(gomega.ContainSubstring("-  key: changed"))
+	g.Expect(diff).To(gomega.ContainSubstring("+  key: value"))
+
+	// the missing fields are reported
+	delete(live.Object, "data")
+
+	diff, err = DiffResource(rendered, live)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(diff).To(gomega.ContainSubstring("+data:"))
+}
+
+func TestPrintResources(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	rsc := &unstructured.Unstructured{}
+	rsc.SetAPIVersion("v1")
+	rsc.SetKind("ConfigMap")
+	rsc.SetName("cm")
+
+	out := &bytes.Buffer{}
+	g.Expect(PrintResources(out, []*unstructured.Unstructured{rsc, rsc}, "abc")).To(gomega.Succeed())
+	g.Expect(out.String()).To(gomega.HavePrefix("# commit: abc\n---\n"))
+	g.Expect(bytes.Count(out.Bytes(), []byte("kind: ConfigMap"))).To(gomega.Equal(2))
+}
+
+func TestTrigger(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme, err := NewScheme()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestSubscription()).Build()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	g.Expect(Trigger(context.TODO(), clt, "default", "appsub", now)).To(gomega.Succeed())
+
+	sub := &appv1.Subscription{}
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "appsub"}, sub)).To(gomega.Succeed())
+	g.Expect(sub.GetAnnotations()).To(gomega.HaveKeyWithValue(appv1.AnnotationManualReconcileTime, "2026-10-16T17:00:00Z"))
+
+	g.Expect(Trigger(context.TODO(), clt, "default", "missing", now)).NotTo(gomega.Succeed())
+}
+
+func TestTopology(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme, err := NewScheme()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	page1 := &appv1alpha1.SubscriptionTopology{
+		ObjectMeta:   metav1.ObjectMeta{Name: "appsub", Namespace: "default"},
+		Subscription: "appsub",
+		Page:         1,
+		TotalPages:   2,
+		TotalEntries: 2,
+		Entries: []appv1alpha1.SubscriptionTopologyEntry{
+			{APIVersion: "tower.ansible.com/v1alpha1", Kind: "AnsibleJob", Namespace: "default", Name: "prehook",
+				Health: appv1alpha1.TopologyUnknown},
+		},
+	}
+
+	page2 := page1.DeepCopy()
+	page2.Name = "appsub.2"
+	page2.Page = 2
+	page2.Entries = []appv1alpha1.SubscriptionTopologyEntry{
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "cm", Cluster: "cluster1",
+			Health: appv1alpha1.TopologyHealthy},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(page1, page2).Build()
+
+	entries, err := getTopology(context.TODO(), clt, "default", "appsub")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(entries).To(gomega.HaveLen(2))
+
+	out := &bytes.Buffer{}
+	g.Expect(PrintTopology(out, entries, "")).To(gomega.Succeed())
+	g.Expect(out.String()).To(gomega.MatchRegexp(`\(hub\)\s+tower.ansible.com/v1alpha1\s+AnsibleJob\s+default\s+prehook\s+Unknown`))
+	g.Expect(out.String()).To(gomega.MatchRegexp(`cluster1\s+v1\s+ConfigMap\s+default\s+cm\s+Healthy`))
+
+	out.Reset()
+	g.Expect(PrintTopology(out, entries, "cluster1")).To(gomega.Succeed())
+	g.Expect(out.String()).NotTo(gomega.ContainSubstring("AnsibleJob"))
+
+	_, err = getTopology(context.TODO(), clt, "default", "missing")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestArgoCD(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	objs, err := ImportArgoCD([]byte(`apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    path: guestbook
+  destination:
+    name: cluster1
+`), "default")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(objs).To(gomega.HaveLen(3))
+
+	out := &bytes.Buffer{}
+	g.Expect(PrintObjects(out, objs)).To(gomega.Succeed())
+	g.Expect(out.String()).To(gomega.ContainSubstring("kind: Placement\n"))
+	g.Expect(out.String()).NotTo(gomega.ContainSubstring("creationTimestamp"))
+
+	_, err = ImportArgoCD([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), "default")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the imported resources are exported back from the hub
+	scheme, err := NewScheme()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, obj := range objs {
+		builder = builder.WithRuntimeObjects(obj)
+	}
+
+	app, err := ExportArgoCD(context.TODO(), builder.Build(), "default", "guestbook", "argocd")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	destination, _, _ := unstructured.NestedString(app.(*unstructured.Unstructured).Object, "spec", "destination", "name")
+	g.Expect(destination).To(gomega.Equal("cluster1"))
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubcli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	plrv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/argocd"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+func newArgoCDCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "argocd",
+		Short: "Convert the ArgoCD applications to subscriptions and back",
+	}
+
+	cmd.AddCommand(newArgoCDImportCommand(o), newArgoCDExportCommand(o))
+
+	return cmd
+}
+
+func newArgoCDImportCommand(o *Options) *cobra.Command {
+	var filename string
+
+	cmd := &cobra.Command{
+		Use:   "import -f FILE",
+		Short: "Convert the ArgoCD Applications and ApplicationSets of the file to channels, placements and subscriptions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				data []byte
+				err  error
+			)
+
+			if filename == "-" {
+				data, err = io.ReadAll(o.In)
+			} else {
+				data, err = os.ReadFile(filename) // #nosec G304 the file is given by the user
+			}
+
+			if err != nil {
+				return err
+			}
+
+			namespace, _, err := o.ConfigFlags.ToRawKubeConfigLoader().Namespace()
+			if err != nil {
+				return err
+			}
+
+			objs, err := ImportArgoCD(data, namespace)
+			if err != nil {
+				return err
+			}
+
+			return PrintObjects(o.Out, objs)
+		},
+	}
+
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "The file of the ArgoCD resources, - for the standard input")
+
+	_ = cmd.MarkFlagRequired("filename")
+
+	return cmd
+}
+
+func newArgoCDExportCommand(o *Options) *cobra.Command {
+	var argoNamespace string
+
+	cmd := &cobra.Command{
+		Use:   "export NAME",
+		Short: "Convert the subscription to an ArgoCD Application, or an ApplicationSet if it is deployed on several clusters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clt, namespace, err := o.hubClient()
+			if err != nil {
+				return err
+			}
+
+			obj, err := ExportArgoCD(cmd.Context(), clt, namespace, args[0], argoNamespace)
+			if err != nil {
+				return err
+			}
+
+			return PrintObjects(o.Out, []runtime.Object{obj})
+		},
+	}
+
+	cmd.Flags().StringVar(&argoNamespace, "argocd-namespace", argocd.DefaultArgoNamespace, "The namespace of the ArgoCD applications")
+
+	return cmd
+}
+
+// ImportArgoCD converts the ArgoCD resources of a multi document YAML stream to the hub resources, the resources are
+// created in the default namespace if their destination has no namespace
+func ImportArgoCD(data []byte, defaultNamespace string) ([]runtime.Object, error) {
+	objs := []runtime.Object{}
+
+	for _, doc := range utils.ParseKubeResoures(data) {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, err
+		}
+
+		res, err := argocd.Import(obj, defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		objs = append(objs, res.Objects()...)
+	}
+
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no ArgoCD resource found")
+	}
+
+	return objs, nil
+}
+
+// ExportArgoCD gets the subscription with its channel and placement from the hub and converts them to an ArgoCD
+// resource
+func ExportArgoCD(ctx context.Context, clt client.Client, namespace, name, argoNamespace string) (runtime.Object, error) {
+	sub, err := getSubscription(ctx, clt, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &argocd.Resources{Subscription: sub, Channel: &chnv1.Channel{}}
+
+	if err := clt.Get(ctx, utils.NamespacedNameFormat(sub.Spec.Channel), res.Channel); err != nil {
+		return nil, fmt.Errorf("failed to get the channel %v: %w", sub.Spec.Channel, err)
+	}
+
+	if sub.Spec.Placement != nil && sub.Spec.Placement.PlacementRef != nil {
+		ref := sub.Spec.Placement.PlacementRef
+		key := types.NamespacedName{Namespace: sub.Namespace, Name: ref.Name}
+
+		var placement client.Object
+
+		if ref.Kind == "Placement" {
+			res.Placement = &clusterv1beta1.Placement{}
+			placement = res.Placement
+		} else {
+			res.PlacementRule = &plrv1.PlacementRule{}
+			placement = res.PlacementRule
+		}
+
+		if err := clt.Get(ctx, key, placement); err != nil {
+			return nil, fmt.Errorf("failed to get the placement %v: %w", key, err)
+		}
+	}
+
+	return argocd.Export(res, argoNamespace)
+}
+
+// PrintObjects prints the objects as a multi document YAML stream, without their status and creation timestamp
+func PrintObjects(w io.Writer, objs []runtime.Object) error {
+	for _, obj := range objs {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+
+		delete(content, "status")
+		unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+
+		out, err := yaml.Marshal(content)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "---\n%s", out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appsubcli implements the kubectl appsub plugin, it inspects, renders, diffs and triggers the application
+// subscriptions of the hub, and converts them to and from the ArgoCD applications
+package appsubcli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// Options are the options shared by the kubectl appsub commands
+type Options struct {
+	genericiooptions.IOStreams
+
+	// ConfigFlags are the kubectl flags of the hub connection
+	ConfigFlags *genericclioptions.ConfigFlags
+}
+
+// NewCommand returns the kubectl appsub command
+func NewCommand(streams genericiooptions.IOStreams) *cobra.Command {
+	o := &Options{
+		IOStreams:   streams,
+		ConfigFlags: genericclioptions.NewConfigFlags(true),
+	}
+
+	cmd := &cobra.Command{
+		Use:          "kubectl-appsub",
+		Short:        "Inspect, render, diff and trigger the application subscriptions of the hub",
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			cobra.CommandDisplayNameAnnotation: "kubectl appsub",
+		},
+	}
+
+	o.ConfigFlags.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(newStatusCommand(o), newRenderCommand(o), newDiffCommand(o), newTriggerCommand(o),
+		newTopologyCommand(o), newArgoCDCommand(o))
+
+	return cmd
+}
+
+// NewScheme returns the scheme of the hub resources read by the commands
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	if err := apis.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	return scheme, nil
+}
+
+// hubClient returns the client of the hub and the namespace of the subscriptions
+func (o *Options) hubClient() (client.Client, string, error) {
+	cfg, err := o.ConfigFlags.ToRESTConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, "", err
+	}
+
+	clt, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", err
+	}
+
+	namespace, _, err := o.ConfigFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return clt, namespace, nil
+}
+
+// getSubscription gets the hub subscription
+func getSubscription(ctx context.Context, clt client.Client, namespace, name string) (*appv1.Subscription, error) {
+	sub := &appv1.Subscription{}
+
+	if err := clt.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, sub); err != nil {
+		return nil, fmt.Errorf("failed to get the subscription %v/%v: %w", namespace, name, err)
+	}
+
+	return sub, nil
+}
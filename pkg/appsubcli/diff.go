@@ -0,0 +1,202 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubcli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/ghodss/yaml"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
+)
+
+func newDiffCommand(o *Options) *cobra.Command {
+	var (
+		commit   string
+		contexts []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff NAME --cluster-context CONTEXT",
+		Short: "Diff the rendered resources of the Git subscription against the live managed clusters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(contexts) == 0 {
+				return errors.New("at least one --cluster-context of a managed cluster is required")
+			}
+
+			resources, commitID, err := o.render(cmd.Context(), args[0], commit)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(o.Out, "# commit: %v\n", commitID)
+
+			for _, kubeContext := range contexts {
+				if err := o.diffCluster(cmd.Context(), kubeContext, resources); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&commit, "commit", "", "The Git commit to render, the desired commit or tag of the subscription or the branch head if empty")
+	cmd.Flags().StringArrayVar(&contexts, "cluster-context", nil, "The kubeconfig context of a managed cluster to diff, can be repeated")
+
+	return cmd
+}
+
+// diffCluster diffs the rendered resources against the managed cluster of the kubeconfig context
+func (o *Options) diffCluster(ctx context.Context, kubeContext string, resources []*unstructured.Unstructured) error {
+	flags := genericclioptions.NewConfigFlags(true)
+	flags.KubeConfig = o.ConfigFlags.KubeConfig
+	flags.Context = &kubeContext
+
+	cfg, err := flags.ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get the config of the cluster context %v: %w", kubeContext, err)
+	}
+
+	dynClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	mapper, err := flags.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.Out, "# cluster: %v\n", kubeContext)
+
+	for _, rendered := range resources {
+		gvk := rendered.GroupVersionKind()
+		id := fmt.Sprintf("%v %v", gvk.Kind, rendered.GetName())
+
+		if rendered.GetNamespace() != "" {
+			id = fmt.Sprintf("%v %v/%v", gvk.Kind, rendered.GetNamespace(), rendered.GetName())
+		}
+
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			fmt.Fprintf(o.Out, "%v: unknown kind, %v\n", id, err)
+
+			continue
+		}
+
+		var rsc dynamic.ResourceInterface = dynClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			rsc = dynClient.Resource(mapping.Resource).Namespace(rendered.GetNamespace())
+		}
+
+		live, err := rsc.Get(ctx, rendered.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				fmt.Fprintf(o.Out, "%v: missing\n", id)
+
+				continue
+			}
+
+			return fmt.Errorf("failed to get %v in the cluster context %v: %w", id, kubeContext, err)
+		}
+
+		if err := printDiff(o.Out, id, rendered, live); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printDiff(w io.Writer, id string, rendered, live *unstructured.Unstructured) error {
+	diff, err := DiffResource(rendered, live)
+	if err != nil {
+		return err
+	}
+
+	if diff == "" {
+		fmt.Fprintf(w, "%v: in sync\n", id)
+
+		return nil
+	}
+
+	fmt.Fprintf(w, "%v: changed\n%v", id, diff)
+
+	return nil
+}
+
+// DiffResource returns the unified diff of the live resource against the rendered resource, it is empty if they are
+// in sync. Only the fields set in the rendered resource are compared, the fields defaulted or added by the cluster
+// are ignored. The lists are compared as a whole.
+func DiffResource(rendered, live *unstructured.Unstructured) (string, error) {
+	pruned, _ := pruneFields(live.Object, rendered.Object).(map[string]interface{})
+
+	if reflect.DeepEqual(pruned, rendered.Object) {
+		return "", nil
+	}
+
+	want, err := yaml.Marshal(rendered.Object)
+	if err != nil {
+		return "", err
+	}
+
+	got, err := yaml.Marshal(pruned)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(got)),
+		B:        difflib.SplitLines(string(want)),
+		FromFile: "live",
+		ToFile:   "rendered",
+		Context:  3,
+	})
+}
+
+// pruneFields returns the live value restricted to the map keys set in the rendered value
+func pruneFields(live, rendered interface{}) interface{} {
+	liveMap, ok := live.(map[string]interface{})
+	if !ok {
+		return live
+	}
+
+	renderedMap, ok := rendered.(map[string]interface{})
+	if !ok {
+		return live
+	}
+
+	pruned := map[string]interface{}{}
+
+	for key, value := range renderedMap {
+		if liveValue, ok := liveMap[key]; ok {
+			pruned[key] = pruneFields(liveValue, value)
+		}
+	}
+
+	return pruned
+}
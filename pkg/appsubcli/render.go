@@ -0,0 +1,166 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubcli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	gitsub "open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber/git"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+func newRenderCommand(o *Options) *cobra.Command {
+	var commit string
+
+	cmd := &cobra.Command{
+		Use:   "render NAME",
+		Short: "Render the resources the Git subscription deploys on the managed clusters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resources, commitID, err := o.render(cmd.Context(), args[0], commit)
+			if err != nil {
+				return err
+			}
+
+			return PrintResources(o.Out, resources, commitID)
+		},
+	}
+
+	cmd.Flags().StringVar(&commit, "commit", "", "The Git commit to render, the desired commit or tag of the subscription or the branch head if empty")
+
+	return cmd
+}
+
+// render clones the Git channel of the hub subscription and renders its resources with the git subscriber pipeline
+func (o *Options) render(ctx context.Context, name, commit string) ([]*unstructured.Unstructured, string, error) {
+	hubClient, namespace, err := o.hubClient()
+	if err != nil {
+		return nil, "", err
+	}
+
+	sub, err := getSubscription(ctx, hubClient, namespace, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	subitem, err := getSubscriberItem(ctx, hubClient, sub)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !utils.IsGitChannel(string(subitem.Channel.Spec.Type)) {
+		return nil, "", fmt.Errorf("the channel %v of the subscription is a %v channel, only the Git channels are rendered",
+			sub.Spec.Channel, subitem.Channel.Spec.Type)
+	}
+
+	mapper, err := o.ConfigFlags.ToRESTMapper()
+	if err != nil {
+		return nil, "", err
+	}
+
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The helm releases and the package filter config map are read from an in memory client, the rendering must not
+	// depend on the state of the hub
+	localObjs := []client.Object{}
+
+	if sub.Spec.PackageFilter != nil && sub.Spec.PackageFilter.FilterRef != nil {
+		filterRef := &corev1.ConfigMap{}
+		key := types.NamespacedName{Namespace: sub.Namespace, Name: sub.Spec.PackageFilter.FilterRef.Name}
+
+		if err := hubClient.Get(ctx, key, filterRef); err != nil {
+			return nil, "", fmt.Errorf("failed to get the package filter config map %v: %w", key, err)
+		}
+
+		localObjs = append(localObjs, filterRef)
+	}
+
+	localClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(localObjs...).Build()
+
+	return gitsub.RenderSubscription(subitem, gitsub.RenderOptions{
+		Commit:     commit,
+		Client:     localClient,
+		RESTMapper: mapper,
+	})
+}
+
+// getSubscriberItem gets the channels of the hub subscription with their secrets and config maps
+func getSubscriberItem(ctx context.Context, hubClient client.Client, sub *appv1.Subscription) (*appv1.SubscriberItem, error) {
+	subitem := &appv1.SubscriberItem{Subscription: sub}
+
+	subitem.Channel = &chnv1.Channel{}
+	if err := hubClient.Get(ctx, utils.NamespacedNameFormat(sub.Spec.Channel), subitem.Channel); err != nil {
+		return nil, fmt.Errorf("failed to get the channel %v: %w", sub.Spec.Channel, err)
+	}
+
+	subitem.ChannelSecret, subitem.ChannelConfigMap = utils.FetchChannelReferences(hubClient, *subitem.Channel)
+
+	if sub.Spec.SecondaryChannel != "" {
+		subitem.SecondaryChannel = &chnv1.Channel{}
+		if err := hubClient.Get(ctx, utils.NamespacedNameFormat(sub.Spec.SecondaryChannel), subitem.SecondaryChannel); err != nil {
+			return nil, fmt.Errorf("failed to get the secondary channel %v: %w", sub.Spec.SecondaryChannel, err)
+		}
+
+		subitem.SecondaryChannelSecret, subitem.SecondaryChannelConfigMap = utils.FetchChannelReferences(hubClient,
+			*subitem.SecondaryChannel)
+	}
+
+	for _, chnName := range sub.Spec.FallbackChannels {
+		fallback := appv1.SubscriberChannel{Channel: &chnv1.Channel{}}
+		if err := hubClient.Get(ctx, utils.NamespacedNameFormat(chnName), fallback.Channel); err != nil {
+			return nil, fmt.Errorf("failed to get the fallback channel %v: %w", chnName, err)
+		}
+
+		fallback.Secret, fallback.ConfigMap = utils.FetchChannelReferences(hubClient, *fallback.Channel)
+		subitem.FallbackChannels = append(subitem.FallbackChannels, fallback)
+	}
+
+	return subitem, nil
+}
+
+// PrintResources prints the rendered resources as a multi document YAML stream, headed by the rendered commit
+func PrintResources(w io.Writer, resources []*unstructured.Unstructured, commitID string) error {
+	if _, err := fmt.Fprintf(w, "# commit: %v\n", commitID); err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		out, err := yaml.Marshal(resource.Object)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "---\n%s", out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubcli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func newStatusCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status NAME",
+		Short: "Show the status of the subscription on all its managed clusters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clt, namespace, err := o.hubClient()
+			if err != nil {
+				return err
+			}
+
+			sub, err := getSubscription(cmd.Context(), clt, namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			report := &appv1alpha1.SubscriptionReport{}
+			if err := clt.Get(cmd.Context(), types.NamespacedName{Namespace: namespace, Name: args[0]}, report); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return fmt.Errorf("failed to get the subscription report %v/%v: %w", namespace, args[0], err)
+				}
+
+				report = nil
+			}
+
+			return PrintStatus(o.Out, sub, report)
+		},
+	}
+}
+
+// PrintStatus prints the status of the hub subscription, aggregated across its managed clusters. The per cluster
+// results are taken from the rollout summary of the subscription, or from its application subscription report if the
+// summary is not set yet.
+func PrintStatus(w io.Writer, sub *appv1.Subscription, report *appv1alpha1.SubscriptionReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Subscription:\t%v/%v\n", sub.Namespace, sub.Name)
+	fmt.Fprintf(tw, "Channel:\t%v\n", sub.Spec.Channel)
+	fmt.Fprintf(tw, "Phase:\t%v\n", sub.Status.Phase)
+
+	if sub.Status.Reason != "" {
+		fmt.Fprintf(tw, "Reason:\t%v\n", sub.Status.Reason)
+	}
+
+	if commit := sub.GetAnnotations()[appv1.AnnotationGitCommit]; commit != "" {
+		fmt.Fprintf(tw, "Commit:\t%v\n", commit)
+	}
+
+	if len(sub.Status.Conditions) > 0 {
+		fmt.Fprintln(tw, "\nCONDITION\tSTATUS\tREASON\tMESSAGE")
+
+		for _, cond := range sub.Status.Conditions {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", cond.Type, cond.Status, cond.Reason, cond.Message)
+		}
+	}
+
+	clusters := clusterResults(sub, report)
+
+	switch summary := sub.Status.Summary; {
+	case summary != nil:
+		fmt.Fprintf(tw, "\nClusters:\t%v (deployed: %v, in progress: %v, failed: %v, propagation failed: %v, out of sync: %v)\n",
+			summary.Clusters, summary.Deployed, summary.InProgress, summary.Failed, summary.PropagationFailed, summary.OutOfSync)
+	case report != nil:
+		fmt.Fprintf(tw, "\nClusters:\t%v (deployed: %v, in progress: %v, failed: %v, propagation failed: %v)\n",
+			report.Summary.Clusters, report.Summary.Deployed, report.Summary.InProgress, report.Summary.Failed,
+			report.Summary.PropagationFailed)
+	default:
+		fmt.Fprintln(tw, "\nClusters:\tno status reported yet")
+	}
+
+	if len(clusters) > 0 {
+		fmt.Fprintln(tw, "\nCLUSTER\tRESULT\tCOMMIT")
+
+		for _, cluster := range clusters {
+			fmt.Fprintf(tw, "%v\t%v\t%v\n", cluster.Cluster, cluster.Result, cluster.Commit)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// clusterResults returns the results of the subscription per managed cluster, sorted by cluster name
+func clusterResults(sub *appv1.Subscription, report *appv1alpha1.SubscriptionReport) []appv1.SubscriptionClusterRolloutStatus {
+	var clusters []appv1.SubscriptionClusterRolloutStatus
+
+	if sub.Status.Summary != nil && len(sub.Status.Summary.ClusterStatuses) > 0 {
+		clusters = append(clusters, sub.Status.Summary.ClusterStatuses...)
+	} else if report != nil {
+		for _, result := range report.Results {
+			if result == nil {
+				continue
+			}
+
+			clusters = append(clusters, appv1.SubscriptionClusterRolloutStatus{
+				Cluster: result.Source,
+				Result:  string(result.Result),
+				Commit:  result.Commit,
+			})
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Cluster < clusters[j].Cluster
+	})
+
+	return clusters
+}
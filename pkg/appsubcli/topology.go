@@ -0,0 +1,103 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubcli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func newTopologyCommand(o *Options) *cobra.Command {
+	var cluster string
+
+	cmd := &cobra.Command{
+		Use:   "topology NAME",
+		Short: "List the resources deployed by the subscription and their health on every managed cluster",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clt, namespace, err := o.hubClient()
+			if err != nil {
+				return err
+			}
+
+			entries, err := getTopology(cmd.Context(), clt, namespace, args[0])
+			if err != nil {
+				return err
+			}
+
+			return PrintTopology(o.Out, entries, cluster)
+		},
+	}
+
+	cmd.Flags().StringVar(&cluster, "cluster", "", "only list the resources of this managed cluster")
+
+	return cmd
+}
+
+// getTopology reads all the pages of the subscription topology and returns their entries
+func getTopology(ctx context.Context, clt client.Client,
+	namespace, name string) ([]appv1alpha1.SubscriptionTopologyEntry, error) {
+	var entries []appv1alpha1.SubscriptionTopologyEntry
+
+	totalPages := int32(1)
+
+	for page := int32(1); page <= totalPages; page++ {
+		pageName := name
+		if page > 1 {
+			pageName = fmt.Sprintf("%s.%d", name, page)
+		}
+
+		topology := &appv1alpha1.SubscriptionTopology{}
+		if err := clt.Get(ctx, types.NamespacedName{Namespace: namespace, Name: pageName}, topology); err != nil {
+			return nil, fmt.Errorf("failed to get the subscription topology %v/%v: %w", namespace, pageName, err)
+		}
+
+		totalPages = topology.TotalPages
+		entries = append(entries, topology.Entries...)
+	}
+
+	return entries, nil
+}
+
+// PrintTopology prints the topology entries, only the entries of the cluster if it is set
+func PrintTopology(w io.Writer, entries []appv1alpha1.SubscriptionTopologyEntry, cluster string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CLUSTER\tAPIVERSION\tKIND\tNAMESPACE\tNAME\tHEALTH")
+
+	for _, entry := range entries {
+		if cluster != "" && entry.Cluster != cluster {
+			continue
+		}
+
+		entryCluster := entry.Cluster
+		if entryCluster == "" {
+			entryCluster = "(hub)"
+		}
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			entryCluster, entry.APIVersion, entry.Kind, entry.Namespace, entry.Name, entry.Health)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubcli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func newTriggerCommand(o *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "trigger NAME",
+		Short: "Trigger an immediate reconcile of the subscription on all its managed clusters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clt, namespace, err := o.hubClient()
+			if err != nil {
+				return err
+			}
+
+			if err := Trigger(cmd.Context(), clt, namespace, args[0], time.Now()); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(o.Out, "subscription %v/%v triggered\n", namespace, args[0])
+
+			return nil
+		},
+	}
+}
+
+// Trigger sets the manual refresh time annotation of the hub subscription, the hub propagates it to the managed
+// clusters and the subscribers reconcile the subscription resources right away
+func Trigger(ctx context.Context, clt client.Client, namespace, name string, now time.Time) error {
+	sub, err := getSubscription(ctx, clt, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(sub.DeepCopy())
+
+	annotations := sub.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[appv1.AnnotationManualReconcileTime] = now.UTC().Format(time.RFC3339)
+	sub.SetAnnotations(annotations)
+
+	if err := clt.Patch(ctx, sub, patch); err != nil {
+		return fmt.Errorf("failed to trigger the subscription %v/%v: %w", namespace, name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package argocd
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+
+	plrv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func parse(g *gomega.WithT, doc string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	g.Expect(yaml.Unmarshal([]byte(doc), &obj.Object)).To(gomega.Succeed())
+
+	return obj
+}
+
+const gitApplication = `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+  namespace: argocd
+spec:
+  project: default
+  source:
+    repoURL: https://github.com/argoproj/argocd-example-apps.git
+    targetRevision: main
+    path: helm-guestbook
+    helm:
+      releaseName: guestbook
+      values: |
+        replicaCount: 2
+      parameters:
+      - name: service.type
+        value: NodePort
+  destination:
+    name: cluster1
+    namespace: guestbook
+`
+
+func TestImportApplication(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	res, err := Import(parse(g, gitApplication), "default")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(res.Channel.Name).To(gomega.Equal("github-com-argoproj-argocd-example-apps"))
+	g.Expect(res.Channel.Namespace).To(gomega.Equal("guestbook"))
+	g.Expect(string(res.Channel.Spec.Type)).To(gomega.Equal(chnv1.ChannelTypeGit))
+	g.Expect(res.Channel.Spec.Pathname).To(gomega.Equal("https://github.com/argoproj/argocd-example-apps.git"))
+
+	sub := res.Subscription
+	g.Expect(sub.Namespace).To(gomega.Equal("guestbook"))
+	g.Expect(sub.Spec.Channel).To(gomega.Equal("guestbook/github-com-argoproj-argocd-example-apps"))
+	g.Expect(sub.Annotations).To(gomega.Equal(map[string]string{
+		appv1.AnnotationGitPath:   "helm-guestbook",
+		appv1.AnnotationGitBranch: "main",
+	}))
+	g.Expect(sub.Spec.Placement.PlacementRef.Kind).To(gomega.Equal("Placement"))
+	g.Expect(sub.Spec.Placement.PlacementRef.Name).To(gomega.Equal("guestbook"))
+
+	g.Expect(sub.Spec.PackageOverrides).To(gomega.HaveLen(1))
+	g.Expect(sub.Spec.PackageOverrides[0].PackageName).To(gomega.Equal("helm-guestbook"))
+	g.Expect(sub.Spec.PackageOverrides[0].PackageAlias).To(gomega.Equal("guestbook"))
+	g.Expect(string(sub.Spec.PackageOverrides[0].PackageOverrides[0].Raw)).To(gomega.MatchJSON(
+		`{"path":"spec","value":{"replicaCount":2,"service":{"type":"NodePort"}}}`))
+
+	g.Expect(res.Placement.Spec.Predicates).To(gomega.HaveLen(1))
+	g.Expect(res.Placement.Spec.Predicates[0].RequiredClusterSelector.LabelSelector.MatchLabels).To(gomega.Equal(
+		map[string]string{"name": "cluster1"}))
+
+	// the subscription is exported back to the application
+	obj, err := Export(res, "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(obj.GetKind()).To(gomega.Equal(KindApplication))
+	g.Expect(obj.GetNamespace()).To(gomega.Equal(DefaultArgoNamespace))
+
+	source, _, _ := unstructured.NestedMap(obj.Object, "spec", "source")
+	g.Expect(source).To(gomega.Equal(map[string]interface{}{
+		"repoURL":        "https://github.com/argoproj/argocd-example-apps.git",
+		"path":           "helm-guestbook",
+		"targetRevision": "main",
+		"helm": map[string]interface{}{
+			"releaseName": "guestbook",
+			"valuesObject": map[string]interface{}{
+				"replicaCount": float64(2),
+				"service":      map[string]interface{}{"type": "NodePort"},
+			},
+		},
+	}))
+
+	destination, _, _ := unstructured.NestedMap(obj.Object, "spec", "destination")
+	g.Expect(destination).To(gomega.Equal(map[string]interface{}{"name": "cluster1", "namespace": "guestbook"}))
+}
+
+func TestImportHelmApplication(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	res, err := Import(parse(g, `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: nginx
+spec:
+  source:
+    repoURL: https://charts.bitnami.com/bitnami
+    chart: nginx
+    targetRevision: 15.1.0
+  destination:
+    server: https://kubernetes.default.svc
+`), "web")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(string(res.Channel.Spec.Type)).To(gomega.Equal(chnv1.ChannelTypeHelmRepo))
+	g.Expect(res.Subscription.Namespace).To(gomega.Equal("web"))
+	g.Expect(res.Subscription.Spec.Package).To(gomega.Equal("nginx"))
+	g.Expect(res.Subscription.Spec.PackageFilter.Version).To(gomega.Equal("15.1.0"))
+	g.Expect(res.Placement.Spec.Predicates[0].RequiredClusterSelector.LabelSelector.MatchLabels).To(gomega.Equal(
+		map[string]string{"name": LocalCluster}))
+
+	obj, err := Export(res, "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	server, _, _ := unstructured.NestedString(obj.Object, "spec", "destination", "server")
+	g.Expect(server).To(gomega.Equal(InClusterServer))
+
+	revision, _, _ := unstructured.NestedString(obj.Object, "spec", "source", "targetRevision")
+	g.Expect(revision).To(gomega.Equal("15.1.0"))
+
+	// the destination servers other than the in-cluster one can't be mapped to a cluster
+	_, err = Import(parse(g, `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: nginx
+spec:
+  source:
+    repoURL: https://charts.bitnami.com/bitnami
+    chart: nginx
+  destination:
+    server: https://10.0.0.1:6443
+`), "web")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("set the destination name")))
+}
+
+func TestImportApplicationSet(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	res, err := Import(parse(g, `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: guestbook
+spec:
+  generators:
+  - clusters:
+      selector:
+        matchLabels:
+          env: prod
+  template:
+    metadata:
+      name: '{{name}}-guestbook'
+    spec:
+      source:
+        repoURL: https://github.com/argoproj/argocd-example-apps.git
+        targetRevision: 53e28ff20cc530b9ada2173fbbd64d48338583ba
+        path: guestbook
+      destination:
+        name: '{{name}}'
+        namespace: guestbook
+`), "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(res.Subscription.Annotations).To(gomega.HaveKeyWithValue(appv1.AnnotationGitTargetCommit,
+		"53e28ff20cc530b9ada2173fbbd64d48338583ba"))
+	g.Expect(res.Placement.Spec.Predicates[0].RequiredClusterSelector.LabelSelector.MatchLabels).To(gomega.Equal(
+		map[string]string{"env": "prod"}))
+
+	obj, err := Export(res, "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(obj.GetKind()).To(gomega.Equal(KindApplicationSet))
+
+	generators, _, _ := unstructured.NestedSlice(obj.Object, "spec", "generators")
+	g.Expect(generators).To(gomega.Equal([]interface{}{
+		map[string]interface{}{"clusters": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"env": "prod"}},
+		}},
+	}))
+
+	// the list generator elements are the clusters
+	res, err = Import(parse(g, `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: guestbook
+spec:
+  generators:
+  - list:
+      elements:
+      - cluster: cluster1
+      - cluster: in-cluster
+  template:
+    metadata:
+      name: '{{cluster}}-guestbook'
+    spec:
+      source:
+        repoURL: https://github.com/argoproj/argocd-example-apps.git
+        path: guestbook
+      destination:
+        name: '{{cluster}}'
+        namespace: guestbook
+`), "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(res.Placement.Spec.Predicates[0].RequiredClusterSelector.LabelSelector.MatchExpressions).To(gomega.Equal(
+		[]metav1.LabelSelectorRequirement{
+			{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{"cluster1", LocalCluster}},
+		}))
+
+	obj, err = Export(res, "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	elements, _, _ := unstructured.NestedSlice(obj.Object, "spec", "generators")
+	g.Expect(elements).To(gomega.Equal([]interface{}{
+		map[string]interface{}{"list": map[string]interface{}{"elements": []interface{}{
+			map[string]interface{}{"cluster": "cluster1"},
+			map[string]interface{}{"cluster": "in-cluster"},
+		}}},
+	}))
+
+	// the template parameters are only supported in the destination
+	_, err = Import(parse(g, `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: guestbook
+spec:
+  generators:
+  - clusters: {}
+  template:
+    spec:
+      source:
+        repoURL: https://github.com/argoproj/argocd-example-apps.git
+        path: 'overlays/{{name}}'
+      destination:
+        name: '{{name}}'
+        namespace: guestbook
+`), "")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("template parameters")))
+}
+
+func TestExportPlacementRule(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	res := &Resources{
+		Channel: &chnv1.Channel{Spec: chnv1.ChannelSpec{Type: chnv1.ChannelTypeGit, Pathname: "https://git.example.com/app.git"}},
+		Subscription: &appv1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app",
+				Namespace:   "app-ns",
+				Annotations: map[string]string{appv1.AnnotationGitPath: "deploy", appv1.AnnotationGitTag: "v1.0.0"},
+			},
+			Spec: appv1.SubscriptionSpec{
+				Placement: &plrv1.Placement{PlacementRef: &corev1.ObjectReference{Kind: "PlacementRule", Name: "app"}},
+			},
+		},
+		PlacementRule: &plrv1.PlacementRule{
+			Spec: plrv1.PlacementRuleSpec{GenericPlacementFields: plrv1.GenericPlacementFields{
+				ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}},
+			}},
+		},
+	}
+
+	obj, err := Export(res, "openshift-gitops")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(obj.GetKind()).To(gomega.Equal(KindApplicationSet))
+	g.Expect(obj.GetNamespace()).To(gomega.Equal("openshift-gitops"))
+
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "template", "metadata", "name")
+	g.Expect(name).To(gomega.Equal("app-{{name}}"))
+
+	revision, _, _ := unstructured.NestedString(obj.Object, "spec", "template", "spec", "source", "targetRevision")
+	g.Expect(revision).To(gomega.Equal("v1.0.0"))
+
+	// the overrides other than the helm values can't be exported
+	res.Subscription.Spec.PackageOverrides = []*appv1.Overrides{{PackageName: "busybox"}}
+
+	_, err = Export(res, "")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestChannelName(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(ChannelName("git@github.com:org/repo.git")).To(gomega.Equal("github-com-org-repo"))
+	g.Expect(ChannelName("https://charts.example.com/stable/")).To(gomega.Equal("charts-example-com-stable"))
+}
@@ -0,0 +1,294 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package argocd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+
+	plrv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+const (
+	// DefaultArgoNamespace is the namespace of the ArgoCD applications
+	DefaultArgoNamespace = "argocd"
+
+	// Version is the API version of the ArgoCD resources
+	Version = "v1alpha1"
+)
+
+// Export converts the hub resources of a subscription to an ArgoCD Application if the subscription is deployed on
+// a single cluster, or to an ApplicationSet otherwise. The application is synced automatically with pruning and
+// self healing, like the subscription.
+func Export(res *Resources, argoNamespace string) (*unstructured.Unstructured, error) {
+	sub := res.Subscription
+
+	if argoNamespace == "" {
+		argoNamespace = DefaultArgoNamespace
+	}
+
+	source, err := exportSource(res.Channel, sub)
+	if err != nil {
+		return nil, fmt.Errorf("subscription %v/%v: %w", sub.Namespace, sub.Name, err)
+	}
+
+	clusters, selector, err := getClusters(res)
+	if err != nil {
+		return nil, fmt.Errorf("subscription %v/%v: %w", sub.Namespace, sub.Name, err)
+	}
+
+	spec := map[string]interface{}{
+		"project": "default",
+		"source":  source,
+		"syncPolicy": map[string]interface{}{
+			"automated": map[string]interface{}{"prune": true, "selfHeal": true},
+		},
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(Group + "/" + Version)
+	obj.SetName(sub.Name)
+	obj.SetNamespace(argoNamespace)
+
+	if len(clusters) == 1 {
+		spec["destination"] = newDestination(clusters[0], sub.Namespace)
+
+		obj.SetKind(KindApplication)
+		obj.Object["spec"] = spec
+
+		return obj, nil
+	}
+
+	var generator map[string]interface{}
+
+	parameter := "name"
+
+	if selector != nil {
+		clustersGenerator := map[string]interface{}{}
+
+		if len(selector.MatchLabels) != 0 || len(selector.MatchExpressions) != 0 {
+			clustersGenerator["selector"], err = toUnstructured(selector)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		generator = map[string]interface{}{"clusters": clustersGenerator}
+	} else {
+		parameter = "cluster"
+
+		elements := []interface{}{}
+
+		for _, cluster := range clusters {
+			if cluster == LocalCluster {
+				cluster = inClusterName
+			}
+
+			elements = append(elements, map[string]interface{}{"cluster": cluster})
+		}
+
+		generator = map[string]interface{}{"list": map[string]interface{}{"elements": elements}}
+	}
+
+	spec["destination"] = map[string]interface{}{"name": "{{" + parameter + "}}", "namespace": sub.Namespace}
+
+	obj.SetKind(KindApplicationSet)
+	obj.Object["spec"] = map[string]interface{}{
+		"generators": []interface{}{generator},
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": sub.Name + "-{{" + parameter + "}}"},
+			"spec":     spec,
+		},
+	}
+
+	return obj, nil
+}
+
+// exportSource converts the channel and the subscription package to the application source
+func exportSource(channel *chnv1.Channel, sub *appv1.Subscription) (map[string]interface{}, error) {
+	source := map[string]interface{}{"repoURL": channel.Spec.Pathname}
+	annotations := sub.GetAnnotations()
+	packageName := sub.Spec.Package
+
+	switch {
+	case utils.IsGitChannel(string(channel.Spec.Type)):
+		sourcePath := annotations[appv1.AnnotationGitPath]
+		if sourcePath == "" {
+			sourcePath = annotations[appv1.AnnotationGithubPath]
+		}
+
+		if sourcePath == "" {
+			sourcePath = "."
+		}
+
+		source["path"] = sourcePath
+		source["targetRevision"] = getGitRevision(annotations)
+		packageName = path.Base(sourcePath)
+	case channel.Spec.Type == chnv1.ChannelTypeHelmRepo:
+		source["chart"] = sub.Spec.Package
+		source["targetRevision"] = "*"
+
+		if sub.Spec.PackageFilter != nil && sub.Spec.PackageFilter.Version != "" {
+			source["targetRevision"] = sub.Spec.PackageFilter.Version
+		}
+	default:
+		return nil, fmt.Errorf("the %v channels are not supported", channel.Spec.Type)
+	}
+
+	helm, err := exportHelm(sub, packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(helm) != 0 {
+		source["helm"] = helm
+	}
+
+	return source, nil
+}
+
+// getGitRevision returns the commit, tag or branch of the subscription, in their precedence order
+func getGitRevision(annotations map[string]string) string {
+	for _, annotation := range []string{appv1.AnnotationGitTargetCommit, appv1.AnnotationGitTag,
+		appv1.AnnotationGitBranch, appv1.AnnotationGithubBranch} {
+		if revision := annotations[annotation]; revision != "" {
+			return revision
+		}
+	}
+
+	return "HEAD"
+}
+
+// exportHelm converts the spec overrides of the package helm release to the helm values and release name, the other
+// package overrides can't be converted
+func exportHelm(sub *appv1.Subscription, packageName string) (map[string]interface{}, error) {
+	helm := map[string]interface{}{}
+
+	for _, overrides := range sub.Spec.PackageOverrides {
+		if overrides == nil {
+			continue
+		}
+
+		if overrides.PackageName != packageName || overrides.Target != nil || overrides.PatchType != "" ||
+			len(overrides.PackageOverridesFrom) != 0 {
+			return nil, fmt.Errorf("only the helm values overrides of the package %v are supported", packageName)
+		}
+
+		if overrides.PackageAlias != "" {
+			helm["releaseName"] = overrides.PackageAlias
+		}
+
+		for _, override := range overrides.PackageOverrides {
+			pathValue := struct {
+				Path  string                 `json:"path"`
+				Value map[string]interface{} `json:"value"`
+			}{}
+
+			if err := json.Unmarshal(override.Raw, &pathValue); err != nil || pathValue.Path != "spec" {
+				return nil, fmt.Errorf("only the spec overrides of the package %v are supported", packageName)
+			}
+
+			helm["valuesObject"] = pathValue.Value
+		}
+	}
+
+	return helm, nil
+}
+
+// getClusters returns the clusters of the subscription placement if they are listed, or the cluster selector
+func getClusters(res *Resources) ([]string, *metav1.LabelSelector, error) {
+	placement := res.Subscription.Spec.Placement
+
+	switch {
+	case placement == nil:
+		return nil, nil, fmt.Errorf("the subscription has no placement")
+	case placement.Local != nil && *placement.Local:
+		return []string{LocalCluster}, nil, nil
+	case placement.PlacementRef == nil:
+		return getGenericClusters(placement.Clusters, placement.ClusterSelector)
+	case res.PlacementRule != nil:
+		return getGenericClusters(res.PlacementRule.Spec.Clusters, res.PlacementRule.Spec.ClusterSelector)
+	case res.Placement == nil:
+		return nil, nil, fmt.Errorf("the placement %v is missing", placement.PlacementRef.Name)
+	}
+
+	predicates := res.Placement.Spec.Predicates
+
+	switch {
+	case len(res.Placement.Spec.ClusterSets) != 0 || res.Placement.Spec.NumberOfClusters != nil:
+		return nil, nil, fmt.Errorf("the placements with cluster sets or a number of clusters are not supported")
+	case len(predicates) == 0:
+		return nil, &metav1.LabelSelector{}, nil
+	case len(predicates) > 1 || len(predicates[0].RequiredClusterSelector.ClaimSelector.MatchExpressions) != 0:
+		return nil, nil, fmt.Errorf("only the placements with a single label selector are supported")
+	}
+
+	selector := predicates[0].RequiredClusterSelector.LabelSelector
+
+	// the placements of named clusters, like the imported ones, are converted back to the cluster list
+	if len(selector.MatchLabels) == 1 && len(selector.MatchExpressions) == 0 && selector.MatchLabels[labelClusterName] != "" {
+		return []string{selector.MatchLabels[labelClusterName]}, nil, nil
+	}
+
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 1 {
+		expression := selector.MatchExpressions[0]
+		if expression.Key == labelClusterName && expression.Operator == metav1.LabelSelectorOpIn {
+			return expression.Values, nil, nil
+		}
+	}
+
+	return nil, &selector, nil
+}
+
+// getGenericClusters returns the listed clusters, or the cluster selector of all the clusters if none are listed
+func getGenericClusters(clusters []plrv1.GenericClusterReference,
+	selector *metav1.LabelSelector) ([]string, *metav1.LabelSelector, error) {
+	if len(clusters) == 0 {
+		if selector == nil {
+			selector = &metav1.LabelSelector{}
+		}
+
+		return nil, selector, nil
+	}
+
+	names := []string{}
+	for _, cluster := range clusters {
+		names = append(names, cluster.Name)
+	}
+
+	return names, nil, nil
+}
+
+// newDestination returns the destination of a managed cluster, the local cluster is the in-cluster server
+func newDestination(cluster, namespace string) map[string]interface{} {
+	if cluster == LocalCluster {
+		return map[string]interface{}{"server": InClusterServer, "namespace": namespace}
+	}
+
+	return map[string]interface{}{"name": cluster, "namespace": namespace}
+}
+
+func toUnstructured(obj interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+
+	return out, convert(obj, &out)
+}
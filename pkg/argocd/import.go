@@ -0,0 +1,480 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package argocd converts the ArgoCD Applications and ApplicationSets to the channels, subscriptions and placements
+// of the hub, and back, to migrate the applications between ArgoCD and the subscriptions
+package argocd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+
+	plrv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// Group is the API group of the ArgoCD resources
+	Group = "argoproj.io"
+
+	KindApplication    = "Application"
+	KindApplicationSet = "ApplicationSet"
+
+	// InClusterServer is the destination server of the cluster running ArgoCD
+	InClusterServer = "https://kubernetes.default.svc"
+
+	// LocalCluster is the managed cluster name of the hub
+	LocalCluster = "local-cluster"
+
+	// inClusterName is the destination name of the in-cluster server
+	inClusterName = "in-cluster"
+
+	// labelClusterName is the managed cluster label holding the cluster name
+	labelClusterName = "name"
+)
+
+var (
+	commitRegexp    = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	parameterRegexp = regexp.MustCompile(`^{{\s*\.?([A-Za-z0-9_.-]+)\s*}}$`)
+	nameRegexp      = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Resources are the hub resources of an application, the subscription references the channel and the placement
+type Resources struct {
+	Channel      *chnv1.Channel
+	Subscription *appv1.Subscription
+
+	// Placement selects the managed clusters of the subscription, if it references a Placement
+	Placement *clusterv1beta1.Placement
+
+	// PlacementRule selects the managed clusters of the subscription, if it references a PlacementRule
+	PlacementRule *plrv1.PlacementRule
+}
+
+// Objects returns the resources in their creation order
+func (r *Resources) Objects() []runtime.Object {
+	objs := []runtime.Object{r.Channel}
+
+	if r.Placement != nil {
+		objs = append(objs, r.Placement)
+	}
+
+	if r.PlacementRule != nil {
+		objs = append(objs, r.PlacementRule)
+	}
+
+	return append(objs, r.Subscription)
+}
+
+// Import converts an ArgoCD Application or ApplicationSet to its hub resources. The resources are created in the
+// destination namespace of the application, or in the default namespace if the destination has none, the
+// subscription deploys the resources without namespace in its own namespace.
+func Import(obj *unstructured.Unstructured, defaultNamespace string) (*Resources, error) {
+	if obj.GroupVersionKind().Group != Group {
+		return nil, fmt.Errorf("%v %v is not an ArgoCD resource", obj.GetKind(), obj.GetName())
+	}
+
+	switch obj.GetKind() {
+	case KindApplication:
+		spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+
+		destination, err := getDestinationCluster(spec)
+		if err != nil {
+			return nil, fmt.Errorf("application %v: %w", obj.GetName(), err)
+		}
+
+		placement := newPlacementSpec([]string{destination}, nil)
+
+		return importSpec(obj.GetName(), spec, defaultNamespace, placement)
+	case KindApplicationSet:
+		return importApplicationSet(obj, defaultNamespace)
+	default:
+		return nil, fmt.Errorf("unsupported ArgoCD kind %v", obj.GetKind())
+	}
+}
+
+// importApplicationSet converts the cluster or list generator of the application set to the placement, the template
+// parameters are only supported in the destination
+func importApplicationSet(obj *unstructured.Unstructured, defaultNamespace string) (*Resources, error) {
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+
+	generators, _, _ := unstructured.NestedSlice(obj.Object, "spec", "generators")
+	if len(generators) != 1 {
+		return nil, fmt.Errorf("application set %v: one generator is supported, found %v", obj.GetName(), len(generators))
+	}
+
+	destination, _, _ := unstructured.NestedMap(spec, "destination")
+	delete(spec, "destination")
+
+	if err := checkNoParameters(spec); err != nil {
+		return nil, fmt.Errorf("application set %v: %w", obj.GetName(), err)
+	}
+
+	generator, _ := generators[0].(map[string]interface{})
+
+	var placement clusterv1beta1.PlacementSpec
+
+	switch {
+	case generator["clusters"] != nil:
+		if !isParameter(destination["name"], "name") && !isParameter(destination["server"], "server") {
+			return nil, fmt.Errorf("application set %v: the destination of the clusters generator must be {{name}} or {{server}}",
+				obj.GetName())
+		}
+
+		selector := &metav1.LabelSelector{}
+		clustersGenerator, _ := generator["clusters"].(map[string]interface{})
+
+		if err := convert(clustersGenerator["selector"], selector); err != nil {
+			return nil, fmt.Errorf("application set %v: invalid cluster selector: %w", obj.GetName(), err)
+		}
+
+		placement = newPlacementSpec(nil, selector)
+	case generator["list"] != nil:
+		clusters, err := getListClusters(generator, destination)
+		if err != nil {
+			return nil, fmt.Errorf("application set %v: %w", obj.GetName(), err)
+		}
+
+		placement = newPlacementSpec(clusters, nil)
+	default:
+		return nil, fmt.Errorf("application set %v: only the clusters and list generators are supported", obj.GetName())
+	}
+
+	if namespace, ok := destination["namespace"]; ok {
+		spec["destination"] = map[string]interface{}{"namespace": namespace}
+	}
+
+	return importSpec(obj.GetName(), spec, defaultNamespace, placement)
+}
+
+// getListClusters returns the clusters of the list generator elements, the destination name or server is the
+// parameter of an element key
+func getListClusters(generator, destination map[string]interface{}) ([]string, error) {
+	field := "name"
+
+	key := getParameter(destination["name"])
+	if key == "" {
+		field = "server"
+		key = getParameter(destination["server"])
+	}
+
+	if key == "" {
+		return nil, fmt.Errorf("the destination of the list generator must be a parameter")
+	}
+
+	elements, _, _ := unstructured.NestedSlice(generator, "list", "elements")
+	clusters := []string{}
+
+	for _, e := range elements {
+		element, _ := e.(map[string]interface{})
+
+		cluster, err := getDestinationCluster(map[string]interface{}{
+			"destination": map[string]interface{}{field: element[key]},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("the list generator has no elements")
+	}
+
+	return clusters, nil
+}
+
+// getDestinationCluster returns the managed cluster of the application destination. The in-cluster server is the
+// local cluster, the other servers can't be mapped to a managed cluster.
+func getDestinationCluster(spec map[string]interface{}) (string, error) {
+	name, _, _ := unstructured.NestedString(spec, "destination", "name")
+	server, _, _ := unstructured.NestedString(spec, "destination", "server")
+
+	switch {
+	case name == inClusterName || (name == "" && server == InClusterServer):
+		return LocalCluster, nil
+	case name != "":
+		return name, nil
+	case server == "":
+		return "", fmt.Errorf("the destination has no cluster")
+	default:
+		return "", fmt.Errorf("the destination server %v can't be mapped to a managed cluster, set the destination name",
+			server)
+	}
+}
+
+// importSpec converts the application spec to the channel and subscription, the placement selects the clusters of
+// the destination
+func importSpec(name string, spec map[string]interface{}, defaultNamespace string,
+	placement clusterv1beta1.PlacementSpec) (*Resources, error) {
+	if _, ok := spec["sources"]; ok {
+		return nil, fmt.Errorf("application %v: the applications with multiple sources are not supported", name)
+	}
+
+	source, found, _ := unstructured.NestedMap(spec, "source")
+	if !found {
+		return nil, fmt.Errorf("application %v has no source", name)
+	}
+
+	if _, ok := source["kustomize"]; ok {
+		return nil, fmt.Errorf("application %v: the kustomize options are not supported, "+
+			"the kustomization of the source path is built by the subscription", name)
+	}
+
+	namespace, _, _ := unstructured.NestedString(spec, "destination", "namespace")
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	if namespace == "" {
+		return nil, fmt.Errorf("application %v has no destination namespace", name)
+	}
+
+	repoURL, _, _ := unstructured.NestedString(source, "repoURL")
+	revision, _, _ := unstructured.NestedString(source, "targetRevision")
+	chart, _, _ := unstructured.NestedString(source, "chart")
+
+	res := &Resources{
+		Channel: &chnv1.Channel{
+			TypeMeta:   metav1.TypeMeta{APIVersion: chnv1.SchemeGroupVersion.String(), Kind: "Channel"},
+			ObjectMeta: metav1.ObjectMeta{Name: ChannelName(repoURL), Namespace: namespace},
+			Spec:       chnv1.ChannelSpec{Pathname: repoURL},
+		},
+		Subscription: &appv1.Subscription{
+			TypeMeta:   metav1.TypeMeta{APIVersion: appv1.SchemeGroupVersion.String(), Kind: "Subscription"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		},
+		Placement: &clusterv1beta1.Placement{
+			TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1beta1.GroupVersion.String(), Kind: "Placement"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       placement,
+		},
+	}
+
+	sub := res.Subscription
+	sub.Spec.Channel = namespace + "/" + res.Channel.Name
+	sub.Spec.Placement = &plrv1.Placement{
+		PlacementRef: &corev1.ObjectReference{Kind: "Placement", Name: name},
+	}
+
+	packageName := chart
+
+	if chart != "" {
+		res.Channel.Spec.Type = chnv1.ChannelTypeHelmRepo
+		sub.Spec.Package = chart
+
+		if revision != "" && revision != "*" {
+			sub.Spec.PackageFilter = &appv1.PackageFilter{Version: revision}
+		}
+	} else {
+		res.Channel.Spec.Type = chnv1.ChannelTypeGit
+		sub.Annotations = map[string]string{}
+
+		sourcePath, _, _ := unstructured.NestedString(source, "path")
+		if sourcePath != "" && sourcePath != "." {
+			sub.Annotations[appv1.AnnotationGitPath] = sourcePath
+			packageName = path.Base(sourcePath)
+		}
+
+		switch {
+		case revision == "" || revision == "HEAD":
+		case commitRegexp.MatchString(revision):
+			sub.Annotations[appv1.AnnotationGitTargetCommit] = revision
+		default:
+			sub.Annotations[appv1.AnnotationGitBranch] = revision
+		}
+	}
+
+	helm, _, _ := unstructured.NestedMap(source, "helm")
+	if len(helm) == 0 {
+		return res, nil
+	}
+
+	if packageName == "" {
+		return nil, fmt.Errorf("application %v: the helm values of a chart at the repository root are not supported", name)
+	}
+
+	values, err := getHelmValues(helm)
+	if err != nil {
+		return nil, fmt.Errorf("application %v: %w", name, err)
+	}
+
+	overrides := &appv1.Overrides{PackageName: packageName}
+	overrides.PackageAlias, _, _ = unstructured.NestedString(helm, "releaseName")
+
+	if len(values) != 0 {
+		override, err := newValuesOverride(values)
+		if err != nil {
+			return nil, fmt.Errorf("application %v: %w", name, err)
+		}
+
+		overrides.PackageOverrides = []appv1.PackageOverride{override}
+	}
+
+	sub.Spec.PackageOverrides = []*appv1.Overrides{overrides}
+
+	return res, nil
+}
+
+// getHelmValues merges the values, valuesObject and parameters of the ArgoCD helm source, the later ones take
+// precedence like in ArgoCD
+func getHelmValues(helm map[string]interface{}) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	if s, ok := helm["values"].(string); ok && s != "" {
+		if err := yaml.Unmarshal([]byte(s), &values); err != nil {
+			return nil, fmt.Errorf("invalid helm values: %w", err)
+		}
+	}
+
+	if obj, ok := helm["valuesObject"].(map[string]interface{}); ok {
+		for k, v := range obj {
+			values[k] = v
+		}
+	}
+
+	if _, ok := helm["valueFiles"]; ok {
+		return nil, fmt.Errorf("the helm value files are not supported")
+	}
+
+	parameters, _, _ := unstructured.NestedSlice(helm, "parameters")
+
+	for _, p := range parameters {
+		parameter, _ := p.(map[string]interface{})
+		name, _ := parameter["name"].(string)
+
+		if name == "" || strings.ContainsAny(name, `[]\`) {
+			return nil, fmt.Errorf("unsupported helm parameter %q", name)
+		}
+
+		if err := unstructured.SetNestedField(values, parameter["value"], strings.Split(name, ".")...); err != nil {
+			return nil, fmt.Errorf("invalid helm parameter %q: %w", name, err)
+		}
+	}
+
+	return values, nil
+}
+
+func newValuesOverride(values map[string]interface{}) (appv1.PackageOverride, error) {
+	raw, err := yaml.Marshal(map[string]interface{}{"path": "spec", "value": values})
+	if err != nil {
+		return appv1.PackageOverride{}, err
+	}
+
+	raw, err = yaml.YAMLToJSON(raw)
+
+	return appv1.PackageOverride{RawExtension: runtime.RawExtension{Raw: raw}}, err
+}
+
+// newPlacementSpec returns the placement of the clusters, or of the cluster selector
+func newPlacementSpec(clusters []string, selector *metav1.LabelSelector) clusterv1beta1.PlacementSpec {
+	if selector == nil {
+		selector = &metav1.LabelSelector{}
+
+		if len(clusters) == 1 {
+			selector.MatchLabels = map[string]string{labelClusterName: clusters[0]}
+		} else {
+			selector.MatchExpressions = []metav1.LabelSelectorRequirement{
+				{Key: labelClusterName, Operator: metav1.LabelSelectorOpIn, Values: clusters},
+			}
+		}
+	}
+
+	spec := clusterv1beta1.PlacementSpec{}
+
+	if len(selector.MatchLabels) != 0 || len(selector.MatchExpressions) != 0 {
+		spec.Predicates = []clusterv1beta1.ClusterPredicate{
+			{RequiredClusterSelector: clusterv1beta1.ClusterSelector{LabelSelector: *selector}},
+		}
+	}
+
+	return spec
+}
+
+// ChannelName returns the channel name of a repository URL
+func ChannelName(repoURL string) string {
+	name := repoURL
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git")
+	name = strings.Trim(nameRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+
+	if len(name) > 63 {
+		name = strings.TrimRight(name[len(name)-63:], "-")
+		name = strings.TrimLeft(name, "-")
+	}
+
+	return name
+}
+
+// checkNoParameters returns an error if the application template has parameters
+func checkNoParameters(spec map[string]interface{}) error {
+	raw, err := yaml.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(string(raw), "{{") {
+		return fmt.Errorf("the template parameters are only supported in the destination")
+	}
+
+	return nil
+}
+
+func isParameter(value interface{}, name string) bool {
+	return getParameter(value) == name
+}
+
+// getParameter returns the parameter name of a {{name}} or {{.name}} template value
+func getParameter(value interface{}) string {
+	s, _ := value.(string)
+
+	m := parameterRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+
+	return m[1]
+}
+
+// convert converts an unstructured value to a typed object
+func convert(in interface{}, out interface{}) error {
+	if in == nil {
+		return nil
+	}
+
+	raw, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(raw, out)
+}
@@ -17,6 +17,6 @@ package controller
 import "open-cluster-management.io/multicloud-operators-subscription/pkg/controller/mcmhub"
 
 func init() {
-	// AddHubToManagerFuncs is a list of functions to create controllers and add them to a manager.
-	AddHubToManagerFuncs = append(AddHubToManagerFuncs, mcmhub.Add)
+	// AddHubToManagerFuncs is a map of functions to create controllers and add them to a manager.
+	AddHubToManagerFuncs["subscription"] = mcmhub.Add
 }
@@ -0,0 +1,206 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apischema
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	clientsetx "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// reportInterval is the interval of the API schema reports, the CRDs installed on the managed cluster are picked up
+// at the next report
+const reportInterval = 10 * time.Minute
+
+// Add adds the API schema reporter to the manager of a managed cluster agent. The reporter saves the API resources
+// served by the managed cluster in its cluster namespace on the hub, for the pre-flight validation of the manifests.
+func Add(mgr manager.Manager, hubconfig *rest.Config, syncid *types.NamespacedName, standalone bool) error {
+	if standalone || hubconfig == nil || syncid == nil || syncid.Namespace == "" {
+		return nil
+	}
+
+	hubclient, err := client.New(hubconfig, client.Options{})
+	if err != nil {
+		klog.Error("Failed to generate client to hub cluster with error:", err)
+		return err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		klog.Error("Failed to generate discovery client with error:", err)
+		return err
+	}
+
+	crdClient, err := clientsetx.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		klog.Error("Failed to generate CRD client with error:", err)
+		return err
+	}
+
+	reporter := &Reporter{
+		hubclient:       hubclient,
+		discoveryClient: discoveryClient,
+		crdClient:       crdClient,
+		clusterName:     syncid.Namespace,
+	}
+
+	return mgr.Add(reporter)
+}
+
+// Reporter reports the API schemas of the managed cluster to the hub
+type Reporter struct {
+	hubclient       client.Client
+	discoveryClient discovery.DiscoveryInterface
+	crdClient       clientsetx.Interface
+	clusterName     string
+}
+
+// Start reports the API schemas until the context is done
+func (r *Reporter) Start(ctx context.Context) error {
+	klog.Info("Starting the API schema reporter of cluster ", r.clusterName)
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.report(ctx); err != nil {
+			klog.Errorf("Failed to report the API schemas of cluster %v, err: %v", r.clusterName, err)
+		}
+	}, reportInterval)
+
+	return nil
+}
+
+func (r *Reporter) report(ctx context.Context) error {
+	schemas, err := r.collect(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := utils.EncodeClusterAPISchemas(schemas)
+	if err != nil {
+		return err
+	}
+
+	// keep the served kinds if the custom resource schemas don't fit in the ConfigMap
+	if len(data) > utils.MaxClusterAPISchemasSize {
+		klog.Warningf("The API schemas of cluster %v are %v bytes, only the served kinds are reported", r.clusterName, len(data))
+
+		for _, res := range schemas.Resources {
+			res.Schema = nil
+		}
+
+		if data, err = utils.EncodeClusterAPISchemas(schemas); err != nil {
+			return err
+		}
+	}
+
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: r.clusterName, Name: utils.ClusterAPISchemasConfigMapName}
+
+	if err := r.hubclient.Get(ctx, key, cm); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			BinaryData: map[string][]byte{utils.ClusterAPISchemasKey: data},
+		}
+
+		klog.Infof("Creating the API schemas %v of cluster %v", key.String(), r.clusterName)
+
+		return r.hubclient.Create(ctx, cm)
+	}
+
+	if bytes.Equal(cm.BinaryData[utils.ClusterAPISchemasKey], data) {
+		return nil
+	}
+
+	cm.BinaryData = map[string][]byte{utils.ClusterAPISchemasKey: data}
+
+	klog.Infof("Updating the API schemas %v of cluster %v", key.String(), r.clusterName)
+
+	return r.hubclient.Update(ctx, cm)
+}
+
+// collect returns the kinds served by the managed cluster and the OpenAPI schemas of its custom resources
+func (r *Reporter) collect(ctx context.Context) (*utils.ClusterAPISchemas, error) {
+	schemas := &utils.ClusterAPISchemas{Resources: map[string]*utils.APIResourceSchema{}}
+
+	// the groups of the unavailable aggregated APIs are skipped
+	_, resourceLists, err := r.discoveryClient.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+
+		for _, resource := range resourceList.APIResources {
+			// skip the subresources
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+
+			schemas.Resources[utils.APIResourceKey(gv.WithKind(resource.Kind))] = &utils.APIResourceSchema{
+				Namespaced: resource.Namespaced,
+			}
+		}
+	}
+
+	crds, err := r.crdClient.ApiextensionsV1().CustomResourceDefinitions().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range crds.Items {
+		if !isCRDEstablished(&crds.Items[i]) {
+			continue
+		}
+
+		for key, res := range utils.GetCRDResourceSchemas(&crds.Items[i]) {
+			schemas.Resources[key] = res
+		}
+	}
+
+	return schemas, nil
+}
+
+func isCRDEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == apiextensionsv1.Established {
+			return cond.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+
+	return false
+}
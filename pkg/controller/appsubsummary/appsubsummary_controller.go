@@ -32,11 +32,18 @@ import (
 	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
 	managedClusterView "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/view/v1beta1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
 	subutils "open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
+// maxRolloutClusterStatuses is the max number of per cluster entries kept in the appsub rollout summary
+const maxRolloutClusterStatuses = 500
+
+// maxCommitHistory is the max number of successful commits kept in the appsub status for rollback
+const maxCommitHistory = 10
+
 // ReconcileAppSubStatus reconciles a AppSubStatus object.
 type ReconcileAppSubSummary struct {
 	client.Client
@@ -46,6 +53,7 @@ type ReconcileAppSubSummary struct {
 type AppSubClusterStatus struct {
 	Cluster string
 	Phase   string
+	Commit  string
 }
 
 // appsub cluster statuses per appsub.
@@ -179,6 +187,7 @@ func (r *ReconcileAppSubSummary) UpdateAppSubMapsPerCluster(appsubReportPerClust
 		cs := AppSubClusterStatus{
 			Cluster: cluster,
 			Phase:   string(result.Result),
+			Commit:  result.Commit,
 		}
 
 		if clusterStatus, ok := appSubClusterStatusMap[result.Source]; ok {
@@ -425,7 +434,130 @@ func (r *ReconcileAppSubSummary) createOrUpdateAppSubReport(
 
 			klog.V(1).Infof("AppsubReport updated, %v/%v", newAppsubReport.GetNamespace(), newAppsubReport.GetName())
 		}
+
+		r.updateAppSubRolloutSummary(appsubNs, appsubName, newAppsubReport.Summary, clustersStatus)
+
+		r.updateAppSubTopology(appsubNs, appsubName, appsubResources, clustersStatus)
+	}
+}
+
+// updateAppSubRolloutSummary rolls the cluster results of the appsub up to the hub appsub status,
+// so the hub appsub tells where the current version of the app is running.
+func (r *ReconcileAppSubSummary) updateAppSubRolloutSummary(appsubNs, appsubName string,
+	appsubSummary appsubReportV1alpha1.SubscriptionReportSummary, clustersStatus AppSubClustersStatus) {
+	appsub := &appsubv1.Subscription{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Name: appsubName, Namespace: appsubNs}, appsub); err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Errorf("Failed to get appsub %v/%v to update rollout summary, err: %v", appsubNs, appsubName, err)
+		} else {
+			metrics.PropagationClusterDeployedRatio.DeleteLabelValues(appsubNs, appsubName)
+		}
+
+		return
+	}
+
+	newSummary := newRolloutSummary(appsubSummary, clustersStatus, subutils.GetAppsubCurrentCommit(appsub))
+
+	if newSummary.Clusters > 0 {
+		metrics.PropagationClusterDeployedRatio.
+			WithLabelValues(appsubNs, appsubName).
+			Set(float64(newSummary.Deployed) / float64(newSummary.Clusters))
+	} else {
+		metrics.PropagationClusterDeployedRatio.DeleteLabelValues(appsubNs, appsubName)
+	}
+	newCommitHistory := recordSuccessfulCommit(appsub.Status.CommitHistory, newSummary)
+
+	if appsub.Status.Summary != nil && isSameRolloutSummary(appsub.Status.Summary, newSummary) &&
+		equality.Semantic.DeepEqual(appsub.Status.CommitHistory, newCommitHistory) {
+		return
+	}
+
+	origAppsub := appsub.DeepCopy()
+
+	newSummary.LastUpdateTime = metav1.Now()
+	appsub.Status.Summary = newSummary
+	appsub.Status.CommitHistory = newCommitHistory
+
+	if err := r.Status().Patch(context.TODO(), appsub, client.MergeFrom(origAppsub)); err != nil {
+		klog.Errorf("Failed to update rollout summary of appsub %v/%v, err: %v", appsubNs, appsubName, err)
+
+		return
+	}
+
+	klog.V(1).Infof("Appsub rollout summary updated, %v/%v", appsubNs, appsubName)
+}
+
+func newRolloutSummary(appsubSummary appsubReportV1alpha1.SubscriptionReportSummary,
+	clustersStatus AppSubClustersStatus, commit string) *appsubv1.SubscriptionRolloutSummary {
+	summary := &appsubv1.SubscriptionRolloutSummary{
+		Deployed:          clustersStatus.Deployed,
+		Failed:            clustersStatus.Failed,
+		PropagationFailed: clustersStatus.PropagationFailed,
+		Commit:            commit,
 	}
+
+	summary.Clusters, _ = strconv.Atoi(appsubSummary.Clusters)
+	summary.InProgress, _ = strconv.Atoi(appsubSummary.InProgress)
+
+	summary.Propagated = summary.Clusters - summary.PropagationFailed
+	if summary.Propagated < 0 {
+		summary.Propagated = 0
+	}
+
+	sort.Sort(AppSubClusterStatusSorter(clustersStatus.Clusters))
+
+	for _, clusterStatus := range clustersStatus.Clusters {
+		if commit != "" && clusterStatus.Commit != "" && clusterStatus.Commit != commit {
+			summary.OutOfSync++
+		}
+
+		if len(summary.ClusterStatuses) >= maxRolloutClusterStatuses {
+			continue
+		}
+
+		summary.ClusterStatuses = append(summary.ClusterStatuses, appsubv1.SubscriptionClusterRolloutStatus{
+			Cluster: clusterStatus.Cluster,
+			Result:  clusterStatus.Phase,
+			Commit:  clusterStatus.Commit,
+		})
+	}
+
+	return summary
+}
+
+// recordSuccessfulCommit adds the commit of the rollout summary on top of the commit history if it is deployed
+// on all the managed clusters. The history is bounded to maxCommitHistory commits.
+func recordSuccessfulCommit(history []appsubv1.SubscriptionCommitRecord,
+	summary *appsubv1.SubscriptionRolloutSummary) []appsubv1.SubscriptionCommitRecord {
+	if summary.Commit == "" || summary.Clusters == 0 || summary.Deployed != summary.Clusters || summary.OutOfSync > 0 {
+		return history
+	}
+
+	if len(history) > 0 && history[0].Commit == summary.Commit {
+		return history
+	}
+
+	newHistory := []appsubv1.SubscriptionCommitRecord{{Commit: summary.Commit, DeployedTime: metav1.Now()}}
+
+	for _, record := range history {
+		if len(newHistory) >= maxCommitHistory {
+			break
+		}
+
+		if record.Commit != summary.Commit {
+			newHistory = append(newHistory, record)
+		}
+	}
+
+	return newHistory
+}
+
+func isSameRolloutSummary(a, b *appsubv1.SubscriptionRolloutSummary) bool {
+	oldSummary := a.DeepCopy()
+	oldSummary.LastUpdateTime = b.LastUpdateTime
+
+	return equality.Semantic.DeepEqual(oldSummary, b)
 }
 
 func (r *ReconcileAppSubSummary) newAppSubReport(appsubNs, appsubName string,
@@ -459,6 +591,7 @@ func (r *ReconcileAppSubSummary) newAppSubReport(appsubNs, appsubName string,
 			Namespace: appsubNs,
 			Labels: map[string]string{
 				"apps.open-cluster-management.io/hosting-subscription": fmt.Sprintf("%.63s", appsubNs+"."+appsubName),
+				subutils.ExcludeFromBackupLabel:                        "true",
 			},
 		},
 		ReportType: "Application",
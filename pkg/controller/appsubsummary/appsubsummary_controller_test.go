@@ -15,6 +15,7 @@
 package appsubsummary
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
 	managedClusterView "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/view/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -165,3 +167,73 @@ func TestRefreshManagedClusterViews(t *testing.T) {
 	err = c.Get(context.TODO(), view1Key, view1)
 	g.Expect(errors.IsNotFound(err)).To(gomega.BeTrue())
 }
+
+func TestNewRolloutSummary(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	appsubSummary := appsubReportV1alpha1.SubscriptionReportSummary{
+		Clusters:   "4",
+		InProgress: "1",
+	}
+
+	clustersStatus := AppSubClustersStatus{
+		Clusters: []AppSubClusterStatus{
+			{Cluster: "cluster3", Phase: "propagationFailed"},
+			{Cluster: "cluster2", Phase: "failed", Commit: "aaa"},
+			{Cluster: "cluster1", Phase: "deployed", Commit: "bbb"},
+		},
+		Deployed:          1,
+		Failed:            1,
+		PropagationFailed: 1,
+	}
+
+	summary := newRolloutSummary(appsubSummary, clustersStatus, "bbb")
+
+	g.Expect(summary.Clusters).To(gomega.Equal(4))
+	g.Expect(summary.Propagated).To(gomega.Equal(3))
+	g.Expect(summary.Deployed).To(gomega.Equal(1))
+	g.Expect(summary.Failed).To(gomega.Equal(1))
+	g.Expect(summary.PropagationFailed).To(gomega.Equal(1))
+	g.Expect(summary.InProgress).To(gomega.Equal(1))
+	g.Expect(summary.OutOfSync).To(gomega.Equal(1))
+	g.Expect(summary.Commit).To(gomega.Equal("bbb"))
+	g.Expect(summary.ClusterStatuses).To(gomega.HaveLen(3))
+	g.Expect(summary.ClusterStatuses[0].Cluster).To(gomega.Equal("cluster1"))
+	g.Expect(summary.ClusterStatuses[0].Commit).To(gomega.Equal("bbb"))
+
+	// same summary with a different update time is not a change
+	newSummary := summary.DeepCopy()
+	newSummary.LastUpdateTime = metav1.Now()
+	g.Expect(isSameRolloutSummary(summary, newSummary)).To(gomega.BeTrue())
+
+	newSummary.Deployed = 2
+	g.Expect(isSameRolloutSummary(summary, newSummary)).To(gomega.BeFalse())
+}
+
+func TestRecordSuccessfulCommit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	summary := &appsubv1.SubscriptionRolloutSummary{Clusters: 2, Deployed: 1, Commit: "aaa"}
+
+	// not deployed on all the clusters yet
+	history := recordSuccessfulCommit(nil, summary)
+	g.Expect(history).To(gomega.BeEmpty())
+
+	summary.Deployed = 2
+	history = recordSuccessfulCommit(history, summary)
+	g.Expect(history).To(gomega.HaveLen(1))
+	g.Expect(history[0].Commit).To(gomega.Equal("aaa"))
+
+	// same commit is recorded once
+	history = recordSuccessfulCommit(history, summary)
+	g.Expect(history).To(gomega.HaveLen(1))
+
+	for i := 0; i < maxCommitHistory+2; i++ {
+		history = recordSuccessfulCommit(history, &appsubv1.SubscriptionRolloutSummary{
+			Clusters: 2, Deployed: 2, Commit: fmt.Sprintf("commit%d", i),
+		})
+	}
+
+	g.Expect(history).To(gomega.HaveLen(maxCommitHistory))
+	g.Expect(history[0].Commit).To(gomega.Equal(fmt.Sprintf("commit%d", maxCommitHistory+1)))
+}
@@ -0,0 +1,255 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appsubsummary
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	subutils "open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxTopologyPageEntries is the max number of entries per SubscriptionTopology page, it keeps the pages far below
+// the object size limit of the hub
+const maxTopologyPageEntries = 1000
+
+// hookJobAPIVersion is the api version of the ansible jobs listed in the appsub topo annotation
+const hookJobAPIVersion = "tower.ansible.com/v1alpha1"
+
+// updateAppSubTopology generates the SubscriptionTopology pages of the appsub from its resource list and its cluster
+// results, and deletes the pages no longer needed.
+func (r *ReconcileAppSubSummary) updateAppSubTopology(appsubNs, appsubName string,
+	appsubResources []*corev1.ObjectReference, clustersStatus AppSubClustersStatus) {
+	appsub := &appsubv1.Subscription{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Name: appsubName, Namespace: appsubNs}, appsub); err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Errorf("Failed to get appsub %v/%v to update topology, err: %v", appsubNs, appsubName, err)
+		}
+
+		return
+	}
+
+	pages := newTopologyPages(appsub, newTopologyEntries(appsub, appsubResources, clustersStatus))
+	desired := map[string]bool{}
+
+	for _, page := range pages {
+		desired[page.Name] = true
+
+		existing := &appsubReportV1alpha1.SubscriptionTopology{}
+
+		err := r.Get(context.TODO(), types.NamespacedName{Name: page.Name, Namespace: page.Namespace}, existing)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				klog.Errorf("Failed to get appsub topology %v/%v, err: %v", page.Namespace, page.Name, err)
+
+				return
+			}
+
+			if err := r.Create(context.TODO(), page); err != nil {
+				klog.Errorf("Failed to create appsub topology %v/%v, err: %v", page.Namespace, page.Name, err)
+
+				return
+			}
+
+			continue
+		}
+
+		if isSameTopologyPage(existing, page) {
+			continue
+		}
+
+		existing.SetLabels(page.GetLabels())
+		existing.SetOwnerReferences(page.GetOwnerReferences())
+		existing.Subscription = page.Subscription
+		existing.Page = page.Page
+		existing.TotalPages = page.TotalPages
+		existing.TotalEntries = page.TotalEntries
+		existing.Entries = page.Entries
+
+		if err := r.Update(context.TODO(), existing); err != nil {
+			klog.Errorf("Failed to update appsub topology %v/%v, err: %v", page.Namespace, page.Name, err)
+
+			return
+		}
+
+		klog.V(1).Infof("Appsub topology updated, %v/%v", page.Namespace, page.Name)
+	}
+
+	existingPages := &appsubReportV1alpha1.SubscriptionTopologyList{}
+
+	if err := r.List(context.TODO(), existingPages, client.InNamespace(appsubNs),
+		client.MatchingLabels(pages[0].GetLabels())); err != nil {
+		klog.Errorf("Failed to list appsub topology of %v/%v, err: %v", appsubNs, appsubName, err)
+
+		return
+	}
+
+	for i := range existingPages.Items {
+		page := &existingPages.Items[i]
+
+		// the label value is truncated, so it may be shared with another appsub
+		if page.Subscription != appsubName || desired[page.Name] {
+			continue
+		}
+
+		if err := r.Delete(context.TODO(), page); err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("Failed to delete appsub topology %v/%v, err: %v", page.Namespace, page.Name, err)
+		}
+	}
+}
+
+// newTopologyEntries lists every appsub resource on every cluster with its health, followed by the hook jobs applied
+// on the hub. The entries are sorted by cluster, kind, namespace and name.
+func newTopologyEntries(appsub *appsubv1.Subscription, appsubResources []*corev1.ObjectReference,
+	clustersStatus AppSubClustersStatus) []appsubReportV1alpha1.SubscriptionTopologyEntry {
+	entries := []appsubReportV1alpha1.SubscriptionTopologyEntry{}
+
+	for _, clusterStatus := range clustersStatus.Clusters {
+		health := getTopologyHealth(clusterStatus.Phase)
+
+		for _, res := range appsubResources {
+			if res == nil {
+				continue
+			}
+
+			entries = append(entries, appsubReportV1alpha1.SubscriptionTopologyEntry{
+				APIVersion: res.APIVersion,
+				Kind:       res.Kind,
+				Name:       res.Name,
+				Namespace:  res.Namespace,
+				Cluster:    clusterStatus.Cluster,
+				Health:     health,
+			})
+		}
+	}
+
+	// the topo annotation lists the hook jobs as hook//<kind>/<namespace>/<name>/0
+	for _, hook := range strings.Split(appsub.GetAnnotations()[appsubv1.AnnotationTopo], ",") {
+		fields := strings.Split(hook, "/")
+		if len(fields) != 6 {
+			continue
+		}
+
+		entries = append(entries, appsubReportV1alpha1.SubscriptionTopologyEntry{
+			APIVersion: hookJobAPIVersion,
+			Kind:       fields[2],
+			Name:       fields[4],
+			Namespace:  fields[3],
+			Health:     appsubReportV1alpha1.TopologyUnknown,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+
+		return a.Name < b.Name
+	})
+
+	return entries
+}
+
+// getTopologyHealth returns the health of the appsub resources on a cluster from the appsub phase on the cluster
+func getTopologyHealth(phase string) appsubReportV1alpha1.TopologyHealth {
+	switch appsubReportV1alpha1.SubscriptionResult(phase) {
+	case "deployed":
+		return appsubReportV1alpha1.TopologyHealthy
+	case "failed", "propagationFailed":
+		return appsubReportV1alpha1.TopologyDegraded
+	default:
+		return appsubReportV1alpha1.TopologyProgressing
+	}
+}
+
+// newTopologyPages splits the topology entries in pages of at most maxTopologyPageEntries entries. There is always
+// a first page, named after the appsub, so an appsub without resources has an empty topology.
+func newTopologyPages(appsub *appsubv1.Subscription,
+	entries []appsubReportV1alpha1.SubscriptionTopologyEntry) []*appsubReportV1alpha1.SubscriptionTopology {
+	totalPages := (len(entries) + maxTopologyPageEntries - 1) / maxTopologyPageEntries
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	pages := []*appsubReportV1alpha1.SubscriptionTopology{}
+
+	for i := 0; i < totalPages; i++ {
+		name := appsub.Name
+		if i > 0 {
+			name = fmt.Sprintf("%s.%d", appsub.Name, i+1)
+		}
+
+		end := (i + 1) * maxTopologyPageEntries
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		page := &appsubReportV1alpha1.SubscriptionTopology{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "SubscriptionTopology",
+				APIVersion: "apps.open-cluster-management.io/v1alpha1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: appsub.Namespace,
+				Labels: map[string]string{
+					"apps.open-cluster-management.io/hosting-subscription": fmt.Sprintf("%.63s", appsub.Namespace+"."+appsub.Name),
+					subutils.ExcludeFromBackupLabel:                        "true",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(appsub, appsubv1.SchemeGroupVersion.WithKind("Subscription")),
+				},
+			},
+			Subscription: appsub.Name,
+			Page:         int32(i + 1),
+			TotalPages:   int32(totalPages),
+			TotalEntries: int32(len(entries)),
+			Entries:      entries[i*maxTopologyPageEntries : end],
+		}
+
+		pages = append(pages, page)
+	}
+
+	return pages
+}
+
+func isSameTopologyPage(a, b *appsubReportV1alpha1.SubscriptionTopology) bool {
+	return equality.Semantic.DeepEqual(a.GetLabels(), b.GetLabels()) &&
+		equality.Semantic.DeepEqual(a.GetOwnerReferences(), b.GetOwnerReferences()) &&
+		a.Subscription == b.Subscription && a.Page == b.Page && a.TotalPages == b.TotalPages &&
+		a.TotalEntries == b.TotalEntries && equality.Semantic.DeepEqual(a.Entries, b.Entries)
+}
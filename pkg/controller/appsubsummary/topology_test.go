@@ -0,0 +1,156 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appsubsummary
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestResources(count int) []*corev1.ObjectReference {
+	resources := []*corev1.ObjectReference{}
+
+	for i := 0; i < count; i++ {
+		resources = append(resources, &corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       fmt.Sprintf("cm-%04d", i),
+			Namespace:  "app1-ns",
+		})
+	}
+
+	return resources
+}
+
+func TestNewTopologyEntries(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	appsub := &appsubv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app1",
+			Namespace: "app1-ns",
+			Annotations: map[string]string{
+				appsubv1.AnnotationTopo: "hook//AnsibleJob/app1-ns/prehook-1/0",
+			},
+		},
+	}
+
+	clustersStatus := AppSubClustersStatus{
+		Clusters: []AppSubClusterStatus{
+			{Cluster: "cluster3", Phase: "propagationFailed"},
+			{Cluster: "cluster2", Phase: ""},
+			{Cluster: "cluster1", Phase: "deployed"},
+		},
+	}
+
+	entries := newTopologyEntries(appsub, newTestResources(2), clustersStatus)
+
+	g.Expect(entries).To(gomega.HaveLen(7))
+	g.Expect(entries[0]).To(gomega.Equal(appsubReportV1alpha1.SubscriptionTopologyEntry{
+		APIVersion: hookJobAPIVersion,
+		Kind:       "AnsibleJob",
+		Name:       "prehook-1",
+		Namespace:  "app1-ns",
+		Health:     appsubReportV1alpha1.TopologyUnknown,
+	}))
+	g.Expect(entries[1]).To(gomega.Equal(appsubReportV1alpha1.SubscriptionTopologyEntry{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       "cm-0000",
+		Namespace:  "app1-ns",
+		Cluster:    "cluster1",
+		Health:     appsubReportV1alpha1.TopologyHealthy,
+	}))
+	g.Expect(entries[3].Health).To(gomega.Equal(appsubReportV1alpha1.TopologyProgressing))
+	g.Expect(entries[5].Health).To(gomega.Equal(appsubReportV1alpha1.TopologyDegraded))
+}
+
+func TestUpdateAppSubTopology(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(scheme)).To(gomega.Succeed())
+
+	appsub := &appsubv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app1", Namespace: "app1-ns", UID: "uid1"}}
+
+	r := &ReconcileAppSubSummary{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(appsub).Build()}
+
+	clustersStatus := AppSubClustersStatus{
+		Clusters: []AppSubClusterStatus{
+			{Cluster: "cluster1", Phase: "deployed"},
+			{Cluster: "cluster2", Phase: "failed"},
+		},
+	}
+
+	listPages := func() []appsubReportV1alpha1.SubscriptionTopology {
+		pages := &appsubReportV1alpha1.SubscriptionTopologyList{}
+		g.Expect(r.List(context.TODO(), pages, client.InNamespace("app1-ns"))).To(gomega.Succeed())
+
+		return pages.Items
+	}
+
+	// the topology is split in pages
+	r.updateAppSubTopology("app1-ns", "app1", newTestResources(maxTopologyPageEntries/2+1), clustersStatus)
+
+	pages := listPages()
+	g.Expect(pages).To(gomega.HaveLen(2))
+
+	page := &appsubReportV1alpha1.SubscriptionTopology{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Name: "app1", Namespace: "app1-ns"}, page)).To(gomega.Succeed())
+	g.Expect(page.Page).To(gomega.Equal(int32(1)))
+	g.Expect(page.TotalPages).To(gomega.Equal(int32(2)))
+	g.Expect(page.TotalEntries).To(gomega.Equal(int32(maxTopologyPageEntries + 2)))
+	g.Expect(page.Entries).To(gomega.HaveLen(maxTopologyPageEntries))
+	g.Expect(page.OwnerReferences).To(gomega.HaveLen(1))
+	g.Expect(page.OwnerReferences[0].Kind).To(gomega.Equal("Subscription"))
+	g.Expect(page.Labels["apps.open-cluster-management.io/hosting-subscription"]).To(gomega.Equal("app1-ns.app1"))
+
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Name: "app1.2", Namespace: "app1-ns"}, page)).To(gomega.Succeed())
+	g.Expect(page.Page).To(gomega.Equal(int32(2)))
+	g.Expect(page.Entries).To(gomega.HaveLen(2))
+	g.Expect(page.Entries[1].Cluster).To(gomega.Equal("cluster2"))
+	g.Expect(page.Entries[1].Health).To(gomega.Equal(appsubReportV1alpha1.TopologyDegraded))
+
+	// the pages no longer needed are deleted
+	r.updateAppSubTopology("app1-ns", "app1", newTestResources(1), clustersStatus)
+
+	pages = listPages()
+	g.Expect(pages).To(gomega.HaveLen(1))
+	g.Expect(pages[0].Name).To(gomega.Equal("app1"))
+	g.Expect(pages[0].TotalPages).To(gomega.Equal(int32(1)))
+	g.Expect(pages[0].Entries).To(gomega.HaveLen(2))
+
+	// an appsub without resources has an empty topology
+	r.updateAppSubTopology("app1-ns", "app1", nil, clustersStatus)
+
+	pages = listPages()
+	g.Expect(pages).To(gomega.HaveLen(1))
+	g.Expect(pages[0].TotalEntries).To(gomega.BeZero())
+	g.Expect(pages[0].Entries).To(gomega.BeEmpty())
+}
@@ -0,0 +1,315 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package channelhealth periodically checks the connection and the credentials of the channels, and reports the
+// results in the Connected condition of the channels
+package channelhealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	helmutils "open-cluster-management.io/multicloud-operators-subscription/pkg/helmrelease/utils"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	awsutils "open-cluster-management.io/multicloud-operators-subscription/pkg/utils/aws"
+)
+
+const (
+	// ConditionConnected is true when the hub can connect to the channel with its credentials
+	ConditionConnected = "Connected"
+
+	// Reasons of the Connected condition
+	ReasonConnected            = "Connected"
+	ReasonAuthenticationFailed = "AuthenticationFailed"
+	ReasonConnectionFailed     = "ConnectionFailed"
+	ReasonSecretProvider       = "SecretProvider"
+
+	// checkInterval is the period of the channel health checks
+	checkInterval = 5 * time.Minute
+
+	// helmCheckTimeout is the timeout of the helm repo index requests
+	helmCheckTimeout = 30 * time.Second
+)
+
+var channelListGVK = chnv1.SchemeGroupVersion.WithKind("ChannelList")
+
+// checkFunc checks the connection and the credentials of a channel
+type checkFunc func(ctx context.Context, clt client.Client, chn *chnv1.Channel) error
+
+// ChannelHealth periodically checks the connection and the credentials of the Git, helm repo and object bucket
+// channels: git ls-remote, helm repo index HEAD request and bucket access. The broken channels are caught before the
+// subscriptions fail to deploy their new resources.
+type ChannelHealth struct {
+	client.Client
+
+	// Reader lists the channels from the API server with their status
+	Reader client.Reader
+
+	// checks are the health checks by channel type, the namespace channels are not checked
+	checks map[string]checkFunc
+}
+
+// Add adds the channel health checks to the hub manager, they run on the leader only
+func Add(mgr manager.Manager) error {
+	return mgr.Add(&ChannelHealth{
+		Client: mgr.GetClient(),
+		Reader: mgr.GetAPIReader(),
+		checks: map[string]checkFunc{
+			chnv1.ChannelTypeGit:          checkGitChannel,
+			chnv1.ChannelTypeGitHub:       checkGitChannel,
+			chnv1.ChannelTypeHelmRepo:     checkHelmRepoChannel,
+			chnv1.ChannelTypeObjectBucket: checkObjectBucketChannel,
+		},
+	})
+}
+
+// Start runs the channel health checks until the context is done
+func (c *ChannelHealth) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, c.checkChannels, checkInterval)
+
+	return nil
+}
+
+// checkChannels checks all the channels. The channels are read as unstructured objects, the channel API has no
+// typed status.
+func (c *ChannelHealth) checkChannels(ctx context.Context) {
+	chnList := &unstructured.UnstructuredList{}
+	chnList.SetGroupVersionKind(channelListGVK)
+
+	if err := c.Reader.List(ctx, chnList); err != nil {
+		klog.Errorf("Failed to list the channels, skip the channel health checks, err: %v", err)
+
+		return
+	}
+
+	// the series of the deleted channels are removed
+	metrics.ChannelConnected.Reset()
+
+	for i := range chnList.Items {
+		c.checkChannel(ctx, &chnList.Items[i])
+	}
+}
+
+func (c *ChannelHealth) checkChannel(ctx context.Context, obj *unstructured.Unstructured) {
+	chn := &chnv1.Channel{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, chn); err != nil {
+		klog.Errorf("Failed to convert the channel %v/%v, err: %v", obj.GetNamespace(), obj.GetName(), err)
+
+		return
+	}
+
+	chnType := strings.ToLower(string(chn.Spec.Type))
+
+	check, ok := c.checks[chnType]
+	if !ok {
+		return
+	}
+
+	cond := metav1.Condition{
+		Type:               ConditionConnected,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonConnected,
+		ObservedGeneration: chn.GetGeneration(),
+	}
+
+	// the channel creator chooses the channel pathname, the credentials of the secret provider are only sent to it
+	// for the subscriptions whose creator is allowed to use the secret provider reference
+	if utils.IsExternalChannelSecret(chn) {
+		cond.Status = metav1.ConditionUnknown
+		cond.Reason = ReasonSecretProvider
+		cond.Message = "the channels with a secret provider are not checked"
+
+		if err := c.setConnectedCondition(ctx, obj, cond); err != nil {
+			klog.Errorf("Failed to update the status of the channel %v/%v, err: %v", chn.Namespace, chn.Name, err)
+		}
+
+		return
+	}
+
+	connected := 1.0
+
+	if err := check(ctx, c.Client, chn); err != nil {
+		reason, metricReason := ReasonConnectionFailed, metrics.ReasonConnectionFailed
+
+		if isAuthError(err) {
+			reason, metricReason = ReasonAuthenticationFailed, metrics.ReasonAuthenticationFailed
+		}
+
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = reason
+		cond.Message = utils.RedactError(err)
+		connected = 0
+
+		klog.Warningf("Channel %v/%v health check failed, reason: %v, err: %v", chn.Namespace, chn.Name, reason,
+			cond.Message)
+		metrics.ChannelHealthCheckFailedCount.WithLabelValues(chnType, metricReason).Inc()
+	}
+
+	metrics.ChannelConnected.WithLabelValues(chn.Namespace, chn.Name, chnType).Set(connected)
+
+	if err := c.setConnectedCondition(ctx, obj, cond); err != nil {
+		klog.Errorf("Failed to update the status of the channel %v/%v, err: %v", chn.Namespace, chn.Name, err)
+	}
+}
+
+// setConnectedCondition patches the Connected condition of the channel status if it changed
+func (c *ChannelHealth) setConnectedCondition(ctx context.Context, obj *unstructured.Unstructured,
+	cond metav1.Condition) error {
+	conditions := []metav1.Condition{}
+
+	if raw, found, _ := unstructured.NestedSlice(obj.Object, "status", "conditions"); found {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(data, &conditions); err != nil {
+			klog.Warningf("Invalid conditions in the status of the channel %v/%v, they are replaced, err: %v",
+				obj.GetNamespace(), obj.GetName(), err)
+
+			conditions = []metav1.Condition{}
+		}
+	}
+
+	if !meta.SetStatusCondition(&conditions, cond) {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"conditions": conditions},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Status().Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch))
+}
+
+// isAuthError returns true if the channel server rejected the channel credentials
+func isAuthError(err error) bool {
+	if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		return true
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatusCode() == http.StatusUnauthorized || statusErr.HTTPStatusCode() == http.StatusForbidden
+	}
+
+	return strings.Contains(err.Error(), "unable to authenticate")
+}
+
+// checkGitChannel lists the references of the Git repository
+func checkGitChannel(_ context.Context, clt client.Client, chn *chnv1.Channel) error {
+	conn, err := utils.GetChannelConnectionConfig(clt, chn)
+	if err != nil {
+		return err
+	}
+
+	_, err = utils.ListGitRemoteRefs(conn)
+
+	return err
+}
+
+// checkObjectBucketChannel checks the bucket of the channel is accessible
+func checkObjectBucketChannel(_ context.Context, clt client.Client, chn *chnv1.Channel) error {
+	_, _, err := awsutils.InitChannelObjectStore(clt, chn)
+
+	return err
+}
+
+// httpStatusError is the error of an unexpected status code of the helm repo
+type httpStatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("http request %v failed: status %v", e.url, e.statusCode)
+}
+
+func (e *httpStatusError) HTTPStatusCode() int {
+	return e.statusCode
+}
+
+// checkHelmRepoChannel requests the index of the helm repo with a HEAD request, or with a GET request if the helm repo
+// doesn't allow the HEAD requests
+func checkHelmRepoChannel(ctx context.Context, clt client.Client, chn *chnv1.Channel) error {
+	secret, err := utils.ResolveChannelSecret(clt, chn)
+	if err != nil {
+		return fmt.Errorf("failed to get the secret of the channel, err: %w", err)
+	}
+
+	_, configMap := utils.FetchChannelReferences(clt, *chn)
+
+	httpClient, err := helmutils.GetHelmRepoClient(chn.Namespace, configMap, secret, chn.Spec.InsecureSkipVerify)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, helmCheckTimeout)
+	defer cancel()
+
+	indexURL := strings.TrimSuffix(chn.Spec.Pathname, "/") + "/index.yaml"
+
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, indexURL, nil)
+		if err != nil {
+			return err
+		}
+
+		if secret != nil {
+			if authHeader, ok := secret.Data["authHeader"]; ok {
+				req.Header.Set("Authorization", string(authHeader))
+			} else if user, ok := secret.Data["user"]; ok {
+				req.SetBasicAuth(string(user), string(secret.Data["password"]))
+			}
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			return &httpStatusError{url: indexURL, statusCode: resp.StatusCode}
+		}
+	}
+
+	return &httpStatusError{url: indexURL, statusCode: http.StatusMethodNotAllowed}
+}
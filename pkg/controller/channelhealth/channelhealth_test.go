@@ -0,0 +1,236 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channelhealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+)
+
+func newChannel(name string, chnType chnv1.ChannelType) *unstructured.Unstructured {
+	chn := &unstructured.Unstructured{}
+	chn.SetGroupVersionKind(chnv1.SchemeGroupVersion.WithKind("Channel"))
+	chn.SetNamespace("ns-ch")
+	chn.SetName(name)
+	chn.SetGeneration(2)
+	chn.Object["spec"] = map[string]interface{}{"type": string(chnType)}
+
+	return chn
+}
+
+func getConnectedCondition(g *gomega.WithT, clt client.Client, name string) *metav1.Condition {
+	chn := &unstructured.Unstructured{}
+	chn.SetGroupVersionKind(chnv1.SchemeGroupVersion.WithKind("Channel"))
+	g.Expect(clt.Get(context.TODO(), client.ObjectKey{Namespace: "ns-ch", Name: name}, chn)).To(gomega.Succeed())
+
+	raw, _, err := unstructured.NestedSlice(chn.Object, "status", "conditions")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	conditions := []metav1.Condition{}
+
+	for _, item := range raw {
+		cond := metav1.Condition{}
+		g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(item.(map[string]interface{}), &cond)).
+			To(gomega.Succeed())
+
+		conditions = append(conditions, cond)
+	}
+
+	return meta.FindStatusCondition(conditions, ConditionConnected)
+}
+
+func TestCheckChannels(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the channels are unstructured, the channel type is not registered so the fake client keeps their status
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+
+	healthy := newChannel("healthy", chnv1.ChannelTypeGit)
+	unauthorized := newChannel("unauthorized", chnv1.ChannelTypeHelmRepo)
+	unreachable := newChannel("unreachable", chnv1.ChannelTypeObjectBucket)
+	namespace := newChannel("namespace", chnv1.ChannelTypeNamespace)
+	provider := newChannel("provider", chnv1.ChannelTypeGit)
+	provider.SetAnnotations(map[string]string{appv1.AnnotationSecretProvider: "vault"})
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(healthy, unauthorized, unreachable, namespace, provider).
+		WithStatusSubresource(healthy, unauthorized, unreachable, namespace, provider).Build()
+
+	checked := []string{}
+	check := func(err error) checkFunc {
+		return func(_ context.Context, _ client.Client, chn *chnv1.Channel) error {
+			checked = append(checked, chn.Name)
+
+			return err
+		}
+	}
+
+	c := &ChannelHealth{
+		Client: clt,
+		Reader: clt,
+		checks: map[string]checkFunc{
+			chnv1.ChannelTypeGit:          check(nil),
+			chnv1.ChannelTypeHelmRepo:     check(&httpStatusError{url: "https://charts.example.com", statusCode: 401}),
+			chnv1.ChannelTypeObjectBucket: check(errors.New("dial tcp: i/o timeout")),
+		},
+	}
+
+	authFailures := testutil.ToFloat64(metrics.ChannelHealthCheckFailedCount.WithLabelValues(
+		chnv1.ChannelTypeHelmRepo, metrics.ReasonAuthenticationFailed))
+
+	c.checkChannels(context.TODO())
+
+	g.Expect(checked).To(gomega.ConsistOf("healthy", "unauthorized", "unreachable"))
+
+	cond := getConnectedCondition(g, clt, "healthy")
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionTrue))
+	g.Expect(cond.Reason).To(gomega.Equal(ReasonConnected))
+	g.Expect(cond.ObservedGeneration).To(gomega.Equal(int64(2)))
+
+	cond = getConnectedCondition(g, clt, "unauthorized")
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(gomega.Equal(ReasonAuthenticationFailed))
+
+	cond = getConnectedCondition(g, clt, "unreachable")
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(gomega.Equal(ReasonConnectionFailed))
+	g.Expect(cond.Message).To(gomega.ContainSubstring("i/o timeout"))
+
+	g.Expect(getConnectedCondition(g, clt, "namespace")).To(gomega.BeNil())
+
+	// the credentials of a secret provider are not sent to the channel pathname
+	cond = getConnectedCondition(g, clt, "provider")
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionUnknown))
+	g.Expect(cond.Reason).To(gomega.Equal(ReasonSecretProvider))
+
+	g.Expect(testutil.ToFloat64(metrics.ChannelConnected.WithLabelValues("ns-ch", "healthy",
+		chnv1.ChannelTypeGit))).To(gomega.Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.ChannelConnected.WithLabelValues("ns-ch", "unreachable",
+		chnv1.ChannelTypeObjectBucket))).To(gomega.Equal(0.0))
+	g.Expect(testutil.ToFloat64(metrics.ChannelHealthCheckFailedCount.WithLabelValues(
+		chnv1.ChannelTypeHelmRepo, metrics.ReasonAuthenticationFailed))).To(gomega.Equal(authFailures + 1))
+
+	// the channel recovered
+	c.checks[chnv1.ChannelTypeObjectBucket] = check(nil)
+	c.checkChannels(context.TODO())
+
+	cond = getConnectedCondition(g, clt, "unreachable")
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionTrue))
+	g.Expect(cond.Reason).To(gomega.Equal(ReasonConnected))
+}
+
+func TestIsAuthError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(isAuthError(fmt.Errorf("failed to list refs: %w", transport.ErrAuthenticationRequired))).To(gomega.BeTrue())
+	g.Expect(isAuthError(transport.ErrAuthorizationFailed)).To(gomega.BeTrue())
+	g.Expect(isAuthError(&httpStatusError{statusCode: http.StatusForbidden})).To(gomega.BeTrue())
+	g.Expect(isAuthError(&httpStatusError{statusCode: http.StatusNotFound})).To(gomega.BeFalse())
+	g.Expect(isAuthError(errors.New("ssh: handshake failed: ssh: unable to authenticate"))).To(gomega.BeTrue())
+	g.Expect(isAuthError(errors.New("dial tcp: i/o timeout"))).To(gomega.BeFalse())
+}
+
+func TestCheckHelmRepoChannel(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	methods := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+
+		if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		// the repo doesn't allow the HEAD requests
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if r.URL.Path != "/charts/index.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "helm-creds"},
+		Data:       map[string][]byte{"user": []byte("admin"), "password": []byte("secret")},
+	}
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	chn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "helm"},
+		Spec: chnv1.ChannelSpec{
+			Type:      chnv1.ChannelTypeHelmRepo,
+			Pathname:  server.URL + "/charts/",
+			SecretRef: &corev1.ObjectReference{Name: "helm-creds"},
+		},
+	}
+
+	g.Expect(checkHelmRepoChannel(context.TODO(), clt, chn)).To(gomega.Succeed())
+	g.Expect(methods).To(gomega.Equal([]string{http.MethodHead, http.MethodGet}))
+
+	// wrong credentials
+	secret.Data["password"] = []byte("wrong")
+	g.Expect(clt.Update(context.TODO(), secret)).To(gomega.Succeed())
+
+	err := checkHelmRepoChannel(context.TODO(), clt, chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(isAuthError(err)).To(gomega.BeTrue())
+
+	// missing secret
+	g.Expect(clt.Delete(context.TODO(), secret)).To(gomega.Succeed())
+
+	err = checkHelmRepoChannel(context.TODO(), clt, chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(isAuthError(err)).To(gomega.BeFalse())
+}
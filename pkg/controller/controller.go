@@ -15,10 +15,14 @@
 package controller
 
 import (
+	"sort"
+
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
 
 // AddToManagerMCMFuncs is a list of functions to add all MCM Controllers (with config to hub) to the Manager
@@ -30,8 +34,8 @@ var AddToManagerFuncs []func(manager.Manager) error
 // AddHelmToManagerFuncs is a list of functions to add helmrelease Controller to the Manager
 var AddHelmToManagerFuncs []func(manager.Manager) error
 
-// AddHubToManagerFuncs is a list of functions to add all Hub Controllers to the Manager
-var AddHubToManagerFuncs []func(manager.Manager) error
+// AddHubToManagerFuncs is a map of functions to add all Hub Controllers to the Manager, by controller name
+var AddHubToManagerFuncs = map[string]func(manager.Manager) error{}
 
 // AddAppSubSummaryToManagerFuncs is a list of functions to add all AppSubSummary Controllers to the Manager
 var AddAppSubSummaryToManagerFuncs []func(manager.Manager, int) error
@@ -65,10 +69,39 @@ func AddToManager(m manager.Manager, cfg *rest.Config, syncid *types.NamespacedN
 	return nil
 }
 
-// AddHubToManager adds all Hub Controllers to the Manager
-func AddHubToManager(m manager.Manager) error {
-	for _, f := range AddHubToManagerFuncs {
-		if err := f(m); err != nil {
+// HubControllerNames returns the sorted names of the Hub Controllers
+func HubControllerNames() []string {
+	names := []string{}
+	for name := range AddHubToManagerFuncs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// shardedHubControllers are the Hub Controllers reconciling the subscriptions of their shard only, the other Hub
+// Controllers are run by the first shard
+var shardedHubControllers = map[string]bool{"subscription": true}
+
+// AddHubToManager adds all Hub Controllers to the Manager, except the disabled ones. The shards other than the first
+// one only add the sharded Hub Controllers.
+func AddHubToManager(m manager.Manager, disabled map[string]bool) error {
+	for _, name := range HubControllerNames() {
+		if disabled[name] {
+			klog.Infof("The %v hub controller is disabled", name)
+
+			continue
+		}
+
+		if !shardedHubControllers[name] && !utils.IsFirstSubscriptionShard() {
+			klog.Infof("The %v hub controller is run by the first shard", name)
+
+			continue
+		}
+
+		if err := AddHubToManagerFuncs[name](m); err != nil {
 			return err
 		}
 	}
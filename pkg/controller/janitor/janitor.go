@@ -0,0 +1,262 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package janitor removes the subscription statuses and reports left on the hub by the deleted subscriptions and
+// the detached managed clusters
+package janitor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+)
+
+const (
+	labelCluster = "apps.open-cluster-management.io/cluster"
+	labelHosting = "apps.open-cluster-management.io/hosting-subscription"
+
+	// janitorInterval is the period of the stale object lookups
+	janitorInterval = 10 * time.Minute
+
+	// staleGracePeriod is the min age of a removed object, it leaves the time to the hub caches to see the new
+	// subscriptions and clusters
+	staleGracePeriod = 10 * time.Minute
+)
+
+// Janitor periodically removes the subscription statuses and reports whose subscription or cluster no longer
+// exists. Those are left behind when a cluster is detached or when the hub misses a subscription deletion.
+type Janitor struct {
+	client.Client
+
+	// Reader lists the statuses and reports from the API server, so they are not cached by the hub
+	Reader client.Reader
+
+	now func() time.Time
+}
+
+// Add adds the janitor to the hub manager, it runs on the leader only
+func Add(mgr manager.Manager) error {
+	return mgr.Add(&Janitor{
+		Client: mgr.GetClient(),
+		Reader: mgr.GetAPIReader(),
+		now:    time.Now,
+	})
+}
+
+// Start runs the janitor until the context is done
+func (j *Janitor) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, j.cleanup, janitorInterval)
+
+	return nil
+}
+
+// cleanup removes the stale statuses and reports. The cluster checks are skipped if the managed clusters can't be
+// listed, for example on a hub without the cluster API.
+func (j *Janitor) cleanup(ctx context.Context) {
+	klog.Info("Start removing the stale subscription statuses and reports")
+
+	subs, err := j.listSubscriptions(ctx)
+	if err != nil {
+		klog.Errorf("Failed to list the subscriptions, skip the stale object cleanup, err: %v", err)
+
+		return
+	}
+
+	clusters, err := j.listClusters(ctx)
+	if err != nil {
+		klog.Warningf("Failed to list the managed clusters, skip the cluster checks, err: %v", err)
+	}
+
+	j.cleanupStatuses(ctx, subs, clusters)
+	j.cleanupReports(ctx, subs, clusters)
+
+	klog.Info("Finish removing the stale subscription statuses and reports")
+}
+
+func (j *Janitor) listSubscriptions(ctx context.Context) (map[string]bool, error) {
+	subList := &appv1.SubscriptionList{}
+	if err := j.List(ctx, subList); err != nil {
+		return nil, err
+	}
+
+	subs := map[string]bool{}
+
+	for _, sub := range subList.Items {
+		subs[sub.Namespace+"/"+sub.Name] = true
+	}
+
+	return subs, nil
+}
+
+func (j *Janitor) listClusters(ctx context.Context) (map[string]bool, error) {
+	clusterList := &spokeClusterV1.ManagedClusterList{}
+	if err := j.List(ctx, clusterList); err != nil {
+		return nil, err
+	}
+
+	clusters := map[string]bool{}
+
+	for _, cluster := range clusterList.Items {
+		clusters[cluster.Name] = true
+	}
+
+	return clusters, nil
+}
+
+// cleanupStatuses deletes the subscription statuses of the cluster namespaces whose cluster or subscription is gone.
+// The status of a cluster is named after its subscription and labeled with the subscription namespace.
+func (j *Janitor) cleanupStatuses(ctx context.Context, subs, clusters map[string]bool) {
+	statusList := &appv1alpha1.SubscriptionStatusList{}
+	if err := j.Reader.List(ctx, statusList, client.HasLabels{labelCluster, labelHosting}); err != nil {
+		klog.Errorf("Failed to list the subscription statuses, err: %v", err)
+
+		return
+	}
+
+	for i := range statusList.Items {
+		status := &statusList.Items[i]
+
+		cluster := status.Labels[labelCluster]
+
+		// the statuses of the subscription namespaces belong to the local cluster
+		if status.Namespace != cluster || !j.isPastGracePeriod(status) {
+			continue
+		}
+
+		reason := ""
+
+		switch {
+		case clusters != nil && !clusters[cluster]:
+			reason = metrics.ReasonClusterNotFound
+		case !subs[statusSubscription(status)]:
+			reason = metrics.ReasonSubscriptionNotFound
+		default:
+			continue
+		}
+
+		if err := j.Delete(ctx, status); err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("Failed to delete the stale subscription status %v/%v, err: %v", status.Namespace, status.Name, err)
+
+			continue
+		}
+
+		klog.Infof("Stale subscription status %v/%v deleted, reason: %v", status.Namespace, status.Name, reason)
+		metrics.JanitorCleanedCount.WithLabelValues(metrics.KindSubscriptionStatus, reason).Inc()
+	}
+}
+
+// statusSubscription returns the namespace/name of the subscription of a cluster subscription status. The hosting
+// label is truncated, so the subscription name is taken from the status name.
+func statusSubscription(status *appv1alpha1.SubscriptionStatus) string {
+	namespace, _, _ := strings.Cut(status.Labels[labelHosting], ".")
+
+	return namespace + "/" + status.Name
+}
+
+// cleanupReports deletes the cluster reports of the removed clusters, removes the results of the deleted
+// subscriptions from the other cluster reports, and deletes the application reports of the deleted subscriptions.
+func (j *Janitor) cleanupReports(ctx context.Context, subs, clusters map[string]bool) {
+	reportList := &appv1alpha1.SubscriptionReportList{}
+	if err := j.Reader.List(ctx, reportList); err != nil {
+		klog.Errorf("Failed to list the subscription reports, err: %v", err)
+
+		return
+	}
+
+	for i := range reportList.Items {
+		report := &reportList.Items[i]
+
+		if !j.isPastGracePeriod(report) {
+			continue
+		}
+
+		switch report.ReportType {
+		case "Cluster":
+			if report.Labels[labelCluster] != "true" {
+				continue
+			}
+
+			if clusters != nil && !clusters[report.Namespace] {
+				j.deleteReport(ctx, report, metrics.ReasonClusterNotFound)
+
+				continue
+			}
+
+			j.pruneReportResults(ctx, report, subs)
+		case "Application":
+			if report.Labels[labelHosting] == "" || subs[report.Namespace+"/"+report.Name] {
+				continue
+			}
+
+			j.deleteReport(ctx, report, metrics.ReasonSubscriptionNotFound)
+		}
+	}
+}
+
+func (j *Janitor) deleteReport(ctx context.Context, report *appv1alpha1.SubscriptionReport, reason string) {
+	if err := j.Delete(ctx, report); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Failed to delete the stale subscription report %v/%v, err: %v", report.Namespace, report.Name, err)
+
+		return
+	}
+
+	klog.Infof("Stale subscription report %v/%v deleted, reason: %v", report.Namespace, report.Name, reason)
+	metrics.JanitorCleanedCount.WithLabelValues(metrics.KindSubscriptionReport, reason).Inc()
+}
+
+// pruneReportResults removes the results of the deleted subscriptions from a cluster report
+func (j *Janitor) pruneReportResults(ctx context.Context, report *appv1alpha1.SubscriptionReport, subs map[string]bool) {
+	results := []*appv1alpha1.SubscriptionReportResult{}
+
+	for _, result := range report.Results {
+		if result == nil || !subs[result.Source] {
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	pruned := len(report.Results) - len(results)
+	if pruned == 0 {
+		return
+	}
+
+	report.Results = results
+
+	if err := j.Update(ctx, report); err != nil {
+		klog.Errorf("Failed to remove the stale results of the subscription report %v/%v, err: %v",
+			report.Namespace, report.Name, err)
+
+		return
+	}
+
+	klog.Infof("%v stale results removed from the subscription report %v/%v", pruned, report.Namespace, report.Name)
+	metrics.JanitorCleanedCount.WithLabelValues(metrics.KindSubscriptionReportResult,
+		metrics.ReasonSubscriptionNotFound).Add(float64(pruned))
+}
+
+func (j *Janitor) isPastGracePeriod(obj client.Object) bool {
+	return j.now().Sub(obj.GetCreationTimestamp().Time) > staleGracePeriod
+}
@@ -0,0 +1,184 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package janitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+)
+
+var created = metav1.NewTime(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+func newStatus(cluster, namespace, appsubNs, appsubName string) *appv1alpha1.SubscriptionStatus {
+	return &appv1alpha1.SubscriptionStatus{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              appsubName,
+			Namespace:         namespace,
+			CreationTimestamp: created,
+			Labels: map[string]string{
+				labelCluster: cluster,
+				labelHosting: appsubNs + "." + appsubName,
+			},
+		},
+	}
+}
+
+func newClusterReport(cluster string, sources ...string) *appv1alpha1.SubscriptionReport {
+	report := &appv1alpha1.SubscriptionReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              cluster,
+			Namespace:         cluster,
+			CreationTimestamp: created,
+			Labels:            map[string]string{labelCluster: "true"},
+		},
+		ReportType: "Cluster",
+	}
+
+	for _, source := range sources {
+		report.Results = append(report.Results, &appv1alpha1.SubscriptionReportResult{Source: source, Result: "deployed"})
+	}
+
+	return report
+}
+
+func TestCleanup(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(spokeClusterV1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	appReport := &appv1alpha1.SubscriptionReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "app2",
+			Namespace:         "app-ns",
+			CreationTimestamp: created,
+			Labels:            map[string]string{labelHosting: "app-ns.app2"},
+		},
+		ReportType: "Application",
+	}
+
+	// a status created after the subscription deletion is kept during the grace period
+	recentStatus := newStatus("cluster1", "cluster1", "app-ns", "app3")
+	recentStatus.CreationTimestamp = metav1.NewTime(created.Add(staleGracePeriod))
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&spokeClusterV1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}},
+		&appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app1", Namespace: "app-ns"}},
+		newStatus("cluster1", "cluster1", "app-ns", "app1"),
+		newStatus("cluster1", "cluster1", "app-ns", "app2"),
+		newStatus("cluster2", "cluster2", "app-ns", "app1"),
+		newStatus("local-cluster", "app-ns", "app-ns", "app2"),
+		recentStatus,
+		newClusterReport("cluster1", "app-ns/app1", "app-ns/app2"),
+		newClusterReport("cluster2", "app-ns/app1"),
+		appReport,
+	).Build()
+
+	j := &Janitor{
+		Client: clt,
+		Reader: clt,
+		now:    func() time.Time { return created.Add(staleGracePeriod + time.Minute) },
+	}
+
+	statusCount := testutil.ToFloat64(
+		metrics.JanitorCleanedCount.WithLabelValues(metrics.KindSubscriptionStatus, metrics.ReasonSubscriptionNotFound))
+
+	j.cleanup(context.TODO())
+
+	statusList := &appv1alpha1.SubscriptionStatusList{}
+	g.Expect(clt.List(context.TODO(), statusList)).To(gomega.Succeed())
+
+	statuses := []string{}
+	for _, status := range statusList.Items {
+		statuses = append(statuses, status.Namespace+"/"+status.Name)
+	}
+
+	g.Expect(statuses).To(gomega.ConsistOf("cluster1/app1", "cluster1/app3", "app-ns/app2"))
+
+	g.Expect(testutil.ToFloat64(metrics.JanitorCleanedCount.WithLabelValues(
+		metrics.KindSubscriptionStatus, metrics.ReasonSubscriptionNotFound))).To(gomega.Equal(statusCount + 1))
+
+	// the report of the detached cluster is deleted, the results of the deleted subscriptions are removed
+	reportList := &appv1alpha1.SubscriptionReportList{}
+	g.Expect(clt.List(context.TODO(), reportList, client.InNamespace("cluster2"))).To(gomega.Succeed())
+	g.Expect(reportList.Items).To(gomega.BeEmpty())
+
+	report := &appv1alpha1.SubscriptionReport{}
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Name: "cluster1", Namespace: "cluster1"}, report)).To(gomega.Succeed())
+	g.Expect(report.Results).To(gomega.HaveLen(1))
+	g.Expect(report.Results[0].Source).To(gomega.Equal("app-ns/app1"))
+
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Name: "app2", Namespace: "app-ns"}, report)).NotTo(gomega.Succeed())
+}
+
+func TestCleanupWithoutClusterAPI(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(spokeClusterV1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app1", Namespace: "app-ns"}},
+		newStatus("cluster1", "cluster1", "app-ns", "app1"),
+		newClusterReport("cluster1", "app-ns/app1"),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, clt client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*spokeClusterV1.ManagedClusterList); ok {
+				return &meta.NoKindMatchError{GroupKind: spokeClusterV1.SchemeGroupVersion.WithKind("ManagedCluster").GroupKind()}
+			}
+
+			return clt.List(ctx, list, opts...)
+		},
+	}).Build()
+
+	j := &Janitor{
+		Client: clt,
+		Reader: clt,
+		now:    func() time.Time { return created.Add(staleGracePeriod + time.Minute) },
+	}
+
+	// the cluster checks are skipped
+	j.cleanup(context.TODO())
+
+	statusList := &appv1alpha1.SubscriptionStatusList{}
+	g.Expect(clt.List(context.TODO(), statusList)).To(gomega.Succeed())
+	g.Expect(statusList.Items).To(gomega.HaveLen(1))
+
+	reportList := &appv1alpha1.SubscriptionReportList{}
+	g.Expect(clt.List(context.TODO(), reportList)).To(gomega.Succeed())
+	g.Expect(reportList.Items).To(gomega.HaveLen(1))
+}
@@ -16,12 +16,12 @@ package mcmhub
 
 import (
 	"context"
-	"crypto/sha1" // #nosec G505 Used only to convert sync time string to a hash
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -31,6 +31,7 @@ import (
 	"k8s.io/klog"
 	ansiblejob "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/ansible/v1alpha1"
 	subv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -317,22 +318,22 @@ func getSyncTimeHash(syncTimeAnnotation string) string {
 		return ""
 	}
 
-	h := sha1.New() // #nosec G401 Used only to convert sync time string to a hash
+	h := utils.NewNameHash()
 	_, err := h.Write([]byte(syncTimeAnnotation))
 
 	if err != nil {
 		return ""
 	}
 
-	sha1Hash := hex.EncodeToString(h.Sum(nil))
+	nameHash := hex.EncodeToString(h.Sum(nil))
 
-	return sha1Hash[:6]
+	return nameHash[:6]
 }
 
 // applyjobs will get the original job and create a instance, the applied
 // instance will is put into the job.Instance array upon the success of the
 // creation
-func (jIns *JobInstances) applyJobs(clt client.Client, subIns *subv1.Subscription, logger logr.Logger) error {
+func (jIns *JobInstances) applyJobs(clt client.Client, rec *utils.EventRecorder, subIns *subv1.Subscription, logger logr.Logger) error {
 	if utils.IsSubscriptionBeDeleted(clt, types.NamespacedName{Name: subIns.GetName(), Namespace: subIns.GetNamespace()}) {
 		return nil
 	}
@@ -370,6 +371,9 @@ func (jIns *JobInstances) applyJobs(clt client.Client, subIns *subv1.Subscriptio
 				if !kerr.IsAlreadyExists(err) {
 					return fmt.Errorf("failed to apply job %v, err: %w", jKey, err)
 				}
+			} else if rec != nil {
+				rec.RecordEvent(subIns, utils.EventReasonHookStarted,
+					fmt.Sprintf("Started %vhook ansible job %v", nx.GetAnnotations()[subv1.AnnotationHookType], jKey.String()), nil)
 			}
 
 			logger.Info(fmt.Sprintf("applied ansiblejob %s/%s", nx.GetNamespace(), nx.GetName()))
@@ -383,7 +387,8 @@ func (jIns *JobInstances) applyJobs(clt client.Client, subIns *subv1.Subscriptio
 
 // check the last instance of the ansiblejobs to see if it's applied and
 // completed or not
-func (jIns *JobInstances) isJobsCompleted(clt client.Client, logger logr.Logger) (bool, error) {
+func (jIns *JobInstances) isJobsCompleted(clt client.Client, rec *utils.EventRecorder, subIns *subv1.Subscription,
+	logger logr.Logger) (bool, error) {
 	for _, job := range *jIns {
 		n := len(job.Instance)
 		if n == 0 {
@@ -395,7 +400,7 @@ func (jIns *JobInstances) isJobsCompleted(clt client.Client, logger logr.Logger)
 
 		logger.Info(fmt.Sprintf("checking if %v job for completed or not", jKey.String()))
 
-		if ok, err := isJobDone(clt, jKey, logger); err != nil || !ok {
+		if ok, err := isJobDone(clt, rec, subIns, jKey, logger); err != nil || !ok {
 			return ok, err
 		}
 	}
@@ -403,7 +408,8 @@ func (jIns *JobInstances) isJobsCompleted(clt client.Client, logger logr.Logger)
 	return true, nil
 }
 
-func isJobDone(clt client.Client, key types.NamespacedName, logger logr.Logger) (bool, error) {
+func isJobDone(clt client.Client, rec *utils.EventRecorder, subIns *subv1.Subscription, key types.NamespacedName,
+	logger logr.Logger) (bool, error) {
 	job := &ansiblejob.AnsibleJob{}
 
 	if err := clt.Get(context.TODO(), key, job); err != nil {
@@ -411,6 +417,8 @@ func isJobDone(clt client.Client, key types.NamespacedName, logger logr.Logger)
 		if kerr.IsNotFound(err) {
 			logger.Info(fmt.Sprintf("ansible job not found, job: %v, err: %v", key.String(), err))
 
+			forgetHookJob(key)
+
 			return false, nil
 		}
 
@@ -421,6 +429,12 @@ func isJobDone(clt client.Client, key types.NamespacedName, logger logr.Logger)
 
 	if isJobRunSuccessful(job, logger) {
 		logger.Info(fmt.Sprintf("ansible job done, job: %v", key.String()))
+
+		if observeHookJobTime(job) && rec != nil && subIns != nil {
+			rec.RecordEvent(subIns, utils.EventReasonHookCompleted,
+				fmt.Sprintf("Completed %vhook ansible job %v", job.GetAnnotations()[subv1.AnnotationHookType], key.String()), nil)
+		}
+
 		return true, nil
 	}
 
@@ -429,6 +443,65 @@ func isJobDone(clt client.Client, key types.NamespacedName, logger logr.Logger)
 	return false, nil
 }
 
+// observedHookJob is the completed ansible job observed by the hook metrics and the subscription hosting it
+type observedHookJob struct {
+	uid     types.UID
+	hosting string
+}
+
+// observedHookJobs tracks the completed ansible jobs already observed by the hook metrics, since the completion of a
+// job is checked on every reconcile. It is keyed by the job namespaced name, the entries are removed when the job or
+// its subscription is gone.
+var observedHookJobs sync.Map
+
+// observeHookJobTime returns true the first time the completed job is observed
+func observeHookJobTime(job *ansiblejob.AnsibleJob) bool {
+	key := types.NamespacedName{Namespace: job.GetNamespace(), Name: job.GetName()}
+	observed := observedHookJob{uid: job.GetUID(), hosting: job.GetAnnotations()[subv1.AnnotationHosting]}
+
+	if prev, loaded := observedHookJobs.LoadOrStore(key, observed); loaded {
+		// a job recreated with the same name is a new run
+		if prev.(observedHookJob).uid == observed.uid {
+			return false
+		}
+
+		observedHookJobs.Store(key, observed)
+	}
+
+	elapsed, err := strconv.ParseFloat(job.Status.AnsibleJobResult.Elapsed, 64)
+	if err != nil {
+		klog.V(1).Infof("failed to parse the elapsed time of ansible job %v/%v, err: %v", job.GetNamespace(), job.GetName(), err)
+
+		return true
+	}
+
+	subNs, subName := utils.ParseNamespacedName(job.GetAnnotations()[subv1.AnnotationHosting])
+
+	metrics.HookJobTime.
+		WithLabelValues(subNs, subName, job.GetAnnotations()[subv1.AnnotationHookType]).
+		Observe(elapsed * 1000)
+
+	return true
+}
+
+// forgetHookJob removes the job from the observed hook jobs
+func forgetHookJob(key types.NamespacedName) {
+	observedHookJobs.Delete(key)
+}
+
+// forgetSubscriptionHookJobs removes the jobs of the subscription from the observed hook jobs
+func forgetSubscriptionHookJobs(subKey types.NamespacedName) {
+	hosting := subKey.String()
+
+	observedHookJobs.Range(func(key, value interface{}) bool {
+		if value.(observedHookJob).hosting == hosting {
+			observedHookJobs.Delete(key)
+		}
+
+		return true
+	})
+}
+
 // Check if last job is running or already done
 // The last job could have not been created in k8s. e.g. posthook job will be created only after prehook jobs
 // and main subscription are done. But the posthook jobs have been created in memory ansible job list.
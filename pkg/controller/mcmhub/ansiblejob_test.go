@@ -0,0 +1,62 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ansiblejob "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/ansible/v1alpha1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestObserveHookJobTime(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newJob := func(name, uid string) *ansiblejob.AnsibleJob {
+		return &ansiblejob.AnsibleJob{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(uid),
+			Annotations: map[string]string{appv1.AnnotationHosting: "default/app", appv1.AnnotationHookType: "prehook"}}}
+	}
+
+	jobKey := types.NamespacedName{Namespace: "default", Name: "prehook-1"}
+	subKey := types.NamespacedName{Namespace: "default", Name: "app"}
+
+	// the completed job is observed once
+	g.Expect(observeHookJobTime(newJob("prehook-1", "uid-1"))).To(gomega.BeTrue())
+	g.Expect(observeHookJobTime(newJob("prehook-1", "uid-1"))).To(gomega.BeFalse())
+
+	// a job recreated with the same name is observed again
+	g.Expect(observeHookJobTime(newJob("prehook-1", "uid-2"))).To(gomega.BeTrue())
+
+	// the deleted job is forgotten
+	forgetHookJob(jobKey)
+
+	_, found := observedHookJobs.Load(jobKey)
+	g.Expect(found).To(gomega.BeFalse())
+
+	// the jobs of the deleted subscription are forgotten
+	g.Expect(observeHookJobTime(newJob("prehook-1", "uid-2"))).To(gomega.BeTrue())
+	g.Expect(observeHookJobTime(newJob("posthook-1", "uid-3"))).To(gomega.BeTrue())
+
+	forgetSubscriptionHookJobs(subKey)
+
+	observedHookJobs.Range(func(key, value interface{}) bool {
+		t.Errorf("hook job %v of the deleted subscription is still observed", key)
+
+		return true
+	})
+}
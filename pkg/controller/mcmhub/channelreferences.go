@@ -0,0 +1,86 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// channelReferencesMapper maps the ConfigMaps or Secrets of the given kind to the subscriptions whose primary,
+// secondary or fallback channels reference them. Only the object metadata is watched.
+type channelReferencesMapper struct {
+	client.Client
+	kind string
+}
+
+func (mapper *channelReferencesMapper) Map(ctx context.Context, obj *metav1.PartialObjectMetadata) []reconcile.Request {
+	// if a channel secret or configmap is created/updated/deleted, the subscriptions of the channel should be
+	// reconciled to propagate the new channel references version.
+	var requests []reconcile.Request
+
+	subs, err := utils.ListChannelReferenceSubscriptions(ctx, mapper.Client, mapper.kind, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		klog.Error("Listing channel reference subscriptions in channelReferencesMapper and got error:", err)
+	}
+
+	for _, sub := range subs {
+		requests = append(requests, reconcile.Request{NamespacedName: sub})
+	}
+
+	if len(requests) > 0 {
+		klog.V(1).Info("Out channel references mapper with requests:", requests)
+	}
+
+	return requests
+}
+
+// getChannelReferencesVersion returns the version of the secrets and configmaps referenced by the channels of the
+// subscription, empty if the channels have no reference. The managed clusters refresh the channel credentials of the
+// subscription when the version changes.
+func (r *ReconcileSubscription) getChannelReferencesVersion(sub *appv1.Subscription) string {
+	primary, secondary, err := GetSubscriptionRefChannel(r.Client, sub)
+	if err != nil || primary == nil {
+		return ""
+	}
+
+	item := &appv1.SubscriberItem{}
+	item.ChannelSecret, item.ChannelConfigMap = utils.FetchChannelReferences(r.Client, *primary)
+
+	if secondary != nil {
+		item.SecondaryChannelSecret, item.SecondaryChannelConfigMap = utils.FetchChannelReferences(r.Client, *secondary)
+	}
+
+	fallbackChannels, err := GetSubscriptionFallbackChannels(r.Client, sub)
+	if err != nil {
+		return ""
+	}
+
+	for _, chn := range fallbackChannels {
+		fallback := appv1.SubscriberChannel{Channel: chn}
+		fallback.Secret, fallback.ConfigMap = utils.FetchChannelReferences(r.Client, *chn)
+
+		item.FallbackChannels = append(item.FallbackChannels, fallback)
+	}
+
+	return utils.GetChannelReferencesVersion(item)
+}
@@ -0,0 +1,264 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	manifestWorkV1 "open-cluster-management.io/api/work/v1"
+	appSubV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appSubStatusV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+const (
+	clusterRetryBaseInterval = 30 * time.Second
+	clusterRetryMaxInterval  = 10 * time.Minute
+	clusterMaxRetries        = 5
+	clusterDeployFailed      = "failed"
+)
+
+// clusterRetryBackoff returns the exponential backoff to wait after the given number of retries
+func clusterRetryBackoff(retries int) time.Duration {
+	backoff := clusterRetryBaseInterval
+
+	for i := 0; i < retries && backoff < clusterRetryMaxInterval; i++ {
+		backoff *= 2
+	}
+
+	if backoff > clusterRetryMaxInterval {
+		backoff = clusterRetryMaxInterval
+	}
+
+	return backoff
+}
+
+// retryFailedClusters requeues the deployment on the managed clusters reporting the appsub as failed.
+// A retry bumps the manual refresh time of the appsub in the cluster manifestWork, so that only the failed
+// clusters redeploy. It returns the time to wait before the next retry is due, 0 if there is no retry pending.
+func (r *ReconcileSubscription) retryFailedClusters(instance *appSubV1.Subscription) time.Duration {
+	children, err := r.getManifestWorkFamily(instance)
+	if err != nil {
+		klog.Errorf("failed to get manifestWorks to retry failed clusters, appsub: %v/%v, err: %v", instance.Namespace, instance.Name, err)
+
+		return 0
+	}
+
+	var requeueAfter time.Duration
+
+	now := r.clk()
+
+	for _, manifestWork := range children {
+		// only the manifestWork chunk carrying the appsub refreshes it
+		if !hasAppsubManifest(manifestWork) {
+			continue
+		}
+
+		original := manifestWork.DeepCopy()
+
+		nextRetry := r.setClusterRetry(manifestWork.Namespace, instance, manifestWork, now)
+		if nextRetry > 0 && (requeueAfter == 0 || nextRetry < requeueAfter) {
+			requeueAfter = nextRetry
+		}
+
+		if equality.Semantic.DeepEqual(original.GetAnnotations(), manifestWork.GetAnnotations()) {
+			continue
+		}
+
+		if err := r.Update(context.TODO(), manifestWork); err != nil {
+			klog.Errorf("failed to update manifestWork %v/%v for retry, err: %v", manifestWork.Namespace, manifestWork.Name, err)
+		}
+	}
+
+	return requeueAfter
+}
+
+// setClusterRetry updates the retry annotations of the cluster manifestWork based on the cluster appsubReport.
+// It returns the time to wait before the next retry is due, 0 if there is no retry pending.
+func (r *ReconcileSubscription) setClusterRetry(cluster string, instance *appSubV1.Subscription,
+	manifestWork *manifestWorkV1.ManifestWork, now time.Time) time.Duration {
+	annotations := manifestWork.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	_, retrying := annotations[appSubV1.AnnotationDeployRetryCount]
+
+	if !r.isClusterDeployFailed(cluster, instance) {
+		if retrying {
+			klog.Infof("cluster %v recovered after %v retries, appsub: %v/%v", cluster,
+				annotations[appSubV1.AnnotationDeployRetryCount], instance.Namespace, instance.Name)
+
+			// the last retry time is kept so the appsub on the managed cluster is not refreshed again
+			resetClusterRetry(annotations)
+			manifestWork.SetAnnotations(annotations)
+		}
+
+		return 0
+	}
+
+	// the retries are counted for the deployed appsub and commit, a new generation or commit gets new retries
+	retryFor := getClusterRetryFor(instance)
+
+	if retrying && annotations[appSubV1.AnnotationDeployRetryFor] != retryFor {
+		klog.Infof("appsub changed since the retries of cluster %v, the retries are reset, appsub: %v/%v", cluster,
+			instance.Namespace, instance.Name)
+
+		resetClusterRetry(annotations)
+
+		retrying = false
+	}
+
+	retries, _ := strconv.Atoi(annotations[appSubV1.AnnotationDeployRetryCount])
+
+	if retrying && retries >= clusterMaxRetries {
+		return 0
+	}
+
+	nextRetryTime, err := time.Parse(time.RFC3339, annotations[appSubV1.AnnotationDeployNextRetryTime])
+	if !retrying || err != nil {
+		// first time the failure is seen, schedule the first retry
+		nextRetryTime = now.Add(clusterRetryBackoff(retries))
+
+		annotations[appSubV1.AnnotationDeployRetryCount] = strconv.Itoa(retries)
+		annotations[appSubV1.AnnotationDeployRetryFor] = retryFor
+		annotations[appSubV1.AnnotationDeployNextRetryTime] = nextRetryTime.UTC().Format(time.RFC3339)
+		manifestWork.SetAnnotations(annotations)
+
+		return nextRetryTime.Sub(now)
+	}
+
+	if now.Before(nextRetryTime) {
+		return nextRetryTime.Sub(now)
+	}
+
+	retries++
+
+	annotations[appSubV1.AnnotationDeployRetryCount] = strconv.Itoa(retries)
+	annotations[appSubV1.AnnotationDeployRetryTime] = now.UTC().Format(time.RFC3339)
+
+	msg := fmt.Sprintf("Retry %v/%v deployment on failed cluster %v", retries, clusterMaxRetries, cluster)
+
+	var requeueAfter time.Duration
+
+	if retries >= clusterMaxRetries {
+		delete(annotations, appSubV1.AnnotationDeployNextRetryTime)
+
+		msg += ", no more retries"
+	} else {
+		requeueAfter = clusterRetryBackoff(retries)
+		annotations[appSubV1.AnnotationDeployNextRetryTime] = now.Add(requeueAfter).UTC().Format(time.RFC3339)
+	}
+
+	manifestWork.SetAnnotations(annotations)
+
+	if err := setManifestWorkRetryTime(manifestWork); err != nil {
+		klog.Errorf("failed to set retry time in manifestWork %v/%v, err: %v", manifestWork.Namespace, manifestWork.Name, err)
+	}
+
+	klog.Infof("%v, appsub: %v/%v", msg, instance.Namespace, instance.Name)
+	r.eventRecorder.RecordEvent(instance, "Retry", msg, nil)
+
+	metrics.PropagationClusterRetryCount.
+		WithLabelValues(instance.Namespace, instance.Name).
+		Inc()
+
+	return requeueAfter
+}
+
+// getClusterRetryFor returns the appsub generation and commit the retries of the failed clusters are counted for
+func getClusterRetryFor(instance *appSubV1.Subscription) string {
+	return fmt.Sprintf("%v/%v", instance.GetGeneration(), utils.GetAppsubCurrentCommit(instance))
+}
+
+// resetClusterRetry removes the retry count of the cluster manifestWork annotations
+func resetClusterRetry(annotations map[string]string) {
+	delete(annotations, appSubV1.AnnotationDeployRetryCount)
+	delete(annotations, appSubV1.AnnotationDeployRetryFor)
+	delete(annotations, appSubV1.AnnotationDeployNextRetryTime)
+}
+
+// isClusterDeployFailed checks if the cluster appsubReport reports the appsub deployment as failed
+func (r *ReconcileSubscription) isClusterDeployFailed(cluster string, instance *appSubV1.Subscription) bool {
+	appsubReport := &appSubStatusV1alpha1.SubscriptionReport{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: cluster, Name: cluster}, appsubReport); err != nil {
+		klog.V(1).Infof("failed to get cluster appsubReport %v, err: %v", cluster, err)
+
+		return false
+	}
+
+	source := instance.Namespace + "/" + instance.Name
+
+	for _, result := range appsubReport.Results {
+		if result.Source == source {
+			return result.Result == clusterDeployFailed
+		}
+	}
+
+	return false
+}
+
+// setManifestWorkRetryTime sets the last retry time of the manifestWork as the manual refresh time of the appsub
+// it carries, so that the subscriber on the managed cluster restarts and redeploys
+func setManifestWorkRetryTime(manifestWork *manifestWorkV1.ManifestWork) error {
+	retryTime := manifestWork.GetAnnotations()[appSubV1.AnnotationDeployRetryTime]
+	if retryTime == "" {
+		return nil
+	}
+
+	for i, manifest := range manifestWork.Spec.Workload.Manifests {
+		obj := &unstructured.Unstructured{}
+
+		if err := json.Unmarshal(manifest.Raw, obj); err != nil {
+			return err
+		}
+
+		if obj.GetKind() != "Subscription" {
+			continue
+		}
+
+		subAnnotations := obj.GetAnnotations()
+		if subAnnotations == nil {
+			subAnnotations = make(map[string]string)
+		}
+
+		// a manual refresh triggered later by the user takes precedence
+		if subAnnotations[appSubV1.AnnotationManualReconcileTime] >= retryTime {
+			return nil
+		}
+
+		subAnnotations[appSubV1.AnnotationManualReconcileTime] = retryTime
+		obj.SetAnnotations(subAnnotations)
+
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+
+		manifestWork.Spec.Workload.Manifests[i].Raw = raw
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	manifestWorkV1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appSubV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appSubStatusV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+func TestClusterRetryBackoff(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(clusterRetryBackoff(0)).To(gomega.Equal(clusterRetryBaseInterval))
+	g.Expect(clusterRetryBackoff(1)).To(gomega.Equal(2 * clusterRetryBaseInterval))
+	g.Expect(clusterRetryBackoff(2)).To(gomega.Equal(4 * clusterRetryBaseInterval))
+	g.Expect(clusterRetryBackoff(100)).To(gomega.Equal(clusterRetryMaxInterval))
+}
+
+func TestSetManifestWorkRetryTime(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	appsub := &appSubV1.Subscription{}
+	appsub.SetGroupVersionKind(appSubV1.SchemeGroupVersion.WithKind("Subscription"))
+	appsub.SetName("test-sub")
+	appsub.SetNamespace("test-ns")
+
+	raw, err := json.Marshal(appsub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	manifestWork := &manifestWorkV1.ManifestWork{}
+	manifestWork.Spec.Workload.Manifests = []manifestWorkV1.Manifest{{RawExtension: runtime.RawExtension{Raw: raw}}}
+
+	getRefreshTime := func() string {
+		obj := &unstructured.Unstructured{}
+		g.Expect(json.Unmarshal(manifestWork.Spec.Workload.Manifests[0].Raw, obj)).To(gomega.Succeed())
+
+		return obj.GetAnnotations()[appSubV1.AnnotationManualReconcileTime]
+	}
+
+	// no retry yet, the appsub is untouched
+	g.Expect(setManifestWorkRetryTime(manifestWork)).To(gomega.Succeed())
+	g.Expect(getRefreshTime()).To(gomega.BeEmpty())
+
+	retryTime := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	manifestWork.SetAnnotations(map[string]string{appSubV1.AnnotationDeployRetryTime: retryTime})
+
+	g.Expect(setManifestWorkRetryTime(manifestWork)).To(gomega.Succeed())
+	g.Expect(getRefreshTime()).To(gomega.Equal(retryTime))
+
+	// a later manual refresh is kept
+	manifestWork.SetAnnotations(map[string]string{
+		appSubV1.AnnotationDeployRetryTime: time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+	})
+
+	g.Expect(setManifestWorkRetryTime(manifestWork)).To(gomega.Succeed())
+	g.Expect(getRefreshTime()).To(gomega.Equal(retryTime))
+}
+
+func TestSetClusterRetryReset(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(appSubStatusV1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	appsubReport := &appSubStatusV1alpha1.SubscriptionReport{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster1"},
+		Results: []*appSubStatusV1alpha1.SubscriptionReportResult{
+			{Source: "test-ns/test-sub", Result: clusterDeployFailed},
+		},
+	}
+
+	r := &ReconcileSubscription{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(appsubReport).Build(),
+		eventRecorder: &utils.EventRecorder{EventRecorder: record.NewFakeRecorder(10)},
+	}
+
+	appsub := &appSubV1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-sub",
+			Namespace:   "test-ns",
+			Generation:  1,
+			Annotations: map[string]string{appSubV1.AnnotationGitCommit: "commit1"},
+		},
+	}
+
+	now := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	manifestWork := &manifestWorkV1.ManifestWork{}
+
+	// all the retries of the commit are done
+	manifestWork.SetAnnotations(map[string]string{
+		appSubV1.AnnotationDeployRetryCount: "5",
+		appSubV1.AnnotationDeployRetryFor:   "1/commit1",
+	})
+
+	g.Expect(r.setClusterRetry("cluster1", appsub, manifestWork, now)).To(gomega.BeZero())
+	g.Expect(manifestWork.GetAnnotations()[appSubV1.AnnotationDeployRetryCount]).To(gomega.Equal("5"))
+
+	// a new commit gets new retries
+	appsub.SetAnnotations(map[string]string{appSubV1.AnnotationGitCommit: "commit2"})
+
+	g.Expect(r.setClusterRetry("cluster1", appsub, manifestWork, now)).To(gomega.Equal(clusterRetryBackoff(0)))
+	g.Expect(manifestWork.GetAnnotations()[appSubV1.AnnotationDeployRetryCount]).To(gomega.Equal("0"))
+	g.Expect(manifestWork.GetAnnotations()[appSubV1.AnnotationDeployRetryFor]).To(gomega.Equal("1/commit2"))
+
+	// so does a new generation
+	manifestWork.GetAnnotations()[appSubV1.AnnotationDeployRetryCount] = "5"
+	appsub.SetGeneration(2)
+
+	g.Expect(r.setClusterRetry("cluster1", appsub, manifestWork, now)).To(gomega.Equal(clusterRetryBackoff(0)))
+	g.Expect(manifestWork.GetAnnotations()[appSubV1.AnnotationDeployRetryCount]).To(gomega.Equal("0"))
+	g.Expect(manifestWork.GetAnnotations()[appSubV1.AnnotationDeployRetryFor]).To(gomega.Equal("2/commit2"))
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+type deploymentWindowMapper struct {
+	client.Client
+}
+
+func (mapper *deploymentWindowMapper) Map(ctx context.Context, obj *appv1.DeploymentWindow) []reconcile.Request {
+	// if a deployment window is created/updated/deleted, the subscriptions referencing it should be reconciled.
+	var requests []reconcile.Request
+
+	subList := &appv1.SubscriptionList{}
+
+	if err := mapper.List(context.TODO(), subList, &client.ListOptions{}); err != nil {
+		klog.Error("Listing all subscriptions in deploymentWindowMapper and got error:", err)
+	}
+
+	for _, sub := range subList.Items {
+		ref := sub.Spec.DeploymentWindowRef
+		if ref == nil {
+			continue
+		}
+
+		refNs := ref.Namespace
+		if refNs == "" {
+			refNs = sub.GetNamespace()
+		}
+
+		if ref.Name != obj.GetName() || refNs != obj.GetNamespace() {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: sub.GetName(), Namespace: sub.GetNamespace()}})
+	}
+
+	klog.V(1).Info("Out deployment window mapper with requests:", requests)
+
+	return requests
+}
+
+// getTimeWindow returns the time window the subscription is handled in, after resolving its deployment window
+// reference and the emergency-deploy annotation. The subscription is blocked if its deployment window can't be
+// resolved, the error is returned along with the blocking time window.
+func (r *ReconcileSubscription) getTimeWindow(instance *appv1.Subscription) (*appv1.TimeWindow, error) {
+	tw, err := utils.GetEffectiveTimeWindow(r.Client, instance)
+	if err != nil {
+		klog.Errorf("blocking subscription %v/%v until its deployment window is resolved, err: %v", instance.GetNamespace(),
+			instance.GetName(), err)
+	}
+
+	return tw, err
+}
+
+// auditEmergencyDeploy records a warning event each time the emergency-deploy annotation of the subscription is set
+// or its reason changed, the time windows of the subscription are bypassed as long as the annotation is set
+func (r *ReconcileSubscription) auditEmergencyDeploy(instance *appv1.Subscription) {
+	key := types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()}.String()
+
+	reason, ok := utils.GetEmergencyDeployReason(instance)
+	if !ok {
+		r.emergencyDeploys.Delete(key)
+
+		return
+	}
+
+	if last, found := r.emergencyDeploys.Load(key); found && last == reason {
+		return
+	}
+
+	r.emergencyDeploys.Store(key, reason)
+
+	msg := fmt.Sprintf("Emergency deployment bypassing the time window, reason: %v", reason)
+
+	klog.Warningf("subscription %v: %v", key, msg)
+
+	if r.eventRecorder != nil {
+		r.eventRecorder.RecordEvent(instance, utils.EventReasonEmergencyDeploy, msg, fmt.Errorf("emergency deployment"))
+	}
+}
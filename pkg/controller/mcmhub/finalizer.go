@@ -0,0 +1,92 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// the requeue period while the managed clusters are cleaning up the resources of a deleted subscription
+const manifestWorkCleanupRequeuePeriod = 10 * time.Second
+
+// setCleanupFinalizer adds the manifestWork cleanup finalizer to a subscription propagated to the managed clusters when
+// it has a deletionPolicy, and removes it otherwise. The change is committed by finalCommit.
+func setCleanupFinalizer(instance *appv1.Subscription) {
+	if instance.Spec.DeletionPolicy != "" {
+		ctrlutil.AddFinalizer(instance, appv1.FinalizerManifestWorkCleanup)
+	} else {
+		ctrlutil.RemoveFinalizer(instance, appv1.FinalizerManifestWorkCleanup)
+	}
+}
+
+// removeCleanupFinalizer removes the manifestWork cleanup finalizer from a subscription that is no longer propagated
+// to the managed clusters.
+func (r *ReconcileSubscription) removeCleanupFinalizer(instance *appv1.Subscription) error {
+	if !ctrlutil.RemoveFinalizer(instance, appv1.FinalizerManifestWorkCleanup) {
+		return nil
+	}
+
+	return r.Update(context.TODO(), instance)
+}
+
+// finalizeSubscription deletes the manifestWorks of a deleted subscription, and removes the manifestWork cleanup
+// finalizer once the work agents confirm the deletion of the subscription on every managed cluster. The subscription
+// agent holds the deletion of the managed subscription until its resources are deleted or orphaned.
+func (r *ReconcileSubscription) finalizeSubscription(instance *appv1.Subscription) (reconcile.Result, error) {
+	if !ctrlutil.ContainsFinalizer(instance, appv1.FinalizerManifestWorkCleanup) {
+		return reconcile.Result{}, nil
+	}
+
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+
+	if err := r.cleanupManifestWork(key); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	manifestWorks, err := r.getManifestWorkFamily(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if len(manifestWorks) > 0 {
+		clusters := map[string]struct{}{}
+		for _, mw := range manifestWorks {
+			clusters[mw.Namespace] = struct{}{}
+		}
+
+		klog.Infof("waiting for %d managed clusters to clean up the resources of subscription %v", len(clusters), key.String())
+
+		return reconcile.Result{RequeueAfter: manifestWorkCleanupRequeuePeriod}, nil
+	}
+
+	ctrlutil.RemoveFinalizer(instance, appv1.FinalizerManifestWorkCleanup)
+
+	if err := r.Update(context.TODO(), instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	klog.Infof("subscription %v is finalized", key.String())
+
+	return reconcile.Result{}, nil
+}
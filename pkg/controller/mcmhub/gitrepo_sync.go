@@ -15,6 +15,7 @@
 package mcmhub
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -45,10 +46,21 @@ type kubeResourceMetadata struct {
 	Namespace string `yaml:"namespace"`
 }
 
-// GetGitResources clones the git repo and regenerate deployables and update annotation if needed
-func (r *ReconcileSubscription) GetGitResources(sub *appv1.Subscription, isAdmin bool) ([]*v1.ObjectReference, error) {
+// gitResources are the resources of a Git repository, the object references of the topology and the rendered
+// manifests of the pre-flight validation
+type gitResources struct {
+	objRefMap map[v1.ObjectReference]*v1.ObjectReference
+	manifests []*unstructured.Unstructured
+}
+
+// GetGitResources clones the git repo and regenerate deployables and update annotation if needed. It returns the
+// object references and the rendered manifests of the repo.
+func (r *ReconcileSubscription) GetGitResources(sub *appv1.Subscription, isAdmin bool) ([]*v1.ObjectReference,
+	[]*unstructured.Unstructured, error) {
 	var objRefList []*v1.ObjectReference
 
+	var manifests []*unstructured.Unstructured
+
 	origsub := &appv1.Subscription{}
 	sub.DeepCopyInto(origsub)
 
@@ -56,7 +68,7 @@ func (r *ReconcileSubscription) GetGitResources(sub *appv1.Subscription, isAdmin
 
 	if err != nil {
 		klog.Errorf("Failed to find a channel for subscription: %s", sub.GetName())
-		return nil, err
+		return nil, nil, err
 	}
 
 	if utils.IsGitChannel(string(primaryChannel.Spec.Type)) {
@@ -66,7 +78,7 @@ func (r *ReconcileSubscription) GetGitResources(sub *appv1.Subscription, isAdmin
 		commit, err := r.hubGitOps.GetLatestCommitID(sub)
 		if err != nil {
 			klog.Error(err.Error())
-			return nil, err
+			return nil, nil, err
 		}
 
 		annotations := sub.GetAnnotations()
@@ -87,10 +99,10 @@ func (r *ReconcileSubscription) GetGitResources(sub *appv1.Subscription, isAdmin
 		baseDir := r.hubGitOps.GetRepoRootDirctory(sub)
 		resourcePath := getResourcePath(r.hubGitOps.ResolveLocalGitFolder, sub)
 
-		objRefList, err = r.processRepo(primaryChannel, sub, r.hubGitOps.ResolveLocalGitFolder(sub), resourcePath, baseDir, isAdmin)
+		objRefList, manifests, err = r.processRepo(primaryChannel, sub, r.hubGitOps.ResolveLocalGitFolder(sub), resourcePath, baseDir, isAdmin)
 		if err != nil {
 			klog.Error(err.Error())
-			return nil, err
+			return nil, nil, err
 		}
 
 		if oldCommit == "" || !strings.EqualFold(oldCommit, commit) {
@@ -100,7 +112,7 @@ func (r *ReconcileSubscription) GetGitResources(sub *appv1.Subscription, isAdmin
 		}
 	}
 
-	return objRefList, nil
+	return objRefList, manifests, nil
 }
 
 func (r *ReconcileSubscription) isHookUpdate(a map[string]string, subKey types.NamespacedName) bool {
@@ -135,27 +147,55 @@ func (r *ReconcileSubscription) AddClusterAdminAnnotation(sub *appv1.Subscriptio
 	return false
 }
 
+// AddTargetNamespacesAnnotation sets the target-namespaces annotation to the target namespaces of the subscription
+// its creator is allowed to create subscriptions in. Like the cluster-admin annotation, the annotation of a
+// subscription with a hosting subscription is inherited and kept.
+func (r *ReconcileSubscription) AddTargetNamespacesAnnotation(sub *appv1.Subscription) {
+	annotations := sub.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	if annotations[appv1.AnnotationHosting] != "" {
+		return
+	}
+
+	delete(annotations, appv1.AnnotationTargetNamespaces)
+
+	allowed, err := utils.ValidateTargetNamespaces(context.TODO(), r.Client, sub)
+	if err != nil {
+		klog.Warningf("Failed to validate the target namespaces of subscription %v/%v, err: %v", sub.Namespace, sub.Name, err)
+
+		if r.eventRecorder != nil {
+			r.eventRecorder.RecordEvent(sub, "TargetNamespacesDenied", err.Error(), err)
+		}
+	}
+
+	if len(allowed) > 0 {
+		annotations[appv1.AnnotationTargetNamespaces] = strings.Join(allowed, ",")
+	}
+
+	sub.SetAnnotations(annotations)
+}
+
 func getResourcePath(localFolderFunc func(*appv1.Subscription) string, sub *appv1.Subscription) string {
 	resourcePath := localFolderFunc(sub)
 
-	annotations := sub.GetAnnotations()
-	if annotations[appv1.AnnotationGithubPath] != "" {
-		resourcePath = filepath.Join(localFolderFunc(sub), annotations[appv1.AnnotationGithubPath])
-	} else if annotations[appv1.AnnotationGitPath] != "" {
-		resourcePath = filepath.Join(localFolderFunc(sub), annotations[appv1.AnnotationGitPath])
+	if gitPath := utils.GetGitPathAnnotation(sub.GetAnnotations()); gitPath != "" {
+		resourcePath = filepath.Join(localFolderFunc(sub), gitPath)
 	}
 
 	return resourcePath
 }
 
 func (r *ReconcileSubscription) processRepo(chn *chnv1.Channel, sub *appv1.Subscription,
-	localRepoRoot, subPath, baseDir string, isAdmin bool) ([]*v1.ObjectReference, error) {
+	localRepoRoot, subPath, baseDir string, isAdmin bool) ([]*v1.ObjectReference, []*unstructured.Unstructured, error) {
 	chartDirs, kustomizeDirs, crdsAndNamespaceFiles, rbacFiles, otherFiles, err := utils.SortResources(localRepoRoot, subPath)
 
 	if err != nil {
 		klog.Error(err, " Failed to sort kubernetes resources and helm charts.")
 
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Build a helm repo index file
@@ -165,7 +205,7 @@ func (r *ReconcileSubscription) processRepo(chn *chnv1.Channel, sub *appv1.Subsc
 		// If package name is not specified in the subscription, filterCharts throws an error. In this case, just return the original index file.
 		klog.Error(err, "Failed to generate helm index file.")
 
-		return nil, err
+		return nil, nil, err
 	}
 
 	b, _ := yaml.Marshal(indexFile)
@@ -173,58 +213,58 @@ func (r *ReconcileSubscription) processRepo(chn *chnv1.Channel, sub *appv1.Subsc
 
 	// Get object reference map for all the kube resources and helm charts from the git repo
 	errMessage := ""
-	objRefMap := make(map[v1.ObjectReference]*v1.ObjectReference)
+	res := &gitResources{objRefMap: make(map[v1.ObjectReference]*v1.ObjectReference)}
 
-	err = r.subscribeResources(crdsAndNamespaceFiles, objRefMap)
+	err = r.subscribeResources(crdsAndNamespaceFiles, res)
 	if err != nil {
 		errMessage += err.Error() + "/n"
 	}
 
-	err = r.subscribeResources(rbacFiles, objRefMap)
+	err = r.subscribeResources(rbacFiles, res)
 	if err != nil {
 		errMessage += err.Error() + "/n"
 	}
 
-	err = r.subscribeResources(otherFiles, objRefMap)
+	err = r.subscribeResources(otherFiles, res)
 	if err != nil {
 		errMessage += err.Error() + "/n"
 	}
 
-	err = r.subscribeKustomizations(sub, kustomizeDirs, baseDir, objRefMap)
+	err = r.subscribeKustomizations(chn, sub, kustomizeDirs, baseDir, res)
 	if err != nil {
 		errMessage += err.Error() + "/n"
 	}
 
-	err = r.subscribeHelmCharts(chn, indexFile, objRefMap)
+	err = r.subscribeHelmCharts(chn, indexFile, res)
 	if err != nil {
 		errMessage += err.Error() + "/n"
 	}
 
 	if errMessage != "" {
-		return nil, errors.New(errMessage)
+		return nil, nil, errors.New(errMessage)
 	}
 
 	// Get list of object references from the map
 	objRefList := []*v1.ObjectReference{}
 
-	for _, value := range objRefMap {
+	for _, value := range res.objRefMap {
 		// respect object customized namespace if the appsub user is subscription admin, or apply it to appsub namespace
 		if isAdmin {
 			if value.Namespace == "" {
 				value.Namespace = sub.Namespace
 			}
 		} else {
-			value.Namespace = sub.Namespace
+			value.Namespace = utils.GetRestrictedResourceNamespace(sub, value.Namespace)
 		}
 
 		objRefList = append(objRefList, value)
 	}
 
-	return objRefList, nil
+	return objRefList, res.manifests, nil
 }
 
 func (r *ReconcileSubscription) subscribeResources(
-	rscFiles []string, objRefMap map[v1.ObjectReference]*v1.ObjectReference) error {
+	rscFiles []string, res *gitResources) error {
 	// sync kube resource manifests
 	for _, rscFile := range rscFiles {
 		file, err := os.ReadFile(rscFile) // #nosec G304 rscFile is not user input
@@ -247,7 +287,7 @@ func (r *ReconcileSubscription) subscribeResources(
 
 		if len(resources) > 0 {
 			for _, resource := range resources {
-				if err := r.addObjectReference(objRefMap, resource); err != nil {
+				if err := r.addObjectReference(res, resource); err != nil {
 					klog.Error("Failed to generate object reference", err)
 					return err
 				}
@@ -258,8 +298,21 @@ func (r *ReconcileSubscription) subscribeResources(
 	return nil
 }
 
-func (r *ReconcileSubscription) subscribeKustomizations(sub *appv1.Subscription, kustomizeDirs map[string]string,
-	baseDir string, objRefMap map[v1.ObjectReference]*v1.ObjectReference) error {
+func (r *ReconcileSubscription) subscribeKustomizations(chn *chnv1.Channel, sub *appv1.Subscription, kustomizeDirs map[string]string,
+	baseDir string, res *gitResources) error {
+	packageOverrides, err := utils.ResolvePackageOverrides(r.Client, sub)
+	if err != nil {
+		klog.Error("Failed to resolve the package overrides, error: ", err.Error())
+		return err
+	}
+
+	kustomizeOptions := r.getKustomizeBuildOptions(chn)
+
+	if err := utils.SetKustomizeBuildOptions(kustomizeOptions, sub); err != nil {
+		klog.Error("Failed to set the kustomize build options, error: ", err.Error())
+		return err
+	}
+
 	for _, kustomizeDir := range kustomizeDirs {
 		klog.Info("Applying kustomization ", kustomizeDir)
 
@@ -270,13 +323,13 @@ func (r *ReconcileSubscription) subscribeKustomizations(sub *appv1.Subscription,
 			relativePath = strings.SplitAfter(kustomizeDir, baseDir+"/")[1]
 		}
 
-		err := utils.VerifyAndOverrideKustomize(sub.Spec.PackageOverrides, relativePath, kustomizeDir)
+		err := utils.VerifyAndOverrideKustomize(packageOverrides, relativePath, kustomizeDir)
 		if err != nil {
 			klog.Error("Failed to override kustomization, error: ", err.Error())
 			return err
 		}
 
-		out, err := utils.RunKustomizeBuild(kustomizeDir)
+		out, err := utils.RunKustomizeBuildWithOptions(kustomizeDir, kustomizeOptions)
 
 		if err != nil {
 			klog.Error("Failed to applying kustomization, error: ", err.Error())
@@ -298,7 +351,7 @@ func (r *ReconcileSubscription) subscribeKustomizations(sub *appv1.Subscription,
 
 			if t.APIVersion == "" || t.Kind == "" {
 				klog.Info("Not a Kubernetes resource")
-			} else if err := r.addObjectReference(objRefMap, resourceFile); err != nil {
+			} else if err := r.addObjectReference(res, resourceFile); err != nil {
 				klog.Error("Failed to generate object reference", err)
 				return err
 			}
@@ -326,8 +379,44 @@ type sourceURLs struct {
 	ChartPath string   `json:"chartPath,omitempty"`
 }
 
+// getKustomizeBuildOptions returns the kustomize build options, the remote bases on the channel Git server are
+// fetched with the channel connection
+func (r *ReconcileSubscription) getKustomizeBuildOptions(chn *chnv1.Channel) *utils.KustomizeBuildOptions {
+	opts := &utils.KustomizeBuildOptions{}
+
+	if chn == nil {
+		return opts
+	}
+
+	user, pwd, sshKey, passphrase, clientkey, clientcert, err := utils.GetChannelSecret(r.Client, chn)
+	if err != nil {
+		klog.Errorf("Failed to get the secret of channel %v/%v, err: %v", chn.GetNamespace(), chn.GetName(), err)
+		return opts
+	}
+
+	conn := &utils.ChannelConnectionCfg{
+		RepoURL:            chn.Spec.Pathname,
+		User:               user,
+		Password:           pwd,
+		SSHKey:             sshKey,
+		Passphrase:         passphrase,
+		ClientKey:          clientkey,
+		ClientCert:         clientcert,
+		InsecureSkipVerify: chn.Spec.InsecureSkipVerify,
+	}
+
+	if channelConfig := utils.GetChannelConfigMap(r.Client, chn); channelConfig != nil {
+		conn.CaCerts = channelConfig.Data[appv1.ChannelCertificateData]
+		utils.SetChannelSSHConfig(conn, channelConfig)
+	}
+
+	opts.Connections = append(opts.Connections, conn)
+
+	return opts
+}
+
 func (r *ReconcileSubscription) subscribeHelmCharts(chn *chnv1.Channel, indexFile *repo.IndexFile,
-	objRefMap map[v1.ObjectReference]*v1.ObjectReference) error {
+	res *gitResources) error {
 	for packageName, chartVersions := range indexFile.Entries {
 		klog.Infof("chart: %s\n%v", packageName, chartVersions)
 
@@ -363,7 +452,7 @@ func (r *ReconcileSubscription) subscribeHelmCharts(chn *chnv1.Channel, indexFil
 
 		klog.V(2).Info("Generating object reference")
 
-		if err := r.addObjectReference(objRefMap, dplSpec); err != nil {
+		if err := r.addObjectReference(res, dplSpec); err != nil {
 			klog.Error("Failed to generate object reference", err)
 			return err
 		}
@@ -372,7 +461,7 @@ func (r *ReconcileSubscription) subscribeHelmCharts(chn *chnv1.Channel, indexFil
 	return nil
 }
 
-func (r *ReconcileSubscription) addObjectReference(objRefMap map[v1.ObjectReference]*v1.ObjectReference, filecontent []byte) error {
+func (r *ReconcileSubscription) addObjectReference(res *gitResources, filecontent []byte) error {
 	obj := &unstructured.Unstructured{}
 	if err := yaml.Unmarshal(filecontent, obj); err != nil {
 		klog.Error("Failed to unmarshal resource YAML.")
@@ -386,7 +475,8 @@ func (r *ReconcileSubscription) addObjectReference(objRefMap map[v1.ObjectRefere
 		APIVersion: obj.GetAPIVersion(),
 	}
 
-	objRefMap[*objRef] = objRef
+	res.objRefMap[*objRef] = objRef
+	res.manifests = append(res.manifests, obj)
 
 	return nil
 }
@@ -99,7 +99,7 @@ func TestGetGitResources(t *testing.T) {
 	githubsub.SetAnnotations(annotations)
 
 	// No channel yet. It will fail and return false.
-	_, err = rec.GetGitResources(githubsub, false)
+	_, _, err = rec.GetGitResources(githubsub, false)
 	g.Expect(err).To(gomega.HaveOccurred())
 
 	err = c.Create(context.TODO(), githubchn)
@@ -107,7 +107,7 @@ func TestGetGitResources(t *testing.T) {
 
 	time.Sleep(5 * time.Second)
 
-	resources, err := rec.GetGitResources(githubsub, false)
+	resources, _, err := rec.GetGitResources(githubsub, false)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 
 	// make sure the subscription-test-namespace namespace kind resource is not skipped.
@@ -105,8 +105,9 @@ type AnsibleHooks struct {
 	registry   map[types.NamespacedName]*Hooks
 	suffixFunc SuffixFunc
 	//logger
-	logger       logr.Logger
-	hookInterval time.Duration
+	logger        logr.Logger
+	eventRecorder *utils.EventRecorder
+	hookInterval  time.Duration
 }
 
 func (h *Hooks) ConstructStatus() subv1.AnsibleJobsStatus {
@@ -154,6 +155,12 @@ func setLogger(logger logr.Logger) HookOps {
 	}
 }
 
+func setEventRecorder(rec *utils.EventRecorder) HookOps {
+	return func(a *AnsibleHooks) {
+		a.eventRecorder = rec
+	}
+}
+
 func setGitOps(g GitOps) HookOps {
 	return func(a *AnsibleHooks) {
 		a.gitClt = g
@@ -244,6 +251,8 @@ func (a *AnsibleHooks) DeregisterSubscription(subKey types.NamespacedName) error
 
 	delete(a.registry, subKey)
 
+	forgetSubscriptionHookJobs(subKey)
+
 	return nil
 }
 
@@ -272,7 +281,16 @@ func (a *AnsibleHooks) IsReadyPlacementDecisionList(appsub *subv1.Subscription)
 
 	pref := appsub.Spec.Placement.PlacementRef
 
-	if pref != nil && pref.Kind == "PlacementRule" {
+	prefKind := ""
+
+	if pref != nil {
+		prefKind, err = getPlacementRefKind(pref)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if prefKind == placementRuleKind {
 		placementRule := &placementrulev1.PlacementRule{}
 		prKey := types.NamespacedName{Name: pref.Name, Namespace: appsub.GetNamespace()}
 
@@ -298,7 +316,7 @@ func (a *AnsibleHooks) IsReadyPlacementDecisionList(appsub *subv1.Subscription)
 		}
 	}
 
-	if pref != nil && pref.Kind == "Placement" {
+	if prefKind == placementKind {
 		placement := &clusterapi.Placement{}
 		pKey := types.NamespacedName{Name: pref.Name, Namespace: appsub.GetNamespace()}
 
@@ -452,12 +470,9 @@ func getHookPath(subIns *subv1.Subscription) (string, string) {
 	annotations := subIns.GetAnnotations()
 
 	preHookPath, postHookPath := "", ""
-	if annotations[subv1.AnnotationGithubPath] != "" {
-		preHookPath = fmt.Sprintf("%v/prehook", annotations[subv1.AnnotationGithubPath])
-		postHookPath = fmt.Sprintf("%v/posthook", annotations[subv1.AnnotationGithubPath])
-	} else if annotations[subv1.AnnotationGitPath] != "" {
-		preHookPath = fmt.Sprintf("%v/prehook", annotations[subv1.AnnotationGitPath])
-		postHookPath = fmt.Sprintf("%v/posthook", annotations[subv1.AnnotationGitPath])
+	if gitPath := utils.GetGitPathAnnotation(annotations); gitPath != "" {
+		preHookPath = fmt.Sprintf("%v/prehook", gitPath)
+		postHookPath = fmt.Sprintf("%v/posthook", gitPath)
 	}
 
 	return preHookPath, postHookPath
@@ -576,6 +591,9 @@ func overrideAnsibleInstance(subIns *subv1.Subscription, job ansiblejob.AnsibleJ
 	job = addingHostingSubscriptionAnno(job,
 		types.NamespacedName{Name: subIns.GetName(), Namespace: subIns.GetNamespace()}, hookType)
 
+	// the hook jobs are restored with the subscription, so the completed hooks don't run again on a restored hub
+	utils.SetBackupLabel(&job)
+
 	//set owerreferce
 	if err := ctrlutil.SetOwnerReference(subIns.DeepCopy(), &job, scheme.Scheme); err != nil {
 		return job, err
@@ -592,7 +610,7 @@ func (a *AnsibleHooks) ApplyPreHooks(subKey types.NamespacedName) error {
 	if a.HasHooks(PreHookType, subKey) {
 		hks := a.registry[subKey].preHooks
 
-		return hks.applyJobs(a.clt, a.registry[subKey].lastSub, a.logger)
+		return hks.applyJobs(a.clt, a.eventRecorder, a.registry[subKey].lastSub, a.logger)
 	}
 
 	return nil
@@ -688,7 +706,7 @@ func (a *AnsibleHooks) IsPreHooksCompleted(subKey types.NamespacedName) (bool, e
 		return true, nil
 	}
 
-	return hks.isJobsCompleted(a.clt, a.logger)
+	return hks.isJobsCompleted(a.clt, a.eventRecorder, a.registry[subKey].lastSub, a.logger)
 }
 
 func (a *AnsibleHooks) HasHooks(hookType string, subKey types.NamespacedName) bool {
@@ -719,7 +737,7 @@ func (a *AnsibleHooks) HasHooks(hookType string, subKey types.NamespacedName) bo
 func (a *AnsibleHooks) ApplyPostHooks(subKey types.NamespacedName) error {
 	if a.HasHooks(PostHookType, subKey) {
 		hks := a.registry[subKey].postHooks
-		return hks.applyJobs(a.clt, a.registry[subKey].lastSub, a.logger)
+		return hks.applyJobs(a.clt, a.eventRecorder, a.registry[subKey].lastSub, a.logger)
 	}
 
 	return nil
@@ -732,7 +750,7 @@ func (a *AnsibleHooks) IsPostHooksCompleted(subKey types.NamespacedName) (bool,
 		return true, nil
 	}
 
-	return hks.isJobsCompleted(a.clt, a.logger)
+	return hks.isJobsCompleted(a.clt, a.eventRecorder, a.registry[subKey].lastSub, a.logger)
 }
 
 func isJobRunSuccessful(job *ansiblejob.AnsibleJob, logger logr.Logger) bool {
@@ -792,11 +810,7 @@ func getClustersFromPlacementRef(instance *subv1.Subscription, kubeclient client
 
 	pref := instance.Spec.Placement.PlacementRef
 
-	if (len(pref.Kind) > 0 && pref.Kind != "PlacementRule" && pref.Kind != "Placement") ||
-		(len(pref.APIVersion) > 0 &&
-			pref.APIVersion != "apps.open-cluster-management.io/v1" &&
-			pref.APIVersion != "cluster.open-cluster-management.io/v1alpha1" &&
-			pref.APIVersion != "cluster.open-cluster-management.io/v1beta1") {
+	if _, err := getPlacementRefKind(pref); err != nil {
 		logger.Info(fmt.Sprintln("Unsupported placement reference:", instance.Spec.Placement.PlacementRef))
 
 		return nil, nil
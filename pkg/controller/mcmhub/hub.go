@@ -21,7 +21,6 @@ import (
 	"strconv"
 	"strings"
 
-	gerr "github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -42,7 +41,7 @@ import (
 )
 
 // doMCMHubReconcile process Subscription on hub - distribute it via manifestWork
-func (r *ReconcileSubscription) doMCMHubReconcile(sub *appv1.Subscription) error {
+func (r *ReconcileSubscription) doMCMHubReconcile(ctx context.Context, sub *appv1.Subscription) error {
 	substr := fmt.Sprintf("%v/%v", sub.GetNamespace(), sub.GetName())
 	klog.V(1).Infof("entry doMCMHubReconcile %v", substr)
 
@@ -69,6 +68,23 @@ func (r *ReconcileSubscription) doMCMHubReconcile(sub *appv1.Subscription) error
 		return newError
 	}
 
+	fallbackChannels, err := GetSubscriptionFallbackChannels(r.Client, sub)
+	if err != nil {
+		return err
+	}
+
+	if len(fallbackChannels) > 0 && !isGitChannel(primaryChannel) {
+		return fmt.Errorf("the fallback channels are only supported with a Git primary channel. primary channel type: %s",
+			primaryChannel.Spec.Type)
+	}
+
+	for _, fallbackChannel := range fallbackChannels {
+		if !isGitChannel(fallbackChannel) {
+			return fmt.Errorf("the fallback channel %s/%s is not a Git channel. fallback channel type: %s",
+				fallbackChannel.Namespace, fallbackChannel.Name, fallbackChannel.Spec.Type)
+		}
+	}
+
 	chnAnnotations := primaryChannel.GetAnnotations()
 
 	if chnAnnotations[appv1.AnnotationResourceReconcileLevel] != "" {
@@ -90,6 +106,9 @@ func (r *ReconcileSubscription) doMCMHubReconcile(sub *appv1.Subscription) error
 	// Check and add cluster-admin annotation for multi-namepsace application
 	isAdmin := r.AddClusterAdminAnnotation(sub)
 
+	// Validate the target namespaces of the subscription against the RBAC of its creator
+	r.AddTargetNamespacesAnnotation(sub)
+
 	// Add or sync application labels
 	r.AddAppLabels(sub)
 
@@ -101,14 +120,21 @@ func (r *ReconcileSubscription) doMCMHubReconcile(sub *appv1.Subscription) error
 			return err
 		}
 
-		return r.PropagateAppSubManifestWork(sub, clusters)
+		// the resources are not rendered on the hub, they are counted from the application report of the subscription
+		if err := r.checkSubscriptionQuota(sub, -1, len(clusters)); err != nil {
+			return err
+		}
+
+		return r.PropagateAppSubManifestWork(ctx, sub, clusters)
 	}
 
 	var resources []*v1.ObjectReference
 
+	var manifests []*unstructured.Unstructured
+
 	switch tp := strings.ToLower(string(primaryChannel.Spec.Type)); tp {
 	case chnv1.ChannelTypeGit, chnv1.ChannelTypeGitHub:
-		resources, err = r.GetGitResources(sub, isAdmin)
+		resources, manifests, err = r.GetGitResources(sub, isAdmin)
 	case chnv1.ChannelTypeHelmRepo:
 		helmRls, err := helmops.GetSubscriptionChartsOnHub(r.Client, primaryChannel, secondaryChannel, sub)
 		if err != nil {
@@ -148,13 +174,23 @@ func (r *ReconcileSubscription) doMCMHubReconcile(sub *appv1.Subscription) error
 		return err
 	}
 
+	if err := r.checkSubscriptionQuota(sub, len(resources), len(clusters)); err != nil {
+		klog.Error("Subscription quota exceeded:", err)
+
+		return err
+	}
+
 	if err := r.createAppAppsubReport(sub, resources, 0, len(clusters)); err != nil {
 		klog.Error(err, "Error creating app appsubReport")
 
 		return err
 	}
 
-	err = r.PropagateAppSubManifestWork(sub, clusters)
+	if utils.IsManifestValidationEnabled(sub) {
+		r.validateClusterManifests(sub, manifests, clusters)
+	}
+
+	err = r.PropagateAppSubManifestWork(ctx, sub, clusters)
 
 	return err
 }
@@ -205,6 +241,26 @@ func GetSubscriptionRefChannel(clt client.Client, s *appv1.Subscription) (*chnv1
 	return primaryChannel, secondaryChannel, err
 }
 
+// GetSubscriptionFallbackChannels returns the fallback channels of the subscription in order
+func GetSubscriptionFallbackChannels(clt client.Client, s *appv1.Subscription) ([]*chnv1.Channel, error) {
+	fallbackChannels := []*chnv1.Channel{}
+
+	for _, chnName := range s.Spec.FallbackChannels {
+		fallbackChannel, err := parseGetChannel(clt, chnName)
+		if err != nil {
+			klog.Errorf("fallback channel %s not found for subscription %s/%s", chnName, s.GetNamespace(), s.GetName())
+
+			return nil, err
+		}
+
+		if fallbackChannel != nil {
+			fallbackChannels = append(fallbackChannels, fallbackChannel)
+		}
+	}
+
+	return fallbackChannels, nil
+}
+
 func parseGetChannel(clt client.Client, channelName string) (*chnv1.Channel, error) {
 	if channelName == "" {
 		return nil, nil
@@ -308,6 +364,7 @@ func (r *ReconcileSubscription) createAppAppsubReport(sub *appv1.Subscription, r
 
 		appsubReport.Labels = map[string]string{
 			"apps.open-cluster-management.io/hosting-subscription": fmt.Sprintf("%.63s", sub.Namespace+"."+sub.Name),
+			utils.ExcludeFromBackupLabel:                           "true",
 		}
 
 		//initialize placementrule cluster count as the pass count
@@ -370,106 +427,13 @@ func (r *ReconcileSubscription) createAppAppsubReport(sub *appv1.Subscription, r
 }
 
 func (r *ReconcileSubscription) initObjectStore(channel *chnv1.Channel) (*awsutils.Handler, string, error) {
-	var err error
-
-	awshandler := &awsutils.Handler{}
-
-	pathName := channel.Spec.Pathname
-
-	if pathName == "" {
-		errmsg := "Empty Pathname in channel " + channel.Spec.Pathname
-		klog.Error(errmsg)
-
-		return nil, "", errors.New(errmsg)
-	}
-
-	if strings.HasSuffix(pathName, "/") {
-		last := len(pathName) - 1
-		pathName = pathName[:last]
-	}
-
-	loc := strings.LastIndex(pathName, "/")
-	endpoint := pathName[:loc]
-	bucket := pathName[loc+1:]
-
-	accessKeyID := ""
-	secretAccessKey := ""
-	region := ""
-	objInsecureSkipVerify := "false"
-	objCaCert := ""
-
-	if channel.Spec.SecretRef != nil {
-		channelSecret := &v1.Secret{}
-		chnseckey := types.NamespacedName{
-			Name:      channel.Spec.SecretRef.Name,
-			Namespace: channel.Namespace,
-		}
-
-		if err := r.Get(context.TODO(), chnseckey, channelSecret); err != nil {
-			return nil, "", gerr.Wrap(err, "failed to get reference secret from channel")
-		}
-
-		err = yaml.Unmarshal(channelSecret.Data[awsutils.SecretMapKeyAccessKeyID], &accessKeyID)
-		if err != nil {
-			klog.Error("Failed to unmashall accessKey from secret with error:", err)
-
-			return nil, "", err
-		}
-
-		err = yaml.Unmarshal(channelSecret.Data[awsutils.SecretMapKeySecretAccessKey], &secretAccessKey)
-		if err != nil {
-			klog.Error("Failed to unmashall secretaccessKey from secret with error:", err)
-
-			return nil, "", err
-		}
-
-		regionData := channelSecret.Data[awsutils.SecretMapKeyRegion]
-
-		if len(regionData) > 0 {
-			err = yaml.Unmarshal(regionData, &region)
-			if err != nil {
-				klog.Error("Failed to unmashall region from secret with error:", err)
-
-				return nil, "", err
-			}
-		}
-	}
-
-	if channel.Spec.ConfigMapRef != nil {
-		configMapRet := utils.GetChannelConfigMap(r.Client, channel)
-
-		if configMapRet != nil {
-			objCaCert = configMapRet.Data[appv1.ChannelCertificateData]
-			if objCaCert != "" {
-				r.logger.Info("ObjectStore channel config map with CA certs found")
-			}
-		}
-	}
-
-	if channel.Spec.InsecureSkipVerify {
-		objInsecureSkipVerify = "true"
-	}
-
-	klog.V(1).Info("Trying to connect to object bucket ", endpoint, "|", bucket)
-
-	if err := awshandler.InitObjectStoreConnection(
-		endpoint, accessKeyID, secretAccessKey, region, objInsecureSkipVerify, objCaCert); err != nil {
-		klog.Error(err, "unable initialize object store settings")
-
-		return nil, "", err
-	}
-	// Check whether the connection is setup successfully
-	if err := awshandler.Exists(bucket); err != nil {
-		klog.Error(err, "Unable to access object store bucket ", bucket, " for channel ", channel.Name)
-
-		return nil, "", err
-	}
-
-	return awshandler, bucket, nil
+	return awsutils.InitChannelObjectStore(r.Client, channel)
 }
 
 func (r *ReconcileSubscription) getObjectBucketResources(sub *appv1.Subscription, channel, secondaryChannel *chnv1.Channel,
 	isAdmin bool) ([]*v1.ObjectReference, error) {
+	usedChannel := channel
+
 	awsHandler, bucket, err := r.initObjectStore(channel)
 	if err != nil {
 		klog.Error(err, "Unable to access object store: ")
@@ -477,6 +441,7 @@ func (r *ReconcileSubscription) getObjectBucketResources(sub *appv1.Subscription
 		if secondaryChannel != nil {
 			klog.Infof("trying the secondary channel %s", secondaryChannel.Name)
 			// Try with secondary channel
+			usedChannel = secondaryChannel
 			awsHandler, bucket, err = r.initObjectStore(secondaryChannel)
 
 			if err != nil {
@@ -491,6 +456,14 @@ func (r *ReconcileSubscription) getObjectBucketResources(sub *appv1.Subscription
 		}
 	}
 
+	// only the manifests signed with the cosign key of the channel are propagated
+	publicKey, err := utils.GetChannelCosignPublicKey(usedChannel)
+	if err != nil {
+		klog.Error(err)
+
+		return nil, err
+	}
+
 	var folderName *string
 
 	annotations := sub.GetAnnotations()
@@ -515,6 +488,10 @@ func (r *ReconcileSubscription) getObjectBucketResources(sub *appv1.Subscription
 	resources := []*v1.ObjectReference{}
 
 	for _, key := range keys {
+		if awsutils.IsSignatureKey(key) {
+			continue
+		}
+
 		tplb, err := awsHandler.Get(bucket, key)
 		if err != nil {
 			klog.Error("Failed to get object ", key, " in bucket ", bucket)
@@ -527,6 +504,14 @@ func (r *ReconcileSubscription) getObjectBucketResources(sub *appv1.Subscription
 			continue
 		}
 
+		if publicKey != nil {
+			if err := awsutils.VerifyObjectSignature(awsHandler, bucket, key, tplb.Content, keys, publicKey); err != nil {
+				klog.Error(err)
+
+				return nil, err
+			}
+		}
+
 		template := &unstructured.Unstructured{}
 		err = yaml.Unmarshal(tplb.Content, template)
 
@@ -553,7 +538,7 @@ func (r *ReconcileSubscription) getObjectBucketResources(sub *appv1.Subscription
 				resource.Namespace = sub.Namespace
 			}
 		} else {
-			resource.Namespace = sub.Namespace
+			resource.Namespace = utils.GetRestrictedResourceNamespace(sub, resource.Namespace)
 		}
 
 		resources = append(resources, resource)
@@ -280,15 +280,7 @@ func getBranchCommitDepthAndTag(subIns *subv1.Subscription) (string, string, str
 		return "", "", "", ""
 	}
 
-	branch := ""
-
-	if an[subv1.AnnotationGitBranch] != "" {
-		branch = an[subv1.AnnotationGitBranch]
-	}
-
-	if an[subv1.AnnotationGithubBranch] != "" {
-		branch = an[subv1.AnnotationGithubBranch]
-	}
+	branch := utils.GetGitBranchAnnotation(an)
 
 	return branch, an[subv1.AnnotationGitTargetCommit], an[subv1.AnnotationGitTag], an[subv1.AnnotationGitCloneDepth]
 }
@@ -437,6 +429,7 @@ func (h *HubGitOps) RegisterBranch(subIns *subv1.Subscription) error {
 	primaryChannelConnectionConfig.User = user
 	primaryChannelConnectionConfig.ClientCert = clientcert
 	primaryChannelConnectionConfig.ClientKey = clientkey
+	utils.SetChannelSSHConfig(primaryChannelConnectionConfig, channelConfig)
 
 	cloneOptions.PrimaryConnectionOption = primaryChannelConnectionConfig
 
@@ -448,7 +441,7 @@ func (h *HubGitOps) RegisterBranch(subIns *subv1.Subscription) error {
 			return err
 		}
 
-		channelConfig := utils.GetChannelConfigMap(h.clt, primaryChannel)
+		channelConfig := utils.GetChannelConfigMap(h.clt, secondaryChannel)
 		caCert := ""
 
 		if channelConfig != nil {
@@ -476,10 +469,27 @@ func (h *HubGitOps) RegisterBranch(subIns *subv1.Subscription) error {
 		secondaryChannelConnectionConfig.User = user
 		secondaryChannelConnectionConfig.ClientCert = clientcert
 		secondaryChannelConnectionConfig.ClientKey = clientkey
+		utils.SetChannelSSHConfig(secondaryChannelConnectionConfig, channelConfig)
 
 		cloneOptions.SecondaryConnectionOption = secondaryChannelConnectionConfig
 	}
 
+	fallbackChannels, err := GetSubscriptionFallbackChannels(h.clt, subIns)
+	if err != nil {
+		h.logger.Error(err, "failed to register subscription to git watcher register")
+		return err
+	}
+
+	for _, fallbackChannel := range fallbackChannels {
+		fallbackChannelConnectionConfig, err := utils.GetChannelConnectionConfig(h.clt, fallbackChannel)
+		if err != nil {
+			h.logger.Error(err, "failed to register subscription to git watcher register")
+			return err
+		}
+
+		cloneOptions.FallbackConnectionOptions = append(cloneOptions.FallbackConnectionOptions, fallbackChannelConnectionConfig)
+	}
+
 	commitID, err := h.cloneFunc(cloneOptions)
 	if err != nil {
 		h.logger.Error(err, "failed to get commitID from initialDownload")
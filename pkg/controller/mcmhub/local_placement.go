@@ -0,0 +1,151 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	placementv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// hasLocalAndRemotePlacement returns true if the subscription is deployed both on the hub and to the managed clusters
+func hasLocalAndRemotePlacement(sub *appv1.Subscription) bool {
+	pl := sub.Spec.Placement
+
+	return pl != nil && pl.Local != nil && *pl.Local &&
+		(pl.PlacementRef != nil || pl.Clusters != nil || pl.ClusterSelector != nil)
+}
+
+// localPlacementSubscriptionKey returns the key of the local subscription deploying the hub subscription on the hub.
+// It is named like the subscription propagated to the local-cluster, which is never propagated with a local placement.
+func localPlacementSubscriptionKey(sub *appv1.Subscription) types.NamespacedName {
+	return types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name + "-local"}
+}
+
+// reconcileLocalPlacement creates or updates the local subscription deploying the hub subscription on the hub if it
+// has a local placement with its remote placement, and deletes it otherwise. The local subscription is a standalone
+// subscription owned by the hub subscription, reconciled by the standalone subscription controller of the hub.
+func (r *ReconcileSubscription) reconcileLocalPlacement(sub *appv1.Subscription) error {
+	key := localPlacementSubscriptionKey(sub)
+
+	existing := &appv1.Subscription{}
+	if err := r.Get(context.TODO(), key, existing); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+
+		existing = nil
+	}
+
+	// never touch a subscription the hub subscription does not own
+	if existing != nil && existing.GetAnnotations()[appv1.AnnotationLocalPlacement] != sub.Name {
+		if hasLocalAndRemotePlacement(sub) {
+			klog.Warningf("the local placement of subscription %v/%v is skipped, subscription %v already exists",
+				sub.Namespace, sub.Name, key.String())
+		}
+
+		return nil
+	}
+
+	if !hasLocalAndRemotePlacement(sub) {
+		if existing == nil {
+			return nil
+		}
+
+		klog.Infof("Deleting the local placement subscription %v", key.String())
+
+		if err := r.Delete(context.TODO(), existing); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	desired, err := r.prepareLocalPlacementSubscription(sub)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		klog.Infof("Creating the local placement subscription %v", key.String())
+
+		return r.Create(context.TODO(), desired)
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) &&
+		equality.Semantic.DeepEqual(existing.GetAnnotations(), desired.GetAnnotations()) &&
+		equality.Semantic.DeepEqual(existing.GetLabels(), desired.GetLabels()) {
+		return nil
+	}
+
+	klog.Infof("Updating the local placement subscription %v", key.String())
+
+	existing.Spec = desired.Spec
+	existing.SetAnnotations(desired.GetAnnotations())
+	existing.SetLabels(desired.GetLabels())
+
+	return r.Update(context.TODO(), existing)
+}
+
+// prepareLocalPlacementSubscription returns the local subscription of the hub subscription. It is the subscription
+// propagated to the managed clusters, without the hosting subscription annotation so it is reconciled as a standalone
+// subscription on the hub.
+func (r *ReconcileSubscription) prepareLocalPlacementSubscription(sub *appv1.Subscription) (*appv1.Subscription, error) {
+	hosting := types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name}
+
+	manifestAppsub, err := r.prepareManifestWorkAppsub(sub, hosting)
+	if err != nil {
+		return nil, err
+	}
+
+	local := &appv1.Subscription{}
+	if err := json.Unmarshal([]byte(manifestAppsub), local); err != nil {
+		return nil, err
+	}
+
+	key := localPlacementSubscriptionKey(sub)
+	local.ObjectMeta = metav1.ObjectMeta{
+		Name:        key.Name,
+		Namespace:   key.Namespace,
+		Labels:      local.GetLabels(),
+		Annotations: local.GetAnnotations(),
+	}
+
+	annotations := local.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	delete(annotations, appv1.AnnotationHosting)
+	annotations[appv1.AnnotationLocalPlacement] = sub.Name
+	local.SetAnnotations(annotations)
+
+	isLocal := true
+	local.Spec.Placement = &placementv1.Placement{Local: &isLocal}
+
+	if err := controllerutil.SetControllerReference(sub, local, r.scheme); err != nil {
+		return nil, err
+	}
+
+	return local, nil
+}
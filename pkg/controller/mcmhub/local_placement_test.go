@@ -0,0 +1,162 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	manifestWorkV1 "open-cluster-management.io/api/work/v1"
+	placementv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileLocalPlacement(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(appv1.SchemeBuilder.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(corev1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	isLocal := true
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "default",
+			UID:         "app-uid",
+			Labels:      map[string]string{"app": "app"},
+			Annotations: map[string]string{appv1.AnnotationGitPath: "dev"},
+		},
+		Spec: appv1.SubscriptionSpec{
+			Channel: "default/git",
+			Placement: &placementv1.Placement{
+				Local:        &isLocal,
+				PlacementRef: &corev1.ObjectReference{Kind: "Placement", Name: "dev"},
+			},
+		},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sub).Build()
+	r := &ReconcileSubscription{Client: clt, scheme: scheme}
+
+	g.Expect(hasLocalAndRemotePlacement(sub)).To(gomega.BeTrue())
+	g.Expect(r.reconcileLocalPlacement(sub)).To(gomega.Succeed())
+
+	// the local subscription is a standalone subscription owned by the hub subscription
+	local := &appv1.Subscription{}
+	g.Expect(clt.Get(context.TODO(), localPlacementSubscriptionKey(sub), local)).To(gomega.Succeed())
+	g.Expect(local.Name).To(gomega.Equal("app-local"))
+	g.Expect(local.Spec.Channel).To(gomega.Equal("default/git"))
+	g.Expect(local.Spec.Placement.PlacementRef).To(gomega.BeNil())
+	g.Expect(*local.Spec.Placement.Local).To(gomega.BeTrue())
+	g.Expect(local.GetLabels()).To(gomega.Equal(map[string]string{"app": "app"}))
+	g.Expect(local.GetAnnotations()).To(gomega.HaveKeyWithValue(appv1.AnnotationLocalPlacement, "app"))
+	g.Expect(local.GetAnnotations()).To(gomega.HaveKeyWithValue(appv1.AnnotationGitPath, "dev"))
+	g.Expect(local.GetAnnotations()).NotTo(gomega.HaveKey(appv1.AnnotationHosting))
+	g.Expect(local.GetOwnerReferences()).To(gomega.HaveLen(1))
+	g.Expect(local.GetOwnerReferences()[0].Name).To(gomega.Equal("app"))
+
+	// the local subscription follows the hub subscription
+	sub.Spec.Channel = "default/git2"
+	g.Expect(r.reconcileLocalPlacement(sub)).To(gomega.Succeed())
+	g.Expect(clt.Get(context.TODO(), localPlacementSubscriptionKey(sub), local)).To(gomega.Succeed())
+	g.Expect(local.Spec.Channel).To(gomega.Equal("default/git2"))
+
+	// the local subscription is deleted with the local placement
+	sub.Spec.Placement.Local = nil
+	g.Expect(hasLocalAndRemotePlacement(sub)).To(gomega.BeFalse())
+	g.Expect(r.reconcileLocalPlacement(sub)).To(gomega.Succeed())
+
+	err := clt.Get(context.TODO(), localPlacementSubscriptionKey(sub), local)
+	g.Expect(k8serrors.IsNotFound(err)).To(gomega.BeTrue())
+
+	// a subscription the hub subscription does not own is never touched
+	other := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app-local", Namespace: "default"}}
+	g.Expect(clt.Create(context.TODO(), other)).To(gomega.Succeed())
+
+	sub.Spec.Placement.Local = &isLocal
+	g.Expect(r.reconcileLocalPlacement(sub)).To(gomega.Succeed())
+	g.Expect(clt.Get(context.TODO(), localPlacementSubscriptionKey(sub), local)).To(gomega.Succeed())
+	g.Expect(local.GetAnnotations()).NotTo(gomega.HaveKey(appv1.AnnotationLocalPlacement))
+
+	sub.Spec.Placement.Local = nil
+	g.Expect(r.reconcileLocalPlacement(sub)).To(gomega.Succeed())
+	g.Expect(clt.Get(context.TODO(), localPlacementSubscriptionKey(sub), local)).To(gomega.Succeed())
+}
+
+func TestLocalAndRemotePlacementPropagation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(appv1.SchemeBuilder.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(corev1.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(spokeClusterV1.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(manifestWorkV1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	isLocal := true
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"},
+		Spec: appv1.SubscriptionSpec{
+			Channel: "default/git",
+			Placement: &placementv1.Placement{
+				Local: &isLocal,
+				GenericPlacementFields: placementv1.GenericPlacementFields{
+					Clusters: []placementv1.GenericClusterReference{{Name: "cluster1"}, {Name: "local-cluster"}},
+				},
+			},
+		},
+	}
+
+	cluster1 := &spokeClusterV1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1",
+		Labels: map[string]string{"name": "cluster1"}}}
+	localCluster := &spokeClusterV1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "local-cluster",
+		Labels: map[string]string{"name": "local-cluster", "local-cluster": "true"}}}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sub, cluster1, localCluster).Build()
+	r := &ReconcileSubscription{Client: clt, scheme: scheme,
+		eventRecorder: &utils.EventRecorder{EventRecorder: record.NewFakeRecorder(10)}}
+
+	// the local-cluster is deployed by the local subscription, not by a manifestWork
+	clusters, err := r.getClustersByPlacement(sub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(clusters).To(gomega.Equal([]ManageClusters{{Cluster: "cluster1"}}))
+
+	g.Expect(r.PropagateAppSubManifestWork(context.TODO(), sub, clusters)).To(gomega.Succeed())
+	g.Expect(r.reconcileLocalPlacement(sub)).To(gomega.Succeed())
+
+	// the remote subscription is propagated to the managed cluster
+	manifestWork := &manifestWorkV1.ManifestWork{}
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "default-app"}, manifestWork)).
+		To(gomega.Succeed())
+
+	err = clt.Get(context.TODO(), types.NamespacedName{Namespace: "local-cluster", Name: "default-app"}, manifestWork)
+	g.Expect(k8serrors.IsNotFound(err)).To(gomega.BeTrue())
+
+	// and the local subscription is created on the hub
+	local := &appv1.Subscription{}
+	g.Expect(clt.Get(context.TODO(), localPlacementSubscriptionKey(sub), local)).To(gomega.Succeed())
+	g.Expect(*local.Spec.Placement.Local).To(gomega.BeTrue())
+	g.Expect(local.Spec.Placement.Clusters).To(gomega.BeEmpty())
+}
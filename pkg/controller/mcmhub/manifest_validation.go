@@ -0,0 +1,86 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// validateClusterManifests validates the rendered manifests of the subscription against the API schemas reported by
+// each managed cluster. The validation errors are set on the clusters, their manifestWorks are not updated.
+// The clusters without reported API schemas and the clusters deploying a kustomize overlay are not validated.
+func (r *ReconcileSubscription) validateClusterManifests(sub *appv1.Subscription, manifests []*unstructured.Unstructured,
+	clusters []ManageClusters) {
+	if len(manifests) == 0 {
+		return
+	}
+
+	for i, cluster := range clusters {
+		if overlayPath, err := r.getClusterKustomizeOverlay(sub, cluster.Cluster); err != nil || overlayPath != "" {
+			continue
+		}
+
+		schemas, err := r.getClusterAPISchemas(cluster.Cluster)
+		if err != nil {
+			klog.Errorf("Failed to get the API schemas of cluster %v, err: %v", cluster.Cluster, err)
+			continue
+		}
+
+		if schemas == nil {
+			klog.Infof("Cluster %v has no API schemas, skipping the validation of appsub %v/%v",
+				cluster.Cluster, sub.GetNamespace(), sub.GetName())
+
+			continue
+		}
+
+		if errs := utils.ValidateManifests(schemas, manifests); len(errs) > 0 {
+			clusters[i].ValidationError = "Manifest validation failed: " + strings.Join(errs, "; ")
+
+			klog.Warningf("Appsub %v/%v is not propagated to cluster %v, %v", sub.GetNamespace(), sub.GetName(),
+				cluster.Cluster, clusters[i].ValidationError)
+		}
+	}
+}
+
+// getClusterAPISchemas returns the API schemas reported by the managed cluster, nil if the cluster has not reported
+// its API schemas
+func (r *ReconcileSubscription) getClusterAPISchemas(clusterName string) (*utils.ClusterAPISchemas, error) {
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: clusterName, Name: utils.ClusterAPISchemasConfigMapName}
+
+	if err := r.Get(context.TODO(), key, cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	data, ok := cm.BinaryData[utils.ClusterAPISchemasKey]
+	if !ok {
+		return nil, nil
+	}
+
+	return utils.DecodeClusterAPISchemas(data)
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	manifestWorkV1 "open-cluster-management.io/api/work/v1"
+	appSubV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// manifestWorkSizeLimit is the max total size of the manifests in a manifestWork, as validated by the ocm work webhook
+	manifestWorkSizeLimit = 500 * 1024
+	// manifestWorkChunkSuffix is appended to the appsub manifestWork name with the chunk index, for the chunks after the first one
+	manifestWorkChunkSuffix = "-chunk-"
+)
+
+// chunkManifests splits the manifests in chunks whose total size doesn't exceed the limit. The manifests keep their order,
+// so the chunk assignment is stable as long as the manifests don't change. It fails if a single manifest exceeds the limit.
+func chunkManifests(manifests []manifestWorkV1.Manifest, limit int) ([][]manifestWorkV1.Manifest, error) {
+	chunks := [][]manifestWorkV1.Manifest{}
+	chunk := []manifestWorkV1.Manifest{}
+	chunkSize := 0
+
+	for _, manifest := range manifests {
+		size := len(manifest.Raw)
+
+		if size > limit {
+			return nil, fmt.Errorf("the resource %v is %v bytes, it exceeds the manifestWork size limit of %v bytes",
+				getManifestName(manifest), size, limit)
+		}
+
+		if chunkSize+size > limit && len(chunk) > 0 {
+			chunks = append(chunks, chunk)
+			chunk = []manifestWorkV1.Manifest{}
+			chunkSize = 0
+		}
+
+		chunk = append(chunk, manifest)
+		chunkSize += size
+	}
+
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+// getManifestWorkChunkName returns the name of the appsub manifestWork holding the given chunk
+func getManifestWorkChunkName(appsub *appSubV1.Subscription, chunk int) string {
+	name := appsub.GetNamespace() + "-" + appsub.GetName()
+
+	if chunk == 0 {
+		return name
+	}
+
+	return name + manifestWorkChunkSuffix + strconv.Itoa(chunk)
+}
+
+// isManifestWorkChunkOf checks if the manifestWork is a chunk, after the first one, of the appsub manifestWork
+func isManifestWorkChunkOf(manifestWork *manifestWorkV1.ManifestWork, appsub *appSubV1.Subscription) bool {
+	if manifestWork.GetAnnotations()[appSubV1.AnnotationHosting] != appsub.GetNamespace()+"/"+appsub.GetName() {
+		return false
+	}
+
+	chunk, found := strings.CutPrefix(manifestWork.GetName(), appsub.GetNamespace()+"-"+appsub.GetName()+manifestWorkChunkSuffix)
+	if !found {
+		return false
+	}
+
+	_, err := strconv.Atoi(chunk)
+
+	return err == nil
+}
+
+// hasAppsubManifest checks if the manifestWork carries the appsub
+func hasAppsubManifest(manifestWork *manifestWorkV1.ManifestWork) bool {
+	for _, manifest := range manifestWork.Spec.Workload.Manifests {
+		obj := &unstructured.Unstructured{}
+
+		if err := json.Unmarshal(manifest.Raw, obj); err == nil && obj.GetKind() == "Subscription" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getManifestName(manifest manifestWorkV1.Manifest) string {
+	obj := &unstructured.Unstructured{}
+
+	if err := json.Unmarshal(manifest.Raw, obj); err != nil {
+		return "unknown"
+	}
+
+	return obj.GetKind() + " " + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// getManifestWorkFailures returns the failed manifestWork chunks of the appsub by cluster, the appsub propagated to all
+// its clusters is not propagated if a cluster fails to apply one of its chunks
+func (r *ReconcileSubscription) getManifestWorkFailures(appsub *appSubV1.Subscription) []string {
+	manifestWorks, err := r.getManifestWorkFamily(appsub)
+	if err != nil {
+		return nil
+	}
+
+	return aggregateManifestWorkFailures(manifestWorks)
+}
+
+// aggregateManifestWorkFailures aggregates the status of the manifestWork chunks by cluster. The appsub fails to propagate to
+// a cluster if one of its chunks is not applied or is degraded, the conditions of a previous generation of a chunk are
+// ignored. It returns the failed chunks of each cluster, sorted by cluster.
+func aggregateManifestWorkFailures(manifestWorks []*manifestWorkV1.ManifestWork) []string {
+	failures := map[string][]string{}
+
+	for _, manifestWork := range manifestWorks {
+		for _, cond := range manifestWork.Status.Conditions {
+			if cond.ObservedGeneration != 0 && cond.ObservedGeneration != manifestWork.GetGeneration() {
+				continue
+			}
+
+			state := ""
+
+			switch {
+			case cond.Type == manifestWorkV1.WorkApplied && cond.Status == metav1.ConditionFalse:
+				state = "not applied"
+			case cond.Type == manifestWorkV1.WorkDegraded && cond.Status == metav1.ConditionTrue:
+				state = "degraded"
+			default:
+				continue
+			}
+
+			failures[manifestWork.GetNamespace()] = append(failures[manifestWork.GetNamespace()],
+				fmt.Sprintf("manifestWork %v is %v: %v", manifestWork.GetName(), state, cond.Message))
+		}
+	}
+
+	clusters := make([]string, 0, len(failures))
+	for cluster := range failures {
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Strings(clusters)
+
+	msgs := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		sort.Strings(failures[cluster])
+		msgs = append(msgs, cluster+": "+strings.Join(failures[cluster], ", "))
+	}
+
+	return msgs
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	manifestWorkV1 "open-cluster-management.io/api/work/v1"
+	appSubV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestChunkManifests(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newManifest := func(size int) manifestWorkV1.Manifest {
+		raw := `{"kind":"ConfigMap","metadata":{"name":"cm"},"data":{"k":"` + strings.Repeat("a", size) + `"}}`
+
+		return manifestWorkV1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(raw)}}
+	}
+
+	manifests := []manifestWorkV1.Manifest{newManifest(100), newManifest(100), newManifest(300)}
+
+	chunks, err := chunkManifests(manifests, 1000)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(chunks).To(gomega.HaveLen(1))
+
+	chunks, err = chunkManifests(manifests, 400)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(chunks).To(gomega.HaveLen(2))
+	g.Expect(chunks[0]).To(gomega.HaveLen(2))
+	g.Expect(chunks[1]).To(gomega.HaveLen(1))
+
+	_, err = chunkManifests(manifests, 200)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("ConfigMap /cm"))
+}
+
+func TestManifestWorkChunkName(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	appsub := &appSubV1.Subscription{}
+	appsub.SetName("sub")
+	appsub.SetNamespace("ns")
+
+	g.Expect(getManifestWorkChunkName(appsub, 0)).To(gomega.Equal("ns-sub"))
+	g.Expect(getManifestWorkChunkName(appsub, 2)).To(gomega.Equal("ns-sub-chunk-2"))
+
+	manifestWork := &manifestWorkV1.ManifestWork{}
+	manifestWork.SetName(getManifestWorkChunkName(appsub, 2))
+	g.Expect(isManifestWorkChunkOf(manifestWork, appsub)).To(gomega.BeFalse())
+
+	manifestWork.SetAnnotations(map[string]string{appSubV1.AnnotationHosting: "ns/sub"})
+	g.Expect(isManifestWorkChunkOf(manifestWork, appsub)).To(gomega.BeTrue())
+
+	manifestWork.SetName("ns-sub-chunk-x")
+	g.Expect(isManifestWorkChunkOf(manifestWork, appsub)).To(gomega.BeFalse())
+}
+
+func TestAggregateManifestWorkFailures(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newManifestWork := func(cluster, name string, conds ...metav1.Condition) *manifestWorkV1.ManifestWork {
+		manifestWork := &manifestWorkV1.ManifestWork{}
+		manifestWork.SetNamespace(cluster)
+		manifestWork.SetName(name)
+		manifestWork.SetGeneration(2)
+		manifestWork.Status.Conditions = conds
+
+		return manifestWork
+	}
+
+	applied := metav1.Condition{Type: manifestWorkV1.WorkApplied, Status: metav1.ConditionTrue, ObservedGeneration: 2}
+	notApplied := metav1.Condition{Type: manifestWorkV1.WorkApplied, Status: metav1.ConditionFalse, ObservedGeneration: 2,
+		Message: "failed to apply the Secret"}
+	degraded := metav1.Condition{Type: manifestWorkV1.WorkDegraded, Status: metav1.ConditionTrue, ObservedGeneration: 2,
+		Message: "the Secret is gone"}
+
+	// all the chunks are applied, or not reported yet
+	g.Expect(aggregateManifestWorkFailures([]*manifestWorkV1.ManifestWork{
+		newManifestWork("cluster1", "ns-sub", applied),
+		newManifestWork("cluster1", "ns-sub-chunk-1"),
+	})).To(gomega.BeEmpty())
+
+	// a failed chunk fails its cluster, the other clusters are not reported
+	g.Expect(aggregateManifestWorkFailures([]*manifestWorkV1.ManifestWork{
+		newManifestWork("cluster2", "ns-sub", applied),
+		newManifestWork("cluster2", "ns-sub-chunk-1", degraded),
+		newManifestWork("cluster1", "ns-sub", applied),
+		newManifestWork("cluster1", "ns-sub-chunk-1", notApplied),
+		newManifestWork("cluster3", "ns-sub", applied),
+	})).To(gomega.Equal([]string{
+		"cluster1: manifestWork ns-sub-chunk-1 is not applied: failed to apply the Secret",
+		"cluster2: manifestWork ns-sub-chunk-1 is degraded: the Secret is gone",
+	}))
+
+	// the conditions of a previous generation of the chunk are outdated
+	notApplied.ObservedGeneration = 1
+	g.Expect(aggregateManifestWorkFailures([]*manifestWorkV1.ManifestWork{
+		newManifestWork("cluster1", "ns-sub-chunk-1", notApplied),
+	})).To(gomega.BeEmpty())
+}
@@ -18,10 +18,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -34,8 +37,10 @@ import (
 	"k8s.io/klog/v2/klogr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -44,10 +49,13 @@ import (
 	"github.com/go-logr/logr"
 
 	clusterapi "open-cluster-management.io/api/cluster/v1beta1"
+	manifestWorkV1 "open-cluster-management.io/api/work/v1"
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	appSubStatusV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/tracing"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
 
@@ -140,7 +148,7 @@ func newReconciler(mgr manager.Manager, op ...Option) reconcile.Reconciler {
 		eventRecorder:       erecorder,
 		logger:              logger,
 		hookRequeueInterval: defaultHookRequeueInterval,
-		hooks:               NewAnsibleHooks(mgr.GetClient(), defaultHookRequeueInterval, setLogger(logger), setGitOps(gitOps)),
+		hooks:               NewAnsibleHooks(mgr.GetClient(), defaultHookRequeueInterval, setLogger(logger), setGitOps(gitOps), setEventRecorder(erecorder)),
 		hubGitOps:           gitOps,
 		clk:                 time.Now,
 	}
@@ -245,7 +253,7 @@ func (mapper *channelMapper) Map(ctx context.Context, obj *chnv1.Channel) []reco
 	}
 
 	for _, sub := range subList.Items {
-		if sub.Spec.Channel == chn || sub.Spec.SecondaryChannel == chn {
+		if sub.Spec.Channel == chn || sub.Spec.SecondaryChannel == chn || slices.Contains(sub.Spec.FallbackChannels, chn) {
 			objkey := types.NamespacedName{
 				Name:      sub.GetName(),
 				Namespace: sub.GetNamespace(),
@@ -325,6 +333,65 @@ func (mapper *placementDecisionMapper) Map(ctx context.Context, obj *clusterapi.
 	return requests
 }
 
+type appsubReportMapper struct {
+	client.Client
+}
+
+func (mapper *appsubReportMapper) Map(ctx context.Context, obj *appSubStatusV1alpha1.SubscriptionReport) []reconcile.Request {
+	// if a cluster appsubReport reports failed appsubs, these appsubs should be reconciled for retry.
+	var requests []reconcile.Request
+
+	for _, result := range obj.Results {
+		if result == nil || result.Result != clusterDeployFailed {
+			continue
+		}
+
+		appsubNs, appsubName, found := strings.Cut(result.Source, "/")
+		if !found {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: appsubName, Namespace: appsubNs}})
+	}
+
+	klog.V(1).Info("Out appsubReport mapper with requests:", requests)
+
+	return requests
+}
+
+// manifestWorkMapper maps the manifestWork chunks to their hosting appsub, the appsub aggregates the status of its chunks
+func manifestWorkMapper(ctx context.Context, obj *manifestWorkV1.ManifestWork) []reconcile.Request {
+	hostKey := utils.GetHostSubscriptionFromObject(obj)
+	if hostKey == nil || hostKey.Name == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: *hostKey}}
+}
+
+// manifestWorkConditionsChangedPredicate filters the manifestWork updates changing the status of the conditions
+var manifestWorkConditionsChangedPredicate = predicate.TypedFuncs[*manifestWorkV1.ManifestWork]{
+	CreateFunc:  func(e event.TypedCreateEvent[*manifestWorkV1.ManifestWork]) bool { return false },
+	DeleteFunc:  func(e event.TypedDeleteEvent[*manifestWorkV1.ManifestWork]) bool { return false },
+	GenericFunc: func(e event.TypedGenericEvent[*manifestWorkV1.ManifestWork]) bool { return false },
+	UpdateFunc: func(e event.TypedUpdateEvent[*manifestWorkV1.ManifestWork]) bool {
+		oldConds, newConds := e.ObjectOld.Status.Conditions, e.ObjectNew.Status.Conditions
+		if len(oldConds) != len(newConds) {
+			return true
+		}
+
+		for _, newCond := range newConds {
+			oldCond := meta.FindStatusCondition(oldConds, newCond.Type)
+			if oldCond == nil || oldCond.Status != newCond.Status || oldCond.Message != newCond.Message ||
+				oldCond.ObservedGeneration != newCond.ObservedGeneration {
+				return true
+			}
+		}
+
+		return false
+	},
+}
+
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
@@ -332,6 +399,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	c, err := controller.New("mcmhub-subscription-controller", mgr, controller.Options{
 		Reconciler:         r,
 		SkipNameValidation: &skipValidation,
+		NewQueue:           utils.NewPriorityQueue,
 	})
 
 	if err != nil {
@@ -343,7 +411,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	err = c.Watch(
 		source.Kind(mgr.GetCache(),
 			&appv1.Subscription{},
-			handler.TypedEnqueueRequestsFromMapFunc(smapper.Map),
+			utils.EnqueueRequestsWithPriority(smapper.Map),
 			utils.SubscriptionPredicateFunctions,
 		),
 	)
@@ -366,6 +434,33 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// in hub, watch for cluster appsubReport changes to retry the failed cluster deployments
+	rMapper := &appsubReportMapper{mgr.GetClient()}
+	err = c.Watch(
+		source.Kind(mgr.GetCache(),
+			&appSubStatusV1alpha1.SubscriptionReport{},
+			handler.TypedEnqueueRequestsFromMapFunc(rMapper.Map),
+			utils.AppSubReportFailedPredicateFunc,
+		),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	// in hub, watch for the status changes of the manifestWork chunks of the appsubs
+	err = c.Watch(
+		source.Kind(mgr.GetCache(),
+			&manifestWorkV1.ManifestWork{},
+			handler.TypedEnqueueRequestsFromMapFunc(manifestWorkMapper),
+			manifestWorkConditionsChangedPredicate,
+		),
+	)
+
+	if err != nil {
+		return err
+	}
+
 	// in hub, watch for placement decision changes
 	if utils.IsReadyPlacementDecision(mgr.GetAPIReader()) {
 		pdMapper := &placementDecisionMapper{mgr.GetClient()}
@@ -382,6 +477,55 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		}
 	}
 
+	// in hub, watch for changes to the ConfigMaps and Secrets referenced by the package overrides
+	for _, kind := range []string{"ConfigMap", "Secret"} {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(kind))
+
+		poMapper := &packageOverridesMapper{Client: mgr.GetClient(), kind: kind}
+		err = c.Watch(
+			source.Kind(mgr.GetCache(),
+				obj,
+				handler.TypedEnqueueRequestsFromMapFunc(poMapper.Map),
+				predicate.TypedResourceVersionChangedPredicate[*metav1.PartialObjectMetadata]{},
+			),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		// and to the ConfigMaps and Secrets referenced by the channels, the managed clusters refresh the channel
+		// credentials when they change
+		crMapper := &channelReferencesMapper{Client: mgr.GetClient(), kind: kind}
+		err = c.Watch(
+			source.Kind(mgr.GetCache(),
+				obj,
+				handler.TypedEnqueueRequestsFromMapFunc(crMapper.Map),
+				predicate.TypedResourceVersionChangedPredicate[*metav1.PartialObjectMetadata]{},
+			),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// in hub, watch for deployment window changes
+	if utils.IsReadyDeploymentWindow(mgr.GetAPIReader()) {
+		dwMapper := &deploymentWindowMapper{mgr.GetClient()}
+		err = c.Watch(
+			source.Kind(mgr.GetCache(),
+				&appv1.DeploymentWindow{},
+				handler.TypedEnqueueRequestsFromMapFunc(dwMapper.Map),
+			),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -405,6 +549,8 @@ type ReconcileSubscription struct {
 	hubGitOps           GitOps
 	restMapper          meta.RESTMapper
 	clk                 clock
+	// last emergency-deploy reason audited for each subscription
+	emergencyDeploys sync.Map
 }
 
 // CreateSubscriptionAdminRBAC checks existence of subscription-admin clusterrole and clusterrolebinding
@@ -506,13 +652,38 @@ func subAdminClusterRoleBinding() *rbacv1.ClusterRoleBinding {
 	}
 }
 
+// isSubscriptionInShard checks if the subscription is reconciled by the shard of this hub controller. The deleted
+// subscriptions are cleaned up by all the shards, the subscriptions moved to another shard are deregistered.
+func (r *ReconcileSubscription) isSubscriptionInShard(key types.NamespacedName) (bool, error) {
+	instance := &appv1.Subscription{}
+
+	if err := r.Get(context.TODO(), key, instance); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	if utils.IsSubscriptionInShard(instance) {
+		return true, nil
+	}
+
+	klog.V(1).Infof("Subscription %v is reconciled by shard %v", key.String(),
+		utils.GetSubscriptionShard(instance, utils.GetSubscriptionShardCount()))
+
+	r.hubGitOps.DeregisterBranch(key)
+
+	return false, r.hooks.DeregisterSubscription(key)
+}
+
 // Reconcile reads that state of the cluster for a Subscription object and makes changes based on the state read
 // and what is in the Subscription.Spec
 func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile.Request) (result reconcile.Result, returnErr error) {
-	logger := r.logger.WithName(request.String())
-	logger.Info(fmt.Sprint("entry MCM Hub Reconciling subscription: ", request.String()))
+	logger := r.logger.WithValues("request", request.NamespacedName)
+	logger.Info("entry MCM Hub Reconciling subscription")
 
-	defer logger.Info(fmt.Sprint("exit Hub Reconciling subscription: ", request.String()))
+	defer logger.Info("exit Hub Reconciling subscription")
 
 	//flag used to indicate Git branch connection intialiazion failed
 	passedBranchRegistration := true
@@ -532,6 +703,14 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 		request.NamespacedName = types.NamespacedName{Name: request.Name, Namespace: request.Namespace}
 	}
 
+	// the subscriptions of the other shards are reconciled by the other replicas of the hub controller
+	if inShard, err := r.isSubscriptionInShard(request.NamespacedName); err != nil || !inShard {
+		return reconcile.Result{}, err
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "ReconcileSubscription", request.Namespace, request.Name)
+	defer span.End()
+
 	var preErr error
 
 	localPlacement := false
@@ -539,6 +718,14 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 	instance := &appv1.Subscription{}
 	oins := &appv1.Subscription{}
 
+	// runs after finalCommit, requeue the subscription to delete it once it expires
+	defer func() {
+		if next := utils.TimeUntilExpiry(instance, r.clk()); next > 0 && !utils.IsHostingAppsub(instance) &&
+			(result.RequeueAfter == 0 || next < result.RequeueAfter) {
+			result.RequeueAfter = next
+		}
+	}()
+
 	defer func() {
 		r.finalCommit(passedBranchRegistration, passedPrehook, preErr, oins, instance, request, &result, localPlacement)
 	}()
@@ -547,12 +734,12 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
-			klog.Info("Subscription: ", request.NamespacedName, " is gone")
-			klog.Infof("Clean up all the manifestWorks owned by appsub: %v", request.NamespacedName)
+			logger.Info("Subscription is gone, clean up all the manifestWorks owned by the appsub",
+				"subscription", request.NamespacedName)
 
 			cleanupErr := r.cleanupManifestWork(request.NamespacedName)
 			if cleanupErr != nil {
-				klog.Warning("error while cleanup manifestwork ", cleanupErr)
+				logger.Error(cleanupErr, "error while cleanup manifestwork")
 			}
 
 			// Object not found, delete existing subscriberitem if any
@@ -562,6 +749,10 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 
 			r.hubGitOps.DeregisterBranch(request.NamespacedName)
 
+			r.emergencyDeploys.Delete(request.NamespacedName.String())
+
+			metrics.DeleteTimeWindowMetrics(request.Namespace, request.Name)
+
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -571,29 +762,43 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 	// for later comparison
 	oins = instance.DeepCopy()
 
+	logger = logging.WithSubscription(r.logger, instance)
+
+	// the deleted subscription is not committed by finalCommit
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return r.finalizeSubscription(instance)
+	}
+
+	// the subscriptions propagated from another hub expire with their hosting subscription
+	if !utils.IsHostingAppsub(instance) && utils.IsSubscriptionExpired(instance, r.clk()) {
+		// the deleted subscription is not committed by finalCommit
+		now := metav1.NewTime(r.clk())
+		oins.SetDeletionTimestamp(&now)
+
+		return reconcile.Result{}, r.deleteExpiredSubscription(instance)
+	}
+
+	r.auditEmergencyDeploy(instance)
+
+	// re-adopt the resources of a subscription restored on a new hub before it is propagated
+	r.reconcileRestore(instance)
+
 	// process as hub subscription, generate deployable to propagate
 	pl := instance.Spec.Placement
 
-	klog.Infof("Subscription: %v with placement %#v", request.NamespacedName.String(), pl)
+	logger.Info("Subscription placement", "placement", pl)
 
 	//status changes below show override the prehook status
 	if pl == nil {
 		instance.Status.Phase = appv1.SubscriptionPropagationFailed
 		instance.Status.Reason = "Placement must be specified"
 
-		metrics.PropagationFailedPullTime.
-			WithLabelValues(instance.Namespace, instance.Name).
-			Observe(0)
-	} else if pl != nil && (pl.PlacementRef != nil || pl.Clusters != nil || pl.ClusterSelector != nil) && (pl.Local != nil && *pl.Local) {
-		logger.Info("both local placement and remote placement are defined in the subscription")
-
-		instance.Status.Phase = appv1.SubscriptionPropagationFailed
-		instance.Status.Reason = "local placement and remote placement cannot be used together"
-
 		metrics.PropagationFailedPullTime.
 			WithLabelValues(instance.Namespace, instance.Name).
 			Observe(0)
 	} else if pl != nil && (pl.PlacementRef != nil || pl.Clusters != nil || pl.ClusterSelector != nil) {
+		setCleanupFinalizer(instance)
+
 		primaryChannel, _, err := r.getChannel(instance)
 		if err != nil {
 			klog.Errorf("Failed to find a channel for subscription: %s", instance.GetName())
@@ -604,9 +809,51 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 			return reconcile.Result{}, nil
 		}
 
+		// stop propagating the subscription if one of its channels is not allowed in the namespace
+		if err := utils.CheckSubscriptionChannelPolicies(r.Client, instance); err != nil {
+			logger.Error(err, "the channel is not allowed")
+			preErr = err
+			passedBranchRegistration = false
+
+			r.eventRecorder.RecordEvent(instance, "ChannelNotAllowed", err.Error(), err)
+
+			metrics.PropagationFailedPullTime.
+				WithLabelValues(instance.Namespace, instance.Name).
+				Observe(0)
+
+			return reconcile.Result{}, nil
+		}
+
+		// the channel secrets and configmaps of another namespace and the secret provider references are only used by
+		// the subscriptions of the users allowed to use them, they are checked before the channel secrets are resolved
+		if err := utils.CheckSubscriptionChannelReferencesAccess(ctx, r.Client, instance); err != nil {
+			logger.Error(err, "the channel references are not allowed")
+			preErr = err
+			passedBranchRegistration = false
+
+			metrics.PropagationFailedPullTime.
+				WithLabelValues(instance.Namespace, instance.Name).
+				Observe(0)
+
+			return reconcile.Result{}, nil
+		}
+
 		// This block is only for Git subscription
 		if strings.EqualFold(string(primaryChannel.Spec.Type), chnv1.ChannelTypeGit) ||
 			strings.EqualFold(string(primaryChannel.Spec.Type), chnv1.ChannelTypeGitHub) {
+			// pin the desired commit before the branch is registered if a rollback is requested
+			if err := r.processRollback(ctx, instance); err != nil {
+				logger.Error(err, "failed to roll back")
+				preErr = err
+				passedBranchRegistration = false
+
+				metrics.PropagationFailedPullTime.
+					WithLabelValues(instance.Namespace, instance.Name).
+					Observe(0)
+
+				return reconcile.Result{}, nil
+			}
+
 			if err := r.hubGitOps.RegisterBranch(instance); err != nil {
 				logger.Error(err, "failed to initialize Git connection")
 				preErr = fmt.Errorf("failed to initialize Git connection, err: %w", err)
@@ -688,7 +935,7 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 
 		//changes will be added to instance
 		startTime := time.Now().UnixMilli()
-		err = r.doMCMHubReconcile(instance)
+		err = r.doMCMHubReconcile(ctx, instance)
 		endTime := time.Now().UnixMilli()
 
 		if err != nil {
@@ -698,10 +945,20 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 				Observe(float64(endTime - startTime))
 
 			instance.Status.Phase = appv1.SubscriptionPropagationFailed
-			instance.Status.Reason = err.Error()
+			instance.Status.Reason = utils.RedactError(err)
 			instance.Status.Statuses = nil
 			preErr = err
 			returnErr = err
+		} else if err := r.reconcileLocalPlacement(instance); err != nil {
+			r.logger.Error(err, "failed to reconcile the local placement")
+			metrics.PropagationFailedPullTime.
+				WithLabelValues(instance.Namespace, instance.Name).
+				Observe(float64(endTime - startTime))
+
+			instance.Status.Phase = appv1.SubscriptionPropagationFailed
+			instance.Status.Reason = utils.RedactError(err)
+			preErr = err
+			returnErr = err
 		} else {
 			metrics.PropagationSuccessfulPullTime.
 				WithLabelValues(instance.Namespace, instance.Name).
@@ -710,11 +967,20 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 			instance.Status.Phase = appv1.SubscriptionPropagated
 			instance.Status.Message = ""
 			instance.Status.Reason = ""
+
+			// requeue the deployment on the clusters reporting failure, with backoff
+			if requeueAfter := r.retryFailedClusters(instance); requeueAfter > 0 {
+				result.RequeueAfter = requeueAfter
+			}
 		}
 	} else { //local: true and handle change true to false
 		// no longer hub subscription
 		localPlacement = true
 
+		if err := r.removeCleanupFinalizer(instance); err != nil {
+			klog.Warningf("failed to remove the finalizer %v, err: %v", appv1.FinalizerManifestWorkCleanup, err)
+		}
+
 		if !utils.IsHostingAppsub(instance) {
 			klog.Infof("Clean up all the manifestWorks owned by appsub: %v/%v", instance.GetNamespace(), instance.GetName())
 
@@ -725,6 +991,10 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 			if cleanupErr != nil {
 				klog.Warning("error while cleanup manifestwork ", cleanupErr)
 			}
+
+			if err := r.reconcileLocalPlacement(instance); err != nil {
+				klog.Warning("error while cleanup the local placement subscription ", err)
+			}
 		}
 
 		if instance.Status.Phase != appv1.SubscriptionFailed && instance.Status.Phase != appv1.SubscriptionSubscribed {
@@ -775,7 +1045,7 @@ func (r *ReconcileSubscription) IsSubscriptionCompleted(subKey types.NamespacedN
 	}
 
 	//local mode
-	if subIns.Spec.Placement.Local != nil && *(subIns.Spec.Placement.Local) {
+	if subIns.Spec.Placement.Local != nil && *(subIns.Spec.Placement.Local) && !hasLocalAndRemotePlacement(subIns) {
 		return true, nil
 	}
 
@@ -843,22 +1113,31 @@ func (r *ReconcileSubscription) finalCommit(passedBranchRegistration bool, passe
 	}
 
 	// time window calculation
-	if nIns.Spec.TimeWindow == nil {
+	tw, twErr := r.getTimeWindow(nIns)
+
+	if tw == nil {
 		nIns.Status.Message = subscriptionActive
 	} else {
-		if utils.IsInWindow(nIns.Spec.TimeWindow, r.clk()) {
+		if utils.IsInWindow(tw, r.clk()) {
 			nIns.Status.Message = subscriptionActive
 		} else {
 			nIns.Status.Message = subscriptionBlock
 		}
+
+		// refresh the Blocked condition when the time window status changes
+		if next := utils.NextStatusReconcile(tw, r.clk()); next > 0 && (res.RequeueAfter == 0 || next < res.RequeueAfter) {
+			res.RequeueAfter = next
+		}
 	}
 
 	if !passedBranchRegistration {
 		nIns.Status = r.hooks.AppendPreHookStatusToSubscription(nIns)
 		nIns.Status.Phase = appv1.SubscriptionPropagationFailed
-		nIns.Status.Reason = preErr.Error()
+		nIns.Status.Reason = utils.RedactError(preErr)
 		nIns.Status.Statuses = appv1.SubscriptionClusterStatusMap{}
 
+		r.setHubConditions(nIns, passedPrehook, tw, twErr)
+
 		if utils.IsHubRelatedStatusChanged(oIns.Status.DeepCopy(), nIns.Status.DeepCopy()) {
 			nIns.Status.LastUpdateTime = metav1.Now()
 
@@ -906,7 +1185,7 @@ func (r *ReconcileSubscription) finalCommit(passedBranchRegistration bool, passe
 	if !passedPrehook {
 		nIns.Status = r.hooks.AppendPreHookStatusToSubscription(nIns)
 		nIns.Status.Phase = appv1.SubscriptionPropagationFailed
-		nIns.Status.Reason = preErr.Error()
+		nIns.Status.Reason = utils.RedactError(preErr)
 		nIns.Status.Statuses = appv1.SubscriptionClusterStatusMap{}
 	} else {
 		nIns.Status = r.hooks.AppendStatusToSubscription(nIns)
@@ -915,6 +1194,8 @@ func (r *ReconcileSubscription) finalCommit(passedBranchRegistration bool, passe
 	klog.Infof("oIns status reason: %v", oIns.Status.Reason)
 	klog.Infof("nIns status reason: %v", nIns.Status.Reason)
 
+	r.setHubConditions(nIns, passedPrehook, tw, twErr)
+
 	if utils.IsHubRelatedStatusChanged(oIns.Status.DeepCopy(), nIns.Status.DeepCopy()) {
 		nIns.Status.LastUpdateTime = metav1.Now()
 
@@ -969,6 +1250,8 @@ func (r *ReconcileSubscription) finalCommit(passedBranchRegistration bool, passe
 
 	nIns.Status = r.hooks.AppendStatusToSubscription(nIns)
 
+	r.setHubConditions(nIns, passedPrehook, tw, twErr)
+
 	if utils.IsHubRelatedStatusChanged(oIns.Status.DeepCopy(), nIns.Status.DeepCopy()) {
 		nIns.Status.LastUpdateTime = metav1.Now()
 
@@ -1005,3 +1288,52 @@ func after(value string, a string) string {
 func PrintHelper(o metav1.Object) types.NamespacedName { //nolint
 	return types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}
 }
+
+// setHubConditions sets the observed generation and the Propagated, HooksCompleted, Blocked, Ready and Expiring
+// conditions of the hub subscription, tw is the time window of the subscription after resolving its deployment window
+func (r *ReconcileSubscription) setHubConditions(sub *appv1.Subscription, passedPrehook bool, tw *appv1.TimeWindow,
+	twErr error) {
+	sub.Status.ObservedGeneration = sub.GetGeneration()
+
+	if sub.Status.Phase == appv1.SubscriptionPropagationFailed {
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionPropagated, false,
+			utils.FailureConditionReason(utils.ConditionReasonPropagationFailed, sub.Status.Reason), sub.Status.Reason)
+	} else if failures := r.getManifestWorkFailures(sub); len(failures) > 0 {
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionPropagated, false,
+			utils.ConditionReasonManifestWorkFailed, strings.Join(failures, "; "))
+	} else {
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionPropagated, true,
+			utils.ConditionReasonPropagated, "")
+	}
+
+	subKey := types.NamespacedName{Namespace: sub.GetNamespace(), Name: sub.GetName()}
+	hasPostHooks := r.hooks.HasHooks(PostHookType, subKey)
+
+	switch {
+	case !r.hooks.HasHooks(PreHookType, subKey) && !hasPostHooks:
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionHooksCompleted, true, utils.ConditionReasonNoHooks, "")
+	case !passedPrehook:
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionHooksCompleted, false, utils.ConditionReasonPreHooksRunning,
+			fmt.Sprintf("waiting for prehook job %v", sub.Status.AnsibleJobsStatus.LastPrehookJob))
+	case hasPostHooks && sub.Status.AnsibleJobsStatus.LastPosthookJob == "":
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionHooksCompleted, false, utils.ConditionReasonPostHooksPending,
+			"posthooks are applied once the subscription is deployed")
+	default:
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionHooksCompleted, true, utils.ConditionReasonHooksCompleted, "")
+	}
+
+	now := r.clk()
+
+	utils.SetSubscriptionBlockedCondition(sub, tw, now)
+
+	// the subscription is blocked until its deployment window is resolved
+	if twErr != nil {
+		utils.SetSubscriptionCondition(sub, appv1.SubscriptionConditionBlocked, true,
+			utils.ConditionReasonDeploymentWindowUnresolved, "Deployment is blocked, "+utils.RedactError(twErr))
+	}
+
+	utils.SetSubscriptionReadyCondition(sub, appv1.SubscriptionConditionPropagated, appv1.SubscriptionConditionHooksCompleted)
+	utils.SetSubscriptionExpiringCondition(sub)
+
+	metrics.SetTimeWindowStatus(sub.GetNamespace(), sub.GetName(), utils.NextStartPoint(tw, now), now)
+}
@@ -83,7 +83,7 @@ var _ = Describe("test propagation statuses set by the hub reconciler", Ordered,
 		Expect(PropagationSuccessfulPullTimeCount).To(BeZero())
 	})
 
-	It("should fail for subscriptions configured for both local and remote placements", func() {
+	It("should propagate the subscriptions configured for both local and remote placements like the remote ones", func() {
 		metrics.PropagationFailedPullTime.Reset()
 		metrics.PropagationSuccessfulPullTime.Reset()
 
@@ -131,9 +131,7 @@ var _ = Describe("test propagation statuses set by the hub reconciler", Ordered,
 		reconciledSubscription := &appsv1.Subscription{}
 		Expect(sutPropagationTestClient.Get(context.TODO(), wrongPlacementSubscriptionKey, reconciledSubscription)).NotTo(HaveOccurred())
 
-		Expect(reconciledSubscription.Status.Phase).To(Equal(appsv1.SubscriptionPropagationFailed))
-		Expect(reconciledSubscription.Status.Reason).To(Equal("local placement and remote placement cannot be used together"))
-
+		// the propagation fails on the missing channel, not on the placement
 		Expect(PropagationFailedPullTimeCount).To(Equal(1))
 		Expect(PropagationSuccessfulPullTimeCount).To(BeZero())
 	})
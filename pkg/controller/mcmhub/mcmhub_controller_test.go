@@ -258,7 +258,7 @@ func TestDoMCMReconcile(t *testing.T) {
 
 	defer c.Delete(context.TODO(), instance)
 
-	g.Expect(rec.doMCMHubReconcile(instance)).NotTo(gomega.HaveOccurred())
+	g.Expect(rec.doMCMHubReconcile(context.TODO(), instance)).NotTo(gomega.HaveOccurred())
 }
 
 func TestNewAppLabels(t *testing.T) {
@@ -0,0 +1,60 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// packageOverridesMapper maps the ConfigMaps or Secrets of the given kind to the subscriptions referencing them in
+// their package overrides. Only the object metadata is watched.
+type packageOverridesMapper struct {
+	client.Client
+	kind string
+}
+
+func (mapper *packageOverridesMapper) Map(ctx context.Context, obj *metav1.PartialObjectMetadata) []reconcile.Request {
+	// if a referenced ConfigMap or Secret is created/updated/deleted, the subscriptions referencing it should be reconciled.
+	var requests []reconcile.Request
+
+	subList := &appv1.SubscriptionList{}
+
+	if err := mapper.List(context.TODO(), subList, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Error("Listing subscriptions in packageOverridesMapper and got error:", err)
+	}
+
+	for _, sub := range subList.Items {
+		if !utils.IsPackageOverridesReferenced(&sub, mapper.kind, obj.GetName()) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: sub.GetName(), Namespace: sub.GetNamespace()}})
+	}
+
+	if len(requests) > 0 {
+		klog.V(1).Info("Out package overrides mapper with requests:", requests)
+	}
+
+	return requests
+}
@@ -28,17 +28,48 @@ import (
 	clusterapi "open-cluster-management.io/api/cluster/v1beta1"
 	appSubV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	placementutils "open-cluster-management.io/multicloud-operators-subscription/pkg/placementrule/utils"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	placementRuleLabel = "cluster.open-cluster-management.io/placementrule"
 	placementLabel     = "cluster.open-cluster-management.io/placement"
+
+	placementRuleKind = "PlacementRule"
+	placementKind     = "Placement"
 )
 
+// getPlacementRefKind returns the kind of the placement reference, PlacementRule or Placement. Without a kind, the
+// references to the cluster.open-cluster-management.io API group are Placements, the others are PlacementRules.
+func getPlacementRefKind(pref *corev1.ObjectReference) (string, error) {
+	switch pref.APIVersion {
+	case "", "apps.open-cluster-management.io/v1",
+		"cluster.open-cluster-management.io/v1alpha1", "cluster.open-cluster-management.io/v1beta1":
+	default:
+		return "", fmt.Errorf("unsupported placement reference: %v", pref)
+	}
+
+	switch {
+	case strings.EqualFold(pref.Kind, placementRuleKind):
+		return placementRuleKind, nil
+	case strings.EqualFold(pref.Kind, placementKind):
+		return placementKind, nil
+	case pref.Kind == "" && strings.HasPrefix(pref.APIVersion, "cluster.open-cluster-management.io/"):
+		return placementKind, nil
+	case pref.Kind == "":
+		return placementRuleKind, nil
+	}
+
+	return "", fmt.Errorf("unsupported placement reference: %v", pref)
+}
+
 type ManageClusters struct {
 	Cluster        string
 	IsLocalCluster bool
+	// ValidationError is the pre-flight validation error of the manifests, the manifestWorks of the cluster
+	// are not updated
+	ValidationError string
 }
 
 // Top priority: placementRef, ignore others
@@ -79,6 +110,25 @@ func (r *ReconcileSubscription) getClustersByPlacement(instance *appSubV1.Subscr
 		return nil, err
 	}
 
+	// the subscription with a local placement is deployed on the hub by its local subscription, not again through
+	// the local-cluster
+	if hasLocalAndRemotePlacement(instance) {
+		remoteClusters := []ManageClusters{}
+
+		for _, cluster := range clusters {
+			if cluster.IsLocalCluster {
+				klog.Infof("Skipping the local cluster %v, subscription %v/%v has a local placement", cluster.Cluster,
+					instance.Namespace, instance.Name)
+
+				continue
+			}
+
+			remoteClusters = append(remoteClusters, cluster)
+		}
+
+		clusters = remoteClusters
+	}
+
 	klog.Info("Deploying to clusters", clusters)
 
 	return clusters, nil
@@ -87,9 +137,14 @@ func (r *ReconcileSubscription) getClustersByPlacement(instance *appSubV1.Subscr
 func getDecisionsFromPlacementRef(pref *corev1.ObjectReference, namespace string, kubeClient client.Client) ([]string, error) {
 	klog.Info("Preparing cluster names from ", pref.Name)
 
+	kind, err := getPlacementRefKind(pref)
+	if err != nil {
+		return nil, err
+	}
+
 	label := placementRuleLabel
 
-	if strings.EqualFold(pref.Kind, "Placement") {
+	if kind == placementKind {
 		label = placementLabel
 	}
 
@@ -128,14 +183,10 @@ func (r *ReconcileSubscription) getClustersFromPlacementRef(instance *appSubV1.S
 
 	pref := instance.Spec.Placement.PlacementRef
 
-	if (len(pref.Kind) > 0 && pref.Kind != "PlacementRule" && pref.Kind != "Placement") ||
-		(len(pref.APIVersion) > 0 &&
-			pref.APIVersion != "apps.open-cluster-management.io/v1" &&
-			pref.APIVersion != "cluster.open-cluster-management.io/v1alpha1" &&
-			pref.APIVersion != "cluster.open-cluster-management.io/v1beta1") {
+	if _, err := getPlacementRefKind(pref); err != nil {
 		klog.Error("Unsupported placement reference:", instance.Spec.Placement.PlacementRef)
 
-		return nil, fmt.Errorf("unsupported placement reference: %v", instance.Spec.Placement.PlacementRef)
+		return nil, err
 	}
 
 	klog.Info("Referencing Placement: ", pref, " in ", instance.GetNamespace())
@@ -183,6 +234,34 @@ func (r *ReconcileSubscription) isLocalCluster(clusterName string) bool {
 	return false
 }
 
+// getClusterTimezone returns the time zone of the managed cluster, empty if the cluster has no valid time zone
+func (r *ReconcileSubscription) getClusterTimezone(clusterName string) string {
+	managedCluster := &spokeClusterV1.ManagedCluster{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Name: clusterName}, managedCluster); err != nil {
+		klog.Errorf("Failed to find managed cluster: %v, error: %v ", clusterName, err)
+		return ""
+	}
+
+	return utils.GetClusterTimezone(managedCluster)
+}
+
+// getClusterKustomizeOverlay returns the path of the kustomize overlay of the subscription selecting the cluster,
+// empty if the subscription has no overlay for the cluster
+func (r *ReconcileSubscription) getClusterKustomizeOverlay(instance *appSubV1.Subscription, clusterName string) (string, error) {
+	if len(instance.Spec.KustomizeOverlays) == 0 {
+		return "", nil
+	}
+
+	managedCluster := &spokeClusterV1.ManagedCluster{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Name: clusterName}, managedCluster); err != nil {
+		return "", fmt.Errorf("failed to get managed cluster %v to select the kustomize overlay: %w", clusterName, err)
+	}
+
+	return utils.GetKustomizeOverlayPath(instance.Spec.KustomizeOverlays, managedCluster.GetLabels())
+}
+
 func isLocalClusterByLabels(clusterLabels map[string]string) bool {
 	if clusterLabels == nil {
 		return false
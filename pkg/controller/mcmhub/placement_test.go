@@ -15,12 +15,18 @@
 package mcmhub
 
 import (
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterapi "open-cluster-management.io/api/cluster/v1beta1"
 	v1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
 	appSubV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestReconcileSubscription_getClustersFromPlacementRef(t *testing.T) {
@@ -59,3 +65,93 @@ func TestReconcileSubscription_getClustersFromPlacementRef(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPlacementRefKind(t *testing.T) {
+	tests := []struct {
+		name    string
+		pref    *corev1.ObjectReference
+		want    string
+		wantErr bool
+	}{
+		{name: "no kind", pref: &corev1.ObjectReference{}, want: placementRuleKind},
+		{name: "placementRule", pref: &corev1.ObjectReference{Kind: "PlacementRule"}, want: placementRuleKind},
+		{name: "placement", pref: &corev1.ObjectReference{Kind: "placement"}, want: placementKind},
+		{
+			name: "placement without kind",
+			pref: &corev1.ObjectReference{APIVersion: "cluster.open-cluster-management.io/v1beta1"},
+			want: placementKind,
+		},
+		{
+			name: "placementRule without kind",
+			pref: &corev1.ObjectReference{APIVersion: "apps.open-cluster-management.io/v1"},
+			want: placementRuleKind,
+		},
+		{name: "unsupported kind", pref: &corev1.ObjectReference{Kind: "ManagedClusterSet"}, wantErr: true},
+		{
+			name:    "unsupported API version",
+			pref:    &corev1.ObjectReference{Kind: "Placement", APIVersion: "cluster.open-cluster-management.io/v1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getPlacementRefKind(tt.pref)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getPlacementRefKind() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("getPlacementRefKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDecisionsFromPlacementRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	newDecision := func(name, label string, clusters ...string) *clusterapi.PlacementDecision {
+		decision := &clusterapi.PlacementDecision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{label: "dev"}},
+		}
+
+		for _, cluster := range clusters {
+			decision.Status.Decisions = append(decision.Status.Decisions, clusterapi.ClusterDecision{ClusterName: cluster})
+		}
+
+		return decision
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newDecision("dev-decision-1", placementLabel, "cluster1", "cluster2"),
+		newDecision("dev-decision-2", placementLabel, "cluster3"),
+		newDecision("dev-rule-decision", placementRuleLabel, "cluster4"),
+	).Build()
+
+	// the decisions of a placement are paged in several placementDecisions
+	clusters, err := getDecisionsFromPlacementRef(&corev1.ObjectReference{
+		Name: "dev", APIVersion: "cluster.open-cluster-management.io/v1beta1"}, "default", clt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(clusters)
+
+	if !reflect.DeepEqual(clusters, []string{"cluster1", "cluster2", "cluster3"}) {
+		t.Errorf("getDecisionsFromPlacementRef() = %v", clusters)
+	}
+
+	clusters, err = getDecisionsFromPlacementRef(&corev1.ObjectReference{Name: "dev"}, "default", clt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(clusters, []string{"cluster4"}) {
+		t.Errorf("getDecisionsFromPlacementRef() = %v", clusters)
+	}
+}
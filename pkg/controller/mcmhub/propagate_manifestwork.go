@@ -19,7 +19,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +33,9 @@ import (
 	placementV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
 	appSubV1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	appSubStatusV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/tracing"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -38,7 +43,13 @@ import (
 var manifestNSString string
 var manifestAppsubString string
 
-func (r *ReconcileSubscription) PropagateAppSubManifestWork(instance *appSubV1.Subscription, clusters []ManageClusters) error {
+// manifestSecretStrings are the package overrides Secrets propagated with the appsub to the remote clusters
+var manifestSecretStrings []string
+
+func (r *ReconcileSubscription) PropagateAppSubManifestWork(ctx context.Context, instance *appSubV1.Subscription, clusters []ManageClusters) error {
+	ctx, span := tracing.StartSpan(ctx, "PropagateManifestWork", instance.GetNamespace(), instance.GetName())
+	defer span.End()
+
 	// try to find all children manifestworks
 	children, err := r.getManifestWorkFamily(instance)
 
@@ -53,18 +64,31 @@ func (r *ReconcileSubscription) PropagateAppSubManifestWork(instance *appSubV1.S
 		expiredManifestWorkmap[manifestWork.GetNamespace()+"-"+manifestWork.GetName()] = manifestWork
 	}
 
-	klog.V(1).Infof("expiredManifestWorkmap: %#v", expiredManifestWorkmap)
+	logging.WithSubscription(r.logger, instance).V(1).Info("expired manifestWorks", "manifestWorks", expiredManifestWorkmap)
 
 	// propagate template
-	expiredManifestWorkmap, err = r.propagateManifestWorks(clusters, instance, expiredManifestWorkmap)
+	startTime := time.Now().UnixMilli()
+	expiredManifestWorkmap, err = r.propagateManifestWorks(ctx, clusters, instance, expiredManifestWorkmap)
+
+	metrics.PropagationManifestWorkTime.
+		WithLabelValues(instance.GetNamespace(), instance.GetName()).
+		Observe(float64(time.Now().UnixMilli() - startTime))
+
 	if err != nil {
 		klog.Error("Error in propagating to clusters:", err)
+		span.RecordError(err)
+
 		return err
 	}
 
 	// delete expired appsub manifestWork
 	klog.Info("Expired manifestWork map:", expiredManifestWorkmap)
 
+	selectedClusters := make(map[string]bool, len(clusters))
+	for _, cluster := range clusters {
+		selectedClusters[cluster.Cluster] = true
+	}
+
 	for _, manifestWork := range expiredManifestWorkmap {
 		mainfestWorkKey := types.NamespacedName{Namespace: manifestWork.GetNamespace(), Name: manifestWork.GetName()}
 		err = r.Delete(context.TODO(), manifestWork)
@@ -76,6 +100,11 @@ func (r *ReconcileSubscription) PropagateAppSubManifestWork(instance *appSubV1.S
 			klog.Errorf("Failed to delete Expired ManifestWork: %v/%v, err: %v", manifestWork.GetNamespace(), manifestWork.GetName(), err)
 		}
 
+		// an expired chunk of a cluster still selected doesn't remove the cluster
+		if selectedClusters[manifestWork.GetNamespace()] {
+			continue
+		}
+
 		// remove relative appSubPakcageStatus CRs from the expired manifestWork cluster NS
 		cleanupErr := r.cleanupAppSubStatus(instance, manifestWork.GetNamespace())
 		if cleanupErr != nil {
@@ -110,7 +139,7 @@ func (r *ReconcileSubscription) getManifestWorkFamily(instance *appSubV1.Subscri
 		// The generated manifestwork labels are exactly the same regardless of the different endings after the first 63 characters
 		// fo-monitoring-incluster.prometheus-stack-incluster-platform-engineering-westeurope01
 		// fo-monitoring-incluster.prometheus-stack-incluster-platform-engineering-eastus02
-		if manifestWork.GetName() != instance.Namespace+"-"+instance.Name {
+		if manifestWork.GetName() != instance.Namespace+"-"+instance.Name && !isManifestWorkChunkOf(&manifestWork, instance) {
 			klog.Infof("Skip the manifestWork %v/%v as it doesn't belong to the app %v/%v", manifestWork.Namespace, manifestWork.Name, instance.Namespace, instance.Name)
 
 			continue
@@ -125,7 +154,7 @@ func (r *ReconcileSubscription) getManifestWorkFamily(instance *appSubV1.Subscri
 	return manifestWorkList, nil
 }
 
-func (r *ReconcileSubscription) propagateManifestWorks(clusters []ManageClusters, instance *appSubV1.Subscription,
+func (r *ReconcileSubscription) propagateManifestWorks(ctx context.Context, clusters []ManageClusters, instance *appSubV1.Subscription,
 	familymap map[string]*manifestWorkV1.ManifestWork) (map[string]*manifestWorkV1.ManifestWork, error) {
 	var err error
 
@@ -143,8 +172,33 @@ func (r *ReconcileSubscription) propagateManifestWorks(clusters []ManageClusters
 		return nil, err
 	}
 
+	// prepare the package overrides Secret manifests
+	manifestSecretStrings, err = r.prepareManifestWorkSecrets(instance, hosting)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, cluster := range clusters {
-		familymap, err = r.createManifestWork(cluster, hosting, instance, familymap)
+		// keep the manifestWorks of the cluster if the manifests are not valid on the cluster
+		if cluster.ValidationError != "" {
+			for key, manifestWork := range familymap {
+				if manifestWork.GetNamespace() == cluster.Cluster {
+					delete(familymap, key)
+				}
+			}
+
+			r.eventRecorder.RecordEvent(instance, "Propagation", "Failed to propagate to cluster "+cluster.Cluster,
+				fmt.Errorf("%s", cluster.ValidationError))
+
+			err = utils.CreateFailedAppsubReportResult(r.Client, cluster.Cluster, instance.Namespace, instance.Name, cluster.ValidationError)
+			if err != nil {
+				klog.Error("Error create cluster appsubReport: ", err)
+			}
+
+			continue
+		}
+
+		familymap, err = r.createManifestWork(ctx, cluster, hosting, instance, familymap)
 		if err != nil {
 			klog.Errorf("Error in propagating to cluster: %v, error:%v", cluster.Cluster, err)
 
@@ -158,74 +212,175 @@ func (r *ReconcileSubscription) propagateManifestWorks(clusters []ManageClusters
 	return familymap, nil
 }
 
-func (r *ReconcileSubscription) createManifestWork(cluster ManageClusters, hosting types.NamespacedName, instance *appSubV1.Subscription,
-	familymap map[string]*manifestWorkV1.ManifestWork) (map[string]*manifestWorkV1.ManifestWork, error) {
+func (r *ReconcileSubscription) createManifestWork(ctx context.Context, cluster ManageClusters, hosting types.NamespacedName,
+	instance *appSubV1.Subscription, familymap map[string]*manifestWorkV1.ManifestWork) (map[string]*manifestWorkV1.ManifestWork, error) {
 	var err error
 
-	klog.V(1).Infof("Creating Managed manifestWork for appsub: %v/%v, cluster: %v", instance.GetNamespace(), instance.GetName(), cluster)
+	ctx, span := tracing.StartSpan(ctx, "CreateManifestWork", instance.GetNamespace(), instance.GetName())
+	span.SetAttributes(attribute.String(tracing.AttributeCluster, cluster.Cluster))
 
-	truekey := cluster.Cluster + "-" + instance.GetNamespace() + "-" + instance.GetName()
+	defer span.End()
 
-	klog.V(1).Infof("truekey: %v, familymap: %#v", truekey, familymap)
+	logging.WithSubscription(r.logger, instance).V(1).Info("Creating Managed manifestWork", "cluster", cluster)
 
-	var existingManifestWork *manifestWorkV1.ManifestWork
-	existingManifestWork, ok := familymap[truekey]
+	// evaluate the time window in the time zone of the managed cluster if requested
+	timezone := ""
+	if tw, _ := r.getTimeWindow(instance); tw != nil && tw.ClusterTimezone {
+		timezone = r.getClusterTimezone(cluster.Cluster)
+	}
+
+	overlayPath, err := r.getClusterKustomizeOverlay(instance, cluster.Cluster)
+	if err != nil {
+		klog.Errorf("Failed to select the kustomize overlay of appsub: %v/%v, err: %v", instance.GetNamespace(), instance.GetName(), err)
 
-	if !ok {
-		existingManifestWork = &manifestWorkV1.ManifestWork{}
+		return nil, err
 	}
 
-	original := existingManifestWork.DeepCopy()
+	manifests, err := getClusterManifests(cluster, timezone, overlayPath)
+	if err != nil {
+		return nil, err
+	}
 
-	existingManifestWork, err = r.setLocalManifestWork(cluster, hosting, instance, existingManifestWork)
+	// split the payload across multiple manifestWorks if it exceeds the manifestWork size limit. The payload is the
+	// appsub with its namespace and Secrets, the deployed resources are fetched from the channel by the managed cluster
+	chunks, err := chunkManifests(manifests, manifestWorkSizeLimit)
 	if err != nil {
-		klog.Error("Failed to set local manifestwork. error:", err)
+		klog.Errorf("Failed to split the manifestWork payload of appsub: %v/%v, err: %v", instance.GetNamespace(), instance.GetName(), err)
+		r.eventRecorder.RecordEvent(instance, "Propagation", "Failed to propagate to cluster "+cluster.Cluster+": "+err.Error(), err)
+
 		return nil, err
 	}
 
-	if !ok {
-		err = r.Create(context.TODO(), existingManifestWork)
-		klog.Infof("Creating new local ManifestWork: %v/%v, err: %v",
-			existingManifestWork.GetNamespace(), existingManifestWork.GetName(), err)
-	} else {
-		if !utils.CompareManifestWork(original, existingManifestWork) {
-			err = r.Update(context.TODO(), existingManifestWork)
-			klog.Infof("Updating existing local ManifestWork: %v/%v err: %v",
+	for i, chunk := range chunks {
+		manifestWorkName := getManifestWorkChunkName(instance, i)
+		truekey := cluster.Cluster + "-" + manifestWorkName
+
+		logging.WithSubscription(r.logger, instance).V(1).Info("manifestWork family", "key", truekey, "family", familymap)
+
+		var existingManifestWork *manifestWorkV1.ManifestWork
+		existingManifestWork, ok := familymap[truekey]
+
+		if !ok {
+			existingManifestWork = &manifestWorkV1.ManifestWork{}
+		}
+
+		original := existingManifestWork.DeepCopy()
+
+		existingManifestWork, err = r.setLocalManifestWork(cluster, hosting, instance, existingManifestWork, manifestWorkName, chunk)
+		if err != nil {
+			klog.Error("Failed to set local manifestwork. error:", err)
+			return nil, err
+		}
+
+		if !ok {
+			setManifestWorkTraceContext(ctx, existingManifestWork)
+
+			err = r.Create(context.TODO(), existingManifestWork)
+			klog.Infof("Creating new local ManifestWork: %v/%v, err: %v",
 				existingManifestWork.GetNamespace(), existingManifestWork.GetName(), err)
 		} else {
-			klog.Infof("Same existing local ManifestWork, no need to update: %v/%v ",
-				existingManifestWork.GetNamespace(), existingManifestWork.GetName())
+			if !utils.CompareManifestWork(original, existingManifestWork) {
+				setManifestWorkTraceContext(ctx, existingManifestWork)
+
+				err = r.Update(context.TODO(), existingManifestWork)
+				klog.Infof("Updating existing local ManifestWork: %v/%v err: %v",
+					existingManifestWork.GetNamespace(), existingManifestWork.GetName(), err)
+			} else {
+				klog.Infof("Same existing local ManifestWork, no need to update: %v/%v ",
+					existingManifestWork.GetNamespace(), existingManifestWork.GetName())
+			}
+		}
+
+		if err != nil {
+			klog.Error("Failed in processing local ManifestWork with error:", err)
+			span.RecordError(err)
+
+			return nil, err
 		}
+
+		// remove it from to-be deleted map
+		logging.WithSubscription(r.logger, instance).V(1).Info("Removing from manifestWork family", "key", truekey, "family", familymap)
+		delete(familymap, truekey)
 	}
 
-	if err != nil {
-		klog.Error("Failed in processing local ManifestWork with error:", err)
+	return familymap, nil
+}
 
-		return nil, err
+// setManifestWorkTraceContext saves the trace context in the appsub of the manifestWork, so the spans of the
+// managed cluster deployment are linked to the hub propagation. It is only set when the manifestWork is applied,
+// the trace annotations are ignored when comparing manifestWorks.
+func setManifestWorkTraceContext(ctx context.Context, manifestWork *manifestWorkV1.ManifestWork) {
+	if !tracing.HasSpan(ctx) {
+		return
 	}
 
-	// remove it from to-be deleted map
-	klog.V(1).Info("Removing ", truekey, " from ", familymap)
-	delete(familymap, truekey)
+	for i, manifest := range manifestWork.Spec.Workload.Manifests {
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(manifest.Raw, obj); err != nil || obj.GetKind() != "Subscription" {
+			continue
+		}
 
-	return familymap, nil
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		tracing.InjectAnnotations(ctx, annotations)
+		obj.SetAnnotations(annotations)
+
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			klog.Errorf("failed to marshal the appsub of manifestWork %v/%v, err: %v", manifestWork.Namespace, manifestWork.Name, err)
+
+			continue
+		}
+
+		manifestWork.Spec.Workload.Manifests[i].Raw = raw
+	}
 }
 
-func (r *ReconcileSubscription) setLocalManifestWork(cluster ManageClusters, hosting types.NamespacedName,
-	appsub *appSubV1.Subscription, localManifestWork *manifestWorkV1.ManifestWork) (*manifestWorkV1.ManifestWork, error) {
+// getClusterManifests returns the appsub namespace and appsub manifests propagated to the cluster. If the
+// timezone is set, it replaces the time window location of the appsub. If the overlay path is set, it replaces
+// the Git path of the appsub
+func getClusterManifests(cluster ManageClusters, timezone, overlayPath string) ([]manifestWorkV1.Manifest, error) {
 	newManifestAppsubByte := []byte(manifestAppsubString)
 
-	// if target cluster is local-cluster, append -local suffix to the appsub name to avoid subscription name collision in the same namespace
-	if cluster.IsLocalCluster {
-		klog.Info("This is local-cluster, Appending -local to the subscription name")
-
+	if cluster.IsLocalCluster || timezone != "" || overlayPath != "" {
 		sub := &unstructured.Unstructured{}
 
 		err := json.Unmarshal(newManifestAppsubByte, sub)
 		if err != nil {
 			klog.Info("Failed to unmarshall manifestAppsub, err:", err, " |template: ", string(newManifestAppsubByte))
 		} else {
-			sub.SetName(sub.GetName() + "-local")
+			// if target cluster is local-cluster, append -local suffix to the appsub name to avoid subscription name collision in the same namespace
+			if cluster.IsLocalCluster {
+				klog.Info("This is local-cluster, Appending -local to the subscription name")
+
+				sub.SetName(sub.GetName() + "-local")
+			}
+
+			// the managed cluster evaluates the time window in its own time zone
+			if timezone != "" {
+				klog.Infof("Setting the time window location of cluster %v to %v", cluster.Cluster, timezone)
+
+				if err := unstructured.SetNestedField(sub.Object, timezone, "spec", "timewindow", "location"); err != nil {
+					klog.Info("Failed to set the time window location, err:", err)
+				}
+			}
+
+			// the managed cluster builds the kustomize overlay selected for it
+			if overlayPath != "" {
+				klog.Infof("Setting the Git path of cluster %v to the kustomize overlay %v", cluster.Cluster, overlayPath)
+
+				annotations := sub.GetAnnotations()
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+
+				annotations[appSubV1.AnnotationGitPath] = overlayPath
+				delete(annotations, appSubV1.AnnotationGithubPath)
+				sub.SetAnnotations(annotations)
+			}
 		}
 
 		newManifestAppsubByte, err = json.Marshal(sub)
@@ -235,10 +390,35 @@ func (r *ReconcileSubscription) setLocalManifestWork(cluster ManageClusters, hos
 		}
 	}
 
+	manifests := []manifestWorkV1.Manifest{
+		{
+			RawExtension: runtime.RawExtension{
+				Raw: []byte(manifestNSString),
+			},
+		},
+	}
+
+	// the appsub of the local cluster sits in the namespace of the hub appsub, it reads the Secrets of the hub appsub
+	if !cluster.IsLocalCluster {
+		for _, secret := range manifestSecretStrings {
+			manifests = append(manifests, manifestWorkV1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(secret)}})
+		}
+	}
+
+	return append(manifests, manifestWorkV1.Manifest{
+		RawExtension: runtime.RawExtension{
+			Raw: newManifestAppsubByte,
+		},
+	}), nil
+}
+
+func (r *ReconcileSubscription) setLocalManifestWork(cluster ManageClusters, hosting types.NamespacedName,
+	appsub *appSubV1.Subscription, localManifestWork *manifestWorkV1.ManifestWork,
+	manifestWorkName string, manifests []manifestWorkV1.Manifest) (*manifestWorkV1.ManifestWork, error) {
 	localManifestWork.APIVersion = "work.open-cluster-management.io/v1"
 	localManifestWork.Kind = "ManifestWork"
 
-	localManifestWork.SetName(appsub.GetNamespace() + "-" + appsub.GetName())
+	localManifestWork.SetName(manifestWorkName)
 	localManifestWork.SetNamespace(cluster.Cluster)
 
 	localLabels := localManifestWork.GetLabels()
@@ -250,19 +430,21 @@ func (r *ReconcileSubscription) setLocalManifestWork(cluster ManageClusters, hos
 	localLabels[appSubV1.AnnotationHosting] = fmt.Sprintf("%.63s", hosting.Namespace+"."+hosting.Name)
 	localManifestWork.SetLabels(localLabels)
 
-	localManifestWork.Spec.Workload.Manifests = []manifestWorkV1.Manifest{
-		{
-			RawExtension: runtime.RawExtension{
-				Raw: []byte(manifestNSString),
-			},
-		},
-		{
-			RawExtension: runtime.RawExtension{
-				Raw: newManifestAppsubByte,
-			},
-		},
+	// the manifestWorks are regenerated from the hub subscription after a restore
+	utils.SetExcludeFromBackupLabel(localManifestWork)
+
+	// the label is truncated, the annotation identifies the hosting appsub of the manifestWork chunks
+	localAnnotations := localManifestWork.GetAnnotations()
+
+	if localAnnotations == nil {
+		localAnnotations = make(map[string]string)
 	}
 
+	localAnnotations[appSubV1.AnnotationHosting] = hosting.String()
+	localManifestWork.SetAnnotations(localAnnotations)
+
+	localManifestWork.Spec.Workload.Manifests = manifests
+
 	localManifestWork.Spec.DeleteOption = &manifestWorkV1.DeleteOption{
 		PropagationPolicy: manifestWorkV1.DeletePropagationPolicyTypeSelectivelyOrphan,
 		SelectivelyOrphan: &manifestWorkV1.SelectivelyOrphan{
@@ -277,8 +459,14 @@ func (r *ReconcileSubscription) setLocalManifestWork(cluster ManageClusters, hos
 		},
 	}
 
+	// keep the appsub refreshed by the last retry of the failed cluster
+	if err := setManifestWorkRetryTime(localManifestWork); err != nil {
+		klog.Info("Failed to set retry time in manifestWork, err:", err)
+		return nil, err
+	}
+
 	for _, manifest := range localManifestWork.Spec.Workload.Manifests {
-		klog.V(1).Infof("workload manifest: %#v", string(manifest.Raw))
+		logging.WithSubscription(r.logger, appsub).V(1).Info("workload manifest", "manifest", string(manifest.Raw))
 	}
 
 	return localManifestWork, nil
@@ -307,17 +495,40 @@ func (r *ReconcileSubscription) prepareManifestWorkAppsub(appsub *appSubV1.Subsc
 		},
 	}
 
+	// the regional hubs re-propagate the appsub to their managed clusters selected by the regional placement
+	if regionalPlacement := utils.GetRegionalPlacement(appsub); regionalPlacement != "" {
+		subep.Spec.Placement = &placementV1.Placement{
+			PlacementRef: &coreV1.ObjectReference{Kind: "Placement", Name: regionalPlacement},
+		}
+	}
+
 	subep.Spec.Channel = appsub.Spec.Channel
 	subep.Spec.Package = appsub.Spec.Package
 	subep.Spec.PackageFilter = appsub.Spec.PackageFilter
-	subep.Spec.PackageOverrides = appsub.Spec.PackageOverrides
+	// the content of the package overrides ConfigMaps is propagated, the referenced Secrets are propagated along with
+	// the appsub and resolved on the managed cluster, so their content is not exposed in the appsub
+	subep.Spec.PackageOverrides, err = utils.ResolveConfigMapPackageOverrides(r.Client, appsub)
+	if err != nil {
+		klog.Errorf("Failed to resolve the package overrides of appsub %v/%v, err: %v", appsub.GetNamespace(), appsub.GetName(), err)
+		return "", err
+	}
+
 	subep.Spec.Overrides = appsub.Spec.Overrides
-	subep.Spec.TimeWindow = appsub.Spec.TimeWindow
+	// propagate the effective time window, the deployment window is resolved on the hub. The managed clusters are
+	// blocked while the deployment window can't be resolved
+	subep.Spec.TimeWindow, _ = r.getTimeWindow(appsub)
 	subep.Spec.HookSecretRef = appsub.Spec.HookSecretRef
 	subep.Spec.Allow = appsub.Spec.Allow
 	subep.Spec.Deny = appsub.Spec.Deny
 	subep.Spec.WatchHelmNamespaceScopedResources = appsub.Spec.WatchHelmNamespaceScopedResources
+	subep.Spec.ReconcileInterval = appsub.Spec.ReconcileInterval
+	subep.Spec.DeletionPolicy = appsub.Spec.DeletionPolicy
+	subep.Spec.NamespaceCreation = appsub.Spec.NamespaceCreation
+	subep.Spec.NamespaceTemplate = appsub.Spec.NamespaceTemplate
+	subep.Spec.ServiceAccountName = appsub.Spec.ServiceAccountName
+	subep.Spec.TargetNamespaces = appsub.Spec.TargetNamespaces
 	subep.Spec.SecondaryChannel = appsub.Spec.SecondaryChannel
+	subep.Spec.FallbackChannels = appsub.Spec.FallbackChannels
 
 	subepanno := r.updateSubAnnotations(appsub, hosting)
 	subep.SetAnnotations(subepanno)
@@ -325,7 +536,7 @@ func (r *ReconcileSubscription) prepareManifestWorkAppsub(appsub *appSubV1.Subsc
 	subepLabels := appsub.GetLabels()
 	subep.SetLabels(subepLabels)
 
-	klog.V(1).Infof("new local subep: %#v", subep)
+	logging.WithSubscription(r.logger, appsub).V(1).Info("new local subscription", "subscription", subep)
 
 	manifestAppsubByte, err := json.Marshal(subep)
 	if err != nil {
@@ -336,6 +547,45 @@ func (r *ReconcileSubscription) prepareManifestWorkAppsub(appsub *appSubV1.Subsc
 	return string(manifestAppsubByte), nil
 }
 
+// prepareManifestWorkSecrets returns the manifests of the Secrets referenced by the package overrides of the appsub
+func (r *ReconcileSubscription) prepareManifestWorkSecrets(appsub *appSubV1.Subscription, hosting types.NamespacedName) ([]string, error) {
+	secrets, err := utils.GetPackageOverridesSecrets(r.Client, appsub)
+	if err != nil {
+		klog.Errorf("Failed to get the package overrides Secrets of appsub %v/%v, err: %v", appsub.GetNamespace(), appsub.GetName(), err)
+		return nil, err
+	}
+
+	manifests := make([]string, 0, len(secrets))
+
+	for _, secret := range secrets {
+		endpointSecret := &coreV1.Secret{
+			TypeMeta: metaV1.TypeMeta{
+				Kind:       "Secret",
+				APIVersion: "v1",
+			},
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      secret.GetName(),
+				Namespace: secret.GetNamespace(),
+				Annotations: map[string]string{
+					appSubV1.AnnotationHosting: hosting.String(),
+				},
+			},
+			Type: secret.Type,
+			Data: secret.Data,
+		}
+
+		manifestSecretByte, err := json.Marshal(endpointSecret)
+		if err != nil {
+			klog.Info("Error in mashalling secret obj ", err)
+			return nil, err
+		}
+
+		manifests = append(manifests, string(manifestSecretByte))
+	}
+
+	return manifests, nil
+}
+
 func prepareManifestWorkNS(appsubNS string, hosting types.NamespacedName) (string, error) {
 	var err error
 
@@ -498,26 +748,30 @@ func (r *ReconcileSubscription) updateSubAnnotations(sub *appSubV1.Subscription,
 		subepanno[appSubV1.AnnotationWebhookEventCount] = origsubanno[appSubV1.AnnotationWebhookEventCount]
 	}
 
-	if !strings.EqualFold(origsubanno[appSubV1.AnnotationGitBranch], "") {
-		subepanno[appSubV1.AnnotationGitBranch] = origsubanno[appSubV1.AnnotationGitBranch]
-	} else if !strings.EqualFold(origsubanno[appSubV1.AnnotationGithubBranch], "") {
-		subepanno[appSubV1.AnnotationGitBranch] = origsubanno[appSubV1.AnnotationGithubBranch]
+	if gitBranch := utils.GetGitBranchAnnotation(origsubanno); gitBranch != "" {
+		subepanno[appSubV1.AnnotationGitBranch] = gitBranch
 	}
 
-	if !strings.EqualFold(origsubanno[appSubV1.AnnotationGitPath], "") {
-		subepanno[appSubV1.AnnotationGitPath] = origsubanno[appSubV1.AnnotationGitPath]
-	} else if !strings.EqualFold(origsubanno[appSubV1.AnnotationGithubPath], "") {
-		subepanno[appSubV1.AnnotationGitPath] = origsubanno[appSubV1.AnnotationGithubPath]
+	if gitPath := utils.GetGitPathAnnotation(origsubanno); gitPath != "" {
+		subepanno[appSubV1.AnnotationGitPath] = gitPath
 	}
 
 	if !strings.EqualFold(origsubanno[appSubV1.AnnotationBucketPath], "") {
 		subepanno[appSubV1.AnnotationBucketPath] = origsubanno[appSubV1.AnnotationBucketPath]
 	}
 
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationLogLevel], "") {
+		subepanno[appSubV1.AnnotationLogLevel] = origsubanno[appSubV1.AnnotationLogLevel]
+	}
+
 	if !strings.EqualFold(origsubanno[appSubV1.AnnotationClusterAdmin], "") && r.AddClusterAdminAnnotation(sub) {
 		subepanno[appSubV1.AnnotationClusterAdmin] = origsubanno[appSubV1.AnnotationClusterAdmin]
 	}
 
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationTargetNamespaces], "") {
+		subepanno[appSubV1.AnnotationTargetNamespaces] = origsubanno[appSubV1.AnnotationTargetNamespaces]
+	}
+
 	if !strings.EqualFold(origsubanno[appSubV1.AnnotationCurrentNamespaceScoped], "") {
 		subepanno[appSubV1.AnnotationCurrentNamespaceScoped] = origsubanno[appSubV1.AnnotationCurrentNamespaceScoped]
 	}
@@ -526,6 +780,10 @@ func (r *ReconcileSubscription) updateSubAnnotations(sub *appSubV1.Subscription,
 		subepanno[appSubV1.AnnotationResourceReconcileOption] = origsubanno[appSubV1.AnnotationResourceReconcileOption]
 	}
 
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationResourceUpdateStrategy], "") {
+		subepanno[appSubV1.AnnotationResourceUpdateStrategy] = origsubanno[appSubV1.AnnotationResourceUpdateStrategy]
+	}
+
 	if !strings.EqualFold(origsubanno[appSubV1.AnnotationGitTargetCommit], "") {
 		subepanno[appSubV1.AnnotationGitTargetCommit] = origsubanno[appSubV1.AnnotationGitTargetCommit]
 	}
@@ -546,6 +804,51 @@ func (r *ReconcileSubscription) updateSubAnnotations(sub *appSubV1.Subscription,
 		subepanno[appSubV1.AnnotationManualReconcileTime] = origsubanno[appSubV1.AnnotationManualReconcileTime]
 	}
 
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationFluxPassthrough], "") {
+		subepanno[appSubV1.AnnotationFluxPassthrough] = origsubanno[appSubV1.AnnotationFluxPassthrough]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationFluxHealthCheck], "") {
+		subepanno[appSubV1.AnnotationFluxHealthCheck] = origsubanno[appSubV1.AnnotationFluxHealthCheck]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationPolicyCheck], "") {
+		subepanno[appSubV1.AnnotationPolicyCheck] = origsubanno[appSubV1.AnnotationPolicyCheck]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationImageResolution], "") {
+		subepanno[appSubV1.AnnotationImageResolution] = origsubanno[appSubV1.AnnotationImageResolution]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationImageUpdatePolicy], "") {
+		subepanno[appSubV1.AnnotationImageUpdatePolicy] = origsubanno[appSubV1.AnnotationImageUpdatePolicy]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationImagePullSecret], "") {
+		subepanno[appSubV1.AnnotationImagePullSecret] = origsubanno[appSubV1.AnnotationImagePullSecret]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationImageScanInterval], "") {
+		subepanno[appSubV1.AnnotationImageScanInterval] = origsubanno[appSubV1.AnnotationImageScanInterval]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationHelmTimeout], "") {
+		subepanno[appSubV1.AnnotationHelmTimeout] = origsubanno[appSubV1.AnnotationHelmTimeout]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationHelmAtomic], "") {
+		subepanno[appSubV1.AnnotationHelmAtomic] = origsubanno[appSubV1.AnnotationHelmAtomic]
+	}
+
+	if !strings.EqualFold(origsubanno[appSubV1.AnnotationHelmReleaseName], "") {
+		subepanno[appSubV1.AnnotationHelmReleaseName] = origsubanno[appSubV1.AnnotationHelmReleaseName]
+	}
+
+	// the managed clusters refresh the channel credentials when the channel references version changes
+	if referencesVersion := r.getChannelReferencesVersion(sub); referencesVersion != "" {
+		subepanno[appSubV1.AnnotationChannelReferencesVersion] = referencesVersion
+	}
+
 	// Keep cluster admin annotation from the source subscription.
 	if !strings.EqualFold(origsubanno[appSubV1.AnnotationClusterAdmin], "") {
 		subepanno[appSubV1.AnnotationClusterAdmin] = origsubanno[appSubV1.AnnotationClusterAdmin]
@@ -0,0 +1,164 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ansiblejob "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/ansible/v1alpha1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// reconcileRestore re-adopts the resources of a hub subscription restored by Velero, so the subscription picks up
+// where the previous hub left it instead of being deployed from scratch. The restored hook jobs are adopted by the
+// subscription and the results of the clusters not managed by this hub are removed from the application report. The
+// manifestWorks are regenerated by the propagation. A restore is reconciled once, its name is kept in the
+// restore-name annotation.
+func (r *ReconcileSubscription) reconcileRestore(instance *appv1.Subscription) {
+	restoreName := utils.GetRestoreName(instance)
+	if restoreName == "" || instance.GetAnnotations()[appv1.AnnotationRestoreName] == restoreName {
+		return
+	}
+
+	adopted, err := r.adoptHookJobs(instance)
+	if err != nil {
+		klog.Errorf("failed to adopt the hook jobs of the restored appsub %v/%v, err: %v", instance.Namespace, instance.Name, err)
+
+		return
+	}
+
+	pruned, err := r.pruneStaleClusterResults(instance)
+	if err != nil {
+		klog.Errorf("failed to prune the stale cluster results of the restored appsub %v/%v, err: %v", instance.Namespace,
+			instance.Name, err)
+
+		return
+	}
+
+	annotations := instance.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[appv1.AnnotationRestoreName] = restoreName
+	instance.SetAnnotations(annotations)
+
+	msg := fmt.Sprintf("Reconciled the restore %v, %v hook jobs adopted, %v stale cluster results removed", restoreName,
+		adopted, pruned)
+
+	klog.Infof("%v, appsub: %v/%v", msg, instance.Namespace, instance.Name)
+
+	if r.eventRecorder != nil {
+		r.eventRecorder.RecordEvent(instance, utils.EventReasonRestored, msg, nil)
+	}
+}
+
+// adoptHookJobs points the owner references of the restored hook jobs of the subscription to the restored
+// subscription, the hooks already run are found by the hook registration and are not run again
+func (r *ReconcileSubscription) adoptHookJobs(instance *appv1.Subscription) (int, error) {
+	jobList := &ansiblejob.AnsibleJobList{}
+	if err := r.List(context.TODO(), jobList, client.InNamespace(instance.Namespace)); err != nil {
+		// the hooks are not used on the hubs without the AnsibleJob API
+		if meta.IsNoMatchError(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	hosting := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}.String()
+	adopted := 0
+
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+
+		if job.GetAnnotations()[appv1.AnnotationHosting] != hosting {
+			continue
+		}
+
+		if !utils.AdoptOwnerReferences(job, instance) {
+			continue
+		}
+
+		if err := r.Update(context.TODO(), job); err != nil {
+			return adopted, err
+		}
+
+		klog.Infof("hook job %v/%v adopted by the restored appsub %v", job.Namespace, job.Name, hosting)
+
+		adopted++
+	}
+
+	return adopted, nil
+}
+
+// pruneStaleClusterResults removes the results of the clusters that are not managed clusters of this hub from the
+// restored application report of the subscription. The report of the restored hub is regenerated from the reports of
+// its managed clusters.
+func (r *ReconcileSubscription) pruneStaleClusterResults(instance *appv1.Subscription) (int, error) {
+	report := &appv1alpha1.SubscriptionReport{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, report)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	clusterList := &spokeClusterV1.ManagedClusterList{}
+	if err := r.List(context.TODO(), clusterList); err != nil {
+		return 0, err
+	}
+
+	clusters := make(map[string]bool, len(clusterList.Items))
+	for _, cluster := range clusterList.Items {
+		clusters[cluster.Name] = true
+	}
+
+	results := []*appv1alpha1.SubscriptionReportResult{}
+
+	for _, result := range report.Results {
+		if result != nil && clusters[result.Source] {
+			results = append(results, result)
+		}
+	}
+
+	pruned := len(report.Results) - len(results)
+	adopted := utils.AdoptOwnerReferences(report, instance)
+
+	if pruned == 0 && !adopted {
+		return 0, nil
+	}
+
+	report.Results = results
+
+	if err := r.Update(context.TODO(), report); err != nil {
+		return 0, err
+	}
+
+	return pruned, nil
+}
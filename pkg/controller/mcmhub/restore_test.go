@@ -0,0 +1,89 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	ansiblejob "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/ansible/v1alpha1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileRestore(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(appv1.SchemeBuilder.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(appv1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(ansiblejob.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(spokeClusterV1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	// the subscription restored on the new hub has a new UID, its restored resources still point to the old one
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "restored-uid",
+		Labels: map[string]string{utils.RestoreNameLabel: "restore-1"}}}
+
+	staleOwner := []metav1.OwnerReference{{APIVersion: appv1.SchemeGroupVersion.String(), Kind: "Subscription",
+		Name: "app", UID: "old-uid"}}
+
+	prehook := &ansiblejob.AnsibleJob{ObjectMeta: metav1.ObjectMeta{Name: "prehook-1", Namespace: "default",
+		Annotations:     map[string]string{appv1.AnnotationHosting: "default/app"},
+		OwnerReferences: staleOwner}}
+	otherJob := &ansiblejob.AnsibleJob{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default",
+		Annotations: map[string]string{appv1.AnnotationHosting: "default/other"}}}
+
+	report := &appv1alpha1.SubscriptionReport{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", OwnerReferences: staleOwner},
+		ReportType: "Application",
+		Results: []*appv1alpha1.SubscriptionReportResult{
+			{Source: "cluster1", Result: "deployed"},
+			{Source: "old-cluster", Result: "failed"},
+		},
+	}
+
+	cluster := &spokeClusterV1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sub, prehook, otherJob, report, cluster).Build()
+	r := &ReconcileSubscription{Client: clt, scheme: scheme}
+
+	r.reconcileRestore(sub)
+	g.Expect(sub.GetAnnotations()).To(gomega.HaveKeyWithValue(appv1.AnnotationRestoreName, "restore-1"))
+
+	// the hook jobs of the subscription are adopted by the restored subscription
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Name: "prehook-1", Namespace: "default"}, prehook)).To(gomega.Succeed())
+	g.Expect(prehook.OwnerReferences[0].UID).To(gomega.Equal(sub.UID))
+
+	// the results of the clusters that are not managed by the new hub are removed
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Name: "app", Namespace: "default"}, report)).To(gomega.Succeed())
+	g.Expect(report.OwnerReferences[0].UID).To(gomega.Equal(sub.UID))
+	g.Expect(report.Results).To(gomega.HaveLen(1))
+	g.Expect(report.Results[0].Source).To(gomega.Equal("cluster1"))
+
+	// a restore is reconciled once
+	report.Results = append(report.Results, &appv1alpha1.SubscriptionReportResult{Source: "old-cluster"})
+	g.Expect(clt.Update(context.TODO(), report)).To(gomega.Succeed())
+
+	r.reconcileRestore(sub)
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Name: "app", Namespace: "default"}, report)).To(gomega.Succeed())
+	g.Expect(report.Results).To(gomega.HaveLen(2))
+}
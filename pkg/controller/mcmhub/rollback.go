@@ -0,0 +1,86 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// processRollback pins the git desired commit of the appsub to the last commit successfully deployed on all the
+// managed clusters when the rollback annotation is set. The annotation is removed once processed. The annotations are
+// patched right away, finalCommit doesn't update the metadata of the appsub if the branch registration fails.
+func (r *ReconcileSubscription) processRollback(ctx context.Context, instance *appv1.Subscription) error {
+	if !strings.EqualFold(instance.GetAnnotations()[appv1.AnnotationRollback], "true") {
+		return nil
+	}
+
+	rolledBack := instance.DeepCopy()
+	annotations := rolledBack.GetAnnotations()
+
+	delete(annotations, appv1.AnnotationRollback)
+
+	commit := getRollbackCommit(instance)
+	if commit != "" {
+		annotations[appv1.AnnotationGitTargetCommit] = commit
+	}
+
+	rolledBack.SetAnnotations(annotations)
+
+	if err := r.Patch(ctx, rolledBack, client.MergeFrom(instance), &client.PatchOptions{FieldManager: r.name}); err != nil {
+		return fmt.Errorf("failed to roll back appsub %v/%v, err: %w", instance.Namespace, instance.Name, err)
+	}
+
+	currentCommit := utils.GetAppsubCurrentCommit(instance)
+
+	instance.SetAnnotations(rolledBack.GetAnnotations())
+	instance.SetResourceVersion(rolledBack.GetResourceVersion())
+
+	if commit == "" {
+		err := fmt.Errorf("no previous commit successfully deployed on all the clusters")
+		klog.Errorf("failed to roll back appsub %v/%v, err: %v", instance.Namespace, instance.Name, err)
+		r.eventRecorder.RecordEvent(instance, "Rollback", "Failed to roll back: "+err.Error(), err)
+
+		return nil
+	}
+
+	msg := fmt.Sprintf("Rolled back from commit %v to commit %v", currentCommit, commit)
+
+	klog.Infof("%v, appsub: %v/%v", msg, instance.Namespace, instance.Name)
+	r.eventRecorder.RecordEvent(instance, "Rollback", msg, nil)
+
+	return nil
+}
+
+// getRollbackCommit returns the most recent commit successfully deployed on all the managed clusters that is not the
+// current commit of the appsub
+func getRollbackCommit(instance *appv1.Subscription) string {
+	currentCommit := utils.GetAppsubCurrentCommit(instance)
+
+	for _, record := range instance.Status.CommitHistory {
+		if record.Commit != currentCommit {
+			return record.Commit
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+func TestGetRollbackCommit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	instance := &appv1.Subscription{}
+	g.Expect(getRollbackCommit(instance)).To(gomega.BeEmpty())
+
+	instance.Status.CommitHistory = []appv1.SubscriptionCommitRecord{{Commit: "ccc"}, {Commit: "bbb"}}
+
+	// the current commit failed to roll out, back to the last successful one
+	instance.SetAnnotations(map[string]string{appv1.AnnotationGitCommit: "ddd-new"})
+	g.Expect(getRollbackCommit(instance)).To(gomega.Equal("ccc"))
+
+	// the current commit is deployed everywhere, back to the previous one
+	instance.SetAnnotations(map[string]string{appv1.AnnotationGitCommit: "ccc"})
+	g.Expect(getRollbackCommit(instance)).To(gomega.Equal("bbb"))
+
+	instance.Status.CommitHistory = instance.Status.CommitHistory[:1]
+	g.Expect(getRollbackCommit(instance)).To(gomega.BeEmpty())
+}
+
+func TestProcessRollbackPersisted(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(appv1.SchemeBuilder.AddToScheme(scheme)).To(gomega.Succeed())
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+			Annotations: map[string]string{
+				appv1.AnnotationRollback:  "true",
+				appv1.AnnotationGitCommit: "ccc",
+			},
+		},
+		Spec: appv1.SubscriptionSpec{Channel: "default/git"},
+		Status: appv1.SubscriptionStatus{
+			CommitHistory: []appv1.SubscriptionCommitRecord{{Commit: "ccc"}, {Commit: "bbb"}},
+		},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sub).WithStatusSubresource(sub).Build()
+	r := &ReconcileSubscription{
+		Client:        clt,
+		scheme:        scheme,
+		logger:        logr.Discard(),
+		eventRecorder: &utils.EventRecorder{EventRecorder: record.NewFakeRecorder(10)},
+		hooks:         NewAnsibleHooks(clt, 0),
+		clk:           time.Now,
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "app"}
+
+	instance := &appv1.Subscription{}
+	g.Expect(clt.Get(context.TODO(), key, instance)).To(gomega.Succeed())
+
+	oins := instance.DeepCopy()
+
+	g.Expect(r.processRollback(context.TODO(), instance)).To(gomega.Succeed())
+	g.Expect(instance.GetAnnotations()).NotTo(gomega.HaveKey(appv1.AnnotationRollback))
+	g.Expect(instance.GetAnnotations()[appv1.AnnotationGitTargetCommit]).To(gomega.Equal("bbb"))
+
+	// the branch registration fails, finalCommit only patches the status of the appsub
+	var res reconcile.Result
+
+	r.finalCommit(false, true, errors.New("failed to initialize Git connection"), oins, instance,
+		reconcile.Request{NamespacedName: key}, &res, false)
+
+	persisted := &appv1.Subscription{}
+	g.Expect(clt.Get(context.TODO(), key, persisted)).To(gomega.Succeed())
+	g.Expect(persisted.Status.Phase).To(gomega.Equal(appv1.SubscriptionPropagationFailed))
+
+	// the rollback is not processed again by the next reconcile
+	g.Expect(persisted.GetAnnotations()).NotTo(gomega.HaveKey(appv1.AnnotationRollback))
+	g.Expect(persisted.GetAnnotations()[appv1.AnnotationGitTargetCommit]).To(gomega.Equal("bbb"))
+}
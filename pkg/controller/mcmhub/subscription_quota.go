@@ -0,0 +1,62 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcmhub
+
+import (
+	"context"
+
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// checkSubscriptionQuota returns an error if propagating the subscription to the clusters exceeds the
+// subscription quotas of its namespace. A negative resourceCount means the resources are not rendered on the hub.
+func (r *ReconcileSubscription) checkSubscriptionQuota(sub *appv1.Subscription, resourceCount, clusterCount int) error {
+	quotas, err := utils.GetSubscriptionQuotas(r.Client, sub.Namespace)
+	if err != nil || len(quotas) == 0 {
+		return err
+	}
+
+	subs := &appv1.SubscriptionList{}
+	if err := r.List(context.TODO(), subs, client.InNamespace(sub.Namespace)); err != nil {
+		return err
+	}
+
+	if err := utils.CheckSubscriptionCountQuota(quotas, subs.Items, sub); err != nil {
+		r.eventRecorder.RecordEvent(sub, "QuotaExceeded", err.Error(), err)
+
+		return err
+	}
+
+	reports := &appsubReportV1alpha1.SubscriptionReportList{}
+	if err := r.List(context.TODO(), reports, client.InNamespace(sub.Namespace)); err != nil {
+		return err
+	}
+
+	if err := utils.CheckDeploymentQuota(quotas, reports.Items, sub, resourceCount, clusterCount); err != nil {
+		r.eventRecorder.RecordEvent(sub, "QuotaExceeded", err.Error(), err)
+
+		return err
+	}
+
+	klog.V(1).Infof("subscription quota checked, appsub: %v/%v, resources: %v, clusters: %v",
+		sub.Namespace, sub.Name, resourceCount, clusterCount)
+
+	return nil
+}
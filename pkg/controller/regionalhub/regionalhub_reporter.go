@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package regionalhub
+
+import (
+	"context"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// reportInterval is the interval of the status roll-up, it matches the interval of the appsub summaries
+const reportInterval = time.Minute
+
+// Add adds the regional hub reporter to the manager of a managed cluster agent. On a regional hub, the reporter rolls
+// the status of the subscriptions re-propagated to its managed clusters up to the cluster appsubReport on the global hub.
+func Add(mgr manager.Manager, hubconfig *rest.Config, syncid *types.NamespacedName, standalone bool) error {
+	if standalone || hubconfig == nil || syncid == nil || syncid.Namespace == "" {
+		return nil
+	}
+
+	hubclient, err := client.New(hubconfig, client.Options{})
+	if err != nil {
+		klog.Error("Failed to generate client to hub cluster with error:", err)
+		return err
+	}
+
+	reporter := &Reporter{
+		Client:      mgr.GetClient(),
+		hubclient:   hubclient,
+		clusterName: syncid.Namespace,
+	}
+
+	return mgr.Add(reporter)
+}
+
+// Reporter reports the status of the regional hub subscriptions to the global hub
+type Reporter struct {
+	client.Client
+	hubclient   client.Client
+	clusterName string
+}
+
+// Start reports the status until the context is done
+func (r *Reporter) Start(ctx context.Context) error {
+	klog.Info("Starting the regional hub reporter of cluster ", r.clusterName)
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.report(ctx); err != nil {
+			klog.Errorf("Failed to report the regional hub subscriptions of cluster %v, err: %v", r.clusterName, err)
+		}
+	}, reportInterval)
+
+	return nil
+}
+
+func (r *Reporter) report(ctx context.Context) error {
+	subList := &appv1.SubscriptionList{}
+	if err := r.List(ctx, subList); err != nil {
+		return err
+	}
+
+	for i := range subList.Items {
+		sub := &subList.Items[i]
+
+		if !utils.IsRegionalHubSubscription(sub) {
+			continue
+		}
+
+		appsubReport := &appsubReportV1alpha1.SubscriptionReport{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name}, appsubReport); err != nil {
+			if !kerrors.IsNotFound(err) {
+				klog.Errorf("Failed to get the appsubReport of appsub %v/%v, err: %v", sub.Namespace, sub.Name, err)
+			}
+
+			continue
+		}
+
+		result, ok := utils.GetRegionalHubResult(appsubReport.Summary)
+		if !ok {
+			continue
+		}
+
+		klog.V(1).Infof("Regional hub appsub %v/%v is %v", sub.Namespace, sub.Name, result)
+
+		if err := utils.UpdateAppsubReportResult(r.hubclient, r.clusterName, sub.Namespace, sub.Name, result); err != nil {
+			klog.Errorf("Failed to report the result of appsub %v/%v, err: %v", sub.Namespace, sub.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalonetarget
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/controller/subscription"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/helmrelease/controller/helmrelease"
+	ghsub "open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber/git"
+	hrsub "open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber/helmrepo"
+	ossub "open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber/objectbucket"
+	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+const (
+	// KubeconfigKey is the key of the kubeconfig in the target secrets
+	KubeconfigKey = "kubeconfig"
+	// the interval of the target secret discovery
+	targetSyncInterval = time.Minute
+	// the directory the Git repos of the targets are cloned under, it is not a valid namespace name so it doesn't
+	// collide with the clone directories of the standalone subscriptions
+	targetGitFolder = "standalone_targets"
+)
+
+// Add adds the standalone target controller to the manager of a standalone subscription controller. It runs a set of
+// subscription controller, subscribers and synchronizer per remote cluster, defined by the kubeconfig secrets labeled
+// with apps.open-cluster-management.io/standalone-target in the pod namespace.
+func Add(mgr manager.Manager, syncid *types.NamespacedName, syncinterval int) error {
+	return mgr.Add(&TargetManager{
+		Client:       mgr.GetClient(),
+		mgr:          mgr,
+		syncid:       syncid,
+		syncinterval: syncinterval,
+		namespace:    utils.GetComponentNamespace(),
+		targets:      map[string]*target{},
+	})
+}
+
+// TargetManager starts and stops the subscription controllers of the remote clusters
+type TargetManager struct {
+	client.Client
+	mgr          manager.Manager
+	syncid       *types.NamespacedName
+	syncinterval int
+	namespace    string
+	targets      map[string]*target
+}
+
+// target is a running subscription controller of a remote cluster
+type target struct {
+	kubeconfigHash string
+	cancel         context.CancelFunc
+}
+
+// Start syncs the targets with the kubeconfig secrets until the context is done
+func (m *TargetManager) Start(ctx context.Context) error {
+	klog.Info("Starting the standalone target manager, kubeconfig secrets namespace: ", m.namespace)
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := m.sync(ctx); err != nil {
+			klog.Errorf("Failed to sync the standalone targets, err: %v", err)
+		}
+	}, targetSyncInterval)
+
+	for name, t := range m.targets {
+		klog.Info("Stopping standalone target ", name)
+		t.cancel()
+	}
+
+	return nil
+}
+
+func (m *TargetManager) sync(ctx context.Context) error {
+	secrets := &corev1.SecretList{}
+	if err := m.List(ctx, secrets, client.InNamespace(m.namespace), client.HasLabels{appv1.LabelStandaloneTarget}); err != nil {
+		return err
+	}
+
+	running := map[string]string{}
+	for name, t := range m.targets {
+		running[name] = t.kubeconfigHash
+	}
+
+	toStart, toStop := diffTargets(running, secrets.Items)
+
+	for _, name := range toStop {
+		klog.Info("Stopping standalone target ", name)
+
+		m.targets[name].cancel()
+		delete(m.targets, name)
+	}
+
+	for name, kubeconfig := range toStart {
+		klog.Info("Starting standalone target ", name)
+
+		cancel, err := m.startTarget(ctx, name, kubeconfig)
+		if err != nil {
+			klog.Errorf("Failed to start standalone target %v, err: %v", name, err)
+			continue
+		}
+
+		m.targets[name] = &target{kubeconfigHash: hashKubeconfig(kubeconfig), cancel: cancel}
+	}
+
+	return nil
+}
+
+// diffTargets returns the kubeconfig of the targets to start and the name of the targets to stop. A running target is
+// restarted if its kubeconfig changed.
+func diffTargets(running map[string]string, secrets []corev1.Secret) (map[string][]byte, []string) {
+	toStart := map[string][]byte{}
+	toStop := []string{}
+	found := map[string]bool{}
+
+	for _, secret := range secrets {
+		kubeconfig := secret.Data[KubeconfigKey]
+		if len(kubeconfig) == 0 {
+			klog.Warningf("Ignoring standalone target secret %v/%v without %v", secret.Namespace, secret.Name, KubeconfigKey)
+			continue
+		}
+
+		found[secret.Name] = true
+
+		hash, ok := running[secret.Name]
+		if ok && hash == hashKubeconfig(kubeconfig) {
+			continue
+		}
+
+		if ok {
+			toStop = append(toStop, secret.Name)
+		}
+
+		toStart[secret.Name] = kubeconfig
+	}
+
+	for name := range running {
+		if !found[name] {
+			toStop = append(toStop, name)
+		}
+	}
+
+	return toStart, toStop
+}
+
+func hashKubeconfig(kubeconfig []byte) string {
+	h := sha256.Sum256(kubeconfig)
+
+	return hex.EncodeToString(h[:])
+}
+
+// startTarget starts the subscription controller of a remote cluster, it is stopped with the returned cancel function
+func (m *TargetManager) startTarget(ctx context.Context, name string, kubeconfig []byte) (context.CancelFunc, error) {
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.QPS = 100.0
+	cfg.Burst = 200
+
+	// the leader election of the standalone subscription pod covers the targets, and the metrics of the targets are
+	// served by the metrics server of the pod
+	targetMgr, err := manager.New(cfg, manager.Options{
+		Scheme:  m.mgr.GetScheme(),
+		Metrics: metricsserver.Options{BindAddress: "0"},
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				DisableFor: utils.GetCacheDisabledObjects(false),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.setupTarget(targetMgr, cfg, name); err != nil {
+		return nil, err
+	}
+
+	targetCtx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		if err := targetMgr.Start(targetCtx); err != nil {
+			klog.Errorf("Standalone target %v exited, err: %v", cfg.Host, err)
+		}
+	}()
+
+	return cancel, nil
+}
+
+// setupTarget adds the synchronizer, the subscribers and the controllers of a remote cluster to its manager
+func (m *TargetManager) setupTarget(targetMgr manager.Manager, cfg *rest.Config, name string) error {
+	sync, err := kubesynchronizer.CreateSynchronizer(cfg, cfg, targetMgr.GetScheme(), m.syncid, m.syncinterval,
+		&kubesynchronizer.SubscriptionExtension{}, false, true)
+	if err != nil {
+		return err
+	}
+
+	if err := targetMgr.Add(sync); err != nil {
+		return err
+	}
+
+	subs := make(map[string]appv1.Subscriber)
+
+	gitSubscriber := ghsub.CreateGitHubSubscriber(cfg, targetMgr.GetScheme(), targetMgr, sync, m.syncinterval)
+	// the same subscription deployed to several targets is cloned once per target
+	gitSubscriber.SetLocalGitFolderPrefix(filepath.Join(targetGitFolder, name))
+
+	subs[chnv1.ChannelTypeHelmRepo] = hrsub.CreateHelmRepoSubsriber(cfg, targetMgr.GetScheme(), targetMgr, sync, m.syncinterval)
+	subs[chnv1.ChannelTypeGitHub] = gitSubscriber
+	subs[chnv1.ChannelTypeGit] = gitSubscriber
+	subs[chnv1.ChannelTypeObjectBucket] = ossub.CreateObjectBucketSubsriber(cfg, targetMgr.GetScheme(), targetMgr, sync, m.syncinterval)
+
+	if err := helmrelease.AddWithSynchronizer(targetMgr, sync); err != nil {
+		return err
+	}
+
+	return subscription.AddWithSubscribers(targetMgr, cfg, subs, true)
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalonetarget
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func targetSecret(name, kubeconfig string) corev1.Secret {
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "open-cluster-management"},
+		Data:       map[string][]byte{KubeconfigKey: []byte(kubeconfig)},
+	}
+}
+
+func TestDiffTargets(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	running := map[string]string{
+		"unchanged": hashKubeconfig([]byte("kubeconfig-1")),
+		"changed":   hashKubeconfig([]byte("kubeconfig-2")),
+		"removed":   hashKubeconfig([]byte("kubeconfig-3")),
+	}
+
+	secrets := []corev1.Secret{
+		targetSecret("unchanged", "kubeconfig-1"),
+		targetSecret("changed", "kubeconfig-2-rotated"),
+		targetSecret("added", "kubeconfig-4"),
+		targetSecret("invalid", ""),
+	}
+
+	toStart, toStop := diffTargets(running, secrets)
+
+	g.Expect(toStart).To(gomega.HaveLen(2))
+	g.Expect(toStart).To(gomega.HaveKeyWithValue("changed", []byte("kubeconfig-2-rotated")))
+	g.Expect(toStart).To(gomega.HaveKeyWithValue("added", []byte("kubeconfig-4")))
+
+	sort.Strings(toStop)
+	g.Expect(toStop).To(gomega.Equal([]string{"changed", "removed"}))
+}
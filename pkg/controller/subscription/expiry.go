@@ -0,0 +1,34 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscription
+
+import (
+	"context"
+
+	"k8s.io/klog"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deleteExpiredSubscription deletes the expired standalone subscription, its resources are purged once it is gone, or
+// by its finalizer if it has a deletionPolicy. The subscriptions propagated from the hub are deleted by the hub.
+func (r *ReconcileSubscription) deleteExpiredSubscription(instance *appv1.Subscription) error {
+	klog.Infof("subscription %v/%v is expired, deleting it", instance.Namespace, instance.Name)
+
+	r.recordEvent(instance, utils.EventReasonExpired, "The subscription is expired, it is deleted with its deployed resources", nil)
+
+	return client.IgnoreNotFound(r.Delete(context.TODO(), instance))
+}
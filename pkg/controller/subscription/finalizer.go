@@ -0,0 +1,154 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscription
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	releasev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// the requeue period while the HelmReleases of a deleted subscription are being uninstalled
+const cleanupRequeuePeriod = 5 * time.Second
+
+// ensureCleanupFinalizer adds the resource cleanup finalizer to the subscription when it has a deletionPolicy, and
+// removes it otherwise. The subscription is only updated if the finalizers are changed.
+func (r *ReconcileSubscription) ensureCleanupFinalizer(instance *appv1.Subscription) error {
+	var changed bool
+
+	if instance.Spec.DeletionPolicy != "" {
+		changed = controllerutil.AddFinalizer(instance, appv1.FinalizerResourceCleanup)
+	} else {
+		changed = controllerutil.RemoveFinalizer(instance, appv1.FinalizerResourceCleanup)
+	}
+
+	if !changed {
+		return nil
+	}
+
+	klog.Infof("updating the finalizers of subscription %s/%s, deletionPolicy: %q",
+		instance.Namespace, instance.Name, instance.Spec.DeletionPolicy)
+
+	return r.Update(context.TODO(), instance)
+}
+
+// finalizeSubscription deletes or orphans the resources deployed by a deleted subscription according to its
+// deletionPolicy, then removes the resource cleanup finalizer.
+func (r *ReconcileSubscription) finalizeSubscription(instance *appv1.Subscription) (reconcile.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, appv1.FinalizerResourceCleanup) {
+		return reconcile.Result{}, nil
+	}
+
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+
+	klog.Infof("finalizing subscription %v, deletionPolicy: %v", key.String(), instance.Spec.DeletionPolicy)
+
+	for _, sub := range r.subscribers {
+		if err := sub.UnsubscribeItem(key); err != nil {
+			return reconcile.Result{RequeueAfter: time.Second * 2}, err
+		}
+	}
+
+	// the subscriber items are not restored yet if the agent is just started, purging again is a no-op otherwise
+	if sync := kubesynchronizer.GetDefaultSynchronizer(); sync != nil {
+		if err := sync.PurgeAllSubscribedResources(instance); err != nil {
+			return reconcile.Result{RequeueAfter: time.Second * 2}, err
+		}
+	}
+
+	// the HelmReleases are owned by the subscription, they have to be released from it before the garbage collector
+	// deletes them with the subscription, or to be uninstalled before the finalizer is removed
+	remaining, err := r.cleanupHelmReleases(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if remaining > 0 {
+		klog.Infof("waiting for %d HelmReleases of subscription %v to be uninstalled", remaining, key.String())
+
+		return reconcile.Result{RequeueAfter: cleanupRequeuePeriod}, nil
+	}
+
+	controllerutil.RemoveFinalizer(instance, appv1.FinalizerResourceCleanup)
+
+	if err := r.Update(context.TODO(), instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	klog.Infof("subscription %v is finalized", key.String())
+
+	return reconcile.Result{}, nil
+}
+
+// cleanupHelmReleases removes the subscription owner reference from its HelmReleases with the Orphan deletionPolicy
+// or the do-not-delete annotation, and deletes them otherwise. It returns the number of HelmReleases that are still
+// being uninstalled.
+func (r *ReconcileSubscription) cleanupHelmReleases(instance *appv1.Subscription) (int, error) {
+	hrList := &releasev1.HelmReleaseList{}
+	if err := r.List(context.TODO(), hrList, client.InNamespace(instance.Namespace)); err != nil {
+		return 0, err
+	}
+
+	remaining := 0
+
+	for i := range hrList.Items {
+		hr := &hrList.Items[i]
+
+		ownerRefs := hr.GetOwnerReferences()
+		kept := ownerRefs[:0]
+
+		for _, ref := range ownerRefs {
+			if ref.UID != instance.UID {
+				kept = append(kept, ref)
+			}
+		}
+
+		if len(kept) == len(ownerRefs) {
+			continue
+		}
+
+		// the HelmReleases with the do-not-delete annotation are orphaned with any deletionPolicy
+		if instance.Spec.DeletionPolicy == appv1.DeletionPolicyOrphan ||
+			hr.GetAnnotations()[appv1.AnnotationResourceDoNotDeleteOption] == "true" {
+			hr.SetOwnerReferences(kept)
+
+			if err := r.Update(context.TODO(), hr); err != nil {
+				return 0, err
+			}
+
+			klog.Infof("orphaned HelmRelease %s/%s", hr.Namespace, hr.Name)
+
+			continue
+		}
+
+		remaining++
+
+		if hr.GetDeletionTimestamp().IsZero() {
+			if err := r.Delete(context.TODO(), hr); client.IgnoreNotFound(err) != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return remaining, nil
+}
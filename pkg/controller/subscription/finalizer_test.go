@@ -0,0 +1,138 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscription
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	releasev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func newFinalizerTestReconciler(g *gomega.WithT, objs ...client.Object) *ReconcileSubscription {
+	scheme := runtime.NewScheme()
+	g.Expect(apis.AddToScheme(scheme)).To(gomega.Succeed())
+
+	return &ReconcileSubscription{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+	}
+}
+
+func TestEnsureCleanupFinalizer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "default"},
+		Spec:       appv1.SubscriptionSpec{DeletionPolicy: appv1.DeletionPolicyDelete},
+	}
+
+	r := newFinalizerTestReconciler(g, sub.DeepCopy())
+	key := types.NamespacedName{Name: "appsub", Namespace: "default"}
+
+	instance := &appv1.Subscription{}
+	g.Expect(r.Get(context.TODO(), key, instance)).To(gomega.Succeed())
+	g.Expect(r.ensureCleanupFinalizer(instance)).To(gomega.Succeed())
+
+	g.Expect(r.Get(context.TODO(), key, instance)).To(gomega.Succeed())
+	g.Expect(instance.GetFinalizers()).To(gomega.ConsistOf(appv1.FinalizerResourceCleanup))
+
+	// the finalizer is removed with the deletionPolicy
+	instance.Spec.DeletionPolicy = ""
+	g.Expect(r.ensureCleanupFinalizer(instance)).To(gomega.Succeed())
+
+	g.Expect(r.Get(context.TODO(), key, instance)).To(gomega.Succeed())
+	g.Expect(instance.GetFinalizers()).To(gomega.BeEmpty())
+}
+
+func TestCleanupHelmReleases(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "default", UID: "appsub-uid"},
+	}
+
+	newHelmRelease := func(name string, uid types.UID) *releasev1.HelmRelease {
+		return &releasev1.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps.open-cluster-management.io/v1",
+					Kind:       "Subscription",
+					Name:       name,
+					UID:        uid,
+				}},
+			},
+		}
+	}
+
+	// the HelmReleases of the subscription are released with the Orphan deletionPolicy
+	r := newFinalizerTestReconciler(g, newHelmRelease("owned", sub.UID), newHelmRelease("other", "other-uid"))
+	sub.Spec.DeletionPolicy = appv1.DeletionPolicyOrphan
+
+	remaining, err := r.cleanupHelmReleases(sub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(remaining).To(gomega.Equal(0))
+
+	hr := &releasev1.HelmRelease{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Name: "owned", Namespace: "default"}, hr)).To(gomega.Succeed())
+	g.Expect(hr.GetOwnerReferences()).To(gomega.BeEmpty())
+
+	// the HelmReleases of the subscription are deleted with the Delete deletionPolicy, unless they are protected
+	protected := newHelmRelease("protected", sub.UID)
+	protected.SetAnnotations(map[string]string{appv1.AnnotationResourceDoNotDeleteOption: "true"})
+
+	r = newFinalizerTestReconciler(g, newHelmRelease("owned", sub.UID), newHelmRelease("other", "other-uid"), protected)
+	sub.Spec.DeletionPolicy = appv1.DeletionPolicyDelete
+
+	remaining, err = r.cleanupHelmReleases(sub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(remaining).To(gomega.Equal(1))
+
+	hrList := &releasev1.HelmReleaseList{}
+	g.Expect(r.List(context.TODO(), hrList)).To(gomega.Succeed())
+	g.Expect(hrList.Items).To(gomega.HaveLen(2))
+	g.Expect(hrList.Items[0].Name).To(gomega.Equal("other"))
+	g.Expect(hrList.Items[1].Name).To(gomega.Equal("protected"))
+	g.Expect(hrList.Items[1].GetOwnerReferences()).To(gomega.BeEmpty())
+
+	remaining, err = r.cleanupHelmReleases(sub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(remaining).To(gomega.Equal(0))
+}
+
+func TestFinalizeSubscriptionWithoutFinalizer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "default"},
+	}
+
+	r := newFinalizerTestReconciler(g)
+
+	result, err := r.finalizeSubscription(sub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.RequeueAfter).To(gomega.BeZero())
+	g.Expect(controllerutil.ContainsFinalizer(sub, appv1.FinalizerResourceCleanup)).To(gomega.BeFalse())
+}
@@ -117,8 +117,9 @@ func (r *LeaseReconciler) updateLease(ctx context.Context, namespace string, cli
 
 		return err
 	default:
-		// update lease
+		// update lease, the lease duration is also updated as the lease may be created by the addon manager
 		lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+		lease.Spec.LeaseDurationSeconds = &r.LeaseDurationSeconds
 		if _, err = client.CoordinationV1().Leases(namespace).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
 			klog.Errorf("unable to update cluster lease %q/%q . error:%v", namespace, r.LeaseName, err)
 
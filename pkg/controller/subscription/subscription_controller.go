@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	gerr "github.com/pkg/errors"
@@ -29,8 +30,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
+	klogv2 "k8s.io/klog/v2"
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
 	ghsub "open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber/git"
 	hrsub "open-cluster-management.io/multicloud-operators-subscription/pkg/subscriber/helmrepo"
@@ -38,8 +42,10 @@ import (
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -59,13 +65,6 @@ const (
 // If standalone = true, it will only reconcile standalone subscriptions without hosting subscription from ACM hub.
 // If standalone = false, it will only reconcile subscriptions that are propagated from ACM hub.
 func Add(mgr manager.Manager, hubconfig *rest.Config, syncid *types.NamespacedName, standalone bool) error {
-	hubclient, err := client.New(hubconfig, client.Options{})
-	if err != nil {
-		klog.Error("Failed to generate client to hub cluster with error:", err)
-
-		return err
-	}
-
 	subs := make(map[string]appv1.Subscriber)
 
 	subs[chnv1.ChannelTypeHelmRepo] = hrsub.GetDefaultSubscriber()
@@ -73,6 +72,19 @@ func Add(mgr manager.Manager, hubconfig *rest.Config, syncid *types.NamespacedNa
 	subs[chnv1.ChannelTypeGit] = ghsub.GetDefaultSubscriber()
 	subs[chnv1.ChannelTypeObjectBucket] = ossub.GetDefaultSubscriber()
 
+	return AddWithSubscribers(mgr, hubconfig, subs, standalone)
+}
+
+// AddWithSubscribers creates a new Subscription Controller dispatching the subscriptions to the given subscribers, per
+// channel type, and adds it to the Manager.
+func AddWithSubscribers(mgr manager.Manager, hubconfig *rest.Config, subs map[string]appv1.Subscriber, standalone bool) error {
+	hubclient, err := client.New(hubconfig, client.Options{})
+	if err != nil {
+		klog.Error("Failed to generate client to hub cluster with error:", err)
+
+		return err
+	}
+
 	return add(mgr, newReconciler(mgr, hubclient, subs, standalone), standalone)
 }
 
@@ -116,6 +128,100 @@ func (mapper *channelMapper) Map(ctx context.Context, obj *chnv1.Channel) []reco
 	return requests
 }
 
+// appsubStatusMapper maps the subscription package status to its subscription, the package status of a local
+// subscription is named after the hub subscription
+type appsubStatusMapper struct {
+	client.Client
+}
+
+func (mapper *appsubStatusMapper) Map(ctx context.Context, obj *appsubReportV1alpha1.SubscriptionStatus) []reconcile.Request {
+	var requests []reconcile.Request
+
+	for _, name := range []string{obj.GetName(), obj.GetName() + "-local"} {
+		key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}
+
+		if err := mapper.Get(ctx, key, &appv1.Subscription{}); err != nil {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: key})
+	}
+
+	return requests
+}
+
+// appsubStatusPhasePredicateFunctions only passes the package status changes of the deploy result, the package status
+// is also updated when the resources are re-applied
+var appsubStatusPhasePredicateFunctions = predicate.TypedFuncs[*appsubReportV1alpha1.SubscriptionStatus]{
+	UpdateFunc: func(e event.TypedUpdateEvent[*appsubReportV1alpha1.SubscriptionStatus]) bool {
+		return getAppsubStatusDeployResult(e.ObjectOld) != getAppsubStatusDeployResult(e.ObjectNew)
+	},
+}
+
+// getAppsubStatusDeployResult returns the overall phase and the failed packages of the package status
+func getAppsubStatusDeployResult(pkgStatus *appsubReportV1alpha1.SubscriptionStatus) string {
+	result := string(pkgStatus.Statuses.SubscriptionStatus.Phase)
+
+	for _, pkg := range pkgStatus.Statuses.SubscriptionPackageStatus {
+		if pkg.Phase == appsubReportV1alpha1.PackageDeployFailed {
+			result += fmt.Sprintf(";%v %v/%v: %v", pkg.Kind, pkg.Namespace, pkg.Name, pkg.Message)
+		}
+	}
+
+	return result
+}
+
+// packageOverridesMapper maps the ConfigMaps or Secrets of the given kind to the standalone subscriptions
+// referencing them in their package overrides.
+type packageOverridesMapper struct {
+	client.Client
+	kind string
+}
+
+func (mapper *packageOverridesMapper) Map(ctx context.Context, obj *metav1.PartialObjectMetadata) []reconcile.Request {
+	var requests []reconcile.Request
+
+	subList := &appv1.SubscriptionList{}
+
+	if err := mapper.List(context.TODO(), subList, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Error("Listing subscriptions in packageOverridesMapper and got error:", err)
+	}
+
+	for _, sub := range subList.Items {
+		if utils.IsPackageOverridesReferenced(&sub, mapper.kind, obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: sub.GetName(), Namespace: sub.GetNamespace()}})
+		}
+	}
+
+	klog.V(5).Info("Out package overrides mapper with requests:", requests)
+
+	return requests
+}
+
+// channelReferencesMapper maps the ConfigMaps or Secrets of the given kind to the standalone subscriptions whose
+// channels reference them, so the subscriber items refresh the channel credentials when they change.
+type channelReferencesMapper struct {
+	client.Client
+	kind string
+}
+
+func (mapper *channelReferencesMapper) Map(ctx context.Context, obj *metav1.PartialObjectMetadata) []reconcile.Request {
+	var requests []reconcile.Request
+
+	subs, err := utils.ListChannelReferenceSubscriptions(ctx, mapper.Client, mapper.kind, obj.GetNamespace(), obj.GetName())
+	if err != nil {
+		klog.Error("Listing channel reference subscriptions in channelReferencesMapper and got error:", err)
+	}
+
+	for _, sub := range subs {
+		requests = append(requests, reconcile.Request{NamespacedName: sub})
+	}
+
+	klog.V(5).Info("Out channel references mapper with requests:", requests)
+
+	return requests
+}
+
 // newReconciler returns a new reconcile.Reconciler.
 func newReconciler(mgr manager.Manager, hubclient client.Client, subscribers map[string]appv1.Subscriber, standalone bool) reconcile.Reconciler {
 	erecorder, _ := utils.NewEventRecorder(mgr.GetConfig(), mgr.GetScheme())
@@ -140,6 +246,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler, standalone bool) error {
 	c, err := controller.New("subscription-controller", mgr, controller.Options{
 		Reconciler:         r,
 		SkipNameValidation: &skipValidation,
+		NewQueue:           utils.NewPriorityQueue,
 	})
 
 	if err != nil {
@@ -151,7 +258,9 @@ func add(mgr manager.Manager, r reconcile.Reconciler, standalone bool) error {
 		source.Kind(
 			mgr.GetCache(),
 			&appv1.Subscription{},
-			&handler.TypedEnqueueRequestForObject[*appv1.Subscription]{},
+			utils.EnqueueRequestsWithPriority(func(_ context.Context, sub *appv1.Subscription) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: sub.Name, Namespace: sub.Namespace}}}
+			}),
 			utils.SubscriptionPredicateFunctions,
 		),
 	)
@@ -159,6 +268,20 @@ func add(mgr manager.Manager, r reconcile.Reconciler, standalone bool) error {
 		return err
 	}
 
+	// the Synced condition is derived from the deploy result in the subscription package status
+	asmapper := &appsubStatusMapper{mgr.GetClient()}
+	err = c.Watch(
+		source.Kind(
+			mgr.GetCache(),
+			&appsubReportV1alpha1.SubscriptionStatus{},
+			handler.TypedEnqueueRequestsFromMapFunc(asmapper.Map),
+			appsubStatusPhasePredicateFunctions,
+		),
+	)
+	if err != nil {
+		return err
+	}
+
 	if standalone {
 		// There is no channel CRD on a managed cluster
 		cmapper := &channelMapper{mgr.GetClient()}
@@ -174,6 +297,41 @@ func add(mgr manager.Manager, r reconcile.Reconciler, standalone bool) error {
 		if err != nil {
 			return err
 		}
+
+		// The package overrides of the subscriptions propagated from the hub are resolved on the hub
+		for _, kind := range []string{"ConfigMap", "Secret"} {
+			obj := &metav1.PartialObjectMetadata{}
+			obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(kind))
+
+			pomapper := &packageOverridesMapper{Client: mgr.GetClient(), kind: kind}
+			err = c.Watch(
+				source.Kind(
+					mgr.GetCache(),
+					obj,
+					handler.TypedEnqueueRequestsFromMapFunc(pomapper.Map),
+					predicate.TypedResourceVersionChangedPredicate[*metav1.PartialObjectMetadata]{},
+				),
+			)
+
+			if err != nil {
+				return err
+			}
+
+			// the channel secrets and configmaps, a secret recreated with the same name is seen as a create event
+			crmapper := &channelReferencesMapper{Client: mgr.GetClient(), kind: kind}
+			err = c.Watch(
+				source.Kind(
+					mgr.GetCache(),
+					obj,
+					handler.TypedEnqueueRequestsFromMapFunc(crmapper.Map),
+					predicate.TypedResourceVersionChangedPredicate[*metav1.PartialObjectMetadata]{},
+				),
+			)
+
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -195,20 +353,26 @@ type ReconcileSubscription struct {
 	clk           clock
 	eventRecorder *utils.EventRecorder
 	standalone    bool
+	// the subscriptions seen paused, to record the paused and resumed events
+	pausedSubs sync.Map
 }
 
 // Reconcile reads that state of the cluster for a Subscription object and makes changes based on the state read
 // and what is in the Subscription.Spec
 func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
-	klog.Info("Standalone/Endpoint Reconciling subscription: ", request.NamespacedName)
-	defer klog.Info("Exit Reconciling subscription: ", request.NamespacedName)
+	klogv2.InfoS("Standalone/Endpoint Reconciling subscription", "subscription", request.NamespacedName)
+	defer klogv2.InfoS("Exit Reconciling subscription", "subscription", request.NamespacedName)
 
 	instance := &appv1.Subscription{}
 	err := r.Get(context.TODO(), request.NamespacedName, instance)
 
 	if err != nil {
 		if errors.IsNotFound(err) {
-			klog.Info("Subscription: ", request.NamespacedName, " is gone")
+			klogv2.InfoS("Subscription is gone", "subscription", request.NamespacedName)
+
+			r.pausedSubs.Delete(request.NamespacedName)
+
+			metrics.DeleteTimeWindowMetrics(request.Namespace, request.Name)
 
 			// Object not found, delete existing subscriberitem if any
 			for _, sub := range r.subscribers {
@@ -221,14 +385,14 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 			err := r.DeleteReferredObjects(request.NamespacedName, objKind)
 
 			if err != nil {
-				klog.Errorf("Had error %v while processing the referred secert", err)
+				klogv2.ErrorS(err, "Failed to delete the referred secrets", "subscription", request.NamespacedName)
 			}
 
 			objKind = schema.GroupVersionKind{Group: "", Kind: ConfigMapKindStr, Version: "v1"}
 			err = r.DeleteReferredObjects(request.NamespacedName, objKind)
 
 			if err != nil {
-				klog.Errorf("Had error %v while processing the referred secert", err)
+				klogv2.ErrorS(err, "Failed to delete the referred configmaps", "subscription", request.NamespacedName)
 			}
 
 			return reconcile.Result{}, err
@@ -237,6 +401,14 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 		return reconcile.Result{}, err
 	}
 
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return r.finalizeSubscription(instance)
+	}
+
+	logger := logging.ForSubscription(instance)
+
+	r.recordPauseEvents(request.NamespacedName, instance)
+
 	annotations := instance.GetAnnotations()
 	pl := instance.Spec.Placement
 
@@ -245,6 +417,16 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 		// If standalone = false, reconcile subscriptions that are propagated from ACM hub. These subscriptions have this annotation.
 		if (strings.EqualFold(annotations[appv1.AnnotationHosting], "") && r.standalone) ||
 			(!strings.EqualFold(annotations[appv1.AnnotationHosting], "") && !r.standalone) {
+			if r.standalone && utils.IsSubscriptionExpired(instance, r.clk()) {
+				return reconcile.Result{}, r.deleteExpiredSubscription(instance)
+			}
+
+			if err := r.ensureCleanupFinalizer(instance); err != nil {
+				logger.Error(err, "failed to update the finalizers of subscription")
+
+				return reconcile.Result{}, err
+			}
+
 			reconcileErr := r.doReconcile(instance)
 
 			// doReconcile updates the subscription. Later this function fails to update the subscription status
@@ -257,12 +439,12 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 
 			if reconcileErr != nil {
 				instance.Status.Phase = appv1.SubscriptionFailed
-				instance.Status.Reason = reconcileErr.Error()
+				instance.Status.Reason = utils.RedactError(reconcileErr)
 
 				var emptyStatuses = make(appv1.SubscriptionClusterStatusMap)
 				instance.Status.Statuses = emptyStatuses
 
-				klog.Errorf("doReconcile got error %v", reconcileErr)
+				logger.Error(reconcileErr, "doReconcile got error")
 
 				// if there is appsub reconcile error on the managed cluster such as channel error, one git_failed_pull_time_count is collected
 				metrics.GitFailedPullTime.
@@ -278,13 +460,17 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 
 			// if the subscription pause lable is true, stop updating subscription status.
 			if utils.GetPauseLabel(instance) {
-				klog.Info("updating subscription status: ", request.NamespacedName, " is paused")
+				logger.Info("updating subscription status is paused")
 
 				return reconcile.Result{}, nil
 			}
 
 			instance.Status.LastUpdateTime = metav1.Now()
 
+			if servingChannel := utils.GetServingChannel(request.NamespacedName); servingChannel != "" {
+				instance.Status.ServingChannel = servingChannel
+			}
+
 			// calculate the requeue time for updating the timewindow status
 			nextStatusUpateAt := time.Duration(0)
 
@@ -294,24 +480,36 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 				if utils.IsInWindow(instance.Spec.TimeWindow, r.clk()) {
 					instance.Status.Message = subscriptionActive
 				} else {
+					if instance.Status.Message != subscriptionBlock {
+						r.recordEvent(instance, utils.EventReasonTimeWindowBlocked, "Deployment is blocked by the subscription time window", nil)
+					}
+
 					instance.Status.Message = subscriptionBlock
 				}
 
 				nextStatusUpateAt = utils.NextStatusReconcile(instance.Spec.TimeWindow, r.clk())
 
-				klog.Infof("Next time window status reconciliation will occur in %v", nextStatusUpateAt.String())
+				logger.Info("Next time window status reconciliation", "after", nextStatusUpateAt.String())
 			}
 
+			setManagedConditions(instance, r.getAppsubStatus(request.Namespace, appsubStatusName), r.clk())
+
 			err = r.Status().Update(context.TODO(), instance)
 
 			result := reconcile.Result{RequeueAfter: nextStatusUpateAt}
 
+			// requeue the standalone subscription to delete it once it expires
+			if next := utils.TimeUntilExpiry(instance, r.clk()); r.standalone && next > 0 &&
+				(result.RequeueAfter == 0 || next < result.RequeueAfter) {
+				result.RequeueAfter = next
+			}
+
 			if err != nil {
-				klog.Errorf("failed to update status for subscription %v with error %v, retry after 1 second", request.NamespacedName, err)
+				logger.Error(err, "failed to update status for subscription, retry after 1 second")
 
 				result.RequeueAfter = 1 * time.Second
 			} else if reconcileErr != nil {
-				klog.Errorf("do Reconcile got error %v, retry after 5 minutes", reconcileErr)
+				logger.Error(reconcileErr, "do Reconcile got error, retry after 5 minutes")
 
 				result.RequeueAfter = 5 * time.Minute
 			}
@@ -319,11 +517,11 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 			return result, err
 		}
 	} else {
-		klog.Infof("Subscription %v is no longer local subscription. Remove subscription packages.", request.NamespacedName)
+		logger.Info("Subscription is no longer local subscription. Remove subscription packages.")
 		// no longer local
 		// if the subscription pause lable is true, stop unsubscription here.
 		if utils.GetPauseLabel(instance) {
-			klog.Info("unsubscribing: ", request.NamespacedName, " is paused")
+			logger.Info("unsubscribing is paused")
 
 			return reconcile.Result{}, nil
 		}
@@ -336,9 +534,141 @@ func (r *ReconcileSubscription) Reconcile(ctx context.Context, request reconcile
 	return reconcile.Result{}, nil
 }
 
+// recordPauseEvents records an event when the subscription is paused or resumed with the pause label
+func (r *ReconcileSubscription) recordPauseEvents(key types.NamespacedName, instance *appv1.Subscription) {
+	_, wasPaused := r.pausedSubs.Load(key)
+
+	if utils.GetPauseLabel(instance) {
+		if !wasPaused {
+			r.pausedSubs.Store(key, struct{}{})
+			r.recordEvent(instance, utils.EventReasonPaused, "Subscription is paused", nil)
+		}
+
+		return
+	}
+
+	if wasPaused {
+		r.pausedSubs.Delete(key)
+		r.recordEvent(instance, utils.EventReasonResumed, "Subscription is resumed", nil)
+	}
+}
+
+func (r *ReconcileSubscription) recordEvent(instance *appv1.Subscription, reason, msg string, err error) {
+	if r.eventRecorder == nil {
+		return
+	}
+
+	r.eventRecorder.RecordEvent(instance, reason, msg, err)
+}
+
+// checkChannelReferencesAccess checks the creator of a subscription created on this cluster can get the channel
+// secrets and configmaps referenced in another namespace. The access of the subscriptions propagated from the hub is
+// checked on the hub.
+func (r *ReconcileSubscription) checkChannelReferencesAccess(instance *appv1.Subscription, channels ...*chnv1.Channel) error {
+	if instance.GetAnnotations()[appv1.AnnotationHosting] != "" {
+		return nil
+	}
+
+	for _, chn := range channels {
+		if chn == nil {
+			continue
+		}
+
+		if err := utils.CheckChannelReferencesAccess(context.TODO(), r.hubclient, instance, chn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getFallbackChannels gets the fallback channels of the subscription from the hub with their referenced secrets and
+// configmaps, and deploys the references on the managed cluster
+func (r *ReconcileSubscription) getFallbackChannels(instance *appv1.Subscription) ([]appv1.SubscriberChannel, error) {
+	fallbacks := []appv1.SubscriberChannel{}
+
+	for _, chnName := range instance.Spec.FallbackChannels {
+		fallback := appv1.SubscriberChannel{Channel: &chnv1.Channel{}}
+
+		if err := r.hubclient.Get(context.TODO(), utils.NamespacedNameFormat(chnName), fallback.Channel); err != nil {
+			return nil, gerr.Wrapf(err, "failed to get the fallback channel %v", chnName)
+		}
+
+		if utils.HasChannelSecret(fallback.Channel) {
+			if err := r.checkChannelReferencesAccess(instance, fallback.Channel); err != nil {
+				return nil, err
+			}
+
+			secret, err := utils.ResolveChannelSecret(r.hubclient, fallback.Channel)
+			if err != nil {
+				return nil, gerr.Wrapf(err, "failed to get reference secret from the fallback channel %v", chnName)
+			}
+
+			fallback.Secret = secret
+
+			gvk := schema.GroupVersionKind{Group: "", Kind: SecretKindStr, Version: "v1"}
+
+			if err := r.ListAndDeployReferredObject(instance, gvk, fallback.Secret); err != nil {
+				return nil, gerr.Wrapf(err, "Can't deploy reference secret %v for subscription %v", fallback.Secret.GetName(), instance.GetName())
+			}
+		}
+
+		if fallback.Channel.Spec.ConfigMapRef != nil {
+			fallback.ConfigMap = &corev1.ConfigMap{}
+			cfgkey := utils.GetChannelConfigMapKey(fallback.Channel)
+
+			if err := r.hubclient.Get(context.TODO(), cfgkey, fallback.ConfigMap); err != nil {
+				return nil, gerr.Wrapf(err, "failed to get reference configmap from the fallback channel %v", chnName)
+			}
+
+			gvk := schema.GroupVersionKind{Group: "", Kind: ConfigMapKindStr, Version: "v1"}
+
+			if err := r.ListAndDeployReferredObject(instance, gvk, fallback.ConfigMap); err != nil {
+				return nil, gerr.Wrapf(err, "can't deploy reference configmap %v for subscription %v", fallback.ConfigMap.GetName(), instance.GetName())
+			}
+		}
+
+		fallbacks = append(fallbacks, fallback)
+	}
+
+	return fallbacks, nil
+}
+
+// getAppsubStatus returns the subscription package status with the deploy result of the subscription resources, nil
+// if it is not created yet
+func (r *ReconcileSubscription) getAppsubStatus(namespace, name string) *appsubReportV1alpha1.SubscriptionStatus {
+	pkgStatus := &appsubReportV1alpha1.SubscriptionStatus{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, pkgStatus); err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Errorf("failed to get the package status %v/%v, err: %v", namespace, name, err)
+		}
+
+		return nil
+	}
+
+	return pkgStatus
+}
+
+// setManagedConditions sets the observed generation and the Synced, Blocked, Ready and Expiring conditions of the
+// managed cluster subscription. The Synced condition is derived from the deploy result in the package status.
+func setManagedConditions(instance *appv1.Subscription, pkgStatus *appsubReportV1alpha1.SubscriptionStatus, now time.Time) {
+	instance.Status.ObservedGeneration = instance.GetGeneration()
+
+	utils.SetSubscriptionSyncedCondition(instance, pkgStatus)
+
+	utils.SetSubscriptionBlockedCondition(instance, instance.Spec.TimeWindow, now)
+	utils.SetSubscriptionReadyCondition(instance, appv1.SubscriptionConditionSynced)
+	utils.SetSubscriptionExpiringCondition(instance)
+
+	metrics.SetTimeWindowStatus(instance.GetNamespace(), instance.GetName(), utils.NextStartPoint(instance.Spec.TimeWindow, now), now)
+}
+
 func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error {
 	var err error
 
+	logger := logging.ForSubscription(instance)
+
 	subitem := &appv1.SubscriberItem{}
 	subitem.Subscription = instance
 
@@ -370,36 +700,27 @@ func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error
 		}
 	}
 
-	if subitem.Channel.Spec.SecretRef != nil {
-		subitem.ChannelSecret = &corev1.Secret{}
-		chnseckey := types.NamespacedName{
-			Name:      subitem.Channel.Spec.SecretRef.Name,
-			Namespace: subitem.Channel.Namespace,
-		}
+	if err := r.checkChannelReferencesAccess(instance, subitem.Channel, subitem.SecondaryChannel); err != nil {
+		return err
+	}
 
-		if err := r.hubclient.Get(context.TODO(), chnseckey, subitem.ChannelSecret); err != nil {
+	if utils.HasChannelSecret(subitem.Channel) {
+		subitem.ChannelSecret, err = utils.ResolveChannelSecret(r.hubclient, subitem.Channel)
+		if err != nil {
 			return gerr.Wrap(err, "failed to get reference secret from channel")
 		}
 	}
 
-	if subitem.SecondaryChannel != nil && subitem.SecondaryChannel.Spec.SecretRef != nil {
-		subitem.SecondaryChannelSecret = &corev1.Secret{}
-		scndChnSecKey := types.NamespacedName{
-			Name:      subitem.SecondaryChannel.Spec.SecretRef.Name,
-			Namespace: subitem.SecondaryChannel.Namespace,
-		}
-
-		if err := r.hubclient.Get(context.TODO(), scndChnSecKey, subitem.SecondaryChannelSecret); err != nil {
+	if subitem.SecondaryChannel != nil && utils.HasChannelSecret(subitem.SecondaryChannel) {
+		subitem.SecondaryChannelSecret, err = utils.ResolveChannelSecret(r.hubclient, subitem.SecondaryChannel)
+		if err != nil {
 			return gerr.Wrap(err, "failed to get reference secret from the secondary channel")
 		}
 	}
 
 	if subitem.Channel != nil && subitem.Channel.Spec.ConfigMapRef != nil {
 		subitem.ChannelConfigMap = &corev1.ConfigMap{}
-		chncfgkey := types.NamespacedName{
-			Name:      subitem.Channel.Spec.ConfigMapRef.Name,
-			Namespace: subitem.Channel.Namespace,
-		}
+		chncfgkey := utils.GetChannelConfigMapKey(subitem.Channel)
 
 		if err := r.hubclient.Get(context.TODO(), chncfgkey, subitem.ChannelConfigMap); err != nil {
 			return gerr.Wrap(err, "failed to get reference configmap from channel")
@@ -408,17 +729,14 @@ func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error
 
 	if subitem.SecondaryChannel != nil && subitem.SecondaryChannel.Spec.ConfigMapRef != nil {
 		subitem.SecondaryChannelConfigMap = &corev1.ConfigMap{}
-		scndChnCfgKey := types.NamespacedName{
-			Name:      subitem.SecondaryChannel.Spec.ConfigMapRef.Name,
-			Namespace: subitem.SecondaryChannel.Namespace,
-		}
+		scndChnCfgKey := utils.GetChannelConfigMapKey(subitem.SecondaryChannel)
 
 		if err := r.hubclient.Get(context.TODO(), scndChnCfgKey, subitem.SecondaryChannelConfigMap); err != nil {
 			return gerr.Wrap(err, "failed to get reference configmap from the secondary channel")
 		}
 	}
 
-	if subitem.Channel.Spec.SecretRef != nil {
+	if subitem.ChannelSecret != nil {
 		obj := subitem.ChannelSecret
 
 		gvk := schema.GroupVersionKind{Group: "", Kind: SecretKindStr, Version: "v1"}
@@ -428,7 +746,7 @@ func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error
 		}
 	}
 
-	if subitem.SecondaryChannel != nil && subitem.SecondaryChannel.Spec.SecretRef != nil {
+	if subitem.SecondaryChannelSecret != nil {
 		obj := subitem.SecondaryChannelSecret
 
 		gvk := schema.GroupVersionKind{Group: "", Kind: SecretKindStr, Version: "v1"}
@@ -458,6 +776,11 @@ func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error
 		}
 	}
 
+	subitem.FallbackChannels, err = r.getFallbackChannels(instance)
+	if err != nil {
+		return err
+	}
+
 	if instance.Spec.PackageFilter != nil && instance.Spec.PackageFilter.FilterRef != nil {
 		subitem.SubscriptionConfigMap = &corev1.ConfigMap{}
 		subcfgkeyL := types.NamespacedName{
@@ -480,6 +803,17 @@ func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error
 		}
 	}
 
+	// the package overrides of the standalone subscriptions reference the ConfigMaps and Secrets of the managed cluster,
+	// the package overrides Secrets of the hub subscriptions are propagated to the managed cluster with the subscription
+	if utils.HasPackageOverridesReference(instance) {
+		overrides, err := utils.ResolvePackageOverrides(r.Client, instance)
+		if err != nil {
+			return gerr.Wrap(err, "failed to resolve the package overrides")
+		}
+
+		instance.Spec.PackageOverrides = overrides
+	}
+
 	subtype := strings.ToLower(string(subitem.Channel.Spec.Type))
 
 	if strings.EqualFold(subtype, chnv1.ChannelTypeGit) || strings.EqualFold(subtype, chnv1.ChannelTypeGitHub) ||
@@ -487,15 +821,33 @@ func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error
 		annotations := instance.GetAnnotations()
 
 		if utils.IsClusterAdmin(r.hubclient, instance, r.eventRecorder) {
-			klog.Info("ADDING apps.open-cluster-management.io/cluster-admin: true")
+			logger.Info("ADDING apps.open-cluster-management.io/cluster-admin: true")
 
 			annotations[appv1.AnnotationClusterAdmin] = "true"
 			subitem.Subscription.SetAnnotations(annotations)
 		} else {
-			klog.Info("REMOVING apps.open-cluster-management.io/cluster-admin annotation")
+			logger.Info("REMOVING apps.open-cluster-management.io/cluster-admin annotation")
 			delete(annotations, appv1.AnnotationClusterAdmin)
 			subitem.Subscription.SetAnnotations(annotations)
 		}
+
+		// the target namespaces of the standalone subscriptions are validated on the managed cluster, the propagated
+		// subscriptions inherit the target namespaces validated on the hub. The standalone subscriptions have no signed
+		// creator identity, all their target namespaces are denied.
+		if annotations[appv1.AnnotationHosting] == "" {
+			delete(annotations, appv1.AnnotationTargetNamespaces)
+
+			allowed, err := utils.ValidateTargetNamespaces(context.TODO(), r.hubclient, instance)
+			if err != nil {
+				logger.Error(err, "Failed to validate the target namespaces")
+			}
+
+			if len(allowed) > 0 {
+				annotations[appv1.AnnotationTargetNamespaces] = strings.Join(allowed, ",")
+			}
+
+			subitem.Subscription.SetAnnotations(annotations)
+		}
 	}
 
 	// subscribe it with right channel type and unsubscribe from other channel types (in case user modify channel type)
@@ -510,23 +862,23 @@ func (r *ReconcileSubscription) doReconcile(instance *appv1.Subscription) error
 		}
 
 		if k != subtype {
-			klog.V(1).Infof("k: %v, sub: %v, subtype:%v,  unsubscribe %v/%v", k, sub, subtype, subitem.Subscription.Namespace, subitem.Subscription.Name)
+			logger.V(1).Info("unsubscribe from the subscriber of another channel type", "subscriber", k, "channelType", subtype)
 
 			// if the subscription pause lable is true, stop unsubscription here.
 			if utils.GetPauseLabel(subitem.Subscription) {
-				klog.Infof("unsubscription: %v/%v is paused.", subitem.Subscription.Namespace, subitem.Subscription.Name)
+				logger.Info("unsubscription is paused")
 				continue
 			}
 
 			if err := sub.UnsubscribeItem(types.NamespacedName{Name: subitem.Subscription.Name, Namespace: subitem.Subscription.Namespace}); err != nil {
-				klog.Errorf("failed to unsubscribe with subscriber %v error %+v", k, err)
+				logger.Error(err, "failed to unsubscribe", "subscriber", k)
 			}
 		}
 	}
 
 	if sub, ok := r.subscribers[subtype]; ok {
 		if err := sub.SubscribeItem(subitem); err != nil {
-			klog.Errorf("failed to subscribe with subscriber %v, error %+v", subtype, err)
+			logger.Error(err, "failed to subscribe", "subscriber", subtype)
 			return err
 		}
 	}
@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -31,6 +32,7 @@ import (
 
 	plv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
@@ -406,3 +408,32 @@ func TestReconcileWithTimeWindowStatusFlow(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordPauseEvents(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	recorder := record.NewFakeRecorder(10)
+	rec := &ReconcileSubscription{eventRecorder: &utils.EventRecorder{EventRecorder: recorder}}
+
+	key := types.NamespacedName{Name: "paused-sub", Namespace: "default"}
+	sub := &appv1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    map[string]string{appv1alpha1.LabelSubscriptionPause: "true"},
+		},
+	}
+
+	// the paused event is recorded once
+	rec.recordPauseEvents(key, sub)
+	rec.recordPauseEvents(key, sub)
+	g.Expect(recorder.Events).To(gomega.HaveLen(1))
+	g.Expect(<-recorder.Events).To(gomega.ContainSubstring(utils.EventReasonPaused))
+
+	sub.SetLabels(nil)
+
+	rec.recordPauseEvents(key, sub)
+	rec.recordPauseEvents(key, sub)
+	g.Expect(recorder.Events).To(gomega.HaveLen(1))
+	g.Expect(<-recorder.Events).To(gomega.ContainSubstring(utils.EventReasonResumed))
+}
@@ -0,0 +1,372 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriptionset
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+const (
+	paramBranch       = "branch"
+	paramBranchSlug   = "branch_slug"
+	paramNumber       = "number"
+	paramSha          = "sha"
+	paramShortSha     = "short_sha"
+	paramCluster      = "cluster"
+	paramLabelsPrefix = "labels."
+	paramValuesPrefix = "values."
+	paramPath         = "path"
+	paramPathBasename = "path_basename"
+	paramPathSlug     = "path_slug"
+	paramElementName  = "name"
+
+	// the branch slug is truncated to leave room for a prefix in the namespace names
+	maxBranchSlugLength = 40
+	shortShaLength      = 7
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// paramSet is a parameter set of a generator, with the defaults of the subscription generated with it
+type paramSet struct {
+	// params are the parameters replaced in the subscription template
+	params map[string]string
+	// slug is a DNS label identifying the parameter set, the default namespace is <set name>-<slug>
+	slug string
+	// generated identifies the parameter set in the status of the subscription set, like its branch or its cluster
+	generated appv1alpha1.GeneratedSubscription
+	// defaultAnnotations are added to the generated subscription unless the template sets them
+	defaultAnnotations map[string]string
+	// annotations are added to the generated subscription
+	annotations map[string]string
+}
+
+// String describes the parameter set in the errors and the logs
+func (p paramSet) String() string {
+	switch {
+	case p.generated.Branch != "":
+		return "branch " + p.generated.Branch
+	case p.generated.Path != "":
+		return "path " + p.generated.Path
+	case p.generated.Cluster != "":
+		return "cluster " + p.generated.Cluster
+	default:
+		return "element " + p.generated.Element
+	}
+}
+
+// branchSlug converts a branch name to a DNS label, for example feature/New_UI to feature-new-ui
+func branchSlug(branch string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(branch), "-")
+
+	if len(slug) > maxBranchSlugLength {
+		slug = slug[:maxBranchSlugLength]
+	}
+
+	return strings.Trim(slug, "-")
+}
+
+func shortSha(sha string) string {
+	if len(sha) > shortShaLength {
+		return sha[:shortShaLength]
+	}
+
+	return sha
+}
+
+func newParams(branch, slug, number, sha string) map[string]string {
+	return map[string]string{
+		paramBranch:     branch,
+		paramBranchSlug: slug,
+		paramNumber:     number,
+		paramSha:        sha,
+		paramShortSha:   shortSha(sha),
+	}
+}
+
+// newBranchParamSet returns the parameter set of a branch or a pull request, the generated subscription subscribes
+// to the branch unless the template sets the git-branch annotation
+func newBranchParamSet(branch, slug, number, sha string) paramSet {
+	return paramSet{
+		params:             newParams(branch, slug, number, sha),
+		slug:               slug,
+		generated:          appv1alpha1.GeneratedSubscription{Branch: branch, Commit: sha},
+		defaultAnnotations: map[string]string{appv1.AnnotationGitBranch: branch},
+		annotations:        map[string]string{appv1alpha1.AnnotationSubscriptionSetBranch: branch},
+	}
+}
+
+// gitBranchesParams returns a parameter set per branch matching the branch pattern of the generator, and per pull
+// request if the generator includes them. The parameter sets are sorted by branch.
+func gitBranchesParams(refs []*plumbing.Reference, gen *appv1alpha1.GitBranchesGenerator) ([]paramSet, error) {
+	if _, err := path.Match(gen.BranchPattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid branchPattern %q: %w", gen.BranchPattern, err)
+	}
+
+	paramSets := []paramSet{}
+
+	for _, ref := range refs {
+		if ref.Type() != plumbing.HashReference {
+			continue
+		}
+
+		name := ref.Name()
+
+		switch {
+		case name.IsBranch():
+			branch := name.Short()
+
+			if gen.BranchPattern != "" {
+				if matched, _ := path.Match(gen.BranchPattern, branch); !matched {
+					continue
+				}
+			}
+
+			paramSets = append(paramSets, newBranchParamSet(branch, branchSlug(branch), "", ref.Hash().String()))
+		case gen.PullRequests && utils.IsPullRequestRef(name):
+			number := utils.GetPullRequestNumber(name)
+
+			paramSets = append(paramSets, newBranchParamSet(name.String(), "pr-"+number, number, ref.Hash().String()))
+		}
+	}
+
+	sort.Slice(paramSets, func(i, j int) bool { return paramSets[i].generated.Branch < paramSets[j].generated.Branch })
+
+	return paramSets, nil
+}
+
+// listParams returns a parameter set per element of the list generator. An element is identified by its name
+// parameter, or by its index if it has no name.
+func listParams(gen *appv1alpha1.ListGenerator) ([]paramSet, error) {
+	paramSets := []paramSet{}
+
+	for i, element := range gen.Elements {
+		id := element[paramElementName]
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+
+		slug := branchSlug(id)
+		if slug == "" {
+			return nil, fmt.Errorf("element %d: invalid name %q", i, id)
+		}
+
+		params := map[string]string{}
+		for k, v := range element {
+			params[k] = v
+		}
+
+		paramSets = append(paramSets, paramSet{
+			params:    params,
+			slug:      slug,
+			generated: appv1alpha1.GeneratedSubscription{Element: id},
+		})
+	}
+
+	return paramSets, nil
+}
+
+// clustersParams returns a parameter set per managed cluster, with the cluster name, its labels and the values of
+// the generator. The parameter sets are sorted by cluster.
+func clustersParams(clusters []spokeClusterV1.ManagedCluster, gen *appv1alpha1.ClustersGenerator) []paramSet {
+	paramSets := []paramSet{}
+
+	for _, cluster := range clusters {
+		if !cluster.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		params := map[string]string{paramCluster: cluster.Name}
+
+		for k, v := range cluster.Labels {
+			params[paramLabelsPrefix+k] = v
+		}
+
+		for k, v := range gen.Values {
+			params[paramValuesPrefix+k] = v
+		}
+
+		paramSets = append(paramSets, paramSet{
+			params:    params,
+			slug:      branchSlug(cluster.Name),
+			generated: appv1alpha1.GeneratedSubscription{Cluster: cluster.Name},
+		})
+	}
+
+	sort.Slice(paramSets, func(i, j int) bool { return paramSets[i].generated.Cluster < paramSets[j].generated.Cluster })
+
+	return paramSets
+}
+
+// gitDirectoriesParams returns a parameter set per directory matching an included pattern of the generator and no
+// excluded pattern. The generated subscription subscribes to the directory and to the revision of the generator
+// unless the template sets the git-path and git-branch annotations. The parameter sets are sorted by path.
+func gitDirectoriesParams(dirs []string, commit string, gen *appv1alpha1.GitDirectoriesGenerator) ([]paramSet, error) {
+	for _, dir := range gen.Directories {
+		if _, err := path.Match(dir.Path, ""); err != nil {
+			return nil, fmt.Errorf("invalid directory path %q: %w", dir.Path, err)
+		}
+	}
+
+	paramSets := []paramSet{}
+
+	for _, dir := range dirs {
+		included := false
+
+		for _, pattern := range gen.Directories {
+			if matched, _ := path.Match(strings.Trim(pattern.Path, "/"), dir); !matched {
+				continue
+			}
+
+			if pattern.Exclude {
+				included = false
+
+				break
+			}
+
+			included = true
+		}
+
+		if !included {
+			continue
+		}
+
+		params := map[string]string{
+			paramPath:         dir,
+			paramPathBasename: path.Base(dir),
+			paramPathSlug:     branchSlug(dir),
+			paramSha:          commit,
+			paramShortSha:     shortSha(commit),
+		}
+
+		defaults := map[string]string{appv1.AnnotationGitPath: dir}
+		if gen.Revision != "" {
+			defaults[appv1.AnnotationGitBranch] = gen.Revision
+		}
+
+		paramSets = append(paramSets, paramSet{
+			params:             params,
+			slug:               params[paramPathSlug],
+			generated:          appv1alpha1.GeneratedSubscription{Path: dir, Commit: commit},
+			defaultAnnotations: defaults,
+		})
+	}
+
+	return paramSets, nil
+}
+
+// renderSubscription renders the subscription template of the subscription set with a parameter set, and adds the
+// default annotations of the parameter set that the template doesn't set, like the git-branch of a branch.
+func renderSubscription(set *appv1alpha1.SubscriptionSet, ps paramSet) (*appv1.Subscription, error) {
+	tpl := set.Spec.Template.DeepCopy()
+
+	if tpl.Metadata.Name == "" {
+		tpl.Metadata.Name = set.Name
+	}
+
+	raw, err := json.Marshal(tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	renderedRaw := string(raw)
+
+	for key, value := range ps.params {
+		// the values are escaped as JSON strings
+		escaped, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		renderedRaw = strings.ReplaceAll(renderedRaw, "{{"+key+"}}", string(escaped[1:len(escaped)-1]))
+	}
+
+	tpl = &appv1alpha1.SubscriptionTemplate{}
+
+	if err := json.Unmarshal([]byte(renderedRaw), tpl); err != nil {
+		return nil, fmt.Errorf("failed to render the subscription template: %w", err)
+	}
+
+	if tpl.Metadata.Namespace == "" {
+		tpl.Metadata.Namespace = set.Name + "-" + ps.slug
+	}
+
+	if tpl.Metadata.Annotations == nil {
+		tpl.Metadata.Annotations = map[string]string{}
+	}
+
+	for k, v := range ps.defaultAnnotations {
+		if !hasTemplateAnnotation(tpl.Metadata.Annotations, k) {
+			tpl.Metadata.Annotations[k] = v
+		}
+	}
+
+	for k, v := range ps.annotations {
+		tpl.Metadata.Annotations[k] = v
+	}
+
+	if errs := validation.IsDNS1123Label(tpl.Metadata.Namespace); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid namespace %q: %v", tpl.Metadata.Namespace, strings.Join(errs, ", "))
+	}
+
+	if errs := validation.IsDNS1123Subdomain(tpl.Metadata.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid name %q: %v", tpl.Metadata.Name, strings.Join(errs, ", "))
+	}
+
+	labels := tpl.Metadata.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels[appv1alpha1.LabelSubscriptionSet] = set.Name
+	labels[appv1alpha1.LabelSubscriptionSetNamespace] = set.Namespace
+
+	return &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        tpl.Metadata.Name,
+			Namespace:   tpl.Metadata.Namespace,
+			Labels:      labels,
+			Annotations: tpl.Metadata.Annotations,
+		},
+		Spec: tpl.Spec,
+	}, nil
+}
+
+// hasTemplateAnnotation returns true if the template sets the annotation, or its deprecated github annotation
+func hasTemplateAnnotation(annotations map[string]string, key string) bool {
+	switch key {
+	case appv1.AnnotationGitBranch:
+		return utils.GetGitBranchAnnotation(annotations) != ""
+	case appv1.AnnotationGitPath:
+		return utils.GetGitPathAnnotation(annotations) != ""
+	default:
+		return annotations[key] != ""
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriptionset
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	testSha1 = "1111111111111111111111111111111111111111"
+	testSha2 = "2222222222222222222222222222222222222222"
+)
+
+func newTestRefs() []*plumbing.Reference {
+	return []*plumbing.Reference{
+		plumbing.NewSymbolicReference(plumbing.HEAD, "refs/heads/main"),
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash(testSha1)),
+		plumbing.NewHashReference("refs/heads/feature/New_UI", plumbing.NewHash(testSha2)),
+		plumbing.NewHashReference("refs/heads/fix/login", plumbing.NewHash(testSha2)),
+		plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash(testSha1)),
+		plumbing.NewHashReference("refs/pull/12/head", plumbing.NewHash(testSha2)),
+		plumbing.NewHashReference("refs/pull/12/merge", plumbing.NewHash(testSha1)),
+	}
+}
+
+func TestBranchSlug(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(branchSlug("main")).To(gomega.Equal("main"))
+	g.Expect(branchSlug("feature/New_UI")).To(gomega.Equal("feature-new-ui"))
+	g.Expect(branchSlug("-fix--login.")).To(gomega.Equal("fix-login"))
+	g.Expect(branchSlug("feature/a-very-long-branch-name-that-goes-on-and-on")).To(
+		gomega.Equal("feature-a-very-long-branch-name-that-goe"))
+}
+
+func TestGitBranchesParams(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// all the branches match without a pattern
+	params, err := gitBranchesParams(newTestRefs(), &appv1alpha1.GitBranchesGenerator{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(params).To(gomega.HaveLen(3))
+	g.Expect(params[0].params).To(gomega.Equal(map[string]string{
+		paramBranch:     "feature/New_UI",
+		paramBranchSlug: "feature-new-ui",
+		paramNumber:     "",
+		paramSha:        testSha2,
+		paramShortSha:   "2222222",
+	}))
+	g.Expect(params[1].params[paramBranch]).To(gomega.Equal("fix/login"))
+	g.Expect(params[2].params[paramBranch]).To(gomega.Equal("main"))
+
+	// the pattern is matched against the branch names, the pull requests are not filtered
+	params, err = gitBranchesParams(newTestRefs(), &appv1alpha1.GitBranchesGenerator{
+		BranchPattern: "feature/*",
+		PullRequests:  true,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(params).To(gomega.HaveLen(2))
+	g.Expect(params[0].params[paramBranch]).To(gomega.Equal("feature/New_UI"))
+	g.Expect(params[1].params).To(gomega.Equal(map[string]string{
+		paramBranch:     "refs/pull/12/head",
+		paramBranchSlug: "pr-12",
+		paramNumber:     "12",
+		paramSha:        testSha2,
+		paramShortSha:   "2222222",
+	}))
+
+	g.Expect(params[1].slug).To(gomega.Equal("pr-12"))
+	g.Expect(params[1].generated).To(gomega.Equal(appv1alpha1.GeneratedSubscription{Branch: "refs/pull/12/head", Commit: testSha2}))
+
+	_, err = gitBranchesParams(newTestRefs(), &appv1alpha1.GitBranchesGenerator{BranchPattern: "feature/["})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestListParams(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	params, err := listParams(&appv1alpha1.ListGenerator{Elements: []map[string]string{
+		{"name": "EU West", "region": "eu-west-1"},
+		{"region": "us-east-1"},
+	}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(params).To(gomega.HaveLen(2))
+	g.Expect(params[0].params).To(gomega.Equal(map[string]string{"name": "EU West", "region": "eu-west-1"}))
+	g.Expect(params[0].slug).To(gomega.Equal("eu-west"))
+	g.Expect(params[0].generated).To(gomega.Equal(appv1alpha1.GeneratedSubscription{Element: "EU West"}))
+
+	// an element without a name is identified by its index
+	g.Expect(params[1].slug).To(gomega.Equal("1"))
+	g.Expect(params[1].generated.Element).To(gomega.Equal("1"))
+
+	_, err = listParams(&appv1alpha1.ListGenerator{Elements: []map[string]string{{"name": "--"}}})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestClustersParams(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := metav1.Now()
+	clusters := []spokeClusterV1.ManagedCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster2", Labels: map[string]string{"env": "prod"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Labels: map[string]string{"env": "dev"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster3", DeletionTimestamp: &now}},
+	}
+
+	params := clustersParams(clusters, &appv1alpha1.ClustersGenerator{Values: map[string]string{"replicas": "2"}})
+	g.Expect(params).To(gomega.HaveLen(2))
+	g.Expect(params[0].params).To(gomega.Equal(map[string]string{
+		paramCluster:                   "cluster1",
+		paramLabelsPrefix + "env":      "dev",
+		paramValuesPrefix + "replicas": "2",
+	}))
+	g.Expect(params[0].slug).To(gomega.Equal("cluster1"))
+	g.Expect(params[1].generated).To(gomega.Equal(appv1alpha1.GeneratedSubscription{Cluster: "cluster2"}))
+}
+
+func TestGitDirectoriesParams(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dirs := []string{"apps", "apps/backend", "apps/frontend", "apps/legacy", "docs"}
+	gen := &appv1alpha1.GitDirectoriesGenerator{
+		Revision: "main",
+		Directories: []appv1alpha1.GitDirectory{
+			{Path: "apps/*"},
+			{Path: "apps/legacy", Exclude: true},
+		},
+	}
+
+	params, err := gitDirectoriesParams(dirs, testSha1, gen)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(params).To(gomega.HaveLen(2))
+	g.Expect(params[0].params).To(gomega.Equal(map[string]string{
+		paramPath:         "apps/backend",
+		paramPathBasename: "backend",
+		paramPathSlug:     "apps-backend",
+		paramSha:          testSha1,
+		paramShortSha:     "1111111",
+	}))
+	g.Expect(params[0].slug).To(gomega.Equal("apps-backend"))
+	g.Expect(params[0].defaultAnnotations).To(gomega.Equal(map[string]string{
+		appv1.AnnotationGitPath:   "apps/backend",
+		appv1.AnnotationGitBranch: "main",
+	}))
+	g.Expect(params[1].generated).To(gomega.Equal(appv1alpha1.GeneratedSubscription{Path: "apps/frontend", Commit: testSha1}))
+
+	_, err = gitDirectoriesParams(dirs, testSha1, &appv1alpha1.GitDirectoriesGenerator{
+		Directories: []appv1alpha1.GitDirectory{{Path: "apps/["}},
+	})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestRenderSubscription(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	set := &appv1alpha1.SubscriptionSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "preview", Namespace: "apps"},
+		Spec: appv1alpha1.SubscriptionSetSpec{
+			Template: appv1alpha1.SubscriptionTemplate{
+				Metadata: appv1alpha1.SubscriptionTemplateMeta{
+					Labels:      map[string]string{"app": "preview-{{branch_slug}}"},
+					Annotations: map[string]string{appv1.AnnotationGitPath: "deploy"},
+				},
+				Spec: appv1.SubscriptionSpec{
+					Channel: "ns-ch/git",
+					PackageOverrides: []*appv1.Overrides{{
+						PackageName: "deploy",
+						PackageOverrides: []appv1.PackageOverride{{
+							RawExtension: runtime.RawExtension{Raw: []byte(`{"path":"metadata.labels.commit","value":"{{short_sha}}"}`)},
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	params := newBranchParamSet("feature/New_UI", "feature-new-ui", "", testSha1)
+
+	sub, err := renderSubscription(set, params)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(sub.Name).To(gomega.Equal("preview"))
+	g.Expect(sub.Namespace).To(gomega.Equal("preview-feature-new-ui"))
+	g.Expect(sub.Labels).To(gomega.Equal(map[string]string{
+		"app":                            "preview-feature-new-ui",
+		appv1alpha1.LabelSubscriptionSet: "preview",
+		appv1alpha1.LabelSubscriptionSetNamespace: "apps",
+	}))
+	g.Expect(sub.Annotations).To(gomega.Equal(map[string]string{
+		appv1.AnnotationGitPath:                     "deploy",
+		appv1.AnnotationGitBranch:                   "feature/New_UI",
+		appv1alpha1.AnnotationSubscriptionSetBranch: "feature/New_UI",
+	}))
+	g.Expect(sub.Spec.Channel).To(gomega.Equal("ns-ch/git"))
+	g.Expect(string(sub.Spec.PackageOverrides[0].PackageOverrides[0].Raw)).To(
+		gomega.Equal(`{"path":"metadata.labels.commit","value":"1111111"}`))
+
+	// the template is left unchanged
+	g.Expect(set.Spec.Template.Metadata.Labels["app"]).To(gomega.Equal("preview-{{branch_slug}}"))
+
+	// the git-branch annotation of the template is kept
+	set.Spec.Template.Metadata.Annotations[appv1.AnnotationGitBranch] = "main"
+
+	sub, err = renderSubscription(set, params)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(sub.Annotations[appv1.AnnotationGitBranch]).To(gomega.Equal("main"))
+
+	// the rendered namespace must be a DNS label
+	set.Spec.Template.Metadata.Namespace = "preview-{{branch}}"
+
+	_, err = renderSubscription(set, params)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the git-path annotation of a directory is added unless the template sets it
+	set.Spec.Template.Metadata.Namespace = ""
+	set.Spec.Template.Metadata.Annotations = map[string]string{appv1.AnnotationGitBranch: "main"}
+
+	dirs, err := gitDirectoriesParams([]string{"apps/backend"}, testSha1, &appv1alpha1.GitDirectoriesGenerator{
+		Directories: []appv1alpha1.GitDirectory{{Path: "apps/*"}},
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	sub, err = renderSubscription(set, dirs[0])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(sub.Namespace).To(gomega.Equal("preview-apps-backend"))
+	g.Expect(sub.Annotations).To(gomega.Equal(map[string]string{
+		appv1.AnnotationGitPath:   "apps/backend",
+		appv1.AnnotationGitBranch: "main",
+	}))
+}
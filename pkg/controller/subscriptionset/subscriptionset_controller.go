@@ -0,0 +1,608 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriptionset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+const (
+	defaultPollInterval = 3 * time.Minute
+
+	// the requeue period while the generated subscriptions of a deleted subscription set are being deleted
+	cleanupRequeuePeriod = 10 * time.Second
+)
+
+// ReconcileSubscriptionSet generates the subscriptions of the subscription sets
+type ReconcileSubscriptionSet struct {
+	client.Client
+
+	// listRefs lists the references of a Git repository
+	listRefs func(*utils.ChannelConnectionCfg) ([]*plumbing.Reference, error)
+
+	// listDirs lists the directories of a branch of a Git repository
+	listDirs func(*utils.ChannelConnectionCfg, plumbing.ReferenceName) (string, []string, error)
+}
+
+// Add creates the subscription set controller and adds it to the manager
+func Add(mgr manager.Manager) error {
+	return add(mgr, &ReconcileSubscriptionSet{
+		Client:   mgr.GetClient(),
+		listRefs: utils.ListGitRemoteRefs,
+		listDirs: utils.ListGitDirectories,
+	})
+}
+
+func add(mgr manager.Manager, r *ReconcileSubscriptionSet) error {
+	skipValidation := true
+
+	c, err := controller.New("subscriptionset-controller", mgr, controller.Options{
+		Reconciler:         r,
+		SkipNameValidation: &skipValidation,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(
+		source.Kind(
+			mgr.GetCache(),
+			&appv1alpha1.SubscriptionSet{},
+			&handler.TypedEnqueueRequestForObject[*appv1alpha1.SubscriptionSet]{},
+			predicate.TypedGenerationChangedPredicate[*appv1alpha1.SubscriptionSet]{},
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	// regenerate the subscriptions of the clusters generators when the managed clusters or their labels change
+	err = c.Watch(
+		source.Kind(
+			mgr.GetCache(),
+			&spokeClusterV1.ManagedCluster{},
+			handler.TypedEnqueueRequestsFromMapFunc(r.mapClusterToSets),
+			predicate.Or[*spokeClusterV1.ManagedCluster](
+				predicate.TypedGenerationChangedPredicate[*spokeClusterV1.ManagedCluster]{},
+				predicate.TypedLabelChangedPredicate[*spokeClusterV1.ManagedCluster]{},
+			),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	// regenerate the subscriptions deleted or changed outside of their subscription set
+	return c.Watch(
+		source.Kind(
+			mgr.GetCache(),
+			&appv1.Subscription{},
+			handler.TypedEnqueueRequestsFromMapFunc(mapSubscriptionToSet),
+			predicate.And[*appv1.Subscription](
+				predicate.NewTypedPredicateFuncs(func(sub *appv1.Subscription) bool {
+					return sub.GetLabels()[appv1alpha1.LabelSubscriptionSet] != ""
+				}),
+				predicate.TypedGenerationChangedPredicate[*appv1.Subscription]{},
+			),
+		),
+	)
+}
+
+func mapSubscriptionToSet(ctx context.Context, sub *appv1.Subscription) []reconcile.Request {
+	name := sub.GetLabels()[appv1alpha1.LabelSubscriptionSet]
+	namespace := sub.GetLabels()[appv1alpha1.LabelSubscriptionSetNamespace]
+
+	if name == "" || namespace == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}}
+}
+
+// mapClusterToSets returns the subscription sets with a clusters generator
+func (r *ReconcileSubscriptionSet) mapClusterToSets(ctx context.Context, _ *spokeClusterV1.ManagedCluster) []reconcile.Request {
+	setList := &appv1alpha1.SubscriptionSetList{}
+	if err := r.List(ctx, setList); err != nil {
+		klog.Errorf("failed to list the subscription sets, err: %v", err)
+
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for _, set := range setList.Items {
+		for _, gen := range set.Spec.Generators {
+			if gen.Clusters != nil {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: set.Name, Namespace: set.Namespace}})
+
+				break
+			}
+		}
+	}
+
+	return requests
+}
+
+// Reconcile generates a subscription per parameter set of the generators of a subscription set, and deletes the
+// generated subscriptions whose parameter set is gone
+func (r *ReconcileSubscriptionSet) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	set := &appv1alpha1.SubscriptionSet{}
+
+	if err := r.Get(ctx, request.NamespacedName, set); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !set.GetDeletionTimestamp().IsZero() {
+		return r.finalizeSubscriptionSet(set)
+	}
+
+	if controllerutil.AddFinalizer(set, appv1alpha1.FinalizerSubscriptionSetCleanup) {
+		if err := r.Update(ctx, set); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	paramSets, pollInterval, genErrs := r.generateParams(set)
+	errMsgs := genErrs
+
+	desired := map[types.NamespacedName]appv1alpha1.GeneratedSubscription{}
+
+	for _, ps := range paramSets {
+		sub, err := renderSubscription(set, ps)
+		if err != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("%v: %v", ps.String(), err))
+
+			continue
+		}
+
+		key := types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name}
+
+		if generated, ok := desired[key]; ok {
+			errMsgs = append(errMsgs, fmt.Sprintf("%v: subscription %v is already generated for %v",
+				ps.String(), key.String(), paramSet{generated: generated}.String()))
+
+			continue
+		}
+
+		generated := ps.generated
+		generated.Namespace = sub.Namespace
+		generated.Name = sub.Name
+		desired[key] = generated
+
+		if err := r.applySubscription(set, sub); err != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("%v: %v", ps.String(), err))
+		}
+	}
+
+	// the subscriptions are only pruned after a successful generation, the preview environments are not torn down
+	// while the repository or the managed clusters can't be listed
+	if len(genErrs) == 0 {
+		if _, err := r.prune(set, desired); err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	if err := r.updateStatus(set, desired, errMsgs); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+// generateParams returns the parameter sets of all the generators, the shortest poll interval of the generators,
+// and the generator errors
+func (r *ReconcileSubscriptionSet) generateParams(set *appv1alpha1.SubscriptionSet) ([]paramSet, time.Duration, []string) {
+	paramSets := []paramSet{}
+	pollInterval := time.Duration(0)
+	errMsgs := []string{}
+
+	for i, gen := range set.Spec.Generators {
+		var (
+			params   []paramSet
+			interval *metav1.Duration
+			err      error
+		)
+
+		switch {
+		case countGenerators(gen) != 1:
+			err = fmt.Errorf("exactly one generator must be specified")
+		case gen.GitBranches != nil:
+			interval = gen.GitBranches.PollInterval
+			params, err = r.listGitBranchesParams(gen.GitBranches)
+		case gen.List != nil:
+			params, err = listParams(gen.List)
+		case gen.Clusters != nil:
+			params, err = r.listClustersParams(gen.Clusters)
+		case gen.GitDirectories != nil:
+			interval = gen.GitDirectories.PollInterval
+			params, err = r.listGitDirectoriesParams(gen.GitDirectories)
+		}
+
+		if gen.GitBranches != nil || gen.GitDirectories != nil {
+			d := defaultPollInterval
+			if interval != nil && interval.Duration > 0 {
+				d = interval.Duration
+			}
+
+			if pollInterval == 0 || d < pollInterval {
+				pollInterval = d
+			}
+		}
+
+		if err != nil {
+			klog.Errorf("failed to generate the parameters of subscription set %v/%v, err: %v", set.Namespace, set.Name, err)
+
+			errMsgs = append(errMsgs, fmt.Sprintf("generator %d: %v", i, err))
+
+			continue
+		}
+
+		paramSets = append(paramSets, params...)
+	}
+
+	if pollInterval == 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	return paramSets, pollInterval, errMsgs
+}
+
+func countGenerators(gen appv1alpha1.SubscriptionSetGenerator) int {
+	count := 0
+
+	for _, specified := range []bool{gen.GitBranches != nil, gen.List != nil, gen.Clusters != nil, gen.GitDirectories != nil} {
+		if specified {
+			count++
+		}
+	}
+
+	return count
+}
+
+// listGitBranchesParams lists the branches and pull requests of the Git channel of the generator
+func (r *ReconcileSubscriptionSet) listGitBranchesParams(gen *appv1alpha1.GitBranchesGenerator) ([]paramSet, error) {
+	connCfg, err := r.getGitConnectionCfg(gen.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := r.listRefs(connCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return gitBranchesParams(refs, gen)
+}
+
+// listGitDirectoriesParams lists the directories of the revision of the Git channel of the generator
+func (r *ReconcileSubscriptionSet) listGitDirectoriesParams(gen *appv1alpha1.GitDirectoriesGenerator) ([]paramSet, error) {
+	connCfg, err := r.getGitConnectionCfg(gen.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, dirs, err := r.listDirs(connCfg, utils.GetSubscriptionBranchRef(gen.Revision))
+	if err != nil {
+		return nil, err
+	}
+
+	return gitDirectoriesParams(dirs, commit, gen)
+}
+
+// listClustersParams lists the managed clusters matching the label selector of the generator
+func (r *ReconcileSubscriptionSet) listClustersParams(gen *appv1alpha1.ClustersGenerator) ([]paramSet, error) {
+	selector := labels.Everything()
+
+	if gen.Selector != nil {
+		var err error
+
+		selector, err = metav1.LabelSelectorAsSelector(gen.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster selector: %w", err)
+		}
+	}
+
+	clusterList := &spokeClusterV1.ManagedClusterList{}
+	if err := r.List(context.TODO(), clusterList, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, err
+	}
+
+	return clustersParams(clusterList.Items, gen), nil
+}
+
+// getGitConnectionCfg returns the connection of a Git channel
+func (r *ReconcileSubscriptionSet) getGitConnectionCfg(channel string) (*utils.ChannelConnectionCfg, error) {
+	chnNamespace, chnName := utils.ParseNamespacedName(channel)
+	if chnNamespace == "" || chnName == "" {
+		return nil, fmt.Errorf("invalid channel %q, it must be a namespaced name", channel)
+	}
+
+	chn := &chnv1.Channel{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: chnNamespace, Name: chnName}, chn); err != nil {
+		return nil, err
+	}
+
+	if !utils.IsGitChannel(string(chn.Spec.Type)) {
+		return nil, fmt.Errorf("channel %v is not a Git channel", channel)
+	}
+
+	return getChannelConnectionCfg(r.Client, chn)
+}
+
+func getChannelConnectionCfg(clt client.Client, chn *chnv1.Channel) (*utils.ChannelConnectionCfg, error) {
+	user, pwd, sshKey, passphrase, clientkey, clientcert, err := utils.GetChannelSecret(clt, chn)
+	if err != nil {
+		return nil, err
+	}
+
+	connCfg := &utils.ChannelConnectionCfg{
+		RepoURL:            chn.Spec.Pathname,
+		User:               user,
+		Password:           pwd,
+		SSHKey:             sshKey,
+		Passphrase:         passphrase,
+		InsecureSkipVerify: chn.Spec.InsecureSkipVerify,
+		ClientKey:          clientkey,
+		ClientCert:         clientcert,
+	}
+
+	channelConfig := utils.GetChannelConfigMap(clt, chn)
+	if channelConfig != nil {
+		connCfg.CaCerts = channelConfig.Data[appv1.ChannelCertificateData]
+	}
+
+	utils.SetChannelSSHConfig(connCfg, channelConfig)
+
+	return connCfg, nil
+}
+
+// applySubscription creates or updates a generated subscription, and creates its namespace if it doesn't exist. The
+// subscriptions that are not generated by the subscription set are left unchanged.
+func (r *ReconcileSubscriptionSet) applySubscription(set *appv1alpha1.SubscriptionSet, sub *appv1.Subscription) error {
+	ns := &corev1.Namespace{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Name: sub.Namespace}, ns)
+	if kerrors.IsNotFound(err) {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   sub.Namespace,
+				Labels: setLabels(set),
+			},
+		}
+
+		klog.Infof("creating namespace %v of subscription set %v/%v", sub.Namespace, set.Namespace, set.Name)
+
+		err = r.Create(context.TODO(), ns)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	existing := &appv1.Subscription{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name}, existing)
+	if kerrors.IsNotFound(err) {
+		klog.Infof("creating subscription %v/%v of subscription set %v/%v", sub.Namespace, sub.Name, set.Namespace, set.Name)
+
+		return r.Create(context.TODO(), sub)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !isGeneratedBy(existing, set) {
+		return fmt.Errorf("subscription %v/%v already exists and is not generated by the subscription set", sub.Namespace, sub.Name)
+	}
+
+	if !existing.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	updated := existing.DeepCopy()
+
+	// the labels and annotations added to the subscription by the hub are kept
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+
+	for k, v := range sub.Labels {
+		updated.Labels[k] = v
+	}
+
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+
+	for k, v := range sub.Annotations {
+		updated.Annotations[k] = v
+	}
+
+	updated.Spec = sub.Spec
+
+	if equality.Semantic.DeepEqual(existing, updated) {
+		return nil
+	}
+
+	klog.Infof("updating subscription %v/%v of subscription set %v/%v", sub.Namespace, sub.Name, set.Namespace, set.Name)
+
+	return r.Update(context.TODO(), updated)
+}
+
+// prune deletes the generated subscriptions that are not desired anymore, then the namespaces created by the
+// subscription set once their generated subscriptions are gone. It returns the number of generated subscriptions and
+// namespaces that are still being deleted.
+func (r *ReconcileSubscriptionSet) prune(set *appv1alpha1.SubscriptionSet,
+	desired map[types.NamespacedName]appv1alpha1.GeneratedSubscription) (int, error) {
+	remaining := 0
+	subNamespaces := map[string]bool{}
+
+	subList := &appv1.SubscriptionList{}
+	if err := r.List(context.TODO(), subList, client.MatchingLabels(setLabels(set))); err != nil {
+		return 0, err
+	}
+
+	for i := range subList.Items {
+		sub := &subList.Items[i]
+		subNamespaces[sub.Namespace] = true
+
+		if _, ok := desired[types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name}]; ok {
+			continue
+		}
+
+		remaining++
+
+		if !sub.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		klog.Infof("deleting subscription %v/%v of subscription set %v/%v", sub.Namespace, sub.Name, set.Namespace, set.Name)
+
+		if err := r.Delete(context.TODO(), sub); client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+	}
+
+	for _, generated := range desired {
+		subNamespaces[generated.Namespace] = true
+	}
+
+	nsList := &corev1.NamespaceList{}
+	if err := r.List(context.TODO(), nsList, client.MatchingLabels(setLabels(set))); err != nil {
+		return 0, err
+	}
+
+	for i := range nsList.Items {
+		ns := &nsList.Items[i]
+
+		// the namespace is deleted once the subscription resources are deleted or orphaned
+		if subNamespaces[ns.Name] {
+			continue
+		}
+
+		remaining++
+
+		if !ns.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		klog.Infof("deleting namespace %v of subscription set %v/%v", ns.Name, set.Namespace, set.Name)
+
+		if err := r.Delete(context.TODO(), ns); client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+	}
+
+	return remaining, nil
+}
+
+// finalizeSubscriptionSet deletes the generated subscriptions and namespaces of a deleted subscription set, then
+// removes its finalizer
+func (r *ReconcileSubscriptionSet) finalizeSubscriptionSet(set *appv1alpha1.SubscriptionSet) (reconcile.Result, error) {
+	if !controllerutil.ContainsFinalizer(set, appv1alpha1.FinalizerSubscriptionSetCleanup) {
+		return reconcile.Result{}, nil
+	}
+
+	remaining, err := r.prune(set, map[types.NamespacedName]appv1alpha1.GeneratedSubscription{})
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if remaining > 0 {
+		klog.Infof("waiting for %d generated resources of subscription set %v/%v to be deleted", remaining, set.Namespace, set.Name)
+
+		return reconcile.Result{RequeueAfter: cleanupRequeuePeriod}, nil
+	}
+
+	controllerutil.RemoveFinalizer(set, appv1alpha1.FinalizerSubscriptionSetCleanup)
+
+	return reconcile.Result{}, r.Update(context.TODO(), set)
+}
+
+func (r *ReconcileSubscriptionSet) updateStatus(set *appv1alpha1.SubscriptionSet,
+	desired map[types.NamespacedName]appv1alpha1.GeneratedSubscription, errMsgs []string) error {
+	generated := make([]appv1alpha1.GeneratedSubscription, 0, len(desired))
+	for _, sub := range desired {
+		generated = append(generated, sub)
+	}
+
+	sort.Slice(generated, func(i, j int) bool {
+		if generated[i].Namespace != generated[j].Namespace {
+			return generated[i].Namespace < generated[j].Namespace
+		}
+
+		return generated[i].Name < generated[j].Name
+	})
+
+	newStatus := set.Status.DeepCopy()
+	newStatus.Subscriptions = generated
+	newStatus.Message = strings.Join(errMsgs, "; ")
+
+	if len(newStatus.Subscriptions) == 0 {
+		newStatus.Subscriptions = nil
+	}
+
+	if equality.Semantic.DeepEqual(set.Status, *newStatus) {
+		return nil
+	}
+
+	set.Status = *newStatus
+	set.Status.LastUpdateTime = metav1.Now()
+
+	return r.Status().Update(context.TODO(), set)
+}
+
+func setLabels(set *appv1alpha1.SubscriptionSet) map[string]string {
+	return map[string]string{
+		appv1alpha1.LabelSubscriptionSet:          set.Name,
+		appv1alpha1.LabelSubscriptionSetNamespace: set.Namespace,
+	}
+}
+
+func isGeneratedBy(obj client.Object, set *appv1alpha1.SubscriptionSet) bool {
+	labels := obj.GetLabels()
+
+	return labels[appv1alpha1.LabelSubscriptionSet] == set.Name && labels[appv1alpha1.LabelSubscriptionSetNamespace] == set.Namespace
+}
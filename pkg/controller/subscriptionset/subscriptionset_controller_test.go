@@ -0,0 +1,268 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subscriptionset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+var setKey = types.NamespacedName{Name: "preview", Namespace: "apps"}
+
+func newTestReconciler(g *gomega.WithT, refs *[]*plumbing.Reference, listErr *error) *ReconcileSubscriptionSet {
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(scheme)).To(gomega.Succeed())
+
+	chn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "ns-ch"},
+		Spec:       chnv1.ChannelSpec{Type: chnv1.ChannelTypeGit, Pathname: "https://github.com/example/app.git"},
+	}
+
+	set := &appv1alpha1.SubscriptionSet{
+		ObjectMeta: metav1.ObjectMeta{Name: setKey.Name, Namespace: setKey.Namespace},
+		Spec: appv1alpha1.SubscriptionSetSpec{
+			Generators: []appv1alpha1.SubscriptionSetGenerator{{
+				GitBranches: &appv1alpha1.GitBranchesGenerator{
+					Channel:       "ns-ch/git",
+					BranchPattern: "feature/*",
+					PollInterval:  &metav1.Duration{Duration: time.Minute},
+				},
+			}},
+			Template: appv1alpha1.SubscriptionTemplate{
+				Spec: appv1.SubscriptionSpec{Channel: "ns-ch/git"},
+			},
+		},
+	}
+
+	// a namespace that is not created by the subscription set is not deleted with it
+	existingNs := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "preview-feature-b"}}
+
+	return &ReconcileSubscriptionSet{
+		Client: fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(chn, set, existingNs).
+			WithStatusSubresource(&appv1alpha1.SubscriptionSet{}).
+			Build(),
+		listRefs: func(connCfg *utils.ChannelConnectionCfg) ([]*plumbing.Reference, error) {
+			g.Expect(connCfg.RepoURL).To(gomega.Equal("https://github.com/example/app.git"))
+
+			return *refs, *listErr
+		},
+		listDirs: func(connCfg *utils.ChannelConnectionCfg, branch plumbing.ReferenceName) (string, []string, error) {
+			g.Expect(connCfg.RepoURL).To(gomega.Equal("https://github.com/example/app.git"))
+
+			return testSha1, []string{"apps", "apps/backend", "apps/frontend"}, *listErr
+		},
+	}
+}
+
+func listGenerated(g *gomega.WithT, r *ReconcileSubscriptionSet) ([]appv1.Subscription, []corev1.Namespace) {
+	subList := &appv1.SubscriptionList{}
+	g.Expect(r.List(context.TODO(), subList, client.MatchingLabels{appv1alpha1.LabelSubscriptionSet: "preview"})).To(gomega.Succeed())
+
+	nsList := &corev1.NamespaceList{}
+	g.Expect(r.List(context.TODO(), nsList, client.MatchingLabels{appv1alpha1.LabelSubscriptionSet: "preview"})).To(gomega.Succeed())
+
+	return subList.Items, nsList.Items
+}
+
+func TestReconcileSubscriptionSet(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	refs := []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash(testSha1)),
+		plumbing.NewHashReference("refs/heads/feature/a", plumbing.NewHash(testSha1)),
+		plumbing.NewHashReference("refs/heads/feature/b", plumbing.NewHash(testSha2)),
+	}
+
+	var listErr error
+
+	r := newTestReconciler(g, &refs, &listErr)
+
+	// a subscription is generated per matching branch
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: setKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.RequeueAfter).To(gomega.Equal(time.Minute))
+
+	subs, namespaces := listGenerated(g, r)
+	g.Expect(subs).To(gomega.HaveLen(2))
+	g.Expect(subs[0].Namespace).To(gomega.Equal("preview-feature-a"))
+	g.Expect(subs[0].Annotations[appv1.AnnotationGitBranch]).To(gomega.Equal("feature/a"))
+	g.Expect(subs[1].Namespace).To(gomega.Equal("preview-feature-b"))
+	g.Expect(namespaces).To(gomega.HaveLen(1))
+	g.Expect(namespaces[0].Name).To(gomega.Equal("preview-feature-a"))
+
+	set := &appv1alpha1.SubscriptionSet{}
+	g.Expect(r.Get(context.TODO(), setKey, set)).To(gomega.Succeed())
+	g.Expect(set.GetFinalizers()).To(gomega.ConsistOf(appv1alpha1.FinalizerSubscriptionSetCleanup))
+	g.Expect(set.Status.Message).To(gomega.BeEmpty())
+	g.Expect(set.Status.Subscriptions).To(gomega.Equal([]appv1alpha1.GeneratedSubscription{
+		{Branch: "feature/a", Commit: testSha1, Namespace: "preview-feature-a", Name: "preview"},
+		{Branch: "feature/b", Commit: testSha2, Namespace: "preview-feature-b", Name: "preview"},
+	}))
+
+	// nothing is deleted while the repository is unreachable
+	listErr = errors.New("connection refused")
+
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: setKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	subs, _ = listGenerated(g, r)
+	g.Expect(subs).To(gomega.HaveLen(2))
+
+	g.Expect(r.Get(context.TODO(), setKey, set)).To(gomega.Succeed())
+	g.Expect(set.Status.Message).To(gomega.ContainSubstring("connection refused"))
+
+	// the subscription of a deleted branch is deleted, its namespace is kept as it is not created by the subscription set
+	listErr = nil
+	refs = refs[:2]
+
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: setKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	subs, namespaces = listGenerated(g, r)
+	g.Expect(subs).To(gomega.HaveLen(1))
+	g.Expect(subs[0].Namespace).To(gomega.Equal("preview-feature-a"))
+	g.Expect(namespaces).To(gomega.HaveLen(1))
+
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Name: "preview-feature-b"}, &corev1.Namespace{})).To(gomega.Succeed())
+
+	// the generated subscriptions and namespaces are deleted with the subscription set
+	g.Expect(r.Delete(context.TODO(), set)).To(gomega.Succeed())
+
+	result, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: setKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.RequeueAfter).To(gomega.Equal(cleanupRequeuePeriod))
+
+	subs, namespaces = listGenerated(g, r)
+	g.Expect(subs).To(gomega.BeEmpty())
+	g.Expect(namespaces).To(gomega.HaveLen(1))
+
+	_, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: setKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, namespaces = listGenerated(g, r)
+	g.Expect(namespaces).To(gomega.BeEmpty())
+
+	result, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: setKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.RequeueAfter).To(gomega.BeZero())
+	g.Expect(r.Get(context.TODO(), setKey, set)).NotTo(gomega.Succeed())
+}
+
+func TestApplySubscriptionNotGenerated(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		refs    []*plumbing.Reference
+		listErr error
+	)
+
+	r := newTestReconciler(g, &refs, &listErr)
+
+	set := &appv1alpha1.SubscriptionSet{}
+	g.Expect(r.Get(context.TODO(), setKey, set)).To(gomega.Succeed())
+
+	existing := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "preview", Namespace: "preview-feature-b"}}
+	g.Expect(r.Create(context.TODO(), existing)).To(gomega.Succeed())
+
+	sub, err := renderSubscription(set, newBranchParamSet("feature/b", "feature-b", "", testSha2))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(r.applySubscription(set, sub)).NotTo(gomega.Succeed())
+}
+
+func TestReconcileSubscriptionSetGenerators(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		refs    []*plumbing.Reference
+		listErr error
+	)
+
+	r := newTestReconciler(g, &refs, &listErr)
+
+	set := &appv1alpha1.SubscriptionSet{}
+	g.Expect(r.Get(context.TODO(), setKey, set)).To(gomega.Succeed())
+
+	for _, cluster := range []*spokeClusterV1.ManagedCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Labels: map[string]string{"env": "prod"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cluster2", Labels: map[string]string{"env": "dev"}}},
+	} {
+		g.Expect(r.Create(context.TODO(), cluster)).To(gomega.Succeed())
+	}
+
+	set.Spec.Generators = []appv1alpha1.SubscriptionSetGenerator{
+		{List: &appv1alpha1.ListGenerator{Elements: []map[string]string{{"name": "staging"}}}},
+		{Clusters: &appv1alpha1.ClustersGenerator{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+		}},
+		{GitDirectories: &appv1alpha1.GitDirectoriesGenerator{
+			Channel:     "ns-ch/git",
+			Revision:    "main",
+			Directories: []appv1alpha1.GitDirectory{{Path: "apps/*"}},
+		}},
+		{},
+	}
+	g.Expect(r.Update(context.TODO(), set)).To(gomega.Succeed())
+
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: setKey})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.RequeueAfter).To(gomega.Equal(defaultPollInterval))
+
+	subs, _ := listGenerated(g, r)
+	g.Expect(subs).To(gomega.HaveLen(4))
+
+	g.Expect(r.Get(context.TODO(), setKey, set)).To(gomega.Succeed())
+	g.Expect(set.Status.Message).To(gomega.ContainSubstring("generator 3: exactly one generator must be specified"))
+	g.Expect(set.Status.Subscriptions).To(gomega.ConsistOf(
+		appv1alpha1.GeneratedSubscription{Element: "staging", Namespace: "preview-staging", Name: "preview"},
+		appv1alpha1.GeneratedSubscription{Cluster: "cluster1", Namespace: "preview-cluster1", Name: "preview"},
+		appv1alpha1.GeneratedSubscription{Path: "apps/backend", Commit: testSha1, Namespace: "preview-apps-backend",
+			Name: "preview"},
+		appv1alpha1.GeneratedSubscription{Path: "apps/frontend", Commit: testSha1, Namespace: "preview-apps-frontend",
+			Name: "preview"},
+	))
+
+	sub := &appv1.Subscription{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Name: "preview", Namespace: "preview-apps-backend"}, sub)).To(
+		gomega.Succeed())
+	g.Expect(sub.Annotations[appv1.AnnotationGitPath]).To(gomega.Equal("apps/backend"))
+	g.Expect(sub.Annotations[appv1.AnnotationGitBranch]).To(gomega.Equal("main"))
+
+	// the subscription set of a clusters generator is reconciled when the managed clusters change
+	g.Expect(r.mapClusterToSets(context.TODO(), &spokeClusterV1.ManagedCluster{})).To(gomega.Equal([]reconcile.Request{
+		{NamespacedName: setKey},
+	}))
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package features defines the feature gates of the subscription controllers, set with the --feature-gates flag
+package features
+
+import (
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+)
+
+const (
+	// PersistSubscriberState persists the state of the Git subscriber in a ConfigMap, so the agent doesn't re-apply
+	// all the Git subscriptions when it restarts
+	PersistSubscriberState featuregate.Feature = "PersistSubscriberState"
+
+	// InMemoryGitRender clones and renders the Git subscriptions in memory, so the agent doesn't write the Git clones,
+	// the kustomize remote bases and the helm charts on the container file system
+	InMemoryGitRender featuregate.Feature = "InMemoryGitRender"
+)
+
+// DefaultMutableFeatureGate is the feature gate of the subscription controllers
+var DefaultMutableFeatureGate featuregate.MutableFeatureGate = featuregate.NewFeatureGate()
+
+var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	PersistSubscriberState: {Default: true, PreRelease: featuregate.Beta},
+	InMemoryGitRender:      {Default: false, PreRelease: featuregate.Alpha},
+}
+
+func init() {
+	utilruntime.Must(DefaultMutableFeatureGate.Add(defaultFeatureGates))
+}
+
+// ValidateFeatureGates returns an error if the --feature-gates value is invalid, e.g. sets an unknown feature
+func ValidateFeatureGates(value string) error {
+	return DefaultMutableFeatureGate.DeepCopy().Set(value)
+}
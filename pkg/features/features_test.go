@@ -0,0 +1,43 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package features
+
+import (
+	"testing"
+)
+
+func TestValidateFeatureGates(t *testing.T) {
+	tests := []struct {
+		value     string
+		expectErr bool
+	}{
+		{value: "PersistSubscriberState=false"},
+		{value: "PersistSubscriberState=true,AllBeta=false"},
+		{value: "InMemoryGitRender=true"},
+		{value: "Unknown=true", expectErr: true},
+		{value: "PersistSubscriberState", expectErr: true},
+	}
+
+	for _, test := range tests {
+		err := ValidateFeatureGates(test.value)
+		if (err != nil) != test.expectErr {
+			t.Errorf("%q: expected error %v, got %v", test.value, test.expectErr, err)
+		}
+	}
+
+	if !DefaultMutableFeatureGate.Enabled(PersistSubscriberState) || DefaultMutableFeatureGate.Enabled(InMemoryGitRender) {
+		t.Errorf("the validation must not change the feature gate")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	// Operations tracked by the watchdog
+	OperationSubscribe = "subscribe"
+	OperationSync      = "sync"
+	OperationPurge     = "purge"
+
+	// maximum number of stalled operations listed in the check error
+	maxReportedOperations = 5
+)
+
+type operation struct {
+	name  string
+	start time.Time
+}
+
+// Watchdog tracks the operations running in the subscriber goroutines and the synchronizer, so the health
+// probe fails if one of them is wedged.
+type Watchdog struct {
+	lock   sync.Mutex
+	nextID uint64
+	ops    map[uint64]operation
+	now    func() time.Time
+}
+
+var defaultWatchdog = NewWatchdog()
+
+// NewWatchdog returns an empty watchdog
+func NewWatchdog() *Watchdog {
+	return &Watchdog{ops: map[uint64]operation{}, now: time.Now}
+}
+
+// Track records the start of an operation on a subscription, the returned function records its end.
+func Track(op, namespace, name string) func() {
+	return defaultWatchdog.Track(op + " " + namespace + "/" + name)
+}
+
+// Checker returns the health checker of the default watchdog
+func Checker(timeout time.Duration) func(*http.Request) error {
+	return defaultWatchdog.Checker(timeout)
+}
+
+// Track records the start of an operation, the returned function records its end.
+func (w *Watchdog) Track(name string) func() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.ops[id] = operation{name: name, start: w.now()}
+
+	return func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+
+		delete(w.ops, id)
+	}
+}
+
+// Stalled returns the operations running for longer than the timeout, the oldest first.
+func (w *Watchdog) Stalled(timeout time.Duration) []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := w.now()
+	stalled := []operation{}
+
+	for _, op := range w.ops {
+		if now.Sub(op.start) > timeout {
+			stalled = append(stalled, op)
+		}
+	}
+
+	sort.Slice(stalled, func(i, j int) bool {
+		return stalled[i].start.Before(stalled[j].start)
+	})
+
+	names := []string{}
+	for _, op := range stalled {
+		names = append(names, fmt.Sprintf("%v (running for %v)", op.name, now.Sub(op.start).Round(time.Second)))
+	}
+
+	return names
+}
+
+// Checker returns a health checker that fails if an operation is running for longer than the timeout.
+// The checker always passes if the timeout is 0.
+func (w *Watchdog) Checker(timeout time.Duration) func(*http.Request) error {
+	return func(_ *http.Request) error {
+		if timeout <= 0 {
+			return nil
+		}
+
+		stalled := w.Stalled(timeout)
+		if len(stalled) == 0 {
+			return nil
+		}
+
+		count := len(stalled)
+
+		klog.Errorf("%d operations are running for longer than %v: %v", count, timeout, stalled)
+
+		if count > maxReportedOperations {
+			stalled = append(stalled[:maxReportedOperations], "...")
+		}
+
+		return fmt.Errorf("%d operations are running for longer than %v: %v", count, timeout, strings.Join(stalled, ", "))
+	}
+}
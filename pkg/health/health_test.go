@@ -0,0 +1,57 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestWatchdog(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	w := NewWatchdog()
+	w.now = func() time.Time { return now }
+
+	check := w.Checker(10 * time.Minute)
+
+	doneClone := w.Track("subscribe ns/git-sub")
+
+	now = now.Add(5 * time.Minute)
+	doneSync := w.Track("sync ns/git-sub")
+
+	g.Expect(check(nil)).To(gomega.Succeed())
+
+	// the clone is running for 11 minutes
+	now = now.Add(6 * time.Minute)
+	g.Expect(w.Stalled(10 * time.Minute)).To(gomega.Equal([]string{"subscribe ns/git-sub (running for 11m0s)"}))
+	g.Expect(check(nil)).NotTo(gomega.Succeed())
+
+	// a disabled checker always passes
+	g.Expect(w.Checker(0)(nil)).To(gomega.Succeed())
+
+	doneClone()
+	g.Expect(check(nil)).To(gomega.Succeed())
+
+	now = now.Add(10 * time.Minute)
+	g.Expect(check(nil)).NotTo(gomega.Succeed())
+
+	doneSync()
+	g.Expect(check(nil)).To(gomega.Succeed())
+	g.Expect(w.Stalled(0)).To(gomega.BeEmpty())
+}
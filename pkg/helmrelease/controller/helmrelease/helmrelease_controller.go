@@ -58,6 +58,9 @@ const (
 	finalizer = "uninstall-helm-release"
 
 	defaultMaxConcurrent = 1
+
+	// defaultAtomicTimeout is the timeout of the atomic releases without timeout, like the helm CLI
+	defaultAtomicTimeout = 5 * time.Minute
 )
 
 // Add creates a new HelmRelease Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -72,6 +75,12 @@ func Add(mgr manager.Manager) error {
 		return err
 	}
 
+	return AddWithSynchronizer(mgr, synchronizer)
+}
+
+// AddWithSynchronizer creates a new HelmRelease Controller reporting the HelmRelease status with the given synchronizer
+// and adds it to the Manager.
+func AddWithSynchronizer(mgr manager.Manager, synchronizer *kubesynchronizer.KubeSynchronizer) error {
 	chartsDir := os.Getenv(appv1.ChartsDir)
 	if chartsDir == "" {
 		chartsDir = "/tmp/hr-charts"
@@ -193,7 +202,13 @@ func (r *ReconcileHelmRelease) Reconcile(ctx context.Context, request reconcile.
 			repoClone := instance.Repo.Clone()
 			instance.Repo = instance.Repo.AltSourceToSource()
 
+			// the secret provider annotations are the credentials of the primary channel, not of the AltSource
+			annotations := instance.GetAnnotations()
+			instance.SetAnnotations(withoutSecretProvider(annotations))
+
 			helmOperatorManagerFactory, err = r.newHelmOperatorManagerFactory(instance)
+
+			instance.SetAnnotations(annotations)
 			if err == nil {
 				altSourcePassed = true
 				instance.Repo = repoClone
@@ -381,6 +396,20 @@ func contains(l []string, s string) bool {
 	return false
 }
 
+// getReleaseTimeout returns the time to wait for the release install or upgrade, the release resources are not waited
+// for if it is zero
+func getReleaseTimeout(hr *appv1.HelmRelease) time.Duration {
+	if hr.Repo.Timeout != nil {
+		return hr.Repo.Timeout.Duration
+	}
+
+	if hr.Repo.Atomic {
+		return defaultAtomicTimeout
+	}
+
+	return 0
+}
+
 // returns the boolean representation of the annotation string
 // will return false if annotation is not set
 func hasHelmUpgradeForceAnnotation(hr *appv1.HelmRelease) bool {
@@ -453,10 +482,21 @@ func (r *ReconcileHelmRelease) install(instance *appv1.HelmRelease, manager helm
 	installOpt := func(install *action.Install) error {
 		install.DryRun = false
 		install.ClientOnly = false
+		install.Atomic = instance.Repo.Atomic
+
+		if timeout := getReleaseTimeout(instance); timeout > 0 {
+			install.Wait = true
+			install.Timeout = timeout
+		}
 
 		return nil
 	}
 
+	// helm already uninstalls the failed atomic installs
+	if instance.Repo.Atomic {
+		rollbackByUninstall = false
+	}
+
 	installedRelease, err = manager.InstallRelease(context.TODO(), installOpt)
 	if err != nil {
 		klog.Error("Failed to install HelmRelease ",
@@ -564,6 +604,12 @@ func (r *ReconcileHelmRelease) upgrade(instance *appv1.HelmRelease, manager helm
 	upgradeOpt := func(upgrade *action.Upgrade) error {
 		upgrade.DryRun = false
 		upgrade.Force = force
+		upgrade.Atomic = instance.Repo.Atomic
+
+		if timeout := getReleaseTimeout(instance); timeout > 0 {
+			upgrade.Wait = true
+			upgrade.Timeout = timeout
+		}
 
 		return nil
 	}
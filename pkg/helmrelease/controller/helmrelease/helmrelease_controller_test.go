@@ -768,3 +768,17 @@ func TestReconcile(t *testing.T) {
 	err = c.Get(context.TODO(), roleKey, role)
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 }
+
+func TestGetReleaseTimeout(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hr := &appv1.HelmRelease{}
+	g.Expect(getReleaseTimeout(hr)).To(gomega.BeZero())
+
+	// the atomic releases are always waited for
+	hr.Repo.Atomic = true
+	g.Expect(getReleaseTimeout(hr)).To(gomega.Equal(defaultAtomicTimeout))
+
+	hr.Repo.Timeout = &metav1.Duration{Duration: 20 * time.Minute}
+	g.Expect(getReleaseTimeout(hr)).To(gomega.Equal(20 * time.Minute))
+}
@@ -28,6 +28,7 @@ import (
 
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/helmrelease/utils"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/secretprovider"
 )
 
 // newHelmOperatorManagerFactory create a new manager returns a helmManagerFactory
@@ -87,6 +88,14 @@ func downloadChart(client client.Client, s *appv1.HelmRelease) (string, error) {
 		return "", err
 	}
 
+	if s.Repo.SecretRef == nil {
+		secret, err = utils.GetSecretProviderSecret(s)
+		if err != nil {
+			klog.Error(err, " - Failed to resolve the secret of the secret provider")
+			return "", err
+		}
+	}
+
 	chartsDir := os.Getenv(appv1.ChartsDir)
 	if chartsDir == "" {
 		chartsDir = "/tmp/hr-charts"
@@ -102,3 +111,18 @@ func downloadChart(client client.Client, s *appv1.HelmRelease) (string, error) {
 
 	return chartDir, nil
 }
+
+// withoutSecretProvider returns a copy of the annotations without the secret provider annotations
+func withoutSecretProvider(annotations map[string]string) map[string]string {
+	copied := map[string]string{}
+
+	for k, v := range annotations {
+		copied[k] = v
+	}
+
+	for _, key := range secretprovider.Annotations {
+		delete(copied, key)
+	}
+
+	return copied
+}
@@ -27,7 +27,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -48,10 +47,13 @@ import (
 
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
 	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
 
-// GetHelmRepoClient returns an *http.client to access the helm repo
-func GetHelmRepoClient(parentNamespace string, configMap *corev1.ConfigMap, skipCertVerify bool) (rest.HTTPClient, error) {
+// GetHelmRepoClient returns an *http.client to access the helm repo, with the CA certificates of the config map and the
+// mTLS client certificate of the secret
+func GetHelmRepoClient(parentNamespace string, configMap *corev1.ConfigMap, secret *corev1.Secret,
+	skipCertVerify bool) (rest.HTTPClient, error) {
 	transport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -63,10 +65,10 @@ func GetHelmRepoClient(parentNamespace string, configMap *corev1.ConfigMap, skip
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSClientConfig: &tls.Config{
+		TLSClientConfig: utils.SetFIPSTLSConfig(&tls.Config{
 			InsecureSkipVerify: skipCertVerify,            // #nosec G402 InsecureSkipVerify conditionally
 			MinVersion:         appsubv1.TLSMinVersionInt, // #nosec G402 -- TLS 1.2 is required for FIPS
-		},
+		}),
 	}
 
 	if skipCertVerify {
@@ -99,8 +101,13 @@ func GetHelmRepoClient(parentNamespace string, configMap *corev1.ConfigMap, skip
 		klog.V(5).Info("configMap is nil")
 	}
 
-	httpClient := http.DefaultClient
-	httpClient.Transport = transport
+	if err := utils.SetHelmRepoTLSConfig(transport.TLSClientConfig, configMap, secret); err != nil {
+		klog.Error(err, " - Unable to set the helm repo TLS config")
+
+		return nil, err
+	}
+
+	httpClient := &http.Client{Transport: transport}
 	klog.V(5).Info("InsecureSkipVerify equal ", transport.TLSClientConfig.InsecureSkipVerify)
 
 	return httpClient, nil
@@ -218,7 +225,15 @@ func DownloadGitRepo(configMap *corev1.ConfigMap,
 			knownhostsfile := filepath.Join(destRepo, "known_hosts")
 
 			if !insecureSkipVerify {
-				err := getKnownHostFromURL(url, knownhostsfile)
+				sshConn := &utils.ChannelConnectionCfg{RepoURL: url}
+				utils.SetChannelSSHConfig(sshConn, configMap)
+
+				var err error
+				if sshConn.KnownHosts == "" && sshConn.TrustScannedHostKeys {
+					err = getKnownHostFromURL(url, knownhostsfile)
+				} else {
+					err = utils.WriteSSHKnownHosts(sshConn, knownhostsfile)
+				}
 
 				if err != nil {
 					return "", err
@@ -249,7 +264,7 @@ func DownloadGitRepo(configMap *corev1.ConfigMap,
 			}
 
 			klog.Error(errClone, " - Clone failed: ", url)
-			err = errClone
+			err = utils.FIPSSSHError(utils.SSHHostKeyError(errClone))
 
 			continue
 		}
@@ -313,36 +328,19 @@ func getKnownHostFromURL(sshURL string, filepath string) error {
 			return err
 		}
 
-		sshhostname = strings.Split(u.Host, ":")[0]
-
-		sshhostport = u.Host
+		sshhostname = u.Hostname()
+		sshhostport = u.Port()
 	} else if strings.HasPrefix(sshURL, "git@") {
 		sshhostname = strings.Split(strings.SplitAfter(sshURL, "@")[1], ":")[0]
 	}
 
 	klog.Info("Getting public SSH host key for " + sshhostname)
 
-	cmd := exec.Command("ssh-keyscan", sshhostname) // #nosec G204 the variable is generated within this function.
-	stdout, err := cmd.Output()
-
+	stdout, err := utils.ScanSSHHostKeys(sshhostname, sshhostport)
 	if err != nil {
 		klog.Error("failed to get public SSH host key: ", err)
 
-		if sshhostport != "" && (sshhostport != sshhostname) {
-			klog.Info("Getting public SSH host key for " + sshhostport)
-
-			cmd2 := exec.Command("ssh-keyscan", sshhostport) // #nosec G204 the variable is generated within this function.
-			stdout2, err2 := cmd2.Output()
-
-			if err2 != nil {
-				klog.Error("failed to get public SSH host key: ", err2)
-				return err2
-			}
-
-			stdout = stdout2
-		} else {
-			return err
-		}
+		return err
 	}
 
 	klog.Info("SSH host key: " + string(stdout))
@@ -395,16 +393,21 @@ func getSSHOptions(options *git.CloneOptions, sshKey, passphrase []byte, knownho
 		publicKey.HostKeyCallback = callback
 	}
 
+	if err := utils.SetFIPSSSHAuth(publicKey); err != nil {
+		return err
+	}
+
 	options.Auth = publicKey
 
 	return nil
 }
 
 func getHTTPOptions(caCerts string, insecureSkipVerify bool) error {
-	installProtocol := false
+	// the default transport doesn't restrict the cipher suites in FIPS mode
+	installProtocol := utils.IsFIPSMode()
 
 	// #nosec G402 -- TLS 1.2 is required for FIPS
-	clientConfig := &tls.Config{MinVersion: appsubv1.TLSMinVersionInt}
+	clientConfig := utils.SetFIPSTLSConfig(&tls.Config{MinVersion: appsubv1.TLSMinVersionInt})
 
 	// skip TLS certificate verification for Git servers with custom or self-signed certs
 	if insecureSkipVerify {
@@ -648,7 +651,7 @@ func downloadFileHTTP(parentNamespace string, configMap *corev1.ConfigMap,
 	}
 
 	if os.IsNotExist(err) {
-		httpClient, downloadErr := GetHelmRepoClient(parentNamespace, configMap, insecureSkipVerify)
+		httpClient, downloadErr := GetHelmRepoClient(parentNamespace, configMap, secret, insecureSkipVerify)
 		if downloadErr != nil {
 			klog.Error(downloadErr, " - Failed to create httpClient")
 			return downloadErr
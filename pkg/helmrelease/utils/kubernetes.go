@@ -24,6 +24,10 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/secretprovider"
 )
 
 // GetAccessToken retrieve the accessToken
@@ -130,3 +134,29 @@ func GetSecret(client client.Client, parentNamespace string, secretRef *corev1.O
 
 	return secret, err
 }
+
+// GetSecretProviderSecret returns the secret of the external secret manager annotations of the HelmRelease, nil if
+// the HelmRelease has no secret provider. The annotations are ignored if they were not signed by the subscriber for the
+// repo of the HelmRelease, the credentials of the application manager are only sent to the repo of the channel.
+func GetSecretProviderSecret(hr *appv1.HelmRelease) (*corev1.Secret, error) {
+	ref, err := secretprovider.GetReference(hr.GetAnnotations())
+	if err != nil || ref == nil {
+		return nil, err
+	}
+
+	if !utils.IsHelmReleaseSecretProviderSigned(hr) {
+		klog.Warningf("The secret provider annotations of the HelmRelease %v/%v were not set by the subscriber, ignored",
+			hr.Namespace, hr.Name)
+
+		return nil, nil
+	}
+
+	data, err := secretprovider.Resolve(context.TODO(), ref)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.Info("Secret resolved from the secret provider ", ref.String())
+
+	return &corev1.Secret{Data: data}, nil
+}
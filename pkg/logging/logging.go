@@ -0,0 +1,192 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	klogv2 "k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// FormatText is the default klog text log format
+	FormatText = "text"
+	// FormatJSON writes one JSON object per log entry
+	FormatJSON = "json"
+)
+
+// Setup sets the log format of the controller-runtime, klog and klog/v2 loggers. The klog/v2 verbosity is set to the
+// klog -v flag, so the structured logs of the controllers follow the verbosity of their text logs.
+// With the JSON format, the structured klog/v2 entries and their keys and values are written by the zap logger.
+func Setup(format string) error {
+	verbosity := klogVerbosity()
+
+	if err := setKlogV2Verbosity(verbosity); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+
+		return nil
+	case FormatJSON:
+	default:
+		return fmt.Errorf("unsupported log format %v, the supported formats are %v and %v", format, FormatText, FormatJSON)
+	}
+
+	logger := zap.New(zap.UseDevMode(false), zap.Level(zapcore.Level(-int8(verbosity))))
+
+	ctrl.SetLogger(logger)
+	klogv2.SetLogger(logger)
+
+	// klog writes all the entries to the info output, the other outputs are discarded to avoid duplicates
+	for _, flagName := range []string{"logtostderr", "alsologtostderr"} {
+		if err := flag.Set(flagName, "false"); err != nil {
+			return err
+		}
+	}
+
+	// a threshold above the fatal severity disables the klog stderr output
+	if err := flag.Set("stderrthreshold", "4"); err != nil {
+		return err
+	}
+
+	klog.SetOutputBySeverity("FATAL", io.Discard)
+	klog.SetOutputBySeverity("ERROR", io.Discard)
+	klog.SetOutputBySeverity("WARNING", io.Discard)
+	klog.SetOutputBySeverity("INFO", &klogWriter{logger: logger.WithName("klog")})
+
+	return nil
+}
+
+// ForSubscription returns the structured logger of the reconcile of a subscription, see WithSubscription
+func ForSubscription(obj metav1.Object) logr.Logger {
+	return WithSubscription(klogv2.Background(), obj)
+}
+
+// WithSubscription adds the subscription to the keys and values of the logger. The verbose logs up to the log level
+// annotation of the subscription are written regardless of the -v flag, to debug a single subscription without raising
+// the verbosity of the whole controller.
+func WithSubscription(logger logr.Logger, obj metav1.Object) logr.Logger {
+	if obj == nil {
+		return logger
+	}
+
+	logger = logger.WithValues("subscription", klogv2.KObj(obj))
+
+	level, err := strconv.Atoi(obj.GetAnnotations()[appv1.AnnotationLogLevel])
+	if err != nil || level <= 0 || logger.GetSink() == nil {
+		return logger
+	}
+
+	sink := logger.GetSink()
+
+	// the level sink adds a call frame
+	if callDepthSink, ok := sink.(logr.CallDepthLogSink); ok {
+		sink = callDepthSink.WithCallDepth(1)
+	}
+
+	return logr.New(&levelSink{LogSink: sink, level: level})
+}
+
+// levelSink writes the verbose entries up to its level, regardless of the level of the sink it wraps. They are written
+// as info entries with their verbosity in the "v" key.
+type levelSink struct {
+	logr.LogSink
+
+	level int
+}
+
+// Init is a no-op, the wrapped sink is already initialized by its logger
+func (s *levelSink) Init(logr.RuntimeInfo) {}
+
+func (s *levelSink) Enabled(level int) bool {
+	return level <= s.level || s.LogSink.Enabled(level)
+}
+
+func (s *levelSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	if s.LogSink.Enabled(level) {
+		s.LogSink.Info(level, msg, keysAndValues...)
+
+		return
+	}
+
+	s.LogSink.Info(0, msg, append(keysAndValues[:len(keysAndValues):len(keysAndValues)], "v", level)...)
+}
+
+func (s *levelSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &levelSink{LogSink: s.LogSink.WithValues(keysAndValues...), level: s.level}
+}
+
+func (s *levelSink) WithName(name string) logr.LogSink {
+	return &levelSink{LogSink: s.LogSink.WithName(name), level: s.level}
+}
+
+func (s *levelSink) WithCallDepth(depth int) logr.LogSink {
+	callDepthSink, ok := s.LogSink.(logr.CallDepthLogSink)
+	if !ok {
+		return s
+	}
+
+	return &levelSink{LogSink: callDepthSink.WithCallDepth(depth), level: s.level}
+}
+
+// klogVerbosity returns the klog -v flag value
+func klogVerbosity() int {
+	f := flag.Lookup("v")
+	if f == nil {
+		return 0
+	}
+
+	v, err := strconv.Atoi(f.Value.String())
+	if err != nil {
+		return 0
+	}
+
+	return v
+}
+
+// setKlogV2Verbosity sets the verbosity of the klog/v2 structured logs, its flags are not registered by the commands
+func setKlogV2Verbosity(verbosity int) error {
+	fs := flag.NewFlagSet("klog/v2", flag.ContinueOnError)
+	klogv2.InitFlags(fs)
+
+	return fs.Set("v", strconv.Itoa(verbosity))
+}
+
+// klogWriter writes the entries of the klog calls that are not structured to a logr logger, the text entry is the
+// message of the logr entry
+type klogWriter struct {
+	logger logr.Logger
+}
+
+func (w *klogWriter) Write(p []byte) (int, error) {
+	w.logger.Info(strings.TrimSuffix(string(p), "\n"))
+
+	return len(p), nil
+}
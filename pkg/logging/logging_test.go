@@ -0,0 +1,77 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestKlogWriter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	entries := []string{}
+	logger := funcr.New(func(prefix, args string) { entries = append(entries, args) }, funcr.Options{})
+
+	w := &klogWriter{logger: logger}
+
+	// the text entries of the klog calls are not parsed
+	_, err := w.Write([]byte("E1016 12:00:00.000000   12345 subscription_controller.go:268] doReconcile got error: failed\n"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(entries).To(gomega.ConsistOf(
+		`"level"=0 "msg"="E1016 12:00:00.000000   12345 subscription_controller.go:268] doReconcile got error: failed"`))
+
+	g.Expect(Setup("yaml")).NotTo(gomega.Succeed())
+}
+
+func TestSubscriptionLogLevel(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	entries := []string{}
+	logger := funcr.New(func(prefix, args string) { entries = append(entries, args) }, funcr.Options{Verbosity: 1})
+
+	log := func(sub *appv1.Subscription, level int) []string {
+		entries = []string{}
+
+		WithSubscription(logger, sub).V(level).Info("reconcile", "key", "value")
+
+		return entries
+	}
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "default"}}
+	g.Expect(log(sub, 1)).To(gomega.ConsistOf(
+		`"level"=1 "msg"="reconcile" "subscription"={"name"="sub" "namespace"="default"} "key"="value"`))
+	g.Expect(log(sub, 4)).To(gomega.BeEmpty())
+
+	// the verbose logs up to the annotation level are written with their level
+	sub.SetAnnotations(map[string]string{appv1.AnnotationLogLevel: "4"})
+	g.Expect(log(sub, 1)).To(gomega.HaveLen(1))
+	g.Expect(log(sub, 4)).To(gomega.ConsistOf(
+		`"level"=0 "msg"="reconcile" "subscription"={"name"="sub" "namespace"="default"} "key"="value" "v"=4`))
+	g.Expect(log(sub, 5)).To(gomega.BeEmpty())
+
+	g.Expect(WithSubscription(logger, sub).WithName("hub").WithValues("cluster", "cluster1").V(4).Enabled()).To(gomega.BeTrue())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationLogLevel: "debug"})
+	g.Expect(log(sub, 4)).To(gomega.BeEmpty())
+
+	g.Expect(WithSubscription(logr.Discard(), nil).V(4).Enabled()).To(gomega.BeFalse())
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var ChannelConnected = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "channel_connected",
+	Help: "1 if the last health check of the channel connection and credentials succeeded, 0 otherwise",
+}, []string{LabelChannelNamespace, LabelChannelName, LabelChannelType})
+
+var ChannelHealthCheckFailedCount = *prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "channel_health_check_failed_count",
+	Help: "Counter of failed channel health checks",
+}, []string{LabelChannelType, LabelReason})
+
+func init() {
+	CollectorsForRegistration = append(CollectorsForRegistration, ChannelConnected, ChannelHealthCheckFailedCount)
+}
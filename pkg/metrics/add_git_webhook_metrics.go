@@ -0,0 +1,26 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var GitWebhookTriggerCount = *prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "git_webhook_trigger_count",
+	Help: "Counter of the Git webhook events of the subscriptions, triggered or coalesced into a scheduled reconcile",
+}, []string{LabelResult})
+
+func init() {
+	CollectorsForRegistration = append(CollectorsForRegistration, GitWebhookTriggerCount)
+}
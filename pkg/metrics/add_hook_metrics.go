@@ -0,0 +1,26 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var HookJobTime = *prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "hook_job_time",
+	Help: "Histogram of completed prehook and posthook ansible job latency",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName, LabelHookType})
+
+func init() {
+	CollectorsForRegistration = append(CollectorsForRegistration, HookJobTime)
+}
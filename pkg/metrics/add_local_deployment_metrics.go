@@ -14,7 +14,11 @@
 
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/profiling"
+)
 
 var LocalDeploymentSuccessfulPullTime = *prometheus.NewHistogramVec(prometheus.HistogramOpts{
 	Name: "local_deployment_successful_time",
@@ -26,6 +30,44 @@ var LocalDeploymentFailedPullTime = *prometheus.NewHistogramVec(prometheus.Histo
 	Help: "Histogram of failed local deployment latency",
 }, []string{LabelSubscriptionNameSpace, LabelSubscriptionName})
 
+var LocalDeploymentResourceCount = *prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "local_deployment_resource_count",
+	Help: "Counter of resources applied, failed and pruned by the local deployment",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName, LabelResult})
+
+var LocalDeploymentPhaseTime = *prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "local_deployment_phase_time",
+	Help: "Histogram of local deployment latency per reconcile phase",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName, LabelPhase})
+
+var LocalDeploymentConcurrentReconciles = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "local_deployment_concurrent_reconciles",
+	Help: "Number of subscriptions being reconciled concurrently by the local deployment",
+}, []string{LabelChannelType})
+
+var LocalDeploymentQueuedReconciles = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "local_deployment_queued_reconciles",
+	Help: "Number of subscriptions waiting for the limit of concurrent reconciles of the local deployment",
+}, []string{LabelChannelType})
+
 func init() {
-	CollectorsForRegistration = append(CollectorsForRegistration, LocalDeploymentSuccessfulPullTime, LocalDeploymentFailedPullTime)
+	CollectorsForRegistration = append(CollectorsForRegistration, LocalDeploymentSuccessfulPullTime, LocalDeploymentFailedPullTime,
+		LocalDeploymentResourceCount, LocalDeploymentPhaseTime, LocalDeploymentConcurrentReconciles,
+		LocalDeploymentQueuedReconciles)
+}
+
+// TrackConcurrentReconcile counts a reconcile of the local deployment in LocalDeploymentConcurrentReconciles until
+// the returned function is called
+func TrackConcurrentReconcile(channelType string) func() {
+	gauge := LocalDeploymentConcurrentReconciles.WithLabelValues(channelType)
+	gauge.Inc()
+
+	return gauge.Dec
+}
+
+// ObserveLocalDeploymentPhase observes the duration of a reconcile phase of a subscription in
+// LocalDeploymentPhaseTime and in the reconcile profile of the subscription
+func ObserveLocalDeploymentPhase(namespace, name, phase string, milliseconds int64) {
+	LocalDeploymentPhaseTime.WithLabelValues(namespace, name, phase).Observe(float64(milliseconds))
+	profiling.ObservePhase(namespace, name, phase, milliseconds)
 }
@@ -0,0 +1,52 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var PlacementRuleDecisionCount = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "placementrule_decision_count",
+	Help: "Number of managed clusters in the placementRule decisions",
+}, []string{LabelPlacementRuleNS, LabelPlacementRuleName})
+
+var PlacementRuleSchedulingTime = *prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "placementrule_scheduling_time",
+	Help: "Histogram of placementRule scheduling latency",
+}, []string{LabelPlacementRuleNS, LabelPlacementRuleName})
+
+var PlacementRuleFilteredClusterCount = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "placementrule_filtered_cluster_count",
+	Help: "Number of managed clusters filtered out by the last placementRule scheduling",
+}, []string{LabelPlacementRuleNS, LabelPlacementRuleName, LabelReason})
+
+var PlacementRuleDecisionChangeCount = *prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "placementrule_decision_change_count",
+	Help: "Counter of managed clusters added to or removed from the placementRule decisions",
+}, []string{LabelPlacementRuleNS, LabelPlacementRuleName})
+
+func init() {
+	CollectorsForRegistration = append(CollectorsForRegistration, PlacementRuleDecisionCount, PlacementRuleSchedulingTime,
+		PlacementRuleFilteredClusterCount, PlacementRuleDecisionChangeCount)
+}
+
+// DeletePlacementRuleMetrics removes the metrics series of a deleted placementRule
+func DeletePlacementRuleMetrics(namespace, name string) {
+	labels := prometheus.Labels{LabelPlacementRuleNS: namespace, LabelPlacementRuleName: name}
+
+	PlacementRuleDecisionCount.DeletePartialMatch(labels)
+	PlacementRuleSchedulingTime.DeletePartialMatch(labels)
+	PlacementRuleFilteredClusterCount.DeletePartialMatch(labels)
+	PlacementRuleDecisionChangeCount.DeletePartialMatch(labels)
+}
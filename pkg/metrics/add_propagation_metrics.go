@@ -26,6 +26,22 @@ var PropagationFailedPullTime = *prometheus.NewHistogramVec(prometheus.Histogram
 	Help: "Histogram of failed propagation latency",
 }, []string{LabelSubscriptionNameSpace, LabelSubscriptionName})
 
+var PropagationClusterRetryCount = *prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "propagation_cluster_retry_count",
+	Help: "Counter of deployment retries requeued by the hub for failed managed clusters",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName})
+
+var PropagationManifestWorkTime = *prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "propagation_manifestwork_time",
+	Help: "Histogram of manifestWork propagation latency",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName})
+
+var PropagationClusterDeployedRatio = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "propagation_cluster_deployed_ratio",
+	Help: "Ratio of the targeted managed clusters where the subscription is deployed successfully",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName})
+
 func init() {
-	CollectorsForRegistration = append(CollectorsForRegistration, PropagationSuccessfulPullTime, PropagationFailedPullTime)
+	CollectorsForRegistration = append(CollectorsForRegistration, PropagationSuccessfulPullTime, PropagationFailedPullTime,
+		PropagationClusterRetryCount, PropagationManifestWorkTime, PropagationClusterDeployedRatio)
 }
@@ -0,0 +1,55 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var TimeWindowBlocked = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "subscription_time_window_blocked",
+	Help: "1 if the subscription deployment is blocked by its time window, 0 otherwise",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName})
+
+var TimeWindowNextStart = *prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "subscription_time_window_next_start_timestamp_seconds",
+	Help: "Unix time the next time window of a blocked subscription starts, 0 if the subscription is not blocked",
+}, []string{LabelSubscriptionNameSpace, LabelSubscriptionName})
+
+func init() {
+	CollectorsForRegistration = append(CollectorsForRegistration, TimeWindowBlocked, TimeWindowNextStart)
+}
+
+// SetTimeWindowStatus sets the time window metrics of a subscription, next is the time remaining until the next
+// time window starts, 0 if the subscription is not blocked
+func SetTimeWindowStatus(namespace, name string, next time.Duration, now time.Time) {
+	if next <= 0 {
+		TimeWindowBlocked.WithLabelValues(namespace, name).Set(0)
+		TimeWindowNextStart.WithLabelValues(namespace, name).Set(0)
+
+		return
+	}
+
+	TimeWindowBlocked.WithLabelValues(namespace, name).Set(1)
+	TimeWindowNextStart.WithLabelValues(namespace, name).Set(float64(now.Add(next).Unix()))
+}
+
+// DeleteTimeWindowMetrics removes the time window metrics series of a deleted subscription
+func DeleteTimeWindowMetrics(namespace, name string) {
+	TimeWindowBlocked.DeleteLabelValues(namespace, name)
+	TimeWindowNextStart.DeleteLabelValues(namespace, name)
+}
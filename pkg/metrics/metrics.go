@@ -23,6 +23,59 @@ const (
 	// Vector label keys
 	LabelSubscriptionNameSpace = "subscription_namespace"
 	LabelSubscriptionName      = "subscription_name"
+	LabelPhase                 = "phase"
+	LabelResult                = "result"
+	LabelHookType              = "hook_type"
+	LabelPlacementRuleNS       = "placementrule_namespace"
+	LabelPlacementRuleName     = "placementrule_name"
+	LabelReason                = "reason"
+	LabelChannelType           = "channel_type"
+	LabelKind                  = "kind"
+	LabelChannelNamespace      = "channel_namespace"
+	LabelChannelName           = "channel_name"
+
+	// Reconcile phases of the git subscriber
+	PhaseClone     = "clone"
+	PhaseSort      = "sort"
+	PhaseKustomize = "kustomize"
+	PhaseHelm      = "helm"
+	PhaseApply     = "apply"
+
+	// Channel types of the local deployment subscribers
+	ChannelTypeGit          = "git"
+	ChannelTypeHelmRepo     = "helmrepo"
+	ChannelTypeObjectBucket = "objectbucket"
+
+	// Results of the resources processed by the synchronizer
+	ResultApplied  = "applied"
+	ResultFailed   = "failed"
+	ResultPruned   = "pruned"
+	ResultSkipped  = "skipped"
+	ResultRetained = "retained"
+
+	// Reasons of the clusters filtered out by the placementRule scheduling
+	ReasonClusterConditions = "cluster_conditions"
+	ReasonUserPermission    = "user_permission"
+	ReasonRequiredAddOns    = "required_addons"
+	ReasonDecisionWebhook   = "decision_webhook"
+	ReasonClusterReplicas   = "cluster_replicas"
+
+	// Kinds of the stale objects removed by the hub janitor
+	KindSubscriptionStatus       = "subscriptionstatus"
+	KindSubscriptionReport       = "subscriptionreport"
+	KindSubscriptionReportResult = "subscriptionreport_result"
+
+	// Reasons of the stale objects removed by the hub janitor
+	ReasonSubscriptionNotFound = "subscription_not_found"
+	ReasonClusterNotFound      = "cluster_not_found"
+
+	// Reasons of the failed channel health checks
+	ReasonAuthenticationFailed = "authentication_failed"
+	ReasonConnectionFailed     = "connection_failed"
+
+	// Results of the Git webhook events
+	ResultTriggered = "triggered"
+	ResultCoalesced = "coalesced"
 )
 
 var CollectorsForRegistration []prometheus.Collector
@@ -0,0 +1,285 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"k8s.io/klog"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// DecisionWebhookFailurePolicyFail keeps the previous decisions of a placementrule if the decision webhook fails
+	DecisionWebhookFailurePolicyFail = "Fail"
+	// DecisionWebhookFailurePolicyIgnore makes the decisions of a placementrule without the decision webhook if it fails
+	DecisionWebhookFailurePolicyIgnore = "Ignore"
+
+	// DefaultDecisionWebhookTimeout is the default timeout of the decision webhook calls
+	DefaultDecisionWebhookTimeout = 10 * time.Second
+
+	maxDecisionWebhookResponseSize = 1 << 20
+)
+
+// DecisionWebhookConfig is the configuration of the decision webhook
+type DecisionWebhookConfig struct {
+	// URL of the decision webhook, the webhook is disabled if it is empty
+	URL string
+	// CAFile is the PEM file of the CA certificates of the webhook server, the system CAs are used if it is empty
+	CAFile string
+	// Timeout of a webhook call
+	Timeout time.Duration
+	// FailurePolicy is Fail or Ignore
+	FailurePolicy string
+}
+
+// DecisionWebhookRequest is the body of the request posted to the decision webhook
+type DecisionWebhookRequest struct {
+	Namespace       string                   `json:"namespace"`
+	Name            string                   `json:"name"`
+	ClusterReplicas *int32                   `json:"clusterReplicas,omitempty"`
+	Clusters        []DecisionWebhookCluster `json:"clusters"`
+}
+
+// DecisionWebhookCluster is a candidate cluster of the decision webhook request
+type DecisionWebhookCluster struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// DecisionWebhookResponse is the body of the decision webhook response
+type DecisionWebhookResponse struct {
+	// Clusters are the allowed candidate clusters in the preferred order, the candidate clusters not listed are
+	// vetoed. The candidate clusters are kept in their order if it is missing.
+	Clusters []string `json:"clusters"`
+}
+
+type decisionWebhook struct {
+	url           string
+	failurePolicy string
+	client        *http.Client
+}
+
+// activeDecisionWebhook is the decision webhook called by the placementrule controller, nil if there is no webhook
+var activeDecisionWebhook *decisionWebhook
+
+// SetDecisionWebhook sets the webhook called to veto or reorder the candidate clusters of the placementrules before
+// their decisions are made
+func SetDecisionWebhook(config DecisionWebhookConfig) error {
+	if config.URL == "" {
+		activeDecisionWebhook = nil
+
+		return nil
+	}
+
+	switch config.FailurePolicy {
+	case "":
+		config.FailurePolicy = DecisionWebhookFailurePolicyFail
+	case DecisionWebhookFailurePolicyFail, DecisionWebhookFailurePolicyIgnore:
+	default:
+		return fmt.Errorf("invalid decision webhook failure policy %v, it must be %v or %v", config.FailurePolicy,
+			DecisionWebhookFailurePolicyFail, DecisionWebhookFailurePolicyIgnore)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultDecisionWebhookTimeout
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: appv1.TLSMinVersionInt, // #nosec G402 -- TLS 1.2 is required for FIPS
+	}
+
+	if config.CAFile != "" {
+		caCert, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the decision webhook CA file %v, error: %w", config.CAFile, err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return fmt.Errorf("no certificate found in the decision webhook CA file %v", config.CAFile)
+		}
+	}
+
+	activeDecisionWebhook = &decisionWebhook{
+		url:           config.URL,
+		failurePolicy: config.FailurePolicy,
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+	}
+
+	klog.Infof("placementrule decision webhook: %v, timeout: %v, failure policy: %v", config.URL, config.Timeout,
+		config.FailurePolicy)
+
+	return nil
+}
+
+// filterClustersByWebhook calls the decision webhook with the candidate clusters. The vetoed clusters are removed from
+// clmap, and the returned index has the order of the webhook response. It returns clidx if there is no webhook, or if
+// the webhook fails with the Ignore failure policy.
+func filterClustersByWebhook(instance *appv1alpha1.PlacementRule, clmap map[string]*spokeClusterV1.ManagedCluster,
+	clidx *clusterIndex) (*clusterIndex, error) {
+	wh := activeDecisionWebhook
+	if wh == nil || instance == nil || len(clmap) == 0 {
+		return clidx, nil
+	}
+
+	candidates := candidateClusters(instance, clmap, clidx)
+
+	allowed, err := wh.call(instance, clmap, candidates)
+	if err != nil {
+		if wh.failurePolicy == DecisionWebhookFailurePolicyIgnore {
+			klog.Warningf("decision webhook failed, ignored, placementrule: %v/%v, error: %v", instance.Namespace,
+				instance.Name, err)
+
+			return clidx, nil
+		}
+
+		return nil, fmt.Errorf("decision webhook failed, placementrule: %v/%v, error: %w", instance.Namespace,
+			instance.Name, err)
+	}
+
+	if allowed == nil {
+		return clidx, nil
+	}
+
+	newidx := &clusterIndex{}
+	picked := map[string]bool{}
+
+	for _, name := range allowed {
+		if _, ok := clmap[name]; !ok || picked[name] {
+			continue
+		}
+
+		picked[name] = true
+
+		newidx.Clusters = append(newidx.Clusters, clusterInfo{Name: name, Namespace: name})
+	}
+
+	for name := range clmap {
+		if !picked[name] {
+			klog.Infof("cluster %v vetoed by the decision webhook, placementrule: %v/%v", name, instance.Namespace,
+				instance.Name)
+
+			delete(clmap, name)
+		}
+	}
+
+	return newidx, nil
+}
+
+// candidateClusters returns the candidate clusters in the order they are picked without the webhook: the order of
+// the resource hint, or the current decisions first then by name
+func candidateClusters(instance *appv1alpha1.PlacementRule, clmap map[string]*spokeClusterV1.ManagedCluster,
+	clidx *clusterIndex) []string {
+	candidates := []string{}
+	listed := map[string]bool{}
+
+	if clidx != nil {
+		for _, cli := range clidx.Clusters {
+			if _, ok := clmap[cli.Name]; ok && !listed[cli.Name] {
+				candidates = append(candidates, cli.Name)
+				listed[cli.Name] = true
+			}
+		}
+
+		return candidates
+	}
+
+	for _, decision := range instance.Status.Decisions {
+		if _, ok := clmap[decision.ClusterName]; ok && !listed[decision.ClusterName] {
+			candidates = append(candidates, decision.ClusterName)
+			listed[decision.ClusterName] = true
+		}
+	}
+
+	others := []string{}
+
+	for name := range clmap {
+		if !listed[name] {
+			others = append(others, name)
+		}
+	}
+
+	sort.Strings(others)
+
+	return append(candidates, others...)
+}
+
+// call posts the candidate clusters to the webhook and returns the allowed clusters, nil if the response keeps the
+// candidates
+func (wh *decisionWebhook) call(instance *appv1alpha1.PlacementRule, clmap map[string]*spokeClusterV1.ManagedCluster,
+	candidates []string) ([]string, error) {
+	req := DecisionWebhookRequest{
+		Namespace:       instance.Namespace,
+		Name:            instance.Name,
+		ClusterReplicas: instance.Spec.ClusterReplicas,
+		Clusters:        make([]DecisionWebhookCluster, 0, len(candidates)),
+	}
+
+	for _, name := range candidates {
+		req.Clusters = append(req.Clusters, DecisionWebhookCluster{Name: name, Labels: clmap[name].GetLabels()})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := wh.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxDecisionWebhookResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	whResp := &DecisionWebhookResponse{}
+	if err := json.Unmarshal(respBody, whResp); err != nil {
+		return nil, fmt.Errorf("invalid response, error: %w", err)
+	}
+
+	return whResp.Clusters, nil
+}
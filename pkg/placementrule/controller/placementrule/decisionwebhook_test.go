@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+)
+
+func TestDecisionWebhook(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer func() { activeDecisionWebhook = nil }()
+
+	var received DecisionWebhookRequest
+
+	response := `{"clusters": ["cluster3", "cluster1", "unknown"]}`
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(json.NewDecoder(r.Body).Decode(&received)).To(gomega.Succeed())
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	newClusters := func() map[string]*spokeClusterV1.ManagedCluster {
+		clmap := map[string]*spokeClusterV1.ManagedCluster{}
+		for _, name := range []string{"cluster1", "cluster2", "cluster3"} {
+			clmap[name] = &spokeClusterV1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"name": name}},
+			}
+		}
+
+		return clmap
+	}
+
+	replicas := int32(1)
+	instance := &appv1alpha1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: prulename, Namespace: prulens},
+		Spec: appv1alpha1.PlacementRuleSpec{
+			ClusterReplicas: &replicas,
+		},
+		Status: appv1alpha1.PlacementRuleStatus{
+			Decisions: []appv1alpha1.PlacementDecision{{ClusterName: "cluster2", ClusterNamespace: "cluster2"}},
+		},
+	}
+
+	// no webhook
+	clmap := newClusters()
+	clidx, err := filterClustersByWebhook(instance, clmap, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(clidx).To(gomega.BeNil())
+	g.Expect(clmap).To(gomega.HaveLen(3))
+
+	g.Expect(SetDecisionWebhook(DecisionWebhookConfig{URL: server.URL, FailurePolicy: "Retry"})).NotTo(gomega.Succeed())
+	g.Expect(SetDecisionWebhook(DecisionWebhookConfig{URL: server.URL, Timeout: time.Second})).To(gomega.Succeed())
+	g.Expect(activeDecisionWebhook.failurePolicy).To(gomega.Equal(DecisionWebhookFailurePolicyFail))
+
+	// the current decisions are the first candidates, cluster2 is vetoed and the clusters are reordered
+	clidx, err = filterClustersByWebhook(instance, clmap, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(received.Namespace).To(gomega.Equal(prulens))
+	g.Expect(received.Name).To(gomega.Equal(prulename))
+	g.Expect(*received.ClusterReplicas).To(gomega.Equal(replicas))
+	g.Expect(received.Clusters).To(gomega.Equal([]DecisionWebhookCluster{
+		{Name: "cluster2", Labels: map[string]string{"name": "cluster2"}},
+		{Name: "cluster1", Labels: map[string]string{"name": "cluster1"}},
+		{Name: "cluster3", Labels: map[string]string{"name": "cluster3"}},
+	}))
+	g.Expect(clmap).To(gomega.HaveLen(2))
+	g.Expect(clmap).NotTo(gomega.HaveKey("cluster2"))
+
+	r := &ReconcilePlacementRule{}
+	g.Expect(r.pickClustersByReplicas(instance, clmap, clidx)).To(gomega.Equal([]appv1alpha1.PlacementDecision{
+		{ClusterName: "cluster3", ClusterNamespace: "cluster3"},
+	}))
+
+	// the candidates are kept without clusters in the response
+	response = `{}`
+	clmap = newClusters()
+	clidx, err = filterClustersByWebhook(instance, clmap, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(clidx).To(gomega.BeNil())
+	g.Expect(clmap).To(gomega.HaveLen(3))
+
+	// all the clusters are vetoed with an empty list
+	response = `{"clusters": []}`
+	clmap = newClusters()
+	_, err = filterClustersByWebhook(instance, clmap, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(clmap).To(gomega.BeEmpty())
+
+	// fail closed
+	status = http.StatusInternalServerError
+	clmap = newClusters()
+	_, err = filterClustersByWebhook(instance, clmap, nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// fail open
+	g.Expect(SetDecisionWebhook(DecisionWebhookConfig{URL: server.URL,
+		FailurePolicy: DecisionWebhookFailurePolicyIgnore})).To(gomega.Succeed())
+
+	clidx, err = filterClustersByWebhook(instance, clmap, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(clidx).To(gomega.BeNil())
+	g.Expect(clmap).To(gomega.HaveLen(3))
+}
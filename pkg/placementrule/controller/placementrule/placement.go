@@ -23,23 +23,35 @@ import (
 	"strings"
 
 	cbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	addonV1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/placementrule/utils"
 )
 
-func (r *ReconcilePlacementRule) hubReconcile(instance *appv1alpha1.PlacementRule) error {
+// hubReconcile sets the new decisions of the placementrule. It returns the reason each selected cluster missing from
+// the new decisions is filtered out.
+func (r *ReconcilePlacementRule) hubReconcile(instance *appv1alpha1.PlacementRule) (map[string]string, error) {
+	filtered := map[string]string{}
+
 	// Return zero cluster decision if ClusterReplicas is set to 0, thus don't need to go through other filters for better performance
 	if instance.Spec.ClusterReplicas != nil {
 		total := int(*instance.Spec.ClusterReplicas)
 		if total == 0 {
+			for _, decision := range instance.Status.Decisions {
+				filtered[decision.ClusterName] = appv1alpha1.DecisionReasonClusterReplicas
+			}
+
 			instance.Status.Decisions = []appv1alpha1.PlacementDecision{}
 
-			return nil
+			return filtered, nil
 		}
 	}
 
@@ -47,39 +59,118 @@ func (r *ReconcilePlacementRule) hubReconcile(instance *appv1alpha1.PlacementRul
 	if err != nil {
 		klog.Error("Error in preparing clusters by status:", err)
 
-		return err
+		return nil, err
 	}
 
+	selected := len(clmap)
+	candidates := clusterNames(clmap)
+
 	err = r.filteClustersByStatus(instance, clmap /* , clstatusmap */)
 	if err != nil {
 		klog.Error("Error in filtering clusters by status:", err)
 
-		return err
+		return nil, err
 	}
 
+	recordFilteredClusters(instance, metrics.ReasonClusterConditions, selected-len(clmap))
+	selected = len(clmap)
+	candidates = setFilteredReason(filtered, candidates, clmap, appv1alpha1.DecisionReasonClusterConditions)
+
 	err = r.filteClustersByUser(instance, clmap)
 	if err != nil {
 		klog.Error("Error in filtering clusters by user Identity:", err)
 
-		return err
+		return nil, err
 	}
 
+	recordFilteredClusters(instance, metrics.ReasonUserPermission, selected-len(clmap))
+	selected = len(clmap)
+	candidates = setFilteredReason(filtered, candidates, clmap, appv1alpha1.DecisionReasonUserPermission)
+
+	err = r.filteClustersByAddOns(instance, clmap)
+	if err != nil {
+		klog.Error("Error in filtering clusters by required addons:", err)
+
+		return nil, err
+	}
+
+	recordFilteredClusters(instance, metrics.ReasonRequiredAddOns, selected-len(clmap))
+	selected = len(clmap)
+	candidates = setFilteredReason(filtered, candidates, clmap, appv1alpha1.DecisionReasonRequiredAddOns)
+
 	err = r.filteClustersByPolicies(instance, clmap /* , clstatusmap */)
 	if err != nil {
 		klog.Error("Error in filtering clusters by policy:", err)
 
-		return err
+		return nil, err
 	}
 
+	candidates = setFilteredReason(filtered, candidates, clmap, appv1alpha1.DecisionReasonPolicies)
+
 	// go without mcm repositories, removed identity check
 
 	clidx := r.sortClustersByResourceHint(instance, clmap /* , clstatusmap */)
 
+	clidx, err = filterClustersByWebhook(instance, clmap, clidx)
+	if err != nil {
+		klog.Error("Error in filtering clusters by decision webhook:", err)
+
+		return nil, err
+	}
+
+	recordFilteredClusters(instance, metrics.ReasonDecisionWebhook, selected-len(clmap))
+	selected = len(clmap)
+
+	setFilteredReason(filtered, candidates, clmap, appv1alpha1.DecisionReasonDecisionWebhook)
+
 	newpd := r.pickClustersByReplicas(instance, clmap, clidx)
 
+	recordFilteredClusters(instance, metrics.ReasonClusterReplicas, selected-len(newpd))
+
+	picked := map[string]bool{}
+	for _, decision := range newpd {
+		picked[decision.ClusterName] = true
+	}
+
+	for name := range clmap {
+		if !picked[name] {
+			filtered[name] = appv1alpha1.DecisionReasonClusterReplicas
+		}
+	}
+
 	instance.Status.Decisions = newpd
 
-	return nil
+	return filtered, nil
+}
+
+// clusterNames returns the names of the clusters of clmap
+func clusterNames(clmap map[string]*spokeClusterV1.ManagedCluster) map[string]bool {
+	names := make(map[string]bool, len(clmap))
+	for name := range clmap {
+		names[name] = true
+	}
+
+	return names
+}
+
+// setFilteredReason sets the reason of the candidate clusters filtered out of clmap by a scheduling step, and
+// returns the remaining candidates
+func setFilteredReason(filtered map[string]string, candidates map[string]bool,
+	clmap map[string]*spokeClusterV1.ManagedCluster, reason string) map[string]bool {
+	for name := range candidates {
+		if _, ok := clmap[name]; !ok {
+			filtered[name] = reason
+		}
+	}
+
+	return clusterNames(clmap)
+}
+
+// recordFilteredClusters sets the number of clusters filtered out by one of the scheduling steps
+func recordFilteredClusters(instance *appv1alpha1.PlacementRule, reason string, filtered int) {
+	metrics.PlacementRuleFilteredClusterCount.
+		WithLabelValues(instance.GetNamespace(), instance.GetName(), reason).
+		Set(float64(filtered))
 }
 
 func (r *ReconcilePlacementRule) filteClustersByStatus(instance *appv1alpha1.PlacementRule, clmap map[string]*spokeClusterV1.ManagedCluster) error {
@@ -115,6 +206,51 @@ func (r *ReconcilePlacementRule) filteClustersByStatus(instance *appv1alpha1.Pla
 	return nil
 }
 
+// filteClustersByAddOns removes the clusters where one of the required addons is not Available
+func (r *ReconcilePlacementRule) filteClustersByAddOns(instance *appv1alpha1.PlacementRule,
+	clmap map[string]*spokeClusterV1.ManagedCluster) error {
+	if instance == nil || len(instance.Spec.RequiredAddOns) == 0 || clmap == nil {
+		return nil
+	}
+
+	for k := range clmap {
+		for _, addOnName := range instance.Spec.RequiredAddOns {
+			available, err := r.isAddOnAvailable(k, addOnName)
+			if err != nil {
+				return err
+			}
+
+			if !available {
+				klog.Infof("cluster %v filtered out, its addon %v is not available, placementrule: %v/%v", k, addOnName,
+					instance.Namespace, instance.Name)
+
+				delete(clmap, k)
+
+				break
+			}
+		}
+	}
+
+	klog.Infof("Required AddOns Check done, placementrule: %v/%v ", instance.Namespace, instance.Name)
+
+	return nil
+}
+
+// isAddOnAvailable returns true if the ManagedClusterAddOn of the cluster has the Available condition
+func (r *ReconcilePlacementRule) isAddOnAvailable(cluster, addOnName string) (bool, error) {
+	addOn := &addonV1alpha1.ManagedClusterAddOn{}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: cluster, Name: addOnName}, addOn); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return meta.IsStatusConditionTrue(addOn.Status.Conditions, addonV1alpha1.ManagedClusterAddOnConditionAvailable), nil
+}
+
 type clusterInfo struct {
 	Name      string
 	Namespace string
@@ -16,13 +16,20 @@ package placementrule
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	addonV1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/placementrule/utils"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
@@ -52,7 +59,12 @@ func Add(mgr manager.Manager) error {
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 	authCfg := mgr.GetConfig()
 
-	return &ReconcilePlacementRule{Client: mgr.GetClient(), scheme: mgr.GetScheme(), authConfig: authCfg}
+	erecorder, err := utils.NewEventRecorder(authCfg, mgr.GetScheme())
+	if err != nil {
+		klog.Error("Failed to create the event recorder of the placementrule controller, error: ", err)
+	}
+
+	return &ReconcilePlacementRule{Client: mgr.GetClient(), scheme: mgr.GetScheme(), authConfig: authCfg, eventRecorder: erecorder}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -97,6 +109,22 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		}
 	}
 
+	if utils.IsReadyManagedClusterAddOnAPI(mgr.GetAPIReader()) {
+		addOnMapper := &AddOnPlacementRuleMapper{mgr.GetClient()}
+		err = c.Watch(
+			source.Kind(
+				mgr.GetCache(),
+				&addonV1alpha1.ManagedClusterAddOn{},
+				handler.TypedEnqueueRequestsFromMapFunc(addOnMapper.Map),
+				utils.AddOnPredicateFunc,
+			),
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -105,8 +133,9 @@ var _ reconcile.Reconciler = &ReconcilePlacementRule{}
 // ReconcilePlacementRule reconciles a PlacementRule object
 type ReconcilePlacementRule struct {
 	client.Client
-	authConfig *rest.Config
-	scheme     *runtime.Scheme
+	authConfig    *rest.Config
+	scheme        *runtime.Scheme
+	eventRecorder *utils.EventRecorder
 }
 
 // ClusterPlacementRuleMapper is defined for PlacementRule to watch clusters
@@ -146,6 +175,43 @@ func (mapper *ClusterPlacementRuleMapper) Map(ctx context.Context, obj *spokeClu
 	return requests
 }
 
+// AddOnPlacementRuleMapper is defined for PlacementRule to watch the ManagedClusterAddOns
+type AddOnPlacementRuleMapper struct {
+	client.Client
+}
+
+// Map triggers the placementRules requiring the addon.
+func (mapper *AddOnPlacementRuleMapper) Map(ctx context.Context, obj *addonV1alpha1.ManagedClusterAddOn) []reconcile.Request {
+	plList := &appv1alpha1.PlacementRuleList{}
+
+	if err := mapper.List(ctx, plList); err != nil {
+		klog.Error("Failed to list placement rules in addon mapper with err:", err)
+
+		return nil
+	}
+
+	var requests []reconcile.Request
+
+	for _, pl := range plList.Items {
+		for _, addOnName := range pl.Spec.RequiredAddOns {
+			if addOnName == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: pl.GetName(), Namespace: pl.GetNamespace()},
+				})
+
+				break
+			}
+		}
+	}
+
+	if len(requests) > 0 {
+		klog.Infof("%d placementRules triggered due to the availability change of addon %v/%v", len(requests),
+			obj.GetNamespace(), obj.GetName())
+	}
+
+	return requests
+}
+
 // PolicyPlacementRuleMapper is defined for PlacementRule to watch policies
 type PolicyPlacementRuleMapper struct {
 	client.Client
@@ -168,6 +234,8 @@ func (r *ReconcilePlacementRule) Reconcile(ctx context.Context, request reconcil
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			metrics.DeletePlacementRuleMetrics(request.Namespace, request.Name)
+
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -187,11 +255,22 @@ func (r *ReconcilePlacementRule) Reconcile(ctx context.Context, request reconcil
 		return reconcile.Result{}, nil
 	}
 
-	err = r.hubReconcile(instance)
+	startTime := time.Now().UnixMilli()
+
+	filtered, err := r.hubReconcile(instance)
+
+	metrics.PlacementRuleSchedulingTime.
+		WithLabelValues(instance.GetNamespace(), instance.GetName()).
+		Observe(float64(time.Now().UnixMilli() - startTime))
+
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
+	metrics.PlacementRuleDecisionCount.
+		WithLabelValues(instance.GetNamespace(), instance.GetName()).
+		Set(float64(len(instance.Status.Decisions)))
+
 	updated := false
 
 	klog.Infof("orgDecisions: %v", orgDecisions)
@@ -221,6 +300,19 @@ func (r *ReconcilePlacementRule) Reconcile(ctx context.Context, request reconcil
 		updated = true
 	}
 
+	changes := decisionChanges(orgDecisions, instance.Status.Decisions, filtered, metav1.Now())
+	if len(changes) > 0 {
+		instance.Status.DecisionHistory = appendDecisionHistory(instance.Status.DecisionHistory, changes)
+
+		updated = true
+	}
+
+	if instance.Status.ObservedGeneration != instance.Generation {
+		instance.Status.ObservedGeneration = instance.Generation
+
+		updated = true
+	}
+
 	// reconcile finished check if need to upadte the resource
 	if updated {
 		klog.Info("Update placementrule ", instance.Name, " with decisions: ", instance.Status.Decisions)
@@ -233,11 +325,110 @@ func (r *ReconcilePlacementRule) Reconcile(ctx context.Context, request reconcil
 		}
 	}
 
+	// count the decision churn once the new decisions are saved
+	if churn := decisionChurn(orgDecisions, instance.Status.Decisions); churn > 0 {
+		metrics.PlacementRuleDecisionChangeCount.
+			WithLabelValues(instance.GetNamespace(), instance.GetName()).
+			Add(float64(churn))
+	}
+
+	if len(changes) > 0 && r.eventRecorder != nil {
+		r.eventRecorder.RecordEvent(instance, utils.EventReasonDecisionsChanged, decisionChangesMessage(changes), nil)
+	}
+
 	klog.Info("Reconciling - finished.", request.NamespacedName)
 
 	return reconcile.Result{}, nil
 }
 
+// decisionChurn returns the number of clusters added to or removed from the decisions
+func decisionChurn(orgDecisions, newDecisions []appv1alpha1.PlacementDecision) int {
+	orgClusters := make(map[string]bool, len(orgDecisions))
+	for _, d := range orgDecisions {
+		orgClusters[d.ClusterName] = true
+	}
+
+	churn := 0
+
+	for _, d := range newDecisions {
+		if orgClusters[d.ClusterName] {
+			delete(orgClusters, d.ClusterName)
+		} else {
+			churn++
+		}
+	}
+
+	return churn + len(orgClusters)
+}
+
+// decisionChanges returns the clusters removed from the decisions, then the clusters added to the decisions. The
+// reason of a removed cluster is its reason in filtered, ClusterNotSelected if it is not there.
+func decisionChanges(orgDecisions, newDecisions []appv1alpha1.PlacementDecision, filtered map[string]string,
+	now metav1.Time) []appv1alpha1.DecisionChange {
+	orgClusters := make(map[string]bool, len(orgDecisions))
+	for _, d := range orgDecisions {
+		orgClusters[d.ClusterName] = true
+	}
+
+	newClusters := make(map[string]bool, len(newDecisions))
+	for _, d := range newDecisions {
+		newClusters[d.ClusterName] = true
+	}
+
+	removed := []appv1alpha1.DecisionChange{}
+
+	for cluster := range orgClusters {
+		if newClusters[cluster] {
+			continue
+		}
+
+		reason, ok := filtered[cluster]
+		if !ok {
+			reason = appv1alpha1.DecisionReasonClusterNotSelected
+		}
+
+		removed = append(removed, appv1alpha1.DecisionChange{
+			ClusterName: cluster, Type: appv1alpha1.DecisionChangeRemoved, Reason: reason, Time: now})
+	}
+
+	added := []appv1alpha1.DecisionChange{}
+
+	for cluster := range newClusters {
+		if !orgClusters[cluster] {
+			added = append(added, appv1alpha1.DecisionChange{
+				ClusterName: cluster, Type: appv1alpha1.DecisionChangeAdded,
+				Reason: appv1alpha1.DecisionReasonClusterSelected, Time: now})
+		}
+	}
+
+	sort.Slice(removed, func(i, j int) bool { return removed[i].ClusterName < removed[j].ClusterName })
+	sort.Slice(added, func(i, j int) bool { return added[i].ClusterName < added[j].ClusterName })
+
+	return append(removed, added...)
+}
+
+// appendDecisionHistory appends the changes to the history and keeps the last MaxDecisionHistory changes
+func appendDecisionHistory(history, changes []appv1alpha1.DecisionChange) []appv1alpha1.DecisionChange {
+	history = append(history, changes...)
+
+	if len(history) > appv1alpha1.MaxDecisionHistory {
+		history = history[len(history)-appv1alpha1.MaxDecisionHistory:]
+	}
+
+	return history
+}
+
+// decisionChangesMessage returns the event message of the decision changes
+func decisionChangesMessage(changes []appv1alpha1.DecisionChange) string {
+	msgs := make([]string, 0, len(changes))
+	for _, change := range changes {
+		msgs = append(msgs, fmt.Sprintf("%s cluster %s (%s)", strings.ToLower(string(change.Type)), change.ClusterName,
+			change.Reason))
+	}
+
+	return "Decisions changed: " + strings.Join(msgs, ", ")
+}
+
 func (r *ReconcilePlacementRule) UpdateStatus(instance *appv1alpha1.PlacementRule) error {
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		return r.Status().Update(context.TODO(), instance)
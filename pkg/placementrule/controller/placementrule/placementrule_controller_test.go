@@ -22,13 +22,17 @@ import (
 	"golang.org/x/net/context"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	addonV1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
@@ -543,3 +547,120 @@ func TestPredicate(t *testing.T) {
 	ret = instance.Delete(deleteEvt)
 	g.Expect(ret).To(gomega.BeTrue())
 }
+
+func TestDecisionChurn(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	orgDecisions := []appv1alpha1.PlacementDecision{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+	}
+
+	g.Expect(decisionChurn(orgDecisions, orgDecisions)).To(gomega.Equal(0))
+	g.Expect(decisionChurn(nil, orgDecisions)).To(gomega.Equal(2))
+	g.Expect(decisionChurn(orgDecisions, nil)).To(gomega.Equal(2))
+
+	newDecisions := []appv1alpha1.PlacementDecision{
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+		{ClusterName: "cluster3", ClusterNamespace: "cluster3"},
+	}
+
+	g.Expect(decisionChurn(orgDecisions, newDecisions)).To(gomega.Equal(2))
+}
+
+func TestDecisionHistory(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := metav1.Now()
+
+	orgDecisions := []appv1alpha1.PlacementDecision{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+		{ClusterName: "cluster3", ClusterNamespace: "cluster3"},
+	}
+
+	newDecisions := []appv1alpha1.PlacementDecision{
+		{ClusterName: "cluster3", ClusterNamespace: "cluster3"},
+		{ClusterName: "cluster4", ClusterNamespace: "cluster4"},
+	}
+
+	g.Expect(decisionChanges(orgDecisions, orgDecisions, nil, now)).To(gomega.BeEmpty())
+
+	// the removed clusters without a filter reason are no longer selected
+	changes := decisionChanges(orgDecisions, newDecisions,
+		map[string]string{"cluster2": appv1alpha1.DecisionReasonClusterConditions}, now)
+	g.Expect(changes).To(gomega.Equal([]appv1alpha1.DecisionChange{
+		{ClusterName: "cluster1", Type: appv1alpha1.DecisionChangeRemoved, Reason: appv1alpha1.DecisionReasonClusterNotSelected, Time: now},
+		{ClusterName: "cluster2", Type: appv1alpha1.DecisionChangeRemoved, Reason: appv1alpha1.DecisionReasonClusterConditions, Time: now},
+		{ClusterName: "cluster4", Type: appv1alpha1.DecisionChangeAdded, Reason: appv1alpha1.DecisionReasonClusterSelected, Time: now},
+	}))
+
+	g.Expect(decisionChangesMessage(changes)).To(gomega.Equal("Decisions changed: removed cluster cluster1 (ClusterNotSelected), " +
+		"removed cluster cluster2 (ClusterConditions), added cluster cluster4 (ClusterSelected)"))
+
+	// the history keeps the last changes
+	history := []appv1alpha1.DecisionChange{}
+	for i := 0; i < appv1alpha1.MaxDecisionHistory; i++ {
+		history = appendDecisionHistory(history, changes[:1])
+	}
+
+	history = appendDecisionHistory(history, changes[1:])
+	g.Expect(history).To(gomega.HaveLen(appv1alpha1.MaxDecisionHistory))
+	g.Expect(history[appv1alpha1.MaxDecisionHistory-1]).To(gomega.Equal(changes[2]))
+	g.Expect(history[appv1alpha1.MaxDecisionHistory-2]).To(gomega.Equal(changes[1]))
+	g.Expect(history[0]).To(gomega.Equal(changes[0]))
+}
+
+func TestFilterClustersByAddOns(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(apis.AddToScheme(scheme)).To(gomega.Succeed())
+
+	newAddOn := func(cluster, name string, available metav1.ConditionStatus) *addonV1alpha1.ManagedClusterAddOn {
+		return &addonV1alpha1.ManagedClusterAddOn{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster},
+			Status: addonV1alpha1.ManagedClusterAddOnStatus{
+				Conditions: []metav1.Condition{{
+					Type:   addonV1alpha1.ManagedClusterAddOnConditionAvailable,
+					Status: available,
+					Reason: "Test",
+				}},
+			},
+		}
+	}
+
+	instance := &appv1alpha1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: prulename, Namespace: prulens},
+		Spec: appv1alpha1.PlacementRuleSpec{
+			RequiredAddOns: []string{"application-manager", "cert-manager"},
+		},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newAddOn("cluster1", "application-manager", metav1.ConditionTrue),
+		newAddOn("cluster1", "cert-manager", metav1.ConditionTrue),
+		newAddOn("cluster2", "application-manager", metav1.ConditionTrue),
+		newAddOn("cluster2", "cert-manager", metav1.ConditionFalse),
+		newAddOn("cluster3", "application-manager", metav1.ConditionTrue),
+		instance,
+	).Build()
+
+	r := &ReconcilePlacementRule{Client: clt, scheme: scheme}
+
+	clmap := map[string]*spokeClusterV1.ManagedCluster{}
+	for _, name := range []string{"cluster1", "cluster2", "cluster3"} {
+		clmap[name] = &spokeClusterV1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	// cluster2 has an unavailable addon, cluster3 is missing an addon
+	g.Expect(r.filteClustersByAddOns(instance, clmap)).To(gomega.Succeed())
+	g.Expect(clmap).To(gomega.HaveLen(1))
+	g.Expect(clmap).To(gomega.HaveKey("cluster1"))
+
+	// the placementRules requiring an addon are reconciled when its availability changes
+	mapper := &AddOnPlacementRuleMapper{clt}
+	g.Expect(mapper.Map(context.TODO(), newAddOn("cluster2", "cert-manager", metav1.ConditionTrue))).To(
+		gomega.Equal([]reconcile.Request{{NamespacedName: prulekey}}))
+	g.Expect(mapper.Map(context.TODO(), newAddOn("cluster2", "other", metav1.ConditionTrue))).To(gomega.BeEmpty())
+}
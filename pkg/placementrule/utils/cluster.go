@@ -20,7 +20,9 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonV1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -103,6 +105,15 @@ var PlacementDecisionPredicateFunc = predicate.TypedFuncs[*clusterv1beta1.Placem
 	},
 }
 
+// AddOnPredicateFunc defines predicate function for the ManagedClusterAddOn watch, it ignores the updates that don't
+// change the addon availability
+var AddOnPredicateFunc = predicate.TypedFuncs[*addonV1alpha1.ManagedClusterAddOn]{
+	UpdateFunc: func(e event.TypedUpdateEvent[*addonV1alpha1.ManagedClusterAddOn]) bool {
+		return meta.IsStatusConditionTrue(e.ObjectOld.Status.Conditions, addonV1alpha1.ManagedClusterAddOnConditionAvailable) !=
+			meta.IsStatusConditionTrue(e.ObjectNew.Status.Conditions, addonV1alpha1.ManagedClusterAddOnConditionAvailable)
+	},
+}
+
 // AcmClusterSecretPredicateFunc defines predicate function for ACM cluster secrets watch
 var AcmClusterSecretPredicateFunc = predicate.TypedFuncs[*v1.Secret]{
 	UpdateFunc: func(e event.TypedUpdateEvent[*v1.Secret]) bool {
@@ -58,6 +58,10 @@ func EnterFnString() string {
 // ExitFuString - called when exiting a function
 func ExitFuString(s string) {}
 
+// EventReasonDecisionsChanged is the reason of the event recorded when clusters are added to or removed from the
+// placementrule decisions
+const EventReasonDecisionsChanged = "DecisionsChanged"
+
 // EventRecorder - record kubernetes event
 type EventRecorder struct {
 	record.EventRecorder
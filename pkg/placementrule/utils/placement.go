@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
+	addonV1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -132,6 +133,19 @@ func IsReadyACMClusterRegistry(clReader client.Reader) bool {
 	return false
 }
 
+// IsReadyManagedClusterAddOnAPI check if the ManagedClusterAddOn API is served or not.
+func IsReadyManagedClusterAddOnAPI(clReader client.Reader) bool {
+	addOnList := &addonV1alpha1.ManagedClusterAddOnList{}
+
+	if err := clReader.List(context.TODO(), addOnList, client.Limit(1)); err != nil {
+		klog.Info("ManagedClusterAddOn API NOT ready: ", err)
+
+		return false
+	}
+
+	return true
+}
+
 // DetectClusterRegistry - Detect the ACM cluster API service every 10 seconds. the controller will be exited when it is ready
 // The controller will be auto restarted by the multicluster-operators-application deployment CR later.
 //
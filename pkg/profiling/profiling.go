@@ -0,0 +1,220 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiling
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+// SubscriptionsPath is the path of the endpoint listing the reconcile profiles of the subscriptions
+const SubscriptionsPath = "/debug/subscriptions"
+
+// Profile is the duration of the phases of the last reconcile of a subscription
+type Profile struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// StartTime is the start time of the last reconcile
+	StartTime time.Time `json:"startTime"`
+
+	// Phases are the durations in milliseconds of the reconcile phases, like clone, sort, kustomize, helm and apply
+	Phases map[string]int64 `json:"phases"`
+
+	// Total is the sum of the phase durations in milliseconds
+	Total int64 `json:"total"`
+}
+
+// String returns the phase durations of the profile, the longest first
+func (p Profile) String() string {
+	phases := make([]string, 0, len(p.Phases))
+	for phase := range p.Phases {
+		phases = append(phases, phase)
+	}
+
+	sort.Slice(phases, func(i, j int) bool {
+		if p.Phases[phases[i]] != p.Phases[phases[j]] {
+			return p.Phases[phases[i]] > p.Phases[phases[j]]
+		}
+
+		return phases[i] < phases[j]
+	})
+
+	durations := make([]string, 0, len(phases)+1)
+	for _, phase := range phases {
+		durations = append(durations, fmt.Sprintf("%s=%dms", phase, p.Phases[phase]))
+	}
+
+	durations = append(durations, fmt.Sprintf("total=%dms", p.Total))
+
+	return strings.Join(durations, " ")
+}
+
+// Recorder keeps the profile of the last reconcile of each subscription
+type Recorder struct {
+	lock     sync.Mutex
+	profiles map[string]*Profile
+	now      func() time.Time
+}
+
+var defaultRecorder = NewRecorder()
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{profiles: map[string]*Profile{}, now: time.Now}
+}
+
+// Start resets the profile of a subscription of the default recorder at the start of a reconcile
+func Start(namespace, name string) {
+	defaultRecorder.Start(namespace, name)
+}
+
+// ObservePhase adds the duration of a reconcile phase to the profile of a subscription of the default recorder
+func ObservePhase(namespace, name, phase string, milliseconds int64) {
+	defaultRecorder.ObservePhase(namespace, name, phase, milliseconds)
+}
+
+// Get returns the profile of a subscription of the default recorder
+func Get(namespace, name string) (Profile, bool) {
+	return defaultRecorder.Get(namespace, name)
+}
+
+// Delete removes the profile of a subscription from the default recorder
+func Delete(namespace, name string) {
+	defaultRecorder.Delete(namespace, name)
+}
+
+// NewServeMux returns a mux serving the Go pprof profiles under /debug/pprof/ and the reconcile profiles of the
+// default recorder under /debug/subscriptions
+func NewServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle(SubscriptionsPath, defaultRecorder)
+
+	return mux
+}
+
+// Start resets the profile of a subscription at the start of a reconcile
+func (r *Recorder) Start(namespace, name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.profiles[namespace+"/"+name] = &Profile{
+		Namespace: namespace,
+		Name:      name,
+		StartTime: r.now(),
+		Phases:    map[string]int64{},
+	}
+}
+
+// ObservePhase adds the duration of a reconcile phase to the profile of a subscription. The durations of a phase
+// run several times in a reconcile are summed.
+func (r *Recorder) ObservePhase(namespace, name, phase string, milliseconds int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	profile, ok := r.profiles[namespace+"/"+name]
+	if !ok {
+		profile = &Profile{
+			Namespace: namespace,
+			Name:      name,
+			StartTime: r.now(),
+			Phases:    map[string]int64{},
+		}
+		r.profiles[namespace+"/"+name] = profile
+	}
+
+	profile.Phases[phase] += milliseconds
+	profile.Total += milliseconds
+}
+
+// Get returns a copy of the profile of a subscription
+func (r *Recorder) Get(namespace, name string) (Profile, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	profile, ok := r.profiles[namespace+"/"+name]
+	if !ok {
+		return Profile{}, false
+	}
+
+	return profile.copy(), true
+}
+
+// Delete removes the profile of a subscription
+func (r *Recorder) Delete(namespace, name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.profiles, namespace+"/"+name)
+}
+
+// List returns a copy of the profiles of the subscriptions of the namespace, of all the namespaces if it is empty,
+// the slowest first
+func (r *Recorder) List(namespace string) []Profile {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	profiles := []Profile{}
+
+	for _, profile := range r.profiles {
+		if namespace == "" || profile.Namespace == namespace {
+			profiles = append(profiles, profile.copy())
+		}
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Total != profiles[j].Total {
+			return profiles[i].Total > profiles[j].Total
+		}
+
+		return profiles[i].Namespace+"/"+profiles[i].Name < profiles[j].Namespace+"/"+profiles[j].Name
+	})
+
+	return profiles
+}
+
+// ServeHTTP writes the profiles of the subscriptions in JSON, the slowest first. The namespace query parameter
+// filters the subscriptions.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(r.List(req.URL.Query().Get("namespace"))); err != nil {
+		klog.Error("failed to write the subscription reconcile profiles, error: ", err)
+	}
+}
+
+func (p *Profile) copy() Profile {
+	profile := *p
+	profile.Phases = make(map[string]int64, len(p.Phases))
+
+	for phase, duration := range p.Phases {
+		profile.Phases[phase] = duration
+	}
+
+	return profile
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profiling
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestRecorder(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	r := NewRecorder()
+	r.now = func() time.Time { return now }
+
+	r.Start("ns", "git-sub")
+	r.ObservePhase("ns", "git-sub", "clone", 1200)
+	r.ObservePhase("ns", "git-sub", "kustomize", 300)
+	r.ObservePhase("ns", "git-sub", "kustomize", 200)
+	r.ObservePhase("ns", "git-sub", "apply", 100)
+
+	// a phase observed before the start of a reconcile creates the profile
+	r.ObservePhase("other", "helm-sub", "apply", 2000)
+
+	profile, ok := r.Get("ns", "git-sub")
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(profile.StartTime).To(gomega.Equal(now))
+	g.Expect(profile.Phases).To(gomega.Equal(map[string]int64{"clone": 1200, "kustomize": 500, "apply": 100}))
+	g.Expect(profile.Total).To(gomega.Equal(int64(1800)))
+	g.Expect(profile.String()).To(gomega.Equal("clone=1200ms kustomize=500ms apply=100ms total=1800ms"))
+
+	// the profile is a copy
+	profile.Phases["clone"] = 0
+	profile, _ = r.Get("ns", "git-sub")
+	g.Expect(profile.Phases["clone"]).To(gomega.Equal(int64(1200)))
+
+	g.Expect(r.List("")).To(gomega.HaveLen(2))
+	g.Expect(r.List("")[0].Name).To(gomega.Equal("helm-sub"))
+	g.Expect(r.List("ns")).To(gomega.HaveLen(1))
+
+	// a new reconcile resets the profile
+	now = now.Add(time.Minute)
+	r.Start("ns", "git-sub")
+
+	profile, _ = r.Get("ns", "git-sub")
+	g.Expect(profile.StartTime).To(gomega.Equal(now))
+	g.Expect(profile.Phases).To(gomega.BeEmpty())
+	g.Expect(profile.Total).To(gomega.BeZero())
+
+	r.Delete("ns", "git-sub")
+
+	_, ok = r.Get("ns", "git-sub")
+	g.Expect(ok).To(gomega.BeFalse())
+}
+
+func TestRecorderServeHTTP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := NewRecorder()
+	r.ObservePhase("ns", "git-sub", "clone", 1200)
+	r.ObservePhase("other", "helm-sub", "apply", 2000)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, SubscriptionsPath+"?namespace=ns", nil))
+
+	g.Expect(rec.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(rec.Header().Get("Content-Type")).To(gomega.Equal("application/json"))
+
+	profiles := []Profile{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &profiles)).To(gomega.Succeed())
+	g.Expect(profiles).To(gomega.HaveLen(1))
+	g.Expect(profiles[0].Name).To(gomega.Equal("git-sub"))
+	g.Expect(profiles[0].Phases).To(gomega.Equal(map[string]int64{"clone": 1200}))
+
+	// the pprof index is served with the profiles
+	rec = httptest.NewRecorder()
+	NewServeMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	g.Expect(rec.Code).To(gomega.Equal(http.StatusOK))
+}
@@ -17,7 +17,6 @@ package git
 import (
 	"errors"
 	"strings"
-	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -28,6 +27,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/features"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/profiling"
 	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
@@ -45,6 +46,8 @@ type SyncSource interface {
 		map[string]map[string]string, map[string]map[string]string, bool, bool) error
 	PurgeAllSubscribedResources(*appv1.Subscription) error
 	UpdateAppsubOverallStatus(*appv1.Subscription, bool, string) error
+	RecordEvent(*appv1.Subscription, string, string, error)
+	ScanImages(*appv1.Subscription) []string
 }
 
 // Subscriber - information to run namespace subscription
@@ -53,6 +56,9 @@ type Subscriber struct {
 	manager      manager.Manager
 	synchronizer SyncSource
 	syncinterval int
+	states       *utils.SubscriberStateStore
+	// gitFolderPrefix is the directory, relative to the temporary directory, the Git repos are cloned under
+	gitFolderPrefix string
 }
 
 var defaultSubscriber *Subscriber
@@ -109,6 +115,20 @@ func (ghs *Subscriber) SubscribeItem(subitem *appv1.SubscriberItem) error {
 		ghssubitem = &SubscriberItem{}
 		ghssubitem.syncinterval = ghs.syncinterval
 		ghssubitem.synchronizer = ghs.synchronizer
+		ghssubitem.states = ghs.states
+		ghssubitem.gitFolderPrefix = ghs.gitFolderPrefix
+
+		// restore the state persisted before the agent restart, so the resources already deployed are not applied again
+		if state, found := ghs.states.Get(itemkey); found && state.Successful {
+			klog.Infof("Restored the state of SubscriberItem %v, commit: %v", itemkey, state.Commit)
+
+			ghssubitem.commitID = state.Commit
+			ghssubitem.resourceHash = state.ResourceHash
+			ghssubitem.successful = true
+			ghssubitem.restored = true
+		}
+
+		ghssubitem.delayFirstReconcile = ghssubitem.restored || utils.IsSubscriptionDeployed(subitem.Subscription)
 	}
 
 	subitem.DeepCopyInto(&ghssubitem.SubscriberItem)
@@ -117,12 +137,16 @@ func (ghs *Subscriber) SubscribeItem(subitem *appv1.SubscriberItem) error {
 
 	previousReconcileLevel := ghssubitem.reconcileRate
 
+	previousReconcileInterval := ghssubitem.reconcileInterval
+
 	previousDesiredCommit := ghssubitem.desiredCommit
 
 	previousDesiredTag := ghssubitem.desiredTag
 
 	previousSyncTime := ghssubitem.syncTime
 
+	previousReferencesVersion := ghssubitem.referencesVersion
+
 	chnAnnotations := ghssubitem.Channel.GetAnnotations()
 
 	subAnnotations := ghssubitem.Subscription.GetAnnotations()
@@ -152,19 +176,34 @@ func (ghs *Subscriber) SubscribeItem(subitem *appv1.SubscriberItem) error {
 	ghssubitem.desiredCommit = subAnnotations[appv1.AnnotationGitTargetCommit]
 	ghssubitem.desiredTag = subAnnotations[appv1.AnnotationGitTag]
 	ghssubitem.syncTime = subAnnotations[appv1.AnnotationManualReconcileTime]
+	ghssubitem.referencesVersion = utils.GetChannelReferencesVersion(subitem)
 	ghssubitem.userID = strings.Trim(subAnnotations[appv1.AnnotationUserIdentity], "")
 	ghssubitem.userGroup = strings.Trim(subAnnotations[appv1.AnnotationUserGroup], "")
 
+	ghssubitem.webhookEnabled = strings.EqualFold(ghssubitem.Channel.GetAnnotations()[appv1.AnnotationWebhookEnabled], "true")
+
+	if ghssubitem.restored && syncOnStart && !ghssubitem.isReconciledPeriodically() {
+		klog.Infof("SubscriberItem %v is not reconciled periodically, apply its resources again after the agent restart", itemkey)
+
+		ghssubitem.restored = false
+	}
+
 	// If the channel has annotation webhookenabled="true", do not poll the repo.
 	// Do subscription only on webhook events.
-	if strings.EqualFold(ghssubitem.Channel.GetAnnotations()[appv1.AnnotationWebhookEnabled], "true") {
+	if ghssubitem.webhookEnabled {
 		klog.Info("Webhook enabled on SubscriberItem ", ghssubitem.Subscription.Name)
-		ghssubitem.webhookEnabled = true
-		// Set successful to false so that the subscription keeps trying until all resources are successfully
-		// applied until the next webhook event.
+		// Set successful to false so that the subscription is applied for the webhook event, it is retried in the
+		// background if it fails.
 		ghssubitem.successful = false
 
-		ghssubitem.doSubscriptionWithRetries(time.Hour*3, 10)
+		ghssubitem.stopRetries()
+		ghssubitem.doSubscriptionWithRetries(0, 0)
+
+		if !ghssubitem.successful {
+			ghssubitem.startRetries()
+		}
+
+		ghssubitem.startImageScans()
 
 		klog.Info("Webhook event processed")
 
@@ -172,7 +211,6 @@ func (ghs *Subscriber) SubscribeItem(subitem *appv1.SubscriberItem) error {
 	}
 
 	klog.Info("Polling enabled on SubscriberItem ", ghssubitem.Subscription.Name)
-	ghssubitem.webhookEnabled = false
 
 	var restart = false
 
@@ -190,6 +228,16 @@ func (ghs *Subscriber) SubscribeItem(subitem *appv1.SubscriberItem) error {
 		restart = true
 	}
 
+	ghssubitem.reconcileInterval, _, _ = utils.GetSubscriptionReconcileInterval(ghssubitem.reconcileRate, ghssubitem.Channel,
+		ghssubitem.Subscription)
+
+	if previousReconcileInterval != 0 && previousReconcileInterval != ghssubitem.reconcileInterval {
+		klog.Infof("reconcile interval has changed from %v to %v. restart to reconcile resources", previousReconcileInterval,
+			ghssubitem.reconcileInterval)
+
+		restart = true
+	}
+
 	// If desired commit or tag has changed, we want to restart the reconcile cycle and deploy the new commit immediately
 	if !strings.EqualFold(previousDesiredCommit, ghssubitem.desiredCommit) {
 		klog.Infof("desired commit hash has changed from %s to %s. restart to reconcile resources", previousDesiredCommit, ghssubitem.desiredCommit)
@@ -214,7 +262,15 @@ func (ghs *Subscriber) SubscribeItem(subitem *appv1.SubscriberItem) error {
 		restart = true
 	}
 
+	// If the channel credentials have changed, we want to restart the reconcile cycle with them immediately
+	if previousReferencesVersion != "" && previousReferencesVersion != ghssubitem.referencesVersion {
+		klog.Infof("channel references of SubscriberItem %v have changed. restart to reconcile resources", itemkey)
+
+		restart = true
+	}
+
 	ghssubitem.Start(restart)
+	ghssubitem.startImageScans()
 
 	return nil
 }
@@ -229,6 +285,13 @@ func (ghs *Subscriber) UnsubscribeItem(key types.NamespacedName) error {
 		subitem.Stop()
 		delete(ghs.itemmap, key)
 
+		if err := ghs.states.Delete(key); err != nil {
+			klog.Errorf("failed to delete the subscriber state of %v, err: %v", key.String(), err)
+		}
+
+		profiling.Delete(key.Namespace, key.Name)
+		utils.DeleteServingChannel(key)
+
 		if err := ghs.synchronizer.PurgeAllSubscribedResources(subitem.Subscription); err != nil {
 			klog.Errorf("failed to unsubscribe  %v, err: %v", key.String(), err)
 
@@ -261,5 +324,15 @@ func CreateGitHubSubscriber(config *rest.Config, scheme *runtime.Scheme, mgr man
 	githubsubscriber.itemmap = make(map[types.NamespacedName]*SubscriberItem)
 	githubsubscriber.syncinterval = syncinterval
 
+	if features.DefaultMutableFeatureGate.Enabled(features.PersistSubscriberState) {
+		githubsubscriber.states = utils.NewSubscriberStateStore(kubesync.GetLocalNonCachedClient())
+	}
+
 	return githubsubscriber
 }
+
+// SetLocalGitFolderPrefix sets the directory, relative to the temporary directory, the Git repos of the subscriber are
+// cloned under
+func (ghs *Subscriber) SetLocalGitFolderPrefix(prefix string) {
+	ghs.gitFolderPrefix = prefix
+}
@@ -18,7 +18,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -32,13 +31,19 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 
 	corev1 "k8s.io/api/core/v1"
 
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/features"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/health"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/profiling"
 	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/tracing"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
 
@@ -66,17 +71,25 @@ type SubscriberItem struct {
 	rbacFiles              []string
 	otherFiles             []string
 	repoRoot               string
+	gitFolderPrefix        string
+	fSys                   filesys.FileSystem
 	commitID               string
+	sourceURL              string // the URL of the Git repo the commit was cloned from
 	reconcileRate          string
+	reconcileInterval      time.Duration
 	desiredCommit          string
 	desiredTag             string
 	syncTime               string
+	referencesVersion      string
 	stopch                 chan struct{}
+	retrych                chan struct{}
+	scanch                 chan struct{}
 	syncinterval           int
 	count                  int
 	synchronizer           SyncSource
 	chartDirs              map[string]string
 	kustomizeDirs          map[string]string
+	kustomizeOptions       *utils.KustomizeBuildOptions
 	resources              []kubesynchronizer.ResourceUnit
 	indexFile              *repo.IndexFile
 	webhookEnabled         bool
@@ -85,6 +98,10 @@ type SubscriberItem struct {
 	currentNamespaceScoped bool
 	userID                 string
 	userGroup              string
+	states                 *utils.SubscriberStateStore
+	resourceHash           string
+	restored               bool
+	delayFirstReconcile    bool
 }
 
 type kubeResource struct {
@@ -110,17 +127,45 @@ func (ghsi *SubscriberItem) Start(restart bool) {
 
 	ghsi.stopch = make(chan struct{})
 
-	loopPeriod, retryInterval, retries := utils.GetReconcileInterval(ghsi.reconcileRate, chnv1.ChannelTypeGit)
+	loopPeriod, retryInterval, retries := utils.GetSubscriptionReconcileInterval(ghsi.reconcileRate, ghsi.Channel, ghsi.Subscription)
 
 	if strings.EqualFold(ghsi.reconcileRate, "off") {
 		klog.Infof("auto-reconcile is OFF")
 
+		ghsi.stopRetries()
 		ghsi.doSubscriptionWithRetries(retryInterval, retries)
 
+		if !ghsi.successful {
+			ghsi.startRetries()
+		}
+
 		return
 	}
 
-	go wait.Until(func() {
+	stopch := ghsi.stopch
+	startDelay := time.Duration(0)
+
+	// spread the first reconcile of the subscriber items already deployed before the agent started across their
+	// reconcile interval, so they don't all clone their repo at the same time
+	if ghsi.delayFirstReconcile {
+		startDelay = utils.GetReconcileStartDelay(types.NamespacedName{Namespace: ghsi.Subscription.Namespace,
+			Name: ghsi.Subscription.Name}, loopPeriod)
+		ghsi.delayFirstReconcile = false
+	}
+
+	go wait.JitterUntil(func() {
+		if startDelay > 0 {
+			klog.Infof("Delaying the first reconcile of the SubscriberItem %v by %v", ghsi.Subscription.Name, startDelay)
+
+			select {
+			case <-time.After(startDelay):
+			case <-stopch:
+				return
+			}
+
+			startDelay = 0
+		}
+
 		tw := ghsi.SubscriberItem.Subscription.Spec.TimeWindow
 		if tw != nil {
 			nextRun := utils.NextStartPoint(tw, time.Now())
@@ -141,13 +186,20 @@ func (ghsi *SubscriberItem) Start(restart bool) {
 		}
 
 		ghsi.doSubscriptionWithRetries(retryInterval, retries)
-	}, loopPeriod, ghsi.stopch)
+	}, loopPeriod, utils.ReconcileJitterFactor, true, stopch)
 }
 
 // Stop unsubscribes a subscriber item with namespace channel
 func (ghsi *SubscriberItem) Stop() {
 	klog.Info("Stopping SubscriberItem ", ghsi.Subscription.Name)
-	close(ghsi.stopch)
+
+	ghsi.stopRetries()
+	ghsi.stopImageScans()
+
+	// the subscriber items of the webhook enabled channels are never started
+	if ghsi.stopch != nil {
+		close(ghsi.stopch)
+	}
 }
 
 func (ghsi *SubscriberItem) doSubscriptionWithRetries(retryInterval time.Duration, retries int) {
@@ -156,6 +208,13 @@ func (ghsi *SubscriberItem) doSubscriptionWithRetries(retryInterval time.Duratio
 		klog.Infof("Try #%d/%d: subcribing to the Git repo", n, retries)
 
 		err := ghsi.doSubscription()
+		if errors.Is(err, kubesynchronizer.ErrShuttingDown) {
+			klog.Infof("appsub (%s/%s) is reconciled again after the agent restarts", ghsi.Subscription.Namespace,
+				ghsi.Subscription.Name)
+
+			return
+		}
+
 		if err != nil {
 			klog.Error(err, "Subscription error.")
 			klog.Infof("mark appsub (%s/%s) as failed with reason: %v", ghsi.Subscription.Namespace, ghsi.Subscription.Name, err.Error())
@@ -183,6 +242,12 @@ func (ghsi *SubscriberItem) doSubscription() error {
 	klog.Info("enter doSubscription: ", hostkey.String())
 
 	defer klog.Info("exit doSubscription: ", hostkey.String())
+	defer utils.AcquireReconcileSlot(metrics.ChannelTypeGit)()
+	defer health.Track(health.OperationSubscribe, hostkey.Namespace, hostkey.Name)()
+
+	// the state restored after an agent restart is only used to skip the first apply
+	restored := ghsi.restored
+	ghsi.restored = false
 
 	utils.UpdateLastUpdateTime(ghsi.synchronizer.GetLocalClient(), ghsi.Subscription)
 
@@ -200,6 +265,18 @@ func (ghsi *SubscriberItem) doSubscription() error {
 
 	klog.Info("Subscribing ...", ghsi.Subscription.Name)
 
+	profiling.Start(hostkey.Namespace, hostkey.Name)
+
+	// the phase durations of the reconcile are recorded in an event for the subscriptions being profiled
+	if strings.EqualFold(ghsi.Subscription.GetAnnotations()[appv1.AnnotationReconcileProfile], "true") {
+		defer func() {
+			if profile, ok := profiling.Get(hostkey.Namespace, hostkey.Name); ok {
+				ghsi.synchronizer.RecordEvent(ghsi.Subscription, utils.EventReasonReconcileProfiled,
+					"Reconcile phase durations: "+profile.String(), nil)
+			}
+		}()
+	}
+
 	//Update the secret and config map
 	if ghsi.Channel != nil {
 		sec, cm := utils.FetchChannelReferences(ghsi.synchronizer.GetRemoteNonCachedClient(), *ghsi.Channel)
@@ -219,12 +296,12 @@ func (ghsi *SubscriberItem) doSubscription() error {
 
 		sec, cm = utils.FetchChannelReferences(ghsi.synchronizer.GetLocalNonCachedClient(), *ghsi.Channel)
 		if sec != nil {
-			klog.V(1).Info("updated in memory channel secret for ", ghsi.Subscription.Name)
+			logging.ForSubscription(ghsi.Subscription).V(1).Info("updated in memory channel secret")
 			ghsi.ChannelSecret = sec
 		}
 
 		if cm != nil {
-			klog.V(1).Info("updated in memory channel configmap for ", ghsi.Subscription.Name)
+			logging.ForSubscription(ghsi.Subscription).V(1).Info("updated in memory channel configmap")
 			ghsi.ChannelConfigMap = cm
 		}
 	}
@@ -252,24 +329,78 @@ func (ghsi *SubscriberItem) doSubscription() error {
 		}
 
 		if cm != nil {
-			klog.V(1).Info("updated in memory secondary channel configmap for ", ghsi.Subscription.Name)
+			logging.ForSubscription(ghsi.Subscription).V(1).Info("updated in memory secondary channel configmap")
 			ghsi.SecondaryChannelConfigMap = cm
 		}
 	}
 
+	for i := range ghsi.FallbackChannels {
+		fallback := &ghsi.FallbackChannels[i]
+
+		sec, cm := utils.FetchChannelReferences(ghsi.synchronizer.GetRemoteNonCachedClient(), *fallback.Channel)
+		if sec != nil {
+			if err := utils.ListAndDeployReferredObject(ghsi.synchronizer.GetLocalNonCachedClient(), ghsi.Subscription,
+				schema.GroupVersionKind{Group: "", Kind: "Secret", Version: "v1"}, sec); err != nil {
+				klog.Warningf("can't deploy reference fallback secret %v for subscription %v", sec.GetName(), ghsi.Subscription.GetName())
+			}
+		}
+
+		if cm != nil {
+			if err := utils.ListAndDeployReferredObject(ghsi.synchronizer.GetLocalNonCachedClient(), ghsi.Subscription,
+				schema.GroupVersionKind{Group: "", Kind: "ConfigMap", Version: "v1"}, cm); err != nil {
+				klog.Warningf("can't deploy reference fallback configmap %v for subscription %v", cm.GetName(), ghsi.Subscription.GetName())
+			}
+		}
+
+		sec, cm = utils.FetchChannelReferences(ghsi.synchronizer.GetLocalNonCachedClient(), *fallback.Channel)
+		if sec != nil {
+			fallback.Secret = sec
+		}
+
+		if cm != nil {
+			fallback.ConfigMap = cm
+		}
+	}
+
 	//Clone the git repo
+	ctx := tracing.ContextFromAnnotations(context.TODO(), ghsi.Subscription.GetAnnotations())
+	_, span := tracing.StartSpan(ctx, "CloneGitRepo", hostkey.Namespace, hostkey.Name)
+
 	startTime := time.Now().UnixMilli()
 	commitID, err := ghsi.cloneGitRepo()
 	endTime := time.Now().UnixMilli()
 
+	// the in-memory clone is released once the resources are applied
+	defer func() { ghsi.fSys = nil }()
+
 	if err != nil {
-		klog.Error(err, "Unable to clone the git repo ", ghsi.Channel.Spec.Pathname)
+		span.RecordError(err)
+	}
+
+	span.End()
+
+	if err != nil {
+		klog.Error(err, "Unable to clone the git repo ", utils.RedactString(ghsi.Channel.Spec.Pathname))
 		ghsi.successful = false
 
+		eventReason := utils.EventReasonCloneFailed
+
+		switch {
+		case errors.Is(err, utils.ErrSSHHostKeyMismatch):
+			eventReason = utils.EventReasonHostKeyMismatch
+		case errors.Is(err, utils.ErrSSHKnownHostsRequired):
+			eventReason = utils.EventReasonKnownHostsRequired
+		}
+
+		ghsi.synchronizer.RecordEvent(ghsi.Subscription, eventReason,
+			fmt.Sprintf("Failed to clone the git repo %v: %v", ghsi.Channel.Spec.Pathname, err), err)
+
 		metrics.GitFailedPullTime.
 			WithLabelValues(ghsi.SubscriberItem.Subscription.Namespace, ghsi.SubscriberItem.Subscription.Name).
 			Observe(float64(endTime - startTime))
 
+		profiling.ObservePhase(hostkey.Namespace, hostkey.Name, metrics.PhaseClone, endTime-startTime)
+
 		return err
 	}
 
@@ -277,6 +408,8 @@ func (ghsi *SubscriberItem) doSubscription() error {
 		WithLabelValues(ghsi.SubscriberItem.Subscription.Namespace, ghsi.SubscriberItem.Subscription.Name).
 		Observe(float64(endTime - startTime))
 
+	metrics.ObserveLocalDeploymentPhase(hostkey.Namespace, hostkey.Name, metrics.PhaseClone, endTime-startTime)
+
 	klog.Info("Git commit: ", commitID)
 
 	if strings.EqualFold(ghsi.reconcileRate, "medium") {
@@ -302,7 +435,11 @@ func (ghsi *SubscriberItem) doSubscription() error {
 
 	ghsi.resources = []kubesynchronizer.ResourceUnit{}
 
+	startTime = time.Now().UnixMilli()
 	err = ghsi.sortClonedGitRepo()
+
+	metrics.ObserveLocalDeploymentPhase(hostkey.Namespace, hostkey.Name, metrics.PhaseSort, time.Now().UnixMilli()-startTime)
+
 	if err != nil {
 		klog.Error(err, " Unable to sort helm charts and kubernetes resources from the cloned git repo.")
 
@@ -362,8 +499,14 @@ func (ghsi *SubscriberItem) doSubscription() error {
 
 	klog.Info("Applying kustomizations: ", ghsi.kustomizeDirs)
 
+	startTime = time.Now().UnixMilli()
 	err = ghsi.subscribeKustomizations()
 
+	if len(ghsi.kustomizeDirs) > 0 {
+		metrics.ObserveLocalDeploymentPhase(hostkey.Namespace, hostkey.Name, metrics.PhaseKustomize,
+			time.Now().UnixMilli()-startTime)
+	}
+
 	if err != nil {
 		klog.Error(err, " Unable to subscribe kustomize resources")
 
@@ -388,8 +531,13 @@ func (ghsi *SubscriberItem) doSubscription() error {
 
 	klog.Info("Applying helm charts..")
 
+	startTime = time.Now().UnixMilli()
 	err = ghsi.subscribeHelmCharts(ghsi.indexFile)
 
+	if len(ghsi.chartDirs) > 0 {
+		metrics.ObserveLocalDeploymentPhase(hostkey.Namespace, hostkey.Name, metrics.PhaseHelm, time.Now().UnixMilli()-startTime)
+	}
+
 	if err != nil {
 		klog.Error(err, "Unable to subscribe helm charts")
 
@@ -425,18 +573,39 @@ func (ghsi *SubscriberItem) doSubscription() error {
 		return fmt.Errorf("%.2000s", errMsg)
 	}
 
-	allowedGroupResources, deniedGroupResources := utils.GetAllowDenyLists(*ghsi.Subscription)
+	resourceObjs := make([]*unstructured.Unstructured, 0, len(ghsi.resources))
+	for _, resource := range ghsi.resources {
+		resourceObjs = append(resourceObjs, resource.Resource)
+	}
 
-	if err := ghsi.synchronizer.ProcessSubResources(ghsi.Subscription, ghsi.resources,
-		allowedGroupResources, deniedGroupResources, ghsi.clusterAdmin, true); err != nil {
-		klog.Error(err)
+	resourceHash := utils.HashSubscriberResources(ghsi.Subscription, resourceObjs)
 
-		ghsi.successful = false
+	if restored && errMsg == "" && commitID == ghsi.commitID && resourceHash == ghsi.resourceHash {
+		klog.Infof("Appsub %s resources of commit %s haven't changed since the agent restart. Skip apply.", hostkey.String(), commitID)
+	} else {
+		allowedGroupResources, deniedGroupResources := utils.GetAllowDenyLists(*ghsi.Subscription)
 
-		return err
+		appliedSub := ghsi.Subscription.DeepCopy()
+		utils.SetAppsubRevision(appliedSub, commitID)
+		utils.SetAppsubSource(appliedSub, ghsi.sourceURL)
+
+		if err := ghsi.synchronizer.ProcessSubResources(appliedSub, ghsi.resources,
+			allowedGroupResources, deniedGroupResources, ghsi.clusterAdmin, true); err != nil {
+			klog.Error(err)
+
+			ghsi.successful = false
+			ghsi.saveState()
+
+			return err
+		}
+
+		if commitID != ghsi.commitID {
+			ghsi.synchronizer.RecordEvent(ghsi.Subscription, utils.EventReasonCommitDeployed, "Deployed commit "+commitID, nil)
+		}
 	}
 
 	ghsi.commitID = commitID
+	ghsi.resourceHash = resourceHash
 
 	ghsi.resources = nil
 	ghsi.chartDirs = nil
@@ -447,10 +616,38 @@ func (ghsi *SubscriberItem) doSubscription() error {
 	ghsi.indexFile = nil
 	ghsi.successful = true
 
+	ghsi.saveState()
+
 	return nil
 }
 
+// saveState persists the state of the subscriber item, so it is restored after an agent restart
+func (ghsi *SubscriberItem) saveState() {
+	key := types.NamespacedName{Name: ghsi.Subscription.Name, Namespace: ghsi.Subscription.Namespace}
+
+	state := utils.SubscriberState{
+		Commit:       ghsi.commitID,
+		ResourceHash: ghsi.resourceHash,
+		Successful:   ghsi.successful,
+	}
+
+	if err := ghsi.states.Set(key, state); err != nil {
+		klog.Errorf("Failed to persist the state of SubscriberItem %v, err: %v", key.String(), err)
+	}
+}
+
 func (ghsi *SubscriberItem) subscribeKustomizations() error {
+	if ghsi.kustomizeOptions == nil {
+		ghsi.kustomizeOptions = &utils.KustomizeBuildOptions{}
+	}
+
+	if err := utils.SetKustomizeBuildOptions(ghsi.kustomizeOptions, ghsi.Subscription); err != nil {
+		klog.Error("Failed to set the kustomize build options, clean up all resources that will deploy. error: ", err.Error())
+		ghsi.resources = []kubesynchronizer.ResourceUnit{}
+
+		return err
+	}
+
 	for _, kustomizeDir := range ghsi.kustomizeDirs {
 		klog.Info("Applying kustomization ", kustomizeDir)
 
@@ -461,7 +658,8 @@ func (ghsi *SubscriberItem) subscribeKustomizations() error {
 			relativePath = strings.SplitAfter(kustomizeDir, ghsi.repoRoot+"/")[1]
 		}
 
-		err := utils.VerifyAndOverrideKustomize(ghsi.Subscription.Spec.PackageOverrides, relativePath, kustomizeDir)
+		err := utils.VerifyAndOverrideKustomizeInFileSystem(ghsi.fileSystem(), ghsi.Subscription.Spec.PackageOverrides, relativePath,
+			kustomizeDir)
 		if err != nil {
 			klog.Error("Failed to override kustomization, clean up all resources that will deploy. error: ", err.Error())
 			ghsi.resources = []kubesynchronizer.ResourceUnit{}
@@ -469,7 +667,7 @@ func (ghsi *SubscriberItem) subscribeKustomizations() error {
 			return err
 		}
 
-		out, err := utils.RunKustomizeBuild(kustomizeDir)
+		out, err := utils.RunKustomizeBuildWithOptions(kustomizeDir, ghsi.kustomizeOptions)
 
 		if err != nil {
 			klog.Error("Failed to apply kustomization, clean up all resources that will deploy. error: ", err.Error())
@@ -526,7 +724,7 @@ func checkSubscriptionAnnotation(resource kubeResource) error {
 func (ghsi *SubscriberItem) subscribeResources(rscFiles []string) error {
 	// sync kube resource manifests
 	for _, rscFile := range rscFiles {
-		file, err := os.ReadFile(rscFile) // #nosec G304 rscFile is not user input
+		file, err := ghsi.fileSystem().ReadFile(rscFile)
 
 		if err != nil {
 			klog.Error(err, "Failed to read YAML file "+rscFile)
@@ -548,10 +746,10 @@ func (ghsi *SubscriberItem) subscribeResources(rscFiles []string) error {
 					continue
 				}
 
-				klog.V(1).Info("Applying Kubernetes resource of kind ", t.Kind)
+				logging.ForSubscription(ghsi.Subscription).V(1).Info("Applying Kubernetes resource", "kind", t.Kind)
 
 				if t.Kind == "Subscription" {
-					klog.V(1).Infof("Injecting userID(%s), Group(%s) to subscription", ghsi.userID, ghsi.userGroup)
+					logging.ForSubscription(ghsi.Subscription).V(1).Info("Injecting user identity to subscription", "userID", ghsi.userID, "group", ghsi.userGroup)
 
 					o := &unstructured.Unstructured{}
 					if err := yaml.Unmarshal(resource, o); err != nil {
@@ -636,7 +834,7 @@ func (ghsi *SubscriberItem) subscribeResource(file []byte) (*unstructured.Unstru
 			klog.Info("cluster-admin is true.")
 
 			if rsc.GetNamespace() != "" {
-				if ghsi.currentNamespaceScoped {
+				if ghsi.currentNamespaceScoped && !isFluxPassthrough(ghsi.Subscription, validgvk) {
 					// If current-namespace-scoped annotation is true, deploy resources into subscription's namespace
 					klog.Info("Setting it to subscription namespace " + ghsi.Subscription.Namespace)
 					rsc.SetNamespace(ghsi.Subscription.Namespace)
@@ -661,8 +859,9 @@ func (ghsi *SubscriberItem) subscribeResource(file []byte) (*unstructured.Unstru
 				rsc.SetAnnotations(rscAnnotations)
 			}
 		} else {
-			klog.Info("No cluster-admin. Setting it to subscription namespace " + ghsi.Subscription.Namespace)
-			rsc.SetNamespace(ghsi.Subscription.Namespace)
+			namespace := utils.GetRestrictedResourceNamespace(ghsi.Subscription, rsc.GetNamespace())
+			klog.Info("No cluster-admin. Setting it to namespace " + namespace)
+			rsc.SetNamespace(namespace)
 		}
 	}
 
@@ -685,7 +884,7 @@ func (ghsi *SubscriberItem) subscribeResource(file []byte) (*unstructured.Unstru
 				errmsg += " and failed to set in cluster package status with error: " + err.Error()
 			}
 
-			klog.V(2).Info(errmsg)
+			logging.ForSubscription(ghsi.Subscription).V(2).Info(errmsg)
 
 			return nil, nil, errors.New(errmsg)
 		}
@@ -712,17 +911,28 @@ func (ghsi *SubscriberItem) subscribeResource(file []byte) (*unstructured.Unstru
 			}
 		}
 
+		// If the update-strategy is set in the resource, honor that. Otherwise, take the subscription's update-strategy
+		if rscAnnotations[appv1.AnnotationResourceUpdateStrategy] == "" && subAnnotations[appv1.AnnotationResourceUpdateStrategy] != "" {
+			rscAnnotations[appv1.AnnotationResourceUpdateStrategy] = subAnnotations[appv1.AnnotationResourceUpdateStrategy]
+		}
+
 		rsc.SetAnnotations(rscAnnotations)
 	}
 
 	// Set app label
 	utils.SetPartOfLabel(ghsi.SubscriberItem.Subscription, rsc)
 
-	klog.Infof("new resource for deployment: %#v", rsc)
+	klog.Infof("new resource for deployment: %#v", utils.RedactObject(rsc))
 
 	return rsc, &validgvk, nil
 }
 
+// isFluxPassthrough returns true if the resource is a Flux custom resource deployed unmodified by the subscription,
+// so the namespace of a Flux kustomization stays next to its source
+func isFluxPassthrough(sub *appv1.Subscription, gvk schema.GroupVersionKind) bool {
+	return utils.IsFluxPassthrough(sub) && utils.IsFluxResource(gvk)
+}
+
 func (ghsi *SubscriberItem) checkFilters(rsc *unstructured.Unstructured) (errMsg string) {
 	if ghsi.Subscription.Spec.Package != "" && ghsi.Subscription.Spec.Package != rsc.GetName() {
 		errMsg = "Name does not match, skiping:" + ghsi.Subscription.Spec.Package + "|" + rsc.GetName()
@@ -731,12 +941,12 @@ func (ghsi *SubscriberItem) checkFilters(rsc *unstructured.Unstructured) (errMsg
 	}
 
 	if ghsi.Subscription.Spec.Package == rsc.GetName() {
-		klog.V(4).Info("Name does matches: " + ghsi.Subscription.Spec.Package + "|" + rsc.GetName())
+		logging.ForSubscription(ghsi.Subscription).V(4).Info("Name does match", "package", ghsi.Subscription.Spec.Package, "resource", rsc.GetName())
 	}
 
 	if ghsi.Subscription.Spec.PackageFilter != nil {
 		if utils.LabelChecker(ghsi.Subscription.Spec.PackageFilter.LabelSelector, rsc.GetLabels()) {
-			klog.V(4).Info("Passed label check on resource " + rsc.GetName())
+			logging.ForSubscription(ghsi.Subscription).V(4).Info("Passed label check", "resource", rsc.GetName())
 		} else {
 			errMsg = "Failed to pass label check on resource " + rsc.GetName()
 
@@ -745,7 +955,7 @@ func (ghsi *SubscriberItem) checkFilters(rsc *unstructured.Unstructured) (errMsg
 
 		annotations := ghsi.Subscription.Spec.PackageFilter.Annotations
 		if annotations != nil {
-			klog.V(4).Info("checking annotations filter:", annotations)
+			logging.ForSubscription(ghsi.Subscription).V(4).Info("checking annotations filter", "annotations", annotations)
 
 			rscanno := rsc.GetAnnotations()
 			if rscanno == nil {
@@ -776,8 +986,14 @@ func (ghsi *SubscriberItem) checkFilters(rsc *unstructured.Unstructured) (errMsg
 }
 
 func (ghsi *SubscriberItem) subscribeHelmCharts(indexFile *repo.IndexFile) (err error) {
+	if err := utils.ValidateHelmReleaseNames(ghsi.Subscription, indexFile); err != nil {
+		klog.Error("Failed to validate the helmrelease CR names, err: ", err)
+
+		return err
+	}
+
 	for packageName, chartVersions := range indexFile.Entries {
-		klog.V(1).Infof("chart: %s\n%v", packageName, chartVersions)
+		logging.ForSubscription(ghsi.Subscription).V(1).Info("chart", "name", packageName, "versions", chartVersions)
 
 		helmReleaseCR, err := utils.CreateHelmCRManifest(
 			"", packageName, chartVersions, ghsi.synchronizer.GetLocalClient(), ghsi.Channel, ghsi.SecondaryChannel, ghsi.Subscription, ghsi.clusterAdmin)
@@ -809,7 +1025,13 @@ func (ghsi *SubscriberItem) cloneGitRepo() (commitID string, err error) {
 		}
 	}
 
-	ghsi.repoRoot = utils.GetLocalGitFolder(ghsi.Subscription)
+	ghsi.repoRoot = utils.GetPrefixedLocalGitFolder(ghsi.gitFolderPrefix, ghsi.Subscription)
+	ghsi.fSys = nil
+
+	// the repo is cloned and rendered in memory, nothing is written on the container file system
+	if features.DefaultMutableFeatureGate.Enabled(features.InMemoryGitRender) {
+		ghsi.fSys = filesys.MakeFsInMemory()
+	}
 
 	cloneOptions := &utils.GitCloneOption{
 		CommitHash:  ghsi.desiredCommit,
@@ -817,6 +1039,18 @@ func (ghsi *SubscriberItem) cloneGitRepo() (commitID string, err error) {
 		CloneDepth:  cloneDepth,
 		Branch:      utils.GetSubscriptionBranch(ghsi.Subscription),
 		DestDir:     ghsi.repoRoot,
+		FileSystem:  ghsi.fSys,
+	}
+
+	// the partial clone fetches the files of the resource path only
+	if strings.EqualFold(annotations[appv1.AnnotationGitPartialClone], "true") {
+		cloneOptions.PartialClone = true
+
+		if gitPath := utils.GetGitPathAnnotation(annotations); gitPath != "" {
+			cloneOptions.Paths = []string{gitPath}
+		} else if ghsi.SubscriberItem.SubscriptionConfigMap != nil && ghsi.SubscriberItem.SubscriptionConfigMap.Data["path"] != "" {
+			cloneOptions.Paths = []string{ghsi.SubscriberItem.SubscriptionConfigMap.Data["path"]}
+		}
 	}
 
 	// Get the primary channel connection options
@@ -830,6 +1064,12 @@ func (ghsi *SubscriberItem) cloneGitRepo() (commitID string, err error) {
 	primaryChannelConnectionConfig.InsecureSkipVerify = ghsi.Channel.Spec.InsecureSkipVerify
 	cloneOptions.PrimaryConnectionOption = primaryChannelConnectionConfig
 
+	// the remote kustomize bases on the channel Git servers are fetched with the channel connections
+	ghsi.kustomizeOptions = &utils.KustomizeBuildOptions{
+		Connections: []*utils.ChannelConnectionCfg{primaryChannelConnectionConfig},
+		FileSystem:  ghsi.fSys,
+	}
+
 	// Get the secondary channel connection options
 	if ghsi.SecondaryChannel != nil {
 		// Get the secondary channel connection options
@@ -842,9 +1082,53 @@ func (ghsi *SubscriberItem) cloneGitRepo() (commitID string, err error) {
 		secondaryChannelConnectionConfig.RepoURL = ghsi.SecondaryChannel.Spec.Pathname
 		secondaryChannelConnectionConfig.InsecureSkipVerify = ghsi.SecondaryChannel.Spec.InsecureSkipVerify
 		cloneOptions.SecondaryConnectionOption = secondaryChannelConnectionConfig
+		ghsi.kustomizeOptions.Connections = append(ghsi.kustomizeOptions.Connections, secondaryChannelConnectionConfig)
+	}
+
+	// Get the fallback channel connection options
+	servingChannels := map[*utils.ChannelConnectionCfg]*chnv1.Channel{
+		cloneOptions.PrimaryConnectionOption:   ghsi.Channel,
+		cloneOptions.SecondaryConnectionOption: ghsi.SecondaryChannel,
 	}
 
-	return utils.CloneGitRepo(cloneOptions)
+	for _, fallback := range ghsi.FallbackChannels {
+		fallbackChannelConnectionConfig, err := getChannelConnectionConfig(fallback.Secret, fallback.ConfigMap)
+
+		if err != nil {
+			return "", err
+		}
+
+		fallbackChannelConnectionConfig.RepoURL = fallback.Channel.Spec.Pathname
+		fallbackChannelConnectionConfig.InsecureSkipVerify = fallback.Channel.Spec.InsecureSkipVerify
+		cloneOptions.FallbackConnectionOptions = append(cloneOptions.FallbackConnectionOptions, fallbackChannelConnectionConfig)
+		ghsi.kustomizeOptions.Connections = append(ghsi.kustomizeOptions.Connections, fallbackChannelConnectionConfig)
+		servingChannels[fallbackChannelConnectionConfig] = fallback.Channel
+	}
+
+	commitID, err = utils.CloneGitRepo(cloneOptions)
+	if err != nil {
+		return "", err
+	}
+
+	if cloneOptions.ServingConnectionOption != nil {
+		ghsi.sourceURL = cloneOptions.ServingConnectionOption.RepoURL
+	}
+
+	if chn := servingChannels[cloneOptions.ServingConnectionOption]; chn != nil {
+		utils.SetServingChannel(types.NamespacedName{Namespace: ghsi.Subscription.Namespace, Name: ghsi.Subscription.Name},
+			chn.Namespace+"/"+chn.Name)
+	}
+
+	return commitID, nil
+}
+
+// fileSystem returns the file system of the cloned repo, the disk if it is not cloned in memory
+func (ghsi *SubscriberItem) fileSystem() filesys.FileSystem {
+	if ghsi.fSys == nil {
+		return filesys.MakeFsOnDisk()
+	}
+
+	return ghsi.fSys
 }
 
 func getChannelConnectionConfig(secret *corev1.Secret, configmap *corev1.ConfigMap) (connCfg *utils.ChannelConnectionCfg, err error) {
@@ -869,6 +1153,8 @@ func getChannelConnectionConfig(secret *corev1.Secret, configmap *corev1.ConfigM
 		caCert := configmap.Data[appv1.ChannelCertificateData]
 
 		connCfg.CaCerts = caCert
+
+		utils.SetChannelSSHConfig(connCfg, configmap)
 	}
 
 	return connCfg, nil
@@ -892,10 +1178,8 @@ func (ghsi *SubscriberItem) sortClonedGitRepo() error {
 
 	annotations := ghsi.Subscription.GetAnnotations()
 
-	if annotations[appv1.AnnotationGithubPath] != "" {
-		resourcePath = filepath.Join(ghsi.repoRoot, annotations[appv1.AnnotationGithubPath])
-	} else if annotations[appv1.AnnotationGitPath] != "" {
-		resourcePath = filepath.Join(ghsi.repoRoot, annotations[appv1.AnnotationGitPath])
+	if gitPath := utils.GetGitPathAnnotation(annotations); gitPath != "" {
+		resourcePath = filepath.Join(ghsi.repoRoot, gitPath)
 	} else if ghsi.SubscriberItem.SubscriptionConfigMap != nil {
 		resourcePath = filepath.Join(ghsi.repoRoot, ghsi.SubscriberItem.SubscriptionConfigMap.Data["path"])
 	}
@@ -904,7 +1188,8 @@ func (ghsi *SubscriberItem) sortClonedGitRepo() error {
 	// crdsAndNamespaceFiles contains CustomResourceDefinition and Namespace Kubernetes resources file paths
 	// rbacFiles contains ServiceAccount, ClusterRole and Role Kubernetes resource file paths
 	// otherFiles contains all other Kubernetes resource file paths
-	chartDirs, kustomizeDirs, crdsAndNamespaceFiles, rbacFiles, otherFiles, err := utils.SortResources(ghsi.repoRoot, resourcePath, utils.SkipHooksOnManaged)
+	chartDirs, kustomizeDirs, crdsAndNamespaceFiles, rbacFiles, otherFiles, err := utils.SortResourcesInFileSystem(ghsi.fileSystem(),
+		ghsi.repoRoot, resourcePath, utils.SkipHooksOnManaged)
 	if err != nil {
 		klog.Error(err, "Failed to sort kubernetes resources and helm charts.")
 
@@ -918,7 +1203,7 @@ func (ghsi *SubscriberItem) sortClonedGitRepo() error {
 	ghsi.otherFiles = otherFiles
 
 	// Build a helm repo index file
-	indexFile, err := utils.GenerateHelmIndexFile(ghsi.Subscription, ghsi.repoRoot, chartDirs)
+	indexFile, err := utils.GenerateHelmIndexFileInFileSystem(ghsi.fileSystem(), ghsi.Subscription, ghsi.repoRoot, chartDirs)
 
 	if err != nil {
 		// If package name is not specified in the subscription, filterCharts throws an error. In this case, just return the original index file.
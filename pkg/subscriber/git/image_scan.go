@@ -0,0 +1,79 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// startImageScans scans the registries of the images last applied by the subscription every image scan interval,
+// the subscription is applied again when a new image is found. The previous scans are stopped, nothing is scanned if
+// the subscription has no image scan interval.
+func (ghsi *SubscriberItem) startImageScans() {
+	ghsi.stopImageScans()
+
+	interval := utils.GetImageScanInterval(ghsi.Subscription)
+	if interval == 0 {
+		return
+	}
+
+	scanch := make(chan struct{})
+	ghsi.scanch = scanch
+
+	klog.Infof("Scanning the images of the SubscriberItem %v every %v", ghsi.Subscription.Name, interval)
+
+	go wait.JitterUntil(ghsi.scanImages, interval, utils.ReconcileJitterFactor, false, scanch)
+}
+
+// stopImageScans stops the registry scans of the subscriber item, if any
+func (ghsi *SubscriberItem) stopImageScans() {
+	if ghsi.scanch != nil {
+		close(ghsi.scanch)
+		ghsi.scanch = nil
+	}
+}
+
+func (ghsi *SubscriberItem) scanImages() {
+	if utils.GetPauseLabel(ghsi.Subscription) {
+		return
+	}
+
+	// the new images are applied when the time window opens
+	if tw := ghsi.Subscription.Spec.TimeWindow; tw != nil && utils.NextStartPoint(tw, time.Now()) > 0 {
+		return
+	}
+
+	updated := ghsi.synchronizer.ScanImages(ghsi.Subscription)
+	if len(updated) == 0 {
+		return
+	}
+
+	msg := "New images found in the registries: " + strings.Join(updated, ", ")
+
+	klog.Info(msg, ", subscription: ", ghsi.Subscription.Namespace, "/", ghsi.Subscription.Name)
+
+	ghsi.synchronizer.RecordEvent(ghsi.Subscription, utils.EventReasonImagesUpdated, msg, nil)
+
+	// reset the commit ID so the resources are applied even if the commit hasn't changed
+	ghsi.commitID = ""
+
+	ghsi.doSubscriptionWithRetries(0, 0)
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
+)
+
+// RenderOptions are the options of RenderSubscription
+type RenderOptions struct {
+	// Commit is the Git commit to render, the desired commit or tag of the subscription or the branch head if empty
+	Commit string
+	// Client gets the package filter config map of the subscription, and the existing helm releases
+	Client client.Client
+	// RESTMapper resolves the namespaced resources, the resources it can't map are cluster scoped
+	RESTMapper meta.RESTMapper
+}
+
+// RenderSubscription clones the Git repository of the subscriber item channel and returns the resources the git
+// subscriber would deploy for the subscription, with the commit they are rendered from. The resources are sorted,
+// kustomized, filtered and overridden like on the managed clusters, the helm charts are returned as HelmReleases.
+// Nothing is applied.
+func RenderSubscription(subitem *appv1.SubscriberItem, opts RenderOptions) ([]*unstructured.Unstructured, string, error) {
+	if subitem == nil || subitem.Subscription == nil || subitem.Channel == nil {
+		return nil, "", errors.New("the subscription and its channel are required")
+	}
+
+	ghsi := &SubscriberItem{}
+	subitem.DeepCopyInto(&ghsi.SubscriberItem)
+
+	ghsi.synchronizer = &renderSource{clt: opts.Client, mapper: opts.RESTMapper}
+
+	subAnnotations := ghsi.Subscription.GetAnnotations()
+
+	ghsi.clusterAdmin = strings.EqualFold(subAnnotations[appv1.AnnotationClusterAdmin], "true")
+	ghsi.currentNamespaceScoped = strings.EqualFold(subAnnotations[appv1.AnnotationCurrentNamespaceScoped], "true")
+	ghsi.userID = subAnnotations[appv1.AnnotationUserIdentity]
+	ghsi.userGroup = subAnnotations[appv1.AnnotationUserGroup]
+	ghsi.desiredCommit = subAnnotations[appv1.AnnotationGitTargetCommit]
+	ghsi.desiredTag = subAnnotations[appv1.AnnotationGitTag]
+
+	if opts.Commit != "" {
+		ghsi.desiredCommit = opts.Commit
+		ghsi.desiredTag = ""
+	}
+
+	commitID, err := ghsi.cloneGitRepo()
+
+	defer os.RemoveAll(ghsi.repoRoot)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to clone the git repo: %w", err)
+	}
+
+	if err := ghsi.sortClonedGitRepo(); err != nil {
+		return nil, commitID, err
+	}
+
+	for _, files := range [][]string{ghsi.crdsAndNamespaceFiles, ghsi.rbacFiles, ghsi.otherFiles} {
+		if err := ghsi.subscribeResources(files); err != nil {
+			return nil, commitID, err
+		}
+	}
+
+	if err := ghsi.subscribeKustomizations(); err != nil {
+		return nil, commitID, fmt.Errorf("failed to apply kustomization: %w", err)
+	}
+
+	if err := ghsi.subscribeHelmCharts(ghsi.indexFile); err != nil {
+		return nil, commitID, err
+	}
+
+	resources := make([]*unstructured.Unstructured, 0, len(ghsi.resources))
+
+	for _, resource := range ghsi.resources {
+		resource.Resource.SetGroupVersionKind(resource.Gvk)
+		resources = append(resources, resource.Resource)
+	}
+
+	return resources, commitID, nil
+}
+
+// renderSource is the SyncSource of the rendered subscriptions, it doesn't apply anything
+type renderSource struct {
+	clt    client.Client
+	mapper meta.RESTMapper
+}
+
+var _ SyncSource = &renderSource{}
+
+func (r *renderSource) GetInterval() int {
+	return 0
+}
+
+func (r *renderSource) GetLocalClient() client.Client {
+	return r.clt
+}
+
+func (r *renderSource) GetLocalNonCachedClient() client.Client {
+	return r.clt
+}
+
+func (r *renderSource) GetRemoteClient() client.Client {
+	return r.clt
+}
+
+func (r *renderSource) GetRemoteNonCachedClient() client.Client {
+	return r.clt
+}
+
+func (r *renderSource) IsResourceNamespaced(rsc *unstructured.Unstructured) bool {
+	if r.mapper == nil {
+		return false
+	}
+
+	gvk := rsc.GroupVersionKind()
+
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		klog.Infof("Failed to get GVR from restmapping: %v", err)
+
+		return false
+	}
+
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace
+}
+
+func (r *renderSource) ProcessSubResources(*appv1.Subscription, []kubesynchronizer.ResourceUnit,
+	map[string]map[string]string, map[string]map[string]string, bool, bool) error {
+	return nil
+}
+
+func (r *renderSource) PurgeAllSubscribedResources(*appv1.Subscription) error {
+	return nil
+}
+
+func (r *renderSource) UpdateAppsubOverallStatus(*appv1.Subscription, bool, string) error {
+	return nil
+}
+
+func (r *renderSource) RecordEvent(*appv1.Subscription, string, string, error) {}
+
+func (r *renderSource) ScanImages(*appv1.Subscription) []string {
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// syncOnStart makes the subscriber items restored after an agent restart apply their resources again if they are not
+// reconciled periodically, with the webhook enabled or the reconcile rate off. Nothing else repairs the drift of
+// their resources while the agent was down.
+var syncOnStart = true
+
+// retryBackoff is the backoff of the background retries of the failed subscriptions that are not reconciled
+// periodically. The retries stop after Steps attempts, until the next webhook event or subscription change.
+var retryBackoff = wait.Backoff{
+	Duration: time.Minute,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    6,
+	Cap:      30 * time.Minute,
+}
+
+// SetSyncOnStart sets whether the restored subscriber items without periodic reconcile apply their resources again
+// after an agent restart
+func SetSyncOnStart(enabled bool) {
+	syncOnStart = enabled
+}
+
+// isReconciledPeriodically returns false if the subscriber item is only reconciled on webhook events or subscription
+// changes
+func (ghsi *SubscriberItem) isReconciledPeriodically() bool {
+	return !ghsi.webhookEnabled && !strings.EqualFold(ghsi.reconcileRate, "off")
+}
+
+// startRetries retries the failed subscription in the background with the bounded retryBackoff. The retries stop
+// when the subscription succeeds, the subscriber item is stopped or the retries are restarted.
+func (ghsi *SubscriberItem) startRetries() {
+	ghsi.stopRetries()
+
+	retrych := make(chan struct{})
+	ghsi.retrych = retrych
+
+	backoff := retryBackoff
+	steps := backoff.Steps
+
+	go func() {
+		for n := 1; backoff.Steps > 0; n++ {
+			delay := backoff.Step()
+
+			klog.Infof("Retry #%d/%d of the failed subscription %v/%v after %v", n, steps,
+				ghsi.Subscription.Namespace, ghsi.Subscription.Name, delay)
+
+			select {
+			case <-time.After(delay):
+			case <-retrych:
+				return
+			}
+
+			ghsi.doSubscriptionWithRetries(0, 0)
+
+			if ghsi.successful {
+				return
+			}
+		}
+
+		msg := fmt.Sprintf("The subscription still fails after %d retries, it is retried on the next webhook event or "+
+			"subscription change", steps)
+
+		klog.Info(msg, ": ", ghsi.Subscription.Namespace, "/", ghsi.Subscription.Name)
+
+		ghsi.synchronizer.RecordEvent(ghsi.Subscription, utils.EventReasonRetriesExhausted, msg, errors.New(msg))
+	}()
+}
+
+// stopRetries stops the background retries of the subscriber item, if any
+func (ghsi *SubscriberItem) stopRetries() {
+	if ghsi.retrych != nil {
+		close(ghsi.retrych)
+		ghsi.retrych = nil
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// eventSource records the events of the subscriber item, it doesn't apply anything
+type eventSource struct {
+	renderSource
+
+	mu      sync.Mutex
+	reasons []string
+}
+
+func (e *eventSource) RecordEvent(_ *appv1.Subscription, reason, _ string, _ error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.reasons = append(e.reasons, reason)
+}
+
+func (e *eventSource) getReasons() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]string{}, e.reasons...)
+}
+
+func newFailingSubscriberItem(t *testing.T) (*SubscriberItem, *eventSource) {
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "retry-sub", Namespace: "default"},
+		Spec:       appv1.SubscriptionSpec{Channel: "default/retry-channel"},
+	}
+
+	source := &eventSource{
+		renderSource: renderSource{clt: fake.NewClientBuilder().WithScheme(scheme).WithObjects(sub.DeepCopy()).Build()},
+	}
+
+	ghsi := &SubscriberItem{
+		SubscriberItem: appv1.SubscriberItem{
+			Subscription: sub,
+			Channel: &chnv1.Channel{
+				ObjectMeta: metav1.ObjectMeta{Name: "retry-channel", Namespace: "default"},
+				Spec: chnv1.ChannelSpec{
+					Type:     chnv1.ChannelTypeGit,
+					Pathname: "file://" + t.TempDir() + "/missing-repo",
+				},
+			},
+		},
+		webhookEnabled: true,
+		synchronizer:   source,
+	}
+
+	return ghsi, source
+}
+
+func TestStartRetries(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defaultBackoff := retryBackoff
+	defer func() { retryBackoff = defaultBackoff }()
+
+	retryBackoff = wait.Backoff{Duration: 10 * time.Millisecond, Factor: 1, Steps: 2}
+
+	// the retries are bounded, an event is recorded when they are exhausted
+	ghsi, source := newFailingSubscriberItem(t)
+	ghsi.startRetries()
+
+	g.Eventually(source.getReasons, 10*time.Second).Should(gomega.ContainElement(utils.EventReasonRetriesExhausted))
+	g.Expect(source.getReasons()).To(gomega.HaveLen(3))
+	g.Expect(ghsi.successful).To(gomega.BeFalse())
+
+	ghsi.stopRetries()
+
+	// the retries are stopped with the subscriber item
+	retryBackoff = wait.Backoff{Duration: time.Hour, Steps: 1}
+
+	ghsi, source = newFailingSubscriberItem(t)
+	ghsi.startRetries()
+	g.Expect(ghsi.retrych).NotTo(gomega.BeNil())
+
+	ghsi.Stop()
+	g.Expect(ghsi.retrych).To(gomega.BeNil())
+	g.Consistently(source.getReasons, 100*time.Millisecond).Should(gomega.BeEmpty())
+}
+
+func TestIsReconciledPeriodically(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect((&SubscriberItem{reconcileRate: "medium"}).isReconciledPeriodically()).To(gomega.BeTrue())
+	g.Expect((&SubscriberItem{reconcileRate: "OFF"}).isReconciledPeriodically()).To(gomega.BeFalse())
+	g.Expect((&SubscriberItem{reconcileRate: "high", webhookEnabled: true}).isReconciledPeriodically()).To(gomega.BeFalse())
+}
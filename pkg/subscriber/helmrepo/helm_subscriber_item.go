@@ -16,12 +16,12 @@ package helmrepo
 
 import (
 	"context"
-	"crypto/sha1" // #nosec G505 Used only to generate random value to be used to generate hash string
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -41,6 +41,8 @@ import (
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	releasev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/health"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
 	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
@@ -48,15 +50,19 @@ import (
 // SubscriberItem - defines the unit of namespace subscription.
 type SubscriberItem struct {
 	appv1.SubscriberItem
-	hash          string
-	reconcileRate string
-	syncTime      string
-	stopch        chan struct{}
-	count         int
-	syncinterval  int
-	success       bool
-	synchronizer  SyncSource
-	clusterAdmin  bool
+	hash                string
+	repoURL             string // the URL of the helm repo the index was last retrieved from
+	reconcileRate       string
+	reconcileInterval   time.Duration
+	syncTime            string
+	referencesVersion   string
+	stopch              chan struct{}
+	count               int
+	syncinterval        int
+	success             bool
+	synchronizer        SyncSource
+	clusterAdmin        bool
+	delayFirstReconcile bool
 }
 
 var (
@@ -86,7 +92,7 @@ func (hrsi *SubscriberItem) Start(restart bool) {
 
 	hrsi.stopch = make(chan struct{})
 
-	loopPeriod, retryInterval, retries := utils.GetReconcileInterval(hrsi.reconcileRate, chnv1.ChannelTypeHelmRepo)
+	loopPeriod, retryInterval, retries := utils.GetSubscriptionReconcileInterval(hrsi.reconcileRate, hrsi.Channel, hrsi.Subscription)
 
 	if strings.EqualFold(hrsi.reconcileRate, "off") {
 		klog.Infof("auto-reconcile is OFF")
@@ -96,7 +102,30 @@ func (hrsi *SubscriberItem) Start(restart bool) {
 		return
 	}
 
-	go wait.Until(func() {
+	stopch := hrsi.stopch
+	startDelay := time.Duration(0)
+
+	// spread the first reconcile of the subscriber items already deployed before the agent started across their
+	// reconcile interval, so they don't all reconcile at the same time
+	if hrsi.delayFirstReconcile {
+		startDelay = utils.GetReconcileStartDelay(types.NamespacedName{Namespace: hrsi.Subscription.Namespace,
+			Name: hrsi.Subscription.Name}, loopPeriod)
+		hrsi.delayFirstReconcile = false
+	}
+
+	go wait.JitterUntil(func() {
+		if startDelay > 0 {
+			klog.Infof("Delaying the first reconcile of the SubscriberItem %v by %v", hrsi.Subscription.Name, startDelay)
+
+			select {
+			case <-time.After(startDelay):
+			case <-stopch:
+				return
+			}
+
+			startDelay = 0
+		}
+
 		tw := hrsi.SubscriberItem.Subscription.Spec.TimeWindow
 		if tw != nil {
 			nextRun := utils.NextStartPoint(tw, time.Now())
@@ -117,7 +146,7 @@ func (hrsi *SubscriberItem) Start(restart bool) {
 		}
 
 		hrsi.doSubscriptionWithRetries(retryInterval, retries)
-	}, loopPeriod, hrsi.stopch)
+	}, loopPeriod, utils.ReconcileJitterFactor, true, stopch)
 }
 
 func (hrsi *SubscriberItem) Stop() {
@@ -148,32 +177,41 @@ func (hrsi *SubscriberItem) doSubscriptionWithRetries(retryInterval time.Duratio
 
 func (hrsi *SubscriberItem) getRepoInfo(usePrimary bool) (*repo.IndexFile, string, error) {
 	channel := hrsi.Channel
+	chnCfg := hrsi.ChannelConfigMap
+	chnSrt := hrsi.ChannelSecret
 
 	if !usePrimary && hrsi.SecondaryChannel != nil {
 		channel = hrsi.SecondaryChannel
+		chnCfg = hrsi.SecondaryChannelConfigMap
+		chnSrt = hrsi.SecondaryChannelSecret
 	}
 
 	//Retrieve the helm repo
 	repoURL := channel.Spec.Pathname
 
-	httpClient, err := getHelmRepoClient(hrsi.ChannelConfigMap, channel.Spec.InsecureSkipVerify)
+	httpClient, err := getHelmRepoClient(chnCfg, chnSrt, channel.Spec.InsecureSkipVerify)
 
 	if err != nil {
-		klog.Error(err, "Unable to create client for helm repo", repoURL)
+		klog.Error(err, "Unable to create client for helm repo", utils.RedactString(repoURL))
 		return nil, "", err
 	}
 
-	indexFile, hash, err := getHelmRepoIndex(httpClient, hrsi.Subscription, hrsi.ChannelSecret, repoURL)
+	indexFile, hash, err := getHelmRepoIndex(httpClient, hrsi.Subscription, chnSrt, repoURL)
 
 	if err != nil {
-		klog.Error(err, "Unable to retrieve the helm repo index", repoURL)
+		klog.Error(err, "Unable to retrieve the helm repo index", utils.RedactString(repoURL))
 		return nil, "", err
 	}
 
+	hrsi.repoURL = repoURL
+
 	return indexFile, hash, nil
 }
 
 func (hrsi *SubscriberItem) doSubscription() {
+	defer utils.AcquireReconcileSlot(metrics.ChannelTypeHelmRepo)()
+	defer health.Track(health.OperationSubscribe, hrsi.Subscription.Namespace, hrsi.Subscription.Name)()
+
 	var indexFile *repo.IndexFile
 
 	var hash string
@@ -431,9 +469,7 @@ func (hrsi *SubscriberItem) processSubscription(indexFile *repo.IndexFile, hash
 	return nil
 }
 
-func getHelmRepoClient(chnCfg *corev1.ConfigMap, insecureSkipVerify bool) (*http.Client, error) {
-	client := http.DefaultClient
-
+func getHelmRepoClient(chnCfg *corev1.ConfigMap, chnSrt *corev1.Secret, insecureSkipVerify bool) (*http.Client, error) {
 	if insecureSkipVerify {
 		klog.Info("Channel spec has insecureSkipVerify: true. Skipping Helm repo server certificate verification.")
 	}
@@ -449,10 +485,10 @@ func getHelmRepoClient(chnCfg *corev1.ConfigMap, insecureSkipVerify bool) (*http
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSClientConfig: &tls.Config{
+		TLSClientConfig: utils.SetFIPSTLSConfig(&tls.Config{
 			InsecureSkipVerify: insecureSkipVerify,     // #nosec G402 InsecureSkipVerify optionally
 			MinVersion:         appv1.TLSMinVersionInt, // #nosec G402 -- TLS 1.2 is required for FIPS
-		},
+		}),
 	}
 
 	if chnCfg != nil && !insecureSkipVerify {
@@ -479,9 +515,14 @@ func getHelmRepoClient(chnCfg *corev1.ConfigMap, insecureSkipVerify bool) (*http
 		klog.Info("s.HelmRepoConfig is nil")
 	}
 
-	client.Transport = transport
+	// the private CA certificates and the mTLS client certificate of the channel
+	if err := utils.SetHelmRepoTLSConfig(transport.TLSClientConfig, chnCfg, chnSrt); err != nil {
+		klog.Error(err, " - Unable to set the helm repo TLS config")
+
+		return nil, err
+	}
 
-	return client, nil
+	return &http.Client{Transport: transport}, nil
 }
 
 // getHelmRepoIndex retreives the index.yaml, loads it into a repo.IndexFile and filters it
@@ -584,19 +625,15 @@ func getChartIndexWithChannel(hubClt client.Client, channel *chnv1.Channel, sub
 
 	chSecret := &corev1.Secret{}
 
-	if channel.Spec.SecretRef != nil {
-		srtNs := channel.GetNamespace()
-
-		chnSecretKey := types.NamespacedName{
-			Name:      channel.Spec.SecretRef.Name,
-			Namespace: srtNs,
+	if utils.HasChannelSecret(channel) {
+		secret, err := utils.ResolveChannelSecret(hubClt, channel)
+		if err != nil {
+			return nil, gerr.Wrapf(err, "failed to get reference secret from channel %v/%v", channel.Namespace, channel.Name)
 		}
 
-		if err := hubClt.Get(context.TODO(), chnSecretKey, chSecret); err != nil {
-			return nil, gerr.Wrapf(err, "failed to get reference secret %v from channel", chnSecretKey.String())
-		}
+		chSecret = secret
 
-		klog.Infof("got secret %v from channel %v", chnSecretKey.String(), channel)
+		klog.Infof("got secret %v from channel %v/%v", chSecret.Name, channel.Namespace, channel.Name)
 	}
 
 	chnCfg := &corev1.ConfigMap{}
@@ -620,7 +657,7 @@ func getChartIndexWithChannel(hubClt client.Client, channel *chnv1.Channel, sub
 		klog.Infof("got configmap %v from channel %v", chnCfgKey.String(), channel)
 	}
 
-	httpClient, err := getHelmRepoClient(chnCfg, channel.Spec.InsecureSkipVerify)
+	httpClient, err := getHelmRepoClient(chnCfg, chSecret, channel.Spec.InsecureSkipVerify)
 
 	if err != nil {
 		return nil, gerr.Wrapf(err, "Unable to create client for helm repo %v", channel.Spec.Pathname)
@@ -682,7 +719,7 @@ func loadIndex(data []byte) (*repo.IndexFile, error) {
 
 // hashKey Calculate a hash key
 func hashKey(b []byte) string {
-	h := sha1.New() // #nosec G401 Used only to generate random value to be used to generate hash string
+	h := utils.NewNameHash()
 	_, err := h.Write(b)
 
 	if err != nil {
@@ -695,6 +732,12 @@ func hashKey(b []byte) string {
 func (hrsi *SubscriberItem) manageHelmCR(indexFile *repo.IndexFile) error {
 	var doErr error
 
+	if err := utils.ValidateHelmReleaseNames(hrsi.Subscription, indexFile); err != nil {
+		klog.Error("failed to validate the helmrelease CR names, err: ", err)
+
+		return err
+	}
+
 	resources := make([]kubesynchronizer.ResourceUnit, 0)
 
 	//Loop on all packages selected by the subscription
@@ -723,7 +766,11 @@ func (hrsi *SubscriberItem) manageHelmCR(indexFile *repo.IndexFile) error {
 				hrsi.Subscription.Namespace, hrsi.Subscription.Name)
 		}
 
-		if err := hrsi.synchronizer.ProcessSubResources(hrsi.Subscription, resources, nil, nil, false, false); err != nil {
+		appliedSub := hrsi.Subscription.DeepCopy()
+		utils.SetAppsubRevision(appliedSub, getChartRevision(indexFile))
+		utils.SetAppsubSource(appliedSub, hrsi.repoURL)
+
+		if err := hrsi.synchronizer.ProcessSubResources(appliedSub, resources, nil, nil, false, false); err != nil {
 			klog.Warningf("failed to put helm manifest to cache (will retry), err: %v", err)
 			doErr = err
 		}
@@ -732,6 +779,27 @@ func (hrsi *SubscriberItem) manageHelmCR(indexFile *repo.IndexFile) error {
 	return doErr
 }
 
+// getChartRevision returns the chart version subscribed, or a sorted list of name:version when multiple charts are selected
+func getChartRevision(indexFile *repo.IndexFile) string {
+	revisions := []string{}
+
+	for packageName, chartVersions := range indexFile.Entries {
+		if len(chartVersions) == 0 || chartVersions[0] == nil || chartVersions[0].Metadata == nil {
+			continue
+		}
+
+		revisions = append(revisions, packageName+":"+chartVersions[0].Version)
+	}
+
+	if len(revisions) == 1 {
+		return strings.SplitN(revisions[0], ":", 2)[1]
+	}
+
+	sort.Strings(revisions)
+
+	return strings.Join(revisions, ",")
+}
+
 func isParentMultiClusterHub(sub *appv1.Subscription) bool {
 	if sub != nil && sub.GetOwnerReferences() != nil {
 		for _, appsubOwner := range sub.GetOwnerReferences() {
@@ -21,6 +21,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -73,3 +75,21 @@ var _ = Describe("", func() {
 		Expect(defaultSubscriber.UnsubscribeItem(sharedkey)).NotTo(HaveOccurred())
 	})
 })
+
+func TestGetChartRevision(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	indexFile := &repo.IndexFile{
+		Entries: map[string]repo.ChartVersions{
+			"nginx-ingress": {&repo.ChartVersion{Metadata: &chart.Metadata{Name: "nginx-ingress", Version: "1.26.0"}}},
+		},
+	}
+
+	g.Expect(getChartRevision(indexFile)).To(Equal("1.26.0"))
+
+	indexFile.Entries["mariadb"] = repo.ChartVersions{&repo.ChartVersion{Metadata: &chart.Metadata{Name: "mariadb", Version: "7.3.1"}}}
+
+	g.Expect(getChartRevision(indexFile)).To(Equal("mariadb:7.3.1,nginx-ingress:1.26.0"))
+
+	g.Expect(getChartRevision(&repo.IndexFile{})).To(Equal(""))
+}
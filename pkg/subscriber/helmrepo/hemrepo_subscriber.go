@@ -106,6 +106,7 @@ func (hrs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		hrssubitem = &SubscriberItem{}
 		hrssubitem.syncinterval = hrs.syncinterval
 		hrssubitem.synchronizer = hrs.synchronizer
+		hrssubitem.delayFirstReconcile = utils.IsSubscriptionDeployed(subitem.Subscription)
 	}
 
 	subitem.DeepCopyInto(&hrssubitem.SubscriberItem)
@@ -114,7 +115,9 @@ func (hrs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 	hrs.itemmap[itemkey] = hrssubitem
 
 	previousReconcileLevel := hrssubitem.reconcileRate
+	previousReconcileInterval := hrssubitem.reconcileInterval
 	previousSyncTime := hrssubitem.syncTime
+	previousReferencesVersion := hrssubitem.referencesVersion
 
 	chnAnnotations := hrssubitem.Channel.GetAnnotations()
 
@@ -127,6 +130,7 @@ func (hrs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 
 	hrssubitem.reconcileRate = utils.GetReconcileRate(chnAnnotations, subAnnotations)
 	hrssubitem.syncTime = subAnnotations[appv1alpha1.AnnotationManualReconcileTime]
+	hrssubitem.referencesVersion = utils.GetChannelReferencesVersion(subitem)
 
 	// Reconcile level can be overridden to be
 	if strings.EqualFold(subAnnotations[appv1alpha1.AnnotationResourceReconcileLevel], "off") {
@@ -134,6 +138,8 @@ func (hrs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		hrssubitem.reconcileRate = "off"
 	}
 
+	hrssubitem.reconcileInterval, _, _ = utils.GetSubscriptionReconcileInterval(hrssubitem.reconcileRate, hrssubitem.Channel, hrssubitem.Subscription)
+
 	var restart = false
 
 	if previousReconcileLevel != "" && !strings.EqualFold(previousReconcileLevel, hrssubitem.reconcileRate) {
@@ -141,6 +147,12 @@ func (hrs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		restart = true
 	}
 
+	if previousReconcileInterval != 0 && previousReconcileInterval != hrssubitem.reconcileInterval {
+		klog.Infof("reconcile interval has changed from %v to %v. restart to reconcile resources", previousReconcileInterval, hrssubitem.reconcileInterval)
+
+		restart = true
+	}
+
 	// If manual sync time is updated, we want to restart the reconcile cycle and deploy the new commit immediately
 	if !strings.EqualFold(previousSyncTime, hrssubitem.syncTime) {
 		klog.Infof("Manual reconcile time has changed from %s to %s. restart to reconcile resources", previousSyncTime, hrssubitem.syncTime)
@@ -148,6 +160,13 @@ func (hrs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		restart = true
 	}
 
+	// If the channel credentials have changed, we want to restart the reconcile cycle with them immediately
+	if previousReferencesVersion != "" && previousReferencesVersion != hrssubitem.referencesVersion {
+		klog.Infof("channel references of SubscriberItem %v have changed. restart to reconcile resources", itemkey)
+
+		restart = true
+	}
+
 	hrssubitem.Start(restart)
 
 	return nil
@@ -42,6 +42,7 @@ type SyncSource interface {
 	ProcessSubResources(*appv1alpha1.Subscription, []kubesynchronizer.ResourceUnit,
 		map[string]map[string]string, map[string]map[string]string, bool, bool) error
 	PurgeAllSubscribedResources(*appv1alpha1.Subscription) error
+	RecordEvent(*appv1alpha1.Subscription, string, string, error)
 }
 
 // Subscriber - information to run object bucket subscription.
@@ -106,6 +107,7 @@ func (obs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		obssubitem = &SubscriberItem{}
 		obssubitem.syncinterval = obs.syncinterval
 		obssubitem.synchronizer = obs.synchronizer
+		obssubitem.delayFirstReconcile = utils.IsSubscriptionDeployed(subitem.Subscription)
 	}
 
 	subitem.DeepCopyInto(&obssubitem.SubscriberItem)
@@ -113,7 +115,9 @@ func (obs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 	obs.itemmap[itemkey] = obssubitem
 
 	previousReconcileLevel := obssubitem.reconcileRate
+	previousReconcileInterval := obssubitem.reconcileInterval
 	previousSyncTime := obssubitem.syncTime
+	previousReferencesVersion := obssubitem.referencesVersion
 
 	chnAnnotations := obssubitem.Channel.GetAnnotations()
 	subAnnotations := obssubitem.Subscription.GetAnnotations()
@@ -125,6 +129,7 @@ func (obs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 
 	obssubitem.reconcileRate = utils.GetReconcileRate(chnAnnotations, subAnnotations)
 	obssubitem.syncTime = subAnnotations[appv1alpha1.AnnotationManualReconcileTime]
+	obssubitem.referencesVersion = utils.GetChannelReferencesVersion(subitem)
 
 	// Reconcile level can be overridden to be
 	if strings.EqualFold(subAnnotations[appv1alpha1.AnnotationResourceReconcileLevel], "off") {
@@ -132,6 +137,8 @@ func (obs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		obssubitem.reconcileRate = "off"
 	}
 
+	obssubitem.reconcileInterval, _, _ = utils.GetSubscriptionReconcileInterval(obssubitem.reconcileRate, obssubitem.Channel, obssubitem.Subscription)
+
 	var restart = false
 
 	if previousReconcileLevel != "" && !strings.EqualFold(previousReconcileLevel, obssubitem.reconcileRate) {
@@ -139,6 +146,12 @@ func (obs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		restart = true
 	}
 
+	if previousReconcileInterval != 0 && previousReconcileInterval != obssubitem.reconcileInterval {
+		klog.Infof("reconcile interval has changed from %v to %v. restart to reconcile resources", previousReconcileInterval, obssubitem.reconcileInterval)
+
+		restart = true
+	}
+
 	// If manual sync time is updated, we want to restart the reconcile cycle and deploy the new commit immediately
 	if !strings.EqualFold(previousSyncTime, obssubitem.syncTime) {
 		klog.Infof("Manual reconcile time has changed from %s to %s. restart to reconcile resources", previousSyncTime, obssubitem.syncTime)
@@ -146,6 +159,13 @@ func (obs *Subscriber) SubscribeItem(subitem *appv1alpha1.SubscriberItem) error
 		restart = true
 	}
 
+	// If the channel credentials have changed, we want to restart the reconcile cycle with them immediately
+	if previousReferencesVersion != "" && previousReferencesVersion != obssubitem.referencesVersion {
+		klog.Infof("channel references of SubscriberItem %v have changed. restart to reconcile resources", itemkey)
+
+		restart = true
+	}
+
 	obssubitem.Start(restart)
 
 	return nil
@@ -15,18 +15,23 @@
 package objectbucket
 
 import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
 
-	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/health"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
 	kubesynchronizer "open-cluster-management.io/multicloud-operators-subscription/pkg/synchronizer/kubernetes"
 
@@ -36,19 +41,26 @@ import (
 
 var SubscriptionGVK = schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Kind: "Subscription", Version: "v1"}
 
+// objectRevisionLength is the length of the object contents digest reported as the applied revision
+const objectRevisionLength = 12
+
 // SubscriberItem - defines the unit of namespace subscription.
 type SubscriberItem struct {
 	appv1.SubscriberItem
 
-	reconcileRate string
-	syncTime      string
-	bucket        string
-	objectStore   awsutils.ObjectStore
-	stopch        chan struct{}
-	successful    bool
-	clusterAdmin  bool
-	syncinterval  int
-	synchronizer  SyncSource
+	reconcileRate       string
+	reconcileInterval   time.Duration
+	syncTime            string
+	referencesVersion   string
+	bucket              string
+	objectStore         awsutils.ObjectStore
+	publicKey           crypto.PublicKey
+	stopch              chan struct{}
+	successful          bool
+	clusterAdmin        bool
+	delayFirstReconcile bool
+	syncinterval        int
+	synchronizer        SyncSource
 }
 
 // SubscribeItem subscribes a subscriber item with namespace channel.
@@ -68,7 +80,7 @@ func (obsi *SubscriberItem) Start(restart bool) {
 
 	obsi.stopch = make(chan struct{})
 
-	loopPeriod, retryInterval, retries := utils.GetReconcileInterval(obsi.reconcileRate, chnv1.ChannelTypeObjectBucket)
+	loopPeriod, retryInterval, retries := utils.GetSubscriptionReconcileInterval(obsi.reconcileRate, obsi.Channel, obsi.Subscription)
 	klog.Infof("reconcileRate: %v, loopPeriod: %v, retryInterval: %v, retries: %v", obsi.reconcileRate, loopPeriod, retryInterval, retries)
 
 	if strings.EqualFold(obsi.reconcileRate, "off") {
@@ -79,7 +91,30 @@ func (obsi *SubscriberItem) Start(restart bool) {
 		return
 	}
 
-	go wait.Until(func() {
+	stopch := obsi.stopch
+	startDelay := time.Duration(0)
+
+	// spread the first reconcile of the subscriber items already deployed before the agent started across their
+	// reconcile interval, so they don't all reconcile at the same time
+	if obsi.delayFirstReconcile {
+		startDelay = utils.GetReconcileStartDelay(types.NamespacedName{Namespace: obsi.Subscription.Namespace,
+			Name: obsi.Subscription.Name}, loopPeriod)
+		obsi.delayFirstReconcile = false
+	}
+
+	go wait.JitterUntil(func() {
+		if startDelay > 0 {
+			klog.Infof("Delaying the first reconcile of the SubscriberItem %v by %v", obsi.Subscription.Name, startDelay)
+
+			select {
+			case <-time.After(startDelay):
+			case <-stopch:
+				return
+			}
+
+			startDelay = 0
+		}
+
 		tw := obsi.SubscriberItem.Subscription.Spec.TimeWindow
 		if tw != nil {
 			nextRun := utils.NextStartPoint(tw, time.Now())
@@ -100,7 +135,7 @@ func (obsi *SubscriberItem) Start(restart bool) {
 		}
 
 		obsi.doSubscriptionWithRetries(retryInterval, retries)
-	}, loopPeriod, obsi.stopch)
+	}, loopPeriod, utils.ReconcileJitterFactor, true, stopch)
 }
 
 // Stop the subscriber.
@@ -195,6 +230,17 @@ func (obsi *SubscriberItem) getAwsHandler(primary bool) error {
 		return err
 	}
 
+	channel := obsi.Channel
+	if !primary {
+		channel = obsi.SecondaryChannel
+	}
+
+	publicKey, err := utils.GetChannelCosignPublicKey(channel)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
 	klog.V(1).Info("Trying to connect to object bucket ", endpoint, "|", obsi.bucket)
 
 	if err := awshandler.InitObjectStoreConnection(
@@ -209,6 +255,7 @@ func (obsi *SubscriberItem) getAwsHandler(primary bool) error {
 	}
 
 	obsi.objectStore = awshandler
+	obsi.publicKey = publicKey
 
 	return nil
 }
@@ -256,6 +303,9 @@ func (obsi *SubscriberItem) doSubscriptionWithRetries(retryInterval time.Duratio
 }
 
 func (obsi *SubscriberItem) doSubscription() {
+	defer utils.AcquireReconcileSlot(metrics.ChannelTypeObjectBucket)()
+	defer health.Track(health.OperationSubscribe, obsi.Subscription.Namespace, obsi.Subscription.Name)()
+
 	var folderName *string
 
 	//Update the secret and config map
@@ -346,8 +396,16 @@ func (obsi *SubscriberItem) doSubscription() {
 
 	tpls := []unstructured.Unstructured{}
 
+	// the revision applied is the digest of all the object contents, in key order
+	revision := sha256.New()
+
 	// converting template from obeject store to DPL
 	for _, key := range keys {
+		// the signature objects are verified with their manifest object
+		if awsutils.IsSignatureKey(key) {
+			continue
+		}
+
 		tplb, err := obsi.objectStore.Get(obsi.bucket, key)
 		if err != nil {
 			klog.Error("Failed to get object ", key, " in bucket ", obsi.bucket)
@@ -364,6 +422,25 @@ func (obsi *SubscriberItem) doSubscription() {
 			continue
 		}
 
+		if obsi.publicKey != nil {
+			err := awsutils.VerifyObjectSignature(obsi.objectStore, obsi.bucket, key, tplb.Content, keys, obsi.publicKey)
+			if err != nil {
+				klog.Error("Failed to verify the cosign signature of ", obsi.bucket, "/", key, " err: ", err)
+				obsi.synchronizer.RecordEvent(obsi.Subscription, utils.EventReasonSignatureVerificationFailed,
+					fmt.Sprintf("Failed to verify the cosign signature of %v, no manifest is deployed: %v", key, err), err)
+
+				obsi.successful = false
+				metrics.LocalDeploymentFailedPullTime.
+					WithLabelValues(obsi.SubscriberItem.Subscription.Namespace, obsi.SubscriberItem.Subscription.Name).
+					Observe(0)
+
+				return
+			}
+		}
+
+		revision.Write([]byte(key))
+		revision.Write(tplb.Content)
+
 		tpl := &unstructured.Unstructured{}
 		err = yaml.Unmarshal(tplb.Content, tpl)
 
@@ -401,7 +478,11 @@ func (obsi *SubscriberItem) doSubscription() {
 
 	allowedGroupResources, deniedGroupResources := utils.GetAllowDenyLists(*obsi.Subscription)
 
-	if err := obsi.synchronizer.ProcessSubResources(obsi.Subscription, resources, allowedGroupResources, deniedGroupResources, false, false); err != nil {
+	appliedSub := obsi.Subscription.DeepCopy()
+	utils.SetAppsubRevision(appliedSub, hex.EncodeToString(revision.Sum(nil))[:objectRevisionLength])
+	utils.SetAppsubSource(appliedSub, obsi.Channel.Spec.Pathname)
+
+	if err := obsi.synchronizer.ProcessSubResources(appliedSub, resources, allowedGroupResources, deniedGroupResources, false, false); err != nil {
 		klog.Error(err)
 
 		obsi.successful = false
@@ -492,6 +573,10 @@ func (obsi *SubscriberItem) doSubscribeManifest(template *unstructured.Unstructu
 			rscAnnotations[appv1.AnnotationResourceReconcileOption] = subAnnotations[appv1.AnnotationResourceReconcileOption]
 		}
 
+		if rscAnnotations[appv1.AnnotationResourceUpdateStrategy] == "" && subAnnotations[appv1.AnnotationResourceUpdateStrategy] != "" {
+			rscAnnotations[appv1.AnnotationResourceUpdateStrategy] = subAnnotations[appv1.AnnotationResourceUpdateStrategy]
+		}
+
 		template.SetAnnotations(rscAnnotations)
 	}
 
@@ -505,8 +590,9 @@ func (obsi *SubscriberItem) doSubscribeManifest(template *unstructured.Unstructu
 			template.SetNamespace(obsi.Subscription.Namespace)
 		}
 	} else {
-		klog.Info("No cluster-admin. Setting it to subscription namespace " + obsi.Subscription.Namespace)
-		template.SetNamespace(obsi.Subscription.Namespace)
+		namespace := utils.GetRestrictedResourceNamespace(obsi.Subscription, template.GetNamespace())
+		klog.Info("No cluster-admin. Setting it to namespace " + namespace)
+		template.SetNamespace(namespace)
 	}
 
 	resource := &kubesynchronizer.ResourceUnit{Resource: template, Gvk: validgvk}
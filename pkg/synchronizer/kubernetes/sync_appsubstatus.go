@@ -13,12 +13,14 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -109,7 +111,8 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 	// If the incoming new appstatus is only one HelmRelease kind resource, skip the appsubstatus sync-up.
 	// Later the helmrelease controller will update the actual resources to the appsubstatus
 	if shouldSkip(appsubClusterStatus, foundPkgStatus, *pkgstatus) {
-		return nil
+		// the HelmRelease controller reports the chart resources, the provenance of the chart is recorded here
+		return sync.updateAppsubStatusProvenance(pkgstatus, appsubClusterStatus.Provenance)
 	}
 
 	if foundPkgStatus && skipUpd {
@@ -118,8 +121,8 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 
 		// Update result in cluster AppsubReport
 		if err := updateAppsubReportResult(sync.RemoteClient, appsubClusterStatus.AppSub.Namespace,
-			appsubName, appsubClusterStatus.Cluster, false,
-			sync.standalone, isLocalCluster); err != nil {
+			appsubName, appsubClusterStatus.Cluster, appsubClusterStatus.Commit, false,
+			sync.standalone, isLocalCluster, sync.reportBatcher); err != nil {
 			return err
 		}
 
@@ -146,19 +149,24 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 		deployFailed := false // true if there is a package with phase failed
 		deployFailedMsg := ""
 		newUnitStatus := []v1alpha1.SubscriptionUnitStatus{}
+		trigger := sync.reconcileTrigger(appsub)
 
 		for _, resource := range appsubClusterStatus.SubscriptionPackageStatus {
 			klog.V(1).Infof("resource status - Name: %v, Namespace: %v, Apiversion: %v, Kind: %v, Phase: %v, Message: %v\n",
 				resource.Name, resource.Namespace, resource.APIVersion, resource.Kind, resource.Phase, resource.Message)
 
+			now := metaV1.Time{Time: time.Now()}
 			uS := &v1alpha1.SubscriptionUnitStatus{
-				Name:           resource.Name,
-				APIVersion:     resource.APIVersion,
-				Kind:           resource.Kind,
-				Namespace:      resource.Namespace,
-				Phase:          v1alpha1.PackagePhase(resource.Phase),
-				Message:        resource.Message,
-				LastUpdateTime: metaV1.Time{Time: time.Now()},
+				Name:               resource.Name,
+				APIVersion:         resource.APIVersion,
+				Kind:               resource.Kind,
+				Namespace:          resource.Namespace,
+				Phase:              v1alpha1.PackagePhase(resource.Phase),
+				Message:            utils.TruncateString(resource.Message, utils.MaxPackageMessageLength),
+				Field:              resource.Field,
+				Images:             resource.Images,
+				LastUpdateTime:     now,
+				LastTransitionTime: now,
 			}
 			newUnitStatus = append(newUnitStatus, *uS)
 
@@ -182,8 +190,11 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 
 			// Create new appsubstatus
 			pkgstatus = buildAppSubStatus(pkgstatusName, pkgstatusNs, appsubName,
-				appsubClusterStatus.AppSub.Namespace, appsubClusterStatus.Cluster, newUnitStatus,
+				appsubClusterStatus.AppSub.Namespace, appsubClusterStatus.Cluster, appsubClusterStatus.Commit, newUnitStatus,
 				deployFailed, deployFailedMsg)
+			pkgstatus.Statuses.History = appendAuditRecord(nil, newAuditRecord(trigger, appsubClusterStatus.Commit,
+				appsubClusterStatus.Commit != "", pkgstatus.Statuses.SubscriptionStatus.Phase, nil, newUnitStatus, nil))
+			pkgstatus.Statuses.Provenance = updateProvenance(nil, appsubClusterStatus.Provenance)
 			klog.Infof("Creating new appsubstatus: %v/%v", pkgstatus.Namespace, pkgstatus.Name)
 
 			// Create appsubstatus on appSub NS
@@ -193,6 +204,10 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 				return err
 			}
 		} else {
+			origPkgstatus := pkgstatus.DeepCopy()
+			prevUnitStatuses := pkgstatus.Statuses.SubscriptionPackageStatus
+			prunedUnitStatuses := []v1alpha1.SubscriptionUnitStatus{}
+
 			if isLocalCluster && foundPkgStatus && len(pkgstatus.Statuses.SubscriptionPackageStatus) == 1 &&
 				strings.EqualFold(pkgstatus.Statuses.SubscriptionPackageStatus[0].Kind, "HelmRelease") &&
 				strings.EqualFold(pkgstatus.Statuses.SubscriptionPackageStatus[0].APIVersion, "apps.open-cluster-management.io/v1") &&
@@ -244,14 +259,27 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 						Namespace: appsubClusterStatus.AppSub.Namespace,
 						Name:      appsubName,
 					}
-					if err := sync.DeleteSingleSubscribedResource(hostSub, resource); err != nil {
+					if retained, err := sync.deleteSubscribedResource(hostSub, resource); err != nil {
 						klog.Errorf("Error deleting subscription resource:%v", err)
 
 						failedUnitStatus := resource.DeepCopy()
 						failedUnitStatus.Phase = v1alpha1.PackageDeployFailed
-						failedUnitStatus.Message = err.Error()
+						failedUnitStatus.Message = utils.RedactError(err)
 
 						newUnitStatus = append(newUnitStatus, *failedUnitStatus)
+					} else if retained {
+						// the retained resource stays in the status, it is pruned once the annotation is removed
+						retainedUnitStatus := resource.DeepCopy()
+						retainedUnitStatus.Phase = v1alpha1.PackageDeployed
+						retainedUnitStatus.Message = retainedMessage
+
+						newUnitStatus = append(newUnitStatus, *retainedUnitStatus)
+
+						if resource.Message != retainedMessage {
+							sync.recordRetained(appsub, resource)
+						}
+					} else {
+						prunedUnitStatuses = append(prunedUnitStatuses, resource)
 					}
 				}
 
@@ -281,7 +309,7 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 				klog.V(1).Infof("Delete result from cluster AppsubReport:%v/%v", pkgstatus.Namespace, pkgstatus.Name)
 
 				if err := deleteAppsubReportResult(sync.RemoteClient, appsubClusterStatus.AppSub.Namespace,
-					appsubName, appsubClusterStatus.Cluster, sync.standalone); err != nil {
+					appsubName, appsubClusterStatus.Cluster, sync.standalone, sync.reportBatcher); err != nil {
 					return err
 				}
 
@@ -302,16 +330,35 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 				subStatusMessage = deployFailedMsg
 			}
 
-			if subStatusPhase != pkgstatus.Statuses.SubscriptionStatus.Phase || subStatusMessage != pkgstatus.Statuses.SubscriptionStatus.Message {
+			subStatusCommit := pkgstatus.Statuses.SubscriptionStatus.Commit
+			if appsubClusterStatus.Commit != "" {
+				subStatusCommit = appsubClusterStatus.Commit
+			}
+
+			pkgstatus.Statuses.History = appendAuditRecord(pkgstatus.Statuses.History,
+				newAuditRecord(trigger, subStatusCommit, subStatusCommit != pkgstatus.Statuses.SubscriptionStatus.Commit,
+					subStatusPhase, prevUnitStatuses, newUnitStatus, prunedUnitStatuses))
+
+			if subStatusPhase != pkgstatus.Statuses.SubscriptionStatus.Phase || subStatusMessage != pkgstatus.Statuses.SubscriptionStatus.Message ||
+				subStatusCommit != pkgstatus.Statuses.SubscriptionStatus.Commit {
 				pkgstatus.Statuses.SubscriptionStatus = v1alpha1.SubscriptionOverallStatus{
 					Phase:          subStatusPhase,
 					Message:        subStatusMessage,
+					Commit:         subStatusCommit,
 					LastUpdateTime: metaV1.Time{Time: time.Now()},
 				}
 			}
 
-			pkgstatus.Statuses.SubscriptionPackageStatus = newUnitStatus
-			if err := sync.LocalClient.Update(context.TODO(), pkgstatus); err != nil {
+			pkgstatus.Statuses.SubscriptionPackageStatus = keepUnitUpdateTimes(prevUnitStatuses, newUnitStatus)
+			pkgstatus.Statuses.Provenance = updateProvenance(pkgstatus.Statuses.Provenance, appsubClusterStatus.Provenance)
+
+			// the appsubstatus created before the backup labels is labeled on its next update
+			utils.SetExcludeFromBackupLabel(pkgstatus)
+
+			// only write the appsubstatus on a material change
+			if equality.Semantic.DeepEqual(origPkgstatus, pkgstatus) {
+				klog.V(1).Infof("No change in appsubstatus:%v/%v, skip the update", pkgstatus.Namespace, pkgstatus.Name)
+			} else if err := sync.LocalClient.Update(context.TODO(), pkgstatus); err != nil {
 				klog.Errorf("Error in updating on managed cluster, appsubstatus:%v/%v, err:%v", pkgstatus.Namespace, pkgstatusName, err)
 				return err
 			}
@@ -319,8 +366,8 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 
 		// Update result in cluster AppsubReport
 		if err := updateAppsubReportResult(sync.RemoteClient, appsubClusterStatus.AppSub.Namespace,
-			appsubName, appsubClusterStatus.Cluster, deployFailed,
-			sync.standalone, isLocalCluster); err != nil {
+			appsubName, appsubClusterStatus.Cluster, appsubClusterStatus.Commit, deployFailed,
+			sync.standalone, isLocalCluster, sync.reportBatcher); err != nil {
 			return err
 		}
 	}
@@ -389,7 +436,7 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 			klog.V(1).Infof("Delete result from cluster AppsubReport:%v/%v", pkgstatus.Namespace, pkgstatus.Name)
 
 			if err := deleteAppsubReportResult(sync.RemoteClient, appsubClusterStatus.AppSub.Namespace,
-				appsubName, appsubClusterStatus.Cluster, sync.standalone); err != nil {
+				appsubName, appsubClusterStatus.Cluster, sync.standalone, sync.reportBatcher); err != nil {
 				return err
 			}
 		} else {
@@ -418,8 +465,8 @@ func (sync *KubeSynchronizer) SyncAppsubClusterStatus(appsub *appv1.Subscription
 
 				// Update result in cluster AppsubReport
 				if err := updateAppsubReportResult(sync.RemoteClient, appsubClusterStatus.AppSub.Namespace,
-					appsubName, appsubClusterStatus.Cluster, deployFailed,
-					sync.standalone, isLocalCluster); err != nil {
+					appsubName, appsubClusterStatus.Cluster, "", deployFailed,
+					sync.standalone, isLocalCluster, sync.reportBatcher); err != nil {
 					return err
 				}
 			}
@@ -436,7 +483,7 @@ func (sync *KubeSynchronizer) UpdateAppsubOverallStatus(appsub *appv1.Subscripti
 		if errors.IsNotFound(err) {
 			// Create new appsubstatus
 			pkgstatus = buildAppSubStatus(appsub.Name, appsub.Namespace, appsub.Name, appsub.Namespace,
-				sync.SynchronizerID.Name, []v1alpha1.SubscriptionUnitStatus{}, deployFailed, message)
+				sync.SynchronizerID.Name, "", []v1alpha1.SubscriptionUnitStatus{}, deployFailed, message)
 			klog.Infof("Creating new appsubstatus: %v/%v", pkgstatus.Namespace, pkgstatus.Name)
 
 			// Create appsubstatus on appSub NS
@@ -464,6 +511,7 @@ func (sync *KubeSynchronizer) UpdateAppsubOverallStatus(appsub *appv1.Subscripti
 		pkgstatus.Statuses.SubscriptionStatus = v1alpha1.SubscriptionOverallStatus{
 			Phase:          subStatusPhase,
 			Message:        subStatusMessage,
+			Commit:         pkgstatus.Statuses.SubscriptionStatus.Commit,
 			LastUpdateTime: metaV1.Time{Time: time.Now()},
 		}
 	}
@@ -476,8 +524,8 @@ func (sync *KubeSynchronizer) UpdateAppsubOverallStatus(appsub *appv1.Subscripti
 	// Update result in cluster AppsubReport
 	localCluster := isLocalCluster(sync.hub, sync.standalone, sync.SynchronizerID.Name, appsub.Name)
 
-	if err := updateAppsubReportResult(sync.RemoteClient, appsub.Namespace, appsub.Name, sync.SynchronizerID.Name,
-		deployFailed, sync.standalone, localCluster); err != nil {
+	if err := updateAppsubReportResult(sync.RemoteClient, appsub.Namespace, appsub.Name, sync.SynchronizerID.Name, "",
+		deployFailed, sync.standalone, localCluster, sync.reportBatcher); err != nil {
 		return err
 	}
 
@@ -505,7 +553,7 @@ func (sync *KubeSynchronizer) recordAppSubStatusEvents(appsub *appv1.Subscriptio
 	sync.eventrecorder.RecordEvent(appsub, action, packageStatuses, nil)
 }
 
-func buildAppSubStatus(statusName, statusNs, appsubName, appsubNs, cluster string,
+func buildAppSubStatus(statusName, statusNs, appsubName, appsubNs, cluster, commit string,
 	unitStatuses []v1alpha1.SubscriptionUnitStatus, deployFailed bool, deployFailedMsg string) *v1alpha1.SubscriptionStatus {
 	pkgstatus := &v1alpha1.SubscriptionStatus{
 		TypeMeta: metaV1.TypeMeta{
@@ -519,6 +567,7 @@ func buildAppSubStatus(statusName, statusNs, appsubName, appsubNs, cluster strin
 	labels := map[string]string{
 		"apps.open-cluster-management.io/cluster":              cluster,
 		"apps.open-cluster-management.io/hosting-subscription": fmt.Sprintf("%.63s", appsubNs+"."+appsubName),
+		utils.ExcludeFromBackupLabel:                           "true",
 	}
 	pkgstatus.Labels = labels
 
@@ -533,13 +582,41 @@ func buildAppSubStatus(statusName, statusNs, appsubName, appsubNs, cluster strin
 		pkgstatus.Statuses.SubscriptionStatus.Message = ""
 	}
 
+	pkgstatus.Statuses.SubscriptionStatus.Commit = commit
 	pkgstatus.Statuses.SubscriptionStatus.LastUpdateTime = metaV1.Time{Time: time.Now()}
 
 	return pkgstatus
 }
 
+// keepUnitUpdateTimes keeps the last update time of the unit statuses that didn't change, so an unchanged
+// appsubstatus is not rewritten, and the last transition time of the unit statuses whose phase didn't change
+func keepUnitUpdateTimes(prevUnitStatuses, newUnitStatuses []v1alpha1.SubscriptionUnitStatus) []v1alpha1.SubscriptionUnitStatus {
+	for i, newUnit := range newUnitStatuses {
+		for _, prevUnit := range prevUnitStatuses {
+			if prevUnit.Name != newUnit.Name || prevUnit.Namespace != newUnit.Namespace || prevUnit.Kind != newUnit.Kind ||
+				prevUnit.APIVersion != newUnit.APIVersion || prevUnit.Phase != newUnit.Phase {
+				continue
+			}
+
+			// the statuses written before the transition times were added have none
+			if !prevUnit.LastTransitionTime.IsZero() {
+				newUnitStatuses[i].LastTransitionTime = prevUnit.LastTransitionTime
+			}
+
+			if prevUnit.Message == newUnit.Message && prevUnit.Field == newUnit.Field &&
+				slices.Equal(prevUnit.Images, newUnit.Images) {
+				newUnitStatuses[i].LastUpdateTime = prevUnit.LastUpdateTime
+			}
+
+			break
+		}
+	}
+
+	return newUnitStatuses
+}
+
 func updateAppsubReportResult(rClient client.Client, appsubNs, appsubName,
-	clusterAppsubReportNs string, deployFailed, standalone, isLocalCluster bool) error {
+	clusterAppsubReportNs, commit string, deployFailed, standalone, isLocalCluster bool, batcher *reportBatcher) error {
 	// For managed clusters, get cluster AppsubReport
 	var appsubReport *v1alpha1.SubscriptionReport
 
@@ -574,6 +651,17 @@ func updateAppsubReportResult(rClient client.Client, appsubNs, appsubName,
 		klog.V(1).Infof("Standalone appsub for helm, continue")
 	}
 
+	if batcher != nil {
+		result := v1alpha1.SubscriptionResult("deployed")
+		if deployFailed {
+			result = v1alpha1.SubscriptionResult("failed")
+		}
+
+		batcher.add(clusterAppsubReportNs, appsubNs+"/"+appsubName, &pendingResult{result: result, commit: commit})
+
+		return nil
+	}
+
 	appsubReport, err = getClusterAppsubReport(rClient, clusterAppsubReportNs, true)
 	if err != nil {
 		klog.Errorf("Error getting cluster AppsubReport:%v/%v, err:%v", appsubReport.Namespace, appsubReport.Name, err)
@@ -605,11 +693,17 @@ func updateAppsubReportResult(rClient client.Client, appsubNs, appsubName,
 		prFailedResult := &v1alpha1.SubscriptionReportResult{
 			Source:    prResultSource,
 			Result:    result,
+			Commit:    commit,
 			Timestamp: metaV1.Timestamp{Seconds: time.Now().Unix()},
 		}
 		appsubReport.Results = append(appsubReport.Results, prFailedResult)
-	} else if prResultFoundIndex >= 0 && appsubReport.Results[prResultFoundIndex].Result != result {
+	} else if prResultFoundIndex >= 0 && (appsubReport.Results[prResultFoundIndex].Result != result ||
+		commit != "" && appsubReport.Results[prResultFoundIndex].Commit != commit) {
 		appsubReport.Results[prResultFoundIndex].Result = result
+
+		if commit != "" {
+			appsubReport.Results[prResultFoundIndex].Commit = commit
+		}
 	} else {
 		return nil
 	}
@@ -623,7 +717,7 @@ func updateAppsubReportResult(rClient client.Client, appsubNs, appsubName,
 }
 
 func deleteAppsubReportResult(rClient client.Client, appsubNs, appsubName, clusterAppsubReportNs string,
-	standalone bool) error {
+	standalone bool, batcher *reportBatcher) error {
 	source := appsubNs + "/" + appsubName
 	klog.V(1).Infof("Delete AppsubReport result, Namespace:%v, source:%v", clusterAppsubReportNs, source)
 
@@ -653,6 +747,12 @@ func deleteAppsubReportResult(rClient client.Client, appsubNs, appsubName, clust
 		klog.V(1).Infof("Standalone appsub for helm, continue")
 	}
 
+	if batcher != nil {
+		batcher.add(clusterAppsubReportNs, source, &pendingResult{deleted: true})
+
+		return nil
+	}
+
 	appsubReport, err = getClusterAppsubReport(rClient, clusterAppsubReportNs, false)
 
 	if err != nil {
@@ -733,6 +833,7 @@ func getClusterAppsubReport(rClient client.Client, clusterAppsubReportNs string,
 
 				labels := map[string]string{
 					"apps.open-cluster-management.io/cluster": "true",
+					utils.ExcludeFromBackupLabel:              "true",
 				}
 				appsubReport.Labels = labels
 				appsubReport.ReportType = "Cluster"
@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"fmt"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+// maxAuditHistory is the max number of audit records kept in the appsubstatus
+const maxAuditHistory = 10
+
+// auditTriggerMarkers are the subscription annotations changed by a webhook event or a manual refresh
+type auditTriggerMarkers struct {
+	webhookEventCount string
+	manualRefreshTime string
+}
+
+// reconcileTrigger returns what triggered the subscription reconcile, from the changes of the webhook event count
+// and manual refresh annotations since the previous reconcile
+func (sync *KubeSynchronizer) reconcileTrigger(appsub *appv1.Subscription) v1alpha1.SubscriptionTrigger {
+	if appsub == nil {
+		return v1alpha1.TriggerInterval
+	}
+
+	annotations := appsub.GetAnnotations()
+	markers := auditTriggerMarkers{
+		webhookEventCount: annotations[appv1.AnnotationWebhookEventCount],
+		manualRefreshTime: annotations[appv1.AnnotationManualReconcileTime],
+	}
+
+	prev, found := sync.auditTriggers.Swap(types.NamespacedName{Namespace: appsub.Namespace, Name: appsub.Name}, markers)
+	if !found {
+		return v1alpha1.TriggerInterval
+	}
+
+	prevMarkers := prev.(auditTriggerMarkers)
+
+	switch {
+	case prevMarkers.manualRefreshTime != markers.manualRefreshTime:
+		return v1alpha1.TriggerManual
+	case prevMarkers.webhookEventCount != markers.webhookEventCount:
+		return v1alpha1.TriggerWebhook
+	}
+
+	return v1alpha1.TriggerInterval
+}
+
+// newAuditRecord returns the audit record of a reconcile applying newUnits over oldUnits and pruning the pruned
+// resources. nil is returned if an interval reconcile didn't change anything.
+func newAuditRecord(trigger v1alpha1.SubscriptionTrigger, commit string, commitChanged bool, phase v1alpha1.SubscriptionPhase,
+	oldUnits, newUnits, pruned []v1alpha1.SubscriptionUnitStatus) *v1alpha1.SubscriptionAuditRecord {
+	record := &v1alpha1.SubscriptionAuditRecord{
+		Commit:  commit,
+		Trigger: trigger,
+		Phase:   phase,
+		Time:    metaV1.Time{Time: time.Now()},
+	}
+
+	oldResources := map[string]bool{}
+	for _, unit := range oldUnits {
+		oldResources[auditResourceName(unit)] = true
+	}
+
+	for _, unit := range newUnits {
+		if unit.Phase != v1alpha1.PackageDeployed {
+			continue
+		}
+
+		if !oldResources[auditResourceName(unit)] {
+			record.Added = append(record.Added, auditResourceName(unit))
+		} else if commitChanged {
+			record.Changed = append(record.Changed, auditResourceName(unit))
+		}
+	}
+
+	for _, unit := range pruned {
+		record.Pruned = append(record.Pruned, auditResourceName(unit))
+	}
+
+	if trigger == v1alpha1.TriggerInterval && !commitChanged &&
+		len(record.Added) == 0 && len(record.Changed) == 0 && len(record.Pruned) == 0 {
+		return nil
+	}
+
+	return record
+}
+
+// appendAuditRecord adds the record to the top of the history, the oldest records beyond maxAuditHistory are dropped
+func appendAuditRecord(history []v1alpha1.SubscriptionAuditRecord, record *v1alpha1.SubscriptionAuditRecord) []v1alpha1.SubscriptionAuditRecord {
+	if record == nil {
+		return history
+	}
+
+	history = append([]v1alpha1.SubscriptionAuditRecord{*record}, history...)
+
+	if len(history) > maxAuditHistory {
+		history = history[:maxAuditHistory]
+	}
+
+	return history
+}
+
+func auditResourceName(unit v1alpha1.SubscriptionUnitStatus) string {
+	if unit.Namespace == "" {
+		return fmt.Sprintf("%v %v", unit.Kind, unit.Name)
+	}
+
+	return fmt.Sprintf("%v %v/%v", unit.Kind, unit.Namespace, unit.Name)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func TestReconcileTrigger(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sync := &KubeSynchronizer{}
+	appsub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "default"}}
+
+	g.Expect(sync.reconcileTrigger(nil)).To(gomega.Equal(v1alpha1.TriggerInterval))
+	g.Expect(sync.reconcileTrigger(appsub)).To(gomega.Equal(v1alpha1.TriggerInterval))
+
+	appsub.SetAnnotations(map[string]string{appv1.AnnotationWebhookEventCount: "1"})
+	g.Expect(sync.reconcileTrigger(appsub)).To(gomega.Equal(v1alpha1.TriggerWebhook))
+	g.Expect(sync.reconcileTrigger(appsub)).To(gomega.Equal(v1alpha1.TriggerInterval))
+
+	appsub.SetAnnotations(map[string]string{appv1.AnnotationWebhookEventCount: "1", appv1.AnnotationManualReconcileTime: "2026-10-16T12:00:00Z"})
+	g.Expect(sync.reconcileTrigger(appsub)).To(gomega.Equal(v1alpha1.TriggerManual))
+}
+
+func TestAuditHistory(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cm := v1alpha1.SubscriptionUnitStatus{Kind: "ConfigMap", Namespace: "default", Name: "cm", Phase: v1alpha1.PackageDeployed}
+	deploy := v1alpha1.SubscriptionUnitStatus{Kind: "Deployment", Namespace: "default", Name: "app", Phase: v1alpha1.PackageDeployed}
+	ns := v1alpha1.SubscriptionUnitStatus{Kind: "Namespace", Name: "app-ns", Phase: v1alpha1.PackageDeployed}
+	failed := v1alpha1.SubscriptionUnitStatus{Kind: "Secret", Namespace: "default", Name: "s", Phase: v1alpha1.PackageDeployFailed}
+
+	// nothing changed on an interval reconcile
+	g.Expect(newAuditRecord(v1alpha1.TriggerInterval, "abc", false, v1alpha1.SubscriptionDeployed,
+		[]v1alpha1.SubscriptionUnitStatus{cm}, []v1alpha1.SubscriptionUnitStatus{cm}, nil)).To(gomega.BeNil())
+
+	// a webhook reconcile is always recorded
+	record := newAuditRecord(v1alpha1.TriggerWebhook, "abc", false, v1alpha1.SubscriptionDeployed,
+		[]v1alpha1.SubscriptionUnitStatus{cm}, []v1alpha1.SubscriptionUnitStatus{cm}, nil)
+	g.Expect(record).NotTo(gomega.BeNil())
+	g.Expect(record.Added).To(gomega.BeEmpty())
+
+	record = newAuditRecord(v1alpha1.TriggerInterval, "def", true, v1alpha1.SubscriptionDeployFailed,
+		[]v1alpha1.SubscriptionUnitStatus{cm, deploy}, []v1alpha1.SubscriptionUnitStatus{cm, ns, failed},
+		[]v1alpha1.SubscriptionUnitStatus{deploy})
+	g.Expect(record.Added).To(gomega.Equal([]string{"Namespace app-ns"}))
+	g.Expect(record.Changed).To(gomega.Equal([]string{"ConfigMap default/cm"}))
+	g.Expect(record.Pruned).To(gomega.Equal([]string{"Deployment default/app"}))
+	g.Expect(record.Phase).To(gomega.Equal(v1alpha1.SubscriptionDeployFailed))
+
+	var history []v1alpha1.SubscriptionAuditRecord
+	for i := 0; i < maxAuditHistory+2; i++ {
+		history = appendAuditRecord(history, &v1alpha1.SubscriptionAuditRecord{Commit: string(rune('a' + i))})
+	}
+
+	g.Expect(history).To(gomega.HaveLen(maxAuditHistory))
+	g.Expect(history[0].Commit).To(gomega.Equal(string(rune('a' + maxAuditHistory + 1))))
+	g.Expect(appendAuditRecord(history, nil)).To(gomega.HaveLen(maxAuditHistory))
+}
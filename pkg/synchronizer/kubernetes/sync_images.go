@@ -0,0 +1,159 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/imageregistry"
+)
+
+// podSpecPaths are the paths of the pod specs of the workload kinds, by API group and kind
+var podSpecPaths = map[string]map[string][]string{
+	"": {
+		"Pod":                   {"spec"},
+		"ReplicationController": {"spec", "template", "spec"},
+	},
+	"apps": {
+		"Deployment":  {"spec", "template", "spec"},
+		"ReplicaSet":  {"spec", "template", "spec"},
+		"StatefulSet": {"spec", "template", "spec"},
+		"DaemonSet":   {"spec", "template", "spec"},
+	},
+	"batch": {
+		"Job":     {"spec", "template", "spec"},
+		"CronJob": {"spec", "jobTemplate", "spec", "template", "spec"},
+	},
+}
+
+// resolveImage resolves an image with its registry, it is replaced by the tests
+var resolveImage = imageregistry.Resolve
+
+// imageResolution resolves the container images of the resources of a subscription apply, the images are resolved
+// once per apply
+type imageResolution struct {
+	opts    imageregistry.Options
+	optsErr error
+	images  map[string]string // the resolved images, by image as written in the resources
+}
+
+func newImageResolution(sync *KubeSynchronizer, appsub *appv1alpha1.Subscription) *imageResolution {
+	opts, err := utils.GetImageOptions(sync.LocalClient, appsub)
+
+	return &imageResolution{opts: opts, optsErr: err, images: map[string]string{}}
+}
+
+// resolve replaces the container images of the resource with the images resolved with the registries, it returns
+// the resolved images of the resource
+func (r *imageResolution) resolve(ctx context.Context, tplunit *unstructured.Unstructured) ([]string, error) {
+	path, ok := podSpecPaths[tplunit.GroupVersionKind().Group][tplunit.GetKind()]
+	if !ok {
+		return nil, nil
+	}
+
+	resolved := []string{}
+
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, found, err := unstructured.NestedSlice(tplunit.Object, append(path, field)...)
+		if err != nil || !found {
+			continue
+		}
+
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			image, ok := container["image"].(string)
+			if !ok || image == "" {
+				continue
+			}
+
+			if r.optsErr != nil {
+				return nil, r.optsErr
+			}
+
+			newImage, cached := r.images[image]
+			if !cached {
+				if newImage, err = resolveImage(ctx, image, r.opts); err != nil {
+					return nil, err
+				}
+
+				r.images[image] = newImage
+			}
+
+			container["image"] = newImage
+			resolved = append(resolved, newImage)
+		}
+
+		if err := unstructured.SetNestedSlice(tplunit.Object, containers, append(path, field)...); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// ScanImages resolves the container images last applied by the subscription with the registries again, it returns
+// the images that are resolved to a new tag or digest, as "<previous image> -> <new image>"
+func (sync *KubeSynchronizer) ScanImages(appsub *appv1alpha1.Subscription) []string {
+	hostSub := types.NamespacedName{Namespace: appsub.Namespace, Name: appsub.Name}
+
+	value, ok := sync.resolvedImages.Load(hostSub)
+	if !ok {
+		return nil
+	}
+
+	if !utils.IsImageAutomation(appsub) {
+		sync.resolvedImages.Delete(hostSub)
+
+		return nil
+	}
+
+	opts, err := utils.GetImageOptions(sync.LocalClient, appsub)
+	if err != nil {
+		klog.Warningf("Failed to scan the images of the subscription %v, err: %v", hostSub.String(), err)
+
+		return nil
+	}
+
+	updated := []string{}
+
+	for image, previous := range value.(map[string]string) {
+		current, err := resolveImage(context.TODO(), image, opts)
+		if err != nil {
+			klog.Warningf("Failed to scan the image %v of the subscription %v, err: %v", image, hostSub.String(), err)
+
+			continue
+		}
+
+		if current != previous {
+			updated = append(updated, fmt.Sprintf("%v -> %v", previous, current))
+		}
+	}
+
+	sort.Strings(updated)
+
+	return updated
+}
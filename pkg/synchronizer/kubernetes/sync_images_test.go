@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/imageregistry"
+)
+
+// setResolveImage replaces the registry resolution of the images with the resolved images of the test
+func setResolveImage(t *testing.T, resolved map[string]string) {
+	prevResolveImage := resolveImage
+
+	resolveImage = func(_ context.Context, image string, _ imageregistry.Options) (string, error) {
+		newImage, ok := resolved[image]
+		if !ok {
+			return "", errors.New("image not found")
+		}
+
+		return newImage, nil
+	}
+
+	t.Cleanup(func() { resolveImage = prevResolveImage })
+}
+
+func TestImageResolution(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	setResolveImage(t, map[string]string{
+		"nginx:1.25":         "nginx:1.25@sha256:nginx",
+		"quay.io/org/init":   "quay.io/org/init:latest@sha256:init",
+		"quay.io/org/app:v1": "quay.io/org/app:v1@sha256:app",
+	})
+
+	appsub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "app",
+		Annotations: map[string]string{appv1.AnnotationImageResolution: "true"}}}
+
+	sync := &KubeSynchronizer{LocalClient: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	images := newImageResolution(sync, appsub)
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "app"},
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": "quay.io/org/init"}},
+			"containers": []interface{}{
+				map[string]interface{}{"name": "web", "image": "nginx:1.25"},
+				map[string]interface{}{"name": "app", "image": "quay.io/org/app:v1"},
+			},
+		}}},
+	}}
+
+	resolved, err := images.resolve(context.TODO(), deployment)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resolved).To(gomega.Equal([]string{"quay.io/org/init:latest@sha256:init", "nginx:1.25@sha256:nginx",
+		"quay.io/org/app:v1@sha256:app"}))
+
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	g.Expect(containers[0].(map[string]interface{})["image"]).To(gomega.Equal("nginx:1.25@sha256:nginx"))
+
+	// the resources without pod specs are unchanged
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]interface{}{"name": "config", "namespace": "app"}}}
+
+	resolved, err = images.resolve(context.TODO(), configMap)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resolved).To(gomega.BeEmpty())
+
+	// the images that can't be resolved fail the resource
+	job := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]interface{}{"name": "migrate", "namespace": "app"},
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "migrate", "image": "quay.io/org/missing:v1"}},
+		}}},
+	}}
+
+	_, err = images.resolve(context.TODO(), job)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the registry scans report the images resolved to a new digest
+	hostSub := types.NamespacedName{Namespace: appsub.Namespace, Name: appsub.Name}
+	sync.resolvedImages.Store(hostSub, images.images)
+
+	g.Expect(sync.ScanImages(appsub)).To(gomega.BeEmpty())
+
+	setResolveImage(t, map[string]string{
+		"nginx:1.25":         "nginx:1.25@sha256:nginx-patched",
+		"quay.io/org/init":   "quay.io/org/init:latest@sha256:init",
+		"quay.io/org/app:v1": "quay.io/org/app:v1@sha256:app",
+	})
+
+	g.Expect(sync.ScanImages(appsub)).To(gomega.Equal([]string{
+		"nginx:1.25@sha256:nginx -> nginx:1.25@sha256:nginx-patched"}))
+
+	// the images are no longer scanned when the image automation is removed
+	appsub.Annotations = nil
+
+	g.Expect(sync.ScanImages(appsub)).To(gomega.BeEmpty())
+
+	_, ok := sync.resolvedImages.Load(hostSub)
+	g.Expect(ok).To(gomega.BeFalse())
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// newNamespace returns the namespace created for a resource of the subscription, with the labels and annotations of
+// the namespace template of the subscription
+func newNamespace(tplunit *unstructured.Unstructured, appsub *appv1alpha1.Subscription) *corev1.Namespace {
+	ns := &corev1.Namespace{}
+	ns.Name = tplunit.GetNamespace()
+
+	tplanno := tplunit.GetAnnotations()
+	if tplanno == nil {
+		tplanno = make(map[string]string)
+	}
+
+	nsanno := make(map[string]string)
+
+	if appsub.Spec.NamespaceTemplate != nil {
+		if len(appsub.Spec.NamespaceTemplate.Labels) > 0 {
+			nslbls := make(map[string]string)
+			for k, v := range appsub.Spec.NamespaceTemplate.Labels {
+				nslbls[k] = v
+			}
+
+			ns.SetLabels(nslbls)
+		}
+
+		for k, v := range appsub.Spec.NamespaceTemplate.Annotations {
+			nsanno[k] = v
+		}
+	}
+
+	if tplanno[appv1alpha1.AnnotationHosting] > "" {
+		nsanno[appv1alpha1.AnnotationHosting] = tplanno[appv1alpha1.AnnotationHosting]
+		nsanno[appv1alpha1.AnnotationSyncSource] = "subnsdpl-" + tplanno[appv1alpha1.AnnotationHosting]
+	}
+
+	if tplanno[appv1alpha1.AnnotationClusterAdmin] > "" {
+		// Do this so that nested children subscriptions inherit the cluster-admin role elevation as well.
+		nsanno[appv1alpha1.AnnotationClusterAdmin] = tplanno[appv1alpha1.AnnotationClusterAdmin]
+	}
+
+	ns.SetAnnotations(nsanno)
+
+	return ns
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestNewNamespace(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tplunit := &unstructured.Unstructured{}
+	tplunit.SetNamespace("payments")
+	tplunit.SetAnnotations(map[string]string{
+		appv1.AnnotationHosting:      "appsub-ns/appsub",
+		appv1.AnnotationClusterAdmin: "true",
+	})
+
+	appsub := &appv1.Subscription{}
+
+	ns := newNamespace(tplunit, appsub)
+	g.Expect(ns.Name).To(gomega.Equal("payments"))
+	g.Expect(ns.Labels).To(gomega.BeEmpty())
+	g.Expect(ns.Annotations).To(gomega.Equal(map[string]string{
+		appv1.AnnotationHosting:      "appsub-ns/appsub",
+		appv1.AnnotationSyncSource:   "subnsdpl-appsub-ns/appsub",
+		appv1.AnnotationClusterAdmin: "true",
+	}))
+
+	// the namespace template can't override the hosting annotations
+	appsub.Spec.NamespaceTemplate = &appv1.NamespaceTemplate{
+		Labels: map[string]string{
+			"pod-security.kubernetes.io/enforce": "restricted",
+			"istio-injection":                    "enabled",
+		},
+		Annotations: map[string]string{
+			"openshift.io/node-selector": "tier=payments",
+			appv1.AnnotationHosting:      "other/appsub",
+		},
+	}
+
+	ns = newNamespace(tplunit, appsub)
+	g.Expect(ns.Labels).To(gomega.Equal(appsub.Spec.NamespaceTemplate.Labels))
+	g.Expect(ns.Annotations).To(gomega.Equal(map[string]string{
+		"openshift.io/node-selector": "tier=payments",
+		appv1.AnnotationHosting:      "appsub-ns/appsub",
+		appv1.AnnotationSyncSource:   "subnsdpl-appsub-ns/appsub",
+		appv1.AnnotationClusterAdmin: "true",
+	}))
+
+	// the labels of the namespace are not shared with the template
+	ns.Labels["istio-injection"] = "disabled"
+	g.Expect(appsub.Spec.NamespaceTemplate.Labels["istio-injection"]).To(gomega.Equal("enabled"))
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appSubStatusV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// applyUnit is an overridden resource of a subscription ready to be applied
+type applyUnit struct {
+	resource   ResourceUnit
+	status     SubscriptionUnitStatus
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// checkAdmissionPolicies evaluates the resources of the subscription against the admission policies of the cluster
+// with a server side apply dry run. If a resource violates a policy, it returns true and the statuses of all the
+// resources: the violating resources are failed, the others are skipped. The resources skipped by the allow and deny
+// lists, the paused or read only resources, and the resources of a service account that is not provisioned yet, are
+// not evaluated. The dry run errors other than the admission webhook denials are left to the apply.
+func (sync *KubeSynchronizer) checkAdmissionPolicies(ctx context.Context, appsub *appv1alpha1.Subscription, units []applyUnit,
+	allowlist, denyList map[string]map[string]string, isAdmin bool) ([]SubscriptionUnitStatus, bool) {
+	violations := map[int]*utils.PolicyViolation{}
+	// the clients of the dry runs, the service account may be provisioned by the resources before it is used
+	clients := &applyClients{sync: sync, appsub: appsub}
+
+	for i, unit := range units {
+		tplunit := unit.resource.Resource
+
+		if !utils.AllowApplyTemplate(sync.LocalClient, tplunit) || utils.IsResourceDenied(*tplunit, denyList, isAdmin) ||
+			!utils.IsResourceAllowed(*tplunit, allowlist, isAdmin) || isUpdateStrategy(tplunit, workv1.UpdateStrategyTypeReadOnly) {
+			continue
+		}
+
+		dc, err := clients.get(tplunit, unit.resource.Gvk)
+		if err != nil {
+			klog.V(1).Infof("Skipped the admission policy dry run of %v %v/%v, err: %v", tplunit.GetKind(),
+				tplunit.GetNamespace(), tplunit.GetName(), err)
+
+			continue
+		}
+
+		var ri dynamic.ResourceInterface = dc.Resource(unit.gvr)
+		if unit.namespaced {
+			ri = dc.Resource(unit.gvr).Namespace(tplunit.GetNamespace())
+		}
+
+		obj := tplunit.DeepCopy()
+		obj.SetResourceVersion("")
+		obj.SetManagedFields(nil)
+
+		_, err = ri.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{
+			FieldManager: serverSideApplyFieldManager,
+			DryRun:       []string{metav1.DryRunAll},
+			Force:        true,
+		})
+		if err == nil {
+			continue
+		}
+
+		violation := utils.GetPolicyViolation(err)
+		if violation == nil {
+			klog.V(1).Infof("Ignored the admission policy dry run error of %v %v/%v, err: %v", tplunit.GetKind(),
+				tplunit.GetNamespace(), tplunit.GetName(), err)
+
+			continue
+		}
+
+		klog.Infof("The resource %v %v/%v of the subscription %v/%v %v", tplunit.GetKind(), tplunit.GetNamespace(),
+			tplunit.GetName(), appsub.Namespace, appsub.Name, violation.Error())
+
+		violations[i] = violation
+	}
+
+	if len(violations) == 0 {
+		return nil, false
+	}
+
+	statuses := make([]SubscriptionUnitStatus, 0, len(units))
+
+	for i, unit := range units {
+		status := unit.status
+
+		violation, violated := violations[i]
+		if !violated {
+			status.Phase = string(appSubStatusV1alpha1.PackageSkipped)
+			status.Message = fmt.Sprintf("not applied, %v resources of the subscription violate the admission policies",
+				len(violations))
+			statuses = append(statuses, status)
+
+			continue
+		}
+
+		status.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
+		status.Message = utils.RedactError(violation)
+		statuses = append(statuses, status)
+
+		sync.RecordEvent(appsub, utils.EventReasonPackagePolicyViolation,
+			fmt.Sprintf("%v %v %v/%v %v", status.APIVersion, status.Kind, status.Namespace, status.Name, violation.Error()),
+			violation)
+	}
+
+	return statuses, true
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appSubStatusV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+var deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func TestCheckAdmissionPolicies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	dryRuns := []string{}
+
+	// the Kyverno policies deny the web deployment, the dry runs of the other resources succeed
+	dc.PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		dryRuns = append(dryRuns, patch.GetName())
+
+		switch patch.GetName() {
+		case "web":
+			return true, nil, &apierrors.StatusError{ErrStatus: metav1.Status{
+				Status: metav1.StatusFailure,
+				Code:   http.StatusBadRequest,
+				Reason: metav1.StatusReasonBadRequest,
+				Message: `admission webhook "validate.kyverno.svc-fail" denied the request: resource Deployment/app/web ` +
+					"was blocked due to the following policies \n\nrequire-labels:\n  check-for-labels: 'label owner is required'",
+			}}
+		case "db":
+			return true, nil, apierrors.NewNotFound(deploymentGVR.GroupResource(), "db")
+		}
+
+		return true, newTestResourceUnit(serviceAccountGVK, "app", patch.GetName()).Resource, nil
+	})
+
+	sync := &KubeSynchronizer{
+		LocalClient:   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		DynamicClient: dc,
+	}
+
+	appsub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "app"}}
+
+	newUnit := func(resource ResourceUnit, gvr schema.GroupVersionResource) applyUnit {
+		return applyUnit{
+			resource:   resource,
+			status:     SubscriptionUnitStatus{Kind: resource.Gvk.Kind, Namespace: "app", Name: resource.Resource.GetName()},
+			gvr:        gvr,
+			namespaced: true,
+		}
+	}
+
+	units := []applyUnit{
+		newUnit(newTestResourceUnit(serviceAccountGVK, "app", "deployer"), serviceAccountGVR),
+		newUnit(newTestResourceUnit(deploymentGVK, "app", "db"), deploymentGVR),
+	}
+
+	// the errors other than the admission webhook denials are left to the apply
+	statuses, violated := sync.checkAdmissionPolicies(context.TODO(), appsub, units, nil, nil, true)
+	g.Expect(violated).To(gomega.BeFalse())
+	g.Expect(statuses).To(gomega.BeEmpty())
+
+	// the resources on the deny list are not evaluated
+	units = append(units, newUnit(newTestResourceUnit(deploymentGVK, "app", "web"), deploymentGVR))
+	denyList := map[string]map[string]string{"apps/v1": {"Deployment": "Deployment"}}
+	dryRuns = []string{}
+
+	_, violated = sync.checkAdmissionPolicies(context.TODO(), appsub, units, nil, denyList, true)
+	g.Expect(violated).To(gomega.BeFalse())
+	g.Expect(dryRuns).To(gomega.Equal([]string{"deployer"}))
+
+	// none of the resources are applied if one of them violates a policy
+	statuses, violated = sync.checkAdmissionPolicies(context.TODO(), appsub, units, nil, nil, true)
+	g.Expect(violated).To(gomega.BeTrue())
+	g.Expect(statuses).To(gomega.HaveLen(3))
+
+	phases := []string{}
+	for _, status := range statuses {
+		phases = append(phases, status.Phase)
+	}
+
+	g.Expect(phases).To(gomega.Equal([]string{string(appSubStatusV1alpha1.PackageSkipped),
+		string(appSubStatusV1alpha1.PackageSkipped), string(appSubStatusV1alpha1.PackageDeployFailed)}))
+	g.Expect(statuses[0].Message).To(gomega.Equal("not applied, 1 resources of the subscription violate the admission policies"))
+	g.Expect(statuses[2].Message).To(gomega.HavePrefix("violates the kyverno policies require-labels: "))
+}
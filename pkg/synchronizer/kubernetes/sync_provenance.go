@@ -0,0 +1,171 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// newProvenance returns the provenance of the resources applied by the subscription, its time is set when it is
+// recorded in the appsubstatus
+func newProvenance(appsub *appv1.Subscription, units []applyUnit) *v1alpha1.SubscriptionProvenance {
+	provenance := &v1alpha1.SubscriptionProvenance{
+		Source:   utils.GetAppsubSource(appsub),
+		Revision: utils.GetAppsubRevision(appsub),
+	}
+
+	charts := map[string]bool{}
+	images := map[string]bool{}
+	resources := make([]string, 0, len(units))
+
+	for _, unit := range units {
+		obj := unit.resource.Resource
+
+		if obj.GetKind() == "HelmRelease" && obj.GroupVersionKind().Group == appv1.SchemeGroupVersion.Group {
+			name, _, _ := unstructured.NestedString(obj.Object, "repo", "chartName")
+			version, _, _ := unstructured.NestedString(obj.Object, "repo", "version")
+
+			if name != "" {
+				charts[name+":"+version] = true
+			}
+		}
+
+		for _, image := range getImages(obj) {
+			images[image] = true
+		}
+
+		data, err := json.Marshal(obj.Object)
+		if err != nil {
+			klog.Warningf("Failed to marshal the resource %v/%v for its provenance digest, err: %v", obj.GetNamespace(),
+				obj.GetName(), err)
+
+			continue
+		}
+
+		resources = append(resources, string(data))
+	}
+
+	provenance.Charts = sortedKeys(charts)
+	provenance.Images = sortedKeys(images)
+
+	// the digest doesn't depend on the order the resources are applied in
+	sort.Strings(resources)
+
+	hash := sha256.New()
+	for _, resource := range resources {
+		hash.Write([]byte(resource))
+		hash.Write([]byte{'\n'})
+	}
+
+	provenance.Digest = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+
+	return provenance
+}
+
+// updateProvenance returns the provenance to record in the appsubstatus. The previous provenance is kept if nothing
+// was applied without errors or if the provenance didn't change, so an unchanged appsubstatus is not rewritten.
+func updateProvenance(prev, provenance *v1alpha1.SubscriptionProvenance) *v1alpha1.SubscriptionProvenance {
+	if provenance == nil {
+		return prev
+	}
+
+	if prev != nil {
+		provenance.Time = prev.Time
+		if equality.Semantic.DeepEqual(prev, provenance) {
+			return prev
+		}
+	}
+
+	provenance.Time = metaV1.Time{Time: time.Now()}
+
+	return provenance
+}
+
+// updateAppsubStatusProvenance updates the provenance of the existing appsubstatus only, if it changed
+func (sync *KubeSynchronizer) updateAppsubStatusProvenance(pkgstatus *v1alpha1.SubscriptionStatus,
+	provenance *v1alpha1.SubscriptionProvenance) error {
+	if provenance == nil || pkgstatus.Name == "" {
+		return nil
+	}
+
+	prev := pkgstatus.Statuses.Provenance
+
+	pkgstatus.Statuses.Provenance = updateProvenance(prev, provenance)
+	if pkgstatus.Statuses.Provenance == prev {
+		return nil
+	}
+
+	if err := sync.LocalClient.Update(context.TODO(), pkgstatus); err != nil {
+		klog.Errorf("Error in updating the provenance of appsubstatus:%v/%v, err:%v", pkgstatus.Namespace,
+			pkgstatus.Name, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// getImages returns the container images of the pod spec of the workload resource
+func getImages(obj *unstructured.Unstructured) []string {
+	path, ok := podSpecPaths[obj.GroupVersionKind().Group][obj.GetKind()]
+	if !ok {
+		return nil
+	}
+
+	images := []string{}
+
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(obj.Object, append(path, field)...)
+
+		for _, c := range containers {
+			if container, ok := c.(map[string]interface{}); ok {
+				if image, ok := container["image"].(string); ok && image != "" {
+					images = append(images, image)
+				}
+			}
+		}
+	}
+
+	return images
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,210 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// the jitter factor of the batched status updates, so the agents of the managed clusters don't write at the same time
+const reportBatchJitterFactor = 0.2
+
+// statusUpdateInterval is the minimum interval between two updates of the cluster AppsubReport on the hub, the
+// results are written right away if it is 0
+var statusUpdateInterval time.Duration
+
+// SetStatusUpdateInterval sets the minimum interval between two updates of the cluster AppsubReport on the hub
+func SetStatusUpdateInterval(interval time.Duration) {
+	statusUpdateInterval = interval
+}
+
+// pendingResult is a result change not written to the cluster AppsubReport yet
+type pendingResult struct {
+	result  v1alpha1.SubscriptionResult
+	commit  string
+	deleted bool
+}
+
+// reportBatcher coalesces the result changes of the subscriptions and writes them to the cluster AppsubReport on the
+// hub in a single update per interval
+type reportBatcher struct {
+	client   client.Client
+	interval time.Duration
+	lock     sync.Mutex
+	// pending result changes per cluster AppsubReport namespace, per result source
+	pending map[string]map[string]*pendingResult
+}
+
+func newReportBatcher(rClient client.Client, interval time.Duration) *reportBatcher {
+	return &reportBatcher{
+		client:   rClient,
+		interval: interval,
+		pending:  map[string]map[string]*pendingResult{},
+	}
+}
+
+// add queues a result change, the last change of a source overrides its previous ones
+func (b *reportBatcher) add(clusterAppsubReportNs, source string, result *pendingResult) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.pending[clusterAppsubReportNs] == nil {
+		b.pending[clusterAppsubReportNs] = map[string]*pendingResult{}
+	}
+
+	// keep the last known commit if the new result has none
+	if prev, ok := b.pending[clusterAppsubReportNs][source]; ok && result.commit == "" && !result.deleted && !prev.deleted {
+		result.commit = prev.commit
+	}
+
+	b.pending[clusterAppsubReportNs][source] = result
+}
+
+// run writes the pending result changes every interval, with jitter, until the context is done. The last changes are
+// written by the synchronizer shutdown, once the in-flight applies complete.
+func (b *reportBatcher) run(ctx context.Context) {
+	klog.Infof("Batching the cluster AppsubReport updates every %v", b.interval)
+
+	wait.JitterUntilWithContext(ctx, b.flush, b.interval, reportBatchJitterFactor, true)
+}
+
+func (b *reportBatcher) flush(ctx context.Context) {
+	b.lock.Lock()
+	pending := b.pending
+	b.pending = map[string]map[string]*pendingResult{}
+	b.lock.Unlock()
+
+	for clusterAppsubReportNs, results := range pending {
+		if err := b.write(ctx, clusterAppsubReportNs, results); err != nil {
+			klog.Errorf("Failed to update the cluster AppsubReport %v, retrying at the next interval, err: %v",
+				clusterAppsubReportNs, err)
+
+			// requeue the failed changes unless they were overridden in the meantime
+			b.lock.Lock()
+
+			if b.pending[clusterAppsubReportNs] == nil {
+				b.pending[clusterAppsubReportNs] = map[string]*pendingResult{}
+			}
+
+			for source, result := range results {
+				if _, ok := b.pending[clusterAppsubReportNs][source]; !ok {
+					b.pending[clusterAppsubReportNs][source] = result
+				}
+			}
+
+			b.lock.Unlock()
+		}
+	}
+}
+
+// write applies the result changes to the cluster AppsubReport, it is only updated if a result changed
+func (b *reportBatcher) write(ctx context.Context, clusterAppsubReportNs string, results map[string]*pendingResult) error {
+	create := false
+
+	for _, result := range results {
+		if !result.deleted {
+			create = true
+			break
+		}
+	}
+
+	appsubReport, err := getClusterAppsubReport(b.client, clusterAppsubReportNs, create)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !applyPendingResults(appsubReport, results) {
+		return nil
+	}
+
+	klog.V(1).Infof("Update %v results in cluster AppsubReport: %v/%v", len(results), appsubReport.Namespace, appsubReport.Name)
+
+	return b.client.Update(ctx, appsubReport)
+}
+
+// applyPendingResults applies the result changes to the cluster AppsubReport, it returns true if the report changed
+func applyPendingResults(appsubReport *v1alpha1.SubscriptionReport, results map[string]*pendingResult) bool {
+	changed := false
+	found := map[string]bool{}
+	kept := []*v1alpha1.SubscriptionReportResult{}
+
+	for _, prResult := range appsubReport.Results {
+		pr, ok := results[prResult.Source]
+		if !ok {
+			kept = append(kept, prResult)
+			continue
+		}
+
+		found[prResult.Source] = true
+
+		if pr.deleted {
+			changed = true
+			continue
+		}
+
+		if prResult.Result != pr.result || pr.commit != "" && prResult.Commit != pr.commit {
+			prResult.Result = pr.result
+
+			if pr.commit != "" {
+				prResult.Commit = pr.commit
+			}
+
+			changed = true
+		}
+
+		kept = append(kept, prResult)
+	}
+
+	sources := []string{}
+
+	for source, pr := range results {
+		if !found[source] && !pr.deleted {
+			sources = append(sources, source)
+		}
+	}
+
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		pr := results[source]
+
+		kept = append(kept, &v1alpha1.SubscriptionReportResult{
+			Source:    source,
+			Result:    pr.result,
+			Commit:    pr.commit,
+			Timestamp: metaV1.Timestamp{Seconds: time.Now().Unix()},
+		})
+
+		changed = true
+	}
+
+	appsubReport.Results = kept
+
+	return changed
+}
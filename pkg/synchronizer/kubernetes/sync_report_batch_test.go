@@ -0,0 +1,116 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func TestReportBatcher(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	b := newReportBatcher(nil, time.Minute)
+
+	// the last change of a source overrides the previous ones, the commit is kept
+	b.add("cluster1", "default/app", &pendingResult{result: "failed", commit: "abc"})
+	b.add("cluster1", "default/app", &pendingResult{result: "deployed"})
+	b.add("cluster1", "default/gone", &pendingResult{result: "deployed"})
+	b.add("cluster1", "default/gone", &pendingResult{deleted: true})
+	b.add("cluster1", "default/new", &pendingResult{result: "deployed", commit: "def"})
+
+	results := b.pending["cluster1"]
+	g.Expect(results).To(gomega.HaveLen(3))
+	g.Expect(*results["default/app"]).To(gomega.Equal(pendingResult{result: "deployed", commit: "abc"}))
+
+	appsubReport := &v1alpha1.SubscriptionReport{
+		Results: []*v1alpha1.SubscriptionReportResult{
+			{Source: "default/app", Result: "failed", Commit: "abc"},
+			{Source: "default/gone", Result: "deployed"},
+			{Source: "default/other", Result: "failed"},
+		},
+	}
+
+	g.Expect(applyPendingResults(appsubReport, results)).To(gomega.BeTrue())
+	g.Expect(appsubReport.Results).To(gomega.HaveLen(3))
+	g.Expect(appsubReport.Results[0].Result).To(gomega.BeEquivalentTo("deployed"))
+	g.Expect(appsubReport.Results[1].Source).To(gomega.Equal("default/other"))
+	g.Expect(appsubReport.Results[2].Source).To(gomega.Equal("default/new"))
+	g.Expect(appsubReport.Results[2].Commit).To(gomega.Equal("def"))
+
+	// nothing to write if the results didn't change
+	g.Expect(applyPendingResults(appsubReport, results)).To(gomega.BeFalse())
+}
+
+func TestKeepUnitUpdateTimes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+
+	prev := []v1alpha1.SubscriptionUnitStatus{
+		{Kind: "ConfigMap", Namespace: "default", Name: "cm", Phase: v1alpha1.PackageDeployed, LastUpdateTime: before},
+		{Kind: "Secret", Namespace: "default", Name: "s", Phase: v1alpha1.PackageDeployed, LastUpdateTime: before},
+	}
+
+	units := keepUnitUpdateTimes(prev, []v1alpha1.SubscriptionUnitStatus{
+		{Kind: "ConfigMap", Namespace: "default", Name: "cm", Phase: v1alpha1.PackageDeployed, LastUpdateTime: now},
+		{Kind: "Secret", Namespace: "default", Name: "s", Phase: v1alpha1.PackageDeployFailed, Message: "denied", LastUpdateTime: now},
+	})
+
+	g.Expect(units[0].LastUpdateTime).To(gomega.Equal(before))
+	g.Expect(units[1].LastUpdateTime).To(gomega.Equal(now))
+}
+
+func TestKeepUnitTransitionTimes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+
+	newUnit := func(name string, phase v1alpha1.PackagePhase, message string, updated metav1.Time) v1alpha1.SubscriptionUnitStatus {
+		return v1alpha1.SubscriptionUnitStatus{Name: name, Kind: "ConfigMap", APIVersion: "v1", Phase: phase, Message: message,
+			LastUpdateTime: updated, LastTransitionTime: updated}
+	}
+
+	prev := []v1alpha1.SubscriptionUnitStatus{
+		newUnit("cm1", v1alpha1.PackageDeployed, "", before),
+		newUnit("cm2", v1alpha1.PackageDeployFailed, "denied", before),
+		newUnit("cm3", v1alpha1.PackageDeployFailed, "denied", before),
+	}
+
+	units := keepUnitUpdateTimes(prev, []v1alpha1.SubscriptionUnitStatus{
+		newUnit("cm1", v1alpha1.PackageDeployed, "", now),
+		newUnit("cm2", v1alpha1.PackageDeployFailed, "invalid", now),
+		newUnit("cm3", v1alpha1.PackageDeployed, "", now),
+	})
+
+	// unchanged
+	g.Expect(units[0].LastUpdateTime).To(gomega.Equal(before))
+	g.Expect(units[0].LastTransitionTime).To(gomega.Equal(before))
+
+	// new message in the same phase
+	g.Expect(units[1].LastUpdateTime).To(gomega.Equal(now))
+	g.Expect(units[1].LastTransitionTime).To(gomega.Equal(before))
+
+	// new phase
+	g.Expect(units[2].LastUpdateTime).To(gomega.Equal(now))
+	g.Expect(units[2].LastTransitionTime).To(gomega.Equal(now))
+}
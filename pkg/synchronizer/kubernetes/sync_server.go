@@ -49,13 +49,17 @@ type SubscriptionUnitStatus struct {
 	Kind       string
 	Phase      string
 	Message    string
+	Field      string   /* fields of the apply error, empty if unknown */
+	Images     []string /* container images resolved with the registries */
 }
 
 type SubscriptionClusterStatus struct {
 	Cluster                   string
 	AppSub                    types.NamespacedName /* hosting appsub */
 	Action                    string               /* "APPLY" or "DELETE" */
+	Commit                    string               /* revision applied, empty if unknown */
 	SubscriptionPackageStatus []SubscriptionUnitStatus
+	Provenance                *appv1alpha1.SubscriptionProvenance /* nil if nothing was applied without errors */
 }
 
 // KubeSynchronizer handles resources to a kube endpoint.
@@ -74,8 +78,13 @@ type KubeSynchronizer struct {
 	SynchronizerID         *types.NamespacedName // managed cluster Namespaced name
 	Extension              Extension
 	eventrecorder          *utils.EventRecorder
-	dmtx                   sync.Mutex //this lock protect the dynamicFactory and stopCh
-	SkipAppSubStatusResDel bool       // used by helm subscriber to skip resource delete based on AppSubStatus
+	dmtx                   sync.Mutex     //this lock protect the dynamicFactory and stopCh
+	SkipAppSubStatusResDel bool           // used by helm subscriber to skip resource delete based on AppSubStatus
+	auditTriggers          sync.Map       // the trigger annotations of the last reconcile per appsub, for the audit history
+	reportBatcher          *reportBatcher // batches the cluster AppsubReport updates, nil if they are written right away
+	shutdownGate           shutdownGate   // tracks the in-flight applies, closed when the agent is shutting down
+	serviceAccountClients  sync.Map       // the dynamic clients impersonating the service accounts of the subscriptions
+	resolvedImages         sync.Map       // the container images resolved by the last apply per appsub, for the registry scans
 }
 
 var defaultSynchronizer *KubeSynchronizer
@@ -93,7 +102,12 @@ func Add(mgr manager.Manager, hubconfig *rest.Config, syncid *types.NamespacedNa
 
 	startCleanup(defaultSynchronizer)
 
-	return mgr.Add(defaultSynchronizer)
+	if err := mgr.Add(defaultSynchronizer); err != nil {
+		return err
+	}
+
+	// the manager waits for the in-flight applies and the pending status updates on shutdown
+	return mgr.Add(manager.RunnableFunc(defaultSynchronizer.waitForShutdown))
 }
 
 // GetDefaultSynchronizer - return the default kubernetse synchronizer.
@@ -160,6 +174,10 @@ func CreateSynchronizer(config, remoteConfig *rest.Config, scheme *runtime.Schem
 		s.RemoteClient = s.RemoteNonCachedClient
 	}
 
+	if statusUpdateInterval > 0 {
+		s.reportBatcher = newReportBatcher(s.RemoteClient, statusUpdateInterval)
+	}
+
 	defaultExtension.localClient = s.LocalClient
 	defaultExtension.remoteClient = s.RemoteClient
 
@@ -183,6 +201,10 @@ func (sync *KubeSynchronizer) Start(ctx context.Context) error {
 	klog.Info("start synchronizer")
 	defer klog.Info("stop synchronizer")
 
+	if sync.reportBatcher != nil {
+		go sync.reportBatcher.run(ctx)
+	}
+
 	return nil
 }
 
@@ -206,6 +228,15 @@ func (sync *KubeSynchronizer) GetRemoteNonCachedClient() client.Client {
 	return sync.RemoteNonCachedClient
 }
 
+// RecordEvent records an event on the subscription of the managed cluster
+func (sync *KubeSynchronizer) RecordEvent(appsub *appv1.Subscription, reason, msg string, err error) {
+	if sync.eventrecorder == nil {
+		return
+	}
+
+	sync.eventrecorder.RecordEvent(appsub, reason, msg, err)
+}
+
 // startCleanup starts a goroutine that cleanup all the orphan subscriptionstatuses
 func startCleanup(synchronizer *KubeSynchronizer) {
 	waitDuration := time.Second * 10
@@ -0,0 +1,117 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// isServiceAccountProvisioning returns true if the resource can provision the service account of a subscription or
+// its RBAC in the subscription namespace, it is applied by the application manager
+func isServiceAccountProvisioning(gvk schema.GroupVersionKind) bool {
+	switch gvk.Group {
+	case corev1.GroupName:
+		return gvk.Kind == "ServiceAccount"
+	case rbacv1.GroupName:
+		return gvk.Kind == "Role" || gvk.Kind == "RoleBinding"
+	}
+
+	return false
+}
+
+// sortServiceAccountResources moves the resources provisioning the service account of the subscription first, so
+// the service account and its RBAC exist before the other resources are applied with it
+func sortServiceAccountResources(appsub *appv1alpha1.Subscription, resources []ResourceUnit) {
+	if appsub.Spec.ServiceAccountName == "" {
+		return
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return isServiceAccountProvisioning(resources[i].Gvk) && !isServiceAccountProvisioning(resources[j].Gvk)
+	})
+}
+
+// applyClients returns the dynamic clients applying the resources of a subscription. The resources are applied by
+// the application manager, or by the service account of the subscription except the ServiceAccounts, Roles and
+// RoleBindings of the subscription namespace
+type applyClients struct {
+	sync     *KubeSynchronizer
+	appsub   *appv1alpha1.Subscription
+	saClient dynamic.Interface
+	saErr    error
+}
+
+func (c *applyClients) get(tplunit *unstructured.Unstructured, gvk schema.GroupVersionKind) (dynamic.Interface, error) {
+	if c.appsub.Spec.ServiceAccountName == "" {
+		return c.sync.DynamicClient, nil
+	}
+
+	if isServiceAccountProvisioning(gvk) && tplunit.GetNamespace() == c.appsub.Namespace {
+		return c.sync.DynamicClient, nil
+	}
+
+	// the service account is checked once the resources provisioning it are applied
+	if c.saClient == nil && c.saErr == nil {
+		c.saClient, c.saErr = c.sync.getServiceAccountClient(c.appsub.Namespace, c.appsub.Spec.ServiceAccountName)
+	}
+
+	return c.saClient, c.saErr
+}
+
+// getServiceAccountClient returns the dynamic client impersonating the service account, the clients are reused
+// across the reconciles
+func (sync *KubeSynchronizer) getServiceAccountClient(namespace, name string) (dynamic.Interface, error) {
+	sa := &corev1.ServiceAccount{}
+	if err := sync.LocalClient.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, sa); err != nil {
+		return nil, fmt.Errorf("failed to get the service account %v/%v of the subscription, err: %w", namespace, name, err)
+	}
+
+	username := "system:serviceaccount:" + namespace + ":" + name
+
+	if dc, ok := sync.serviceAccountClients.Load(username); ok {
+		return dc.(dynamic.Interface), nil
+	}
+
+	if sync.localConfig == nil {
+		return nil, fmt.Errorf("failed to impersonate the service account %v/%v, no local cluster config", namespace, name)
+	}
+
+	cfg := rest.CopyConfig(sync.localConfig)
+	cfg.Impersonate = rest.ImpersonationConfig{UserName: username}
+
+	dc, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.Infof("Created the client impersonating the service account %v/%v", namespace, name)
+
+	actual, _ := sync.serviceAccountClients.LoadOrStore(username, dc)
+
+	return actual.(dynamic.Interface), nil
+}
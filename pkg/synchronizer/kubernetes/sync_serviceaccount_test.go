@@ -0,0 +1,127 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+var (
+	deploymentGVK     = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	serviceAccountGVK = schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}
+	roleBindingGVK    = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}
+	clusterRoleGVK    = schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+)
+
+func newTestResourceUnit(gvk schema.GroupVersionKind, namespace, name string) ResourceUnit {
+	tplunit := &unstructured.Unstructured{}
+	tplunit.SetGroupVersionKind(gvk)
+	tplunit.SetNamespace(namespace)
+	tplunit.SetName(name)
+
+	return ResourceUnit{Resource: tplunit, Gvk: gvk}
+}
+
+func TestSortServiceAccountResources(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	resources := []ResourceUnit{
+		newTestResourceUnit(deploymentGVK, "app", "web"),
+		newTestResourceUnit(roleBindingGVK, "app", "deployer"),
+		newTestResourceUnit(clusterRoleGVK, "", "deployer"),
+		newTestResourceUnit(serviceAccountGVK, "app", "deployer"),
+	}
+
+	appsub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "app"}}
+
+	// the order is kept without a service account
+	sortServiceAccountResources(appsub, resources)
+	g.Expect(resources[0].Gvk).To(gomega.Equal(deploymentGVK))
+
+	appsub.Spec.ServiceAccountName = "deployer"
+	sortServiceAccountResources(appsub, resources)
+
+	kinds := []string{}
+	for _, resource := range resources {
+		kinds = append(kinds, resource.Gvk.Kind)
+	}
+
+	g.Expect(kinds).To(gomega.Equal([]string{"RoleBinding", "ServiceAccount", "Deployment", "ClusterRole"}))
+}
+
+func TestApplyClients(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "deployer", Namespace: "app"}}
+
+	sync := &KubeSynchronizer{
+		LocalClient:   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		localConfig:   &rest.Config{Host: "https://127.0.0.1:6443"},
+	}
+
+	appsub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "app"}}
+	deployment := newTestResourceUnit(deploymentGVK, "app", "web")
+	roleBinding := newTestResourceUnit(roleBindingGVK, "app", "deployer")
+
+	// the application manager applies the resources without a service account
+	dc, err := (&applyClients{sync: sync, appsub: appsub}).get(deployment.Resource, deployment.Gvk)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(dc).To(gomega.BeIdenticalTo(sync.DynamicClient))
+
+	appsub.Spec.ServiceAccountName = "deployer"
+	clients := &applyClients{sync: sync, appsub: appsub}
+
+	// the RBAC of the subscription namespace is applied by the application manager
+	dc, err = clients.get(roleBinding.Resource, roleBinding.Gvk)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(dc).To(gomega.BeIdenticalTo(sync.DynamicClient))
+
+	otherRoleBinding := newTestResourceUnit(roleBindingGVK, "other", "deployer")
+
+	// the other resources fail while the service account doesn't exist
+	_, err = clients.get(otherRoleBinding.Resource, otherRoleBinding.Gvk)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = clients.get(deployment.Resource, deployment.Gvk)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	g.Expect(sync.LocalClient.Create(context.TODO(), sa)).To(gomega.Succeed())
+
+	clients = &applyClients{sync: sync, appsub: appsub}
+
+	dc, err = clients.get(deployment.Resource, deployment.Gvk)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(dc).NotTo(gomega.BeIdenticalTo(sync.DynamicClient))
+
+	// the impersonating client is reused by the next reconciles
+	again, err := (&applyClients{sync: sync, appsub: appsub}).get(deployment.Resource, deployment.Gvk)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(again).To(gomega.BeIdenticalTo(dc))
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+// ErrShuttingDown is returned by ProcessSubResources once the agent is shutting down, the resources of the
+// subscription are left untouched and applied again after the agent restarts
+var ErrShuttingDown = errors.New("the agent is shutting down, the subscription resources are applied after it restarts")
+
+// shutdownTimeout is the maximum time the synchronizer takes to shut down, the in-flight applies are waited for
+// until shutdownFlushTimeout is left to write the pending status updates
+var shutdownTimeout = 25 * time.Second
+
+// shutdownFlushTimeout is the time reserved to write the pending status updates on shutdown
+const shutdownFlushTimeout = 5 * time.Second
+
+// SetShutdownTimeout sets the maximum time the synchronizer takes to shut down, it must be shorter than the graceful
+// shutdown timeout of the manager
+func SetShutdownTimeout(timeout time.Duration) {
+	shutdownTimeout = timeout
+}
+
+// shutdownGate tracks the in-flight applies of the synchronizer, so the agent lets them complete instead of leaving
+// half-applied resource sets when it is terminated. Its zero value is open.
+type shutdownGate struct {
+	lock     sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+}
+
+// enter registers an in-flight apply, it returns false if the gate is closed and the apply must not start
+func (g *shutdownGate) enter() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.closed {
+		return false
+	}
+
+	g.inflight.Add(1)
+
+	return true
+}
+
+// leave unregisters an in-flight apply
+func (g *shutdownGate) leave() {
+	g.inflight.Done()
+}
+
+// close refuses the new applies and waits for the in-flight ones to complete, it returns false if they didn't
+// complete within the timeout
+func (g *shutdownGate) close(timeout time.Duration) bool {
+	g.lock.Lock()
+	g.closed = true
+	g.lock.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		g.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// waitForShutdown blocks until the manager stops, then lets the in-flight applies complete and writes the pending
+// status updates before returning
+func (sync *KubeSynchronizer) waitForShutdown(ctx context.Context) error {
+	<-ctx.Done()
+
+	sync.shutdown()
+
+	return nil
+}
+
+// shutdown waits for the in-flight applies to complete, then writes the pending status updates
+func (sync *KubeSynchronizer) shutdown() {
+	klog.Info("Waiting for the in-flight subscription applies to complete")
+
+	applyTimeout := shutdownTimeout - shutdownFlushTimeout
+	if applyTimeout < 0 {
+		applyTimeout = 0
+	}
+
+	if !sync.shutdownGate.close(applyTimeout) {
+		klog.Warningf("The in-flight subscription applies didn't complete within %v, they are applied again after the "+
+			"agent restarts", applyTimeout)
+	}
+
+	if sync.reportBatcher != nil {
+		klog.Info("Writing the pending cluster AppsubReport updates")
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+		defer cancel()
+
+		sync.reportBatcher.flush(ctx)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func TestShutdownGate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	gate := &shutdownGate{}
+
+	// the in-flight applies are waited for
+	g.Expect(gate.enter()).To(gomega.BeTrue())
+
+	closed := make(chan bool)
+
+	go func() {
+		closed <- gate.close(time.Minute)
+	}()
+
+	g.Eventually(func() bool {
+		gate.lock.Lock()
+		defer gate.lock.Unlock()
+
+		return gate.closed
+	}).Should(gomega.BeTrue())
+	g.Expect(gate.enter()).To(gomega.BeFalse())
+	g.Consistently(closed, 100*time.Millisecond).ShouldNot(gomega.Receive())
+
+	gate.leave()
+	g.Eventually(closed).Should(gomega.Receive(gomega.BeTrue()))
+
+	// the wait is bounded
+	gate = &shutdownGate{}
+	g.Expect(gate.enter()).To(gomega.BeTrue())
+	g.Expect(gate.close(10 * time.Millisecond)).To(gomega.BeFalse())
+}
+
+func TestShutdown(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(v1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	hubClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	sync := &KubeSynchronizer{reportBatcher: newReportBatcher(hubClient, time.Hour)}
+	sync.reportBatcher.add("cluster1", "default/app", &pendingResult{result: "deployed", commit: "abc"})
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	// the pending status updates are written on shutdown
+	g.Expect(sync.waitForShutdown(ctx)).To(gomega.Succeed())
+
+	appsubReport := &v1alpha1.SubscriptionReport{}
+	g.Expect(hubClient.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "cluster1"},
+		appsubReport)).To(gomega.Succeed())
+	g.Expect(appsubReport.Results).To(gomega.HaveLen(1))
+	g.Expect(appsubReport.Results[0].Source).To(gomega.Equal("default/app"))
+
+	// the resources are left untouched after the shutdown
+	appsub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}}
+
+	err := sync.ProcessSubResources(appsub, []ResourceUnit{{}}, nil, nil, false, false)
+	g.Expect(err).To(gomega.MatchError(ErrShuttingDown))
+}
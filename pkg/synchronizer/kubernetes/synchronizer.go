@@ -16,7 +16,6 @@ import (
 	"strings"
 	"time"
 
-	corev1 "k8s.io/api/core/v1"
 	errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -27,9 +26,13 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/klog"
 
+	workv1 "open-cluster-management.io/api/work/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	appSubStatusV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/health"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/logging"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/tracing"
 	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
 
@@ -55,21 +58,44 @@ func (sync *KubeSynchronizer) getGVRfromGVK(group, version, kind string) (schema
 	return mapping.Resource, isNamespaced, nil
 }
 
+// the unit status message of the resources kept by the do-not-delete annotation
+var retainedMessage = "Retained by the " + appv1alpha1.AnnotationResourceDoNotDeleteOption + " annotation"
+
+// recordRetained records an event on the subscription for a resource retained by the do-not-delete annotation
+func (sync *KubeSynchronizer) recordRetained(appsub *appv1alpha1.Subscription, pkgStatus appSubStatusV1alpha1.SubscriptionUnitStatus) {
+	if appsub == nil {
+		return
+	}
+
+	sync.RecordEvent(appsub, utils.EventReasonPackageRetained,
+		fmt.Sprintf("Retained %v %v %v/%v: %v", pkgStatus.APIVersion, pkgStatus.Kind, pkgStatus.Namespace, pkgStatus.Name,
+			retainedMessage), nil)
+}
+
 // DeleteSingleSubscribedResource delete a subcribed resource from a appsub.
 func (sync *KubeSynchronizer) DeleteSingleSubscribedResource(hostSub types.NamespacedName,
 	pkgStatus appSubStatusV1alpha1.SubscriptionUnitStatus) error {
+	_, err := sync.deleteSubscribedResource(hostSub, pkgStatus)
+
+	return err
+}
+
+// deleteSubscribedResource deletes a subscribed resource from a appsub, it returns true if the resource is retained
+// because of its do-not-delete annotation.
+func (sync *KubeSynchronizer) deleteSubscribedResource(hostSub types.NamespacedName,
+	pkgStatus appSubStatusV1alpha1.SubscriptionUnitStatus) (bool, error) {
 	pkgGroup, pkgVersion := utils.ParseAPIVersion(pkgStatus.APIVersion)
 
 	if pkgGroup == "" && pkgVersion == "" {
 		if pkgStatus.Phase == "Failed" {
 			klog.Info("phase of resource is failed with no apiVersion info, nothing to delete")
 
-			return nil
+			return false, nil
 		}
 
 		klog.Infof("invalid apiversion pkgStatus: %v", pkgStatus)
 
-		return fmt.Errorf("invalid apiversion")
+		return false, fmt.Errorf("invalid apiversion")
 	}
 
 	pkgGVR, isNamespaced, err := sync.getGVRfromGVK(pkgGroup, pkgVersion, pkgStatus.Kind)
@@ -77,7 +103,7 @@ func (sync *KubeSynchronizer) DeleteSingleSubscribedResource(hostSub types.Names
 	if err != nil {
 		klog.Infof("Failed to get GVR from restmapping: %v", err)
 
-		return err
+		return false, err
 	}
 
 	nri := sync.DynamicClient.Resource(pkgGVR)
@@ -95,7 +121,7 @@ func (sync *KubeSynchronizer) DeleteSingleSubscribedResource(hostSub types.Names
 	if err != nil {
 		klog.Infof("Failed to get the package, no need to delete. err: %v, ", err)
 
-		return nil
+		return false, nil
 	}
 
 	annotations := pkgObj.GetAnnotations()
@@ -103,7 +129,12 @@ func (sync *KubeSynchronizer) DeleteSingleSubscribedResource(hostSub types.Names
 	// If the resource has a do-not-delete: "true" annotation, skip the deletion of this resource
 	if annotations[appv1alpha1.AnnotationResourceDoNotDeleteOption] == "true" {
 		klog.Infof("pkgName: %v, pkgNamespace: %v has do-not-delete annotation, skip deleting", pkgStatus.Name, pkgStatus.Namespace)
-		return nil
+
+		metrics.LocalDeploymentResourceCount.
+			WithLabelValues(hostSub.Namespace, hostSub.Name, metrics.ResultRetained).
+			Inc()
+
+		return true, nil
 	}
 
 	// The resource might not be owned by the subscription if you deployed the susbcription
@@ -113,7 +144,7 @@ func (sync *KubeSynchronizer) DeleteSingleSubscribedResource(hostSub types.Names
 		klog.Infof("appsub: %v, pkgName: %v, pkgNamespace: %v, is not owned by the subscription. Skip deleting.",
 			hostSub, pkgStatus.Name, pkgStatus.Namespace)
 
-		return nil
+		return false, nil
 	}
 
 	deletepolicy := metav1.DeletePropagationBackground
@@ -123,10 +154,14 @@ func (sync *KubeSynchronizer) DeleteSingleSubscribedResource(hostSub types.Names
 		klog.Errorf("Failed to delete package, appsub: %v, pkgName: %v, pkgNamespace: %v, err: %v",
 			hostSub, pkgStatus.Name, pkgStatus.Namespace, err)
 
-		return err
+		return false, err
 	}
 
-	return nil
+	metrics.LocalDeploymentResourceCount.
+		WithLabelValues(hostSub.Namespace, hostSub.Name, metrics.ResultPruned).
+		Inc()
+
+	return false, nil
 }
 
 // PurgeSubscribedResources purge all resources deployed by the appsub.
@@ -134,6 +169,9 @@ func (sync *KubeSynchronizer) PurgeAllSubscribedResources(appsub *appv1alpha1.Su
 	sync.kmtx.Lock()
 	defer sync.kmtx.Unlock()
 
+	// the wait for the lock held by the other subscriptions doesn't count in the stall timeout
+	defer health.Track(health.OperationPurge, appsub.GetNamespace(), appsub.GetName())()
+
 	hostSub := types.NamespacedName{
 		Namespace: appsub.GetNamespace(),
 		Name:      appsub.GetName(),
@@ -188,7 +226,10 @@ func (sync *KubeSynchronizer) PurgeAllSubscribedResources(appsub *appv1alpha1.Su
 		return nil
 	}
 
-	if sync.SkipAppSubStatusResDel {
+	// an explicit deletionPolicy of the subscription overrides the SkipAppSubStatusResDel setting of the synchronizer
+	if appsub.Spec.DeletionPolicy == appv1alpha1.DeletionPolicyOrphan {
+		klog.Info("deletionPolicy Orphan, leaving the resources of ", hostSub.Namespace, "/", hostSub.Name, " in place")
+	} else if sync.SkipAppSubStatusResDel && appsub.Spec.DeletionPolicy != appv1alpha1.DeletionPolicyDelete {
 		klog.Info("SkipAppSubStatusResDel enabled for ", hostSub.Namespace, "/", hostSub.Name)
 	} else {
 		for _, pkgStatus := range appSubStatus.Statuses.SubscriptionPackageStatus {
@@ -198,10 +239,10 @@ func (sync *KubeSynchronizer) PurgeAllSubscribedResources(appsub *appv1alpha1.Su
 			appSubUnitStatus.Name = pkgStatus.Name
 			appSubUnitStatus.Namespace = pkgStatus.Namespace
 
-			err := sync.DeleteSingleSubscribedResource(hostSub, pkgStatus)
+			retained, err := sync.deleteSubscribedResource(hostSub, pkgStatus)
 			if err != nil {
 				appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
-				appSubUnitStatus.Message = err.Error()
+				appSubUnitStatus.Message = utils.RedactError(err)
 				appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 
 				continue
@@ -209,6 +250,13 @@ func (sync *KubeSynchronizer) PurgeAllSubscribedResources(appsub *appv1alpha1.Su
 
 			appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployed)
 			appSubUnitStatus.Message = ""
+
+			if retained {
+				appSubUnitStatus.Message = retainedMessage
+
+				sync.recordRetained(appsub, pkgStatus)
+			}
+
 			appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 		}
 
@@ -226,10 +274,10 @@ func (sync *KubeSynchronizer) PurgeAllSubscribedResources(appsub *appv1alpha1.Su
 				}
 			}
 
-			err := sync.DeleteSingleSubscribedResource(hostSub, legacyResource)
+			retained, err := sync.deleteSubscribedResource(hostSub, legacyResource)
 			if err != nil {
 				appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
-				appSubUnitStatus.Message = err.Error()
+				appSubUnitStatus.Message = utils.RedactError(err)
 				appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 
 				continue
@@ -237,6 +285,13 @@ func (sync *KubeSynchronizer) PurgeAllSubscribedResources(appsub *appv1alpha1.Su
 
 			appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployed)
 			appSubUnitStatus.Message = ""
+
+			if retained {
+				appSubUnitStatus.Message = retainedMessage
+
+				sync.recordRetained(appsub, legacyResource)
+			}
+
 			appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 		}
 	}
@@ -258,6 +313,16 @@ func (sync *KubeSynchronizer) PurgeAllSubscribedResources(appsub *appv1alpha1.Su
 
 func (sync *KubeSynchronizer) ProcessSubResources(appsub *appv1alpha1.Subscription, resources []ResourceUnit,
 	allowlist, denyList map[string]map[string]string, isAdmin, failOnStatusErr bool) error {
+	// the resources are left untouched once the agent is shutting down, the in-flight applies complete before it stops
+	if !sync.shutdownGate.enter() {
+		klog.Infof("Skipping the resources of subscription %v/%v, the agent is shutting down", appsub.GetNamespace(),
+			appsub.GetName())
+
+		return ErrShuttingDown
+	}
+
+	defer sync.shutdownGate.leave()
+
 	hostSub := types.NamespacedName{
 		Namespace: appsub.GetNamespace(),
 		Name:      appsub.GetName(),
@@ -272,10 +337,27 @@ func (sync *KubeSynchronizer) ProcessSubResources(appsub *appv1alpha1.Subscripti
 
 	defer sync.kmtx.Unlock()
 
+	// the wait for the lock held by the other subscriptions doesn't count in the stall timeout
+	defer health.Track(health.OperationSync, appsub.GetNamespace(), appsub.GetName())()
+
+	ctx := tracing.ContextFromAnnotations(context.TODO(), appsub.GetAnnotations())
+	ctx, span := tracing.StartSpan(ctx, "ApplySubscriptionResources", appsub.Namespace, appsub.Name)
+
+	defer span.End()
+
 	appSubUnitStatuses := []SubscriptionUnitStatus{}
 	gotDeployErrs := false
 	startTime := time.Now().UnixMilli()
 
+	sortServiceAccountResources(appsub, resources)
+
+	units := []applyUnit{}
+
+	var images *imageResolution
+	if utils.IsImageAutomation(appsub) {
+		images = newImageResolution(sync, appsub)
+	}
+
 	for _, resource := range resources {
 		appSubUnitStatus := SubscriptionUnitStatus{}
 
@@ -283,7 +365,7 @@ func (sync *KubeSynchronizer) ProcessSubResources(appsub *appv1alpha1.Subscripti
 
 		if err != nil {
 			appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
-			appSubUnitStatus.Message = err.Error()
+			appSubUnitStatus.Message = utils.RedactError(err)
 			appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 			gotDeployErrs = true
 
@@ -307,7 +389,7 @@ func (sync *KubeSynchronizer) ProcessSubResources(appsub *appv1alpha1.Subscripti
 		if err != nil {
 			appSubUnitStatus.Namespace = resource.Resource.GetNamespace()
 			appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
-			appSubUnitStatus.Message = err.Error()
+			appSubUnitStatus.Message = utils.RedactError(err)
 			appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 			gotDeployErrs = true
 
@@ -316,37 +398,154 @@ func (sync *KubeSynchronizer) ProcessSubResources(appsub *appv1alpha1.Subscripti
 			continue
 		}
 
-		nri := sync.DynamicClient.Resource(pkgGVR)
+		if images != nil {
+			resolved, err := images.resolve(ctx, resource.Resource)
+			if err != nil {
+				appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
+				appSubUnitStatus.Message = utils.RedactError(err)
+				appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
+				gotDeployErrs = true
+
+				klog.Errorf("Failed to resolve the images of the resource, pkg: %v/%v, error: %v",
+					appSubUnitStatus.Namespace, appSubUnitStatus.Name, err)
+
+				continue
+			}
+
+			appSubUnitStatus.Images = resolved
+		}
+
+		units = append(units, applyUnit{
+			resource:   resource,
+			status:     appSubUnitStatus,
+			gvr:        pkgGVR,
+			namespaced: isNamespaced,
+		})
+	}
+
+	// the registries of the resolved images are scanned for new images
+	if images != nil {
+		sync.resolvedImages.Store(hostSub, images.images)
+	} else {
+		sync.resolvedImages.Delete(hostSub)
+	}
+
+	// none of the resources are applied if one of them violates the admission policies of the cluster
+	if utils.IsPolicyCheck(appsub) {
+		if statuses, violated := sync.checkAdmissionPolicies(ctx, appsub, units, allowlist, denyList, isAdmin); violated {
+			appSubUnitStatuses = append(appSubUnitStatuses, statuses...)
+			gotDeployErrs = true
+			units = nil
+		}
+	}
+
+	clients := &applyClients{sync: sync, appsub: appsub}
 
-		err = sync.applyTemplate(nri, isNamespaced, resource, isSpecialResource(pkgGVR), allowlist, denyList, isAdmin)
+	for _, unit := range units {
+		resource := unit.resource
+		appSubUnitStatus := unit.status
+		isNamespaced := unit.namespaced
 
+		dc, err := clients.get(resource.Resource, resource.Gvk)
 		if err != nil {
 			appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
-			appSubUnitStatus.Message = err.Error()
+			appSubUnitStatus.Message = utils.RedactError(err)
+			appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
+			gotDeployErrs = true
+
+			klog.Errorf("Failed to get the client applying the resource, pkg: %v/%v, error: %v",
+				appSubUnitStatus.Namespace, appSubUnitStatus.Name, err)
+
+			continue
+		}
+
+		nri := dc.Resource(unit.gvr)
+
+		err = sync.applyTemplate(appsub, dc, nri, isNamespaced, resource, isSpecialResource(unit.gvr), allowlist, denyList, isAdmin)
+
+		if _, skipped := err.(*resourceSkippedError); skipped {
+			appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageSkipped)
+			appSubUnitStatus.Message = utils.RedactError(err)
+			appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
+
+			sync.RecordEvent(appsub, utils.EventReasonPackageSkipped,
+				fmt.Sprintf("Skipped %v %v %v/%v: %v", appSubUnitStatus.APIVersion, appSubUnitStatus.Kind,
+					appSubUnitStatus.Namespace, appSubUnitStatus.Name, err), err)
+
+			continue
+		}
+
+		if err != nil {
+			appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
+			appSubUnitStatus.Message = utils.RedactError(err)
+			appSubUnitStatus.Field = utils.GetErrorFields(err)
 			appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 			gotDeployErrs = true
 
 			klog.Errorf("Failed to apply kind template, pkg: %v/%v, error: %v ",
 				appSubUnitStatus.Namespace, appSubUnitStatus.Name, err)
 
+			sync.RecordEvent(appsub, utils.EventReasonPackageApplyFailed,
+				fmt.Sprintf("Failed to apply %v %v %v/%v: %v", appSubUnitStatus.APIVersion, appSubUnitStatus.Kind,
+					appSubUnitStatus.Namespace, appSubUnitStatus.Name, err), err)
+
 			continue
 		}
 
+		if utils.IsFluxHealthCheck(appsub) && utils.IsFluxResource(resource.Gvk) {
+			if err := getFluxNotReadyError(nri, isNamespaced, resource.Resource); err != nil {
+				appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployFailed)
+				appSubUnitStatus.Message = utils.RedactError(err)
+				appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
+				gotDeployErrs = true
+
+				sync.RecordEvent(appsub, utils.EventReasonPackageNotReady,
+					fmt.Sprintf("%v %v %v/%v: %v", appSubUnitStatus.APIVersion, appSubUnitStatus.Kind,
+						appSubUnitStatus.Namespace, appSubUnitStatus.Name, err), err)
+
+				continue
+			}
+		}
+
 		appSubUnitStatus.Phase = string(appSubStatusV1alpha1.PackageDeployed)
 		appSubUnitStatus.Message = ""
 		appSubUnitStatuses = append(appSubUnitStatuses, appSubUnitStatus)
 	}
 
+	for _, appSubUnitStatus := range appSubUnitStatuses {
+		result := metrics.ResultApplied
+		if appSubUnitStatus.Phase == string(appSubStatusV1alpha1.PackageDeployFailed) {
+			result = metrics.ResultFailed
+		} else if appSubUnitStatus.Phase == string(appSubStatusV1alpha1.PackageSkipped) {
+			result = metrics.ResultSkipped
+		}
+
+		metrics.LocalDeploymentResourceCount.
+			WithLabelValues(appsub.Namespace, appsub.Name, result).
+			Inc()
+	}
+
 	appsubClusterStatus := SubscriptionClusterStatus{
 		Cluster:                   sync.SynchronizerID.Name,
 		AppSub:                    hostSub,
 		Action:                    "APPLY",
+		Commit:                    utils.GetAppsubRevision(appsub),
 		SubscriptionPackageStatus: appSubUnitStatuses,
 	}
 
+	// the provenance records the deployments without errors only
+	if !gotDeployErrs {
+		appsubClusterStatus.Provenance = newProvenance(appsub, units)
+	}
+
+	_, statusSpan := tracing.StartSpan(ctx, "ReportSubscriptionStatus", appsub.Namespace, appsub.Name)
 	err := sync.SyncAppsubClusterStatus(appsub, appsubClusterStatus, nil, nil)
 	endTime := time.Now().UnixMilli()
 
+	statusSpan.End()
+
+	metrics.ObserveLocalDeploymentPhase(appsub.Namespace, appsub.Name, metrics.PhaseApply, endTime-startTime)
+
 	if err != nil {
 		klog.Error("error while sync app sub cluster status: ", err)
 		metrics.LocalDeploymentFailedPullTime.
@@ -384,38 +583,24 @@ func (sync *KubeSynchronizer) ProcessSubResources(appsub *appv1alpha1.Subscripti
 	return nil
 }
 
-func (sync *KubeSynchronizer) createNewResourceByTemplateUnit(ri dynamic.ResourceInterface, tplunit *unstructured.Unstructured) error {
+func (sync *KubeSynchronizer) createNewResourceByTemplateUnit(dc dynamic.Interface, ri dynamic.ResourceInterface,
+	tplunit *unstructured.Unstructured, appsub *appv1alpha1.Subscription) error {
 	klog.Infof("Apply - Creating New Resource: %v/%v, kind: %v", tplunit.GetNamespace(), tplunit.GetName(), tplunit.GetKind())
 
 	tplunit.SetResourceVersion("")
 	obj, err := ri.Create(context.TODO(), tplunit, metav1.CreateOptions{})
 
-	// Auto Create Namespace if not exist
-	if err != nil && errors.IsNotFound(err) {
-		ns := &corev1.Namespace{}
-		ns.Name = tplunit.GetNamespace()
+	// Auto Create Namespace if not exist, unless the subscription never creates the namespaces
+	if err != nil && errors.IsNotFound(err) && appsub.Spec.NamespaceCreation == appv1alpha1.NamespaceCreationNever {
+		klog.Errorf("Apply - Namespace %v doesn't exist and the namespace creation policy of subscription %v/%v is %v",
+			tplunit.GetNamespace(), appsub.Namespace, appsub.Name, appsub.Spec.NamespaceCreation)
 
-		tplanno := tplunit.GetAnnotations()
-		if tplanno == nil {
-			tplanno = make(map[string]string)
-		}
-
-		nsanno := ns.GetAnnotations()
-		if nsanno == nil {
-			nsanno = make(map[string]string)
-		}
-
-		if tplanno[appv1alpha1.AnnotationHosting] > "" {
-			nsanno[appv1alpha1.AnnotationHosting] = tplanno[appv1alpha1.AnnotationHosting]
-			nsanno[appv1alpha1.AnnotationSyncSource] = "subnsdpl-" + tplanno[appv1alpha1.AnnotationHosting]
-		}
-
-		if tplanno[appv1alpha1.AnnotationClusterAdmin] > "" {
-			// Do this so that nested children subscriptions inherit the cluster-admin role elevation as well.
-			nsanno[appv1alpha1.AnnotationClusterAdmin] = tplanno[appv1alpha1.AnnotationClusterAdmin]
-		}
+		return fmt.Errorf("namespace %v doesn't exist and the namespace creation policy of the subscription is %v",
+			tplunit.GetNamespace(), appsub.Spec.NamespaceCreation)
+	}
 
-		ns.SetAnnotations(nsanno)
+	if err != nil && errors.IsNotFound(err) {
+		ns := newNamespace(tplunit, appsub)
 
 		klog.Infof("Apply - Creating New Namespace: %#v", ns)
 
@@ -428,7 +613,7 @@ func (sync *KubeSynchronizer) createNewResourceByTemplateUnit(ri dynamic.Resourc
 				Kind:    "Namespace",
 			})
 
-			_, err = sync.DynamicClient.Resource(schema.GroupVersionResource{
+			_, err = dc.Resource(schema.GroupVersionResource{
 				Version:  "v1",
 				Resource: "namespaces",
 			}).Create(context.TODO(), nsus, metav1.CreateOptions{})
@@ -473,6 +658,13 @@ func (sync *KubeSynchronizer) updateResourceByTemplateUnit(ri dynamic.ResourceIn
 
 	tmplAnnotations := tplunit.GetAnnotations()
 
+	if isUpdateStrategy(tplunit, workv1.UpdateStrategyTypeCreateOnly) || isUpdateStrategy(tplunit, workv1.UpdateStrategyTypeReadOnly) {
+		klog.Infof("Resource %v/%v exists, skip updating it with update strategy: %v",
+			tplunit.GetNamespace(), tplunit.GetName(), tmplAnnotations[appv1alpha1.AnnotationResourceUpdateStrategy])
+
+		return nil
+	}
+
 	if tplown != nil && !sync.Extension.IsObjectOwnedByHost(origUnit, *tplown, sync.SynchronizerID) {
 		// If the subscription is created by a subscription admin and reconcile option exists,
 		// we can update the resource even if it is not owned by this subscription.
@@ -524,7 +716,27 @@ func (sync *KubeSynchronizer) updateResourceByTemplateUnit(ri dynamic.ResourceIn
 		newobj = utils.RemoveSubOwnerRef(newobj)
 	}
 
-	if (merge || specialResource) && !isHelmRelease {
+	if isUpdateStrategy(tplunit, workv1.UpdateStrategyTypeServerSideApply) && !isHelmRelease {
+		var tplb []byte
+
+		newobj.SetResourceVersion("")
+		newobj.SetManagedFields(nil)
+
+		tplb, err = newobj.MarshalJSON()
+		if err != nil {
+			klog.Error("Failed to marshall tplunit with error:", err)
+
+			return err
+		}
+
+		klog.Infof("Server side apply object. obj: %s, %s", newobj.GetName(), newobj.GroupVersionKind().String())
+
+		// the fields in the package are owned by the subscription, the other fields are left to the other managers
+		force := true
+
+		_, err = ri.Patch(context.TODO(), newobj.GetName(), types.ApplyPatchType, tplb,
+			metav1.PatchOptions{FieldManager: serverSideApplyFieldManager, Force: &force})
+	} else if (merge || specialResource) && !isHelmRelease {
 		if specialResource {
 			klog.Info("One of special resources requiring merge update")
 		}
@@ -555,13 +767,15 @@ func (sync *KubeSynchronizer) updateResourceByTemplateUnit(ri dynamic.ResourceIn
 			return err
 		}
 
-		klog.Infof("Patch object. obj: %s, %s, patch: %s", origUnit.GetName(), origUnit.GroupVersionKind().String(), string(pb))
-		klog.V(1).Info("Generating Patch for service update.\nObjb:", string(objb), "\ntplb:", string(tplb), "\nPatch:", string(pb))
+		klog.Infof("Patch object. obj: %s, %s, patch: %s", origUnit.GetName(), origUnit.GroupVersionKind().String(),
+			utils.RedactJSON(origUnit.GetKind(), pb))
+		klog.V(1).Info("Generating Patch for service update.\nObjb:", utils.RedactJSON(origUnit.GetKind(), objb),
+			"\ntplb:", utils.RedactJSON(origUnit.GetKind(), tplb), "\nPatch:", utils.RedactJSON(origUnit.GetKind(), pb))
 
 		_, err = ri.Patch(context.TODO(), origUnit.GetName(), types.MergePatchType, pb, metav1.PatchOptions{})
 	} else {
 		klog.Info("Apply object. newobj: " + newobj.GroupVersionKind().String())
-		klog.V(1).Infof("Apply object. newobj: %#v", newobj)
+		klog.V(1).Infof("Apply object. newobj: %#v", utils.RedactObject(newobj))
 		_, err = ri.Update(context.TODO(), newobj, metav1.UpdateOptions{})
 
 		// Some kubernetes resources are immutable after creation. Log and ignore update errors.
@@ -576,7 +790,7 @@ func (sync *KubeSynchronizer) updateResourceByTemplateUnit(ri dynamic.ResourceIn
 		}
 	}
 
-	klog.Info("Check - Updated existing Resource to", tplunit, " with err:", err)
+	klog.Info("Check - Updated existing Resource to", utils.RedactObject(tplunit), " with err:", err)
 
 	if err != nil {
 		klog.Error("Failed to update resource with error:", err)
@@ -591,6 +805,14 @@ func (sync *KubeSynchronizer) updateResourceByTemplateUnit(ri dynamic.ResourceIn
 	return nil
 }
 
+// serverSideApplyFieldManager is the field manager of the resources applied with the ServerSideApply update strategy
+const serverSideApplyFieldManager = "multicluster-operators-subscription"
+
+// isUpdateStrategy checks if the resource update strategy annotation is the given ocm work API update strategy type
+func isUpdateStrategy(tplunit *unstructured.Unstructured, updateStrategy workv1.UpdateStrategyType) bool {
+	return strings.EqualFold(tplunit.GetAnnotations()[appv1alpha1.AnnotationResourceUpdateStrategy], string(updateStrategy))
+}
+
 var serviceGVR = schema.GroupVersionResource{
 	Version:  "v1",
 	Resource: "services",
@@ -610,8 +832,18 @@ func isSpecialResource(gvr schema.GroupVersionResource) bool {
 	return gvr == serviceGVR || gvr == serviceAccountGVR || gvr == namespaceGVR
 }
 
-func (sync *KubeSynchronizer) applyTemplate(nri dynamic.NamespaceableResourceInterface, namespaced bool,
-	resource ResourceUnit, specialResource bool, allowlist, denyList map[string]map[string]string, isAdmin bool) error {
+// resourceSkippedError is returned by applyTemplate for the resources not deployed because of the allow and deny lists
+type resourceSkippedError struct {
+	msg string
+}
+
+func (e *resourceSkippedError) Error() string {
+	return e.msg
+}
+
+func (sync *KubeSynchronizer) applyTemplate(appsub *appv1alpha1.Subscription, dc dynamic.Interface,
+	nri dynamic.NamespaceableResourceInterface, namespaced bool, resource ResourceUnit, specialResource bool, allowlist, denyList map[string]map[string]string,
+	isAdmin bool) error {
 	tplunit := resource.Resource
 	klog.Infof("Applying template: %v/%v, kind: %v", tplunit.GetNamespace(), tplunit.GetName(), tplunit.GetKind())
 
@@ -629,8 +861,8 @@ func (sync *KubeSynchronizer) applyTemplate(nri dynamic.NamespaceableResourceInt
 	}
 
 	if utils.IsResourceDenied(*tplunit, denyList, isAdmin) {
-		denyError := fmt.Errorf("the resource apiVersion: %s kind: %s is on the deny list. Not deployed",
-			tplunit.GetAPIVersion(), tplunit.GetKind())
+		denyError := &resourceSkippedError{fmt.Sprintf("the resource apiVersion: %s kind: %s is on the deny list. Not deployed",
+			tplunit.GetAPIVersion(), tplunit.GetKind())}
 
 		klog.Info(denyError.Error())
 
@@ -638,12 +870,13 @@ func (sync *KubeSynchronizer) applyTemplate(nri dynamic.NamespaceableResourceInt
 	}
 
 	if !utils.IsResourceAllowed(*tplunit, allowlist, isAdmin) {
-		denyError := fmt.Errorf("the resource apiVersion: %s kind: %s is not on the allow list. Not deployed",
-			tplunit.GetAPIVersion(), tplunit.GetKind())
+		denyError := &resourceSkippedError{fmt.Sprintf("the resource apiVersion: %s kind: %s is not on the allow list. Not deployed",
+			tplunit.GetAPIVersion(), tplunit.GetKind())}
 
 		if !isAdmin {
-			denyError = fmt.Errorf("not deployed by a subscription admin. the resource apiVersion: %s kind: %s is not deployed",
-				tplunit.GetAPIVersion(), tplunit.GetKind())
+			denyError = &resourceSkippedError{fmt.Sprintf(
+				"not deployed by a subscription admin. the resource apiVersion: %s kind: %s is not deployed",
+				tplunit.GetAPIVersion(), tplunit.GetKind())}
 		}
 
 		klog.Info(denyError.Error())
@@ -654,8 +887,10 @@ func (sync *KubeSynchronizer) applyTemplate(nri dynamic.NamespaceableResourceInt
 	origUnit, err := ri.Get(context.TODO(), tplunit.GetName(), metav1.GetOptions{})
 
 	if err != nil {
-		if errors.IsNotFound(err) {
-			err = sync.createNewResourceByTemplateUnit(ri, tplunit)
+		if errors.IsNotFound(err) && isUpdateStrategy(tplunit, workv1.UpdateStrategyTypeReadOnly) {
+			klog.Infof("Resource %v/%v with ReadOnly update strategy does not exist", tplunit.GetNamespace(), tplunit.GetName())
+		} else if errors.IsNotFound(err) {
+			err = sync.createNewResourceByTemplateUnit(dc, ri, tplunit, appsub)
 		} else {
 			klog.Error("Failed to apply resource with error:", err)
 		}
@@ -668,6 +903,25 @@ func (sync *KubeSynchronizer) applyTemplate(nri dynamic.NamespaceableResourceInt
 	return err
 }
 
+// getFluxNotReadyError returns an error if the applied Flux custom resource is not ready
+func getFluxNotReadyError(nri dynamic.NamespaceableResourceInterface, namespaced bool,
+	tplunit *unstructured.Unstructured) error {
+	var ri dynamic.ResourceInterface = nri
+	if namespaced {
+		ri = nri.Namespace(tplunit.GetNamespace())
+	}
+
+	live, err := ri.Get(context.TODO(), tplunit.GetName(), metav1.GetOptions{})
+	if err != nil {
+		klog.Infof("Failed to get the Flux resource %v/%v to check its readiness, err: %v", tplunit.GetNamespace(),
+			tplunit.GetName(), err)
+
+		return nil
+	}
+
+	return utils.GetFluxNotReadyError(live)
+}
+
 // OverrideResource updates resource based on the hosting appsub before the resource is deployed.
 func (sync *KubeSynchronizer) OverrideResource(hostSub types.NamespacedName, resource *ResourceUnit) (*unstructured.Unstructured, error) {
 	// Parse the resource in template
@@ -700,7 +954,7 @@ func (sync *KubeSynchronizer) OverrideResource(hostSub types.NamespacedName, res
 		tpllbls = make(map[string]string)
 	}
 
-	klog.V(1).Infof("pre template lables : %v", tpllbls)
+	logging.ForSubscription(appsub).V(1).Info("pre template labels", "labels", tpllbls)
 
 	for k, v := range appsub.GetLabels() {
 		if _, ok := tpllbls[k]; ok {
@@ -710,7 +964,7 @@ func (sync *KubeSynchronizer) OverrideResource(hostSub types.NamespacedName, res
 		tpllbls[k] = v
 	}
 
-	klog.V(1).Infof("template lables combinded with appsub labels: %v", tpllbls)
+	logging.ForSubscription(appsub).V(1).Info("template labels combined with appsub labels", "labels", tpllbls)
 
 	template.SetLabels(tpllbls)
 
@@ -314,6 +314,27 @@ var _ = Describe("test Delete Single Subscribed Resource", func() {
 		err = sync.DeleteSingleSubscribedResource(hostSub, pkgStatus)
 		Expect(err).To(BeNil())
 	})
+
+	It("should report the resource with do-not-delete annotation as retained", func() {
+		workload1 := workload4Configmap.DeepCopy()
+		Expect(k8sClient.Create(context.TODO(), workload1)).NotTo(HaveOccurred())
+
+		defer k8sClient.Delete(context.TODO(), workload1)
+
+		pkgStatus := appSubStatusV1alpha1.SubscriptionUnitStatus{
+			Name:       "configmap2",
+			Namespace:  "appsub-ns-1",
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		}
+
+		retained, err := sync.deleteSubscribedResource(hostSub, pkgStatus)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(retained).To(BeTrue())
+
+		cm := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: "configmap2", Namespace: "appsub-ns-1"}, cm)).To(Succeed())
+	})
 })
 
 var _ = Describe("test PurgeAllSubscribedResources", func() {
@@ -816,3 +837,51 @@ var _ = Describe("test cleanup of resources", func() {
 		}).Should(BeTrue())
 	})
 })
+
+var _ = Describe("test resource update strategy", func() {
+	var sync *KubeSynchronizer
+	var err error
+
+	BeforeEach(func() {
+		sync, err = CreateSynchronizer(k8sManager.GetConfig(), k8sManager.GetConfig(), k8sManager.GetScheme(), &host, 2, nil, false, false)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should honor the CreateOnly and ServerSideApply update strategies", func() {
+		configmap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "update-strategy-configmap",
+				Namespace: "default",
+			},
+			Data: map[string]string{"key": "original"},
+		}
+		Expect(k8sClient.Create(context.TODO(), configmap)).NotTo(HaveOccurred())
+
+		defer k8sClient.Delete(context.TODO(), configmap)
+
+		ri := sync.DynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace("default")
+
+		tplunit := &unstructured.Unstructured{}
+		tplunit.SetAPIVersion("v1")
+		tplunit.SetKind("ConfigMap")
+		tplunit.SetName(configmap.Name)
+		tplunit.SetNamespace(configmap.Namespace)
+		Expect(unstructured.SetNestedStringMap(tplunit.Object, map[string]string{"key": "updated"}, "data")).To(Succeed())
+
+		updated := &corev1.ConfigMap{}
+
+		// CreateOnly leaves the existing resource untouched
+		tplunit.SetAnnotations(map[string]string{appv1alpha1.AnnotationResourceUpdateStrategy: "CreateOnly"})
+		origUnit, err := ri.Get(context.TODO(), configmap.Name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sync.updateResourceByTemplateUnit(ri, origUnit, tplunit, false)).To(Succeed())
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: configmap.Name, Namespace: configmap.Namespace}, updated)).To(Succeed())
+		Expect(updated.Data["key"]).To(Equal("original"))
+
+		// ServerSideApply updates the resource
+		tplunit.SetAnnotations(map[string]string{appv1alpha1.AnnotationResourceUpdateStrategy: "ServerSideApply"})
+		Expect(sync.updateResourceByTemplateUnit(ri, origUnit, tplunit, false)).To(Succeed())
+		Expect(k8sClient.Get(context.TODO(), types.NamespacedName{Name: configmap.Name, Namespace: configmap.Namespace}, updated)).To(Succeed())
+		Expect(updated.Data["key"]).To(Equal("updated"))
+	})
+})
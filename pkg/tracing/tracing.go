@@ -0,0 +1,124 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	tracerName = "open-cluster-management.io/multicloud-operators-subscription"
+
+	// Span attribute keys
+	AttributeSubscriptionNamespace = "subscription.namespace"
+	AttributeSubscriptionName      = "subscription.name"
+	AttributeCluster               = "cluster"
+)
+
+var propagator = propagation.TraceContext{}
+
+// InitTracer exports the spans to the OTLP gRPC endpoint. Tracing is disabled if the endpoint is empty.
+// The returned function flushes the pending spans and stops the exporter.
+func InitTracer(ctx context.Context, serviceName, endpoint string, insecure bool) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	klog.Infof("Exporting traces of %v to %v", serviceName, endpoint)
+
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span for the subscription
+func StartSpan(ctx context.Context, spanName, namespace, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, spanName, trace.WithAttributes(
+		attribute.String(AttributeSubscriptionNamespace, namespace),
+		attribute.String(AttributeSubscriptionName, name),
+	))
+}
+
+// ContextFromAnnotations returns a context carrying the trace context saved in the subscription annotations,
+// so the spans of the managed cluster are linked to the hub propagation
+func ContextFromAnnotations(ctx context.Context, annotations map[string]string) context.Context {
+	return propagator.Extract(ctx, annotationCarrier(annotations))
+}
+
+// HasSpan returns true if ctx carries a valid span, i.e. tracing is enabled
+func HasSpan(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
+
+// InjectAnnotations saves the trace context of ctx in the annotations. Nothing is saved if ctx has no valid span.
+func InjectAnnotations(ctx context.Context, annotations map[string]string) {
+	propagator.Inject(ctx, annotationCarrier(annotations))
+}
+
+// annotationCarrier maps the W3C trace context headers to the subscription trace annotations
+type annotationCarrier map[string]string
+
+var carrierAnnotations = map[string]string{
+	"traceparent": appv1.AnnotationTraceParent,
+	"tracestate":  appv1.AnnotationTraceState,
+}
+
+func (c annotationCarrier) Get(key string) string {
+	return c[carrierAnnotations[key]]
+}
+
+func (c annotationCarrier) Set(key, value string) {
+	if annotation, ok := carrierAnnotations[key]; ok {
+		c[annotation] = value
+	}
+}
+
+func (c annotationCarrier) Keys() []string {
+	keys := []string{}
+
+	for key, annotation := range carrierAnnotations {
+		if _, ok := c[annotation]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestTraceContextAnnotations(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	annotations := map[string]string{appv1.AnnotationGitBranch: "main"}
+
+	// nothing is saved without a span
+	InjectAnnotations(context.TODO(), annotations)
+	g.Expect(annotations).To(gomega.HaveLen(1))
+	g.Expect(HasSpan(context.TODO())).To(gomega.BeFalse())
+
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.TODO())
+
+	ctx, span := provider.Tracer("test").Start(context.TODO(), "propagate")
+	defer span.End()
+
+	g.Expect(HasSpan(ctx)).To(gomega.BeTrue())
+
+	InjectAnnotations(ctx, annotations)
+	g.Expect(annotations).To(gomega.HaveKey(appv1.AnnotationTraceParent))
+	g.Expect(annotations[appv1.AnnotationGitBranch]).To(gomega.Equal("main"))
+
+	// the managed cluster spans are linked to the hub span
+	remote := trace.SpanContextFromContext(ContextFromAnnotations(context.TODO(), annotations))
+	g.Expect(remote.IsRemote()).To(gomega.BeTrue())
+	g.Expect(remote.TraceID()).To(gomega.Equal(span.SpanContext().TraceID()))
+	g.Expect(remote.SpanID()).To(gomega.Equal(span.SpanContext().SpanID()))
+}
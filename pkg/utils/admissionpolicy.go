@@ -0,0 +1,117 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// PolicyEngineKyverno is the policy engine of the Kyverno admission webhooks
+	PolicyEngineKyverno = "kyverno"
+	// PolicyEngineGatekeeper is the policy engine of the OPA Gatekeeper admission webhooks
+	PolicyEngineGatekeeper = "gatekeeper"
+
+	kyvernoBlockedPolicyText = "following policies"
+)
+
+var (
+	regexAdmissionDenied  = regexp.MustCompile(`admission webhook "([^"]+)" denied the request:\s*(?s)(.*)$`)
+	regexGatekeeperPolicy = regexp.MustCompile(`(?m)^\s*\[([^\]]+)\]`)
+	regexKyvernoPolicy    = regexp.MustCompile(`(?m)^([^\s:][^:]*):\s*$`)
+)
+
+// PolicyViolation is a resource denied by a validating admission webhook, like a Kyverno or Gatekeeper policy
+type PolicyViolation struct {
+	// Webhook is the name of the admission webhook denying the resource
+	Webhook string
+	// Engine is the policy engine of the webhook, kyverno or gatekeeper, empty for the other webhooks
+	Engine string
+	// Policies are the names of the violated policies or constraints found in the webhook message
+	Policies []string
+	// Message is the message of the webhook
+	Message string
+}
+
+func (v *PolicyViolation) Error() string {
+	if len(v.Policies) == 0 {
+		return fmt.Sprintf("is denied by the admission webhook %v: %v", v.Webhook, v.Message)
+	}
+
+	return fmt.Sprintf("violates the %v policies %v: %v", v.Engine, strings.Join(v.Policies, ", "), v.Message)
+}
+
+// IsPolicyCheck returns true if the resources of the subscription are evaluated against the admission policies of
+// the cluster before they are applied
+func IsPolicyCheck(sub *appv1.Subscription) bool {
+	return strings.EqualFold(sub.GetAnnotations()[appv1.AnnotationPolicyCheck], "true")
+}
+
+// GetPolicyViolation returns the policy violation of an error returned by the API server, nil if the error is not
+// an admission webhook denial
+func GetPolicyViolation(err error) *PolicyViolation {
+	var statusErr apierrors.APIStatus
+	if err == nil || !errors.As(err, &statusErr) {
+		return nil
+	}
+
+	matches := regexAdmissionDenied.FindStringSubmatch(statusErr.Status().Message)
+	if matches == nil {
+		return nil
+	}
+
+	violation := &PolicyViolation{
+		Webhook: matches[1],
+		Message: strings.Join(strings.Fields(matches[2]), " "),
+	}
+
+	webhook := strings.ToLower(violation.Webhook)
+
+	switch {
+	case strings.Contains(webhook, PolicyEngineKyverno):
+		violation.Engine = PolicyEngineKyverno
+
+		// the policy names are the unindented lines following the "blocked due to the following policies" line
+		if _, policies, found := strings.Cut(matches[2], kyvernoBlockedPolicyText); found {
+			for _, m := range regexKyvernoPolicy.FindAllStringSubmatch(policies, -1) {
+				violation.Policies = appendUnique(violation.Policies, strings.TrimSpace(m[1]))
+			}
+		}
+	case strings.Contains(webhook, PolicyEngineGatekeeper):
+		violation.Engine = PolicyEngineGatekeeper
+
+		// each violation of a constraint starts with the constraint name in brackets
+		for _, m := range regexGatekeeperPolicy.FindAllStringSubmatch(matches[2], -1) {
+			violation.Policies = appendUnique(violation.Policies, strings.TrimSpace(m[1]))
+		}
+	}
+
+	return violation
+}
+
+func appendUnique(list []string, s string) []string {
+	if slices.Contains(list, s) {
+		return list
+	}
+
+	return append(list, s)
+}
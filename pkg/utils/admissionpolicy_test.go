@@ -0,0 +1,73 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newAdmissionDeniedError(msg string) error {
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusBadRequest,
+		Reason:  metav1.StatusReasonBadRequest,
+		Message: msg,
+	}}
+}
+
+func TestGetPolicyViolation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(GetPolicyViolation(nil)).To(gomega.BeNil())
+	g.Expect(GetPolicyViolation(errors.New("connection refused"))).To(gomega.BeNil())
+	g.Expect(GetPolicyViolation(apierrors.NewNotFound(metav1.SchemeGroupVersion.WithResource("namespaces").GroupResource(),
+		"app"))).To(gomega.BeNil())
+
+	violation := GetPolicyViolation(newAdmissionDeniedError(`admission webhook "validate.kyverno.svc-fail" denied the ` +
+		"request: \n\nresource Deployment/app/web was blocked due to the following policies \n\n" +
+		"require-labels:\n  check-for-labels: 'validation error: label ''owner'' is required. rule check-for-labels " +
+		"failed at path /metadata/labels/owner/'\n" +
+		"disallow-latest-tag:\n  validate-image-tag: 'validation error: Using a mutable image tag e.g. ''latest'' is " +
+		"not allowed. rule validate-image-tag failed at path /spec/template/spec/containers/0/image/'\n"))
+	g.Expect(violation).NotTo(gomega.BeNil())
+	g.Expect(violation.Webhook).To(gomega.Equal("validate.kyverno.svc-fail"))
+	g.Expect(violation.Engine).To(gomega.Equal(PolicyEngineKyverno))
+	g.Expect(violation.Policies).To(gomega.Equal([]string{"require-labels", "disallow-latest-tag"}))
+	g.Expect(violation.Error()).To(gomega.HavePrefix("violates the kyverno policies require-labels, disallow-latest-tag: " +
+		"resource Deployment/app/web was blocked"))
+
+	violation = GetPolicyViolation(newAdmissionDeniedError(`admission webhook "validation.gatekeeper.sh" denied the ` +
+		"request: [must-have-owner] you must provide labels: {\"owner\"}\n" +
+		"[allowed-repos] container <web> has an invalid image repo <nginx>\n" +
+		"[must-have-owner] you must provide labels: {\"team\"}"))
+	g.Expect(violation).NotTo(gomega.BeNil())
+	g.Expect(violation.Engine).To(gomega.Equal(PolicyEngineGatekeeper))
+	g.Expect(violation.Policies).To(gomega.Equal([]string{"must-have-owner", "allowed-repos"}))
+
+	// the other admission webhooks are reported without policies
+	violation = GetPolicyViolation(newAdmissionDeniedError(`admission webhook "pod-policy.example.com" denied the ` +
+		"request: privileged containers are not allowed"))
+	g.Expect(violation).NotTo(gomega.BeNil())
+	g.Expect(violation.Engine).To(gomega.BeEmpty())
+	g.Expect(violation.Policies).To(gomega.BeEmpty())
+	g.Expect(violation.Error()).To(gomega.Equal("is denied by the admission webhook " +
+		"pod-policy.example.com: privileged containers are not allowed"))
+}
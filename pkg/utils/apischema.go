@@ -0,0 +1,231 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// ClusterAPISchemasConfigMapName is the name of the API schema cache of a managed cluster, in the cluster
+	// namespace on the hub
+	ClusterAPISchemasConfigMapName = "application-manager-api-schemas"
+	// ClusterAPISchemasKey is the key of the gzipped JSON API schemas in the binary data of the schema cache
+	ClusterAPISchemasKey = "schemas.json.gz"
+
+	// maximum size of the schema cache, below the 1MiB limit of a ConfigMap
+	MaxClusterAPISchemasSize = 900 * 1024
+)
+
+// ClusterAPISchemas are the API resources served by a managed cluster
+type ClusterAPISchemas struct {
+	// Resources are indexed by group/version/kind
+	Resources map[string]*APIResourceSchema `json:"resources"`
+}
+
+// APIResourceSchema is an API resource served by a managed cluster, the OpenAPI schema is only collected for the
+// custom resources
+type APIResourceSchema struct {
+	Namespaced bool                             `json:"namespaced"`
+	Schema     *apiextensionsv1.JSONSchemaProps `json:"schema,omitempty"`
+}
+
+// APIResourceKey returns the key of a group version kind in the cluster API schemas
+func APIResourceKey(gvk schema.GroupVersionKind) string {
+	return gvk.Group + "/" + gvk.Version + "/" + gvk.Kind
+}
+
+// IsManifestValidationEnabled checks if the manifests of the subscription are validated against the managed
+// cluster API schemas before the propagation
+func IsManifestValidationEnabled(sub *appv1.Subscription) bool {
+	return strings.EqualFold(sub.GetAnnotations()[appv1.AnnotationValidateManifests], "true")
+}
+
+// EncodeClusterAPISchemas returns the gzipped JSON of the cluster API schemas
+func EncodeClusterAPISchemas(schemas *ClusterAPISchemas) ([]byte, error) {
+	raw, err := json.Marshal(schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeClusterAPISchemas decodes the gzipped JSON of the cluster API schemas
+func DecodeClusterAPISchemas(data []byte) (*ClusterAPISchemas, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := &ClusterAPISchemas{}
+	if err := json.Unmarshal(raw, schemas); err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
+
+// ValidateManifests validates the manifests against the API schemas of a managed cluster. It returns an error per
+// invalid manifest. The kind of each manifest has to be served by the cluster or defined by a CRD of the manifests,
+// the custom resources are also validated against the OpenAPI schema of their CRD.
+func ValidateManifests(schemas *ClusterAPISchemas, manifests []*unstructured.Unstructured) []string {
+	resources := map[string]*APIResourceSchema{}
+
+	for key, res := range schemas.Resources {
+		resources[key] = res
+	}
+
+	// the CRDs deployed with the manifests define new kinds or new schemas
+	for _, obj := range manifests {
+		if obj.GetKind() != "CustomResourceDefinition" || obj.GroupVersionKind().Group != apiextensionsv1.GroupName {
+			continue
+		}
+
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, crd); err != nil {
+			continue
+		}
+
+		for key, res := range GetCRDResourceSchemas(crd) {
+			resources[key] = res
+		}
+	}
+
+	errs := []string{}
+
+	for _, obj := range manifests {
+		if err := validateManifest(resources, obj); err != nil {
+			errs = append(errs, fmt.Sprintf("%v %v: %v", obj.GetKind(), getManifestName(obj), err))
+		}
+	}
+
+	sort.Strings(errs)
+
+	return errs
+}
+
+// GetCRDResourceSchemas returns the API resource schemas of the served versions of a CRD
+func GetCRDResourceSchemas(crd *apiextensionsv1.CustomResourceDefinition) map[string]*APIResourceSchema {
+	resources := map[string]*APIResourceSchema{}
+
+	for _, version := range crd.Spec.Versions {
+		if !version.Served {
+			continue
+		}
+
+		res := &APIResourceSchema{Namespaced: crd.Spec.Scope == apiextensionsv1.NamespaceScoped}
+		if version.Schema != nil {
+			res.Schema = version.Schema.OpenAPIV3Schema
+		}
+
+		gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+		resources[APIResourceKey(gvk)] = res
+	}
+
+	return resources
+}
+
+func validateManifest(resources map[string]*APIResourceSchema, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+
+	res, ok := resources[APIResourceKey(gvk)]
+	if !ok {
+		return fmt.Errorf("kind %v of apiVersion %v is not served by the cluster", gvk.Kind, gvk.GroupVersion().String())
+	}
+
+	if res.Schema == nil {
+		return nil
+	}
+
+	internal := &apiextensions.JSONSchemaProps{}
+	if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(res.Schema, internal, nil); err != nil {
+		return fmt.Errorf("failed to convert the schema of kind %v: %w", gvk.Kind, err)
+	}
+
+	// the status of the manifests is not applied
+	required := []string{}
+
+	for _, field := range internal.Required {
+		if field != "status" {
+			required = append(required, field)
+		}
+	}
+
+	internal.Required = required
+
+	validator, _, err := validation.NewSchemaValidator(internal)
+	if err != nil {
+		return fmt.Errorf("failed to load the schema of kind %v: %w", gvk.Kind, err)
+	}
+
+	content := obj.DeepCopy().Object
+	delete(content, "status")
+
+	result := validator.Validate(content)
+	if result == nil || result.IsValid() {
+		return nil
+	}
+
+	msgs := []string{}
+	for _, err := range result.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return errors.New(strings.Join(msgs, ", "))
+}
+
+func getManifestName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
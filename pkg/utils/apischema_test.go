@@ -0,0 +1,115 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var widgetSchema = &apiextensionsv1.JSONSchemaProps{
+	Type: "object",
+	Properties: map[string]apiextensionsv1.JSONSchemaProps{
+		"spec": {
+			Type:     "object",
+			Required: []string{"size"},
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"size": {Type: "integer"},
+			},
+		},
+	},
+}
+
+const gadgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Gadget
+    plural: gadgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              color:
+                type: string`
+
+func parseManifest(g *gomega.WithT, manifest string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	g.Expect(yaml.Unmarshal([]byte(manifest), obj)).To(gomega.Succeed())
+
+	return obj
+}
+
+func TestValidateManifests(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	schemas := &ClusterAPISchemas{Resources: map[string]*APIResourceSchema{
+		"/v1/ConfigMap":         {Namespaced: true},
+		"example.com/v1/Widget": {Namespaced: true, Schema: widgetSchema},
+	}}
+
+	// the schemas are saved gzipped on the hub
+	data, err := EncodeClusterAPISchemas(schemas)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	schemas, err = DecodeClusterAPISchemas(data)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(schemas.Resources).To(gomega.HaveKey("example.com/v1/Widget"))
+
+	valid := []*unstructured.Unstructured{
+		parseManifest(g, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n  namespace: default\n"),
+		parseManifest(g, "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\nspec:\n  size: 3\n"),
+	}
+
+	g.Expect(ValidateManifests(schemas, valid)).To(gomega.BeEmpty())
+
+	invalid := []*unstructured.Unstructured{
+		parseManifest(g, "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n  namespace: default\nspec:\n  size: big\n"),
+		parseManifest(g, "apiVersion: example.com/v2\nkind: Widget\nmetadata:\n  name: w2\nspec:\n  size: 3\n"),
+	}
+
+	errs := ValidateManifests(schemas, invalid)
+	g.Expect(errs).To(gomega.HaveLen(2))
+	g.Expect(errs[0]).To(gomega.HavePrefix("Widget default/w: "))
+	g.Expect(errs[0]).To(gomega.ContainSubstring("spec.size"))
+	g.Expect(errs[1]).To(gomega.ContainSubstring("kind Widget of apiVersion example.com/v2 is not served by the cluster"))
+
+	// the CRDs of the manifests define the kinds of the custom resources
+	withCRD := []*unstructured.Unstructured{
+		parseManifest(g, "apiVersion: example.com/v1\nkind: Gadget\nmetadata:\n  name: gadget\nspec:\n  color: 1\n"),
+		parseManifest(g, gadgetCRD),
+	}
+
+	schemas.Resources["apiextensions.k8s.io/v1/CustomResourceDefinition"] = &APIResourceSchema{}
+
+	errs = ValidateManifests(schemas, withCRD)
+	g.Expect(errs).To(gomega.HaveLen(1))
+	g.Expect(errs[0]).To(gomega.HavePrefix("Gadget gadget: "))
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	gerr "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// InitChannelObjectStore connects to the object store of an object bucket channel with the channel secret and config
+// map, and returns the handler and the bucket of the channel once the bucket is accessible
+func InitChannelObjectStore(clt client.Client, channel *chnv1.Channel) (*Handler, string, error) {
+	var err error
+
+	awshandler := &Handler{}
+
+	pathName := channel.Spec.Pathname
+
+	if pathName == "" {
+		errmsg := "Empty Pathname in channel " + channel.Spec.Pathname
+		klog.Error(errmsg)
+
+		return nil, "", errors.New(errmsg)
+	}
+
+	if strings.HasSuffix(pathName, "/") {
+		last := len(pathName) - 1
+		pathName = pathName[:last]
+	}
+
+	loc := strings.LastIndex(pathName, "/")
+	if loc < 0 {
+		return nil, "", errors.New("no bucket in the pathname of channel " + channel.Spec.Pathname)
+	}
+
+	endpoint := pathName[:loc]
+	bucket := pathName[loc+1:]
+
+	accessKeyID := ""
+	secretAccessKey := ""
+	region := ""
+	objInsecureSkipVerify := "false"
+	objCaCert := ""
+
+	if utils.HasChannelSecret(channel) {
+		var channelSecret *corev1.Secret
+
+		channelSecret, err = utils.ResolveChannelSecret(clt, channel)
+		if err != nil {
+			return nil, "", gerr.Wrap(err, "failed to get reference secret from channel")
+		}
+
+		err = yaml.Unmarshal(channelSecret.Data[SecretMapKeyAccessKeyID], &accessKeyID)
+		if err != nil {
+			klog.Error("Failed to unmashall accessKey from secret with error:", err)
+
+			return nil, "", err
+		}
+
+		err = yaml.Unmarshal(channelSecret.Data[SecretMapKeySecretAccessKey], &secretAccessKey)
+		if err != nil {
+			klog.Error("Failed to unmashall secretaccessKey from secret with error:", err)
+
+			return nil, "", err
+		}
+
+		regionData := channelSecret.Data[SecretMapKeyRegion]
+
+		if len(regionData) > 0 {
+			err = yaml.Unmarshal(regionData, &region)
+			if err != nil {
+				klog.Error("Failed to unmashall region from secret with error:", err)
+
+				return nil, "", err
+			}
+		}
+	}
+
+	if channel.Spec.ConfigMapRef != nil {
+		configMapRet := utils.GetChannelConfigMap(clt, channel)
+
+		if configMapRet != nil {
+			objCaCert = configMapRet.Data[appv1.ChannelCertificateData]
+			if objCaCert != "" {
+				klog.Info("ObjectStore channel config map with CA certs found")
+			}
+		}
+	}
+
+	if channel.Spec.InsecureSkipVerify {
+		objInsecureSkipVerify = "true"
+	}
+
+	klog.V(1).Info("Trying to connect to object bucket ", endpoint, "|", bucket)
+
+	if err := awshandler.InitObjectStoreConnection(
+		endpoint, accessKeyID, secretAccessKey, region, objInsecureSkipVerify, objCaCert); err != nil {
+		klog.Error(err, "unable initialize object store settings")
+
+		return nil, "", err
+	}
+	// Check whether the connection is setup successfully
+	if err := awshandler.Exists(bucket); err != nil {
+		klog.Error(err, "Unable to access object store bucket ", bucket, " for channel ", channel.Name)
+
+		return nil, "", err
+	}
+
+	return awshandler, bucket, nil
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/klog"
 
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 )
 
 // ObjectStore interface.
@@ -171,7 +172,7 @@ func (h *Handler) InitObjectStoreConnection(
 	// Create a custom HTTP transport with TLS configuration
 	transport := &http.Transport{
 		// Custom TLS configuration
-		TLSClientConfig: createCustomTLSConfig(objInsecureSkipVerify, objCaCert),
+		TLSClientConfig: utils.SetFIPSTLSConfig(createCustomTLSConfig(objInsecureSkipVerify, objCaCert)),
 
 		// Optional: Customize connection pooling and timeouts
 		MaxIdleConns:        100,
@@ -0,0 +1,68 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+
+	"k8s.io/klog"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// SignatureKeySuffix is the key suffix of the cosign signature object of a manifest object
+const SignatureKeySuffix = ".sig"
+
+// IsSignatureKey returns true if the object key is a cosign signature object, it is not a manifest
+func IsSignatureKey(key string) bool {
+	return strings.HasSuffix(key, SignatureKeySuffix)
+}
+
+// VerifyObjectSignature verifies the content of the manifest object with its <key>.sig cosign signature object. The
+// keys are the listed keys of the bucket.
+func VerifyObjectSignature(store ObjectStore, bucket, key string, content []byte, keys []string,
+	publicKey crypto.PublicKey) error {
+	sigKey := key + SignatureKeySuffix
+
+	found := false
+
+	for _, k := range keys {
+		if k == sigKey {
+			found = true
+
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w, the signature object %v of %v is missing in bucket %v", utils.ErrCosignSignatureInvalid,
+			sigKey, key, bucket)
+	}
+
+	sigObj, err := store.Get(bucket, sigKey)
+	if err != nil {
+		klog.Error("Failed to get signature object ", sigKey, " in bucket ", bucket)
+
+		return err
+	}
+
+	if err := utils.VerifyCosignSignature(publicKey, content, sigObj.Content); err != nil {
+		return fmt.Errorf("failed to verify %v in bucket %v: %w", key, bucket, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/onsi/gomega"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+func TestVerifyObjectSignature(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	backend := s3mem.New()
+	faker := gofakes3.New(backend)
+	ts := httptest.NewServer(faker.Server())
+
+	defer ts.Close()
+
+	awshandler := &Handler{}
+	g.Expect(awshandler.InitObjectStoreConnection(ts.URL, "randomid", "randomkey", "minio", "false", "")).To(gomega.Succeed())
+	g.Expect(awshandler.Create("signed")).To(gomega.Succeed())
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	content := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: signed\n")
+	digest := sha256.Sum256(content)
+
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(awshandler.Put("signed", DeployableObject{Name: "cm.yaml", Content: content})).To(gomega.Succeed())
+	g.Expect(awshandler.Put("signed", DeployableObject{Name: "cm.yaml.sig",
+		Content: []byte(base64.StdEncoding.EncodeToString(sig))})).To(gomega.Succeed())
+	g.Expect(awshandler.Put("signed", DeployableObject{Name: "unsigned.yaml", Content: content})).To(gomega.Succeed())
+
+	keys, err := awshandler.List("signed", nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(IsSignatureKey("cm.yaml.sig")).To(gomega.BeTrue())
+	g.Expect(IsSignatureKey("cm.yaml")).To(gomega.BeFalse())
+
+	g.Expect(VerifyObjectSignature(awshandler, "signed", "cm.yaml", content, keys, &key.PublicKey)).To(gomega.Succeed())
+
+	// the content was replaced after signing
+	err = VerifyObjectSignature(awshandler, "signed", "cm.yaml", []byte("kind: Secret"), keys, &key.PublicKey)
+	g.Expect(errors.Is(err, utils.ErrCosignSignatureInvalid)).To(gomega.BeTrue())
+
+	err = VerifyObjectSignature(awshandler, "signed", "unsigned.yaml", content, keys, &key.PublicKey)
+	g.Expect(errors.Is(err, utils.ErrCosignSignatureInvalid)).To(gomega.BeTrue())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("unsigned.yaml.sig"))
+}
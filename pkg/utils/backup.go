@@ -0,0 +1,97 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// BackupLabel includes the labeled resource in the hub backups, the hub backup only picks the resources of
+	// the API groups it doesn't know of if they carry it
+	BackupLabel = "cluster.open-cluster-management.io/backup"
+	// BackupLabelValue is the value of the backup label of the subscription resources
+	BackupLabelValue = "application"
+	// ExcludeFromBackupLabel excludes the labeled resource from the Velero backups, it sits in the resources
+	// regenerated by the subscription controllers
+	ExcludeFromBackupLabel = "velero.io/exclude-from-backup"
+	// RestoreNameLabel is set by Velero in the restored resources, gives the name of the restore
+	RestoreNameLabel = "velero.io/restore-name"
+)
+
+// SetBackupLabel labels the resource to be included in the hub backups. It returns true if the labels changed.
+func SetBackupLabel(obj metav1.Object) bool {
+	return setBackupLabels(obj, BackupLabel, BackupLabelValue, ExcludeFromBackupLabel)
+}
+
+// SetExcludeFromBackupLabel labels the resource to be excluded from the backups, the resource is regenerated after
+// a restore. It returns true if the labels changed.
+func SetExcludeFromBackupLabel(obj metav1.Object) bool {
+	return setBackupLabels(obj, ExcludeFromBackupLabel, "true", BackupLabel)
+}
+
+func setBackupLabels(obj metav1.Object, key, value, conflictingKey string) bool {
+	labels := obj.GetLabels()
+
+	_, conflicting := labels[conflictingKey]
+	if labels[key] == value && !conflicting {
+		return false
+	}
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels[key] = value
+	delete(labels, conflictingKey)
+
+	obj.SetLabels(labels)
+
+	return true
+}
+
+// GetRestoreName returns the name of the Velero restore that restored the resource, empty if it was not restored
+func GetRestoreName(obj metav1.Object) string {
+	return obj.GetLabels()[RestoreNameLabel]
+}
+
+// AdoptOwnerReferences points the subscription owner references of the resource to the subscription. A restored
+// subscription has a new UID, its restored resources are garbage collected unless they are adopted. It returns true
+// if an owner reference changed.
+func AdoptOwnerReferences(obj metav1.Object, sub *appv1.Subscription) bool {
+	refs := obj.GetOwnerReferences()
+	adopted := false
+
+	for i := range refs {
+		if refs[i].Kind != "Subscription" || refs[i].Name != sub.GetName() || refs[i].UID == sub.GetUID() {
+			continue
+		}
+
+		if group, _ := ParseAPIVersion(refs[i].APIVersion); group != appv1.SchemeGroupVersion.Group {
+			continue
+		}
+
+		refs[i].UID = sub.GetUID()
+		adopted = true
+	}
+
+	if adopted {
+		obj.SetOwnerReferences(refs)
+	}
+
+	return adopted
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestBackupLabels(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Labels: map[string]string{"app": "web"}}}
+
+	g.Expect(SetExcludeFromBackupLabel(obj)).To(gomega.BeTrue())
+	g.Expect(SetExcludeFromBackupLabel(obj)).To(gomega.BeFalse())
+	g.Expect(obj.Labels).To(gomega.Equal(map[string]string{"app": "web", ExcludeFromBackupLabel: "true"}))
+
+	// a resource is either included in or excluded from the backups
+	g.Expect(SetBackupLabel(obj)).To(gomega.BeTrue())
+	g.Expect(obj.Labels).To(gomega.Equal(map[string]string{"app": "web", BackupLabel: BackupLabelValue}))
+
+	g.Expect(GetRestoreName(obj)).To(gomega.BeEmpty())
+
+	obj.Labels[RestoreNameLabel] = "restore-1"
+	g.Expect(GetRestoreName(obj)).To(gomega.Equal("restore-1"))
+}
+
+func TestAdoptOwnerReferences(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "new-uid"}}
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", OwnerReferences: []metav1.OwnerReference{
+		{APIVersion: "apps.open-cluster-management.io/v1", Kind: "Subscription", Name: "app", UID: "old-uid"},
+		{APIVersion: "apps.open-cluster-management.io/v1", Kind: "Subscription", Name: "other", UID: "other-uid"},
+		{APIVersion: "v1", Kind: "Subscription", Name: "app", UID: "core-uid"},
+	}}}
+
+	g.Expect(AdoptOwnerReferences(obj, sub)).To(gomega.BeTrue())
+	g.Expect(obj.OwnerReferences[0].UID).To(gomega.Equal(sub.UID))
+	g.Expect(obj.OwnerReferences[1].UID).To(gomega.BeEquivalentTo("other-uid"))
+	g.Expect(obj.OwnerReferences[2].UID).To(gomega.BeEquivalentTo("core-uid"))
+
+	g.Expect(AdoptOwnerReferences(obj, sub)).To(gomega.BeFalse())
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetAgentCacheOptions returns the cache options of the managed cluster agent. The agent only watches the
+// application-manager service account and its token secrets in the addon namespace, so the informers don't cache all
+// the service accounts and secrets of large managed clusters.
+func GetAgentCacheOptions() cache.Options {
+	addonNS := GetComponentNamespace()
+
+	return cache.Options{
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.ServiceAccount{}: {
+				Namespaces: map[string]cache.Config{addonNS: {}},
+				Field:      fields.OneTermEqualSelector("metadata.name", addonServiceAccountName),
+			},
+			&corev1.Secret{}: {
+				Namespaces: map[string]cache.Config{addonNS: {}},
+				Field:      fields.OneTermEqualSelector("type", string(corev1.SecretTypeServiceAccountToken)),
+			},
+		},
+	}
+}
+
+// GetCacheDisabledObjects returns the objects read directly from the API server instead of the cache. The agent doesn't
+// cache the channel and subscription ConfigMaps either, they are read a few times per subscription reconcile and
+// caching them means caching all the ConfigMaps of the managed cluster.
+func GetCacheDisabledObjects(agent bool) []client.Object {
+	objs := []client.Object{&corev1.Secret{}, &corev1.ServiceAccount{}}
+
+	if agent {
+		objs = append(objs, &corev1.ConfigMap{})
+	}
+
+	return objs
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+func TestGetAgentCacheOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	opts := GetAgentCacheOptions()
+	g.Expect(opts.ByObject).To(gomega.HaveLen(2))
+
+	for obj, byObject := range opts.ByObject {
+		g.Expect(byObject.Namespaces).To(gomega.HaveKey(GetComponentNamespace()))
+		g.Expect(byObject.Namespaces).To(gomega.HaveLen(1))
+
+		switch obj.(type) {
+		case *corev1.ServiceAccount:
+			g.Expect(byObject.Field.Matches(fields.Set{"metadata.name": "application-manager"})).To(gomega.BeTrue())
+			g.Expect(byObject.Field.Matches(fields.Set{"metadata.name": "default"})).To(gomega.BeFalse())
+		case *corev1.Secret:
+			g.Expect(byObject.Field.Matches(fields.Set{"type": "kubernetes.io/service-account-token"})).To(gomega.BeTrue())
+			g.Expect(byObject.Field.Matches(fields.Set{"type": "Opaque"})).To(gomega.BeFalse())
+		default:
+			t.Errorf("unexpected cached object %T", obj)
+		}
+	}
+}
+
+func TestGetCacheDisabledObjects(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(GetCacheDisabledObjects(false)).To(gomega.HaveLen(2))
+	g.Expect(GetCacheDisabledObjects(true)).To(gomega.ContainElement(&corev1.ConfigMap{}))
+}
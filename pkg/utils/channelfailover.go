@@ -0,0 +1,122 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+)
+
+// ChannelFailbackInterval is how long a channel that failed to connect is tried after the other channels of the
+// subscription. The subscription fails back to the channel once the interval has elapsed.
+const ChannelFailbackInterval = 5 * time.Minute
+
+// channelHealth tracks the last connection failure of the channels, keyed by their URL
+type channelHealth struct {
+	mu       sync.Mutex
+	failures map[string]time.Time
+	now      func() time.Time
+}
+
+var gitChannelHealth = newChannelHealth()
+
+func newChannelHealth() *channelHealth {
+	return &channelHealth{
+		failures: map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+// order returns the healthy connections in their order, then the connections that failed within the failback
+// interval in their order
+func (h *channelHealth) order(connections []*ChannelConnectionCfg) []*ChannelConnectionCfg {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	healthy := []*ChannelConnectionCfg{}
+	failed := []*ChannelConnectionCfg{}
+
+	for _, conn := range connections {
+		if failedAt, ok := h.failures[conn.RepoURL]; ok && h.now().Sub(failedAt) < ChannelFailbackInterval {
+			failed = append(failed, conn)
+
+			continue
+		}
+
+		healthy = append(healthy, conn)
+	}
+
+	return append(healthy, failed...)
+}
+
+func (h *channelHealth) recordFailure(url string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures[url] = h.now()
+}
+
+func (h *channelHealth) recordSuccess(url string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.failures[url]; ok {
+		klog.Infof("channel %v is connected again", RedactString(url))
+	}
+
+	delete(h.failures, url)
+}
+
+// connectionOptions returns the connections of the primary, secondary and fallback channels in order
+func (cloneOptions *GitCloneOption) connectionOptions() []*ChannelConnectionCfg {
+	connections := []*ChannelConnectionCfg{}
+
+	for _, conn := range append([]*ChannelConnectionCfg{cloneOptions.PrimaryConnectionOption,
+		cloneOptions.SecondaryConnectionOption}, cloneOptions.FallbackConnectionOptions...) {
+		if conn != nil {
+			connections = append(connections, conn)
+		}
+	}
+
+	return connections
+}
+
+// servingChannels holds the channel serving each subscription, "<channel namespace>/<channel name>"
+var servingChannels sync.Map
+
+// SetServingChannel records the channel the subscription resources were last deployed from
+func SetServingChannel(subKey types.NamespacedName, channel string) {
+	if previous, ok := servingChannels.Swap(subKey, channel); ok && previous != channel {
+		klog.Warningf("subscription %v is now served by channel %v instead of channel %v", subKey.String(), channel, previous)
+	}
+}
+
+// GetServingChannel returns the channel the subscription resources were last deployed from, empty if unknown
+func GetServingChannel(subKey types.NamespacedName) string {
+	channel, ok := servingChannels.Load(subKey)
+	if !ok {
+		return ""
+	}
+
+	return channel.(string)
+}
+
+// DeleteServingChannel forgets the channel serving the subscription
+func DeleteServingChannel(subKey types.NamespacedName) {
+	servingChannels.Delete(subKey)
+}
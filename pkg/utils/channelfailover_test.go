@@ -0,0 +1,74 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestChannelHealthOrder(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	h := newChannelHealth()
+	h.now = func() time.Time { return now }
+
+	primary := &ChannelConnectionCfg{RepoURL: "https://github.com/org/repo.git"}
+	secondary := &ChannelConnectionCfg{RepoURL: "https://mirror-eu.example.com/org/repo.git"}
+	fallback := &ChannelConnectionCfg{RepoURL: "https://mirror-us.example.com/org/repo.git"}
+
+	cloneOptions := &GitCloneOption{
+		PrimaryConnectionOption:   primary,
+		FallbackConnectionOptions: []*ChannelConnectionCfg{secondary, fallback},
+	}
+
+	connections := cloneOptions.connectionOptions()
+	g.Expect(connections).To(gomega.Equal([]*ChannelConnectionCfg{primary, secondary, fallback}))
+	g.Expect(h.order(connections)).To(gomega.Equal([]*ChannelConnectionCfg{primary, secondary, fallback}))
+
+	// the channels that failed are tried last, in their order
+	h.recordFailure(primary.RepoURL)
+	h.recordFailure(secondary.RepoURL)
+	g.Expect(h.order(connections)).To(gomega.Equal([]*ChannelConnectionCfg{fallback, primary, secondary}))
+
+	// failback once the channel is connected again
+	h.recordSuccess(secondary.RepoURL)
+	g.Expect(h.order(connections)).To(gomega.Equal([]*ChannelConnectionCfg{secondary, fallback, primary}))
+
+	// failback once the failback interval has elapsed
+	now = now.Add(ChannelFailbackInterval)
+	g.Expect(h.order(connections)).To(gomega.Equal([]*ChannelConnectionCfg{primary, secondary, fallback}))
+}
+
+func TestServingChannel(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	key := types.NamespacedName{Namespace: "ns", Name: "git-sub"}
+
+	g.Expect(GetServingChannel(key)).To(gomega.BeEmpty())
+
+	SetServingChannel(key, "ns/primary")
+	g.Expect(GetServingChannel(key)).To(gomega.Equal("ns/primary"))
+
+	SetServingChannel(key, "ns/mirror")
+	g.Expect(GetServingChannel(key)).To(gomega.Equal("ns/mirror"))
+
+	DeleteServingChannel(key)
+	g.Expect(GetServingChannel(key)).To(gomega.BeEmpty())
+}
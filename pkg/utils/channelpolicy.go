@@ -0,0 +1,168 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+// CheckChannelPolicies returns an error if the channel is not allowed for the subscriptions in the namespace
+// by the AllowedSubscriptionChannels policies.
+func CheckChannelPolicies(clt client.Client, namespace string, chn *chnv1.Channel) error {
+	policies := &appsubReportV1alpha1.AllowedSubscriptionChannelsList{}
+	if err := clt.List(context.TODO(), policies); err != nil {
+		// the policy CRD is not installed
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to list the subscription channel policies, err: %w", err)
+	}
+
+	if len(policies.Items) == 0 {
+		return nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := clt.Get(context.TODO(), types.NamespacedName{Name: namespace}, ns); err != nil {
+		return fmt.Errorf("failed to get namespace %v, err: %w", namespace, err)
+	}
+
+	return IsChannelAllowed(policies.Items, ns.GetLabels(), chn)
+}
+
+// CheckSubscriptionChannelPolicies returns an error if the primary, secondary or any fallback channel of the
+// subscription is not allowed in the subscription namespace. The channels not found are skipped, the missing channels
+// are reported by the hub controller.
+func CheckSubscriptionChannelPolicies(clt client.Client, sub *appv1.Subscription) error {
+	chnNames := append([]string{sub.Spec.Channel, sub.Spec.SecondaryChannel}, sub.Spec.FallbackChannels...)
+
+	for _, chnNsName := range chnNames {
+		if chnNsName == "" {
+			continue
+		}
+
+		chnNs, chnName := ParseNamespacedName(chnNsName)
+		if chnName == "" {
+			continue
+		}
+
+		chn := &chnv1.Channel{}
+		if err := clt.Get(context.TODO(), types.NamespacedName{Namespace: chnNs, Name: chnName}, chn); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+
+			return fmt.Errorf("failed to get channel %v, err: %w", chnNsName, err)
+		}
+
+		if err := CheckChannelPolicies(clt, sub.Namespace, chn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsChannelAllowed returns an error if the channel matches a deny rule, or if it does not match any allow rule
+// of the policies selecting the namespace labels.
+func IsChannelAllowed(policies []appsubReportV1alpha1.AllowedSubscriptionChannels, nsLabels map[string]string, chn *chnv1.Channel) error {
+	allowRules := 0
+
+	allowed := false
+
+	for _, policy := range policies {
+		if policy.Spec.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+			if err != nil {
+				klog.Errorf("invalid namespace selector in subscription channel policy %v, err: %v", policy.Name, err)
+
+				continue
+			}
+
+			if !selector.Matches(labels.Set(nsLabels)) {
+				continue
+			}
+		}
+
+		for _, rule := range policy.Spec.Deny {
+			if isChannelRuleMatched(rule, chn) {
+				return fmt.Errorf("channel %v/%v is denied by the subscription channel policy %v", chn.Namespace, chn.Name, policy.Name)
+			}
+		}
+
+		for _, rule := range policy.Spec.Allow {
+			allowRules++
+
+			if isChannelRuleMatched(rule, chn) {
+				allowed = true
+			}
+		}
+	}
+
+	if allowRules > 0 && !allowed {
+		return fmt.Errorf("channel %v/%v is not allowed by the subscription channel policies", chn.Namespace, chn.Name)
+	}
+
+	return nil
+}
+
+func isChannelRuleMatched(rule appsubReportV1alpha1.ChannelRule, chn *chnv1.Channel) bool {
+	if rule.Type != "" && !isSameChannelType(rule.Type, string(chn.Spec.Type)) {
+		return false
+	}
+
+	if rule.Pathname == "" {
+		return true
+	}
+
+	matched, err := path.Match(strings.TrimSuffix(rule.Pathname, "/"), strings.TrimSuffix(chn.Spec.Pathname, "/"))
+	if err != nil {
+		klog.Errorf("invalid pathname pattern %v in subscription channel policy, err: %v", rule.Pathname, err)
+
+		return false
+	}
+
+	return matched
+}
+
+// isSameChannelType treats the github channel type as the git channel type
+func isSameChannelType(a, b string) bool {
+	normalize := func(t string) string {
+		if strings.EqualFold(t, chnv1.ChannelTypeGitHub) {
+			return chnv1.ChannelTypeGit
+		}
+
+		return strings.ToLower(t)
+	}
+
+	return normalize(a) == normalize(b)
+}
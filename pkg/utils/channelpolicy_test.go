@@ -0,0 +1,131 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func TestIsChannelAllowed(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	gitChn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "ch"},
+		Spec:       chnv1.ChannelSpec{Type: chnv1.ChannelTypeGitHub, Pathname: "https://github.com/my-org/app.git"},
+	}
+
+	helmChn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Name: "helm", Namespace: "ch"},
+		Spec:       chnv1.ChannelSpec{Type: chnv1.ChannelTypeHelmRepo, Pathname: "https://charts.example.com/"},
+	}
+
+	tenantLabels := map[string]string{"tenant": "team-a"}
+
+	// no policy
+	g.Expect(IsChannelAllowed(nil, tenantLabels, gitChn)).To(gomega.Succeed())
+
+	policies := []appsubReportV1alpha1.AllowedSubscriptionChannels{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: appsubReportV1alpha1.AllowedSubscriptionChannelsSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "team-a"}},
+				Allow: []appsubReportV1alpha1.ChannelRule{
+					{Type: chnv1.ChannelTypeGit, Pathname: "https://github.com/my-org/*"},
+				},
+			},
+		},
+	}
+
+	// the git org is allowed, the github channel type is matched as git
+	g.Expect(IsChannelAllowed(policies, tenantLabels, gitChn)).To(gomega.Succeed())
+
+	// the helm channel does not match any allow rule
+	g.Expect(IsChannelAllowed(policies, tenantLabels, helmChn)).NotTo(gomega.Succeed())
+
+	// the policy does not select other namespaces
+	g.Expect(IsChannelAllowed(policies, map[string]string{"tenant": "team-b"}, helmChn)).To(gomega.Succeed())
+
+	// deny rules take precedence over allow rules
+	policies = append(policies, appsubReportV1alpha1.AllowedSubscriptionChannels{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-app"},
+		Spec: appsubReportV1alpha1.AllowedSubscriptionChannelsSpec{
+			Deny: []appsubReportV1alpha1.ChannelRule{{Pathname: "https://github.com/*/app.git"}},
+		},
+	})
+
+	g.Expect(IsChannelAllowed(policies, tenantLabels, gitChn)).NotTo(gomega.Succeed())
+}
+
+func TestCheckSubscriptionChannelPolicies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(s)).To(gomega.Succeed())
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app-ns", Labels: map[string]string{"tenant": "team-a"}}}
+
+	gitChn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Name: "git", Namespace: "ch"},
+		Spec:       chnv1.ChannelSpec{Type: chnv1.ChannelTypeGitHub, Pathname: "https://github.com/my-org/app.git"},
+	}
+
+	helmChn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Name: "helm", Namespace: "ch"},
+		Spec:       chnv1.ChannelSpec{Type: chnv1.ChannelTypeHelmRepo, Pathname: "https://charts.example.com/"},
+	}
+
+	policy := &appsubReportV1alpha1.AllowedSubscriptionChannels{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec: appsubReportV1alpha1.AllowedSubscriptionChannelsSpec{
+			Allow: []appsubReportV1alpha1.ChannelRule{{Type: chnv1.ChannelTypeGit, Pathname: "https://github.com/my-org/*"}},
+		},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(s).WithObjects(ns, gitChn, helmChn, policy).Build()
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app-ns"},
+		Spec:       appv1.SubscriptionSpec{Channel: "ch/git"},
+	}
+
+	g.Expect(CheckSubscriptionChannelPolicies(clt, sub)).To(gomega.Succeed())
+
+	// the channels not found are skipped
+	sub.Spec.FallbackChannels = []string{"ch/missing"}
+	g.Expect(CheckSubscriptionChannelPolicies(clt, sub)).To(gomega.Succeed())
+
+	// a secondary channel not allowed is denied
+	secondary := sub.DeepCopy()
+	secondary.Spec.SecondaryChannel = "ch/helm"
+	g.Expect(CheckSubscriptionChannelPolicies(clt, secondary)).NotTo(gomega.Succeed())
+
+	// a fallback channel not allowed is denied
+	fallback := sub.DeepCopy()
+	fallback.Spec.FallbackChannels = append(fallback.Spec.FallbackChannels, "ch/helm")
+	g.Expect(CheckSubscriptionChannelPolicies(clt, fallback)).NotTo(gomega.Succeed())
+}
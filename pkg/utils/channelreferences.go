@@ -0,0 +1,368 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"sort"
+	"strings"
+
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/secretprovider"
+)
+
+// SecretProviderResource is the resource of the apps.open-cluster-management.io group checked with the use verb before
+// a subscription resolves a channel secret from an external secret manager. The resource name is the provider and the
+// reference of the secret, like vault:secret/data/git/github.
+const SecretProviderResource = "secretproviders"
+
+// GetChannelSecretKey returns the key of the channel secret, the secret is in the channel namespace unless the
+// secret reference has a namespace
+func GetChannelSecretKey(chn *chnv1.Channel) types.NamespacedName {
+	key := types.NamespacedName{Name: chn.Spec.SecretRef.Name, Namespace: chn.Namespace}
+
+	if chn.Spec.SecretRef.Namespace != "" {
+		key.Namespace = chn.Spec.SecretRef.Namespace
+	}
+
+	return key
+}
+
+// GetChannelConfigMapKey returns the key of the channel configmap, the configmap is in the channel namespace unless
+// the configmap reference has a namespace
+func GetChannelConfigMapKey(chn *chnv1.Channel) types.NamespacedName {
+	key := types.NamespacedName{Name: chn.Spec.ConfigMapRef.Name, Namespace: chn.Namespace}
+
+	if chn.Spec.ConfigMapRef.Namespace != "" {
+		key.Namespace = chn.Spec.ConfigMapRef.Namespace
+	}
+
+	return key
+}
+
+// HasChannelSecret returns true if the channel has a secret reference or an external secret manager
+func HasChannelSecret(chn *chnv1.Channel) bool {
+	return chn.Spec.SecretRef != nil || chn.GetAnnotations()[appv1.AnnotationSecretProvider] != ""
+}
+
+// ResolveChannelSecret returns the secret of the channel, nil if the channel has no secret. The secret of a channel
+// with an external secret manager is resolved from the secret manager, it is never stored in the clusters. The
+// secret reference of the channel is ignored then.
+func ResolveChannelSecret(clt client.Client, chn *chnv1.Channel) (*corev1.Secret, error) {
+	ref, err := secretprovider.GetReference(chn.GetAnnotations())
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret provider of channel %v/%v, err: %w", chn.Namespace, chn.Name, err)
+	}
+
+	if ref != nil {
+		data, err := secretprovider.Resolve(context.TODO(), ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the secret %v of channel %v/%v, err: %w", ref.String(),
+				chn.Namespace, chn.Name, err)
+		}
+
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        chn.Name,
+				Namespace:   chn.Namespace,
+				Annotations: map[string]string{appv1.AnnotationSecretProvider: ref.Provider},
+			},
+			Data: data,
+		}, nil
+	}
+
+	if chn.Spec.SecretRef == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := clt.Get(context.TODO(), GetChannelSecretKey(chn), secret); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// IsExternalChannelSecret returns true if the channel secret is resolved from an external secret manager
+func IsExternalChannelSecret(obj metav1.Object) bool {
+	return obj.GetAnnotations()[appv1.AnnotationSecretProvider] != ""
+}
+
+// IsCrossNamespaceChannelSecret returns true if the channel references a secret in another namespace
+func IsCrossNamespaceChannelSecret(chn *chnv1.Channel) bool {
+	return chn.Spec.SecretRef != nil && GetChannelSecretKey(chn).Namespace != chn.Namespace
+}
+
+// IsCrossNamespaceChannelConfigMap returns true if the channel references a configmap in another namespace
+func IsCrossNamespaceChannelConfigMap(chn *chnv1.Channel) bool {
+	return chn.Spec.ConfigMapRef != nil && GetChannelConfigMapKey(chn).Namespace != chn.Namespace
+}
+
+// CheckChannelReferencesAccess checks the creator of the subscription can get the channel secret and configmap
+// referenced in another namespace, with a SubjectAccessReview. The shared credentials and connection configurations
+// of a central namespace are only used by the subscriptions of the users allowed to read them. The creator identity
+// is only trusted if it was signed by the subscription mutating webhook. The references in the channel namespace are
+// not checked. The secret of an external secret manager is resolved with the credentials of the application manager,
+// the creator must be allowed to use it in the channel namespace, see SecretProviderResource.
+func CheckChannelReferencesAccess(ctx context.Context, clt client.Client, sub *appv1.Subscription, chn *chnv1.Channel) error {
+	refs := []channelReference{}
+
+	if IsExternalChannelSecret(chn) {
+		ref, err := secretprovider.GetReference(chn.GetAnnotations())
+		if err != nil {
+			return fmt.Errorf("invalid secret provider of channel %v/%v, err: %w", chn.Namespace, chn.Name, err)
+		}
+
+		refs = append(refs, channelReference{Kind: "secret provider reference", Group: appv1.SchemeGroupVersion.Group,
+			Resource: SecretProviderResource, Verb: "use", Key: types.NamespacedName{Namespace: chn.Namespace, Name: ref.String()}})
+	}
+
+	if IsCrossNamespaceChannelSecret(chn) {
+		refs = append(refs, channelReference{Kind: SecretKindStr, Resource: "secrets", Verb: "get", Key: GetChannelSecretKey(chn)})
+	}
+
+	if IsCrossNamespaceChannelConfigMap(chn) {
+		refs = append(refs, channelReference{Kind: ConfigMapKindStr, Resource: "configmaps", Verb: "get",
+			Key: GetChannelConfigMapKey(chn)})
+	}
+
+	if len(refs) == 0 {
+		return nil
+	}
+
+	annotations := sub.GetAnnotations()
+
+	user := Base64StringDecode(strings.Trim(annotations[appv1.AnnotationUserIdentity], ""))
+	if user == "" {
+		return fmt.Errorf("channel %v/%v references the %v %v, the subscription has no creator identity to check the access to it",
+			chn.Namespace, chn.Name, refs[0].Kind, refs[0].Key.String())
+	}
+
+	// the identity annotations set by the users while the webhook was not available can't be trusted
+	if !IsUserIdentitySigned(sub) {
+		return fmt.Errorf("channel %v/%v references the %v %v, the creator identity of the subscription was not set by the subscription mutating webhook",
+			chn.Namespace, chn.Name, refs[0].Kind, refs[0].Key.String())
+	}
+
+	groups := []string{}
+
+	if userGroups := Base64StringDecode(strings.Trim(annotations[appv1.AnnotationUserGroup], "")); userGroups != "" {
+		groups = strings.Split(userGroups, ",")
+	}
+
+	for _, ref := range refs {
+		sar := &authv1.SubjectAccessReview{
+			Spec: authv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &authv1.ResourceAttributes{
+					Namespace: ref.Key.Namespace,
+					Name:      ref.Key.Name,
+					Verb:      ref.Verb,
+					Group:     ref.Group,
+					Resource:  ref.Resource,
+				},
+				User:   user,
+				Groups: groups,
+			},
+		}
+
+		if err := clt.Create(ctx, sar); err != nil {
+			return fmt.Errorf("failed to check the access of user %v to the channel %v %v, err: %w", user, ref.Kind,
+				ref.Key.String(), err)
+		}
+
+		if !sar.Status.Allowed {
+			return fmt.Errorf("user %v is not allowed to %v the %v %v referenced by channel %v/%v",
+				user, ref.Verb, ref.Kind, ref.Key.String(), chn.Namespace, chn.Name)
+		}
+	}
+
+	return nil
+}
+
+// CheckSubscriptionChannelReferencesAccess runs CheckChannelReferencesAccess for the primary, secondary and fallback
+// channels of the subscription. The channels not found are skipped, the missing channels are reported by the hub
+// controller.
+func CheckSubscriptionChannelReferencesAccess(ctx context.Context, clt client.Client, sub *appv1.Subscription) error {
+	chnNames := append([]string{sub.Spec.Channel, sub.Spec.SecondaryChannel}, sub.Spec.FallbackChannels...)
+
+	for _, chnNsName := range chnNames {
+		if chnNsName == "" {
+			continue
+		}
+
+		chnNs, chnName := ParseNamespacedName(chnNsName)
+		if chnName == "" {
+			continue
+		}
+
+		chn := &chnv1.Channel{}
+		if err := clt.Get(ctx, types.NamespacedName{Namespace: chnNs, Name: chnName}, chn); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+
+			return fmt.Errorf("failed to get channel %v, err: %w", chnNsName, err)
+		}
+
+		if err := CheckChannelReferencesAccess(ctx, clt, sub, chn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// channelReference is a channel secret, configmap or secret provider reference whose access is checked
+type channelReference struct {
+	Kind     string
+	Group    string
+	Resource string
+	Verb     string
+	Key      types.NamespacedName
+}
+
+// IsChannelReference returns true if the channel references the Secret or the ConfigMap of the given kind
+func IsChannelReference(chn *chnv1.Channel, kind, namespace, name string) bool {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+
+	switch kind {
+	case SecretKindStr:
+		return chn.Spec.SecretRef != nil && GetChannelSecretKey(chn) == key
+	case ConfigMapKindStr:
+		return chn.Spec.ConfigMapRef != nil && GetChannelConfigMapKey(chn) == key
+	}
+
+	return false
+}
+
+// GetChannelReferencesVersion returns a hash of the content of the secrets and the configmaps referenced by the
+// channels of the subscriber item, empty if the channels have no reference. The hash only changes when the
+// credentials or the connection configuration change, a secret recreated with the same name and a new content changes
+// it too.
+func GetChannelReferencesVersion(item *appv1.SubscriberItem) string {
+	secrets := []*corev1.Secret{item.ChannelSecret, item.SecondaryChannelSecret}
+	configMaps := []*corev1.ConfigMap{item.ChannelConfigMap, item.SecondaryChannelConfigMap}
+
+	for _, fallback := range item.FallbackChannels {
+		secrets = append(secrets, fallback.Secret)
+		configMaps = append(configMaps, fallback.ConfigMap)
+	}
+
+	h := sha256.New()
+	found := false
+
+	for _, secret := range secrets {
+		if secret == nil {
+			continue
+		}
+
+		found = true
+
+		h.Write([]byte(SecretKindStr + "/" + secret.Name + "\n"))
+		hashData(h, secret.Data)
+	}
+
+	for _, configMap := range configMaps {
+		if configMap == nil {
+			continue
+		}
+
+		found = true
+
+		data := map[string][]byte{}
+		for k, v := range configMap.Data {
+			data[k] = []byte(v)
+		}
+
+		for k, v := range configMap.BinaryData {
+			data[k] = v
+		}
+
+		h.Write([]byte(ConfigMapKindStr + "/" + configMap.Name + "\n"))
+		hashData(h, data)
+	}
+
+	if !found {
+		return ""
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func hashData(h hash.Hash, data map[string][]byte) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		h.Write([]byte(k + "="))
+		h.Write(data[k])
+		h.Write([]byte("\n"))
+	}
+}
+
+// ListChannelReferenceSubscriptions returns the subscriptions with a primary, secondary or fallback channel
+// referencing the Secret or the ConfigMap of the given kind
+func ListChannelReferenceSubscriptions(ctx context.Context, clt client.Client, kind, namespace,
+	name string) ([]types.NamespacedName, error) {
+	chnList := &chnv1.ChannelList{}
+	if err := clt.List(ctx, chnList); err != nil {
+		return nil, err
+	}
+
+	channels := map[string]bool{}
+
+	for i := range chnList.Items {
+		if IsChannelReference(&chnList.Items[i], kind, namespace, name) {
+			channels[chnList.Items[i].Namespace+"/"+chnList.Items[i].Name] = true
+		}
+	}
+
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	subList := &appv1.SubscriptionList{}
+	if err := clt.List(ctx, subList); err != nil {
+		return nil, err
+	}
+
+	subs := []types.NamespacedName{}
+
+	for _, sub := range subList.Items {
+		if channels[sub.Spec.Channel] || channels[sub.Spec.SecondaryChannel] ||
+			slices.ContainsFunc(sub.Spec.FallbackChannels, func(chn string) bool { return channels[chn] }) {
+			subs = append(subs, types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name})
+		}
+	}
+
+	return subs, nil
+}
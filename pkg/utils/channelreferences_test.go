@@ -0,0 +1,327 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+	"testing"
+
+	"github.com/onsi/gomega"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/secretprovider"
+)
+
+func TestIsChannelReference(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	chn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "git"},
+		Spec: chnv1.ChannelSpec{
+			SecretRef:    &corev1.ObjectReference{Name: "git-creds"},
+			ConfigMapRef: &corev1.ObjectReference{Name: "git-ca", Namespace: "ns-cm"},
+		},
+	}
+
+	g.Expect(IsChannelReference(chn, SecretKindStr, "ns-ch", "git-creds")).To(gomega.BeTrue())
+	g.Expect(IsChannelReference(chn, SecretKindStr, "default", "git-creds")).To(gomega.BeFalse())
+	g.Expect(IsChannelReference(chn, ConfigMapKindStr, "ns-cm", "git-ca")).To(gomega.BeTrue())
+	g.Expect(IsChannelReference(chn, ConfigMapKindStr, "ns-ch", "git-ca")).To(gomega.BeFalse())
+	g.Expect(IsChannelReference(chn, ConfigMapKindStr, "ns-ch", "git-creds")).To(gomega.BeFalse())
+}
+
+func TestGetChannelReferencesVersion(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	item := &appv1.SubscriberItem{}
+	g.Expect(GetChannelReferencesVersion(item)).To(gomega.BeEmpty())
+
+	item.ChannelSecret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "git-creds", UID: "1", ResourceVersion: "10"},
+		Data:       map[string][]byte{"user": []byte("admin"), "accessToken": []byte("token")},
+	}
+	item.FallbackChannels = []appv1.SubscriberChannel{{
+		ConfigMap: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "mirror-ca"},
+			Data:       map[string]string{"caCerts": "cert"},
+		},
+	}}
+
+	version := GetChannelReferencesVersion(item)
+	g.Expect(version).NotTo(gomega.BeEmpty())
+
+	// the version doesn't change with the metadata, like a secret copied to the subscription namespace or recreated
+	// with the same content
+	item.ChannelSecret.Namespace = "default"
+	item.ChannelSecret.UID = "2"
+	item.ChannelSecret.ResourceVersion = ""
+	g.Expect(GetChannelReferencesVersion(item)).To(gomega.Equal(version))
+
+	item.ChannelSecret.Data["accessToken"] = []byte("rotated")
+	g.Expect(GetChannelReferencesVersion(item)).NotTo(gomega.Equal(version))
+
+	item.ChannelSecret.Data["accessToken"] = []byte("token")
+	item.FallbackChannels[0].ConfigMap.Data["caCerts"] = "new cert"
+	g.Expect(GetChannelReferencesVersion(item)).NotTo(gomega.Equal(version))
+}
+
+func TestListChannelReferenceSubscriptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(s)).To(gomega.Succeed())
+
+	newSub := func(name string, channels ...string) *appv1.Subscription {
+		sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+		sub.Spec.Channel = channels[0]
+
+		if len(channels) > 1 {
+			sub.Spec.SecondaryChannel = channels[1]
+		}
+
+		if len(channels) > 2 {
+			sub.Spec.FallbackChannels = channels[2:]
+		}
+
+		return sub
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&chnv1.Channel{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "github"},
+			Spec:       chnv1.ChannelSpec{SecretRef: &corev1.ObjectReference{Name: "git-creds"}},
+		},
+		&chnv1.Channel{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "mirror"},
+			Spec:       chnv1.ChannelSpec{SecretRef: &corev1.ObjectReference{Name: "git-creds"}},
+		},
+		&chnv1.Channel{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "other"},
+			Spec:       chnv1.ChannelSpec{SecretRef: &corev1.ObjectReference{Name: "other-creds"}},
+		},
+		newSub("primary", "ns-ch/github"),
+		newSub("secondary", "ns-ch/other", "ns-ch/mirror"),
+		newSub("fallback", "ns-ch/other", "", "ns-ch/mirror"),
+		newSub("unrelated", "ns-ch/other"),
+	).Build()
+
+	subs, err := ListChannelReferenceSubscriptions(context.TODO(), clt, SecretKindStr, "ns-ch", "git-creds")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(subs).To(gomega.ConsistOf(
+		types.NamespacedName{Namespace: "default", Name: "primary"},
+		types.NamespacedName{Namespace: "default", Name: "secondary"},
+		types.NamespacedName{Namespace: "default", Name: "fallback"},
+	))
+
+	subs, err = ListChannelReferenceSubscriptions(context.TODO(), clt, ConfigMapKindStr, "ns-ch", "git-creds")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(subs).To(gomega.BeEmpty())
+}
+
+func TestCheckChannelReferencesAccess(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	SetUserIdentityKey([]byte("identity-key"))
+	defer SetUserIdentityKey(nil)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+
+	reviews := []authv1.SubjectAccessReviewSpec{}
+	clt := fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, clt client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			sar, ok := obj.(*authv1.SubjectAccessReview)
+			if !ok {
+				return clt.Create(ctx, obj, opts...)
+			}
+
+			reviews = append(reviews, sar.Spec)
+			sar.Status.Allowed = sar.Spec.User == "alice" || slices.Contains(sar.Spec.Groups, "platform-team")
+
+			return nil
+		},
+	}).Build()
+
+	chn := &chnv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "git"},
+		Spec:       chnv1.ChannelSpec{SecretRef: &corev1.ObjectReference{Name: "git-creds"}},
+	}
+
+	newSub := func(user, groups string) *appv1.Subscription {
+		annotations := map[string]string{}
+
+		if user != "" {
+			annotations[appv1.AnnotationUserIdentity] = base64.StdEncoding.EncodeToString([]byte(user))
+		}
+
+		if groups != "" {
+			annotations[appv1.AnnotationUserGroup] = base64.StdEncoding.EncodeToString([]byte(groups))
+		}
+
+		sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sub", Annotations: annotations}}
+		SignUserIdentity(sub)
+
+		return sub
+	}
+
+	// the secrets of the channel namespace are not checked
+	g.Expect(CheckChannelReferencesAccess(context.TODO(), clt, newSub("bob", ""), chn)).To(gomega.Succeed())
+	g.Expect(reviews).To(gomega.BeEmpty())
+
+	chn.Spec.SecretRef.Namespace = "shared-creds"
+
+	g.Expect(CheckChannelReferencesAccess(context.TODO(), clt, newSub("alice", ""), chn)).To(gomega.Succeed())
+	g.Expect(reviews).To(gomega.HaveLen(1))
+	g.Expect(*reviews[0].ResourceAttributes).To(gomega.Equal(authv1.ResourceAttributes{
+		Namespace: "shared-creds", Name: "git-creds", Verb: "get", Resource: "secrets",
+	}))
+
+	g.Expect(CheckChannelReferencesAccess(context.TODO(), clt, newSub("bob", "dev-team,platform-team"), chn)).To(gomega.Succeed())
+	g.Expect(reviews[1].Groups).To(gomega.Equal([]string{"dev-team", "platform-team"}))
+
+	err := CheckChannelReferencesAccess(context.TODO(), clt, newSub("bob", "dev-team"), chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("not allowed"))
+
+	// the subscriptions without creator identity can't use the secrets of another namespace
+	g.Expect(CheckChannelReferencesAccess(context.TODO(), clt, newSub("", ""), chn)).NotTo(gomega.Succeed())
+	g.Expect(reviews).To(gomega.HaveLen(3))
+
+	// the identity not signed by the webhook is not trusted
+	forged := newSub("bob", "dev-team")
+	forged.Annotations[appv1.AnnotationUserGroup] = base64.StdEncoding.EncodeToString([]byte("platform-team"))
+
+	err = CheckChannelReferencesAccess(context.TODO(), clt, forged, chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("mutating webhook"))
+	g.Expect(reviews).To(gomega.HaveLen(3))
+
+	// the configmaps of another namespace are checked too
+	chn.Spec.SecretRef = nil
+	chn.Spec.ConfigMapRef = &corev1.ObjectReference{Name: "git-config", Namespace: "shared-config"}
+
+	err = CheckChannelReferencesAccess(context.TODO(), clt, newSub("bob", "dev-team"), chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(*reviews[3].ResourceAttributes).To(gomega.Equal(authv1.ResourceAttributes{
+		Namespace: "shared-config", Name: "git-config", Verb: "get", Resource: "configmaps",
+	}))
+
+	// the secret provider references are checked in the channel namespace too
+	secretprovider.Register("static", staticSecretProvider{})
+
+	chn.Spec.ConfigMapRef = nil
+	chn.Annotations = map[string]string{
+		appv1.AnnotationSecretProvider:    "static",
+		appv1.AnnotationSecretProviderRef: "git",
+	}
+
+	g.Expect(CheckChannelReferencesAccess(context.TODO(), clt, newSub("alice", ""), chn)).To(gomega.Succeed())
+	g.Expect(*reviews[4].ResourceAttributes).To(gomega.Equal(authv1.ResourceAttributes{
+		Namespace: "ns-ch", Name: "static:git", Verb: "use", Group: "apps.open-cluster-management.io",
+		Resource: SecretProviderResource,
+	}))
+
+	err = CheckChannelReferencesAccess(context.TODO(), clt, newSub("bob", "dev-team"), chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("not allowed to use"))
+
+	err = CheckChannelReferencesAccess(context.TODO(), clt, forged, chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("mutating webhook"))
+	g.Expect(reviews).To(gomega.HaveLen(6))
+}
+
+type staticSecretProvider map[string][]byte
+
+func (p staticSecretProvider) GetSecret(_ context.Context, _ string) (map[string][]byte, error) {
+	return p, nil
+}
+
+func TestResolveChannelSecret(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	secretprovider.Register("static", staticSecretProvider{"accessToken": []byte("external-token")})
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(s)).To(gomega.Succeed())
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "git-sub", UID: "sub-uid"}}
+	staleCopy := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "git-creds",
+			Labels: map[string]string{SercertReferredMarker + sub.Name: "true"}},
+		Data: map[string][]byte{"accessToken": []byte("static-token")},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "git-creds"},
+			Data:       map[string][]byte{"accessToken": []byte("static-token")},
+		},
+		staleCopy,
+	).Build()
+
+	chn := &chnv1.Channel{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "github"}}
+	g.Expect(HasChannelSecret(chn)).To(gomega.BeFalse())
+
+	secret, err := ResolveChannelSecret(clt, chn)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(secret).To(gomega.BeNil())
+
+	chn.Spec.SecretRef = &corev1.ObjectReference{Name: "git-creds"}
+
+	secret, err = ResolveChannelSecret(clt, chn)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(secret.Data["accessToken"])).To(gomega.Equal("static-token"))
+	g.Expect(IsExternalChannelSecret(secret)).To(gomega.BeFalse())
+
+	// the secret provider overrides the secret reference
+	chn.Annotations = map[string]string{
+		appv1.AnnotationSecretProvider:    "static",
+		appv1.AnnotationSecretProviderRef: "git",
+	}
+
+	secret, err = ResolveChannelSecret(clt, chn)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(secret.Data["accessToken"])).To(gomega.Equal("external-token"))
+	g.Expect(IsExternalChannelSecret(secret)).To(gomega.BeTrue())
+
+	// the external secret is not copied to the subscription namespace, the previous copy is removed
+	gvk := corev1.SchemeGroupVersion.WithKind(SecretKindStr)
+	g.Expect(ListAndDeployReferredObject(clt, sub, gvk, secret)).To(gomega.Succeed())
+
+	secrets := &corev1.SecretList{}
+	g.Expect(clt.List(context.TODO(), secrets, client.InNamespace("default"))).To(gomega.Succeed())
+	g.Expect(secrets.Items).To(gomega.BeEmpty())
+
+	chn.Annotations[appv1.AnnotationSecretProviderRef] = ""
+
+	_, err = ResolveChannelSecret(clt, chn)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
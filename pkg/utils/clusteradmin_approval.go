@@ -0,0 +1,195 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// clusterAdminApproverGroups are the user groups permitted to approve the cluster admin access of the subscriptions.
+// The approval is not required if there is no group.
+var clusterAdminApproverGroups []string
+
+// SetClusterAdminApproverGroups sets the user groups permitted to approve the cluster admin access of the subscriptions
+func SetClusterAdminApproverGroups(groups []string) {
+	approvers := []string{}
+
+	for _, group := range groups {
+		if group = strings.TrimSpace(group); group != "" {
+			approvers = append(approvers, group)
+		}
+	}
+
+	clusterAdminApproverGroups = approvers
+}
+
+// IsClusterAdminApprovalRequired returns true if the cluster admin access of the subscriptions must be approved
+func IsClusterAdminApprovalRequired() bool {
+	return len(clusterAdminApproverGroups) > 0
+}
+
+// IsClusterAdminApprover returns true if the user is in a cluster admin approver group
+func IsClusterAdminApprover(userInfo authenticationv1.UserInfo) bool {
+	for _, group := range userInfo.Groups {
+		for _, approverGroup := range clusterAdminApproverGroups {
+			if group == approverGroup {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ClusterAdminApprovalHash returns the hash of the content of a subscription approved by a cluster admin approval,
+// its spec and the annotations selecting what it deploys from the channel. The approval of a subscription whose
+// content changed since it was approved is pending again.
+func ClusterAdminApprovalHash(sub *appv1.Subscription) string {
+	annotations := sub.GetAnnotations()
+
+	content, err := json.Marshal(struct {
+		Spec       appv1.SubscriptionSpec `json:"spec"`
+		GitBranch  string                 `json:"gitBranch,omitempty"`
+		GitPath    string                 `json:"gitPath,omitempty"`
+		GitCommit  string                 `json:"gitCommit,omitempty"`
+		GitTag     string                 `json:"gitTag,omitempty"`
+		BucketPath string                 `json:"bucketPath,omitempty"`
+	}{
+		Spec:       sub.Spec,
+		GitBranch:  GetGitBranchAnnotation(annotations),
+		GitPath:    GetGitPathAnnotation(annotations),
+		GitCommit:  annotations[appv1.AnnotationGitTargetCommit],
+		GitTag:     annotations[appv1.AnnotationGitTag],
+		BucketPath: annotations[appv1.AnnotationBucketPath],
+	})
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// clusterAdminApprovalSignature returns the signature of the approval of the subscription content by the approver, with
+// the key of the user identity signatures. It is empty if there is no key, the approvals are not trusted without it.
+// The signature covers the subscription namespace and name, so it can't be copied to another subscription.
+func clusterAdminApprovalSignature(sub *appv1.Subscription, approver string) string {
+	if len(userIdentityKey) == 0 || approver == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, userIdentityKey)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", sub.GetNamespace(), sub.GetName(), approver, ClusterAdminApprovalHash(sub))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckClusterAdminApproval checks the change of the cluster admin approval of a subscription on admission, oldSub is
+// nil on create. If a user of a cluster admin approver group adds or changes the approval, or changes the approved
+// content of the subscription, the approval is set to the name of the user and to the signature of the subscription
+// content. An error is returned if a user who is not an approver adds or changes the approval. The content changes of
+// the other users leave the approval pending. It returns true if the annotations are changed.
+func CheckClusterAdminApproval(sub, oldSub *appv1.Subscription, userInfo authenticationv1.UserInfo) (bool, error) {
+	annotations := sub.GetAnnotations()
+
+	approver := annotations[appv1.AnnotationClusterAdminApprovedBy]
+	if approver == "" {
+		if annotations[appv1.AnnotationClusterAdminApprovedSpec] == "" {
+			return false, nil
+		}
+
+		delete(annotations, appv1.AnnotationClusterAdminApprovedSpec)
+		sub.SetAnnotations(annotations)
+
+		return true, nil
+	}
+
+	var oldAnnotations map[string]string
+	if oldSub != nil {
+		oldAnnotations = oldSub.GetAnnotations()
+	}
+
+	approvalChanged := approver != oldAnnotations[appv1.AnnotationClusterAdminApprovedBy] ||
+		annotations[appv1.AnnotationClusterAdminApprovedSpec] != oldAnnotations[appv1.AnnotationClusterAdminApprovedSpec]
+
+	if !IsClusterAdminApprover(userInfo) {
+		if approvalChanged {
+			return false, fmt.Errorf("user %v is not permitted to approve the cluster admin access of subscriptions, "+
+				"the %v annotation can only be set by the users of the groups %v", userInfo.Username,
+				appv1.AnnotationClusterAdminApprovedBy, clusterAdminApproverGroups)
+		}
+
+		return false, nil
+	}
+
+	if !approvalChanged && (annotations[appv1.AnnotationClusterAdminApprovedSpec] ==
+		clusterAdminApprovalSignature(sub, approver) || ClusterAdminApprovalHash(oldSub) == ClusterAdminApprovalHash(sub)) {
+		// the approved content or the changes of the other users pending approval are not changed by the approver
+		return false, nil
+	}
+
+	annotations[appv1.AnnotationClusterAdminApprovedBy] = userInfo.Username
+	annotations[appv1.AnnotationClusterAdminApprovedSpec] = clusterAdminApprovalSignature(sub, userInfo.Username)
+	sub.SetAnnotations(annotations)
+
+	return true, nil
+}
+
+// setClusterAdminApproval sets the ClusterAdminApproved condition of a subscription requesting the cluster admin
+// access and returns its approver. The approver is empty if the access is pending approval. The approval is only
+// trusted with the signature of the mutating webhook, whatever the failure policy of the webhook.
+func setClusterAdminApproval(sub *appv1.Subscription, eventRecorder *EventRecorder) string {
+	approver := sub.GetAnnotations()[appv1.AnnotationClusterAdminApprovedBy]
+	signature := clusterAdminApprovalSignature(sub, approver)
+
+	if approver != "" && (signature == "" || sub.GetAnnotations()[appv1.AnnotationClusterAdminApprovedSpec] != signature) {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionClusterAdminApproved, false, ConditionReasonApprovalPending,
+			fmt.Sprintf("the subscription changed since it was approved by %v, or the approval was not set by the "+
+				"mutating webhook, the cluster admin access must be approved again by a user of the groups %v", approver,
+				clusterAdminApproverGroups))
+
+		if eventRecorder != nil {
+			eventRecorder.RecordEvent(sub, EventReasonClusterAdminApprovalPending,
+				"Cluster admin access of subscription "+sub.Name+" is pending approval of its changes", nil)
+		}
+
+		return ""
+	}
+
+	if approver == "" {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionClusterAdminApproved, false, ConditionReasonApprovalPending,
+			fmt.Sprintf("the cluster admin access must be approved by a user of the groups %v", clusterAdminApproverGroups))
+
+		if eventRecorder != nil {
+			eventRecorder.RecordEvent(sub, EventReasonClusterAdminApprovalPending,
+				"Cluster admin access of subscription "+sub.Name+" is pending approval", nil)
+		}
+
+		return ""
+	}
+
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionClusterAdminApproved, true, ConditionReasonApproved,
+		"approved by "+approver)
+
+	return approver
+}
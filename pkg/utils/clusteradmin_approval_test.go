@@ -0,0 +1,154 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestCheckClusterAdminApproval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	SetClusterAdminApproverGroups([]string{"platform-admins", " "})
+	defer SetClusterAdminApproverGroups(nil)
+
+	SetUserIdentityKey([]byte("identity-key"))
+	defer SetUserIdentityKey(nil)
+
+	g.Expect(IsClusterAdminApprovalRequired()).To(gomega.BeTrue())
+
+	approver := authenticationv1.UserInfo{Username: "alice", Groups: []string{"system:authenticated", "platform-admins"}}
+	user := authenticationv1.UserInfo{Username: "bob", Groups: []string{"system:authenticated"}}
+
+	newSub := func(annotations map[string]string) *appv1.Subscription {
+		return &appv1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app-ns", Annotations: annotations},
+			Spec:       appv1.SubscriptionSpec{Channel: "ch-ns/ch", Package: "app"},
+		}
+	}
+
+	// no approval annotation
+	changed, err := CheckClusterAdminApproval(newSub(map[string]string{}), nil, user)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changed).To(gomega.BeFalse())
+
+	// a user who is not an approver can't approve, even on behalf of an approver
+	_, err = CheckClusterAdminApproval(newSub(map[string]string{appv1.AnnotationClusterAdminApprovedBy: "alice"}), nil, user)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the annotations are set to the approver name and the signature of the approved content
+	sub := newSub(map[string]string{appv1.AnnotationClusterAdminApprovedBy: "yes"})
+	changed, err = CheckClusterAdminApproval(sub, newSub(map[string]string{}), approver)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changed).To(gomega.BeTrue())
+	g.Expect(sub.GetAnnotations()[appv1.AnnotationClusterAdminApprovedBy]).To(gomega.Equal("alice"))
+	g.Expect(sub.GetAnnotations()[appv1.AnnotationClusterAdminApprovedSpec]).To(
+		gomega.Equal(clusterAdminApprovalSignature(sub, "alice")))
+
+	approved := sub.DeepCopy()
+
+	// a user who is not an approver can't forge the content signature
+	forged := approved.DeepCopy()
+	forged.Annotations[appv1.AnnotationClusterAdminApprovedSpec] = "forged"
+	_, err = CheckClusterAdminApproval(forged, approved, user)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the unchanged approval is kept on the updates of the other users
+	changed, err = CheckClusterAdminApproval(approved.DeepCopy(), approved, user)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changed).To(gomega.BeFalse())
+
+	// the content changes of the other users leave the approval pending
+	updated := approved.DeepCopy()
+	updated.Annotations[appv1.AnnotationGitPath] = "other"
+	changed, err = CheckClusterAdminApproval(updated, approved, user)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changed).To(gomega.BeFalse())
+	g.Expect(updated.GetAnnotations()[appv1.AnnotationClusterAdminApprovedSpec]).NotTo(
+		gomega.Equal(clusterAdminApprovalSignature(updated, "alice")))
+
+	// the content changes of an approver are approved
+	updated = approved.DeepCopy()
+	updated.Spec.Channel = "ch-ns/other"
+	changed, err = CheckClusterAdminApproval(updated, approved, approver)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changed).To(gomega.BeTrue())
+	g.Expect(updated.GetAnnotations()[appv1.AnnotationClusterAdminApprovedSpec]).To(
+		gomega.Equal(clusterAdminApprovalSignature(updated, "alice")))
+
+	// the approval can be revoked, the content signature is removed with it
+	revoked := newSub(map[string]string{
+		appv1.AnnotationClusterAdminApprovedSpec: approved.GetAnnotations()[appv1.AnnotationClusterAdminApprovedSpec],
+	})
+	changed, err = CheckClusterAdminApproval(revoked, approved, user)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changed).To(gomega.BeTrue())
+	g.Expect(revoked.GetAnnotations()).NotTo(gomega.HaveKey(appv1.AnnotationClusterAdminApprovedSpec))
+}
+
+func TestSetClusterAdminApproval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	SetClusterAdminApproverGroups([]string{"platform-admins"})
+	defer SetClusterAdminApproverGroups(nil)
+
+	SetUserIdentityKey([]byte("identity-key"))
+	defer SetUserIdentityKey(nil)
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app-ns"}}
+
+	g.Expect(setClusterAdminApproval(sub, nil)).To(gomega.BeEmpty())
+
+	cond := meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionClusterAdminApproved)
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(gomega.Equal(ConditionReasonApprovalPending))
+
+	// the approval set without the mutating webhook is not trusted, its content hash can be computed by anyone
+	sub.SetAnnotations(map[string]string{
+		appv1.AnnotationClusterAdminApprovedBy:   "alice",
+		appv1.AnnotationClusterAdminApprovedSpec: ClusterAdminApprovalHash(sub),
+	})
+
+	g.Expect(setClusterAdminApproval(sub, nil)).To(gomega.BeEmpty())
+
+	cond = meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionClusterAdminApproved)
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(gomega.Equal(ConditionReasonApprovalPending))
+
+	sub.Annotations[appv1.AnnotationClusterAdminApprovedSpec] = clusterAdminApprovalSignature(sub, "alice")
+
+	g.Expect(setClusterAdminApproval(sub, nil)).To(gomega.Equal("alice"))
+
+	cond = meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionClusterAdminApproved)
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionTrue))
+	g.Expect(cond.Message).To(gomega.Equal("approved by alice"))
+
+	// the approval of a subscription changed since it was approved is pending
+	sub.Spec.Package = "other"
+
+	g.Expect(setClusterAdminApproval(sub, nil)).To(gomega.BeEmpty())
+
+	cond = meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionClusterAdminApproved)
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(gomega.Equal(ConditionReasonApprovalPending))
+}
@@ -0,0 +1,247 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	// condition reasons of the subscription status conditions
+	ConditionReasonPropagated        = "Propagated"
+	ConditionReasonPropagationFailed = "PropagationFailed"
+	ConditionReasonSubscribed        = "Subscribed"
+	ConditionReasonFailed            = "Failed"
+	ConditionReasonNoHooks           = "NoHooks"
+	ConditionReasonPreHooksRunning   = "PreHooksRunning"
+	ConditionReasonPostHooksPending  = "PostHooksPending"
+	ConditionReasonHooksCompleted    = "HooksCompleted"
+	ConditionReasonInTimeWindow      = "InTimeWindow"
+	ConditionReasonOutOfTimeWindow   = "OutOfTimeWindow"
+	ConditionReasonReady             = "Ready"
+	ConditionReasonNotReady          = "NotReady"
+	ConditionReasonApproved          = "Approved"
+	ConditionReasonApprovalPending   = "ApprovalPending"
+	ConditionReasonHostKeyMismatch   = "HostKeyMismatch"
+	ConditionReasonExpiryScheduled   = "ExpiryScheduled"
+	ConditionReasonInvalidExpiry     = "InvalidExpiry"
+	ConditionReasonNetworkError      = "NetworkError"
+	ConditionReasonTimeout           = "Timeout"
+	ConditionReasonInvalidManifest   = "InvalidManifest"
+	ConditionReasonForbidden         = "Forbidden"
+	ConditionReasonAdmissionDenied   = "AdmissionDenied"
+
+	// the subscription is blocked while its deployment window can't be resolved
+	ConditionReasonDeploymentWindowUnresolved = "DeploymentWindowUnresolved"
+
+	// the deploy result of the subscription resources is not reported yet, or a resource failed to deploy
+	ConditionReasonSyncPending  = "SyncPending"
+	ConditionReasonDeployFailed = "DeployFailed"
+
+	// a managed cluster failed to apply a manifestWork of the subscription
+	ConditionReasonManifestWorkFailed = "ManifestWorkFailed"
+
+	// the SSH channel has no known hosts to verify the host key of the Git server with
+	ConditionReasonKnownHostsRequired = "KnownHostsRequired"
+
+	// maximum length of a condition message
+	maxConditionMessageLength = 32768
+)
+
+// SetSubscriptionCondition sets a condition on the subscription status. The observed generation of the
+// condition is the subscription generation, the transition time is only updated if the status changes.
+func SetSubscriptionCondition(sub *appv1.Subscription, condType string, status bool, reason, message string) {
+	condStatus := metav1.ConditionFalse
+	if status {
+		condStatus = metav1.ConditionTrue
+	}
+
+	if len(message) > maxConditionMessageLength {
+		message = message[:maxConditionMessageLength]
+	}
+
+	meta.SetStatusCondition(&sub.Status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             condStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: sub.GetGeneration(),
+	})
+}
+
+// failureReasonPatterns are the substrings of the lower case failure messages of each failure reason, in their
+// matching order. The admission webhooks can deny a request with a forbidden error, so they are matched first.
+var failureReasonPatterns = []struct {
+	reason   string
+	patterns []string
+}{
+	{ConditionReasonAdmissionDenied, []string{"admission webhook", "denied the request", "denied request"}},
+	{ConditionReasonForbidden, []string{"is forbidden", "forbidden:"}},
+	{ConditionReasonTimeout, []string{"timeout", "timed out", "deadline exceeded"}},
+	{ConditionReasonNetworkError, []string{"connection refused", "connection reset", "no such host", "network is unreachable",
+		"no route to host", "broken pipe", "unexpected eof", "temporary failure", "service unavailable", "too many requests"}},
+	{ConditionReasonInvalidManifest, []string{"error converting yaml", "yaml: ", "json: cannot unmarshal", "invalid character",
+		"is invalid", "no matches for kind", "unknown field"}},
+}
+
+// FailureConditionReason returns the condition reason of the subscription failure message:
+//   - HostKeyMismatch if the SSH host key of the Git server doesn't match the channel known hosts
+//   - KnownHostsRequired if the SSH channel has no known hosts and the strict host key checking is enabled
+//   - AdmissionDenied if an admission webhook or policy rejected a resource
+//   - Forbidden if the RBAC rules don't allow the request
+//   - Timeout or NetworkError if the Git, Helm or object store server or the API server couldn't be reached
+//   - InvalidManifest if a resource can't be parsed or is rejected by the validation of the API server
+//
+// and the default reason otherwise. Timeout and NetworkError are transient, the other reasons need a fix.
+func FailureConditionReason(defaultReason, msg string) string {
+	if IsSSHHostKeyMismatch(msg) {
+		return ConditionReasonHostKeyMismatch
+	}
+
+	if IsSSHKnownHostsRequired(msg) {
+		return ConditionReasonKnownHostsRequired
+	}
+
+	lower := strings.ToLower(msg)
+
+	for _, p := range failureReasonPatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(lower, pattern) {
+				return p.reason
+			}
+		}
+	}
+
+	return defaultReason
+}
+
+// SetSubscriptionSyncedCondition sets the Synced condition of the managed cluster subscription from the deploy result
+// of its resources in the subscription package status. The condition is false while the result is not reported.
+func SetSubscriptionSyncedCondition(sub *appv1.Subscription, pkgStatus *appsubReportV1alpha1.SubscriptionStatus) {
+	if sub.Status.Phase == appv1.SubscriptionFailed {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, false,
+			FailureConditionReason(ConditionReasonFailed, sub.Status.Reason), sub.Status.Reason)
+
+		return
+	}
+
+	if pkgStatus == nil {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, false, ConditionReasonSyncPending,
+			"Waiting for the deploy result of the subscription resources")
+
+		return
+	}
+
+	for _, pkg := range pkgStatus.Statuses.SubscriptionPackageStatus {
+		if pkg.Phase != appsubReportV1alpha1.PackageDeployFailed {
+			continue
+		}
+
+		msg := fmt.Sprintf("%v %v/%v failed to deploy: %v", pkg.Kind, pkg.Namespace, pkg.Name, pkg.Message)
+
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, false,
+			FailureConditionReason(ConditionReasonDeployFailed, pkg.Message), msg)
+
+		return
+	}
+
+	overall := pkgStatus.Statuses.SubscriptionStatus
+	if overall.Phase == appsubReportV1alpha1.SubscriptionDeployFailed {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, false,
+			FailureConditionReason(ConditionReasonDeployFailed, overall.Message), overall.Message)
+
+		return
+	}
+
+	if overall.Phase == appsubReportV1alpha1.SubscriptionUnknown && len(pkgStatus.Statuses.SubscriptionPackageStatus) == 0 {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, false, ConditionReasonSyncPending,
+			"Waiting for the deploy result of the subscription resources")
+
+		return
+	}
+
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, true, ConditionReasonSubscribed, "")
+}
+
+// SetSubscriptionBlockedCondition sets the Blocked condition from the subscription time window. If the subscription
+// is blocked, the condition message has the start time of the next window. It has no countdown, so the condition
+// doesn't change on every reconcile while the subscription is blocked
+func SetSubscriptionBlockedCondition(sub *appv1.Subscription, tw *appv1.TimeWindow, now time.Time) {
+	next := NextStartPoint(tw, now)
+	if next <= 0 {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionBlocked, false, ConditionReasonInTimeWindow, "")
+
+		return
+	}
+
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionBlocked, true, ConditionReasonOutOfTimeWindow,
+		fmt.Sprintf("Deployment is blocked by the subscription time window, the next window starts at %v",
+			now.Add(next).UTC().Format(time.RFC3339)))
+}
+
+// SetSubscriptionReadyCondition sets the Ready condition, the subscription is ready if all the dependent
+// conditions are true and the subscription is not blocked
+func SetSubscriptionReadyCondition(sub *appv1.Subscription, dependsOn ...string) {
+	for _, condType := range dependsOn {
+		if !meta.IsStatusConditionTrue(sub.Status.Conditions, condType) {
+			msg := condType + " condition is not true"
+			if cond := meta.FindStatusCondition(sub.Status.Conditions, condType); cond != nil && cond.Message != "" {
+				msg = cond.Message
+			}
+
+			SetSubscriptionCondition(sub, appv1.SubscriptionConditionReady, false, ConditionReasonNotReady, msg)
+
+			return
+		}
+	}
+
+	if blocked := meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionBlocked); blocked != nil &&
+		blocked.Status == metav1.ConditionTrue {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionReady, false, ConditionReasonOutOfTimeWindow, blocked.Message)
+
+		return
+	}
+
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionReady, true, ConditionReasonReady, "")
+}
+
+// isSameConditions compares the conditions, ignoring the transition time
+func isSameConditions(a, b []metav1.Condition) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, ac := range a {
+		bc := meta.FindStatusCondition(b, ac.Type)
+		if bc == nil {
+			return false
+		}
+
+		if ac.Status != bc.Status || ac.Reason != bc.Reason || ac.Message != bc.Message ||
+			ac.ObservedGeneration != bc.ObservedGeneration {
+			return false
+		}
+	}
+
+	return true
+}
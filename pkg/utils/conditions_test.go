@@ -0,0 +1,161 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func TestSubscriptionConditions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "default", Generation: 3},
+	}
+
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, false, ConditionReasonFailed, "channel not found")
+	SetSubscriptionBlockedCondition(sub, nil, time.Now())
+	SetSubscriptionReadyCondition(sub, appv1.SubscriptionConditionSynced)
+
+	ready := meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionReady)
+	g.Expect(ready).NotTo(gomega.BeNil())
+	g.Expect(ready.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(ready.Message).To(gomega.Equal("channel not found"))
+	g.Expect(ready.ObservedGeneration).To(gomega.Equal(int64(3)))
+
+	old := sub.Status.DeepCopy()
+
+	// setting the same conditions again is not a status change
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, false, ConditionReasonFailed, "channel not found")
+	SetSubscriptionReadyCondition(sub, appv1.SubscriptionConditionSynced)
+	g.Expect(IsHubRelatedStatusChanged(old, sub.Status.DeepCopy())).To(gomega.BeFalse())
+
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionSynced, true, ConditionReasonSubscribed, "")
+	SetSubscriptionReadyCondition(sub, appv1.SubscriptionConditionSynced)
+	g.Expect(meta.IsStatusConditionTrue(sub.Status.Conditions, appv1.SubscriptionConditionReady)).To(gomega.BeTrue())
+	g.Expect(IsHubRelatedStatusChanged(old, sub.Status.DeepCopy())).To(gomega.BeTrue())
+
+	// a blocked subscription is not ready, the message has the next window start time
+	tw := &appv1.TimeWindow{
+		WindowType: "active",
+		Daysofweek: []string{"Saturday"},
+		Hours:      []appv1.HourRange{{Start: "02:00AM", End: "04:00AM"}},
+	}
+
+	// Wednesday
+	now := time.Date(2021, time.June, 2, 12, 0, 0, 0, time.UTC)
+
+	SetSubscriptionBlockedCondition(sub, tw, now)
+	SetSubscriptionReadyCondition(sub, appv1.SubscriptionConditionSynced)
+
+	blocked := meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionBlocked)
+	g.Expect(blocked.Status).To(gomega.Equal(metav1.ConditionTrue))
+	g.Expect(blocked.Message).To(gomega.Equal(
+		"Deployment is blocked by the subscription time window, the next window starts at 2021-06-05T02:00:00Z"))
+
+	// the message doesn't change while the subscription is blocked
+	SetSubscriptionBlockedCondition(sub, tw, now.Add(90*time.Minute))
+	g.Expect(meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionBlocked).Message).To(
+		gomega.Equal("Deployment is blocked by the subscription time window, the next window starts at 2021-06-05T02:00:00Z"))
+
+	ready = meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionReady)
+	g.Expect(ready.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(ready.Reason).To(gomega.Equal(ConditionReasonOutOfTimeWindow))
+	g.Expect(ready.Message).To(gomega.Equal(blocked.Message))
+	g.Expect(sub.Status.Conditions).To(gomega.HaveLen(3))
+}
+
+func TestSubscriptionSyncedCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "default"}}
+	sub.Status.Phase = appv1.SubscriptionSubscribed
+
+	synced := func() *metav1.Condition {
+		return meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionSynced)
+	}
+
+	// the subscription is not synced until the deploy result is reported
+	SetSubscriptionSyncedCondition(sub, nil)
+	g.Expect(synced().Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(synced().Reason).To(gomega.Equal(ConditionReasonSyncPending))
+
+	pkgStatus := &appsubReportV1alpha1.SubscriptionStatus{}
+	SetSubscriptionSyncedCondition(sub, pkgStatus)
+	g.Expect(synced().Reason).To(gomega.Equal(ConditionReasonSyncPending))
+
+	pkgStatus.Statuses.SubscriptionStatus.Phase = appsubReportV1alpha1.SubscriptionDeployed
+	pkgStatus.Statuses.SubscriptionPackageStatus = []appsubReportV1alpha1.SubscriptionUnitStatus{
+		{Kind: "ConfigMap", Namespace: "default", Name: "cm", Phase: appsubReportV1alpha1.PackageDeployed},
+	}
+
+	SetSubscriptionSyncedCondition(sub, pkgStatus)
+	g.Expect(synced().Status).To(gomega.Equal(metav1.ConditionTrue))
+	g.Expect(synced().Reason).To(gomega.Equal(ConditionReasonSubscribed))
+
+	// a resource failed to deploy
+	pkgStatus.Statuses.SubscriptionPackageStatus = append(pkgStatus.Statuses.SubscriptionPackageStatus,
+		appsubReportV1alpha1.SubscriptionUnitStatus{Kind: "Deployment", Namespace: "default", Name: "app",
+			Phase: appsubReportV1alpha1.PackageDeployFailed, Message: `deployments.apps "app" is forbidden: no access`})
+
+	SetSubscriptionSyncedCondition(sub, pkgStatus)
+	g.Expect(synced().Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(synced().Reason).To(gomega.Equal(ConditionReasonForbidden))
+	g.Expect(synced().Message).To(gomega.HavePrefix("Deployment default/app failed to deploy"))
+
+	pkgStatus.Statuses.SubscriptionPackageStatus = pkgStatus.Statuses.SubscriptionPackageStatus[:1]
+	pkgStatus.Statuses.SubscriptionStatus = appsubReportV1alpha1.SubscriptionOverallStatus{
+		Phase: appsubReportV1alpha1.SubscriptionDeployFailed, Message: "failed to apply the resources"}
+
+	SetSubscriptionSyncedCondition(sub, pkgStatus)
+	g.Expect(synced().Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(synced().Reason).To(gomega.Equal(ConditionReasonDeployFailed))
+
+	// the subscription failure takes precedence over the deploy result
+	sub.Status.Phase = appv1.SubscriptionFailed
+	sub.Status.Reason = "channel not found"
+
+	SetSubscriptionSyncedCondition(sub, pkgStatus)
+	g.Expect(synced().Reason).To(gomega.Equal(ConditionReasonFailed))
+	g.Expect(synced().Message).To(gomega.Equal("channel not found"))
+}
+
+func TestFailureConditionReasonClassification(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := map[string]string{
+		"failed to clone the repo: dial tcp: lookup github.com: no such host":                                  ConditionReasonNetworkError,
+		"Get \"https://charts.example.com/index.yaml\": dial tcp 10.0.0.1:443: connect: connection refused":    ConditionReasonNetworkError,
+		"failed to clone the repo: context deadline exceeded":                                                  ConditionReasonTimeout,
+		"net/http: TLS handshake timeout":                                                                      ConditionReasonTimeout,
+		"error converting YAML to JSON: yaml: line 3: mapping values are not allowed in this context":          ConditionReasonInvalidManifest,
+		"Deployment.apps \"web\" is invalid: spec.replicas: Invalid value: -1":                                 ConditionReasonInvalidManifest,
+		"deployments.apps is forbidden: User \"system:serviceaccount:app:sa\" cannot create resource":          ConditionReasonForbidden,
+		"admission webhook \"validate.kyverno.svc\" denied the request: privileged containers are not allowed": ConditionReasonAdmissionDenied,
+		"channel not found": ConditionReasonFailed,
+	}
+
+	for msg, reason := range tests {
+		g.Expect(FailureConditionReason(ConditionReasonFailed, msg)).To(gomega.Equal(reason), msg)
+	}
+}
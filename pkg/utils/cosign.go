@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// ErrCosignSignatureInvalid is wrapped by the errors of the missing or invalid cosign signatures
+var ErrCosignSignatureInvalid = errors.New("invalid cosign signature")
+
+// ParseCosignPublicKey parses the PEM encoded public key of a cosign key pair, an ECDSA, RSA or ed25519 key
+func ParseCosignPublicKey(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemKey)))
+	if block == nil {
+		return nil, errors.New("failed to decode the PEM cosign public key")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the cosign public key: %w", err)
+	}
+
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		return publicKey, nil
+	case ed25519.PublicKey:
+		if IsFIPSMode() {
+			return nil, fmt.Errorf("the ed25519 cosign public key is %w, use an ECDSA or RSA key", ErrFIPSDisallowed)
+		}
+
+		return publicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported cosign public key type %T", publicKey)
+	}
+}
+
+// GetChannelCosignPublicKey returns the cosign public key of the channel annotation. It is nil if the channel
+// doesn't require the cosign signatures.
+func GetChannelCosignPublicKey(channel *chnv1.Channel) (crypto.PublicKey, error) {
+	if channel == nil {
+		return nil, nil
+	}
+
+	pemKey := channel.GetAnnotations()[appv1.AnnotationCosignPublicKey]
+	if strings.TrimSpace(pemKey) == "" {
+		return nil, nil
+	}
+
+	publicKey, err := ParseCosignPublicKey(pemKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v annotation of the channel %v/%v: %w", appv1.AnnotationCosignPublicKey,
+			channel.Namespace, channel.Name, err)
+	}
+
+	return publicKey, nil
+}
+
+// VerifyCosignSignature verifies the base64 encoded signature of the content, as created by cosign sign-blob. The
+// ECDSA and RSA signatures are verified against the SHA-256 digest of the content.
+func VerifyCosignSignature(publicKey crypto.PublicKey, content, signature []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil {
+		return fmt.Errorf("%w, failed to decode the base64 signature: %v", ErrCosignSignatureInvalid, err)
+	}
+
+	digest := sha256.Sum256(content)
+
+	verified := false
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		verified = ecdsa.VerifyASN1(key, digest[:], sig)
+	case *rsa.PublicKey:
+		verified = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case ed25519.PublicKey:
+		verified = ed25519.Verify(key, content, sig)
+	default:
+		return fmt.Errorf("unsupported cosign public key type %T", publicKey)
+	}
+
+	if !verified {
+		return fmt.Errorf("%w, the signature doesn't match the content and the channel public key", ErrCosignSignatureInvalid)
+	}
+
+	return nil
+}
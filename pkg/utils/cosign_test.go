@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func encodeTestPublicKey(t *testing.T, publicKey crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerifyCosignSignature(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	content := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: signed\n")
+	digest := sha256.Sum256(content)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ecdsaSig, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest[:])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ed25519Public, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	tests := []struct {
+		desc      string
+		publicKey crypto.PublicKey
+		signature []byte
+	}{
+		{desc: "ecdsa", publicKey: &ecdsaKey.PublicKey, signature: ecdsaSig},
+		{desc: "rsa", publicKey: &rsaKey.PublicKey, signature: rsaSig},
+		{desc: "ed25519", publicKey: ed25519Public, signature: ed25519.Sign(ed25519Key, content)},
+	}
+
+	for _, tt := range tests {
+		publicKey, err := ParseCosignPublicKey(encodeTestPublicKey(t, tt.publicKey))
+		g.Expect(err).NotTo(gomega.HaveOccurred(), tt.desc)
+
+		// the cosign sign-blob output ends with a new line
+		signature := []byte(base64.StdEncoding.EncodeToString(tt.signature) + "\n")
+		g.Expect(VerifyCosignSignature(publicKey, content, signature)).To(gomega.Succeed(), tt.desc)
+
+		err = VerifyCosignSignature(publicKey, append(content, []byte("  namespace: tampered\n")...), signature)
+		g.Expect(errors.Is(err, ErrCosignSignatureInvalid)).To(gomega.BeTrue(), tt.desc)
+	}
+
+	err = VerifyCosignSignature(&ecdsaKey.PublicKey, content, []byte("not base64!"))
+	g.Expect(errors.Is(err, ErrCosignSignatureInvalid)).To(gomega.BeTrue())
+
+	// signed with another key
+	err = VerifyCosignSignature(&ecdsaKey.PublicKey, content, []byte(base64.StdEncoding.EncodeToString(rsaSig)))
+	g.Expect(errors.Is(err, ErrCosignSignatureInvalid)).To(gomega.BeTrue())
+
+	// the ed25519 keys are not allowed in FIPS mode
+	SetFIPSMode(true)
+	defer SetFIPSMode(false)
+
+	_, err = ParseCosignPublicKey(encodeTestPublicKey(t, ed25519Public))
+	g.Expect(errors.Is(err, ErrFIPSDisallowed)).To(gomega.BeTrue())
+}
+
+func TestGetChannelCosignPublicKey(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	channel := &chnv1.Channel{ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "ch-ns"}}
+
+	publicKey, err := GetChannelCosignPublicKey(channel)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(publicKey).To(gomega.BeNil())
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	channel.SetAnnotations(map[string]string{appv1.AnnotationCosignPublicKey: encodeTestPublicKey(t, &ecdsaKey.PublicKey)})
+
+	publicKey, err = GetChannelCosignPublicKey(channel)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(publicKey).To(gomega.Equal(&ecdsaKey.PublicKey))
+
+	channel.SetAnnotations(map[string]string{appv1.AnnotationCosignPublicKey: "not a key"})
+
+	_, err = GetChannelCosignPublicKey(channel)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("ch-ns/bucket"))
+}
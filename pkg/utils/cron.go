@@ -0,0 +1,364 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maximum number of days searched for the next cron activation
+	maxCronSearchDays = 366 * 5
+	// marker of the last day of the month or the last weekday of the month
+	cronLast = -1
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronWeekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// nthWeekday is a weekday of the month, for example the first Saturday (6#1) or the last Friday (5L)
+type nthWeekday struct {
+	weekday time.Weekday
+	nth     int
+}
+
+// CronSchedule is a parsed 5 fields cron expression: minute, hour, day of month, month and day of week
+type CronSchedule struct {
+	minutes      uint64
+	hours        uint64
+	daysOfMonth  uint64
+	months       uint64
+	daysOfWeek   uint64
+	nthWeekdays  []nthWeekday
+	lastDayOfMon bool
+
+	// as in the standard cron, if both the day of month and the day of week are restricted,
+	// a day matches if either of them matches
+	domRestricted bool
+	dowRestricted bool
+}
+
+// ParseCronSchedule parses a standard 5 fields cron expression. Besides the standard syntax (*, lists, ranges,
+// steps, month and weekday names and the @daily like macros), the day of month field accepts L for the last day
+// of the month and the day of week field accepts 6#1 for the first Saturday and 5L for the last Friday of the month.
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron schedule %q: expected 5 fields, found %d", expr, len(fields))
+	}
+
+	sched := &CronSchedule{}
+
+	var err error
+
+	if sched.minutes, _, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute field of cron schedule %q: %w", expr, err)
+	}
+
+	if sched.hours, _, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour field of cron schedule %q: %w", expr, err)
+	}
+
+	if err := sched.parseDaysOfMonth(fields[2]); err != nil {
+		return nil, fmt.Errorf("invalid day of month field of cron schedule %q: %w", expr, err)
+	}
+
+	if sched.months, _, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid month field of cron schedule %q: %w", expr, err)
+	}
+
+	if err := sched.parseDaysOfWeek(fields[4]); err != nil {
+		return nil, fmt.Errorf("invalid day of week field of cron schedule %q: %w", expr, err)
+	}
+
+	return sched, nil
+}
+
+func (c *CronSchedule) parseDaysOfMonth(field string) error {
+	items := []string{}
+
+	for _, item := range strings.Split(field, ",") {
+		if strings.EqualFold(item, "L") {
+			c.lastDayOfMon = true
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	c.domRestricted = c.lastDayOfMon
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	bits, all, err := parseCronField(strings.Join(items, ","), 1, 31, nil)
+	if err != nil {
+		return err
+	}
+
+	c.daysOfMonth = bits
+	c.domRestricted = c.domRestricted || !all
+
+	return nil
+}
+
+func (c *CronSchedule) parseDaysOfWeek(field string) error {
+	items := []string{}
+
+	for _, item := range strings.Split(field, ",") {
+		switch {
+		case strings.Contains(item, "#"):
+			parts := strings.SplitN(item, "#", 2)
+
+			wd, err := parseCronValue(parts[0], 0, 7, cronWeekdayNames)
+			if err != nil {
+				return err
+			}
+
+			nth, err := strconv.Atoi(parts[1])
+			if err != nil || nth < 1 || nth > 5 {
+				return fmt.Errorf("invalid weekday occurrence %q, expected 1 to 5", parts[1])
+			}
+
+			c.nthWeekdays = append(c.nthWeekdays, nthWeekday{weekday: time.Weekday(wd % 7), nth: nth})
+		case len(item) > 1 && strings.HasSuffix(strings.ToUpper(item), "L"):
+			wd, err := parseCronValue(item[:len(item)-1], 0, 7, cronWeekdayNames)
+			if err != nil {
+				return err
+			}
+
+			c.nthWeekdays = append(c.nthWeekdays, nthWeekday{weekday: time.Weekday(wd % 7), nth: cronLast})
+		default:
+			items = append(items, item)
+		}
+	}
+
+	c.dowRestricted = len(c.nthWeekdays) > 0
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	bits, all, err := parseCronField(strings.Join(items, ","), 0, 7, cronWeekdayNames)
+	if err != nil {
+		return err
+	}
+
+	// 7 is also Sunday
+	if bits&(1<<7) != 0 {
+		bits |= 1
+	}
+
+	c.daysOfWeek = bits
+	c.dowRestricted = c.dowRestricted || !all
+
+	return nil
+}
+
+// parseCronField returns the bitset of the values of a cron field and whether the field matches all the values
+func parseCronField(field string, lo, hi int, names map[string]int) (uint64, bool, error) {
+	var bits uint64
+
+	all := false
+
+	for _, item := range strings.Split(field, ",") {
+		rangeStr, step := item, 1
+
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			var err error
+
+			rangeStr = item[:idx]
+
+			step, err = strconv.Atoi(item[idx+1:])
+			if err != nil || step < 1 {
+				return 0, false, fmt.Errorf("invalid step %q", item[idx+1:])
+			}
+		}
+
+		start, end := lo, hi
+
+		switch {
+		case rangeStr == "*" || rangeStr == "?":
+			all = all || step == 1
+		case strings.Contains(rangeStr, "-"):
+			parts := strings.SplitN(rangeStr, "-", 2)
+
+			var err error
+
+			if start, err = parseCronValue(parts[0], lo, hi, names); err != nil {
+				return 0, false, err
+			}
+
+			if end, err = parseCronValue(parts[1], lo, hi, names); err != nil {
+				return 0, false, err
+			}
+
+			if start > end {
+				return 0, false, fmt.Errorf("invalid range %q", rangeStr)
+			}
+		default:
+			var err error
+
+			if start, err = parseCronValue(rangeStr, lo, hi, names); err != nil {
+				return 0, false, err
+			}
+
+			// a/n means from a to the max value every n
+			if !strings.Contains(item, "/") {
+				end = start
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, all, nil
+}
+
+func parseCronValue(s string, lo, hi int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+
+	if v < lo || v > hi {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, lo, hi)
+	}
+
+	return v, nil
+}
+
+func (c *CronSchedule) matchDayOfMonth(t time.Time) bool {
+	if c.daysOfMonth&(1<<uint(t.Day())) != 0 {
+		return true
+	}
+
+	return c.lastDayOfMon && t.AddDate(0, 0, 1).Day() == 1
+}
+
+func (c *CronSchedule) matchDayOfWeek(t time.Time) bool {
+	if c.daysOfWeek&(1<<uint(t.Weekday())) != 0 {
+		return true
+	}
+
+	for _, nw := range c.nthWeekdays {
+		if nw.weekday != t.Weekday() {
+			continue
+		}
+
+		if nw.nth == cronLast {
+			if t.AddDate(0, 0, 7).Month() != t.Month() {
+				return true
+			}
+
+			continue
+		}
+
+		if (t.Day()-1)/7+1 == nw.nth {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *CronSchedule) matchDay(t time.Time) bool {
+	if c.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	switch {
+	case c.domRestricted && c.dowRestricted:
+		return c.matchDayOfMonth(t) || c.matchDayOfWeek(t)
+	case c.domRestricted:
+		return c.matchDayOfMonth(t)
+	case c.dowRestricted:
+		return c.matchDayOfWeek(t)
+	default:
+		return true
+	}
+}
+
+// Next returns the first activation time of the schedule at or after t, in the location of t.
+// The zero time is returned if there is no activation in the next 5 years.
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	for i := 0; i < maxCronSearchDays; i++ {
+		d := day.AddDate(0, 0, i)
+
+		if !c.matchDay(d) {
+			continue
+		}
+
+		for h := 0; h < 24; h++ {
+			if c.hours&(1<<uint(h)) == 0 {
+				continue
+			}
+
+			for m := 0; m < 60; m++ {
+				if c.minutes&(1<<uint(m)) == 0 {
+					continue
+				}
+
+				a := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, loc)
+
+				// skip the wall clock times that don't exist because of a DST transition
+				if a.Hour() != h || a.Minute() != m {
+					continue
+				}
+
+				if !a.Before(t) {
+					return a
+				}
+			}
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		schedule string
+		from     string
+		want     string
+	}{
+		{
+			desc:     "every day at 2am",
+			schedule: "0 2 * * *",
+			from:     "Tue Jun  1 10:00:00 UTC 2021",
+			want:     "Wed Jun  2 02:00:00 UTC 2021",
+		},
+		{
+			desc:     "activation at the from time",
+			schedule: "@daily",
+			from:     "Tue Jun  1 00:00:00 UTC 2021",
+			want:     "Tue Jun  1 00:00:00 UTC 2021",
+		},
+		{
+			desc:     "first Saturday of the month",
+			schedule: "0 2 * * 6#1",
+			from:     "Tue Jun  1 10:00:00 UTC 2021",
+			want:     "Sat Jun  5 02:00:00 UTC 2021",
+		},
+		{
+			desc:     "first Saturday of the next month",
+			schedule: "0 2 * * SAT#1",
+			from:     "Sat Jun  5 02:01:00 UTC 2021",
+			want:     "Sat Jul  3 02:00:00 UTC 2021",
+		},
+		{
+			desc:     "last Friday of the month",
+			schedule: "30 22 * * 5L",
+			from:     "Tue Jun  1 10:00:00 UTC 2021",
+			want:     "Fri Jun 25 22:30:00 UTC 2021",
+		},
+		{
+			desc:     "last day of the month",
+			schedule: "0 0 L * *",
+			from:     "Mon Feb  1 10:00:00 UTC 2021",
+			want:     "Sun Feb 28 00:00:00 UTC 2021",
+		},
+		{
+			desc:     "day of month or day of week",
+			schedule: "0 0 15 * MON",
+			from:     "Tue Jun  1 10:00:00 UTC 2021",
+			want:     "Mon Jun  7 00:00:00 UTC 2021",
+		},
+		{
+			desc:     "steps and ranges",
+			schedule: "*/20 9-17 * JAN-MAR 1-5",
+			from:     "Fri Jan  1 17:50:00 UTC 2021",
+			want:     "Mon Jan  4 09:00:00 UTC 2021",
+		},
+		{
+			desc:     "Sunday as 7",
+			schedule: "0 12 * * 7",
+			from:     "Tue Jun  1 10:00:00 UTC 2021",
+			want:     "Sun Jun  6 12:00:00 UTC 2021",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			sched, err := ParseCronSchedule(tC.schedule)
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", tC.schedule, err)
+			}
+
+			from, _ := time.Parse(time.UnixDate, tC.from)
+			want, _ := time.Parse(time.UnixDate, tC.want)
+
+			if got := sched.Next(from); !got.Equal(want) {
+				t.Errorf("wanted %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	for _, schedule := range []string{"", "0 2 * *", "60 2 * * *", "0 24 * * *", "0 0 0 * *", "0 0 * 13 *",
+		"0 0 * * 8", "0 0 * * 6#6", "0 0 * * FOO", "0 5-2 * * *", "*/0 * * * *"} {
+		if _, err := ParseCronSchedule(schedule); err == nil {
+			t.Errorf("expected an error for the cron schedule %q", schedule)
+		}
+	}
+}
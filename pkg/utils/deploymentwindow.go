@@ -0,0 +1,84 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// GetEmergencyDeployReason returns the reason of the emergency deployment if the subscription has the
+// emergency-deploy annotation
+func GetEmergencyDeployReason(sub *appv1.Subscription) (string, bool) {
+	reason := strings.TrimSpace(sub.GetAnnotations()[appv1.AnnotationEmergencyDeploy])
+
+	return reason, reason != ""
+}
+
+// GetEffectiveTimeWindow returns the time window the subscription is handled in, resolving the deployment
+// window referenced by the subscription. If the deployment window can't be fetched, the subscription is blocked until
+// it is resolved, a blocking time window is returned along with the error.
+func GetEffectiveTimeWindow(clt client.Client, sub *appv1.Subscription) (*appv1.TimeWindow, error) {
+	if _, ok := GetEmergencyDeployReason(sub); ok {
+		return nil, nil
+	}
+
+	ref := sub.Spec.DeploymentWindowRef
+	if ref == nil || ref.Name == "" {
+		return sub.Spec.TimeWindow, nil
+	}
+
+	key := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if key.Namespace == "" {
+		key.Namespace = sub.GetNamespace()
+	}
+
+	dw := &appv1.DeploymentWindow{}
+	if err := clt.Get(context.TODO(), key, dw); err != nil {
+		return UnresolvedDeploymentWindow(), fmt.Errorf("failed to get deployment window %v, err: %w", key.String(), err)
+	}
+
+	return MergeDeploymentWindow(sub, dw), nil
+}
+
+// UnresolvedDeploymentWindow returns the time window of a subscription whose deployment window can't be resolved, it
+// blocks the subscription all day long. The subscription is reconciled when the deployment window is created.
+func UnresolvedDeploymentWindow() *appv1.TimeWindow {
+	return &appv1.TimeWindow{
+		WindowType: "blocked",
+		Hours:      []appv1.HourRange{{Start: MIDNIGHT, End: MIDNIGHT}},
+	}
+}
+
+// MergeDeploymentWindow returns the time window of the subscription referencing the deployment window.
+// The subscription timewindow takes precedence unless the deployment window is enforced, the emergency-deploy
+// annotation bypasses both of them.
+func MergeDeploymentWindow(sub *appv1.Subscription, dw *appv1.DeploymentWindow) *appv1.TimeWindow {
+	if _, ok := GetEmergencyDeployReason(sub); ok {
+		return nil
+	}
+
+	if dw == nil || (sub.Spec.TimeWindow != nil && !dw.Spec.Enforced) {
+		return sub.Spec.TimeWindow
+	}
+
+	return dw.Spec.TimeWindow.DeepCopy()
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestMergeDeploymentWindow(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	subWindow := &appv1.TimeWindow{WindowType: "active", Daysofweek: []string{"Monday"}}
+
+	dw := &appv1.DeploymentWindow{
+		ObjectMeta: metav1.ObjectMeta{Name: "freeze", Namespace: "windows"},
+		Spec: appv1.DeploymentWindowSpec{
+			TimeWindow: appv1.TimeWindow{WindowType: "blocked", Daysofweek: []string{"Friday"}},
+		},
+	}
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app-ns"},
+		Spec: appv1.SubscriptionSpec{
+			DeploymentWindowRef: &appv1.DeploymentWindowReference{Name: "freeze", Namespace: "windows"},
+		},
+	}
+
+	// the deployment window applies to the subscription without timewindow
+	g.Expect(MergeDeploymentWindow(sub, dw)).To(gomega.Equal(&dw.Spec.TimeWindow))
+
+	// the subscription timewindow takes precedence
+	sub.Spec.TimeWindow = subWindow
+	g.Expect(MergeDeploymentWindow(sub, dw)).To(gomega.Equal(subWindow))
+
+	// unless the deployment window is enforced
+	dw.Spec.Enforced = true
+	g.Expect(MergeDeploymentWindow(sub, dw)).To(gomega.Equal(&dw.Spec.TimeWindow))
+
+	// the emergency deployment bypasses both of them
+	sub.SetAnnotations(map[string]string{appv1.AnnotationEmergencyDeploy: "CVE-2021-44228 hotfix"})
+	g.Expect(MergeDeploymentWindow(sub, dw)).To(gomega.BeNil())
+
+	reason, ok := GetEmergencyDeployReason(sub)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(reason).To(gomega.Equal("CVE-2021-44228 hotfix"))
+}
+
+func TestUnresolvedDeploymentWindow(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(apis.AddToScheme(s)).To(gomega.Succeed())
+
+	clt := fake.NewClientBuilder().WithScheme(s).Build()
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "default"},
+		Spec:       appv1.SubscriptionSpec{DeploymentWindowRef: &appv1.DeploymentWindowReference{Name: "maintenance"}},
+	}
+
+	// the subscription is blocked while its deployment window is not found
+	tw, err := GetEffectiveTimeWindow(clt, sub)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	for _, now := range []time.Time{
+		time.Date(2021, time.June, 5, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, time.June, 5, 12, 30, 0, 0, time.UTC),
+		time.Date(2021, time.June, 11, 23, 59, 0, 0, time.UTC),
+	} {
+		g.Expect(IsInWindow(tw, now)).To(gomega.BeFalse())
+		g.Expect(NextStatusReconcile(tw, now)).To(gomega.BeNumerically(">", 0))
+	}
+}
@@ -36,6 +36,32 @@ const NoiseLogLel = 5
 // VeryNoisy = show call stack, routine  and everything
 const VeryNoisy = 10
 
+// Reasons of the subscription lifecycle events
+const (
+	EventReasonCloneFailed                 = "CloneFailed"
+	EventReasonCommitDeployed              = "CommitDeployed"
+	EventReasonPackageApplyFailed          = "PackageApplyFailed"
+	EventReasonPackageSkipped              = "PackageSkipped"
+	EventReasonPackageNotReady             = "PackageNotReady"
+	EventReasonPackagePolicyViolation      = "PackagePolicyViolation"
+	EventReasonImagesUpdated               = "ImagesUpdated"
+	EventReasonHookStarted                 = "HookStarted"
+	EventReasonHookCompleted               = "HookCompleted"
+	EventReasonTimeWindowBlocked           = "TimeWindowBlocked"
+	EventReasonPaused                      = "Paused"
+	EventReasonResumed                     = "Resumed"
+	EventReasonEmergencyDeploy             = "EmergencyDeploy"
+	EventReasonClusterAdminApprovalPending = "ClusterAdminApprovalPending"
+	EventReasonHostKeyMismatch             = "HostKeyMismatch"
+	EventReasonKnownHostsRequired          = "KnownHostsRequired"
+	EventReasonSignatureVerificationFailed = "SignatureVerificationFailed"
+	EventReasonRetriesExhausted            = "RetriesExhausted"
+	EventReasonPackageRetained             = "PackageRetained"
+	EventReasonExpired                     = "Expired"
+	EventReasonReconcileProfiled           = "ReconcileProfiled"
+	EventReasonRestored                    = "Restored"
+)
+
 var regexStripFnPreamble = regexp.MustCompile(`^.*\.(.*)$`)
 
 // GetFnName - get name of function
@@ -84,7 +110,7 @@ func NewEventRecorder(cfg *rest.Config, scheme *apiruntime.Scheme) (*EventRecord
 // RecordEvent - record kuberentes event
 func (rec *EventRecorder) RecordEvent(obj apiruntime.Object, reason, msg string, err error) {
 	eventType := corev1.EventTypeNormal
-	eventMsg := msg
+	eventMsg := RedactString(msg)
 
 	if err != nil {
 		eventType = corev1.EventTypeWarning
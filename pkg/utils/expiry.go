@@ -0,0 +1,88 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// GetSubscriptionExpiry returns the expiry time of the subscription, the earliest of its expire-at annotation and of
+// its creation time plus its expireAfter. It returns the zero time if the subscription doesn't expire.
+func GetSubscriptionExpiry(sub *appv1.Subscription) (time.Time, error) {
+	var expiry time.Time
+
+	if sub.Spec.ExpireAfter != nil {
+		if sub.Spec.ExpireAfter.Duration <= 0 {
+			return time.Time{}, fmt.Errorf("invalid expireAfter %v, it must be positive", sub.Spec.ExpireAfter.Duration)
+		}
+
+		expiry = sub.GetCreationTimestamp().Add(sub.Spec.ExpireAfter.Duration)
+	}
+
+	if expireAt, ok := sub.GetAnnotations()[appv1.AnnotationExpireAt]; ok {
+		at, err := time.Parse(time.RFC3339, expireAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %v annotation %q, it must be a RFC3339 time", appv1.AnnotationExpireAt, expireAt)
+		}
+
+		if expiry.IsZero() || at.Before(expiry) {
+			expiry = at
+		}
+	}
+
+	return expiry, nil
+}
+
+// IsSubscriptionExpired returns true if the subscription has an expiry time that is reached
+func IsSubscriptionExpired(sub *appv1.Subscription, now time.Time) bool {
+	expiry, err := GetSubscriptionExpiry(sub)
+
+	return err == nil && !expiry.IsZero() && !expiry.After(now)
+}
+
+// TimeUntilExpiry returns the time until the subscription expires, 0 if it doesn't expire or is already expired
+func TimeUntilExpiry(sub *appv1.Subscription, now time.Time) time.Duration {
+	expiry, err := GetSubscriptionExpiry(sub)
+	if err != nil || expiry.IsZero() || !expiry.After(now) {
+		return 0
+	}
+
+	return expiry.Sub(now)
+}
+
+// SetSubscriptionExpiringCondition sets the Expiring condition with the expiry time of the subscription, the
+// condition is removed if the subscription doesn't expire
+func SetSubscriptionExpiringCondition(sub *appv1.Subscription) {
+	expiry, err := GetSubscriptionExpiry(sub)
+	if err != nil {
+		SetSubscriptionCondition(sub, appv1.SubscriptionConditionExpiring, false, ConditionReasonInvalidExpiry, err.Error())
+
+		return
+	}
+
+	if expiry.IsZero() {
+		meta.RemoveStatusCondition(&sub.Status.Conditions, appv1.SubscriptionConditionExpiring)
+
+		return
+	}
+
+	SetSubscriptionCondition(sub, appv1.SubscriptionConditionExpiring, true, ConditionReasonExpiryScheduled,
+		fmt.Sprintf("The subscription and its deployed resources are deleted at %v", expiry.UTC().Format(time.RFC3339)))
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestGetSubscriptionExpiry(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+	}
+
+	// no expiry
+	expiry, err := GetSubscriptionExpiry(sub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expiry.IsZero()).To(BeTrue())
+	g.Expect(IsSubscriptionExpired(sub, created.Add(time.Hour))).To(BeFalse())
+	g.Expect(TimeUntilExpiry(sub, created)).To(BeZero())
+
+	// expireAfter is relative to the creation time
+	sub.Spec.ExpireAfter = &metav1.Duration{Duration: 2 * time.Hour}
+	expiry, err = GetSubscriptionExpiry(sub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expiry).To(Equal(created.Add(2 * time.Hour)))
+	g.Expect(TimeUntilExpiry(sub, created.Add(time.Hour))).To(Equal(time.Hour))
+	g.Expect(IsSubscriptionExpired(sub, created.Add(time.Hour))).To(BeFalse())
+	g.Expect(IsSubscriptionExpired(sub, created.Add(2*time.Hour))).To(BeTrue())
+
+	// the earliest expiry wins
+	sub.SetAnnotations(map[string]string{appv1.AnnotationExpireAt: "2024-01-01T01:00:00Z"})
+	expiry, err = GetSubscriptionExpiry(sub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expiry).To(Equal(created.Add(time.Hour)))
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationExpireAt: "2024-01-01T03:00:00Z"})
+	expiry, err = GetSubscriptionExpiry(sub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expiry).To(Equal(created.Add(2 * time.Hour)))
+
+	// the invalid expiry never expires
+	sub.SetAnnotations(map[string]string{appv1.AnnotationExpireAt: "tomorrow"})
+	_, err = GetSubscriptionExpiry(sub)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(IsSubscriptionExpired(sub, created.Add(3*time.Hour))).To(BeFalse())
+
+	sub.SetAnnotations(nil)
+	sub.Spec.ExpireAfter = &metav1.Duration{Duration: -time.Hour}
+	_, err = GetSubscriptionExpiry(sub)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSetSubscriptionExpiringCondition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{appv1.AnnotationExpireAt: "2024-01-01T01:00:00Z"},
+		},
+	}
+
+	SetSubscriptionExpiringCondition(sub)
+
+	cond := meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionExpiring)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(cond.Reason).To(Equal(ConditionReasonExpiryScheduled))
+	g.Expect(cond.Message).To(ContainSubstring("2024-01-01T01:00:00Z"))
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationExpireAt: "tomorrow"})
+	SetSubscriptionExpiringCondition(sub)
+
+	cond = meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionExpiring)
+	g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(Equal(ConditionReasonInvalidExpiry))
+
+	// the condition is removed with the expiry
+	sub.SetAnnotations(nil)
+	SetSubscriptionExpiringCondition(sub)
+	g.Expect(meta.FindStatusCondition(sub.Status.Conditions, appv1.SubscriptionConditionExpiring)).To(BeNil())
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/rsa"
+	"crypto/sha1" // #nosec G505 Used only to generate hash strings of names
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+	"k8s.io/klog"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// ErrFIPSDisallowed is wrapped by the errors of the credentials and algorithms disallowed in FIPS mode
+var ErrFIPSDisallowed = errors.New("not allowed in FIPS mode")
+
+// fipsMode enables the FIPS checks in the binaries built without the fips build tag, for testing
+var fipsMode bool
+
+// minFIPSRSAKeyBits is the minimum size of the RSA SSH keys in FIPS mode
+const minFIPSRSAKeyBits = 2048
+
+// FIPSSSHHostKeyAlgorithms are the SSH host key algorithms accepted in FIPS mode. The SHA-1 ssh-rsa signatures and the
+// ed25519 and DSA host keys are rejected.
+var FIPSSSHHostKeyAlgorithms = []string{
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+	ssh.KeyAlgoRSASHA256,
+}
+
+// fipsTLSCipherSuites are the TLS 1.2 cipher suites accepted in FIPS mode
+var fipsTLSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// SetFIPSMode enables or disables the FIPS checks. They are always enabled in the binaries built with the fips build tag.
+func SetFIPSMode(enabled bool) {
+	fipsMode = enabled
+}
+
+// IsFIPSMode returns true if the FIPS checks are enabled
+func IsFIPSMode() bool {
+	return fipsBuild || fipsMode
+}
+
+// SetFIPSTLSConfig restricts the TLS config to the FIPS approved versions, cipher suites and curves in FIPS mode
+func SetFIPSTLSConfig(cfg *tls.Config) *tls.Config {
+	if cfg == nil || !IsFIPSMode() {
+		return cfg
+	}
+
+	if cfg.MinVersion < appv1.TLSMinVersionInt {
+		cfg.MinVersion = appv1.TLSMinVersionInt
+	}
+
+	cfg.CipherSuites = fipsTLSCipherSuites
+	cfg.CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+	return cfg
+}
+
+// CheckFIPSSSHSigner returns an error if the SSH private key is disallowed in FIPS mode. Only the ECDSA keys and the RSA
+// keys of 2048 bits or more are allowed. The signer of an RSA key is returned restricted to the SHA-2 signatures.
+func CheckFIPSSSHSigner(signer ssh.Signer) (ssh.Signer, error) {
+	if !IsFIPSMode() {
+		return signer, nil
+	}
+
+	keyType := signer.PublicKey().Type()
+
+	switch keyType {
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		return signer, nil
+	case ssh.KeyAlgoRSA:
+		cryptoKey, ok := signer.PublicKey().(ssh.CryptoPublicKey)
+		if !ok {
+			return nil, fmt.Errorf("the %v SSH key is %w, failed to get its size", keyType, ErrFIPSDisallowed)
+		}
+
+		rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+		if !ok || rsaKey.N.BitLen() < minFIPSRSAKeyBits {
+			return nil, fmt.Errorf("the %v SSH key of less than %v bits is %w", keyType, minFIPSRSAKeyBits, ErrFIPSDisallowed)
+		}
+
+		algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+		if !ok {
+			return nil, fmt.Errorf("the %v SSH key is %w, it doesn't support the SHA-2 signatures", keyType, ErrFIPSDisallowed)
+		}
+
+		return ssh.NewSignerWithAlgorithms(algorithmSigner, []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256})
+	default:
+		return nil, fmt.Errorf("the %v SSH key is %w, use an ECDSA key or an RSA key of at least %v bits",
+			keyType, ErrFIPSDisallowed, minFIPSRSAKeyBits)
+	}
+}
+
+// SetFIPSSSHAuth checks the SSH key and restricts the host key algorithms of the SSH auth in FIPS mode
+func SetFIPSSSHAuth(auth *gitssh.PublicKeys) error {
+	if !IsFIPSMode() {
+		return nil
+	}
+
+	signer, err := CheckFIPSSSHSigner(auth.Signer)
+	if err != nil {
+		klog.Error("failed to check SSH key: ", err)
+		return err
+	}
+
+	auth.Signer = signer
+	auth.HostKeyAlgorithms = FIPSSSHHostKeyAlgorithms
+
+	return nil
+}
+
+// FIPSSSHError returns a clear error if the SSH connection failed because the server only offers the host key
+// algorithms disallowed in FIPS mode, the error is returned as is otherwise
+func FIPSSSHError(err error) error {
+	if err == nil || !IsFIPSMode() || !strings.Contains(err.Error(), "no common algorithm for host key") {
+		return err
+	}
+
+	return fmt.Errorf("the SSH host key algorithms of the server are %w, the server must offer one of %v: %v",
+		ErrFIPSDisallowed, FIPSSSHHostKeyAlgorithms, err)
+}
+
+// NewNameHash returns the hash used to shorten the names. It is SHA-1 in FIPS mode too: the hash is not a security
+// function, and another algorithm would rename the Helm releases and the Ansible jobs generated by the previous releases.
+func NewNameHash() hash.Hash {
+	return sha1.New() // #nosec G401 Used only to generate hash strings of names
+}
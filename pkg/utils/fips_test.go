@@ -0,0 +1,151 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCheckFIPSSSHSigner(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ed25519Signer, err := ssh.NewSignerFromKey(ed25519Key)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ecdsaSigner, err := ssh.NewSignerFromKey(ecdsaKey)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024) // #nosec G403 testing the rejection of the small keys
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	smallRSASigner, err := ssh.NewSignerFromKey(smallRSAKey)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	rsaSigner, err := ssh.NewSignerFromKey(rsaKey)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// all the keys are allowed if FIPS mode is off
+	if !fipsBuild {
+		signer, err := CheckFIPSSSHSigner(ed25519Signer)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(signer).To(gomega.BeIdenticalTo(ed25519Signer))
+	}
+
+	SetFIPSMode(true)
+	defer SetFIPSMode(false)
+
+	_, err = CheckFIPSSSHSigner(ed25519Signer)
+	g.Expect(errors.Is(err, ErrFIPSDisallowed)).To(gomega.BeTrue())
+	g.Expect(err.Error()).To(gomega.ContainSubstring(ssh.KeyAlgoED25519))
+
+	_, err = CheckFIPSSSHSigner(smallRSASigner)
+	g.Expect(errors.Is(err, ErrFIPSDisallowed)).To(gomega.BeTrue())
+
+	signer, err := CheckFIPSSSHSigner(ecdsaSigner)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(signer).To(gomega.BeIdenticalTo(ecdsaSigner))
+
+	// the SHA-1 ssh-rsa signatures are disabled
+	signer, err = CheckFIPSSSHSigner(rsaSigner)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	multiSigner, ok := signer.(ssh.MultiAlgorithmSigner)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(multiSigner.Algorithms()).To(gomega.ConsistOf(ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSASHA512))
+
+	auth := &gitssh.PublicKeys{User: "git", Signer: rsaSigner}
+	g.Expect(SetFIPSSSHAuth(auth)).To(gomega.Succeed())
+	g.Expect(auth.HostKeyAlgorithms).To(gomega.Equal(FIPSSSHHostKeyAlgorithms))
+	g.Expect(auth.HostKeyAlgorithms).NotTo(gomega.ContainElement(ssh.KeyAlgoRSA))
+
+	g.Expect(SetFIPSSSHAuth(&gitssh.PublicKeys{User: "git", Signer: ed25519Signer})).NotTo(gomega.Succeed())
+}
+
+func TestSetFIPSTLSConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	if !fipsBuild {
+		cfg := SetFIPSTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}) // #nosec G402
+		g.Expect(cfg.CipherSuites).To(gomega.BeEmpty())
+	}
+
+	SetFIPSMode(true)
+	defer SetFIPSMode(false)
+
+	cfg := SetFIPSTLSConfig(&tls.Config{InsecureSkipVerify: true}) // #nosec G402
+	g.Expect(cfg.MinVersion).To(gomega.Equal(uint16(tls.VersionTLS12)))
+	g.Expect(cfg.InsecureSkipVerify).To(gomega.BeTrue())
+	g.Expect(cfg.CipherSuites).To(gomega.Equal(fipsTLSCipherSuites))
+	g.Expect(cfg.CurvePreferences).NotTo(gomega.ContainElement(tls.X25519))
+
+	g.Expect(SetFIPSTLSConfig(nil)).To(gomega.BeNil())
+}
+
+func TestFIPSSSHError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hostKeyErr := errors.New("ssh: handshake failed: ssh: no common algorithm for host key; client offered: " +
+		"[ecdsa-sha2-nistp256], server offered: [ssh-rsa]")
+
+	SetFIPSMode(true)
+	defer SetFIPSMode(false)
+
+	err := FIPSSSHError(hostKeyErr)
+	g.Expect(errors.Is(err, ErrFIPSDisallowed)).To(gomega.BeTrue())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("server offered: [ssh-rsa]"))
+
+	otherErr := errors.New("authentication required")
+	g.Expect(FIPSSSHError(otherErr)).To(gomega.BeIdenticalTo(otherErr))
+	g.Expect(FIPSSSHError(nil)).To(gomega.BeNil())
+}
+
+func TestNewNameHash(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	longName := "a-very-long-subscription-name-that-exceeds-the-maximum-length-of-the-helm-release-names"
+
+	name, err := GetReleaseName(longName)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// the names generated by the previous releases are kept in FIPS mode
+	SetFIPSMode(true)
+	defer SetFIPSMode(false)
+
+	g.Expect(NewNameHash().Size()).To(gomega.Equal(20))
+
+	fipsName, err := GetReleaseName(longName)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(fipsName).To(gomega.Equal(name))
+}
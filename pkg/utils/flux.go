@@ -0,0 +1,76 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// the API groups of the Flux kustomizations, Helm releases and their sources
+var fluxGroups = map[string]bool{
+	"kustomize.toolkit.fluxcd.io": true,
+	"helm.toolkit.fluxcd.io":      true,
+	"source.toolkit.fluxcd.io":    true,
+}
+
+// IsFluxResource returns true if the resource is a Flux custom resource
+func IsFluxResource(gvk schema.GroupVersionKind) bool {
+	return fluxGroups[gvk.Group]
+}
+
+// IsFluxPassthrough returns true if the subscription deploys the Flux custom resources unmodified
+func IsFluxPassthrough(sub *appv1.Subscription) bool {
+	return strings.EqualFold(sub.GetAnnotations()[appv1.AnnotationFluxPassthrough], "true")
+}
+
+// IsFluxHealthCheck returns true if the readiness of the Flux custom resources is reported in the subscription
+// status. It only applies to the passthrough subscriptions.
+func IsFluxHealthCheck(sub *appv1.Subscription) bool {
+	return IsFluxPassthrough(sub) && strings.EqualFold(sub.GetAnnotations()[appv1.AnnotationFluxHealthCheck], "true")
+}
+
+// GetFluxNotReadyError returns an error if the Ready condition of the Flux custom resource is False for its current
+// generation. The resources being reconciled by Flux, or without a Ready condition yet, are not reported.
+func GetFluxNotReadyError(obj *unstructured.Unstructured) error {
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil || !found || observedGeneration != obj.GetGeneration() {
+		return nil
+	}
+
+	conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return nil
+	}
+
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+
+		if condition["status"] != "False" {
+			return nil
+		}
+
+		return fmt.Errorf("not ready, %v: %v", condition["reason"], condition["message"])
+	}
+
+	return nil
+}
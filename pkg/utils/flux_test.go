@@ -0,0 +1,86 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestFluxPassthrough(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(IsFluxResource(schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"})).
+		To(gomega.BeTrue())
+	g.Expect(IsFluxResource(schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"})).
+		To(gomega.BeTrue())
+	g.Expect(IsFluxResource(schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Version: "v1", Kind: "HelmRelease"})).
+		To(gomega.BeFalse())
+
+	sub := &appv1.Subscription{}
+	g.Expect(IsFluxPassthrough(sub)).To(gomega.BeFalse())
+
+	// the health check requires the passthrough
+	sub.SetAnnotations(map[string]string{appv1.AnnotationFluxHealthCheck: "true"})
+	g.Expect(IsFluxHealthCheck(sub)).To(gomega.BeFalse())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationFluxPassthrough: "true", appv1.AnnotationFluxHealthCheck: "true"})
+	g.Expect(IsFluxPassthrough(sub)).To(gomega.BeTrue())
+	g.Expect(IsFluxHealthCheck(sub)).To(gomega.BeTrue())
+}
+
+func TestGetFluxNotReadyError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newKustomization := func(observedGeneration int64, status string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "kustomize.toolkit.fluxcd.io/v1",
+			"kind":       "Kustomization",
+			"metadata":   map[string]interface{}{"name": "app", "generation": int64(2)},
+			"status": map[string]interface{}{
+				"observedGeneration": observedGeneration,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Reconciling", "status": "False"},
+					map[string]interface{}{
+						"type":               "Ready",
+						"status":             status,
+						"reason":             "BuildFailed",
+						"message":            "kustomization path not found",
+						"lastTransitionTime": metav1.Now().UTC().Format("2006-01-02T15:04:05Z"),
+					},
+				},
+			},
+		}}
+
+		return obj
+	}
+
+	g.Expect(GetFluxNotReadyError(newKustomization(2, "True"))).To(gomega.Succeed())
+
+	err := GetFluxNotReadyError(newKustomization(2, "False"))
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.Equal("not ready, BuildFailed: kustomization path not found"))
+
+	// the condition of a previous generation is not reported
+	g.Expect(GetFluxNotReadyError(newKustomization(1, "False"))).To(gomega.Succeed())
+
+	// nor the resources without status
+	g.Expect(GetFluxNotReadyError(&unstructured.Unstructured{Object: map[string]interface{}{}})).To(gomega.Succeed())
+}
@@ -0,0 +1,486 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/sideband"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/go-git/go-git/v5/utils/ioutil"
+	"k8s.io/klog"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// ErrPartialCloneUnsupported is returned when the Git server or the subscription doesn't support the partial clone
+var ErrPartialCloneUnsupported = errors.New("partial clone is not supported")
+
+// gitTreeFile is a file of a Git tree, its blob is not necessarily fetched
+type gitTreeFile struct {
+	path string
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+// partialCloneGitRepo clones the commit of the repository without its blobs, like git clone --filter=blob:none, then
+// fetches the blobs of the files under the paths of the clone options only, and writes them in the destination
+// directory. It returns the cloned commit ID.
+func partialCloneGitRepo(cloneOptions *GitCloneOption, options *git.CloneOptions) (string, error) {
+	if IsPullRequestRef(cloneOptions.Branch) {
+		return "", fmt.Errorf("%w for the pull request reference %s", ErrPartialCloneUnsupported, cloneOptions.Branch)
+	}
+
+	ep, err := transport.NewEndpoint(options.URL)
+	if err != nil {
+		return "", err
+	}
+
+	ep.InsecureSkipTLS = options.InsecureSkipTLS
+	ep.CaBundle = options.CABundle
+	ep.ClientCert = options.ClientCert
+	ep.ClientKey = options.ClientKey
+	ep.Proxy = options.ProxyOptions
+
+	s := memory.NewStorage()
+
+	commitHash, err := fetchPartialClonePack(ep, options.Auth, s, func(ar *packp.AdvRefs) ([]plumbing.Hash, error) {
+		hash, err := resolvePartialCloneCommit(cloneOptions, ar)
+		if err != nil {
+			return nil, err
+		}
+
+		return []plumbing.Hash{hash}, nil
+	}, true)
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := object.GetCommit(s, commitHash)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := listGitTreeFiles(s, commit.TreeHash)
+	if err != nil {
+		return "", err
+	}
+
+	selected := selectGitTreeFiles(files, cloneOptions.Paths)
+
+	requested := map[plumbing.Hash]bool{}
+
+	// fetch the blobs of the selected files, then the blobs of the files they reference until there is none left
+	for wants := missingBlobs(s, selected); len(wants) > 0; wants = missingBlobs(s, selected) {
+		for _, want := range wants {
+			if requested[want] {
+				return "", fmt.Errorf("the Git server did not send the blob %s", want)
+			}
+
+			requested[want] = true
+		}
+
+		klog.Infof("Fetching %d blobs of the partial clone of %s", len(wants), RedactString(options.URL))
+
+		if _, err := fetchPartialClonePack(ep, options.Auth, s, func(*packp.AdvRefs) ([]plumbing.Hash, error) {
+			return wants, nil
+		}, false); err != nil {
+			return "", err
+		}
+
+		for _, ref := range referencedGitTreeFiles(s, files, selected) {
+			selected[ref.path] = ref
+		}
+	}
+
+	fSys := cloneOptions.FileSystem
+	if fSys == nil {
+		fSys = filesys.MakeFsOnDisk()
+	}
+
+	if err := writeGitTreeFiles(s, fSys, cloneOptions.DestDir, files, selected); err != nil {
+		// leave an empty destination directory for the full clone
+		if err := fSys.RemoveAll(cloneOptions.DestDir); err != nil {
+			klog.Warning(err, "Failed to remove directory ", cloneOptions.DestDir)
+		}
+
+		if err := fSys.MkdirAll(cloneOptions.DestDir); err != nil {
+			klog.Warning(err, "Failed to create directory ", cloneOptions.DestDir)
+		}
+
+		return "", err
+	}
+
+	klog.Infof("Successfully partially cloned %d of the %d files of the commit %s", len(selected), len(files), commitHash)
+
+	return commitHash.String(), nil
+}
+
+// fetchPartialClonePack opens an upload pack session, requests the objects returned by the wants function from the
+// advertised references and stores the received pack. It returns the first wanted object. The commit fetch is shallow
+// and filters out all the blobs.
+func fetchPartialClonePack(ep *transport.Endpoint, auth transport.AuthMethod, s storer.Storer,
+	wants func(*packp.AdvRefs) ([]plumbing.Hash, error), filterBlobs bool) (hash plumbing.Hash, err error) {
+	client, err := gitclient.NewClient(ep)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	session, err := client.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return plumbing.ZeroHash, sshCloneError(err)
+	}
+
+	defer ioutil.CheckClose(session, &err)
+
+	ar, err := session.AdvertisedReferences()
+	if err != nil {
+		return plumbing.ZeroHash, sshCloneError(err)
+	}
+
+	if !ar.Capabilities.Supports(capability.Filter) {
+		return plumbing.ZeroHash, fmt.Errorf("%w by the Git server", ErrPartialCloneUnsupported)
+	}
+
+	req := packp.NewUploadPackRequestFromCapabilities(ar.Capabilities)
+
+	if req.Wants, err = wants(ar); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if filterBlobs {
+		req.Depth = packp.DepthCommits(1)
+		req.Filter = packp.FilterBlobNone()
+
+		if err := req.Capabilities.Set(capability.Shallow); err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if err := req.Capabilities.Set(capability.Filter); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	if ar.Capabilities.Supports(capability.NoProgress) {
+		if err := req.Capabilities.Set(capability.NoProgress); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	reader, err := session.UploadPack(context.TODO(), req)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	defer ioutil.CheckClose(reader, &err)
+
+	var pack io.Reader = reader
+
+	switch {
+	case req.Capabilities.Supports(capability.Sideband64k):
+		pack = sideband.NewDemuxer(sideband.Sideband64k, reader)
+	case req.Capabilities.Supports(capability.Sideband):
+		pack = sideband.NewDemuxer(sideband.Sideband, reader)
+	}
+
+	if err := packfile.UpdateObjectStorage(s, pack); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return req.Wants[0], nil
+}
+
+// resolvePartialCloneCommit returns the commit to clone from the advertised references. Like the full clone, the
+// commit hash takes precedence over the revision tag, and the revision tag over the branch.
+func resolvePartialCloneCommit(cloneOptions *GitCloneOption, ar *packp.AdvRefs) (plumbing.Hash, error) {
+	if cloneOptions.CommitHash != "" {
+		return plumbing.NewHash(strings.TrimSpace(cloneOptions.CommitHash)), nil
+	}
+
+	if cloneOptions.RevisionTag != "" {
+		tag := "refs/tags/" + cloneOptions.RevisionTag
+
+		// the annotated tags are peeled to their commit
+		if hash, ok := ar.Peeled[tag]; ok {
+			return hash, nil
+		}
+
+		if hash, ok := ar.References[tag]; ok {
+			return hash, nil
+		}
+
+		return plumbing.ZeroHash, errors.New("failed to resolve revision tag " + cloneOptions.RevisionTag)
+	}
+
+	if cloneOptions.Branch != "" {
+		if hash, ok := ar.References[cloneOptions.Branch.String()]; ok {
+			return hash, nil
+		}
+
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve branch %s", cloneOptions.Branch)
+	}
+
+	if ar.Head == nil {
+		return plumbing.ZeroHash, errors.New("failed to resolve the HEAD of the repository")
+	}
+
+	return *ar.Head, nil
+}
+
+// listGitTreeFiles lists the files of a tree and of its sub trees, without reading their blobs. The submodules are
+// skipped.
+func listGitTreeFiles(s storer.EncodedObjectStorer, treeHash plumbing.Hash) ([]gitTreeFile, error) {
+	tree, err := object.GetTree(s, treeHash)
+	if err != nil {
+		return nil, err
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	files := []gitTreeFile{}
+
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+			continue
+		}
+
+		files = append(files, gitTreeFile{path: name, hash: entry.Hash, mode: entry.Mode})
+	}
+
+	return files, nil
+}
+
+// selectGitTreeFiles selects the files under the paths, all the files if there is no path
+func selectGitTreeFiles(files []gitTreeFile, paths []string) map[string]gitTreeFile {
+	selected := map[string]gitTreeFile{}
+
+	for _, file := range files {
+		if len(paths) == 0 {
+			selected[file.path] = file
+
+			continue
+		}
+
+		for _, p := range paths {
+			if isUnderGitTreePath(file.path, p) {
+				selected[file.path] = file
+
+				break
+			}
+		}
+	}
+
+	return selected
+}
+
+// isUnderGitTreePath returns true if the file is the path or is in the path directory
+func isUnderGitTreePath(file, dir string) bool {
+	dir = path.Clean(strings.Trim(dir, "/"))
+
+	return dir == "." || file == dir || strings.HasPrefix(file, dir+"/")
+}
+
+// missingBlobs returns the blobs of the selected files missing in the storage
+func missingBlobs(s storer.EncodedObjectStorer, selected map[string]gitTreeFile) []plumbing.Hash {
+	wants := []plumbing.Hash{}
+	seen := map[plumbing.Hash]bool{}
+
+	for _, file := range selected {
+		if seen[file.hash] {
+			continue
+		}
+
+		seen[file.hash] = true
+
+		if s.HasEncodedObject(file.hash) != nil {
+			wants = append(wants, file.hash)
+		}
+	}
+
+	sort.Slice(wants, func(i, j int) bool { return wants[i].String() < wants[j].String() })
+
+	return wants
+}
+
+// referencedGitTreeFiles returns the files out of the selection referenced by the selected files: the local
+// resources, bases, components, patches and generator files of the kustomizations, and the targets of the symbolic
+// links.
+func referencedGitTreeFiles(s storer.EncodedObjectStorer, files []gitTreeFile,
+	selected map[string]gitTreeFile) []gitTreeFile {
+	refs := []string{}
+
+	for _, file := range selected {
+		switch {
+		case file.mode == filemode.Symlink:
+			target, err := readGitBlob(s, file.hash)
+			if err != nil {
+				continue
+			}
+
+			refs = append(refs, path.Join(path.Dir(file.path), string(target)))
+		case isKustomizationFileName(path.Base(file.path)):
+			content, err := readGitBlob(s, file.hash)
+			if err != nil {
+				continue
+			}
+
+			var kustomization interface{}
+
+			if err := yaml.Unmarshal(content, &kustomization); err != nil {
+				klog.Warningf("Failed to parse %s, err: %v", file.path, err)
+
+				continue
+			}
+
+			for _, value := range yamlStringValues(kustomization) {
+				refs = append(refs, path.Join(path.Dir(file.path), value))
+			}
+		}
+	}
+
+	referenced := []gitTreeFile{}
+
+	for _, ref := range refs {
+		if ref == ".." || strings.HasPrefix(ref, "../") || path.IsAbs(ref) {
+			continue
+		}
+
+		for _, file := range files {
+			if _, ok := selected[file.path]; !ok && isUnderGitTreePath(file.path, ref) {
+				referenced = append(referenced, file)
+			}
+		}
+	}
+
+	return referenced
+}
+
+// isKustomizationFileName returns true for the file names of the kustomizations
+func isKustomizationFileName(name string) bool {
+	return name == "kustomization.yaml" || name == "kustomization.yml" || name == "Kustomization"
+}
+
+// yamlStringValues returns the string values of a YAML document, recursively
+func yamlStringValues(doc interface{}) []string {
+	values := []string{}
+
+	switch v := doc.(type) {
+	case string:
+		values = append(values, v)
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, yamlStringValues(item)...)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			values = append(values, yamlStringValues(item)...)
+		}
+	}
+
+	return values
+}
+
+// readGitBlob reads the content of a blob of the storage
+func readGitBlob(s storer.EncodedObjectStorer, hash plumbing.Hash) ([]byte, error) {
+	blob, err := object.GetBlob(s, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// writeGitTreeFiles writes the selected files in the destination directory of the file system. The symbolic links to
+// the selected files are written as files, the other symbolic links are skipped.
+func writeGitTreeFiles(s storer.EncodedObjectStorer, fSys filesys.FileSystem, destDir string, files []gitTreeFile,
+	selected map[string]gitTreeFile) error {
+	if err := fSys.MkdirAll(destDir); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if _, ok := selected[file.path]; !ok {
+			continue
+		}
+
+		hash := file.hash
+
+		if file.mode == filemode.Symlink {
+			target, err := readGitBlob(s, file.hash)
+			if err != nil {
+				return err
+			}
+
+			targetFile, ok := selected[path.Join(path.Dir(file.path), string(target))]
+			if !ok || targetFile.mode == filemode.Symlink {
+				klog.V(4).Info("Skipping the symbolic link ", file.path)
+
+				continue
+			}
+
+			hash = targetFile.hash
+		}
+
+		content, err := readGitBlob(s, hash)
+		if err != nil {
+			return err
+		}
+
+		target := path.Join(destDir, file.path)
+
+		if err := fSys.MkdirAll(path.Dir(target)); err != nil {
+			return err
+		}
+
+		if err := fSys.WriteFile(target, content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	billyutil "github.com/go-git/go-billy/v5/util"
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/onsi/gomega"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+func TestPartialCloneFileSelection(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	files := map[string]string{
+		"apps/dev/kustomization.yaml": "resources:\n- ../../base\npatches:\n- path: patch.yaml\n",
+		"apps/dev/patch.yaml":         "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+		"apps/prod/configmap.yaml":    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: prod\n",
+		"base/kustomization.yaml":     "resources:\n- configmap.yaml\n- https://github.com/example/repo//config\n",
+		"base/configmap.yaml":         "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+		"assets/large.bin":            "binary",
+		"shared/secret.yaml":          "apiVersion: v1\nkind: Secret\nmetadata:\n  name: shared\n",
+	}
+
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	workTree, err := repo.Worktree()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for name, content := range files {
+		g.Expect(billyutil.WriteFile(workTree.Filesystem, name, []byte(content), 0600)).To(gomega.Succeed())
+	}
+
+	g.Expect(workTree.Filesystem.Symlink("../../shared/secret.yaml", "apps/dev/secret.yaml")).To(gomega.Succeed())
+
+	_, err = workTree.Add(".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	commitHash, err := workTree.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	commit, err := repo.CommitObject(commitHash)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	treeFiles, err := listGitTreeFiles(repo.Storer, commit.TreeHash)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(treeFiles).To(gomega.HaveLen(len(files) + 1))
+
+	// all the files are selected without a path
+	g.Expect(selectGitTreeFiles(treeFiles, nil)).To(gomega.HaveLen(len(files) + 1))
+
+	selected := selectGitTreeFiles(treeFiles, []string{"/apps/dev/"})
+	g.Expect(selected).To(gomega.HaveKey("apps/dev/kustomization.yaml"))
+	g.Expect(selected).To(gomega.HaveKey("apps/dev/patch.yaml"))
+	g.Expect(selected).To(gomega.HaveKey("apps/dev/secret.yaml"))
+	g.Expect(selected).To(gomega.HaveLen(3))
+
+	// the kustomize bases and the symbolic link targets are selected, the remote resources are ignored
+	for refs := referencedGitTreeFiles(repo.Storer, treeFiles, selected); len(refs) > 0; refs = referencedGitTreeFiles(
+		repo.Storer, treeFiles, selected) {
+		for _, ref := range refs {
+			selected[ref.path] = ref
+		}
+	}
+
+	g.Expect(selected).To(gomega.HaveKey("base/kustomization.yaml"))
+	g.Expect(selected).To(gomega.HaveKey("base/configmap.yaml"))
+	g.Expect(selected).To(gomega.HaveKey("shared/secret.yaml"))
+	g.Expect(selected).NotTo(gomega.HaveKey("apps/prod/configmap.yaml"))
+	g.Expect(selected).NotTo(gomega.HaveKey("assets/large.bin"))
+	g.Expect(selected).To(gomega.HaveLen(6))
+
+	// all the blobs are in the storage of the test repo
+	g.Expect(missingBlobs(repo.Storer, selected)).To(gomega.BeEmpty())
+	g.Expect(missingBlobs(memory.NewStorage(), selected)).To(gomega.HaveLen(5))
+
+	repoRoot := "/in-memory/default/sub"
+	fSys := filesys.MakeFsInMemory()
+
+	g.Expect(writeGitTreeFiles(repo.Storer, fSys, repoRoot, treeFiles, selected)).To(gomega.Succeed())
+
+	// the symbolic links are written as files
+	secret, err := fSys.ReadFile(repoRoot + "/apps/dev/secret.yaml")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(secret)).To(gomega.Equal(files["shared/secret.yaml"]))
+
+	base, err := fSys.ReadFile(repoRoot + "/base/configmap.yaml")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(base)).To(gomega.Equal(files["base/configmap.yaml"]))
+
+	g.Expect(fSys.Exists(repoRoot + "/assets/large.bin")).To(gomega.BeFalse())
+	g.Expect(fSys.Exists(repoRoot + "/apps/prod")).To(gomega.BeFalse())
+}
+
+func TestResolvePartialCloneCommit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	head := plumbing.NewHash("1111111111111111111111111111111111111111")
+	branch := plumbing.NewHash("2222222222222222222222222222222222222222")
+	tag := plumbing.NewHash("3333333333333333333333333333333333333333")
+	tagCommit := plumbing.NewHash("4444444444444444444444444444444444444444")
+
+	ar := packp.NewAdvRefs()
+	ar.Head = &head
+	ar.References["refs/heads/dev"] = branch
+	ar.References["refs/tags/v1"] = tag
+	ar.Peeled["refs/tags/v1"] = tagCommit
+
+	hash, err := resolvePartialCloneCommit(&GitCloneOption{}, ar)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(hash).To(gomega.Equal(head))
+
+	hash, err = resolvePartialCloneCommit(&GitCloneOption{Branch: "refs/heads/dev"}, ar)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(hash).To(gomega.Equal(branch))
+
+	// the annotated tags are peeled
+	hash, err = resolvePartialCloneCommit(&GitCloneOption{Branch: "refs/heads/dev", RevisionTag: "v1"}, ar)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(hash).To(gomega.Equal(tagCommit))
+
+	hash, err = resolvePartialCloneCommit(&GitCloneOption{RevisionTag: "v1", CommitHash: branch.String()}, ar)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(hash).To(gomega.Equal(branch))
+
+	_, err = resolvePartialCloneCommit(&GitCloneOption{Branch: "refs/heads/missing"}, ar)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = resolvePartialCloneCommit(&GitCloneOption{RevisionTag: "v2"}, ar)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the pull request heads are not partially cloned
+	_, err = partialCloneGitRepo(&GitCloneOption{Branch: "refs/pull/1/head"}, &git.CloneOptions{})
+	g.Expect(errors.Is(err, ErrPartialCloneUnsupported)).To(gomega.BeTrue())
+}
@@ -0,0 +1,178 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"k8s.io/klog"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// pullRequestRefRegexp matches the head references of the GitHub pull requests and of the GitLab merge requests
+var pullRequestRefRegexp = regexp.MustCompile(`^refs/(pull|merge-requests)/([0-9]+)/head$`)
+
+// IsPullRequestRef returns true if the reference is the head of a pull request or of a merge request
+func IsPullRequestRef(ref plumbing.ReferenceName) bool {
+	return pullRequestRefRegexp.MatchString(ref.String())
+}
+
+// GetPullRequestNumber returns the number of the pull request or merge request of a head reference, empty otherwise
+func GetPullRequestNumber(ref plumbing.ReferenceName) string {
+	matches := pullRequestRefRegexp.FindStringSubmatch(ref.String())
+	if matches == nil {
+		return ""
+	}
+
+	return matches[2]
+}
+
+// ListGitRemoteRefs lists the references of a Git repository without cloning it
+func ListGitRemoteRefs(connOption *ChannelConnectionCfg) ([]*plumbing.Reference, error) {
+	// the directory only holds the SSH known hosts file
+	tmpDir, err := os.MkdirTemp("", "git-ls-remote-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	options, err := getConnectionOptions(&GitCloneOption{DestDir: tmpDir}, connOption)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{options.URL},
+	})
+
+	refs, err := remote.List(&git.ListOptions{
+		Auth:            options.Auth,
+		InsecureSkipTLS: options.InsecureSkipTLS,
+		CABundle:        options.CABundle,
+		ClientCert:      options.ClientCert,
+		ClientKey:       options.ClientKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the references of %v%v%w", RedactString(options.URL), Error, sshCloneError(err))
+	}
+
+	return refs, nil
+}
+
+// ListGitDirectories clones the branch of a Git repository in memory, the default branch if it is empty, and lists
+// its directories relative to the root of the repository. The hidden directories, like .github, are skipped. It
+// returns the cloned commit ID.
+func ListGitDirectories(connOption *ChannelConnectionCfg, branch plumbing.ReferenceName) (string, []string, error) {
+	const root = "/repo"
+
+	fSys := filesys.MakeFsInMemory()
+
+	commitID, err := CloneGitRepo(&GitCloneOption{
+		Branch:                  branch,
+		DestDir:                 root,
+		CloneDepth:              1,
+		PrimaryConnectionOption: connOption,
+		FileSystem:              fSys,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	dirs, err := listDirectories(fSys, root)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return commitID, dirs, nil
+}
+
+// listDirectories lists the directories under the root directory of the file system, relative to the root, sorted.
+// The hidden directories are skipped.
+func listDirectories(fSys filesys.FileSystem, root string) ([]string, error) {
+	dirs := []string{}
+
+	err := fSys.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() || path == root {
+			return nil
+		}
+
+		if strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		dirs = append(dirs, strings.TrimPrefix(path, root+"/"))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
+
+// fetchPullRequestRef fetches the head of a pull request into a repository cloned from the default branch, and checks
+// it out. Pull request heads are not branches, the clone can't fetch them.
+func fetchPullRequestRef(repo *git.Repository, options *git.CloneOptions, ref plumbing.ReferenceName) (*plumbing.Reference, error) {
+	klog.Infof("Fetching pull request reference %s", ref)
+
+	err := repo.Fetch(&git.FetchOptions{
+		RefSpecs:        []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", ref, ref))},
+		Depth:           options.Depth,
+		Auth:            options.Auth,
+		Tags:            git.NoTags,
+		InsecureSkipTLS: options.InsecureSkipTLS,
+		CABundle:        options.CABundle,
+		ClientCert:      options.ClientCert,
+		ClientKey:       options.ClientKey,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("failed to fetch %v%v%w", ref, Error, sshCloneError(err))
+	}
+
+	prRef, err := repo.Reference(ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %v%v%w", ref, Error, err)
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := workTree.Checkout(&git.CheckoutOptions{Hash: prRef.Hash()}); err != nil {
+		return nil, fmt.Errorf("failed to checkout %v%v%w", ref, Error, err)
+	}
+
+	return prRef, nil
+}
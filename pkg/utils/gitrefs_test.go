@@ -0,0 +1,114 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/onsi/gomega"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+func TestPullRequestRef(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(IsPullRequestRef("refs/pull/12/head")).To(gomega.BeTrue())
+	g.Expect(IsPullRequestRef("refs/merge-requests/3/head")).To(gomega.BeTrue())
+	g.Expect(IsPullRequestRef("refs/pull/12/merge")).To(gomega.BeFalse())
+	g.Expect(IsPullRequestRef("refs/heads/pull/12/head")).To(gomega.BeFalse())
+
+	g.Expect(GetPullRequestNumber("refs/pull/12/head")).To(gomega.Equal("12"))
+	g.Expect(GetPullRequestNumber("refs/merge-requests/3/head")).To(gomega.Equal("3"))
+	g.Expect(GetPullRequestNumber("refs/heads/main")).To(gomega.BeEmpty())
+
+	// the pull request heads are not branches
+	g.Expect(GetSubscriptionBranchRef("refs/pull/12/head")).To(gomega.Equal(plumbing.ReferenceName("refs/pull/12/head")))
+	g.Expect(GetSubscriptionBranchRef("pull/12/head")).To(gomega.Equal(plumbing.ReferenceName("refs/heads/pull/12/head")))
+}
+
+func TestFetchPullRequestRef(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tmpDir := t.TempDir()
+	originDir := filepath.Join(tmpDir, "origin")
+
+	origin, err := git.PlainInit(originDir, false)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	workTree, err := origin.Worktree()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	commit := func(content string) plumbing.Hash {
+		g.Expect(os.WriteFile(filepath.Join(originDir, "app.yaml"), []byte(content), 0600)).To(gomega.Succeed())
+
+		_, err := workTree.Add("app.yaml")
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		hash, err := workTree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return hash
+	}
+
+	mainHash := commit("main")
+	prHash := commit("pull request")
+
+	// the pull request commit is only reachable from its head reference
+	g.Expect(origin.Storer.SetReference(plumbing.NewHashReference("refs/pull/7/head", prHash))).To(gomega.Succeed())
+	g.Expect(workTree.Reset(&git.ResetOptions{Commit: mainHash, Mode: git.HardReset})).To(gomega.Succeed())
+
+	head, err := origin.Head()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(origin.Storer.SetReference(plumbing.NewHashReference(head.Name(), mainHash))).To(gomega.Succeed())
+
+	options := &git.CloneOptions{URL: originDir, SingleBranch: true}
+
+	repo, err := git.PlainClone(filepath.Join(tmpDir, "clone"), false, options)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ref, err := fetchPullRequestRef(repo, options, "refs/pull/7/head")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ref.Hash()).To(gomega.Equal(prHash))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "clone", "app.yaml"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(content)).To(gomega.Equal("pull request"))
+
+	_, err = fetchPullRequestRef(repo, options, "refs/pull/8/head")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestListDirectories(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	fSys := filesys.MakeFsInMemory()
+
+	for _, file := range []string{"apps/guestbook/app.yaml", "apps/nginx/app.yaml", ".github/workflows/ci.yaml", "README.md"} {
+		g.Expect(fSys.WriteFile(filepath.Join("/repo", file), []byte("content"))).To(gomega.Succeed())
+	}
+
+	// the hidden directories are skipped
+	dirs, err := listDirectories(fSys, "/repo")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(dirs).To(gomega.Equal([]string{"apps", "apps/guestbook", "apps/nginx"}))
+}
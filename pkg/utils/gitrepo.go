@@ -26,13 +26,13 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"time"
 
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/knownhosts"
 	admissionv1 "k8s.io/api/admissionregistration/v1"
@@ -47,9 +47,12 @@ import (
 	gitignore "github.com/sabhiram/go-gitignore"
 
 	"github.com/ghodss/yaml"
+	"github.com/go-git/go-billy/v5/memfs"
+	billyutil "github.com/go-git/go-billy/v5/util"
 	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
@@ -64,9 +67,9 @@ const (
 	SSHKey = "sshKey"
 	// Passphrase is used to open the SSH key
 	Passphrase = "passphrase"
-	// ClientKey is a client private key for connecting to a Git server
+	// ClientKey is a client private key for connecting to a Git server or a Helm repo
 	ClientKey = "clientKey"
-	// ClientCert is a client certificate for connecting to a Git server
+	// ClientCert is a client certificate for connecting to a Git server or a Helm repo
 	ClientCert = "clientCert"
 
 	Error = " err: "
@@ -90,18 +93,34 @@ type GitCloneOption struct {
 	CloneDepth                int
 	PrimaryConnectionOption   *ChannelConnectionCfg
 	SecondaryConnectionOption *ChannelConnectionCfg
+	// FallbackConnectionOptions are tried in order if neither the primary nor the secondary connection can clone the
+	// repository
+	FallbackConnectionOptions []*ChannelConnectionCfg
+	// ServingConnectionOption is set by CloneGitRepo to the connection the repository was cloned with
+	ServingConnectionOption *ChannelConnectionCfg
+	// FileSystem is the file system the repository is cloned in, in the destination directory. The repository is
+	// cloned on the disk if it is nil, in memory otherwise.
+	FileSystem filesys.FileSystem
+	// PartialClone clones the commit without its blobs, then fetches the blobs of the files under Paths only. It falls
+	// back to the full clone if the Git server doesn't support the partial clone.
+	PartialClone bool
+	// Paths are the directories of the repository read by the subscription, the partial clone fetches all the files
+	// if it is empty
+	Paths []string
 }
 
 type ChannelConnectionCfg struct {
-	RepoURL            string
-	User               string
-	Password           string
-	SSHKey             []byte
-	Passphrase         []byte
-	InsecureSkipVerify bool
-	CaCerts            string
-	ClientKey          []byte
-	ClientCert         []byte
+	RepoURL              string
+	User                 string
+	Password             string
+	SSHKey               []byte
+	Passphrase           []byte
+	InsecureSkipVerify   bool
+	CaCerts              string
+	ClientKey            []byte
+	ClientCert           []byte
+	KnownHosts           string
+	TrustScannedHostKeys bool
 }
 
 // ParseKubeResoures parses a YAML content and returns kube resources in byte array from the file
@@ -165,20 +184,8 @@ func getCertChain(certs string) tls.Certificate {
 	return certChain
 }
 
-// A subscription can have secondary channel to use when it cannot connect to the primary channel
-// This builds connectionOptions *git.CloneOptions based on the channel selection
-func getConnectionOptions(cloneOptions *GitCloneOption, primary bool) (connectionOptions *git.CloneOptions, err error) {
-	channelConnOptions := cloneOptions.PrimaryConnectionOption
-
-	if !primary {
-		if cloneOptions.SecondaryConnectionOption == nil {
-			klog.Error("no secondary channel to try")
-			return nil, nil
-		}
-
-		channelConnOptions = cloneOptions.SecondaryConnectionOption
-	}
-
+// getConnectionOptions builds connectionOptions *git.CloneOptions with the connection of a channel
+func getConnectionOptions(cloneOptions *GitCloneOption, channelConnOptions *ChannelConnectionCfg) (connectionOptions *git.CloneOptions, err error) {
 	options := &git.CloneOptions{
 		URL:               channelConnOptions.RepoURL,
 		SingleBranch:      true,
@@ -186,17 +193,19 @@ func getConnectionOptions(cloneOptions *GitCloneOption, primary bool) (connectio
 		ReferenceName:     cloneOptions.Branch,
 	}
 
-	// The destination directory needs to be created here
-	err = os.RemoveAll(cloneOptions.DestDir)
+	// The destination directory needs to be created here, the in-memory clones are copied in the file system later
+	if cloneOptions.FileSystem == nil {
+		err = os.RemoveAll(cloneOptions.DestDir)
 
-	if err != nil {
-		klog.Warning(err, "Failed to remove directory ", cloneOptions.DestDir)
-	}
+		if err != nil {
+			klog.Warning(err, "Failed to remove directory ", cloneOptions.DestDir)
+		}
 
-	err = os.MkdirAll(cloneOptions.DestDir, os.ModePerm) // #nosec G301
+		err = os.MkdirAll(cloneOptions.DestDir, os.ModePerm) // #nosec G301
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// If branch name is provided, clone the specified branch only.
@@ -205,6 +214,11 @@ func getConnectionOptions(cloneOptions *GitCloneOption, primary bool) (connectio
 		options.SingleBranch = true
 	}
 
+	// The head of a pull request is fetched after cloning the default branch
+	if IsPullRequestRef(cloneOptions.Branch) {
+		options.ReferenceName = ""
+	}
+
 	if strings.HasPrefix(options.URL, "http") {
 		klog.Info("Connecting to Git server via HTTP")
 
@@ -223,17 +237,12 @@ func getConnectionOptions(cloneOptions *GitCloneOption, primary bool) (connectio
 	} else {
 		klog.Info("Connecting to Git server via SSH")
 
-		knownhostsfile := filepath.Join(cloneOptions.DestDir, "known_hosts")
-
-		if !channelConnOptions.InsecureSkipVerify {
-			err := getKnownHostFromURL(channelConnOptions.RepoURL, knownhostsfile)
-
-			if err != nil {
-				return nil, err
-			}
+		hostKeyCallback, err := getSSHHostKeyCallback(cloneOptions, channelConnOptions)
+		if err != nil {
+			return nil, err
 		}
 
-		err = getSSHOptions(options, channelConnOptions.SSHKey, channelConnOptions.Passphrase, knownhostsfile, channelConnOptions.InsecureSkipVerify)
+		err = getSSHOptions(options, channelConnOptions.SSHKey, channelConnOptions.Passphrase, hostKeyCallback)
 		if err != nil {
 			klog.Error(err, " failed to prepare SSH clone options")
 			return nil, err
@@ -255,84 +264,118 @@ func getConnectionOptions(cloneOptions *GitCloneOption, primary bool) (connectio
 	return options, nil
 }
 
-// CloneGitRepo clones a GitHub repository
+// CloneGitRepo clones a GitHub repository with the first connection of the primary, secondary and fallback channels
+// that succeeds. The connections that failed recently are tried last, see ChannelFailbackInterval.
 func CloneGitRepo(cloneOptions *GitCloneOption) (commitID string, err error) {
-	usingPrimary := true
-
-	options, err := getConnectionOptions(cloneOptions, true)
-
-	if err != nil {
-		klog.Errorf("Failed to get Git clone options with the primary channel. Trying the secondary channel. err: %v", err)
+	cloneOptions.ServingConnectionOption = nil
 
-		usingPrimary = false
+	connections := gitChannelHealth.order(cloneOptions.connectionOptions())
+	if len(connections) == 0 {
+		return "", errors.New("no git connection options")
 	}
 
-	secondaryOptions, err := getConnectionOptions(cloneOptions, false)
+	klog.Info("cloneOptions.DestDir = " + cloneOptions.DestDir)
+	klog.Info("cloneOptions.Branch = " + cloneOptions.Branch)
+	klog.Info("cloneOptions.CommitHash = " + cloneOptions.CommitHash)
+	klog.Info("cloneOptions.RevisionTag = " + cloneOptions.RevisionTag)
+	klog.Infof("cloneOptions.CloneDepth = %d", cloneOptions.CloneDepth)
 
-	if err != nil {
-		if !usingPrimary {
-			// we could not get both primary and secondary Git connection options. return error
-			klog.Errorf("Failed to get Git clone options with the secondary channel. err: %v", err)
-			return "", err
+	var connErr error
+
+	for i, conn := range connections {
+		if i > 0 {
+			klog.Infof("Trying to clone with the next channel %s", RedactString(conn.RepoURL))
 		}
 
-		klog.Warningf("Failed to get Git clone options with the secondary channel. err: %v", err)
-	}
+		commitID, connected, err := cloneGitRepoWithConnection(cloneOptions, conn)
+		if connected {
+			gitChannelHealth.recordSuccess(conn.RepoURL)
+
+			if err == nil {
+				cloneOptions.ServingConnectionOption = conn
+			}
 
-	// we could not get the connection options with the primary channel but we got it with the secondary channel. Use it instead
-	if !usingPrimary {
-		if secondaryOptions == nil {
-			// if trying the secondary connection option but nothing there, return error
-			// at this point, we have no Git connection options
-			klog.Error("failed to build secondary git connection options")
-			return "", errors.New("failed to build secondary git connection options")
+			return commitID, err
 		}
 
-		options = secondaryOptions
-	}
+		gitChannelHealth.recordFailure(conn.RepoURL)
 
-	klog.Info("Cloning ", options.URL, " into ", cloneOptions.DestDir)
+		klog.Errorf("Failed to clone git with the channel %s. err: %v", RedactString(conn.RepoURL), err)
 
-	klog.Info("cloneOptions.DestDir = " + cloneOptions.DestDir)
-	klog.Info("cloneOptions.Branch = " + cloneOptions.Branch)
-	klog.Info("cloneOptions.CommitHash = " + cloneOptions.CommitHash)
-	klog.Info("cloneOptions.RevisionTag = " + cloneOptions.RevisionTag)
-	klog.Infof("cloneOptions.CloneDepth = %d", cloneOptions.CloneDepth)
+		// report the error of the first channel tried, like a credential disallowed in FIPS mode
+		if connErr == nil {
+			connErr = err
+		}
+	}
 
-	repo, err := git.PlainClone(cloneOptions.DestDir, false, options)
+	return "", connErr
+}
 
+// cloneGitRepoWithConnection clones the repository with a channel connection. connected is false if the repository
+// could not be cloned with the connection, then the next channel connection can be tried.
+func cloneGitRepoWithConnection(cloneOptions *GitCloneOption, conn *ChannelConnectionCfg) (commitID string,
+	connected bool, err error) {
+	options, err := getConnectionOptions(cloneOptions, conn)
 	if err != nil {
-		if usingPrimary {
-			klog.Error(err, " Failed to git clone with the primary channel: ", err.Error())
+		klog.Errorf("Failed to get Git clone options. err: %v", err)
 
-			if secondaryOptions == nil {
-				return "", errors.New("Failed to clone git: " + options.URL + Error + err.Error())
-			}
+		return "", false, err
+	}
 
-			klog.Info("Trying to clone with the secondary channel")
-			klog.Info("Cloning ", secondaryOptions.URL, " into ", cloneOptions.DestDir)
+	klog.Info("Cloning ", RedactString(options.URL), " into ", cloneOptions.DestDir)
 
-			repo, err = git.PlainClone(cloneOptions.DestDir, false, secondaryOptions)
+	if cloneOptions.PartialClone {
+		commitID, err := partialCloneGitRepo(cloneOptions, options)
+		if err == nil {
+			return commitID, true, nil
+		}
 
-			if err != nil {
-				klog.Error("Failed to clone Git with the secondary channel." + Error + err.Error())
+		klog.Warningf("Failed to partially clone %s, falling back to the full clone. err: %v", RedactString(options.URL), err)
+	}
 
-				return "", errors.New("Failed to clone git: " + secondaryOptions.URL + " branch: " + cloneOptions.Branch.String() + Error + err.Error())
-			}
-		} else {
-			klog.Errorf("failed to clone secondary git channel. err: %v", err)
-			return "", errors.New("Failed to clone git: " + options.URL + " branch: " + cloneOptions.Branch.String() + Error + err.Error())
-		}
+	repo, err := gitClone(cloneOptions, options)
+	if err != nil {
+		return "", false, fmt.Errorf("Failed to clone git: %v branch: %v%v%w", RedactString(options.URL),
+			cloneOptions.Branch.String(), Error, sshCloneError(err))
 	}
 
+	commitID, err = checkoutGitRepo(cloneOptions, repo, options)
+
+	return commitID, true, err
+}
+
+// checkoutGitRepo checks out the target commit of the cloned repository and returns its commit ID
+func checkoutGitRepo(cloneOptions *GitCloneOption, repo *git.Repository, options *git.CloneOptions) (commitID string,
+	err error) {
 	ref, err := repo.Head()
 	if err != nil {
 		klog.Error(err, " Failed to get git repo head")
 		return "", errors.New("failed to get git repo head," + Error + err.Error())
 	}
 
+	if IsPullRequestRef(cloneOptions.Branch) {
+		ref, err = fetchPullRequestRef(repo, options, cloneOptions.Branch)
+		if err != nil {
+			klog.Error(err, " Failed to fetch the pull request")
+			return "", err
+		}
+	}
+
 	klog.Infof("Successfully cloned the repo and the current branch is %s", ref.Name().Short())
 
+	// the in-memory clone is copied in the file system once the commit is checked out
+	if cloneOptions.FileSystem != nil {
+		defer func() {
+			if err == nil {
+				err = copyGitWorktree(repo, cloneOptions.FileSystem, cloneOptions.DestDir)
+			}
+
+			if err != nil {
+				commitID = ""
+			}
+		}()
+	}
+
 	// If both commitHash and revisionTag are provided, take commitHash.
 	targetCommit := cloneOptions.CommitHash
 
@@ -388,6 +431,76 @@ func CloneGitRepo(cloneOptions *GitCloneOption) (commitID string, err error) {
 }
 
 func getKnownHostFromURL(sshURL string, filepath string) error {
+	stdout, err := scanKnownHostFromURL(sshURL)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath, stdout, 0600); err != nil {
+		klog.Error("failed to write known_hosts file: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// gitClone clones the repository in the destination directory, or in memory if the clone has a file system
+func gitClone(cloneOptions *GitCloneOption, options *git.CloneOptions) (*git.Repository, error) {
+	if cloneOptions.FileSystem == nil {
+		return git.PlainClone(cloneOptions.DestDir, false, options)
+	}
+
+	return git.Clone(memory.NewStorage(), memfs.New(), options)
+}
+
+// copyGitWorktree copies the files of the work tree of an in-memory clone in the destination directory of the file
+// system. The symbolic links to files are copied as files, the symbolic links to directories are skipped.
+func copyGitWorktree(repo *git.Repository, fSys filesys.FileSystem, destDir string) error {
+	workTree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if err := fSys.RemoveAll(destDir); err != nil {
+		klog.Warning(err, "Failed to remove directory ", destDir)
+	}
+
+	if err := fSys.MkdirAll(destDir); err != nil {
+		return err
+	}
+
+	wfs := workTree.Filesystem
+
+	return billyutil.Walk(wfs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(destDir, path)
+
+		if info.IsDir() {
+			return fSys.MkdirAll(target)
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err := wfs.Stat(path); err != nil || info.IsDir() {
+				klog.V(4).Info("Skipping the symbolic link ", path)
+
+				return nil
+			}
+		}
+
+		bs, err := billyutil.ReadFile(wfs, path)
+		if err != nil {
+			return err
+		}
+
+		return fSys.WriteFile(target, bs)
+	})
+}
+
+// scanKnownHostFromURL returns the SSH host keys scanned from the Git server of the URL in the known hosts format
+func scanKnownHostFromURL(sshURL string) ([]byte, error) {
 	sshhostname := ""
 	sshhostport := ""
 
@@ -396,7 +509,7 @@ func getKnownHostFromURL(sshURL string, filepath string) error {
 
 		if err != nil {
 			klog.Error("failed toparse SSH URL: ", err)
-			return err
+			return nil, err
 		}
 
 		sshhostname = u.Hostname()
@@ -410,14 +523,7 @@ func getKnownHostFromURL(sshURL string, filepath string) error {
 
 	klog.Info("Getting public SSH host key for " + sshhostname)
 
-	cmd := exec.Command("ssh-keyscan", sshhostname) // #nosec G204 the variable is generated within this function.
-
-	if sshhostport != "" {
-		cmd = exec.Command("ssh-keyscan", "-p", sshhostport, sshhostname) // #nosec G204 the variable is generated within this function.
-		klog.Infof("Running command ssh-keyscan -p %s %s", sshhostport, sshhostname)
-	}
-
-	stdout, err := cmd.Output()
+	stdout, err := ScanSSHHostKeys(sshhostname, sshhostport)
 
 	if err != nil {
 		klog.Error("failed to get public SSH host key: ", err)
@@ -425,15 +531,45 @@ func getKnownHostFromURL(sshURL string, filepath string) error {
 
 	klog.Info("SSH host key: " + string(stdout))
 
-	if err := os.WriteFile(filepath, stdout, 0600); err != nil {
-		klog.Error("failed to write known_hosts file: ", err)
-		return err
+	return stdout, nil
+}
+
+// getSSHHostKeyCallback returns the callback verifying the SSH host key of the Git server of the channel connection. The
+// known hosts are written in the destination directory, or kept in memory if the repository is cloned in memory.
+func getSSHHostKeyCallback(cloneOptions *GitCloneOption, conn *ChannelConnectionCfg) (ssh.HostKeyCallback, error) {
+	if conn.InsecureSkipVerify {
+		klog.Info("Insecure ignore SSH host key")
+
+		return ssh.InsecureIgnoreHostKey(), nil // #nosec G106 this is optional and used only if users specify it in channel configuration
 	}
 
-	return nil
+	klog.Info("Using SSH known host keys")
+
+	if cloneOptions.FileSystem != nil {
+		knownHosts, err := GetSSHKnownHosts(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		return knownHostsCallback(knownHosts)
+	}
+
+	knownhostsfile := filepath.Join(cloneOptions.DestDir, "known_hosts")
+
+	if err := WriteSSHKnownHosts(conn, knownhostsfile); err != nil {
+		return nil, err
+	}
+
+	callback, err := knownhosts.New(knownhostsfile)
+	if err != nil {
+		klog.Error("failed to get knownhosts ", err)
+		return nil, err
+	}
+
+	return callback, nil
 }
 
-func getSSHOptions(options *git.CloneOptions, sshKey, passphrase []byte, knownhostsfile string, insecureSkipVerify bool) error {
+func getSSHOptions(options *git.CloneOptions, sshKey, passphrase []byte, hostKeyCallback ssh.HostKeyCallback) error {
 	publicKey := &gitssh.PublicKeys{}
 	publicKey.User = "git"
 
@@ -457,20 +593,10 @@ func getSSHOptions(options *git.CloneOptions, sshKey, passphrase []byte, knownho
 		publicKey.Signer = signer
 	}
 
-	if insecureSkipVerify {
-		klog.Info("Insecure ignore SSH host key")
-
-		publicKey.HostKeyCallback = ssh.InsecureIgnoreHostKey() // #nosec G106 this is optional and used only if users specify it in channel configuration
-	} else {
-		klog.Info("Using SSH known host keys")
-		callback, err := knownhosts.New(knownhostsfile)
-
-		if err != nil {
-			klog.Error("failed to get knownhosts ", err)
-			return err
-		}
+	publicKey.HostKeyCallback = hostKeyCallback
 
-		publicKey.HostKeyCallback = callback
+	if err := SetFIPSSSHAuth(publicKey); err != nil {
+		return err
 	}
 
 	options.Auth = publicKey
@@ -478,6 +604,11 @@ func getSSHOptions(options *git.CloneOptions, sshKey, passphrase []byte, knownho
 	return nil
 }
 
+// sshCloneError returns the clone error with a clear message if the SSH host key verification failed
+func sshCloneError(err error) error {
+	return FIPSSSHError(SSHHostKeyError(err))
+}
+
 func getHTTPOptions(options *git.CloneOptions, user, password, caCerts string, insecureSkipVerify bool, clientkey, clientcert []byte) error {
 	if user != "" && password != "" {
 		options.Auth = &githttp.BasicAuth{
@@ -486,10 +617,11 @@ func getHTTPOptions(options *git.CloneOptions, user, password, caCerts string, i
 		}
 	}
 
-	installProtocol := false
+	// the default transport doesn't restrict the cipher suites in FIPS mode
+	installProtocol := IsFIPSMode()
 
 	// #nosec G402 -- TLS 1.2 is required for FIPS
-	clientConfig := &tls.Config{MinVersion: appv1.TLSMinVersionInt}
+	clientConfig := SetFIPSTLSConfig(&tls.Config{MinVersion: appv1.TLSMinVersionInt})
 
 	// skip TLS certificate verification for Git servers with custom or self-signed certs
 	if insecureSkipVerify {
@@ -591,19 +723,64 @@ func getHTTPOptions(options *git.CloneOptions, user, password, caCerts string, i
 
 // GetSubscriptionBranch returns GitHub repo branch for a given subscription
 func GetSubscriptionBranch(sub *appv1.Subscription) plumbing.ReferenceName {
-	annotations := sub.GetAnnotations()
-	branchStr := annotations[appv1.AnnotationGitBranch]
+	return GetSubscriptionBranchRef(GetGitBranchAnnotation(sub.GetAnnotations()))
+}
 
-	if branchStr == "" {
-		branchStr = annotations[appv1.AnnotationGithubBranch] // AnnotationGithubBranch will be depricated
+// GetGitBranchAnnotation returns the git branch of a subscription. The git-branch annotation takes
+// precedence over the deprecated github-branch annotation.
+func GetGitBranchAnnotation(annotations map[string]string) string {
+	if annotations[appv1.AnnotationGitBranch] != "" {
+		return annotations[appv1.AnnotationGitBranch]
 	}
 
-	return GetSubscriptionBranchRef(branchStr)
+	return annotations[appv1.AnnotationGithubBranch]
+}
+
+// GetGitPathAnnotation returns the git path of a subscription. The git-path annotation takes
+// precedence over the deprecated github-path annotation.
+func GetGitPathAnnotation(annotations map[string]string) string {
+	if annotations[appv1.AnnotationGitPath] != "" {
+		return annotations[appv1.AnnotationGitPath]
+	}
+
+	return annotations[appv1.AnnotationGithubPath]
+}
+
+// NormalizeGitAnnotations moves the deprecated github-path and github-branch annotations to
+// git-path and git-branch. It returns true if the annotations are changed.
+func NormalizeGitAnnotations(annotations map[string]string) bool {
+	if annotations == nil {
+		return false
+	}
+
+	updated := false
+
+	legacyKeys := map[string]string{
+		appv1.AnnotationGithubPath:   appv1.AnnotationGitPath,
+		appv1.AnnotationGithubBranch: appv1.AnnotationGitBranch,
+	}
+
+	for legacyKey, key := range legacyKeys {
+		legacyValue, ok := annotations[legacyKey]
+		if !ok {
+			continue
+		}
+
+		if annotations[key] == "" && legacyValue != "" {
+			annotations[key] = legacyValue
+		}
+
+		delete(annotations, legacyKey)
+
+		updated = true
+	}
+
+	return updated
 }
 
 func GetSubscriptionBranchRef(b string) plumbing.ReferenceName {
 	if b != "" {
-		if !strings.HasPrefix(b, "refs/heads/") {
+		if !strings.HasPrefix(b, "refs/heads/") && !IsPullRequestRef(plumbing.ReferenceName(b)) {
 			b = "refs/heads/" + b
 		}
 
@@ -622,15 +799,8 @@ func GetChannelSecret(client client.Client, chn *chnv1.Channel) (string, string,
 	clientkey := []byte("")
 	clientcert := []byte("")
 
-	if chn.Spec.SecretRef != nil {
-		secret := &corev1.Secret{}
-		secns := chn.Spec.SecretRef.Namespace
-
-		if secns == "" {
-			secns = chn.Namespace
-		}
-
-		err := client.Get(context.TODO(), types.NamespacedName{Name: chn.Spec.SecretRef.Name, Namespace: secns}, secret)
+	if HasChannelSecret(chn) {
+		secret, err := ResolveChannelSecret(client, chn)
 		if err != nil {
 			klog.Error(err, "Unable to get secret from local cluster.")
 			return username, accessToken, sshKey, passphrase, clientkey, clientcert, err
@@ -646,6 +816,35 @@ func GetChannelSecret(client client.Client, chn *chnv1.Channel) (string, string,
 	return username, accessToken, sshKey, passphrase, clientkey, clientcert, nil
 }
 
+// GetChannelConnectionConfig returns the connection of a Git channel with its secret and configmap
+func GetChannelConnectionConfig(clt client.Client, chn *chnv1.Channel) (*ChannelConnectionCfg, error) {
+	user, pwd, sshKey, passphrase, clientkey, clientcert, err := GetChannelSecret(clt, chn)
+	if err != nil {
+		return nil, err
+	}
+
+	channelConfig := GetChannelConfigMap(clt, chn)
+
+	connCfg := &ChannelConnectionCfg{
+		RepoURL:            chn.Spec.Pathname,
+		InsecureSkipVerify: chn.Spec.InsecureSkipVerify,
+		Passphrase:         passphrase,
+		Password:           pwd,
+		SSHKey:             sshKey,
+		User:               user,
+		ClientCert:         clientcert,
+		ClientKey:          clientkey,
+	}
+
+	if channelConfig != nil {
+		connCfg.CaCerts = channelConfig.Data[appv1.ChannelCertificateData]
+	}
+
+	SetChannelSSHConfig(connCfg, channelConfig)
+
+	return connCfg, nil
+}
+
 // GetDataFromChannelConfigMap returns username and password for channel
 func GetChannelConfigMap(client client.Client, chn *chnv1.Channel) *corev1.ConfigMap {
 	if chn.Spec.ConfigMapRef != nil {
@@ -711,7 +910,13 @@ func ParseChannelSecret(secret *corev1.Secret) (string, string, []byte, []byte,
 
 // GetLocalGitFolder returns the local Git repo clone directory
 func GetLocalGitFolder(sub *appv1.Subscription) string {
-	return filepath.Join(os.TempDir(), sub.Namespace, sub.Name)
+	return GetPrefixedLocalGitFolder("", sub)
+}
+
+// GetPrefixedLocalGitFolder returns the local Git repo clone directory under the prefix directory, so the subscriptions
+// of the same name deployed to different clusters by the same process don't share a clone directory
+func GetPrefixedLocalGitFolder(prefix string, sub *appv1.Subscription) string {
+	return filepath.Join(os.TempDir(), prefix, sub.Namespace, sub.Name)
 }
 
 type SkipFunc func(string, string) bool
@@ -721,6 +926,12 @@ func SortResources(repoRoot, resourcePath string, skips ...SkipFunc) (map[string
 	//wait for 2 seconds until the local repo clone is ready.
 	time.Sleep(2 * time.Second)
 
+	return SortResourcesInFileSystem(filesys.MakeFsOnDisk(), repoRoot, resourcePath, skips...)
+}
+
+// SortResourcesInFileSystem sorts the kube resources of the repo cloned in the file system, like SortResources.
+func SortResourcesInFileSystem(fSys filesys.FileSystem, repoRoot, resourcePath string,
+	skips ...SkipFunc) (map[string]string, map[string]string, []string, []string, []string, error) {
 	klog.Info("Git repo subscription directory: ", resourcePath)
 
 	var skip SkipFunc
@@ -747,9 +958,9 @@ func SortResources(repoRoot, resourcePath string, skips ...SkipFunc) (map[string
 	currentChartDir := "NONE"
 	currentKustomizeDir := "NONE"
 
-	kubeIgnore := GetKubeIgnore(resourcePath)
+	kubeIgnore := getKubeIgnore(fSys, resourcePath)
 
-	err := filepath.Walk(resourcePath,
+	err := fSys.Walk(resourcePath,
 		func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
@@ -765,7 +976,7 @@ func SortResources(repoRoot, resourcePath string, skips ...SkipFunc) (map[string
 				if info.IsDir() {
 					klog.V(4).Info("Ignoring subfolders of ", currentChartDir)
 
-					if _, err := os.Stat(path + "/Chart.yaml"); err == nil {
+					if fSys.Exists(path + "/Chart.yaml") {
 						klog.V(4).Info("Found Chart.yaml in ", path)
 
 						if !strings.HasPrefix(path, currentChartDir) {
@@ -773,23 +984,31 @@ func SortResources(repoRoot, resourcePath string, skips ...SkipFunc) (map[string
 							chartDirs[path+"/"] = path + "/"
 							currentChartDir = path + "/"
 						}
-					} else if _, err := os.Stat(path + "/kustomization.yaml"); err == nil {
+					} else if fSys.Exists(path + "/kustomization.yaml") {
 						// If there are nested kustomizations or any other folder structures containing kube
 						// resources under a kustomization, subscription should not process them and let kustomize
 						// build handle them based on the top-level kustomization.yaml.
 						if !strings.HasPrefix(path, currentKustomizeDir) {
 							klog.V(4).Info("Found kustomization.yaml in ", path)
 							currentKustomizeDir = path + "/"
-							kustomizeDirs[path+"/"] = path + "/"
+
+							// a component is built by the kustomizations listing it
+							if !isKustomizeComponent(fSys, path) {
+								kustomizeDirs[path+"/"] = path + "/"
+							}
 						}
-					} else if _, err := os.Stat(path + "/kustomization.yml"); err == nil {
+					} else if fSys.Exists(path + "/kustomization.yml") {
 						// If there are nested kustomizations or any other folder structures containing kube
 						// resources under a kustomization, subscription should not process them and let kustomize
 						// build handle them based on the top-level kustomization.yaml
 						if !strings.HasPrefix(path, currentKustomizeDir) {
 							klog.V(4).Info("Found kustomization.yml in ", path)
 							currentKustomizeDir = path + "/"
-							kustomizeDirs[path+"/"] = path + "/"
+
+							// a component is built by the kustomizations listing it
+							if !isKustomizeComponent(fSys, path) {
+								kustomizeDirs[path+"/"] = path + "/"
+							}
 						}
 					}
 				} else if !strings.HasPrefix(path, currentChartDir) &&
@@ -799,7 +1018,7 @@ func SortResources(repoRoot, resourcePath string, skips ...SkipFunc) (map[string
 					// If there are nested kustomizations or any other folder structures containing kube
 					// resources under a kustomization, subscription should not process them and let kustomize
 					// build handle them based on the top-level kustomization.yaml
-					crdsAndNamespaceFiles, rbacFiles, otherFiles, err = sortKubeResource(crdsAndNamespaceFiles, rbacFiles, otherFiles, path)
+					crdsAndNamespaceFiles, rbacFiles, otherFiles, err = sortKubeResource(fSys, crdsAndNamespaceFiles, rbacFiles, otherFiles, path)
 					if err != nil {
 						klog.Error(err.Error())
 						return err
@@ -813,11 +1032,12 @@ func SortResources(repoRoot, resourcePath string, skips ...SkipFunc) (map[string
 	return chartDirs, kustomizeDirs, crdsAndNamespaceFiles, rbacFiles, otherFiles, err
 }
 
-func sortKubeResource(crdsAndNamespaceFiles, rbacFiles, otherFiles []string, path string) ([]string, []string, []string, error) {
+func sortKubeResource(fSys filesys.FileSystem, crdsAndNamespaceFiles, rbacFiles, otherFiles []string,
+	path string) ([]string, []string, []string, error) {
 	if strings.EqualFold(filepath.Ext(path), ".yml") || strings.EqualFold(filepath.Ext(path), ".yaml") {
 		klog.V(4).Info("Reading file: ", path)
 
-		file, err := os.ReadFile(path) // #nosec G304 path is not user input
+		file, err := fSys.ReadFile(path)
 
 		if err != nil {
 			klog.Error(err, "Failed to read YAML file "+path)
@@ -873,17 +1093,21 @@ func SkipHooksOnManaged(resourcePath, curPath string) bool {
 
 // GetKubeIgnore get .kubernetesignore list
 func GetKubeIgnore(resourcePath string) *gitignore.GitIgnore {
+	return getKubeIgnore(filesys.MakeFsOnDisk(), resourcePath)
+}
+
+func getKubeIgnore(fSys filesys.FileSystem, resourcePath string) *gitignore.GitIgnore {
 	klog.V(4).Info("Git repo resource root directory: ", resourcePath)
 
 	lines := []string{""}
-	kubeIgnore := gitignore.CompileIgnoreLines(lines...)
 
-	if _, err := os.Stat(filepath.Join(resourcePath, ".kubernetesignore")); err == nil {
+	if bs, err := fSys.ReadFile(filepath.Join(resourcePath, ".kubernetesignore")); err == nil {
 		klog.V(4).Info("Found .kubernetesignore in ", resourcePath)
-		kubeIgnore, _ = gitignore.CompileIgnoreFile(filepath.Join(resourcePath, ".kubernetesignore"))
+
+		lines = strings.Split(string(bs), "\n")
 	}
 
-	return kubeIgnore
+	return gitignore.CompileIgnoreLines(lines...)
 }
 
 // IsGitChannel returns true if channel type is github or git
@@ -960,6 +1184,17 @@ func IsClusterAdmin(client client.Client, sub *appv1.Subscription, eventRecorder
 				eventRecorder.RecordEvent(sub, "RoleElevation",
 					"Role was elevated to cluster admin for hub subscription of hub subscription "+sub.Name, nil)
 			}
+			isClusterAdmin = true
+		}
+	} else if isUserSubAdmin && IsClusterAdminApprovalRequired() {
+		// the subscription admin requests the cluster admin access, a user of an approver group must approve it
+		if approver := setClusterAdminApproval(sub, eventRecorder); approver != "" {
+			if eventRecorder != nil {
+				eventRecorder.RecordEvent(sub, "RoleElevation",
+					"Role was elevated to cluster admin for subscription "+sub.Name+" by user "+userIdentity+
+						", approved by "+approver, nil)
+			}
+
 			isClusterAdmin = true
 		}
 	} else if isUserSubAdmin {
@@ -26,7 +26,12 @@ import (
 	"time"
 
 	"github.com/ghodss/yaml"
+	"github.com/go-git/go-billy/v5/memfs"
+	billyutil "github.com/go-git/go-billy/v5/util"
+	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/onsi/gomega"
 	admissionv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -37,6 +42,7 @@ import (
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 )
 
 var (
@@ -633,6 +639,70 @@ func TestNestedKustomize(t *testing.T) {
 	g.Expect(kustomizeDirs["../../test/github/nestedKustomize/wordpress2/"]).To(gomega.Equal("../../test/github/nestedKustomize/wordpress2/"))
 }
 
+func TestSortResourcesInMemory(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	files := map[string]string{
+		"namespace.yaml":               "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: apps\n",
+		"rbac/account.yaml":            "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: app\n",
+		"app/configmap.yaml":           "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+		"chart/Chart.yaml":             "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+		"kustomize/kustomization.yaml": "resources:\n- configmap.yaml\n",
+		"kustomize/configmap.yaml":     "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: kustomized\n",
+		"ignored/secret.yaml":          "apiVersion: v1\nkind: Secret\nmetadata:\n  name: ignored\n",
+		".kubernetesignore":            "ignored/\n",
+	}
+
+	// clone the repo in memory
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	workTree, err := repo.Worktree()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for name, content := range files {
+		g.Expect(billyutil.WriteFile(workTree.Filesystem, name, []byte(content), 0600)).To(gomega.Succeed())
+	}
+
+	g.Expect(workTree.Filesystem.Symlink("app/configmap.yaml", "link.yaml")).To(gomega.Succeed())
+	g.Expect(workTree.Filesystem.Symlink("chart", "chartlink")).To(gomega.Succeed())
+
+	_, err = workTree.Add(".")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = workTree.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	repoRoot := "/in-memory/default/sub"
+	fSys := filesys.MakeFsInMemory()
+
+	g.Expect(copyGitWorktree(repo, fSys, repoRoot)).To(gomega.Succeed())
+
+	// the symbolic links to files are copied as files
+	link, err := fSys.ReadFile(repoRoot + "/link.yaml")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(link)).To(gomega.Equal(files["app/configmap.yaml"]))
+	g.Expect(fSys.Exists(repoRoot + "/chartlink")).To(gomega.BeFalse())
+
+	chartDirs, kustomizeDirs, crdsAndNamespaceFiles, rbacFiles, otherFiles, err := SortResourcesInFileSystem(fSys,
+		repoRoot, repoRoot)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(chartDirs).To(gomega.Equal(map[string]string{repoRoot + "/chart/": repoRoot + "/chart/"}))
+	g.Expect(kustomizeDirs).To(gomega.Equal(map[string]string{repoRoot + "/kustomize/": repoRoot + "/kustomize/"}))
+	g.Expect(crdsAndNamespaceFiles).To(gomega.Equal([]string{repoRoot + "/namespace.yaml"}))
+	g.Expect(rbacFiles).To(gomega.Equal([]string{repoRoot + "/rbac/account.yaml"}))
+	g.Expect(otherFiles).To(gomega.Equal([]string{repoRoot + "/app/configmap.yaml", repoRoot + "/link.yaml"}))
+
+	indexFile, err := GenerateHelmIndexFileInFileSystem(fSys, &appv1.Subscription{}, repoRoot, chartDirs)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(indexFile.Entries["web"]).To(gomega.HaveLen(1))
+	g.Expect(indexFile.Entries["web"][0].URLs).To(gomega.Equal([]string{"chart"}))
+
+	// nothing is written on the disk
+	_, err = os.Stat(repoRoot)
+	g.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+}
+
 func TestSimple(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 	g.Expect("hello").To(gomega.Equal("hello"))
@@ -15,31 +15,41 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	semver "github.com/Masterminds/semver/v3"
 	"github.com/ghodss/yaml"
-	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
 	clientsetx "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 
 	releasev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/secretprovider"
 )
 
 func GetPackageAlias(sub *appv1.Subscription, packageName string) string {
@@ -58,6 +68,13 @@ func GetPackageAlias(sub *appv1.Subscription, packageName string) string {
 
 // GenerateHelmIndexFile generate helm repo index file
 func GenerateHelmIndexFile(sub *appv1.Subscription, repoRoot string, chartDirs map[string]string) (*repo.IndexFile, error) {
+	return GenerateHelmIndexFileInFileSystem(filesys.MakeFsOnDisk(), sub, repoRoot, chartDirs)
+}
+
+// GenerateHelmIndexFileInFileSystem generates the helm repo index file of the charts of the repo cloned in the file
+// system
+func GenerateHelmIndexFileInFileSystem(fSys filesys.FileSystem, sub *appv1.Subscription, repoRoot string,
+	chartDirs map[string]string) (*repo.IndexFile, error) {
 	// Build a helm repo index file
 	indexFile := repo.NewIndexFile()
 
@@ -71,7 +88,7 @@ func GenerateHelmIndexFile(sub *appv1.Subscription, repoRoot string, chartDirs m
 		// Get the relative parent directory from the git repo root
 		chartBaseDir := strings.TrimPrefix(chartParentDir, repoRoot+"/")
 
-		chartMetadata, err := chartutil.LoadChartfile(filepath.Join(chartDir, "Chart.yaml"))
+		chartMetadata, err := loadChartfile(fSys, filepath.Join(chartDir, "Chart.yaml"))
 
 		if err != nil {
 			klog.Error("There was a problem in generating helm charts index file: ", err.Error())
@@ -96,6 +113,18 @@ func GenerateHelmIndexFile(sub *appv1.Subscription, repoRoot string, chartDirs m
 	return indexFile, nil
 }
 
+// loadChartfile loads the Chart.yaml file of the file system, like chartutil.LoadChartfile
+func loadChartfile(fSys filesys.FileSystem, filename string) (*chart.Metadata, error) {
+	bs, err := fSys.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &chart.Metadata{}
+
+	return metadata, yaml.Unmarshal(bs, metadata)
+}
+
 func createSource(channel *chnv1.Channel, chartVersions repo.ChartVersions, sub *appv1.Subscription, packageName string) (*releasev1.Source, error) {
 	var source *releasev1.Source
 
@@ -194,6 +223,8 @@ func CreateOrUpdateHelmChart(
 	sub *appv1.Subscription) (helmRelease *releasev1.HelmRelease, err error) {
 	helmRelease = &releasev1.HelmRelease{}
 
+	timeout, atomic := GetHelmReleaseOptions(sub)
+
 	source, err := createSource(channel, chartVersions, sub, packageName)
 
 	if err != nil {
@@ -275,6 +306,8 @@ func CreateOrUpdateHelmChart(
 					Digest:                        digest,
 					AltSource:                     altSource,
 					WatchNamespaceScopedResources: sub.Spec.WatchHelmNamespaceScopedResources,
+					Timeout:                       timeout,
+					Atomic:                        atomic,
 				},
 			}
 		} else {
@@ -311,12 +344,67 @@ func CreateOrUpdateHelmChart(
 			Digest:                        digest,
 			AltSource:                     altSource,
 			WatchNamespaceScopedResources: sub.Spec.WatchHelmNamespaceScopedResources,
+			Timeout:                       timeout,
+			Atomic:                        atomic,
 		}
 	}
 
+	setHelmReleaseSecretProvider(helmRelease, channel)
+
+	// the helmReleases are regenerated from the subscription after a restore, a restored helmRelease is adopted by
+	// the restored subscription
+	SetExcludeFromBackupLabel(helmRelease)
+	AdoptOwnerReferences(helmRelease, sub)
+
 	return helmRelease, nil
 }
 
+// setHelmReleaseSecretProvider copies the external secret manager annotations of the channel to the HelmRelease and
+// signs them, the chart is downloaded with the credentials of the secret manager instead of a secret reference
+func setHelmReleaseSecretProvider(helmRelease *releasev1.HelmRelease, channel *chnv1.Channel) {
+	annotations := helmRelease.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	for _, key := range secretprovider.Annotations {
+		delete(annotations, key)
+
+		if value := channel.GetAnnotations()[key]; value != "" {
+			annotations[key] = value
+		}
+	}
+
+	if IsExternalChannelSecret(channel) {
+		helmRelease.Repo.SecretRef = nil
+	}
+
+	helmRelease.SetAnnotations(annotations)
+
+	// the HelmRelease controller only resolves the secret provider annotations set by the subscriber
+	signHelmReleaseSecretProvider(helmRelease)
+}
+
+// GetHelmReleaseOptions returns the install and upgrade timeout and the atomic flag of the helm releases of the
+// subscription, from its helm-timeout and helm-atomic annotations. An invalid timeout is ignored.
+func GetHelmReleaseOptions(sub *appv1.Subscription) (*metav1.Duration, bool) {
+	annotations := sub.GetAnnotations()
+
+	var timeout *metav1.Duration
+
+	if value := annotations[appv1.AnnotationHelmTimeout]; value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			klog.Warningf("Invalid %v annotation %q of the subscription %v/%v, ignored", appv1.AnnotationHelmTimeout,
+				value, sub.Namespace, sub.Name)
+		} else {
+			timeout = &metav1.Duration{Duration: d}
+		}
+	}
+
+	return timeout, strings.EqualFold(annotations[appv1.AnnotationHelmAtomic], "true")
+}
+
 func Override(helmRelease *releasev1.HelmRelease, sub *appv1.Subscription) error {
 	//Overrides with the values provided in the subscription for that package
 	overrides := getOverrides(helmRelease.Repo.ChartName, sub)
@@ -371,9 +459,15 @@ func getShortSubUID(subUID string) string {
 	return shortUID
 }
 
+// PkgToReleaseCRName returns the HelmRelease CR name of a chart of the subscription: the package alias of the chart,
+// or the helm-release-name template of the subscription, or the chart name and the short subscription UID
 func PkgToReleaseCRName(sub *appv1.Subscription, packageName string) (string, error) {
 	releaseCRName := GetPackageAlias(sub, packageName)
 	if releaseCRName == "" {
+		if nameTemplate := sub.GetAnnotations()[appv1.AnnotationHelmReleaseName]; nameTemplate != "" {
+			return renderHelmReleaseName(sub, packageName, nameTemplate)
+		}
+
 		releaseCRName = packageName
 		subUID := string(sub.UID)
 
@@ -390,6 +484,92 @@ func PkgToReleaseCRName(sub *appv1.Subscription, packageName string) (string, er
 	return releaseCRName, nil
 }
 
+// renderHelmReleaseName replaces the placeholders of the HelmRelease name template for the chart, the hash is
+// computed from the subscription namespace, name and the chart so the name is the same on every reconcile
+func renderHelmReleaseName(sub *appv1.Subscription, packageName, nameTemplate string) (string, error) {
+	h := NewNameHash()
+	if _, err := h.Write([]byte(sub.Namespace + "/" + sub.Name + "/" + packageName)); err != nil {
+		return "", err
+	}
+
+	name := strings.NewReplacer(
+		"{{chart}}", packageName,
+		"{{subscription}}", sub.Name,
+		"{{namespace}}", sub.Namespace,
+		"{{hash}}", hex.EncodeToString(h.Sum(nil))[:randomLength],
+	).Replace(nameTemplate)
+
+	if strings.Contains(name, "{{") || strings.Contains(name, "}}") {
+		return "", fmt.Errorf("invalid %v annotation %q of the subscription %v/%v, the placeholders must be {{chart}}, "+
+			"{{subscription}}, {{namespace}} or {{hash}}", appv1.AnnotationHelmReleaseName, nameTemplate, sub.Namespace, sub.Name)
+	}
+
+	name, err := GetReleaseName(strings.ToLower(name))
+	if err != nil {
+		return "", err
+	}
+
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("invalid HelmRelease name %v of the chart %v rendered from the %v annotation of the "+
+			"subscription %v/%v: %v", name, packageName, appv1.AnnotationHelmReleaseName, sub.Namespace, sub.Name, strings.Join(errs, ", "))
+	}
+
+	return name, nil
+}
+
+// ValidateHelmReleaseNames returns an error if two charts of the index file get the same HelmRelease CR name
+func ValidateHelmReleaseNames(sub *appv1.Subscription, indexFile *repo.IndexFile) error {
+	packageNames := make([]string, 0, len(indexFile.Entries))
+	for packageName := range indexFile.Entries {
+		packageNames = append(packageNames, packageName)
+	}
+
+	sort.Strings(packageNames)
+
+	charts := map[string]string{}
+
+	for _, packageName := range packageNames {
+		releaseCRName, err := PkgToReleaseCRName(sub, packageName)
+		if err != nil {
+			return err
+		}
+
+		if chart, ok := charts[releaseCRName]; ok {
+			return fmt.Errorf("the charts %v and %v of the subscription %v/%v have the same HelmRelease name %v, "+
+				"set a package alias or a %v annotation that includes {{chart}}", chart, packageName, sub.Namespace, sub.Name,
+				releaseCRName, appv1.AnnotationHelmReleaseName)
+		}
+
+		charts[releaseCRName] = packageName
+	}
+
+	return nil
+}
+
+// checkHelmReleaseOwner returns an error if the HelmRelease CR of the name is owned by another subscription of the
+// namespace, the subscriptions would overwrite the chart of each other
+func checkHelmReleaseOwner(clt client.Client, sub *appv1.Subscription, releaseCRName, packageName string) error {
+	helmRelease := &releasev1.HelmRelease{}
+
+	if err := clt.Get(context.TODO(), types.NamespacedName{Name: releaseCRName, Namespace: sub.Namespace}, helmRelease); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, owner := range helmRelease.GetOwnerReferences() {
+		if owner.Kind == "Subscription" && owner.Name != sub.Name {
+			return fmt.Errorf("the HelmRelease %v/%v of the chart %v is owned by the subscription %v, set a package alias "+
+				"or a %v annotation on the subscription %v", sub.Namespace, releaseCRName, packageName, owner.Name,
+				appv1.AnnotationHelmReleaseName, sub.Name)
+		}
+	}
+
+	return nil
+}
+
 func CreateHelmCRManifest(
 	repoURL string,
 	packageName string,
@@ -404,6 +584,10 @@ func CreateHelmCRManifest(
 		return nil, err
 	}
 
+	if err := checkHelmReleaseOwner(client, sub, releaseCRName, packageName); err != nil {
+		return nil, err
+	}
+
 	if channel == nil || !IsGitChannel(string(channel.Spec.Type)) {
 		for i := range chartVersions[0].URLs {
 			parsedURL, err := url.Parse(chartVersions[0].URLs[i])
@@ -704,3 +888,51 @@ func IsURL(str string) bool {
 	u, err := url.Parse(str)
 	return err == nil && u.Scheme != "" && u.Host != ""
 }
+
+// SetHelmRepoTLSConfig adds the CA certificates of the caCerts key of the channel config map and the client certificate
+// key pair of the clientCert and clientKey keys of the channel secret to the TLS config of a helm repo client, for the
+// helm repos with a private CA or requiring the mTLS client certificate auth
+func SetHelmRepoTLSConfig(tlsConfig *tls.Config, configMap *corev1.ConfigMap, secret *corev1.Secret) error {
+	if configMap != nil && strings.TrimSpace(configMap.Data[appv1.ChannelCertificateData]) != "" {
+		certPool, _ := x509.SystemCertPool()
+		if certPool == nil {
+			certPool = x509.NewCertPool()
+		}
+
+		if !certPool.AppendCertsFromPEM([]byte(configMap.Data[appv1.ChannelCertificateData])) {
+			return fmt.Errorf("failed to parse the %v of the channel config map %v/%v", appv1.ChannelCertificateData, configMap.Namespace, configMap.Name)
+		}
+
+		klog.Info("Adding the helm repo CA certificates of the channel config map ", configMap.Namespace, "/", configMap.Name)
+
+		tlsConfig.RootCAs = certPool
+	}
+
+	if secret == nil {
+		return nil
+	}
+
+	clientCert := bytes.TrimSpace(secret.Data[ClientCert])
+	clientKey := bytes.TrimSpace(secret.Data[ClientKey])
+
+	if len(clientCert) == 0 && len(clientKey) == 0 {
+		return nil
+	}
+
+	if len(clientCert) == 0 || len(clientKey) == 0 {
+		return fmt.Errorf("both the %v and %v are required in the channel secret %v/%v for the mTLS connection",
+			ClientCert, ClientKey, secret.Namespace, secret.Name)
+	}
+
+	clientCertificate, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		return fmt.Errorf("failed to load the client certificate key pair of the channel secret %v/%v: %w",
+			secret.Namespace, secret.Name, err)
+	}
+
+	klog.Info("Adding the helm repo client certificate of the channel secret ", secret.Namespace, "/", secret.Name)
+
+	tlsConfig.Certificates = []tls.Certificate{clientCertificate}
+
+	return nil
+}
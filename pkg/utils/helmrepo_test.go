@@ -16,6 +16,16 @@ package utils
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -26,11 +36,14 @@ import (
 	clientsetx "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/apis"
 	releasev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
@@ -514,3 +527,214 @@ func Test_generateGitChartPath(t *testing.T) {
 		})
 	}
 }
+
+func newTestClientCert(g *gomega.WithT) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "helm-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestSetHelmRepoTLSConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// a helm repo with a private CA requiring a client certificate
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+
+	defer server.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	certPEM, keyPEM := newTestClientCert(g)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "helm-repo", Namespace: "default"},
+		Data:       map[string]string{appv1.ChannelCertificateData: string(caPEM)},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "helm-repo", Namespace: "default"},
+		Data:       map[string][]byte{ClientCert: certPEM, ClientKey: keyPEM},
+	}
+
+	get := func(cfg *tls.Config) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+
+		resp, err := client.Get(server.URL + "/index.yaml")
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	// the private CA is not trusted and the client certificate is missing
+	g.Expect(get(&tls.Config{MinVersion: tls.VersionTLS12})).NotTo(gomega.Succeed())
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	g.Expect(SetHelmRepoTLSConfig(tlsConfig, configMap, secret)).To(gomega.Succeed())
+	g.Expect(tlsConfig.Certificates).To(gomega.HaveLen(1))
+	g.Expect(get(tlsConfig)).To(gomega.Succeed())
+
+	// no CA and no client certificate
+	tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	g.Expect(SetHelmRepoTLSConfig(tlsConfig, nil, &corev1.Secret{Data: map[string][]byte{UserID: []byte("admin")}})).To(gomega.Succeed())
+	g.Expect(tlsConfig.RootCAs).To(gomega.BeNil())
+	g.Expect(tlsConfig.Certificates).To(gomega.BeEmpty())
+
+	// the client key is missing
+	g.Expect(SetHelmRepoTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}, nil,
+		&corev1.Secret{Data: map[string][]byte{ClientCert: certPEM}})).NotTo(gomega.Succeed())
+
+	// an invalid CA
+	g.Expect(SetHelmRepoTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12},
+		&corev1.ConfigMap{Data: map[string]string{appv1.ChannelCertificateData: "not a certificate"}}, nil)).NotTo(gomega.Succeed())
+}
+
+func TestGetHelmReleaseOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{}
+
+	timeout, atomic := GetHelmReleaseOptions(sub)
+	g.Expect(timeout).To(gomega.BeNil())
+	g.Expect(atomic).To(gomega.BeFalse())
+
+	sub.SetAnnotations(map[string]string{
+		appv1.AnnotationHelmTimeout: "15m",
+		appv1.AnnotationHelmAtomic:  "true",
+	})
+
+	timeout, atomic = GetHelmReleaseOptions(sub)
+	g.Expect(timeout.Duration).To(gomega.Equal(15 * time.Minute))
+	g.Expect(atomic).To(gomega.BeTrue())
+
+	// an invalid timeout is ignored
+	sub.SetAnnotations(map[string]string{appv1.AnnotationHelmTimeout: "15"})
+
+	timeout, _ = GetHelmReleaseOptions(sub)
+	g.Expect(timeout).To(gomega.BeNil())
+}
+
+func TestPkgToReleaseCRName(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns", UID: "a1b2c3d4"},
+	}
+
+	name, err := PkgToReleaseCRName(sub, "nginx")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(name).To(gomega.Equal("nginx-a1b2c"))
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationHelmReleaseName: "{{chart}}-{{subscription}}"})
+
+	name, err = PkgToReleaseCRName(sub, "nginx")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(name).To(gomega.Equal("nginx-app"))
+
+	// the hash is the same on every reconcile, and different for another subscription
+	sub.SetAnnotations(map[string]string{appv1.AnnotationHelmReleaseName: "{{chart}}-{{hash}}"})
+
+	name, err = PkgToReleaseCRName(sub, "nginx")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(name).To(gomega.HavePrefix("nginx-"))
+	g.Expect(name).To(gomega.HaveLen(len("nginx-") + 5))
+
+	again, _ := PkgToReleaseCRName(sub, "nginx")
+	g.Expect(again).To(gomega.Equal(name))
+
+	other := sub.DeepCopy()
+	other.Name = "other"
+	otherName, _ := PkgToReleaseCRName(other, "nginx")
+	g.Expect(otherName).NotTo(gomega.Equal(name))
+
+	// the package alias has precedence over the template
+	sub.Spec.PackageOverrides = []*appv1.Overrides{{PackageName: "nginx", PackageAlias: "web"}}
+
+	name, err = PkgToReleaseCRName(sub, "nginx")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(name).To(gomega.Equal("web"))
+
+	sub.Spec.PackageOverrides = nil
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationHelmReleaseName: "{{chart}}-{{cluster}}"})
+	_, err = PkgToReleaseCRName(sub, "nginx")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationHelmReleaseName: "{{chart}}_{{subscription}}"})
+	_, err = PkgToReleaseCRName(sub, "nginx")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestValidateHelmReleaseNames(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns", UID: "a1b2c3d4"},
+	}
+
+	indexFile := repo.NewIndexFile()
+	indexFile.Entries["nginx"] = repo.ChartVersions{}
+	indexFile.Entries["redis"] = repo.ChartVersions{}
+
+	g.Expect(ValidateHelmReleaseNames(sub, indexFile)).To(gomega.Succeed())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationHelmReleaseName: "{{subscription}}"})
+	g.Expect(ValidateHelmReleaseNames(sub, indexFile)).NotTo(gomega.Succeed())
+
+	sub.Spec.PackageOverrides = []*appv1.Overrides{{PackageName: "redis", PackageAlias: "cache"}}
+	g.Expect(ValidateHelmReleaseNames(sub, indexFile)).To(gomega.Succeed())
+}
+
+func TestCheckHelmReleaseOwner(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(apis.AddToScheme(s)).To(gomega.Succeed())
+
+	helmRelease := &releasev1.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx",
+			Namespace: "ns",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps.open-cluster-management.io/v1",
+				Kind:       "Subscription",
+				Name:       "app1",
+				UID:        "uid1",
+			}},
+		},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(s).WithObjects(helmRelease).Build()
+
+	app1 := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app1", Namespace: "ns"}}
+	app2 := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app2", Namespace: "ns"}}
+
+	g.Expect(checkHelmReleaseOwner(clt, app1, "nginx", "nginx")).To(gomega.Succeed())
+	g.Expect(checkHelmReleaseOwner(clt, app2, "nginx", "nginx")).NotTo(gomega.Succeed())
+	g.Expect(checkHelmReleaseOwner(clt, app2, "nginx-app2", "nginx")).To(gomega.Succeed())
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
+)
+
+const (
+	// HostedKubeconfigValidCondition is the ManagedClusterAddOn condition reporting if the managed cluster kubeconfig
+	// of an agent running in Hosted mode is valid
+	HostedKubeconfigValidCondition = "HostedKubeconfigValid"
+	// HostedKubeconfigValidReason is the reason of the condition when the kubeconfig is valid
+	HostedKubeconfigValidReason = "KubeconfigValid"
+	// HostedKubeconfigInvalidReason is the reason of the condition when the kubeconfig is invalid
+	HostedKubeconfigInvalidReason = "KubeconfigInvalid"
+)
+
+// CheckKubeConfig returns an error if the kubeconfig file can't be loaded or if its cluster can't be reached
+func CheckKubeConfig(kubeconfigFile string) error {
+	cfg, err := GetClientConfigFromKubeConfig(kubeconfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the kubeconfig %v: %w", kubeconfigFile, err)
+	}
+
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create the discovery client of the kubeconfig %v: %w", kubeconfigFile, err)
+	}
+
+	if _, err := dc.ServerVersion(); err != nil {
+		return fmt.Errorf("failed to reach the cluster of the kubeconfig %v: %w", kubeconfigFile, err)
+	}
+
+	return nil
+}
+
+// hostedKubeConfigCondition returns the HostedKubeconfigValid condition for the result of CheckKubeConfig
+func hostedKubeConfigCondition(kubeconfigErr error) metav1.Condition {
+	if kubeconfigErr != nil {
+		return metav1.Condition{
+			Type:    HostedKubeconfigValidCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  HostedKubeconfigInvalidReason,
+			Message: kubeconfigErr.Error(),
+		}
+	}
+
+	return metav1.Condition{
+		Type:    HostedKubeconfigValidCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  HostedKubeconfigValidReason,
+		Message: "The managed cluster kubeconfig of the hosted agent is valid",
+	}
+}
+
+// SetHostedKubeConfigCondition sets the HostedKubeconfigValid condition of the agent ManagedClusterAddOn on the hub
+func SetHostedKubeConfigCondition(ctx context.Context, addonClient addonv1alpha1client.Interface,
+	clusterName, addonName string, kubeconfigErr error) error {
+	cond := hostedKubeConfigCondition(kubeconfigErr)
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		addon, err := addonClient.AddonV1alpha1().ManagedClusterAddOns(clusterName).Get(ctx, addonName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if !meta.SetStatusCondition(&addon.Status.Conditions, cond) {
+			return nil
+		}
+
+		klog.Infof("Setting the %v condition of the ManagedClusterAddOn %v/%v to %v, reason: %v",
+			cond.Type, clusterName, addonName, cond.Status, cond.Reason)
+
+		_, err = addonClient.AddonV1alpha1().ManagedClusterAddOns(clusterName).UpdateStatus(ctx, addon, metav1.UpdateOptions{})
+
+		return err
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+)
+
+func TestCheckKubeConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir := t.TempDir()
+
+	g.Expect(CheckKubeConfig(filepath.Join(dir, "missing"))).NotTo(gomega.Succeed())
+
+	invalid := filepath.Join(dir, "kubeconfig")
+	g.Expect(os.WriteFile(invalid, []byte("not a kubeconfig"), 0600)).To(gomega.Succeed())
+	g.Expect(CheckKubeConfig(invalid)).NotTo(gomega.Succeed())
+}
+
+func TestSetHostedKubeConfigCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	addonClient := addonfake.NewSimpleClientset(&addonapiv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: "application-manager", Namespace: "cluster1"},
+	})
+
+	getCondition := func() *metav1.Condition {
+		addon, err := addonClient.AddonV1alpha1().ManagedClusterAddOns("cluster1").Get(
+			context.TODO(), "application-manager", metav1.GetOptions{})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		return meta.FindStatusCondition(addon.Status.Conditions, HostedKubeconfigValidCondition)
+	}
+
+	g.Expect(SetHostedKubeConfigCondition(context.TODO(), addonClient, "cluster1", "application-manager",
+		errors.New("token expired"))).To(gomega.Succeed())
+
+	cond := getCondition()
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(gomega.Equal(HostedKubeconfigInvalidReason))
+	g.Expect(cond.Message).To(gomega.Equal("token expired"))
+
+	g.Expect(SetHostedKubeConfigCondition(context.TODO(), addonClient, "cluster1", "application-manager", nil)).To(gomega.Succeed())
+	g.Expect(getCondition().Status).To(gomega.Equal(metav1.ConditionTrue))
+
+	g.Expect(SetHostedKubeConfigCondition(context.TODO(), addonClient, "cluster1", "missing", nil)).NotTo(gomega.Succeed())
+}
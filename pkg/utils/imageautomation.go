@@ -0,0 +1,100 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/imageregistry"
+)
+
+// MinImageScanInterval is the minimum interval of the registry scans of the subscription images, the shorter
+// intervals are raised to it
+const MinImageScanInterval = time.Minute
+
+// IsImageAutomation returns true if the container images of the subscription are resolved with the registries when
+// they are applied, pinned to their digests or updated by an image update policy
+func IsImageAutomation(sub *appv1.Subscription) bool {
+	return strings.EqualFold(sub.GetAnnotations()[appv1.AnnotationImageResolution], "true") ||
+		sub.GetAnnotations()[appv1.AnnotationImageUpdatePolicy] != ""
+}
+
+// GetImageOptions returns the image resolution options of the subscription, with the registry credentials of its
+// image pull secret in the subscription namespace
+func GetImageOptions(clt client.Client, sub *appv1.Subscription) (imageregistry.Options, error) {
+	opts := imageregistry.Options{
+		Policy:    strings.TrimSpace(sub.GetAnnotations()[appv1.AnnotationImageUpdatePolicy]),
+		PinDigest: strings.EqualFold(sub.GetAnnotations()[appv1.AnnotationImageResolution], "true"),
+	}
+
+	if opts.Policy != "" {
+		if err := imageregistry.ValidatePolicy(opts.Policy); err != nil {
+			return opts, err
+		}
+	}
+
+	secretName := sub.GetAnnotations()[appv1.AnnotationImagePullSecret]
+	if secretName == "" {
+		return opts, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := clt.Get(context.TODO(), types.NamespacedName{Namespace: sub.Namespace, Name: secretName}, secret); err != nil {
+		return opts, fmt.Errorf("failed to get the image pull secret %v/%v, err: %w", sub.Namespace, secretName, err)
+	}
+
+	credentials, err := imageregistry.CredentialsFromDockerConfig(secret.Data[corev1.DockerConfigJsonKey])
+	if err != nil {
+		return opts, fmt.Errorf("invalid image pull secret %v/%v, err: %w", sub.Namespace, secretName, err)
+	}
+
+	opts.Credentials = credentials
+
+	return opts, nil
+}
+
+// GetImageScanInterval returns the interval of the registry scans of the subscription images, at least
+// MinImageScanInterval. It returns 0 if the images are not scanned.
+func GetImageScanInterval(sub *appv1.Subscription) time.Duration {
+	value := sub.GetAnnotations()[appv1.AnnotationImageScanInterval]
+	if value == "" || !IsImageAutomation(sub) {
+		return 0
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		klog.Warningf("Ignoring the invalid %v annotation %q of subscription %v/%v", appv1.AnnotationImageScanInterval,
+			value, sub.Namespace, sub.Name)
+
+		return 0
+	}
+
+	if interval < MinImageScanInterval {
+		klog.Infof("Raising the image scan interval %v to the minimum %v", interval, MinImageScanInterval)
+
+		interval = MinImageScanInterval
+	}
+
+	return interval
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/imageregistry"
+)
+
+func TestGetImageScanInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newSub := func(annotations map[string]string) *appv1.Subscription {
+		return &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "app", Annotations: annotations}}
+	}
+
+	g.Expect(GetImageScanInterval(newSub(nil))).To(gomega.BeZero())
+	g.Expect(GetImageScanInterval(newSub(map[string]string{appv1.AnnotationImageScanInterval: "5m"}))).To(gomega.BeZero())
+
+	g.Expect(GetImageScanInterval(newSub(map[string]string{appv1.AnnotationImageResolution: "true",
+		appv1.AnnotationImageScanInterval: "5m"}))).To(gomega.Equal(5 * time.Minute))
+	g.Expect(GetImageScanInterval(newSub(map[string]string{appv1.AnnotationImageUpdatePolicy: "~1.25",
+		appv1.AnnotationImageScanInterval: "10s"}))).To(gomega.Equal(MinImageScanInterval))
+	g.Expect(GetImageScanInterval(newSub(map[string]string{appv1.AnnotationImageResolution: "true",
+		appv1.AnnotationImageScanInterval: "often"}))).To(gomega.BeZero())
+}
+
+func TestGetImageOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "app"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(
+			`{"auths": {"quay.io": {"username": "user", "password": "pass"}}}`)},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "app",
+		Annotations: map[string]string{
+			appv1.AnnotationImageResolution:   "true",
+			appv1.AnnotationImageUpdatePolicy: "~1.25",
+			appv1.AnnotationImagePullSecret:   "pull-secret",
+		}}}
+
+	g.Expect(IsImageAutomation(sub)).To(gomega.BeTrue())
+
+	opts, err := GetImageOptions(clt, sub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(opts).To(gomega.Equal(imageregistry.Options{
+		Policy:      "~1.25",
+		PinDigest:   true,
+		Credentials: map[string]imageregistry.Credential{"quay.io": {Username: "user", Password: "pass"}},
+	}))
+
+	sub.Annotations[appv1.AnnotationImageUpdatePolicy] = "not a constraint"
+
+	_, err = GetImageOptions(clt, sub)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	sub.Annotations[appv1.AnnotationImageUpdatePolicy] = ""
+	sub.Annotations[appv1.AnnotationImagePullSecret] = "missing"
+
+	_, err = GetImageOptions(clt, sub)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
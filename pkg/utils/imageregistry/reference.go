@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imageregistry resolves the container images of the subscriptions with the registries, the mutable tags
+// to their digests and the tags to the latest tag of an update policy
+package imageregistry
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// dockerHubRegistry is the registry of the images without a registry host
+	dockerHubRegistry = "docker.io"
+	// dockerHubAPIHost is the API host of the Docker Hub registry
+	dockerHubAPIHost = "registry-1.docker.io"
+	// defaultTag is the tag of the images without a tag or a digest
+	defaultTag = "latest"
+)
+
+// Reference is a container image reference, like quay.io/org/app:1.0 or nginx@sha256:<digest>
+type Reference struct {
+	// Name is the image as written without its tag and digest, like nginx or quay.io/org/app
+	Name string
+	// Registry is the registry host, docker.io for the images without a registry host
+	Registry string
+	// Repository is the repository path in the registry, like library/nginx or org/app
+	Repository string
+	// Tag is the image tag, latest if the image has no tag and no digest
+	Tag string
+	// Digest is the manifest digest of the image, empty if the image is not pinned
+	Digest string
+}
+
+// ParseReference parses a container image reference
+func ParseReference(image string) (*Reference, error) {
+	ref := &Reference{}
+	rest := strings.TrimSpace(image)
+
+	if name, digest, found := strings.Cut(rest, "@"); found {
+		if !strings.Contains(digest, ":") {
+			return nil, fmt.Errorf("invalid image %q, the digest must be <algorithm>:<hex>", image)
+		}
+
+		rest, ref.Digest = name, digest
+	}
+
+	// the tag follows the last colon after the last slash, a colon before is the registry port
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		rest, ref.Tag = rest[:i], rest[i+1:]
+	}
+
+	if rest == "" || (ref.Tag == "" && strings.HasSuffix(image, ":")) {
+		return nil, fmt.Errorf("invalid image %q", image)
+	}
+
+	ref.Name = rest
+
+	// the first component is a registry host if it has a dot or a port, or is localhost
+	if host, repository, found := strings.Cut(rest, "/"); found &&
+		(strings.ContainsAny(host, ".:") || host == "localhost") {
+		ref.Registry, ref.Repository = normalizeRegistry(host), repository
+	} else {
+		ref.Registry, ref.Repository = dockerHubRegistry, rest
+	}
+
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if ref.Repository == "" || strings.ToLower(ref.Repository) != ref.Repository {
+		return nil, fmt.Errorf("invalid image %q, the repository must be lowercase", image)
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	return ref, nil
+}
+
+// String returns the image reference with its name as written, its tag and its digest
+func (r *Reference) String() string {
+	image := r.Name
+
+	if r.Tag != "" {
+		image += ":" + r.Tag
+	}
+
+	if r.Digest != "" {
+		image += "@" + r.Digest
+	}
+
+	return image
+}
+
+// apiHost returns the host of the registry API
+func (r *Reference) apiHost() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubAPIHost
+	}
+
+	return r.Registry
+}
+
+// normalizeRegistry returns the registry of a registry host, the Docker Hub hosts are docker.io
+func normalizeRegistry(host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+
+	if host == "index.docker.io" || host == dockerHubAPIHost {
+		return dockerHubRegistry
+	}
+
+	return host
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageregistry
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestParseReference(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Name: "nginx", Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"bitnami/redis:7.2", Reference{Name: "bitnami/redis", Registry: "docker.io", Repository: "bitnami/redis",
+			Tag: "7.2"}},
+		{"quay.io/org/app:1.0", Reference{Name: "quay.io/org/app", Registry: "quay.io", Repository: "org/app",
+			Tag: "1.0"}},
+		{"localhost:5000/app", Reference{Name: "localhost:5000/app", Registry: "localhost:5000", Repository: "app",
+			Tag: "latest"}},
+		{"index.docker.io/library/nginx:1.25@sha256:abc", Reference{Name: "index.docker.io/library/nginx",
+			Registry: "docker.io", Repository: "library/nginx", Tag: "1.25", Digest: "sha256:abc"}},
+		{"registry:5000/app@sha256:abc", Reference{Name: "registry:5000/app", Registry: "registry:5000",
+			Repository: "app", Digest: "sha256:abc"}},
+	}
+
+	for _, tt := range tests {
+		ref, err := ParseReference(tt.image)
+		g.Expect(err).NotTo(gomega.HaveOccurred(), tt.image)
+		g.Expect(*ref).To(gomega.Equal(tt.want), tt.image)
+	}
+
+	for _, image := range []string{"", "nginx:", "nginx@abc", "quay.io/Org/App:1.0"} {
+		_, err := ParseReference(image)
+		g.Expect(err).To(gomega.HaveOccurred(), image)
+	}
+}
+
+func TestReferenceString(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ref, err := ParseReference("nginx:1.25")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ref.Digest = "sha256:abc"
+	g.Expect(ref.String()).To(gomega.Equal("nginx:1.25@sha256:abc"))
+
+	ref, err = ParseReference("quay.io/org/app@sha256:abc")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ref.String()).To(gomega.Equal("quay.io/org/app@sha256:abc"))
+}
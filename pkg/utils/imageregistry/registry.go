@@ -0,0 +1,315 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageregistry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// requestTimeout is the timeout of the registry requests
+	requestTimeout = 30 * time.Second
+
+	// maxResponseSize is the maximum size of the registry responses
+	maxResponseSize = 4 << 20
+
+	// maxTagPages is the maximum number of pages of the tag lists
+	maxTagPages = 10
+)
+
+// manifestMediaTypes are the media types of the image manifests and indexes, the digest of an index is resolved for
+// the multi-architecture images
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+var (
+	httpClient = &http.Client{Timeout: requestTimeout}
+
+	regexChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	regexNextLink       = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// Credential is the user name and password of a registry
+type Credential struct {
+	Username string
+	Password string
+}
+
+// CredentialsFromDockerConfig returns the registry credentials of a .dockerconfigjson pull secret, by registry
+func CredentialsFromDockerConfig(data []byte) (map[string]Credential, error) {
+	config := struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}{}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid docker config, err: %w", err)
+	}
+
+	credentials := map[string]Credential{}
+
+	for host, auth := range config.Auths {
+		credential := Credential{Username: auth.Username, Password: auth.Password}
+
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid docker config auth of the registry %v, err: %w", host, err)
+			}
+
+			credential.Username, credential.Password, _ = strings.Cut(string(decoded), ":")
+		}
+
+		credentials[normalizeRegistry(host)] = credential
+	}
+
+	return credentials, nil
+}
+
+// StatusError is the error of an unexpected status code of the registry
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("registry request %v failed: status %v", e.URL, e.StatusCode)
+}
+
+// registryClient sends the requests of a repository to its registry, authenticated with the bearer token or the
+// basic authentication challenged by the registry
+type registryClient struct {
+	ref        *Reference
+	credential *Credential
+	token      string
+	basicAuth  bool
+}
+
+// getDigest returns the manifest digest of the image tag
+func (c *registryClient) getDigest(ctx context.Context) (string, error) {
+	path := "/v2/" + c.ref.Repository + "/manifests/" + c.ref.Tag
+
+	resp, err := c.do(ctx, http.MethodHead, c.url(path))
+	if err != nil {
+		return "", err
+	}
+
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// the registries that don't return the digest header are asked for the manifest, its digest is its sha256
+	resp, err = c.do(ctx, http.MethodGet, c.url(path))
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// listTags returns the tags of the image repository
+func (c *registryClient) listTags(ctx context.Context) ([]string, error) {
+	tags := []string{}
+	next := c.url("/v2/" + c.ref.Repository + "/tags/list")
+
+	for page := 0; next != "" && page < maxTagPages; page++ {
+		resp, err := c.do(ctx, http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+
+		list := struct {
+			Tags []string `json:"tags"`
+		}{}
+
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&list)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag list of the image %v, err: %w", c.ref.Name, err)
+		}
+
+		tags = append(tags, list.Tags...)
+		next = ""
+
+		if m := regexNextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			link, err := url.Parse(m[1])
+			if err != nil {
+				break
+			}
+
+			next = resp.Request.URL.ResolveReference(link).String()
+		}
+	}
+
+	return tags, nil
+}
+
+func (c *registryClient) url(path string) string {
+	return "https://" + c.ref.apiHost() + path
+}
+
+// do sends the request, it authenticates and sends it again if the registry challenges it
+func (c *registryClient) do(ctx context.Context, method, target string) (*http.Response, error) {
+	resp, err := c.send(ctx, method, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" && !c.basicAuth {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.authenticate(ctx, challenge); err != nil {
+			return nil, err
+		}
+
+		if resp, err = c.send(ctx, method, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		resp.Body.Close()
+
+		return nil, &StatusError{URL: target, StatusCode: resp.StatusCode}
+	}
+
+	return resp, nil
+}
+
+func (c *registryClient) send(ctx context.Context, method, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+	switch {
+	case c.basicAuth:
+		req.SetBasicAuth(c.credential.Username, c.credential.Password)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	return httpClient.Do(req)
+}
+
+// authenticate gets the bearer token of the challenge from its realm, with the credential if any
+func (c *registryClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+
+	if strings.EqualFold(scheme, "Basic") {
+		if c.credential == nil {
+			return fmt.Errorf("the registry %v requires credentials", c.ref.Registry)
+		}
+
+		c.basicAuth = true
+
+		return nil
+	}
+
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("unsupported authentication challenge %q of the registry %v", scheme, c.ref.Registry)
+	}
+
+	values := map[string]string{}
+	for _, m := range regexChallengeParam.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(m[1])] = m[2]
+	}
+
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid authentication realm %q of the registry %v", values["realm"], c.ref.Registry)
+	}
+
+	query := realm.Query()
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+
+	query.Set("scope", "repository:"+c.ref.Repository+":pull")
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	if c.credential != nil {
+		req.SetBasicAuth(c.credential.Username, c.credential.Password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{URL: realm.Scheme + "://" + realm.Host + realm.Path, StatusCode: resp.StatusCode}
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+		return fmt.Errorf("invalid token of the registry %v, err: %w", c.ref.Registry, err)
+	}
+
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+
+	if c.token == "" {
+		return fmt.Errorf("no token in the authentication response of the registry %v", c.ref.Registry)
+	}
+
+	return nil
+}
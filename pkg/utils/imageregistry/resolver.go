@@ -0,0 +1,222 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageregistry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// cacheTTL is how long the digests and the tags of the registries are cached, so the images of the subscriptions
+// are not resolved with the registries on every reconcile
+const cacheTTL = time.Minute
+
+// Options are the options of the image resolution of a subscription
+type Options struct {
+	// Policy is the semver constraint of the tags the images are updated to, like ~1.25 or >=1.2.0 <2.0.0. The tags
+	// are kept if it is empty
+	Policy string
+	// PinDigest pins the tags of the images to their digests
+	PinDigest bool
+	// Credentials are the credentials of the registries, by registry
+	Credentials map[string]Credential
+}
+
+// ValidatePolicy returns an error if the image update policy is not a valid semver constraint
+func ValidatePolicy(policy string) error {
+	if _, err := semver.NewConstraint(policy); err != nil {
+		return fmt.Errorf("invalid image update policy %q, it must be a semver constraint like ~1.25, err: %w", policy, err)
+	}
+
+	return nil
+}
+
+// LatestTag returns the highest semver tag matching the policy, the tags that are not semver versions are ignored.
+// It returns an empty string if no tag matches.
+func LatestTag(tags []string, policy string) (string, error) {
+	constraint, err := semver.NewConstraint(policy)
+	if err != nil {
+		return "", fmt.Errorf("invalid image update policy %q, err: %w", policy, err)
+	}
+
+	latestTag := ""
+
+	var latest *semver.Version
+
+	for _, tag := range tags {
+		version, err := semver.NewVersion(tag)
+		if err != nil || !constraint.Check(version) {
+			continue
+		}
+
+		if latest == nil || version.GreaterThan(latest) {
+			latest, latestTag = version, tag
+		}
+	}
+
+	return latestTag, nil
+}
+
+// Resolve returns the image updated to the latest tag of the policy and pinned to its digest by the options. The
+// images already pinned to a digest are returned unchanged. The digests and the tags are cached for a minute.
+func Resolve(ctx context.Context, image string, opts Options) (string, error) {
+	return defaultCache.resolve(ctx, image, opts)
+}
+
+type cacheEntry struct {
+	digest    string
+	tags      []string
+	expiresAt time.Time
+}
+
+// registryCache caches the digests of the tags and the tags of the repositories, keyed by the image and the user
+// name of the registry credential
+type registryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+var defaultCache = newRegistryCache()
+
+func newRegistryCache() *registryCache {
+	return &registryCache{
+		entries: map[string]cacheEntry{},
+		now:     time.Now,
+	}
+}
+
+func (c *registryCache) resolve(ctx context.Context, image string, opts Options) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.Digest != "" {
+		return image, nil
+	}
+
+	client := &registryClient{ref: ref}
+	if credential, ok := opts.Credentials[ref.Registry]; ok {
+		client.credential = &credential
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	if opts.Policy != "" {
+		tags, err := c.getTags(ctx, client)
+		if err != nil {
+			return "", fmt.Errorf("failed to list the tags of the image %v, err: %w", ref.Name, err)
+		}
+
+		latest, err := LatestTag(tags, opts.Policy)
+		if err != nil {
+			return "", err
+		}
+
+		// the images without a tag matching the policy keep their tag
+		if latest != "" {
+			ref.Tag = latest
+		}
+	}
+
+	if opts.PinDigest {
+		if ref.Digest, err = c.getDigest(ctx, client); err != nil {
+			return "", fmt.Errorf("failed to resolve the digest of the image %v, err: %w", ref.String(), err)
+		}
+	}
+
+	return ref.String(), nil
+}
+
+func (c *registryCache) key(client *registryClient, suffix string) string {
+	key := client.ref.Registry + "/" + client.ref.Repository + suffix
+	if client.credential != nil {
+		key += "|" + client.credential.Username
+	}
+
+	return key
+}
+
+func (c *registryCache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (c *registryCache) set(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the expired entries are dropped, so the images no longer deployed are not kept
+	for k, e := range c.entries {
+		if !c.now().Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	entry.expiresAt = c.now().Add(cacheTTL)
+	c.entries[key] = entry
+}
+
+func (c *registryCache) getDigest(ctx context.Context, client *registryClient) (string, error) {
+	key := c.key(client, ":"+client.ref.Tag)
+
+	if entry, ok := c.get(key); ok {
+		return entry.digest, nil
+	}
+
+	digest, err := client.getDigest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.Contains(digest, ":") {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+
+	c.set(key, cacheEntry{digest: digest})
+
+	return digest, nil
+}
+
+func (c *registryCache) getTags(ctx context.Context, client *registryClient) ([]string, error) {
+	key := c.key(client, "")
+
+	if entry, ok := c.get(key); ok {
+		return entry.tags, nil
+	}
+
+	tags, err := client.listTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.set(key, cacheEntry{tags: tags})
+
+	return tags, nil
+}
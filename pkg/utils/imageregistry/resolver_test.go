@@ -0,0 +1,184 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageregistry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+// newTestRegistry starts a registry of the org/app repository, its API requires the bearer token of its token realm
+// and the token realm requires the user credential. It returns the registry host and the number of API requests.
+func newTestRegistry(t *testing.T) (string, *int32) {
+	requests := new(int32)
+
+	var server *httptest.Server
+
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "registry-token"})
+
+			return
+		}
+
+		atomic.AddInt32(requests, 1)
+
+		if r.Header.Get("Authorization") != "Bearer registry-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test-registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/org/app/tags/list":
+			_ = json.NewEncoder(w).Encode(map[string][]string{"tags": {"1.2.0", "1.2.5", "1.3.0", "latest", "dev"}})
+		case "/v2/org/app/manifests/1.2.5":
+			w.Header().Set("Docker-Content-Digest", "sha256:125")
+		case "/v2/org/app/manifests/latest":
+			w.Header().Set("Docker-Content-Digest", "sha256:latest")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	prevClient := httpClient
+	httpClient = server.Client()
+
+	t.Cleanup(func() { httpClient = prevClient })
+
+	return strings.TrimPrefix(server.URL, "https://"), requests
+}
+
+func TestResolve(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	host, requests := newTestRegistry(t)
+	cache := newRegistryCache()
+	ctx := context.TODO()
+
+	opts := Options{PinDigest: true, Credentials: map[string]Credential{host: {Username: "user", Password: "pass"}}}
+
+	// the mutable tag is pinned to its digest
+	image, err := cache.resolve(ctx, host+"/org/app", opts)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(image).To(gomega.Equal(host + "/org/app:latest@sha256:latest"))
+
+	// the tag is updated to the latest tag of the policy and pinned to its digest
+	opts.Policy = "~1.2"
+
+	image, err = cache.resolve(ctx, host+"/org/app:1.2.0", opts)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(image).To(gomega.Equal(host + "/org/app:1.2.5@sha256:125"))
+
+	// the digests and the tags are cached
+	count := atomic.LoadInt32(requests)
+
+	image, err = cache.resolve(ctx, host+"/org/app:1.2.0", opts)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(image).To(gomega.Equal(host + "/org/app:1.2.5@sha256:125"))
+	g.Expect(atomic.LoadInt32(requests)).To(gomega.Equal(count))
+
+	// the images already pinned are unchanged
+	image, err = cache.resolve(ctx, host+"/org/app:1.0@sha256:100", opts)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(image).To(gomega.Equal(host + "/org/app:1.0@sha256:100"))
+
+	// the token realm rejects the requests without the credential
+	_, err = newRegistryCache().resolve(ctx, host+"/org/app:1.2.0", Options{PinDigest: true})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the missing tags fail the resolution
+	_, err = cache.resolve(ctx, host+"/org/app:2.0", Options{PinDigest: true, Credentials: opts.Credentials})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("status 404"))
+}
+
+func TestRegistryCacheExpiry(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := time.Now()
+	cache := newRegistryCache()
+	cache.now = func() time.Time { return now }
+
+	cache.set("quay.io/org/app:1.0", cacheEntry{digest: "sha256:abc"})
+
+	entry, ok := cache.get("quay.io/org/app:1.0")
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(entry.digest).To(gomega.Equal("sha256:abc"))
+
+	now = now.Add(cacheTTL)
+
+	_, ok = cache.get("quay.io/org/app:1.0")
+	g.Expect(ok).To(gomega.BeFalse())
+
+	// the expired entries are dropped on the next set
+	cache.set("quay.io/org/app:2.0", cacheEntry{digest: "sha256:def"})
+	g.Expect(cache.entries).To(gomega.HaveLen(1))
+}
+
+func TestLatestTag(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tags := []string{"1.24.0", "1.25.1", "1.25.3", "v1.25.2", "1.26.0", "latest"}
+
+	tag, err := LatestTag(tags, "~1.25")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(tag).To(gomega.Equal("1.25.3"))
+
+	tag, err = LatestTag(tags, ">=2.0.0")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(tag).To(gomega.BeEmpty())
+
+	_, err = LatestTag(tags, "not a constraint")
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(ValidatePolicy("not a constraint")).To(gomega.HaveOccurred())
+	g.Expect(ValidatePolicy(">=1.2.0 <2.0.0")).To(gomega.Succeed())
+}
+
+func TestCredentialsFromDockerConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:secret"))
+
+	credentials, err := CredentialsFromDockerConfig([]byte(`{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + auth + `"},
+		"quay.io": {"username": "user", "password": "pass"}}}`))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(credentials).To(gomega.Equal(map[string]Credential{
+		"docker.io": {Username: "robot", Password: "secret"},
+		"quay.io":   {Username: "user", Password: "pass"},
+	}))
+
+	_, err = CredentialsFromDockerConfig([]byte(`{"auths": {"quay.io": {"auth": "not base64!"}}}`))
+	g.Expect(err).To(gomega.HaveOccurred())
+}
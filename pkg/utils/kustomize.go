@@ -18,11 +18,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"strings"
 
 	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
@@ -31,9 +34,111 @@ import (
 	"sigs.k8s.io/kustomize/kyaml/filesys"
 )
 
+const (
+	// KustomizeOptionHelm permits the kustomize-enable-helm subscription annotation
+	KustomizeOptionHelm = "helm"
+	// KustomizeOptionLoadRestrictionsNone permits the LoadRestrictionsNone kustomize-load-restrictor annotation
+	KustomizeOptionLoadRestrictionsNone = "load-restrictions-none"
+	// kustomizeOptionExec is refused, the exec KRM functions run unsandboxed with the privileges of the subscription pod
+	kustomizeOptionExec = "exec"
+)
+
+// kustomizeAllowedOptions are the kustomize build options the subscriptions are permitted to enable
+var kustomizeAllowedOptions = map[string]bool{KustomizeOptionHelm: true, KustomizeOptionLoadRestrictionsNone: true}
+
+// SetKustomizeAllowedOptions sets the kustomize build options the subscriptions are permitted to enable
+func SetKustomizeAllowedOptions(options []string) error {
+	allowed := map[string]bool{}
+
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+
+		switch option {
+		case "":
+			continue
+		case KustomizeOptionHelm, KustomizeOptionLoadRestrictionsNone:
+			allowed[option] = true
+		case kustomizeOptionExec:
+			return fmt.Errorf("the kustomize build option %q is not supported, the exec KRM functions would run "+
+				"unsandboxed with the privileges of the subscription pod", option)
+		default:
+			return fmt.Errorf("invalid kustomize build option %q, the valid options are %v and %v", option,
+				KustomizeOptionHelm, KustomizeOptionLoadRestrictionsNone)
+		}
+	}
+
+	kustomizeAllowedOptions = allowed
+
+	return nil
+}
+
+// SetKustomizeBuildOptions sets the kustomize build options from the subscription annotations. An error is returned
+// if the subscription enables an option that is not permitted, or the exec KRM functions.
+func SetKustomizeBuildOptions(opts *KustomizeBuildOptions, sub *appv1.Subscription) error {
+	annotations := sub.GetAnnotations()
+
+	isEnabled := func(annotation, option string) (bool, error) {
+		if !strings.EqualFold(annotations[annotation], "true") {
+			return false, nil
+		}
+
+		if !kustomizeAllowedOptions[option] {
+			return false, fmt.Errorf("the %v annotation is not permitted by the kustomize build policy", annotation)
+		}
+
+		return true, nil
+	}
+
+	var err error
+
+	if opts.EnableHelm, err = isEnabled(appv1.AnnotationKustomizeEnableHelm, KustomizeOptionHelm); err != nil {
+		return err
+	}
+
+	if strings.EqualFold(annotations[appv1.AnnotationKustomizeEnableExec], "true") {
+		return fmt.Errorf("the %v annotation is not supported, the exec KRM functions would run unsandboxed with the "+
+			"privileges of the subscription pod", appv1.AnnotationKustomizeEnableExec)
+	}
+
+	// without the annotation, the kustomizations load files outside of their root directory if the policy permits it
+	switch restrictor := annotations[appv1.AnnotationKustomizeLoadRestrictor]; restrictor {
+	case "":
+		opts.LoadRestrictionsRootOnly = !kustomizeAllowedOptions[KustomizeOptionLoadRestrictionsNone]
+	case kustomizetypes.LoadRestrictionsRootOnly.String():
+		opts.LoadRestrictionsRootOnly = true
+	case kustomizetypes.LoadRestrictionsNone.String():
+		if !kustomizeAllowedOptions[KustomizeOptionLoadRestrictionsNone] {
+			return fmt.Errorf("the %v annotation %v is not permitted by the kustomize build policy",
+				appv1.AnnotationKustomizeLoadRestrictor, restrictor)
+		}
+
+		opts.LoadRestrictionsRootOnly = false
+	default:
+		return fmt.Errorf("invalid %v annotation %v, the valid values are %v and %v", appv1.AnnotationKustomizeLoadRestrictor,
+			restrictor, kustomizetypes.LoadRestrictionsRootOnly, kustomizetypes.LoadRestrictionsNone)
+	}
+
+	return nil
+}
+
 // RunKustomizeBuild runs kustomize build and returns the build output
 func RunKustomizeBuild(kustomizeDir string) ([]byte, error) {
-	fSys := filesys.MakeFsOnDisk()
+	return RunKustomizeBuildWithOptions(kustomizeDir, nil)
+}
+
+// RunKustomizeBuildWithOptions runs kustomize build and returns the build output. The remote resources, bases
+// and components are fetched first with the channel connections of the options.
+func RunKustomizeBuildWithOptions(kustomizeDir string, opts *KustomizeBuildOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &KustomizeBuildOptions{}
+	}
+
+	helmCharts, err := fetchKustomizeRemoteResources(kustomizeDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fSys := opts.fileSystem()
 
 	// Allow external plugins when executing Kustomize. This is required to support the policy
 	// generator. This builtin plugin loading option is the default value and is recommended
@@ -42,9 +147,41 @@ func RunKustomizeBuild(kustomizeDir string) ([]byte, error) {
 		kustomizetypes.PluginRestrictionsNone,
 		kustomizetypes.BploUseStaticallyLinked,
 	)
+
+	// the helmCharts are already inflated with the helm library, the deprecated helmChartInflationGenerator runs the
+	// helm command
+	if helmCharts {
+		if !opts.EnableHelm {
+			return nil, fmt.Errorf("the kustomization in %v inflates helmCharts, set the %v annotation to enable helm",
+				kustomizeDir, appv1.AnnotationKustomizeEnableHelm)
+		}
+
+		// the helm command reads the charts on the disk
+		if opts.FileSystem != nil {
+			return nil, fmt.Errorf("the kustomization in %v uses the helmChartInflationGenerator, it is not supported "+
+				"by the in-memory Git render, use helmCharts instead", kustomizeDir)
+		}
+
+		helmCommand, err := exec.LookPath("helm")
+		if err != nil {
+			return nil, fmt.Errorf("the kustomization in %v uses the helmChartInflationGenerator, the helm command is not "+
+				"found: %w", kustomizeDir, err)
+		}
+
+		pluginConfig.HelmConfig = kustomizetypes.HelmConfig{
+			Enabled: true,
+			Command: helmCommand,
+		}
+	}
+
 	options := &krusty.Options{
-		Reorder:      krusty.ReorderOptionLegacy,
-		PluginConfig: pluginConfig,
+		Reorder:          krusty.ReorderOptionLegacy,
+		LoadRestrictions: kustomizetypes.LoadRestrictionsNone,
+		PluginConfig:     pluginConfig,
+	}
+
+	if opts.LoadRestrictionsRootOnly {
+		options.LoadRestrictions = kustomizetypes.LoadRestrictionsRootOnly
 	}
 
 	k := krusty.MakeKustomizer(options)
@@ -62,6 +199,23 @@ func RunKustomizeBuild(kustomizeDir string) ([]byte, error) {
 	return byteOut, nil
 }
 
+// GetKustomizeOverlayPath returns the path of the first kustomize overlay selecting the cluster labels, empty if
+// none of the overlays selects the cluster
+func GetKustomizeOverlayPath(overlays []appv1.KustomizeOverlay, clusterLabels map[string]string) (string, error) {
+	for _, overlay := range overlays {
+		selector, err := metav1.LabelSelectorAsSelector(overlay.ClusterSelector)
+		if err != nil {
+			return "", fmt.Errorf("invalid cluster selector of the kustomize overlay %v: %w", overlay.Path, err)
+		}
+
+		if selector.Matches(labels.Set(clusterLabels)) {
+			return overlay.Path, nil
+		}
+	}
+
+	return "", nil
+}
+
 func CheckPackageOverride(ov *appv1.Overrides) error {
 	if ov.PackageOverrides == nil || len(ov.PackageOverrides) < 1 {
 		return errors.New("no PackageOverride is specified. Skipping to override kustomization")
@@ -71,6 +225,13 @@ func CheckPackageOverride(ov *appv1.Overrides) error {
 }
 
 func VerifyAndOverrideKustomize(packageOverrides []*appv1.Overrides, relativePath, kustomizeDir string) error {
+	return VerifyAndOverrideKustomizeInFileSystem(filesys.MakeFsOnDisk(), packageOverrides, relativePath, kustomizeDir)
+}
+
+// VerifyAndOverrideKustomizeInFileSystem overrides the kustomization of the directory of the file system with the
+// package overrides of the subscription
+func VerifyAndOverrideKustomizeInFileSystem(fSys filesys.FileSystem, packageOverrides []*appv1.Overrides,
+	relativePath, kustomizeDir string) error {
 	for _, ov := range packageOverrides {
 		ovKustomizeDir := strings.Split(ov.PackageName, "kustomization")[0]
 
@@ -86,7 +247,7 @@ func VerifyAndOverrideKustomize(packageOverrides []*appv1.Overrides, relativePat
 				klog.Info("Overriding kustomization ", kustomizeDir)
 
 				pov := ov.PackageOverrides[0] // there is only one override for kustomization.yaml
-				err := OverrideKustomize(pov, kustomizeDir)
+				err := overrideKustomize(fSys, pov, kustomizeDir)
 
 				if err != nil {
 					klog.Errorf("Failed to override kustomization. error: %v", err)
@@ -100,6 +261,10 @@ func VerifyAndOverrideKustomize(packageOverrides []*appv1.Overrides, relativePat
 }
 
 func OverrideKustomize(pov appv1.PackageOverride, kustomizeDir string) error {
+	return overrideKustomize(filesys.MakeFsOnDisk(), pov, kustomizeDir)
+}
+
+func overrideKustomize(fSys filesys.FileSystem, pov appv1.PackageOverride, kustomizeDir string) error {
 	kustomizeOverride := appv1.ClusterOverride(pov)
 	ovuobj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&kustomizeOverride)
 
@@ -135,15 +300,15 @@ func OverrideKustomize(pov appv1.PackageOverride, kustomizeDir string) error {
 
 	kustomizeYamlFilePath := filepath.Join(kustomizeDir, "kustomization.yaml")
 
-	if _, err := os.Stat(kustomizeYamlFilePath); os.IsNotExist(err) {
+	if !fSys.Exists(kustomizeYamlFilePath) {
 		kustomizeYamlFilePath = filepath.Join(kustomizeDir, "kustomization.yml")
-		if _, err := os.Stat(kustomizeYamlFilePath); os.IsNotExist(err) {
+		if !fSys.Exists(kustomizeYamlFilePath) {
 			klog.Error("Kustomization file not found in ", kustomizeDir)
-			return err
+			return fmt.Errorf("kustomization file not found in %v: %w", kustomizeDir, os.ErrNotExist)
 		}
 	}
 
-	err = mergeKustomization(kustomizeYamlFilePath, override)
+	err = mergeKustomization(fSys, kustomizeYamlFilePath, override)
 	if err != nil {
 		return err
 	}
@@ -151,10 +316,10 @@ func OverrideKustomize(pov appv1.PackageOverride, kustomizeDir string) error {
 	return nil
 }
 
-func mergeKustomization(kustomizeYamlFilePath string, override map[string]interface{}) error {
+func mergeKustomization(fSys filesys.FileSystem, kustomizeYamlFilePath string, override map[string]interface{}) error {
 	var master map[string]interface{}
 
-	bs, err := os.ReadFile(kustomizeYamlFilePath) // #nosec G304 constructed filepath.Join(kustomizeDir, "kustomization.yaml")
+	bs, err := fSys.ReadFile(kustomizeYamlFilePath)
 
 	if err != nil {
 		klog.Error("Failed to read file ", kustomizeYamlFilePath, " err: ", err)
@@ -177,7 +342,7 @@ func mergeKustomization(kustomizeYamlFilePath string, override map[string]interf
 		return err
 	}
 
-	if err := os.WriteFile(kustomizeYamlFilePath, bs, 0600); err != nil {
+	if err := fSys.WriteFile(kustomizeYamlFilePath, bs); err != nil {
 		klog.Error("Failed to overwrite kustomize file ", " err: ", err)
 		return err
 	}
@@ -0,0 +1,131 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kustomizetypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// newBenchmarkGitRepo creates a local Git repository with a kustomization inflating a helm chart
+func newBenchmarkGitRepo(b *testing.B) string {
+	dir := b.TempDir()
+
+	files := map[string]string{
+		"kustomization.yaml": "namePrefix: prod-\nhelmCharts:\n- name: web\n  releaseName: shop\n  valuesInline:\n" +
+			"    color: red\n",
+	}
+
+	for name, content := range testKustomizeHelmChart {
+		files[name] = content
+	}
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0750); err != nil {
+			b.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if err := worktree.AddGlob("."); err != nil {
+		b.Fatal(err)
+	}
+
+	if _, err := worktree.Commit("benchmark", &git.CommitOptions{
+		Author: &object.Signature{Name: "benchmark", Email: "benchmark@example.com", When: time.Now()},
+	}); err != nil {
+		b.Fatal(err)
+	}
+
+	return dir
+}
+
+// BenchmarkCloneAndBuild compares the clone and kustomize build of a repository inflating a helm chart with the Git
+// and helm libraries, as the subscriptions do, and with the git and helm commands
+func BenchmarkCloneAndBuild(b *testing.B) {
+	repoDir := newBenchmarkGitRepo(b)
+
+	b.Run("libraries", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dest := filepath.Join(b.TempDir(), "clone")
+
+			// the clone options of CloneGitRepo, which only clones the HTTP and SSH URLs
+			if _, err := git.PlainClone(dest, false, &git.CloneOptions{URL: repoDir, SingleBranch: true, Depth: 1}); err != nil {
+				b.Fatal(err)
+			}
+
+			if _, err := RunKustomizeBuildWithOptions(dest, &KustomizeBuildOptions{EnableHelm: true}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("commands", func(b *testing.B) {
+		gitCommand, err := exec.LookPath("git")
+		if err != nil {
+			b.Skip("the git command is not found")
+		}
+
+		helmCommand, err := exec.LookPath("helm")
+		if err != nil {
+			b.Skip("the helm command is not found")
+		}
+
+		pluginConfig := kustomizetypes.MakePluginConfig(kustomizetypes.PluginRestrictionsNone,
+			kustomizetypes.BploUseStaticallyLinked)
+		pluginConfig.HelmConfig = kustomizetypes.HelmConfig{Enabled: true, Command: helmCommand}
+
+		for i := 0; i < b.N; i++ {
+			dest := filepath.Join(b.TempDir(), "clone")
+
+			// #nosec G204 the benchmark repository
+			if err := exec.Command(gitCommand, "clone", "--quiet", "--single-branch", "--depth=1", "file://"+repoDir, dest).Run(); err != nil {
+				b.Fatal(err)
+			}
+
+			k := krusty.MakeKustomizer(&krusty.Options{
+				Reorder:          krusty.ReorderOptionLegacy,
+				LoadRestrictions: kustomizetypes.LoadRestrictionsNone,
+				PluginConfig:     pluginConfig,
+			})
+
+			if _, err := k.Run(filesys.MakeFsOnDisk(), dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
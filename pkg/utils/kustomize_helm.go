@@ -0,0 +1,468 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"helm.sh/helm/v3/pkg/action"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/ignore"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/klog"
+	kustomizetypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/merge2"
+)
+
+const (
+	// the helm charts of a kustomization are rendered in this directory of the kustomization
+	kustomizeHelmDir = ".kustomize-helm"
+
+	valuesMergeOverride = "override"
+	valuesMergeMerge    = "merge"
+	valuesMergeReplace  = "replace"
+)
+
+// inflateKustomizeHelmCharts renders the helmCharts of the kustomization of the directory with the helm library, writes
+// the manifests in the helm directory of the kustomization and lists them in its resources instead of the helmCharts,
+// so the kustomize build doesn't run the helm command. The charts and the manifests are in the file system of the
+// options.
+func inflateKustomizeHelmCharts(dir string, kustomization map[string]interface{}, opts *KustomizeBuildOptions) error {
+	var (
+		charts  []kustomizetypes.HelmChart
+		globals kustomizetypes.HelmGlobals
+	)
+
+	if err := convertKustomizeField(kustomization["helmCharts"], &charts); err != nil {
+		return fmt.Errorf("invalid helmCharts: %w", err)
+	}
+
+	if err := convertKustomizeField(kustomization["helmGlobals"], &globals); err != nil {
+		return fmt.Errorf("invalid helmGlobals: %w", err)
+	}
+
+	chartHome := globals.ChartHome
+	if chartHome == "" {
+		chartHome = kustomizetypes.HelmDefaultHome
+	}
+
+	if !filepath.IsAbs(chartHome) {
+		chartHome = filepath.Join(dir, chartHome)
+	}
+
+	fSys := opts.fileSystem()
+
+	if err := fSys.MkdirAll(filepath.Join(dir, kustomizeHelmDir)); err != nil {
+		return err
+	}
+
+	resources, _ := kustomization["resources"].([]interface{})
+
+	for i := range charts {
+		manifest, err := renderKustomizeHelmChart(dir, chartHome, &charts[i], opts)
+		if err != nil {
+			return fmt.Errorf("chart %v: %w", charts[i].Name, err)
+		}
+
+		if strings.TrimSpace(manifest) == "" {
+			klog.Infof("The helm chart %v of the kustomization in %v has no resource", charts[i].Name, dir)
+
+			continue
+		}
+
+		file := filepath.Join(kustomizeHelmDir, fmt.Sprintf("%d-%v.yaml", i, filepath.Base(charts[i].Name)))
+
+		if err := fSys.WriteFile(filepath.Join(dir, file), []byte(manifest)); err != nil {
+			return err
+		}
+
+		resources = append(resources, file)
+	}
+
+	delete(kustomization, "helmCharts")
+	delete(kustomization, "helmGlobals")
+
+	kustomization["resources"] = resources
+
+	return nil
+}
+
+// renderKustomizeHelmChart renders a helm chart like the helm template command run by kustomize. The chart is pulled
+// in memory if it is not found in the chart home. Without release name, the chart name is the release name.
+func renderKustomizeHelmChart(dir, chartHome string, chart *kustomizetypes.HelmChart,
+	opts *KustomizeBuildOptions) (string, error) {
+	if chart.Name == "" {
+		return "", fmt.Errorf("the chart name is empty")
+	}
+
+	// kustomize pulls the charts with a version in their own directory
+	if chart.Version != "" && chart.Repo != "" {
+		chartHome = filepath.Join(chartHome, fmt.Sprintf("%v-%v", chart.Name, chart.Version))
+	}
+
+	chartPath := filepath.Join(chartHome, chart.Name)
+	fSys := opts.fileSystem()
+
+	var (
+		chrt *helmchart.Chart
+		err  error
+	)
+
+	if fSys.IsDir(chartPath) {
+		chrt, err = loadKustomizeHelmChart(fSys, chartPath)
+	} else {
+		if chart.Repo == "" {
+			return "", fmt.Errorf("no repo specified for pull, no chart found at %v", chartPath)
+		}
+
+		if chrt, err = pullKustomizeHelmChart(chart); err != nil {
+			err = fmt.Errorf("failed to pull the chart from %v: %w", chart.Repo, err)
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	values, err := getKustomizeHelmValues(dir, chrt, chart, opts)
+	if err != nil {
+		return "", err
+	}
+
+	install := action.NewInstall(&action.Configuration{Log: func(_ string, _ ...interface{}) {}})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = chart.ReleaseName
+	install.Namespace = chart.Namespace
+	install.IncludeCRDs = chart.IncludeCRDs
+	install.DisableHooks = chart.SkipHooks
+	install.APIVersions = chart.ApiVersions
+
+	if install.Namespace == "" {
+		install.Namespace = "default"
+	}
+
+	if install.ReleaseName == "" {
+		install.ReleaseName = chart.Name
+
+		if chart.NameTemplate != "" {
+			if install.ReleaseName, err = action.TemplateName(chart.NameTemplate); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if chart.KubeVersion != "" {
+		if install.KubeVersion, err = chartutil.ParseKubeVersion(chart.KubeVersion); err != nil {
+			return "", err
+		}
+	}
+
+	rel, err := install.Run(chrt, values)
+	if err != nil {
+		return "", err
+	}
+
+	manifest := &strings.Builder{}
+	manifest.WriteString(rel.Manifest)
+
+	if !chart.SkipHooks {
+		for _, hook := range rel.Hooks {
+			if chart.SkipTests && isHelmTestHook(hook) {
+				continue
+			}
+
+			fmt.Fprintf(manifest, "\n---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+		}
+	}
+
+	return manifest.String(), nil
+}
+
+// loadKustomizeHelmChart loads the chart of the directory of the file system, without the files of its .helmignore
+func loadKustomizeHelmChart(fSys filesys.FileSystem, chartPath string) (*helmchart.Chart, error) {
+	rules := ignore.Empty()
+
+	if bs, err := fSys.ReadFile(filepath.Join(chartPath, ignore.HelmIgnore)); err == nil {
+		if rules, err = ignore.Parse(bytes.NewReader(bs)); err != nil {
+			return nil, err
+		}
+	}
+
+	rules.AddDefaults()
+
+	files := []*loader.BufferedFile{}
+
+	err := fSys.Walk(chartPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(chartPath, path)
+		if err != nil || name == "." {
+			return err
+		}
+
+		name = filepath.ToSlash(name)
+
+		if rules.Ignore(name, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		bs, err := fSys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, &loader.BufferedFile{Name: name, Data: bs})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.LoadFiles(files)
+}
+
+// pullKustomizeHelmChart downloads the chart from its helm or OCI repository and loads it in memory
+func pullKustomizeHelmChart(chart *kustomizetypes.HelmChart) (*helmchart.Chart, error) {
+	klog.Infof("Pulling the helm chart %v %v from %v", chart.Name, chart.Version, chart.Repo)
+
+	if registry.IsOCI(chart.Repo) {
+		return pullKustomizeHelmOCIChart(chart)
+	}
+
+	httpGetter, err := getter.NewHTTPGetter(getter.WithURL(chart.Repo))
+	if err != nil {
+		return nil, err
+	}
+
+	repoURL := strings.TrimSuffix(chart.Repo, "/")
+
+	buf, err := httpGetter.Get(repoURL + "/index.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(buf.Bytes(), index); err != nil {
+		return nil, fmt.Errorf("failed to parse the index of the repository: %w", err)
+	}
+
+	index.SortEntries()
+
+	chartVersion, err := index.Get(chart.Name, chart.Version)
+	if err != nil {
+		return nil, fmt.Errorf("chart %v %v not found: %w", chart.Name, chart.Version, err)
+	}
+
+	if len(chartVersion.URLs) == 0 {
+		return nil, fmt.Errorf("chart %v %v has no URL", chart.Name, chartVersion.Version)
+	}
+
+	chartURL, err := repo.ResolveReferenceURL(repoURL, chartVersion.URLs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if buf, err = httpGetter.Get(chartURL); err != nil {
+		return nil, err
+	}
+
+	return loader.LoadArchive(buf)
+}
+
+// pullKustomizeHelmOCIChart pulls the chart from its OCI registry, the latest version matching the version constraint
+// of the chart if it is not an exact version
+func pullKustomizeHelmOCIChart(chart *kustomizetypes.HelmChart) (*helmchart.Chart, error) {
+	registryClient, err := registry.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ref := strings.TrimPrefix(strings.TrimSuffix(chart.Repo, "/"), "oci://") + "/" + chart.Name
+
+	tags, err := registryClient.Tags(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := registry.GetTagMatchingVersionOrConstraint(tags, chart.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := registryClient.Pull(ref+":"+tag, registry.PullOptWithChart(true))
+	if err != nil {
+		return nil, err
+	}
+
+	return loader.LoadArchive(bytes.NewReader(result.Chart.Data))
+}
+
+// getKustomizeHelmValues returns the values of the chart: the values file, or the values of the chart, merged with the
+// inline values according to the valuesMerge option, then with the additional values files
+func getKustomizeHelmValues(dir string, chrt *helmchart.Chart, chart *kustomizetypes.HelmChart,
+	opts *KustomizeBuildOptions) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	if chart.ValuesFile != "" {
+		fileValues, err := readKustomizeHelmValues(dir, chart.ValuesFile, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		values = fileValues
+	} else if chrt.Values != nil {
+		values = chrt.Values
+	}
+
+	if len(chart.ValuesInline) != 0 {
+		merged, err := mergeKustomizeHelmValues(values, chart.ValuesInline, chart.ValuesMerge)
+		if err != nil {
+			return nil, err
+		}
+
+		values = merged
+	}
+
+	for _, file := range chart.AdditionalValuesFiles {
+		fileValues, err := readKustomizeHelmValues(dir, file, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		values = chartutil.MergeTables(fileValues, values)
+	}
+
+	return values, nil
+}
+
+// mergeKustomizeHelmValues merges the inline values with the values file like kustomize: the inline values take
+// precedence with the override option, the values file with the merge option, and replace the values file with the
+// replace option
+func mergeKustomizeHelmValues(values, inline map[string]interface{}, valuesMerge string) (map[string]interface{}, error) {
+	if valuesMerge == "" {
+		valuesMerge = valuesMergeOverride
+	}
+
+	if valuesMerge == valuesMergeReplace {
+		return inline, nil
+	}
+
+	if valuesMerge != valuesMergeOverride && valuesMerge != valuesMergeMerge {
+		return nil, fmt.Errorf("valuesMerge must be one of %v, %v or %v", valuesMergeMerge, valuesMergeOverride,
+			valuesMergeReplace)
+	}
+
+	fileNode, err := kyaml.FromMap(values)
+	if err != nil {
+		return nil, err
+	}
+
+	inlineNode, err := kyaml.FromMap(inline)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged *kyaml.RNode
+
+	if valuesMerge == valuesMergeOverride {
+		merged, err = merge2.Merge(inlineNode, fileNode, kyaml.MergeOptions{})
+	} else {
+		merged, err = merge2.Merge(fileNode, inlineNode, kyaml.MergeOptions{})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return merged.Map()
+}
+
+// readKustomizeHelmValues reads a values file of the kustomization, relative to the kustomization directory. With the
+// LoadRestrictionsRootOnly option, the values file must be in the kustomization directory.
+func readKustomizeHelmValues(dir, file string, opts *KustomizeBuildOptions) (map[string]interface{}, error) {
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, file)
+	}
+
+	if opts.LoadRestrictionsRootOnly {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("the values file %v is outside of the kustomization directory %v", file, dir)
+		}
+	}
+
+	bs, err := opts.fileSystem().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(bs, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse the values file %v: %w", file, err)
+	}
+
+	return values, nil
+}
+
+// convertKustomizeField converts a field of the kustomization map to its kustomize type
+func convertKustomizeField(field, out interface{}) error {
+	if field == nil {
+		return nil
+	}
+
+	bs, err := yaml.Marshal(field)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(bs, out)
+}
+
+func isHelmTestHook(hook *release.Hook) bool {
+	for _, event := range hook.Events {
+		if event == release.HookTest {
+			return true
+		}
+	}
+
+	return false
+}
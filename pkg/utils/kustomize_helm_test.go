@@ -0,0 +1,190 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/onsi/gomega"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+var testKustomizeHelmChart = map[string]string{
+	"charts/web/Chart.yaml":  "apiVersion: v2\nname: web\nversion: 1.0.0\n",
+	"charts/web/values.yaml": "color: blue\nsize: small\n",
+	"charts/web/templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n" +
+		"  namespace: {{ .Release.Namespace }}\ndata:\n  color: {{ .Values.color }}\n  size: {{ .Values.size }}\n",
+	"charts/web/templates/test.yaml": "apiVersion: v1\nkind: Pod\nmetadata:\n  name: {{ .Release.Name }}-test\n" +
+		"  annotations:\n    helm.sh/hook: test\nspec:\n  containers:\n  - name: test\n    image: busybox\n",
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0750); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunKustomizeBuildHelmCharts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, testKustomizeHelmChart)
+	writeTestFiles(t, dir, map[string]string{
+		"prod.yaml": "size: large\n",
+		"kustomization.yaml": "namePrefix: prod-\nhelmCharts:\n- name: web\n  releaseName: shop\n  namespace: apps\n" +
+			"  skipTests: true\n  valuesInline:\n    color: red\n  additionalValuesFiles:\n  - prod.yaml\n",
+	})
+
+	out, err := RunKustomizeBuildWithOptions(dir, &KustomizeBuildOptions{EnableHelm: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).To(gomega.Equal("apiVersion: v1\ndata:\n  color: red\n  size: large\nkind: ConfigMap\n" +
+		"metadata:\n  name: prod-shop\n  namespace: apps\n"))
+
+	// the helm charts are disabled
+	dir = t.TempDir()
+
+	writeTestFiles(t, dir, testKustomizeHelmChart)
+	writeTestFiles(t, dir, map[string]string{"kustomization.yaml": "helmCharts:\n- name: web\n"})
+
+	_, err = RunKustomizeBuildWithOptions(dir, &KustomizeBuildOptions{})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the test hooks are rendered without skipTests
+	out, err = RunKustomizeBuildWithOptions(dir, &KustomizeBuildOptions{EnableHelm: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).To(gomega.ContainSubstring("name: web-test"))
+	g.Expect(string(out)).To(gomega.ContainSubstring("color: blue"))
+
+	// the chart is missing without repo
+	dir = t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{"kustomization.yaml": "helmCharts:\n- name: web\n"})
+
+	_, err = RunKustomizeBuildWithOptions(dir, &KustomizeBuildOptions{EnableHelm: true})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("no chart found"))
+}
+
+func TestRunKustomizeBuildInMemory(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	fSys := filesys.MakeFsInMemory()
+	files := map[string]string{
+		"app/kustomization.yaml": "resources:\n- configmap.yaml\nhelmCharts:\n- name: web\n  namespace: apps\n" +
+			"  skipTests: true\nhelmGlobals:\n  chartHome: ../charts\n",
+		"app/configmap.yaml":           "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: apps\n",
+		"generator/kustomization.yaml": "helmChartInflationGenerator:\n- chartName: web\n",
+	}
+
+	for name, content := range testKustomizeHelmChart {
+		files[name] = content
+	}
+
+	for name, content := range files {
+		g.Expect(fSys.WriteFile(filepath.Join("/repo", name), []byte(content))).To(gomega.Succeed())
+	}
+
+	ov := &appv1.Overrides{}
+	g.Expect(yaml.Unmarshal([]byte("packageName: app/kustomization.yaml\npackageOverrides:\n- value: |\n"+
+		"    namePrefix: prod-\n"), ov)).To(gomega.Succeed())
+
+	err := VerifyAndOverrideKustomizeInFileSystem(fSys, []*appv1.Overrides{ov}, "app/", "/repo/app")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	opts := &KustomizeBuildOptions{EnableHelm: true, LoadRestrictionsRootOnly: true, FileSystem: fSys}
+
+	out, err := RunKustomizeBuildWithOptions("/repo/app", opts)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).To(gomega.Equal("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: prod-settings\n" +
+		"  namespace: apps\n---\napiVersion: v1\ndata:\n  color: blue\n  size: small\nkind: ConfigMap\n" +
+		"metadata:\n  name: prod-web\n  namespace: apps\n"))
+
+	// the manifests of the charts are written in the file system, not on the disk
+	g.Expect(fSys.Exists("/repo/app/.kustomize-helm/0-web.yaml")).To(gomega.BeTrue())
+
+	_, err = os.Stat("/repo/app")
+	g.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+
+	// the helm command needs the charts on the disk
+	_, err = RunKustomizeBuildWithOptions("/repo/generator", opts)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("in-memory"))
+}
+
+func TestGetKustomizeHelmValuesLoadRestrictions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, testKustomizeHelmChart)
+	writeTestFiles(t, dir, map[string]string{
+		"values/prod.yaml": "size: large\n",
+		"app/kustomization.yaml": "helmCharts:\n- name: web\n  valuesFile: ../values/prod.yaml\n" +
+			"helmGlobals:\n  chartHome: ../charts\n",
+	})
+
+	_, err := RunKustomizeBuildWithOptions(filepath.Join(dir, "app"), &KustomizeBuildOptions{EnableHelm: true,
+		LoadRestrictionsRootOnly: true})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("outside of the kustomization directory"))
+
+	writeTestFiles(t, dir, map[string]string{
+		"app/kustomization.yaml": "helmCharts:\n- name: web\n  valuesFile: ../values/prod.yaml\n" +
+			"helmGlobals:\n  chartHome: ../charts\n",
+	})
+
+	out, err := RunKustomizeBuildWithOptions(filepath.Join(dir, "app"), &KustomizeBuildOptions{EnableHelm: true})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	// the values file is merged with the chart values
+	g.Expect(string(out)).To(gomega.ContainSubstring("size: large"))
+	g.Expect(string(out)).To(gomega.ContainSubstring("color: blue"))
+}
+
+func TestMergeKustomizeHelmValues(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	file := func() map[string]interface{} {
+		return map[string]interface{}{"color": "blue", "image": map[string]interface{}{"tag": "1.0", "repo": "web"}}
+	}
+	inline := map[string]interface{}{"color": "red", "image": map[string]interface{}{"tag": "2.0"}}
+
+	values, err := mergeKustomizeHelmValues(file(), inline, "")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(values).To(gomega.Equal(map[string]interface{}{"color": "red",
+		"image": map[string]interface{}{"tag": "2.0", "repo": "web"}}))
+
+	values, err = mergeKustomizeHelmValues(file(), inline, "merge")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(values).To(gomega.Equal(file()))
+
+	values, err = mergeKustomizeHelmValues(file(), inline, "replace")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(values).To(gomega.Equal(inline))
+
+	_, err = mergeKustomizeHelmValues(file(), inline, "unknown")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,489 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-git/go-git/v5/plumbing"
+	"k8s.io/klog"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+const (
+	// the remote resources of a kustomization are fetched in this directory of the kustomization
+	kustomizeRemoteDir = ".kustomize-remote"
+	// maximum depth of the local and remote bases followed to fetch the remote resources
+	maxKustomizeDepth = 10
+	// maximum size of a remote file
+	maxKustomizeRemoteFileSize = 10 << 20
+)
+
+var (
+	commitHashRegexp = regexp.MustCompile("^[0-9a-f]{40}$")
+
+	// the hosts accepting github.com/org/repo/path remote bases without the // separator
+	kustomizeShortURLHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+)
+
+// KustomizeBuildOptions defines the options of the kustomize build
+type KustomizeBuildOptions struct {
+	// Connections are the channel connections. The remote bases on the Git server of a channel are fetched with
+	// the channel credentials, the other remote bases are fetched anonymously.
+	Connections []*ChannelConnectionCfg
+	// EnableHelm enables the inflation of the helmCharts with the helm library
+	EnableHelm bool
+	// LoadRestrictionsRootOnly prevents the kustomizations from loading files outside of their root directory
+	LoadRestrictionsRootOnly bool
+	// FileSystem is the file system of the kustomizations, the disk if it is nil. The remote bases are cloned in
+	// memory and the remote resources are written in it if it is set.
+	FileSystem filesys.FileSystem
+}
+
+// fileSystem returns the file system of the kustomizations
+func (opts *KustomizeBuildOptions) fileSystem() filesys.FileSystem {
+	if opts.FileSystem == nil {
+		return filesys.MakeFsOnDisk()
+	}
+
+	return opts.FileSystem
+}
+
+// kustomizeRemoteRepo is a remote base of a kustomization, a Git repository with an optional path and ref
+type kustomizeRemoteRepo struct {
+	repoURL string
+	path    string
+	ref     string
+}
+
+// kustomizationFile returns the kustomization file of the directory, empty if there is none
+func kustomizationFile(fSys filesys.FileSystem, dir string) string {
+	for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		if fSys.Exists(filepath.Join(dir, name)) {
+			return filepath.Join(dir, name)
+		}
+	}
+
+	return ""
+}
+
+// IsKustomizeComponent checks if the kustomization of the directory is a component. A component is only built
+// as part of the kustomizations listing it in their components.
+func IsKustomizeComponent(dir string) bool {
+	return isKustomizeComponent(filesys.MakeFsOnDisk(), dir)
+}
+
+func isKustomizeComponent(fSys filesys.FileSystem, dir string) bool {
+	file := kustomizationFile(fSys, dir)
+	if file == "" {
+		return false
+	}
+
+	bs, err := fSys.ReadFile(file)
+	if err != nil {
+		return false
+	}
+
+	k := struct {
+		Kind string `json:"kind"`
+	}{}
+
+	if err := yaml.Unmarshal(bs, &k); err != nil {
+		return false
+	}
+
+	return k.Kind == "Component"
+}
+
+// isKustomizeRemote checks if a resource, base or component of a kustomization is a remote URL
+func isKustomizeRemote(entry string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git::", "git@"} {
+		if strings.HasPrefix(entry, prefix) {
+			return true
+		}
+	}
+
+	for _, host := range kustomizeShortURLHosts {
+		if strings.HasPrefix(entry, host+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isKustomizeRemoteFile checks if a remote URL is a single file rather than a Git repository
+func isKustomizeRemoteFile(entry string) bool {
+	if !strings.HasPrefix(entry, "https://") && !strings.HasPrefix(entry, "http://") {
+		return false
+	}
+
+	u, err := url.Parse(entry)
+	if err != nil || u.Query().Has("ref") || u.Query().Has("version") || strings.Contains(u.Path, "//") {
+		return false
+	}
+
+	ext := strings.ToLower(filepath.Ext(u.Path))
+
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+// parseKustomizeRemoteRepo parses the remote bases URL formats of kustomize, for example
+// https://github.com/org/repo//path?ref=v1, git@github.com:org/repo.git/path and github.com/org/repo/path?ref=main
+func parseKustomizeRemoteRepo(entry string) kustomizeRemoteRepo {
+	remote := kustomizeRemoteRepo{}
+	s := strings.TrimPrefix(entry, "git::")
+
+	if idx := strings.Index(s, "?"); idx >= 0 {
+		query, _ := url.ParseQuery(s[idx+1:])
+
+		remote.ref = query.Get("ref")
+		if remote.ref == "" {
+			remote.ref = query.Get("version")
+		}
+
+		s = s[:idx]
+	}
+
+	scheme := ""
+
+	switch {
+	case strings.Contains(s, "://"):
+		idx := strings.Index(s, "://")
+		scheme, s = s[:idx+3], s[idx+3:]
+	case !strings.HasPrefix(s, "git@"):
+		scheme = "https://"
+	}
+
+	switch {
+	case strings.Contains(s, "//"):
+		idx := strings.Index(s, "//")
+		s, remote.path = s[:idx], s[idx+2:]
+	case strings.Contains(s, ".git/"):
+		idx := strings.Index(s, ".git/")
+		s, remote.path = s[:idx+4], s[idx+5:]
+	default:
+		// host/org/repo/path
+		segments := strings.Split(strings.Replace(s, ":", "/", 1), "/")
+		if len(segments) > 3 {
+			s = s[:len(strings.Join(segments[:3], "/"))]
+			remote.path = strings.Join(segments[3:], "/")
+		}
+	}
+
+	remote.repoURL = scheme + s
+
+	return remote
+}
+
+// getURLHost returns the host of a Git URL, including the scp like git@host:org/repo URLs
+func getURLHost(rawURL string) string {
+	if strings.HasPrefix(rawURL, "git@") {
+		host := strings.TrimPrefix(rawURL, "git@")
+
+		return strings.SplitN(host, ":", 2)[0]
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
+
+// getKustomizeRemoteConnection returns the connection to fetch a remote URL, with the credentials of the channel
+// on the same host if any
+func getKustomizeRemoteConnection(remoteURL string, opts *KustomizeBuildOptions) *ChannelConnectionCfg {
+	host := getURLHost(remoteURL)
+
+	for _, conn := range opts.Connections {
+		if conn != nil && host != "" && getURLHost(conn.RepoURL) == host {
+			remoteConn := *conn
+			remoteConn.RepoURL = remoteURL
+
+			return &remoteConn
+		}
+	}
+
+	return &ChannelConnectionCfg{RepoURL: remoteURL}
+}
+
+// fetchKustomizeRemoteResources fetches the remote resources, bases, components and plugin configs of the
+// kustomization of the directory and of its local bases, and replaces them with the fetched local copies. The
+// helmCharts are inflated too if helm is enabled, and the exec KRM functions are refused. It returns true if one of the kustomizations still needs the helm command.
+func fetchKustomizeRemoteResources(kustomizeDir string, opts *KustomizeBuildOptions) (bool, error) {
+	visited := map[string]bool{}
+
+	return fetchKustomizationRemotes(kustomizeDir, opts, visited, 0)
+}
+
+func fetchKustomizationRemotes(dir string, opts *KustomizeBuildOptions, visited map[string]bool, depth int) (bool, error) {
+	dir = filepath.Clean(dir)
+
+	if visited[dir] || depth > maxKustomizeDepth {
+		return false, nil
+	}
+
+	visited[dir] = true
+
+	fSys := opts.fileSystem()
+
+	file := kustomizationFile(fSys, dir)
+	if file == "" {
+		return false, nil
+	}
+
+	bs, err := fSys.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+
+	var kustomization map[string]interface{}
+
+	if err := yaml.Unmarshal(bs, &kustomization); err != nil {
+		return false, fmt.Errorf("failed to parse %v: %w", file, err)
+	}
+
+	// the helmCharts are rendered with the helm library if helm is enabled, the deprecated helmChartInflationGenerator
+	// still runs the helm command
+	helmCharts := kustomization["helmChartInflationGenerator"] != nil || (kustomization["helmCharts"] != nil && !opts.EnableHelm)
+	updated := false
+
+	if kustomization["helmCharts"] != nil && opts.EnableHelm {
+		if err := inflateKustomizeHelmCharts(dir, kustomization, opts); err != nil {
+			return false, fmt.Errorf("failed to inflate the helm charts of %v: %w", file, err)
+		}
+
+		updated = true
+	}
+
+	for _, field := range []string{"resources", "bases", "components", "generators", "transformers", "validators"} {
+		entries, ok := kustomization[field].([]interface{})
+		if !ok {
+			continue
+		}
+
+		for i, e := range entries {
+			entry, ok := e.(string)
+			if !ok {
+				continue
+			}
+
+			// the generators, transformers and validators can be inline configs
+			if strings.Contains(entry, "\n") {
+				if err := checkKustomizeExecFunctions(file, []byte(entry)); err != nil {
+					return false, err
+				}
+
+				continue
+			}
+
+			localDir := filepath.Join(dir, entry)
+
+			if isKustomizeRemote(entry) {
+				localPath, err := fetchKustomizeRemote(dir, entry, opts)
+				if err != nil {
+					return false, err
+				}
+
+				relPath, err := filepath.Rel(dir, localPath)
+				if err != nil {
+					return false, err
+				}
+
+				klog.Infof("Fetched remote %v %v of %v into %v", field, entry, file, relPath)
+
+				entries[i] = relPath
+				localDir = localPath
+				updated = true
+			}
+
+			if fSys.IsDir(localDir) {
+				charts, err := fetchKustomizationRemotes(localDir, opts, visited, depth+1)
+				if err != nil {
+					return false, err
+				}
+
+				helmCharts = helmCharts || charts
+
+				continue
+			}
+
+			if field == "generators" || field == "transformers" || field == "validators" {
+				config, err := fSys.ReadFile(localDir)
+				if err != nil {
+					return false, err
+				}
+
+				if err := checkKustomizeExecFunctions(file, config); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+
+	if updated {
+		bs, err = yaml.Marshal(kustomization)
+		if err != nil {
+			return false, err
+		}
+
+		if err := fSys.WriteFile(file, bs); err != nil {
+			return false, err
+		}
+	}
+
+	return helmCharts, nil
+}
+
+// checkKustomizeExecFunctions returns an error if a generator, transformer or validator config of the kustomization
+// runs an exec KRM function. The exec functions run unsandboxed with the privileges of the subscription pod, kustomize
+// skips them silently if they are not enabled.
+func checkKustomizeExecFunctions(file string, config []byte) error {
+	for _, item := range ParseYAML(config) {
+		var fn struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+
+		if err := yaml.Unmarshal([]byte(item), &fn); err != nil {
+			continue
+		}
+
+		for _, key := range []string{"config.kubernetes.io/function", "config.k8s.io/function"} {
+			spec := map[string]interface{}{}
+
+			if err := yaml.Unmarshal([]byte(fn.Metadata.Annotations[key]), &spec); err != nil {
+				continue
+			}
+
+			if spec["exec"] != nil {
+				return fmt.Errorf("the kustomization %v runs the exec KRM function %v, the exec KRM functions are not "+
+					"supported", file, fn.Metadata.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchKustomizeRemote fetches a remote URL in the remote directory of the kustomization and returns its local path.
+// The remote directory is in the kustomization directory, the kustomize load restrictions allow loading its files.
+func fetchKustomizeRemote(dir, entry string, opts *KustomizeBuildOptions) (string, error) {
+	fSys := opts.fileSystem()
+	sum := sha256.Sum256([]byte(entry))
+	destDir := filepath.Join(dir, kustomizeRemoteDir, hex.EncodeToString(sum[:])[:16])
+
+	if isKustomizeRemoteFile(entry) {
+		u, _ := url.Parse(entry)
+		destFile := filepath.Join(destDir, filepath.Base(u.Path))
+
+		if fSys.Exists(destFile) {
+			return destFile, nil
+		}
+
+		return destFile, downloadKustomizeRemoteFile(entry, destFile, opts)
+	}
+
+	remote := parseKustomizeRemoteRepo(entry)
+	localPath := filepath.Join(destDir, remote.path)
+
+	if fSys.Exists(destDir) {
+		return localPath, nil
+	}
+
+	cloneOptions := &GitCloneOption{
+		DestDir:                 destDir,
+		PrimaryConnectionOption: getKustomizeRemoteConnection(remote.repoURL, opts),
+		FileSystem:              opts.FileSystem,
+	}
+
+	var err error
+
+	switch {
+	case remote.ref == "":
+		_, err = CloneGitRepo(cloneOptions)
+	case commitHashRegexp.MatchString(remote.ref):
+		cloneOptions.CommitHash = remote.ref
+		_, err = CloneGitRepo(cloneOptions)
+	default:
+		// the ref is a branch or a tag
+		cloneOptions.Branch = plumbing.NewBranchReferenceName(remote.ref)
+
+		if _, err = CloneGitRepo(cloneOptions); err != nil {
+			cloneOptions.Branch = plumbing.NewTagReferenceName(remote.ref)
+			_, err = CloneGitRepo(cloneOptions)
+		}
+	}
+
+	if err != nil {
+		_ = fSys.RemoveAll(destDir)
+
+		return "", fmt.Errorf("failed to fetch the kustomize remote base %v: %w", entry, err)
+	}
+
+	return localPath, nil
+}
+
+func downloadKustomizeRemoteFile(fileURL, destFile string, opts *KustomizeBuildOptions) error {
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return err
+	}
+
+	if conn := getKustomizeRemoteConnection(fileURL, opts); conn.User != "" && conn.Password != "" {
+		req.SetBasicAuth(conn.User, conn.Password)
+	}
+
+	// the default transport uses the HTTP_PROXY, HTTPS_PROXY and NO_PROXY proxy settings
+	httpClient := &http.Client{Timeout: 60 * time.Second}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the kustomize remote resource %v: %w", fileURL, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch the kustomize remote resource %v: %v", fileURL, resp.Status)
+	}
+
+	bs, err := io.ReadAll(io.LimitReader(resp.Body, maxKustomizeRemoteFileSize))
+	if err != nil {
+		return err
+	}
+
+	fSys := opts.fileSystem()
+
+	if err := fSys.MkdirAll(filepath.Dir(destFile)); err != nil {
+		return err
+	}
+
+	return fSys.WriteFile(destFile, bs)
+}
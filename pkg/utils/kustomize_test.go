@@ -15,12 +15,17 @@
 package utils
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/ghodss/yaml"
 	"github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 )
 
 func Test_RunKustomizeBuild(t *testing.T) {
@@ -47,3 +52,211 @@ func Test_RunKustomizeBuild(t *testing.T) {
 		}
 	}
 }
+
+func TestParseKustomizeRemoteRepo(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  kustomizeRemoteRepo
+	}{
+		{
+			entry: "https://github.com/org/repo//deploy/base?ref=v1.0",
+			want:  kustomizeRemoteRepo{repoURL: "https://github.com/org/repo", path: "deploy/base", ref: "v1.0"},
+		},
+		{
+			entry: "github.com/org/repo/deploy/base?ref=main",
+			want:  kustomizeRemoteRepo{repoURL: "https://github.com/org/repo", path: "deploy/base", ref: "main"},
+		},
+		{
+			entry: "git@github.com:org/repo.git/deploy?version=v2",
+			want:  kustomizeRemoteRepo{repoURL: "git@github.com:org/repo.git", path: "deploy", ref: "v2"},
+		},
+		{
+			entry: "git::https://gitlab.example.com/org/repo.git",
+			want:  kustomizeRemoteRepo{repoURL: "https://gitlab.example.com/org/repo.git"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := parseKustomizeRemoteRepo(tt.entry); got != tt.want {
+			t.Errorf("parseKustomizeRemoteRepo(%v) = %+v, want %+v", tt.entry, got, tt.want)
+		}
+	}
+}
+
+func TestIsKustomizeRemote(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(isKustomizeRemote("../base")).To(gomega.BeFalse())
+	g.Expect(isKustomizeRemote("deployment.yaml")).To(gomega.BeFalse())
+	g.Expect(isKustomizeRemote("github.com/org/repo/base")).To(gomega.BeTrue())
+	g.Expect(isKustomizeRemote("https://github.com/org/repo//base")).To(gomega.BeTrue())
+
+	g.Expect(isKustomizeRemoteFile("https://example.com/manifests/deployment.yaml")).To(gomega.BeTrue())
+	g.Expect(isKustomizeRemoteFile("https://github.com/org/repo//base?ref=main")).To(gomega.BeFalse())
+	g.Expect(isKustomizeRemoteFile("https://github.com/org/repo")).To(gomega.BeFalse())
+
+	opts := &KustomizeBuildOptions{
+		Connections: []*ChannelConnectionCfg{{RepoURL: "https://github.com/org/app", User: "user", Password: "token"}},
+	}
+
+	g.Expect(getKustomizeRemoteConnection("https://github.com/org/base", opts).User).To(gomega.Equal("user"))
+	g.Expect(getKustomizeRemoteConnection("https://gitlab.com/org/base", opts).User).To(gomega.BeEmpty())
+}
+
+func TestRunKustomizeBuildComponent(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"base/kustomization.yaml": "resources:\n- configmap.yaml\n",
+		"base/configmap.yaml":     "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\ndata:\n  key: base\n",
+		"component/kustomization.yaml": "apiVersion: kustomize.config.k8s.io/v1alpha1\nkind: Component\n" +
+			"commonLabels:\n  component: enabled\n",
+		"overlay/kustomization.yaml": "resources:\n- ../base\ncomponents:\n- ../component\n",
+	}
+
+	for name, content := range files {
+		g.Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0750)).To(gomega.Succeed())
+		g.Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0600)).To(gomega.Succeed())
+	}
+
+	g.Expect(IsKustomizeComponent(filepath.Join(dir, "component"))).To(gomega.BeTrue())
+	g.Expect(IsKustomizeComponent(filepath.Join(dir, "overlay"))).To(gomega.BeFalse())
+
+	out, err := RunKustomizeBuildWithOptions(filepath.Join(dir, "overlay"), &KustomizeBuildOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).To(gomega.ContainSubstring("component: enabled"))
+}
+
+func TestSetKustomizeBuildOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer func() {
+		g.Expect(SetKustomizeAllowedOptions([]string{KustomizeOptionHelm, KustomizeOptionLoadRestrictionsNone})).To(gomega.Succeed())
+	}()
+
+	sub := &appv1.Subscription{}
+	opts := &KustomizeBuildOptions{}
+
+	g.Expect(SetKustomizeBuildOptions(opts, sub)).To(gomega.Succeed())
+	g.Expect(*opts).To(gomega.Equal(KustomizeBuildOptions{}))
+
+	sub.SetAnnotations(map[string]string{
+		appv1.AnnotationKustomizeEnableHelm:     "true",
+		appv1.AnnotationKustomizeLoadRestrictor: "LoadRestrictionsRootOnly",
+	})
+	g.Expect(SetKustomizeBuildOptions(opts, sub)).To(gomega.Succeed())
+	g.Expect(opts.EnableHelm).To(gomega.BeTrue())
+	g.Expect(opts.LoadRestrictionsRootOnly).To(gomega.BeTrue())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationKustomizeEnableExec: "true"})
+	g.Expect(SetKustomizeBuildOptions(opts, sub)).NotTo(gomega.Succeed())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationKustomizeLoadRestrictor: "none"})
+	g.Expect(SetKustomizeBuildOptions(opts, sub)).NotTo(gomega.Succeed())
+
+	g.Expect(SetKustomizeAllowedOptions([]string{"helm", "unknown"})).NotTo(gomega.Succeed())
+
+	// the exec KRM functions can't be permitted
+	g.Expect(SetKustomizeAllowedOptions([]string{"exec"})).NotTo(gomega.Succeed())
+	g.Expect(SetKustomizeAllowedOptions([]string{KustomizeOptionHelm})).To(gomega.Succeed())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationKustomizeEnableExec: "true"})
+	g.Expect(SetKustomizeBuildOptions(opts, sub)).NotTo(gomega.Succeed())
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationKustomizeLoadRestrictor: "LoadRestrictionsNone"})
+	g.Expect(SetKustomizeBuildOptions(opts, sub)).NotTo(gomega.Succeed())
+}
+
+func TestRunKustomizeBuildLoadRestrictions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"common/config.env":        "key=value\n",
+		"app/kustomization.yaml":   "configMapGenerator:\n- name: test\n  envs:\n  - ../common/config.env\n",
+		"chart/kustomization.yaml": "helmCharts:\n- name: test\n  repo: https://example.com/charts\n",
+		"exec/kustomization.yaml":  "generators:\n- generator.yaml\n",
+		"exec/generator.yaml": "apiVersion: example.com/v1\nkind: Generator\nmetadata:\n  name: gen\n  annotations:\n" +
+			"    config.kubernetes.io/function: |\n      exec:\n        path: ./generate.sh\n",
+	}
+
+	for name, content := range files {
+		g.Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0750)).To(gomega.Succeed())
+		g.Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0600)).To(gomega.Succeed())
+	}
+
+	_, err := RunKustomizeBuildWithOptions(filepath.Join(dir, "app"), &KustomizeBuildOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = RunKustomizeBuildWithOptions(filepath.Join(dir, "app"), &KustomizeBuildOptions{LoadRestrictionsRootOnly: true})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = RunKustomizeBuildWithOptions(filepath.Join(dir, "chart"), &KustomizeBuildOptions{})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring(appv1.AnnotationKustomizeEnableHelm))
+
+	// the exec KRM functions are refused
+	_, err = RunKustomizeBuildWithOptions(filepath.Join(dir, "exec"), &KustomizeBuildOptions{})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestGetKustomizeOverlayPath(t *testing.T) {
+	overlays := []appv1.KustomizeOverlay{
+		{
+			ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "prod"}},
+			Path:            "overlays/prod",
+		},
+		{
+			ClusterSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "environment", Operator: metav1.LabelSelectorOpIn, Values: []string{"dev", "test"}},
+				},
+			},
+			Path: "overlays/dev",
+		},
+		{
+			ClusterSelector: &metav1.LabelSelector{},
+			Path:            "overlays/default",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		overlays []appv1.KustomizeOverlay
+		labels   map[string]string
+		want     string
+	}{
+		{name: "first match", overlays: overlays, labels: map[string]string{"environment": "prod"}, want: "overlays/prod"},
+		{name: "expression", overlays: overlays, labels: map[string]string{"environment": "test"}, want: "overlays/dev"},
+		{name: "empty selector", overlays: overlays, labels: map[string]string{"region": "us"}, want: "overlays/default"},
+		{name: "no match", overlays: overlays[:2], labels: nil, want: ""},
+		{name: "no overlays", labels: map[string]string{"environment": "prod"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		got, err := GetKustomizeOverlayPath(tt.overlays, tt.labels)
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", tt.name, err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%v: GetKustomizeOverlayPath() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	invalid := []appv1.KustomizeOverlay{
+		{
+			ClusterSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "environment", Operator: "Unknown"}},
+			},
+			Path: "overlays/invalid",
+		},
+	}
+
+	if _, err := GetKustomizeOverlayPath(invalid, nil); err == nil {
+		t.Errorf("GetKustomizeOverlayPath() expected an error for an invalid selector")
+	}
+}
@@ -15,13 +15,23 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	appsubv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 )
@@ -48,7 +58,8 @@ func PrepareOverrides(cluster types.NamespacedName, appsub *appsubv1.Subscriptio
 		}
 	}
 
-	klog.Infof("get overrides: %#v", overrides)
+	// the override values may be secret, only their number is logged
+	klog.Infof("get %v overrides for cluster %v", len(overrides), cluster.Name)
 
 	return overrides, nil
 }
@@ -93,3 +104,261 @@ func OverrideTemplate(template *unstructured.Unstructured, overrides []appsubv1.
 
 	return ovt, nil
 }
+
+// PatchTemplate alter the given template with the package overrides, using the patch type of the overrides.
+// Without patch type, each package override sets the value of a path.
+func PatchTemplate(template *unstructured.Unstructured, ov *appsubv1.Overrides) (*unstructured.Unstructured, error) {
+	if template == nil || ov == nil {
+		return template, nil
+	}
+
+	if ov.PatchType == "" {
+		overrides := make([]appsubv1.ClusterOverride, 0, len(ov.PackageOverrides))
+		for _, pov := range ov.PackageOverrides {
+			overrides = append(overrides, appsubv1.ClusterOverride(pov))
+		}
+
+		return OverrideTemplate(template, overrides)
+	}
+
+	doc, err := json.Marshal(template.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	patches := make([][]byte, 0, len(ov.PackageOverrides))
+
+	for _, pov := range ov.PackageOverrides {
+		patch, err := yaml.YAMLToJSON(pov.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse %v override: %w", ov.PatchType, err)
+		}
+
+		patches = append(patches, patch)
+	}
+
+	switch ov.PatchType {
+	case appsubv1.PatchTypeMerge:
+		for _, patch := range patches {
+			if doc, err = jsonpatch.MergePatch(doc, patch); err != nil {
+				return nil, fmt.Errorf("failed to apply merge override: %w", err)
+			}
+		}
+	case appsubv1.PatchTypeStrategicMerge:
+		for _, patch := range patches {
+			if doc, err = strategicMergePatch(template, doc, patch); err != nil {
+				return nil, fmt.Errorf("failed to apply strategic merge override: %w", err)
+			}
+		}
+	case appsubv1.PatchTypeJSON6902:
+		// each package override is an operation of the JSON patch
+		ops, err := jsonpatch.DecodePatch([]byte("[" + string(bytes.Join(patches, []byte(","))) + "]"))
+		if err != nil {
+			return nil, fmt.Errorf("can not parse json6902 override: %w", err)
+		}
+
+		if doc, err = ops.Apply(doc); err != nil {
+			return nil, fmt.Errorf("failed to apply json6902 override: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown override patch type %v", ov.PatchType)
+	}
+
+	ovt := &unstructured.Unstructured{}
+	if err := json.Unmarshal(doc, &ovt.Object); err != nil {
+		return nil, err
+	}
+
+	klog.V(1).Infof("Finished %v overriding of template %v/%v", ov.PatchType, ovt.GetNamespace(), ovt.GetName())
+
+	return ovt, nil
+}
+
+// strategicMergePatch applies a strategic merge patch to the known kinds, a JSON merge patch to the custom resources
+func strategicMergePatch(template *unstructured.Unstructured, doc, patch []byte) ([]byte, error) {
+	obj, err := scheme.Scheme.New(template.GroupVersionKind())
+	if err != nil {
+		klog.V(1).Infof("No schema of %v, falling back to merge override", template.GroupVersionKind())
+
+		return jsonpatch.MergePatch(doc, patch)
+	}
+
+	return strategicpatch.StrategicMergePatch(doc, patch, obj)
+}
+
+// HasPackageOverridesReference checks if the package overrides of the subscription reference a ConfigMap or Secret
+func HasPackageOverridesReference(sub *appsubv1.Subscription) bool {
+	if sub == nil {
+		return false
+	}
+
+	for _, ov := range sub.Spec.PackageOverrides {
+		if ov != nil && len(ov.PackageOverridesFrom) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsPackageOverridesReferenced checks if the package overrides of the subscription reference the ConfigMap or Secret
+func IsPackageOverridesReferenced(sub *appsubv1.Subscription, kind, name string) bool {
+	for _, ov := range sub.Spec.PackageOverrides {
+		if ov == nil {
+			continue
+		}
+
+		for _, ref := range ov.PackageOverridesFrom {
+			if ref.Kind == kind && ref.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ResolvePackageOverrides returns a copy of the package overrides of the subscription, the content of the referenced
+// ConfigMap and Secret keys is appended to the package overrides and the references are removed
+func ResolvePackageOverrides(clt client.Client, sub *appsubv1.Subscription) ([]*appsubv1.Overrides, error) {
+	return resolvePackageOverrides(clt, sub, true)
+}
+
+// ResolveConfigMapPackageOverrides returns a copy of the package overrides of the subscription, the content of the
+// referenced ConfigMap keys is appended to the package overrides and the ConfigMap references are removed. The Secret
+// references are kept, so the Secret content is not copied in plain text in the subscription.
+func ResolveConfigMapPackageOverrides(clt client.Client, sub *appsubv1.Subscription) ([]*appsubv1.Overrides, error) {
+	return resolvePackageOverrides(clt, sub, false)
+}
+
+func resolvePackageOverrides(clt client.Client, sub *appsubv1.Subscription, resolveSecrets bool) ([]*appsubv1.Overrides, error) {
+	if sub == nil || sub.Spec.PackageOverrides == nil {
+		return nil, nil
+	}
+
+	overrides := make([]*appsubv1.Overrides, 0, len(sub.Spec.PackageOverrides))
+
+	for _, ov := range sub.Spec.PackageOverrides {
+		if ov == nil {
+			continue
+		}
+
+		nov := ov.DeepCopy()
+		nov.PackageOverridesFrom = nil
+
+		for _, ref := range ov.PackageOverridesFrom {
+			if ref.Kind == "Secret" && !resolveSecrets {
+				nov.PackageOverridesFrom = append(nov.PackageOverridesFrom, ref)
+
+				continue
+			}
+
+			content, err := getPackageOverridesContent(clt, sub.GetNamespace(), ref)
+			if err != nil {
+				return nil, err
+			}
+
+			povs, err := parsePackageOverrides(content)
+			if err != nil {
+				return nil, fmt.Errorf("can not parse the package overrides of %v %v/%v key %v: %w",
+					ref.Kind, sub.GetNamespace(), ref.Name, ref.Key, err)
+			}
+
+			nov.PackageOverrides = append(nov.PackageOverrides, povs...)
+		}
+
+		overrides = append(overrides, nov)
+	}
+
+	return overrides, nil
+}
+
+// GetPackageOverridesSecrets returns the Secrets referenced by the package overrides of the subscription
+func GetPackageOverridesSecrets(clt client.Client, sub *appsubv1.Subscription) ([]*corev1.Secret, error) {
+	secrets := []*corev1.Secret{}
+	found := map[string]bool{}
+
+	for _, ov := range sub.Spec.PackageOverrides {
+		if ov == nil {
+			continue
+		}
+
+		for _, ref := range ov.PackageOverridesFrom {
+			if ref.Kind != "Secret" || found[ref.Name] {
+				continue
+			}
+
+			key := types.NamespacedName{Name: ref.Name, Namespace: sub.GetNamespace()}
+
+			secret := &corev1.Secret{}
+			if err := clt.Get(context.TODO(), key, secret); err != nil {
+				return nil, fmt.Errorf("failed to get the package overrides Secret %v: %w", key, err)
+			}
+
+			found[ref.Name] = true
+
+			secrets = append(secrets, secret)
+		}
+	}
+
+	return secrets, nil
+}
+
+func getPackageOverridesContent(clt client.Client, namespace string, ref appsubv1.PackageOverridesReference) ([]byte, error) {
+	key := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+
+	switch ref.Kind {
+	case "ConfigMap":
+		cm := &corev1.ConfigMap{}
+		if err := clt.Get(context.TODO(), key, cm); err != nil {
+			return nil, fmt.Errorf("failed to get the package overrides ConfigMap %v: %w", key, err)
+		}
+
+		if data, ok := cm.Data[ref.Key]; ok {
+			return []byte(data), nil
+		}
+
+		if data, ok := cm.BinaryData[ref.Key]; ok {
+			return data, nil
+		}
+	case "Secret":
+		secret := &corev1.Secret{}
+		if err := clt.Get(context.TODO(), key, secret); err != nil {
+			return nil, fmt.Errorf("failed to get the package overrides Secret %v: %w", key, err)
+		}
+
+		if data, ok := secret.Data[ref.Key]; ok {
+			return data, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown package overrides reference kind %v, expected ConfigMap or Secret", ref.Kind)
+	}
+
+	return nil, fmt.Errorf("key %v not found in the package overrides %v %v", ref.Key, ref.Kind, key)
+}
+
+// parsePackageOverrides parses a YAML list of package overrides or a single package override
+func parsePackageOverrides(content []byte) ([]appsubv1.PackageOverride, error) {
+	doc, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	doc = bytes.TrimSpace(doc)
+
+	if bytes.HasPrefix(doc, []byte("{")) {
+		return []appsubv1.PackageOverride{{RawExtension: runtime.RawExtension{Raw: doc}}}, nil
+	}
+
+	items := []json.RawMessage{}
+	if err := json.Unmarshal(doc, &items); err != nil {
+		return nil, errors.New("expected a list of package overrides or a package override")
+	}
+
+	povs := make([]appsubv1.PackageOverride, 0, len(items))
+	for _, item := range items {
+		povs = append(povs, appsubv1.PackageOverride{RawExtension: runtime.RawExtension{Raw: item}})
+	}
+
+	return povs, nil
+}
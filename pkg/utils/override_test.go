@@ -15,13 +15,19 @@
 package utils
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/gomega"
 	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestPrepareOverrides(t *testing.T) {
@@ -52,3 +58,234 @@ func TestPrepareOverrides(t *testing.T) {
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(overrideMap).To(BeNil())
 }
+
+var overrideDeployment = &unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "web",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": "nginx:1.24"},
+						map[string]interface{}{"name": "sidecar", "image": "envoy:1.28"},
+					},
+				},
+			},
+		},
+	},
+}
+
+func packageOverride(raw string) appv1.PackageOverride {
+	return appv1.PackageOverride{RawExtension: runtime.RawExtension{Raw: []byte(raw)}}
+}
+
+func containerImages(g *WithT, tpl *unstructured.Unstructured) map[string]interface{} {
+	containers, _, err := unstructured.NestedSlice(tpl.Object, "spec", "template", "spec", "containers")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	images := map[string]interface{}{}
+	for _, c := range containers {
+		container := c.(map[string]interface{})
+		images[container["name"].(string)] = container["image"]
+	}
+
+	return images
+}
+
+func TestPatchTemplate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// strategic merge, the containers are merged by name
+	ovt, err := PatchTemplate(overrideDeployment, &appv1.Overrides{
+		PatchType: appv1.PatchTypeStrategicMerge,
+		PackageOverrides: []appv1.PackageOverride{
+			packageOverride(`{"spec":{"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25"}]}}}}`),
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(containerImages(g, ovt)).To(Equal(map[string]interface{}{"web": "nginx:1.25", "sidecar": "envoy:1.28"}))
+
+	// merge, the containers list is replaced
+	ovt, err = PatchTemplate(overrideDeployment, &appv1.Overrides{
+		PatchType: appv1.PatchTypeMerge,
+		PackageOverrides: []appv1.PackageOverride{
+			packageOverride(`{"spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.25"}]}}}}`),
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(containerImages(g, ovt)).To(Equal(map[string]interface{}{"web": "nginx:1.25"}))
+	g.Expect(ovt.Object["spec"].(map[string]interface{})["replicas"]).To(BeEquivalentTo(3))
+
+	// json6902, each package override is an operation
+	ovt, err = PatchTemplate(overrideDeployment, &appv1.Overrides{
+		PatchType: appv1.PatchTypeJSON6902,
+		PackageOverrides: []appv1.PackageOverride{
+			packageOverride(`{"op":"replace","path":"/spec/template/spec/containers/1/image","value":"envoy:1.29"}`),
+			packageOverride(`{"op":"add","path":"/metadata/labels","value":{"app":"web"}}`),
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(containerImages(g, ovt)).To(Equal(map[string]interface{}{"web": "nginx:1.24", "sidecar": "envoy:1.29"}))
+	g.Expect(ovt.GetLabels()).To(Equal(map[string]string{"app": "web"}))
+
+	// json6902 test operation failure
+	_, err = PatchTemplate(overrideDeployment, &appv1.Overrides{
+		PatchType:        appv1.PatchTypeJSON6902,
+		PackageOverrides: []appv1.PackageOverride{packageOverride(`{"op":"test","path":"/spec/replicas","value":5}`)},
+	})
+	g.Expect(err).To(HaveOccurred())
+
+	// unknown patch type
+	_, err = PatchTemplate(overrideDeployment, &appv1.Overrides{PatchType: "unknown"})
+	g.Expect(err).To(HaveOccurred())
+
+	// the template is not changed
+	g.Expect(containerImages(g, overrideDeployment)).To(Equal(map[string]interface{}{"web": "nginx:1.24", "sidecar": "envoy:1.28"}))
+}
+
+func TestOverrideResourceBySubscriptionTarget(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	replicas := func(n int) *appv1.Overrides {
+		return &appv1.Overrides{
+			Target:           &appv1.OverrideTarget{Kind: "Deployment", Name: "web"},
+			PatchType:        appv1.PatchTypeMerge,
+			PackageOverrides: []appv1.PackageOverride{packageOverride(fmt.Sprintf(`{"spec":{"replicas":%d}}`, n))},
+		}
+	}
+
+	appsub := &appv1.Subscription{}
+	appsub.Spec.PackageOverrides = []*appv1.Overrides{replicas(2)}
+
+	ovt, err := OverrideResourceBySubscription(overrideDeployment, "web", appsub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ovt.Object["spec"].(map[string]interface{})["replicas"]).To(BeEquivalentTo(2))
+
+	// the target doesn't match
+	ov := replicas(2)
+	ov.Target.Namespace = "prod"
+	appsub.Spec.PackageOverrides = []*appv1.Overrides{ov}
+
+	ovt, err = OverrideResourceBySubscription(overrideDeployment, "web", appsub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ovt).To(Equal(overrideDeployment))
+
+	// the package name doesn't match
+	ov = replicas(2)
+	ov.PackageName = "api"
+	appsub.Spec.PackageOverrides = []*appv1.Overrides{ov}
+
+	ovt, err = OverrideResourceBySubscription(overrideDeployment, "web", appsub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ovt).To(Equal(overrideDeployment))
+
+	// the overrides are applied in order
+	appsub.Spec.PackageOverrides = []*appv1.Overrides{replicas(2), replicas(4)}
+
+	ovt, err = OverrideResourceBySubscription(overrideDeployment, "web", appsub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ovt.Object["spec"].(map[string]interface{})["replicas"]).To(BeEquivalentTo(4))
+}
+
+func TestParsePackageOverrides(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// a list of package overrides
+	povs, err := parsePackageOverrides([]byte(`
+- path: spec.replicas
+  value: 2
+- path: metadata.labels.app
+  value: web
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(povs).To(HaveLen(2))
+	g.Expect(string(povs[0].Raw)).To(Equal(`{"path":"spec.replicas","value":2}`))
+
+	// a single package override
+	povs, err = parsePackageOverrides([]byte(`{"spec": {"replicas": 3}}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(povs).To(HaveLen(1))
+	g.Expect(string(povs[0].Raw)).To(Equal(`{"spec":{"replicas":3}}`))
+
+	// neither a list nor an object
+	_, err = parsePackageOverrides([]byte(`replicas`))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestIsPackageOverridesReferenced(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	appsub := &appv1.Subscription{}
+	g.Expect(HasPackageOverridesReference(appsub)).To(BeFalse())
+
+	appsub.Spec.PackageOverrides = []*appv1.Overrides{
+		{
+			PackageName: "web",
+			PackageOverridesFrom: []appv1.PackageOverridesReference{
+				{Kind: "ConfigMap", Name: "web-overrides", Key: "overrides.yaml"},
+			},
+		},
+	}
+
+	g.Expect(HasPackageOverridesReference(appsub)).To(BeTrue())
+	g.Expect(IsPackageOverridesReferenced(appsub, "ConfigMap", "web-overrides")).To(BeTrue())
+	g.Expect(IsPackageOverridesReferenced(appsub, "Secret", "web-overrides")).To(BeFalse())
+	g.Expect(IsPackageOverridesReferenced(appsub, "ConfigMap", "api-overrides")).To(BeFalse())
+}
+
+func TestResolveConfigMapPackageOverrides(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-overrides", Namespace: "app-ns"},
+		Data:       map[string]string{"overrides.yaml": "spec:\n  replicas: 2\n"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-secret-overrides", Namespace: "app-ns"},
+		Data:       map[string][]byte{"overrides.yaml": []byte("spec:\n  password: secret\n")},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(cm, secret).Build()
+
+	appsub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app-ns"},
+		Spec: appv1.SubscriptionSpec{
+			PackageOverrides: []*appv1.Overrides{
+				{
+					PackageName: "web",
+					PackageOverridesFrom: []appv1.PackageOverridesReference{
+						{Kind: "ConfigMap", Name: "web-overrides", Key: "overrides.yaml"},
+						{Kind: "Secret", Name: "web-secret-overrides", Key: "overrides.yaml"},
+					},
+				},
+			},
+		},
+	}
+
+	// the ConfigMap content is resolved, the Secret reference is kept
+	overrides, err := ResolveConfigMapPackageOverrides(clt, appsub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(overrides).To(HaveLen(1))
+	g.Expect(overrides[0].PackageOverrides).To(HaveLen(1))
+	g.Expect(string(overrides[0].PackageOverrides[0].Raw)).To(Equal(`{"spec":{"replicas":2}}`))
+	g.Expect(overrides[0].PackageOverridesFrom).To(Equal([]appv1.PackageOverridesReference{
+		{Kind: "Secret", Name: "web-secret-overrides", Key: "overrides.yaml"},
+	}))
+
+	// both are resolved
+	overrides, err = ResolvePackageOverrides(clt, appsub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(overrides[0].PackageOverrides).To(HaveLen(2))
+	g.Expect(overrides[0].PackageOverridesFrom).To(BeEmpty())
+
+	secrets, err := GetPackageOverridesSecrets(clt, appsub)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secrets).To(HaveLen(1))
+	g.Expect(secrets[0].Name).To(Equal("web-secret-overrides"))
+}
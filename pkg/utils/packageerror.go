@@ -0,0 +1,76 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// MaxPackageMessageLength is the max length of the message of a package in the subscription status
+	MaxPackageMessageLength = 1024
+
+	// MaxPackageFieldLength is the max length of the fields of a package in the subscription status
+	MaxPackageFieldLength = 256
+
+	truncatedSuffix = "..."
+)
+
+// GetErrorFields returns the fields of an API error, like the invalid fields of a validation error or the fields
+// of a server side apply conflict, separated by commas. It returns an empty string if the error has no field.
+func GetErrorFields(err error) string {
+	var statusErr apierrors.APIStatus
+	if err == nil || !errors.As(err, &statusErr) {
+		return ""
+	}
+
+	details := statusErr.Status().Details
+	if details == nil {
+		return ""
+	}
+
+	fields := []string{}
+	found := map[string]bool{}
+
+	for _, cause := range details.Causes {
+		if cause.Field == "" || found[cause.Field] {
+			continue
+		}
+
+		found[cause.Field] = true
+		fields = append(fields, cause.Field)
+	}
+
+	return TruncateString(strings.Join(fields, ", "), MaxPackageFieldLength)
+}
+
+// TruncateString returns the string cut to max bytes, with a ... suffix if it is cut
+func TruncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	cut := max - len(truncatedSuffix)
+
+	// don't cut a multi-byte character
+	for cut > 0 && (s[cut]&0xC0) == 0x80 {
+		cut--
+	}
+
+	return s[:cut] + truncatedSuffix
+}
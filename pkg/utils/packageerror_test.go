@@ -0,0 +1,59 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestGetErrorFields(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(GetErrorFields(nil)).To(gomega.BeEmpty())
+	g.Expect(GetErrorFields(fmt.Errorf("connection refused"))).To(gomega.BeEmpty())
+
+	invalid := apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "web", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+		field.Required(field.NewPath("spec", "selector"), ""),
+		field.Invalid(field.NewPath("spec", "replicas"), -1, "must be an integer"),
+	})
+	g.Expect(GetErrorFields(fmt.Errorf("failed to apply: %w", invalid))).To(gomega.Equal("spec.replicas, spec.selector"))
+
+	conflict := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{
+			{Type: metav1.CauseTypeFieldManagerConflict, Field: ".spec.replicas"},
+		}},
+	}}
+	g.Expect(GetErrorFields(conflict)).To(gomega.Equal(".spec.replicas"))
+}
+
+func TestTruncateString(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(TruncateString("short", 10)).To(gomega.Equal("short"))
+	g.Expect(TruncateString(strings.Repeat("a", 20), 10)).To(gomega.Equal("aaaaaaa..."))
+
+	// the multi-byte characters are not cut
+	g.Expect(TruncateString("aaaaaa€€€", 10)).To(gomega.Equal("aaaaaa..."))
+}
@@ -67,6 +67,52 @@ func CreateFailedAppsubReportResult(client client.Client, cluster string, appsub
 	return nil
 }
 
+// UpdateAppsubReportResult sets the result of the appsub in the cluster appsubReport in the managed cluster namespace
+func UpdateAppsubReportResult(client client.Client, cluster string, appsubNs, appsubName string,
+	result appsubReportV1alpha1.SubscriptionResult) error {
+	appsubReport, err := getClusterAppsubReport(client, cluster, true)
+	if err != nil {
+		klog.Errorf("Error getting cluster appsubReport:%v/%v, err:%v", appsubReport.Namespace, appsubReport.Name, err)
+		return err
+	}
+
+	prResultSource := appsubNs + "/" + appsubName
+	prResultFound := false
+
+	for _, prResult := range appsubReport.Results {
+		if prResult.Source != prResultSource {
+			continue
+		}
+
+		if prResult.Result == result {
+			return nil
+		}
+
+		prResult.Result = result
+		prResult.Timestamp = metaV1.Timestamp{Seconds: time.Now().Unix()}
+		prResultFound = true
+
+		break
+	}
+
+	if !prResultFound {
+		klog.V(1).Infof("Add result (source:%v) to appsubReport", prResultSource)
+
+		appsubReport.Results = append(appsubReport.Results, &appsubReportV1alpha1.SubscriptionReportResult{
+			Source:    prResultSource,
+			Result:    result,
+			Timestamp: metaV1.Timestamp{Seconds: time.Now().Unix()},
+		})
+	}
+
+	if err := client.Update(context.TODO(), appsubReport); err != nil {
+		klog.Errorf("Error in updating on hub, appsubReport:%v/%v, err:%v", appsubReport.Namespace, appsubReport.Name, err)
+		return err
+	}
+
+	return nil
+}
+
 func getClusterAppsubReport(rClient client.Client, clusterAppsubReportNs string,
 	create bool) (*appsubReportV1alpha1.SubscriptionReport, error) {
 	appsubReport := &appsubReportV1alpha1.SubscriptionReport{
@@ -88,6 +134,7 @@ func getClusterAppsubReport(rClient client.Client, clusterAppsubReportNs string,
 
 				labels := map[string]string{
 					"apps.open-cluster-management.io/cluster": "true",
+					ExcludeFromBackupLabel:                    "true",
 				}
 				appsubReport.Labels = labels
 				appsubReport.ReportType = "Cluster"
@@ -0,0 +1,126 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// ReconcilePriorityCritical is the priority of the platform critical subscriptions
+	ReconcilePriorityCritical = "critical"
+	// ReconcilePriorityNormal is the default priority of the subscriptions
+	ReconcilePriorityNormal = "normal"
+	// ReconcilePriorityLow is the priority of the bulk subscriptions
+	ReconcilePriorityLow = "low"
+)
+
+// the work queue priorities, the requests not enqueued for a subscription event have the normal priority
+var reconcilePriorities = map[string]int{
+	ReconcilePriorityCritical: 100,
+	ReconcilePriorityNormal:   0,
+	ReconcilePriorityLow:      -100,
+}
+
+// GetReconcilePriority returns the work queue priority of the subscription, the normal priority if the subscription
+// has no valid reconcile priority annotation
+func GetReconcilePriority(obj metav1.Object) int {
+	priority := strings.ToLower(strings.TrimSpace(obj.GetAnnotations()[appv1.AnnotationReconcilePriority]))
+	if priority == "" {
+		return reconcilePriorities[ReconcilePriorityNormal]
+	}
+
+	value, ok := reconcilePriorities[priority]
+	if !ok {
+		klog.Warningf("Invalid reconcile priority %v of subscription %v/%v, using %v", priority, obj.GetNamespace(),
+			obj.GetName(), ReconcilePriorityNormal)
+
+		return reconcilePriorities[ReconcilePriorityNormal]
+	}
+
+	return value
+}
+
+// NewPriorityQueue returns the priority work queue of a subscription controller, the workers reconcile the requests
+// with the highest priority first
+func NewPriorityQueue(controllerName string,
+	rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return priorityqueue.New(controllerName, func(o *priorityqueue.Opts[reconcile.Request]) {
+		o.RateLimiter = rateLimiter
+	})
+}
+
+// EnqueueRequestsWithPriority enqueues the requests mapped from a subscription event with the reconcile priority of
+// the subscription
+func EnqueueRequestsWithPriority(fn handler.TypedMapFunc[*appv1.Subscription, reconcile.Request],
+) handler.TypedEventHandler[*appv1.Subscription, reconcile.Request] {
+	return &priorityEnqueueHandler{toRequests: fn}
+}
+
+type priorityEnqueueHandler struct {
+	toRequests handler.TypedMapFunc[*appv1.Subscription, reconcile.Request]
+}
+
+func (e *priorityEnqueueHandler) Create(ctx context.Context, evt event.TypedCreateEvent[*appv1.Subscription],
+	q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	e.enqueue(ctx, evt.Object, q)
+}
+
+func (e *priorityEnqueueHandler) Update(ctx context.Context, evt event.TypedUpdateEvent[*appv1.Subscription],
+	q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	e.enqueue(ctx, evt.ObjectNew, q)
+}
+
+func (e *priorityEnqueueHandler) Delete(ctx context.Context, evt event.TypedDeleteEvent[*appv1.Subscription],
+	q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	e.enqueue(ctx, evt.Object, q)
+}
+
+func (e *priorityEnqueueHandler) Generic(ctx context.Context, evt event.TypedGenericEvent[*appv1.Subscription],
+	q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	e.enqueue(ctx, evt.Object, q)
+}
+
+func (e *priorityEnqueueHandler) enqueue(ctx context.Context, sub *appv1.Subscription,
+	q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	if sub == nil {
+		return
+	}
+
+	reqs := e.toRequests(ctx, sub)
+	if len(reqs) == 0 {
+		return
+	}
+
+	if pq, ok := q.(priorityqueue.PriorityQueue[reconcile.Request]); ok {
+		pq.AddWithOpts(priorityqueue.AddOpts{Priority: GetReconcilePriority(sub)}, reqs...)
+
+		return
+	}
+
+	for _, req := range reqs {
+		q.Add(req)
+	}
+}
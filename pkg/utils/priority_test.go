@@ -0,0 +1,68 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestReconcilePriority(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newSub := func(name, priority string) *appv1.Subscription {
+		sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		if priority != "" {
+			sub.Annotations = map[string]string{appv1.AnnotationReconcilePriority: priority}
+		}
+
+		return sub
+	}
+
+	g.Expect(GetReconcilePriority(newSub("a", ""))).To(gomega.Equal(0))
+	g.Expect(GetReconcilePriority(newSub("a", "Critical"))).To(gomega.BeNumerically(">", 0))
+	g.Expect(GetReconcilePriority(newSub("a", "low"))).To(gomega.BeNumerically("<", 0))
+	g.Expect(GetReconcilePriority(newSub("a", "urgent"))).To(gomega.Equal(0))
+
+	q := NewPriorityQueue("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer q.ShutDown()
+
+	h := EnqueueRequestsWithPriority(func(_ context.Context, sub *appv1.Subscription) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: sub.Name, Namespace: sub.Namespace}}}
+	})
+
+	for _, sub := range []*appv1.Subscription{newSub("bulk", "low"), newSub("app", ""), newSub("platform", "critical")} {
+		h.Create(context.TODO(), event.TypedCreateEvent[*appv1.Subscription]{Object: sub}, q)
+	}
+
+	g.Eventually(q.Len).Should(gomega.Equal(3))
+
+	// the critical subscription is reconciled first, the low priority one last
+	for _, name := range []string{"platform", "app", "bulk"} {
+		req, shutdown := q.Get()
+		g.Expect(shutdown).To(gomega.BeFalse())
+		g.Expect(req.Name).To(gomega.Equal(name))
+		q.Done(req)
+	}
+}
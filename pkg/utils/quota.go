@@ -0,0 +1,156 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+// GetSubscriptionQuotas returns the subscription quotas of the namespace
+func GetSubscriptionQuotas(clt client.Client, namespace string) ([]appsubReportV1alpha1.SubscriptionQuota, error) {
+	quotas := &appsubReportV1alpha1.SubscriptionQuotaList{}
+	if err := clt.List(context.TODO(), quotas, client.InNamespace(namespace)); err != nil {
+		// the quota CRD is not installed
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to list the subscription quotas in namespace %v, err: %w", namespace, err)
+	}
+
+	return quotas.Items, nil
+}
+
+// CheckSubscriptionCountQuota returns an error if the subscription exceeds the maximum number of subscriptions of
+// the namespace. The oldest subscriptions are within the quota. A subscription missing from subs is counted as the newest.
+func CheckSubscriptionCountQuota(quotas []appsubReportV1alpha1.SubscriptionQuota, subs []appv1.Subscription, sub *appv1.Subscription) error {
+	maxSubs, quotaName := minQuota(quotas, func(spec appsubReportV1alpha1.SubscriptionQuotaSpec) *int32 { return spec.MaxSubscriptions })
+	if maxSubs < 0 || sub.GetAnnotations()[appv1.AnnotationHosting] != "" {
+		return nil
+	}
+
+	hubSubs := []appv1.Subscription{}
+
+	for _, s := range subs {
+		// the subscriptions created by the hub for local placement are not counted
+		if s.GetAnnotations()[appv1.AnnotationHosting] != "" {
+			continue
+		}
+
+		hubSubs = append(hubSubs, s)
+	}
+
+	sort.Slice(hubSubs, func(i, j int) bool {
+		if !hubSubs[i].CreationTimestamp.Equal(&hubSubs[j].CreationTimestamp) {
+			return hubSubs[i].CreationTimestamp.Before(&hubSubs[j].CreationTimestamp)
+		}
+
+		return hubSubs[i].Name < hubSubs[j].Name
+	})
+
+	idx := len(hubSubs)
+
+	for i, s := range hubSubs {
+		if s.Name == sub.Name {
+			idx = i
+
+			break
+		}
+	}
+
+	if idx >= maxSubs {
+		return fmt.Errorf("subscription quota %v exceeded, the namespace %v is limited to %v subscriptions", quotaName, sub.Namespace, maxSubs)
+	}
+
+	return nil
+}
+
+// CheckDeploymentQuota returns an error if the subscription targets more clusters than allowed, or if the resources
+// deployed by the subscriptions of the namespace exceed the quota. The resources deployed by the other subscriptions
+// are read from their application SubscriptionReports. A negative resourceCount means the resources of the
+// subscription are not rendered on the hub, they are read from its own application SubscriptionReport.
+func CheckDeploymentQuota(quotas []appsubReportV1alpha1.SubscriptionQuota, reports []appsubReportV1alpha1.SubscriptionReport,
+	sub *appv1.Subscription, resourceCount, clusterCount int) error {
+	maxClusters, quotaName := minQuota(quotas, func(spec appsubReportV1alpha1.SubscriptionQuotaSpec) *int32 { return spec.MaxClusters })
+	if maxClusters >= 0 && clusterCount > maxClusters {
+		return fmt.Errorf("subscription quota %v exceeded, the subscription targets %v clusters, the limit is %v",
+			quotaName, clusterCount, maxClusters)
+	}
+
+	maxResources, quotaName := minQuota(quotas, func(spec appsubReportV1alpha1.SubscriptionQuotaSpec) *int32 { return spec.MaxDeployedResources })
+	if maxResources < 0 {
+		return nil
+	}
+
+	if resourceCount < 0 {
+		resourceCount = 0
+
+		for _, report := range reports {
+			if report.ReportType == "Application" && report.Name == sub.Name {
+				resourceCount = len(report.Resources)
+			}
+		}
+	}
+
+	deployed := resourceCount * clusterCount
+
+	for _, report := range reports {
+		if report.ReportType != "Application" || report.Name == sub.Name {
+			continue
+		}
+
+		clusters, err := strconv.Atoi(report.Summary.Clusters)
+		if err != nil {
+			continue
+		}
+
+		deployed += len(report.Resources) * clusters
+	}
+
+	if deployed > maxResources {
+		return fmt.Errorf("subscription quota %v exceeded, the subscriptions in namespace %v deploy %v resources, the limit is %v",
+			quotaName, sub.Namespace, deployed, maxResources)
+	}
+
+	return nil
+}
+
+// minQuota returns the lowest limit of the quotas and the name of the quota setting it, -1 if no quota sets the limit
+func minQuota(quotas []appsubReportV1alpha1.SubscriptionQuota,
+	limit func(appsubReportV1alpha1.SubscriptionQuotaSpec) *int32) (int, string) {
+	minLimit, quotaName := -1, ""
+
+	for _, quota := range quotas {
+		l := limit(quota.Spec)
+		if l == nil {
+			continue
+		}
+
+		if minLimit < 0 || int(*l) < minLimit {
+			minLimit, quotaName = int(*l), quota.Name
+		}
+	}
+
+	return minLimit, quotaName
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func TestCheckSubscriptionCountQuota(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := time.Now()
+
+	newSub := func(name string, age time.Duration) appv1.Subscription {
+		return appv1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "team-a",
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+		}
+	}
+
+	subs := []appv1.Subscription{newSub("newest", time.Minute), newSub("oldest", time.Hour), newSub("middle", 10*time.Minute)}
+
+	// no limit
+	g.Expect(CheckSubscriptionCountQuota(nil, subs, &subs[0])).To(gomega.Succeed())
+
+	quotas := []appsubReportV1alpha1.SubscriptionQuota{
+		{ObjectMeta: metav1.ObjectMeta{Name: "loose"}, Spec: appsubReportV1alpha1.SubscriptionQuotaSpec{MaxSubscriptions: int32Ptr(5)}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "strict"}, Spec: appsubReportV1alpha1.SubscriptionQuotaSpec{MaxSubscriptions: int32Ptr(2)}},
+	}
+
+	g.Expect(CheckSubscriptionCountQuota(quotas, subs, &subs[1])).To(gomega.Succeed())
+	g.Expect(CheckSubscriptionCountQuota(quotas, subs, &subs[2])).To(gomega.Succeed())
+	g.Expect(CheckSubscriptionCountQuota(quotas, subs, &subs[0])).NotTo(gomega.Succeed())
+
+	// a subscription being created is counted as the newest
+	created := newSub("created", 0)
+	g.Expect(CheckSubscriptionCountQuota(quotas, subs[1:], &created)).NotTo(gomega.Succeed())
+	g.Expect(CheckSubscriptionCountQuota(quotas, subs[2:], &created)).To(gomega.Succeed())
+}
+
+func TestCheckDeploymentQuota(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "team-a"}}
+
+	quotas := []appsubReportV1alpha1.SubscriptionQuota{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "quota"},
+			Spec: appsubReportV1alpha1.SubscriptionQuotaSpec{
+				MaxClusters:          int32Ptr(3),
+				MaxDeployedResources: int32Ptr(10),
+			},
+		},
+	}
+
+	reports := []appsubReportV1alpha1.SubscriptionReport{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-a"},
+			ReportType: "Application",
+			Summary:    appsubReportV1alpha1.SubscriptionReportSummary{Clusters: "2"},
+			Resources:  []*corev1.ObjectReference{{Kind: "ConfigMap", Name: "cm1"}, {Kind: "ConfigMap", Name: "cm2"}},
+		},
+		{
+			// the report of the subscription itself is replaced by the new resource and cluster counts
+			ObjectMeta: metav1.ObjectMeta{Name: "appsub", Namespace: "team-a"},
+			ReportType: "Application",
+			Summary:    appsubReportV1alpha1.SubscriptionReportSummary{Clusters: "3"},
+			Resources:  []*corev1.ObjectReference{{Kind: "ConfigMap", Name: "cm3"}},
+		},
+	}
+
+	g.Expect(CheckDeploymentQuota(quotas, reports, sub, 2, 3)).To(gomega.Succeed())
+	g.Expect(CheckDeploymentQuota(quotas, reports, sub, 1, 4)).NotTo(gomega.Succeed())
+	g.Expect(CheckDeploymentQuota(quotas, reports, sub, 3, 3)).NotTo(gomega.Succeed())
+
+	// the resources not rendered on the hub are counted from the report of the subscription
+	g.Expect(CheckDeploymentQuota(quotas, reports, sub, -1, 3)).To(gomega.Succeed())
+
+	reports[1].Resources = append(reports[1].Resources, &corev1.ObjectReference{Kind: "ConfigMap", Name: "cm4"},
+		&corev1.ObjectReference{Kind: "ConfigMap", Name: "cm5"})
+	g.Expect(CheckDeploymentQuota(quotas, reports, sub, -1, 3)).NotTo(gomega.Succeed())
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// MinReconcileInterval is the minimum reconcile interval of the subscriptions, the shorter channel intervals are
+// raised to it
+const MinReconcileInterval = 15 * time.Second
+
+// ReconcileJitterFactor is the jitter factor of the reconcile loops and of the first reconcile delay of the
+// subscriptions, so the subscriptions sharing the same interval don't drift back in step
+const ReconcileJitterFactor = 0.1
+
+// ValidateReconcileInterval returns an error if the reconcile interval of the subscription is shorter than
+// MinReconcileInterval
+func ValidateReconcileInterval(interval *metav1.Duration) error {
+	if interval == nil {
+		return nil
+	}
+
+	if interval.Duration < MinReconcileInterval {
+		return fmt.Errorf("the reconcileInterval %v is shorter than the minimum reconcile interval %v", interval.Duration,
+			MinReconcileInterval)
+	}
+
+	return nil
+}
+
+// GetChannelReconcileInterval returns the reconcile interval of the channel reconcile-interval annotation, or 0 if
+// it is not set or invalid
+func GetChannelReconcileInterval(chn *chnv1.Channel) time.Duration {
+	if chn == nil {
+		return 0
+	}
+
+	value := chn.GetAnnotations()[appv1.AnnotationReconcileInterval]
+	if value == "" {
+		return 0
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		klog.Warningf("Ignoring the invalid %v annotation %q of channel %v/%v", appv1.AnnotationReconcileInterval, value,
+			chn.Namespace, chn.Name)
+
+		return 0
+	}
+
+	return interval
+}
+
+// GetSubscriptionReconcileInterval returns the reconcile loop interval, the retry interval and the retry count of the
+// subscription. The loop interval is the reconcileInterval of the subscription, or the reconcile-interval annotation
+// of its channel, or the interval of the reconcile rate. It is at least MinReconcileInterval.
+func GetSubscriptionReconcileInterval(reconcileRate string, chn *chnv1.Channel, sub *appv1.Subscription) (time.Duration,
+	time.Duration, int) {
+	chType := ""
+	if chn != nil {
+		chType = string(chn.Spec.Type)
+	}
+
+	interval, retryInterval, retries := GetReconcileInterval(reconcileRate, chType)
+
+	if chnInterval := GetChannelReconcileInterval(chn); chnInterval > 0 {
+		interval = chnInterval
+	}
+
+	if sub != nil && sub.Spec.ReconcileInterval != nil && sub.Spec.ReconcileInterval.Duration > 0 {
+		interval = sub.Spec.ReconcileInterval.Duration
+	}
+
+	if interval < MinReconcileInterval {
+		klog.Infof("Raising the reconcile interval %v to the minimum %v", interval, MinReconcileInterval)
+
+		interval = MinReconcileInterval
+	}
+
+	// a failed reconcile is retried before the next one
+	if retryInterval > interval {
+		retryInterval = interval / 2
+	}
+
+	return interval, retryInterval, retries
+}
+
+// ReconcilePhaseOffset returns the stable offset of the subscription in its reconcile interval window. It is derived
+// from the hash of the subscription key, so the reconciles of the subscriptions sharing the same interval are spread
+// across the window and keep the same phase after an agent restart.
+func ReconcilePhaseOffset(key types.NamespacedName, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key.String()))
+
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+// GetReconcileStartDelay returns the delay of the first reconcile of a subscription already deployed before the
+// agent started, its phase offset plus a random jitter of up to ReconcileJitterFactor of the interval
+func GetReconcileStartDelay(key types.NamespacedName, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	jitter := time.Duration(rand.Float64() * ReconcileJitterFactor * float64(interval)) // #nosec G404 Used only to spread the reconciles
+
+	return ReconcilePhaseOffset(key, interval) + jitter
+}
+
+// IsSubscriptionDeployed returns true if the subscription was already deployed on the managed cluster, before the
+// current agent started for example. Its first reconcile can be delayed without leaving it undeployed.
+func IsSubscriptionDeployed(sub *appv1.Subscription) bool {
+	return sub != nil && sub.Status.Phase == appv1.SubscriptionSubscribed
+}
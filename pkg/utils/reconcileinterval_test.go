@@ -0,0 +1,137 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestValidateReconcileInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(ValidateReconcileInterval(nil)).To(gomega.Succeed())
+	g.Expect(ValidateReconcileInterval(&metav1.Duration{Duration: 15 * time.Second})).To(gomega.Succeed())
+	g.Expect(ValidateReconcileInterval(&metav1.Duration{Duration: 6 * time.Hour})).To(gomega.Succeed())
+	g.Expect(ValidateReconcileInterval(&metav1.Duration{Duration: 5 * time.Second})).NotTo(gomega.Succeed())
+	g.Expect(ValidateReconcileInterval(&metav1.Duration{})).NotTo(gomega.Succeed())
+}
+
+func TestGetSubscriptionReconcileInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	chn := &chnv1.Channel{Spec: chnv1.ChannelSpec{Type: chnv1.ChannelTypeHelmRepo}}
+	sub := &appv1.Subscription{}
+
+	// the interval of the reconcile rate by default
+	loopPeriod, retryInterval, retries := GetSubscriptionReconcileInterval("medium", chn, sub)
+	g.Expect(loopPeriod).To(gomega.Equal(15 * time.Minute))
+	g.Expect(retryInterval).To(gomega.Equal(90 * time.Second))
+	g.Expect(retries).To(gomega.Equal(1))
+
+	// the channel interval overrides the reconcile rate
+	chn.SetAnnotations(map[string]string{appv1.AnnotationReconcileInterval: "6h"})
+
+	loopPeriod, retryInterval, retries = GetSubscriptionReconcileInterval("high", chn, sub)
+	g.Expect(loopPeriod).To(gomega.Equal(6 * time.Hour))
+	g.Expect(retryInterval).To(gomega.Equal(60 * time.Second))
+	g.Expect(retries).To(gomega.Equal(1))
+
+	// the subscription interval overrides the channel interval, it is retried before the next reconcile
+	sub.Spec.ReconcileInterval = &metav1.Duration{Duration: 30 * time.Second}
+
+	loopPeriod, retryInterval, _ = GetSubscriptionReconcileInterval("medium", chn, sub)
+	g.Expect(loopPeriod).To(gomega.Equal(30 * time.Second))
+	g.Expect(retryInterval).To(gomega.Equal(15 * time.Second))
+
+	// the intervals are at least the minimum
+	sub.Spec.ReconcileInterval = nil
+	chn.SetAnnotations(map[string]string{appv1.AnnotationReconcileInterval: "1s"})
+
+	loopPeriod, _, _ = GetSubscriptionReconcileInterval("medium", chn, sub)
+	g.Expect(loopPeriod).To(gomega.Equal(MinReconcileInterval))
+
+	// the invalid channel intervals are ignored
+	for _, value := range []string{"often", "-1m", "0"} {
+		chn.SetAnnotations(map[string]string{appv1.AnnotationReconcileInterval: value})
+		g.Expect(GetChannelReconcileInterval(chn)).To(gomega.BeZero())
+
+		loopPeriod, _, _ = GetSubscriptionReconcileInterval("low", chn, nil)
+		g.Expect(loopPeriod).To(gomega.Equal(time.Hour))
+	}
+
+	g.Expect(GetChannelReconcileInterval(nil)).To(gomega.BeZero())
+}
+
+func TestReconcilePhaseOffset(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	key := types.NamespacedName{Namespace: "default", Name: "appsub"}
+
+	// the offset is stable and within the interval
+	offset := ReconcilePhaseOffset(key, 15*time.Minute)
+	g.Expect(offset).To(gomega.Equal(ReconcilePhaseOffset(key, 15*time.Minute)))
+	g.Expect(offset).To(gomega.BeNumerically(">=", 0))
+	g.Expect(offset).To(gomega.BeNumerically("<", 15*time.Minute))
+
+	g.Expect(ReconcilePhaseOffset(key, 0)).To(gomega.BeZero())
+
+	// the subscriptions sharing the same interval are spread across the window
+	offsets := map[time.Duration]bool{}
+
+	for i := 0; i < 100; i++ {
+		offsets[ReconcilePhaseOffset(types.NamespacedName{Namespace: "default", Name: fmt.Sprintf("appsub-%d", i)},
+			15*time.Minute)] = true
+	}
+
+	g.Expect(len(offsets)).To(gomega.BeNumerically(">", 90))
+}
+
+func TestGetReconcileStartDelay(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	key := types.NamespacedName{Namespace: "default", Name: "appsub"}
+	offset := ReconcilePhaseOffset(key, time.Hour)
+
+	for i := 0; i < 10; i++ {
+		delay := GetReconcileStartDelay(key, time.Hour)
+		g.Expect(delay).To(gomega.BeNumerically(">=", offset))
+		g.Expect(delay).To(gomega.BeNumerically("<=", offset+6*time.Minute))
+	}
+
+	g.Expect(GetReconcileStartDelay(key, 0)).To(gomega.BeZero())
+}
+
+func TestIsSubscriptionDeployed(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{}
+	g.Expect(IsSubscriptionDeployed(nil)).To(gomega.BeFalse())
+	g.Expect(IsSubscriptionDeployed(sub)).To(gomega.BeFalse())
+
+	sub.Status.Phase = appv1.SubscriptionFailed
+	g.Expect(IsSubscriptionDeployed(sub)).To(gomega.BeFalse())
+
+	sub.Status.Phase = appv1.SubscriptionSubscribed
+	g.Expect(IsSubscriptionDeployed(sub)).To(gomega.BeTrue())
+}
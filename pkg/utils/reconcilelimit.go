@@ -0,0 +1,68 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"k8s.io/klog"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+)
+
+// reconcileSlots limits the number of subscriber items reconciled at the same time, it is nil if they are not
+// limited. The reconciles waiting for a slot are queued in the order they started.
+var reconcileSlots chan struct{}
+
+// SetMaxConcurrentReconciles sets the maximum number of subscriber items reconciled at the same time by the managed
+// cluster agent, 0 or less for no limit. It must be set before the subscribers start.
+func SetMaxConcurrentReconciles(max int) {
+	if max <= 0 {
+		reconcileSlots = nil
+
+		return
+	}
+
+	klog.Infof("Limiting the concurrent subscription reconciles to %v", max)
+
+	reconcileSlots = make(chan struct{}, max)
+}
+
+// AcquireReconcileSlot waits until the subscriber item of the channel type can be reconciled within the limit of
+// concurrent reconciles, the returned function releases the slot when the reconcile ends
+func AcquireReconcileSlot(channelType string) func() {
+	slots := reconcileSlots
+
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+		default:
+			queued := metrics.LocalDeploymentQueuedReconciles.WithLabelValues(channelType)
+			queued.Inc()
+
+			slots <- struct{}{}
+
+			queued.Dec()
+		}
+	}
+
+	done := metrics.TrackConcurrentReconcile(channelType)
+
+	return func() {
+		done()
+
+		if slots != nil {
+			<-slots
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	promTestUtils "github.com/prometheus/client_golang/prometheus/testutil"
+
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+)
+
+func TestAcquireReconcileSlot(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer SetMaxConcurrentReconciles(0)
+
+	running := metrics.LocalDeploymentConcurrentReconciles.WithLabelValues(metrics.ChannelTypeGit)
+	queued := metrics.LocalDeploymentQueuedReconciles.WithLabelValues(metrics.ChannelTypeGit)
+
+	// the reconciles are not limited by default
+	release1 := AcquireReconcileSlot(metrics.ChannelTypeGit)
+	release2 := AcquireReconcileSlot(metrics.ChannelTypeGit)
+	g.Expect(promTestUtils.ToFloat64(running)).To(gomega.Equal(2.0))
+
+	release1()
+	release2()
+	g.Expect(promTestUtils.ToFloat64(running)).To(gomega.Equal(0.0))
+
+	// the reconciles over the limit wait for a slot
+	SetMaxConcurrentReconciles(1)
+
+	release1 = AcquireReconcileSlot(metrics.ChannelTypeGit)
+
+	acquired := make(chan func())
+
+	go func() {
+		acquired <- AcquireReconcileSlot(metrics.ChannelTypeGit)
+	}()
+
+	g.Eventually(func() float64 { return promTestUtils.ToFloat64(queued) }).Should(gomega.Equal(1.0))
+	g.Consistently(acquired, 100*time.Millisecond).ShouldNot(gomega.Receive())
+	g.Expect(promTestUtils.ToFloat64(running)).To(gomega.Equal(1.0))
+
+	release1()
+
+	g.Eventually(acquired).Should(gomega.Receive(&release2))
+	g.Expect(promTestUtils.ToFloat64(queued)).To(gomega.Equal(0.0))
+	g.Expect(promTestUtils.ToFloat64(running)).To(gomega.Equal(1.0))
+
+	release2()
+	g.Expect(promTestUtils.ToFloat64(running)).To(gomega.Equal(0.0))
+}
@@ -74,6 +74,10 @@ func ListAndDeployReferredObject(clt client.Client, instance *appv1.Subscription
 	found := false
 	referLabel := SercertReferredMarker + insName
 
+	// the secrets of the external secret managers are not copied, the previous copies of the channel secret are
+	// removed
+	external := IsExternalChannelSecret(refObj)
+
 	for _, obj := range uObjList.Items {
 		u := obj.DeepCopy()
 		lb := u.GetLabels()
@@ -82,7 +86,7 @@ func ListAndDeployReferredObject(clt client.Client, instance *appv1.Subscription
 			lb = make(map[string]string)
 		}
 
-		if u.GetName() == refObj.GetName() {
+		if u.GetName() == refObj.GetName() && !external {
 			found = true
 			lb[referLabel] = "true"
 
@@ -145,7 +149,7 @@ func ListAndDeployReferredObject(clt client.Client, instance *appv1.Subscription
 		}
 	}
 
-	if !found {
+	if !found && !external {
 		lb := refObj.GetLabels()
 
 		if len(lb) == 0 {
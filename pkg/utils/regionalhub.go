@@ -0,0 +1,73 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+// GetRegionalPlacement returns the Placement the regional hubs re-propagate the subscription with, empty if the
+// subscription is not propagated to regional hubs. A subscription propagated from another hub is never
+// re-propagated as a regional subscription, this stops the propagation loops between the hubs.
+func GetRegionalPlacement(sub *appv1.Subscription) string {
+	annotations := sub.GetAnnotations()
+
+	if annotations[appv1.AnnotationHosting] != "" {
+		return ""
+	}
+
+	return strings.TrimSpace(annotations[appv1.AnnotationRegionalPlacement])
+}
+
+// IsRegionalHubSubscription checks if the subscription was propagated from a global hub to be re-propagated by this
+// regional hub to its managed clusters
+func IsRegionalHubSubscription(sub *appv1.Subscription) bool {
+	if sub.GetAnnotations()[appv1.AnnotationHosting] == "" {
+		return false
+	}
+
+	pl := sub.Spec.Placement
+
+	return pl != nil && (pl.Local == nil || !*pl.Local) &&
+		(pl.PlacementRef != nil || pl.Clusters != nil || pl.ClusterSelector != nil)
+}
+
+// GetRegionalHubResult rolls the summary of a regional hub subscription up to a single result for the global hub.
+// The subscription has failed if it failed on any managed cluster of the regional hub, it is deployed when it is
+// deployed on all of them. false is returned while the deployment is in progress.
+func GetRegionalHubResult(summary appsubReportV1alpha1.SubscriptionReportSummary) (appsubReportV1alpha1.SubscriptionResult, bool) {
+	failed, _ := strconv.Atoi(summary.Failed)
+	propagationFailed, _ := strconv.Atoi(summary.PropagationFailed)
+
+	if failed > 0 || propagationFailed > 0 {
+		return "failed", true
+	}
+
+	clusters, err := strconv.Atoi(summary.Clusters)
+	if err != nil || clusters == 0 {
+		return "", false
+	}
+
+	deployed, _ := strconv.Atoi(summary.Deployed)
+	if deployed == clusters {
+		return "deployed", true
+	}
+
+	return "", false
+}
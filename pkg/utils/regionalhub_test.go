@@ -0,0 +1,100 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	plrv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+)
+
+func TestRegionalHubSubscription(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	local := true
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "app-ns",
+			Annotations: map[string]string{appv1.AnnotationRegionalPlacement: "regional-clusters"},
+		},
+		Spec: appv1.SubscriptionSpec{
+			Placement: &plrv1.Placement{PlacementRef: &corev1.ObjectReference{Kind: "Placement", Name: "regional-hubs"}},
+		},
+	}
+
+	// global hub subscription
+	g.Expect(GetRegionalPlacement(sub)).To(gomega.Equal("regional-clusters"))
+	g.Expect(IsRegionalHubSubscription(sub)).To(gomega.BeFalse())
+
+	// the subscription re-propagated by the regional hub is not propagated to regional hubs again
+	sub.Annotations[appv1.AnnotationHosting] = "app-ns/app"
+	g.Expect(GetRegionalPlacement(sub)).To(gomega.BeEmpty())
+	g.Expect(IsRegionalHubSubscription(sub)).To(gomega.BeTrue())
+
+	// the subscription deployed by the managed clusters of the regional hub
+	sub.Spec.Placement = &plrv1.Placement{Local: &local}
+	g.Expect(IsRegionalHubSubscription(sub)).To(gomega.BeFalse())
+}
+
+func TestGetRegionalHubResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  appsubReportV1alpha1.SubscriptionReportSummary
+		result   appsubReportV1alpha1.SubscriptionResult
+		reported bool
+	}{
+		{
+			name:     "deployed on all clusters",
+			summary:  appsubReportV1alpha1.SubscriptionReportSummary{Deployed: "3", InProgress: "0", Failed: "0", PropagationFailed: "0", Clusters: "3"},
+			result:   "deployed",
+			reported: true,
+		},
+		{
+			name:     "failed on a cluster",
+			summary:  appsubReportV1alpha1.SubscriptionReportSummary{Deployed: "2", InProgress: "0", Failed: "1", PropagationFailed: "0", Clusters: "3"},
+			result:   "failed",
+			reported: true,
+		},
+		{
+			name:     "propagation failed on a cluster",
+			summary:  appsubReportV1alpha1.SubscriptionReportSummary{Deployed: "0", InProgress: "0", Failed: "0", PropagationFailed: "1", Clusters: "1"},
+			result:   "failed",
+			reported: true,
+		},
+		{
+			name:    "in progress",
+			summary: appsubReportV1alpha1.SubscriptionReportSummary{Deployed: "1", InProgress: "2", Failed: "0", PropagationFailed: "0", Clusters: "3"},
+		},
+		{
+			name:    "no cluster",
+			summary: appsubReportV1alpha1.SubscriptionReportSummary{Deployed: "0", Clusters: "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		result, reported := GetRegionalHubResult(tt.summary)
+		if result != tt.result || reported != tt.reported {
+			t.Errorf("%v: expected result %v (%v), got %v (%v)", tt.name, tt.result, tt.reported, result, reported)
+		}
+	}
+}
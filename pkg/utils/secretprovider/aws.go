@@ -0,0 +1,107 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// awsSecretsManagerProvider reads the secrets of AWS Secrets Manager. The reference is the name or the ARN of the
+// secret, its value is a JSON object of the channel secret keys. The credentials and the region are the default AWS
+// configuration: environment variables, shared files, IAM roles for service accounts or instance profile. The region
+// of an ARN reference overrides the default region. The endpoint is the AWS_ENDPOINT_URL_SECRETS_MANAGER environment
+// variable, the regional endpoint by default.
+type awsSecretsManagerProvider struct{}
+
+func (p *awsSecretsManagerProvider) GetSecret(ctx context.Context, ref string) (map[string][]byte, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS configuration, err: %w", err)
+	}
+
+	region := cfg.Region
+
+	// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+	if arn := strings.Split(ref, ":"); len(arn) > 3 && arn[0] == "arn" && arn[3] != "" {
+		region = arn[3]
+	}
+
+	if region == "" {
+		return nil, errors.New("no AWS region, set the AWS_REGION environment variable or use the ARN of the secret")
+	}
+
+	if cfg.Credentials == nil {
+		return nil, errors.New("no AWS credentials")
+	}
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the AWS credentials, err: %w", err)
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER")
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+
+	payload, err := json.Marshal(map[string]string{"SecretId": ref})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	payloadHash := sha256.Sum256(payload)
+
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "secretsmanager",
+		region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign the AWS Secrets Manager request, err: %w", err)
+	}
+
+	secret := struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary []byte `json:"SecretBinary"`
+	}{}
+
+	if err := doJSON(req, &secret); err != nil {
+		return nil, err
+	}
+
+	value := []byte(secret.SecretString)
+	if len(value) == 0 {
+		value = secret.SecretBinary
+	}
+
+	return parseJSONSecret(value)
+}
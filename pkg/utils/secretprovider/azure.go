@@ -0,0 +1,147 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	azureKeyVaultResource   = "https://vault.azure.net"
+	azureKeyVaultAPIVersion = "7.4"
+	defaultAzureAuthority   = "https://login.microsoftonline.com/"
+)
+
+// azureIMDSTokenURL is the token endpoint of the Azure managed identities
+var azureIMDSTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// azureKeyVaultProvider reads the secrets of Azure Key Vault. The reference is the name of the secret, with an
+// optional version like git-credentials/<version>, its value is a JSON object of the channel secret keys. The Key
+// Vault URL is the AZURE_KEYVAULT_URL environment variable. The credentials are the client secret or the workload identity of the AZURE_TENANT_ID and AZURE_CLIENT_ID environment
+// variables, or the managed identity.
+type azureKeyVaultProvider struct{}
+
+func (p *azureKeyVaultProvider) GetSecret(ctx context.Context, ref string) (map[string][]byte, error) {
+	address := os.Getenv("AZURE_KEYVAULT_URL")
+	if address == "" {
+		return nil, errors.New("no Key Vault URL, set the AZURE_KEYVAULT_URL environment variable of the application manager")
+	}
+
+	token, err := azureToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(address, "/")+"/secrets/"+
+		strings.TrimPrefix(ref, "/")+"?api-version="+azureKeyVaultAPIVersion, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	secret := struct {
+		Value string `json:"value"`
+	}{}
+
+	if err := doJSON(req, &secret); err != nil {
+		return nil, err
+	}
+
+	return parseJSONSecret([]byte(secret.Value))
+}
+
+// azureToken returns a Key Vault access token of the client secret, the workload identity or the managed identity
+func azureToken(ctx context.Context) (string, error) {
+	tenantID := os.Getenv("AZURE_TENANT_ID")
+	clientID := os.Getenv("AZURE_CLIENT_ID")
+
+	var req *http.Request
+
+	if tenantID != "" && clientID != "" {
+		form := url.Values{
+			"grant_type": {"client_credentials"},
+			"client_id":  {clientID},
+			"scope":      {azureKeyVaultResource + "/.default"},
+		}
+
+		if clientSecret := os.Getenv("AZURE_CLIENT_SECRET"); clientSecret != "" {
+			form.Set("client_secret", clientSecret)
+		} else if tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); tokenFile != "" {
+			assertion, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return "", fmt.Errorf("failed to read the Azure federated token, err: %w", err)
+			}
+
+			form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+			form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+		} else {
+			return "", errors.New("no Azure credentials, set the AZURE_CLIENT_SECRET or the AZURE_FEDERATED_TOKEN_FILE environment variable")
+		}
+
+		authority := os.Getenv("AZURE_AUTHORITY_HOST")
+		if authority == "" {
+			authority = defaultAzureAuthority
+		}
+
+		var err error
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost,
+			strings.TrimSuffix(authority, "/")+"/"+tenantID+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		query := url.Values{
+			"api-version": {"2018-02-01"},
+			"resource":    {azureKeyVaultResource},
+		}
+
+		if clientID != "" {
+			query.Set("client_id", clientID)
+		}
+
+		var err error
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, azureIMDSTokenURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+
+		req.Header.Set("Metadata", "true")
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err := doJSON(req, &token); err != nil {
+		return "", fmt.Errorf("failed to get the Azure Key Vault access token, err: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", errors.New("the Azure token endpoint returned no access token")
+	}
+
+	return token.AccessToken, nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secretprovider resolves the channel credentials from the external secret managers, HashiCorp Vault, AWS
+// Secrets Manager and Azure Key Vault, so the channels don't need static Kubernetes secrets
+package secretprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// ProviderVault reads the secrets of the HashiCorp Vault KV secrets engine
+	ProviderVault = "vault"
+	// ProviderAWSSecretsManager reads the secrets of AWS Secrets Manager
+	ProviderAWSSecretsManager = "aws-secrets-manager"
+	// ProviderAzureKeyVault reads the secrets of Azure Key Vault
+	ProviderAzureKeyVault = "azure-key-vault"
+
+	// DefaultRefreshInterval is how long the resolved secrets are cached when the channel has no refresh interval
+	DefaultRefreshInterval = 5 * time.Minute
+
+	// requestTimeout is the timeout of the secret manager requests
+	requestTimeout = 30 * time.Second
+
+	// maxResponseSize is the maximum size of the secret manager responses
+	maxResponseSize = 1 << 20
+)
+
+// Provider reads the key/values of a secret from an external secret manager. The address and the credentials of the
+// secret manager are the environment of the application manager, they are never read from the channels.
+type Provider interface {
+	GetSecret(ctx context.Context, ref string) (map[string][]byte, error)
+}
+
+// Annotations are the channel annotations of the external secret managers
+var Annotations = []string{
+	appv1.AnnotationSecretProvider,
+	appv1.AnnotationSecretProviderRef,
+	appv1.AnnotationSecretProviderRefreshInterval,
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		ProviderVault:             &vaultProvider{tokenFile: serviceAccountTokenFile},
+		ProviderAWSSecretsManager: &awsSecretsManagerProvider{},
+		ProviderAzureKeyVault:     &azureKeyVaultProvider{},
+	}
+
+	httpClient = &http.Client{Timeout: requestTimeout}
+)
+
+// Register registers the provider of an external secret manager, it replaces the provider of the same name
+func Register(name string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[name] = provider
+}
+
+func getProvider(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	provider, ok := providers[name]
+
+	return provider, ok
+}
+
+// Reference is the reference of a channel secret in an external secret manager
+type Reference struct {
+	Provider        string
+	Ref             string
+	RefreshInterval time.Duration
+}
+
+func (r *Reference) String() string {
+	return r.Provider + ":" + r.Ref
+}
+
+// GetReference returns the external secret reference of the channel annotations, nil if the channel has no secret
+// provider
+func GetReference(annotations map[string]string) (*Reference, error) {
+	name := annotations[appv1.AnnotationSecretProvider]
+	if name == "" {
+		return nil, nil
+	}
+
+	if _, ok := getProvider(name); !ok {
+		return nil, fmt.Errorf("unknown secret provider %v", name)
+	}
+
+	// the credentials of the application manager would be sent to the address chosen by the channel creator
+	if annotations[appv1.AnnotationSecretProviderAddress] != "" {
+		return nil, fmt.Errorf("the %v annotation is not supported, the address of the secret provider %v is "+
+			"configured in the environment of the application manager", appv1.AnnotationSecretProviderAddress, name)
+	}
+
+	ref := &Reference{
+		Provider:        name,
+		Ref:             annotations[appv1.AnnotationSecretProviderRef],
+		RefreshInterval: DefaultRefreshInterval,
+	}
+
+	if ref.Ref == "" {
+		return nil, fmt.Errorf("the %v annotation is required with the secret provider %v",
+			appv1.AnnotationSecretProviderRef, name)
+	}
+
+	if interval := annotations[appv1.AnnotationSecretProviderRefreshInterval]; interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %v annotation %q, it must be a positive duration like 1m or 1h",
+				appv1.AnnotationSecretProviderRefreshInterval, interval)
+		}
+
+		ref.RefreshInterval = d
+	}
+
+	return ref, nil
+}
+
+// Resolve returns the key/values of the external secret. They are cached for the refresh interval of the reference,
+// the rotated credentials are read once the interval has elapsed. The cached key/values are kept while the secret
+// manager can't be reached.
+func Resolve(ctx context.Context, ref *Reference) (map[string][]byte, error) {
+	return defaultCache.resolve(ctx, ref)
+}
+
+type cacheEntry struct {
+	data      map[string][]byte
+	expiresAt time.Time
+}
+
+// secretCache caches the resolved secrets, keyed by provider and reference
+type secretCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+var defaultCache = newSecretCache()
+
+func newSecretCache() *secretCache {
+	return &secretCache{
+		entries: map[string]cacheEntry{},
+		now:     time.Now,
+	}
+}
+
+func (c *secretCache) resolve(ctx context.Context, ref *Reference) (map[string][]byte, error) {
+	key := ref.Provider + "|" + ref.Ref
+
+	c.mu.Lock()
+	entry, cached := c.entries[key]
+	c.mu.Unlock()
+
+	if cached && c.now().Before(entry.expiresAt) {
+		return entry.data, nil
+	}
+
+	provider, ok := getProvider(ref.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown secret provider %v", ref.Provider)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	data, err := provider.GetSecret(ctx, ref.Ref)
+	if err != nil {
+		if cached {
+			klog.Warningf("failed to refresh the secret %v, the cached secret is used, err: %v", ref.String(), err)
+
+			return entry.data, nil
+		}
+
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{data: data, expiresAt: c.now().Add(ref.RefreshInterval)}
+	c.mu.Unlock()
+
+	return data, nil
+}
+
+// StatusError is the error of an unexpected status code of the secret manager
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("secret manager request %v failed: status %v", e.URL, e.StatusCode)
+}
+
+// HTTPStatusCode returns the status code of the secret manager response
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// doJSON sends the request and decodes the JSON response
+func doJSON(req *http.Request, out interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &StatusError{URL: req.URL.Scheme + "://" + req.URL.Host + req.URL.Path, StatusCode: resp.StatusCode}
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// toSecretData converts the values of a JSON object to the values of a secret, the values that are not strings are
+// kept as JSON
+func toSecretData(values map[string]interface{}) map[string][]byte {
+	data := map[string][]byte{}
+
+	for k, v := range values {
+		if s, ok := v.(string); ok {
+			data[k] = []byte(s)
+
+			continue
+		}
+
+		raw, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+
+		data[k] = raw
+	}
+
+	return data
+}
+
+// parseJSONSecret returns the key/values of a secret value holding a JSON object, like
+// {"user": "admin", "accessToken": "..."}
+func parseJSONSecret(value []byte) (map[string][]byte, error) {
+	values := map[string]interface{}{}
+
+	if err := json.Unmarshal(value, &values); err != nil {
+		return nil, fmt.Errorf("the secret value must be a JSON object of the channel secret keys, err: %w", err)
+	}
+
+	return toSecretData(values), nil
+}
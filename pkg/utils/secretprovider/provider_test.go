@@ -0,0 +1,276 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+type fakeProvider struct {
+	calls int
+	data  map[string][]byte
+	err   error
+}
+
+func (p *fakeProvider) GetSecret(_ context.Context, _ string) (map[string][]byte, error) {
+	p.calls++
+
+	return p.data, p.err
+}
+
+func TestGetReference(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ref, err := GetReference(map[string]string{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ref).To(gomega.BeNil())
+
+	_, err = GetReference(map[string]string{appv1.AnnotationSecretProvider: "unknown", appv1.AnnotationSecretProviderRef: "git"})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = GetReference(map[string]string{appv1.AnnotationSecretProvider: ProviderVault})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = GetReference(map[string]string{
+		appv1.AnnotationSecretProvider:                ProviderVault,
+		appv1.AnnotationSecretProviderRef:             "secret/data/git",
+		appv1.AnnotationSecretProviderRefreshInterval: "never",
+	})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// the address of the secret manager can't be set by the channel
+	_, err = GetReference(map[string]string{
+		appv1.AnnotationSecretProvider:        ProviderVault,
+		appv1.AnnotationSecretProviderRef:     "secret/data/git",
+		appv1.AnnotationSecretProviderAddress: "https://vault.example.com",
+	})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	ref, err = GetReference(map[string]string{
+		appv1.AnnotationSecretProvider:                ProviderVault,
+		appv1.AnnotationSecretProviderRef:             "secret/data/git",
+		appv1.AnnotationSecretProviderRefreshInterval: "1h",
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(*ref).To(gomega.Equal(Reference{
+		Provider:        ProviderVault,
+		Ref:             "secret/data/git",
+		RefreshInterval: time.Hour,
+	}))
+}
+
+func TestSecretCache(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	provider := &fakeProvider{data: map[string][]byte{"accessToken": []byte("token1")}}
+	Register("fake", provider)
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	c := newSecretCache()
+	c.now = func() time.Time { return now }
+
+	ref := &Reference{Provider: "fake", Ref: "git", RefreshInterval: time.Minute}
+
+	data, err := c.resolve(context.TODO(), ref)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(data["accessToken"])).To(gomega.Equal("token1"))
+
+	// cached for the refresh interval
+	provider.data = map[string][]byte{"accessToken": []byte("token2")}
+	data, _ = c.resolve(context.TODO(), ref)
+	g.Expect(string(data["accessToken"])).To(gomega.Equal("token1"))
+	g.Expect(provider.calls).To(gomega.Equal(1))
+
+	// the rotated secret is read once the interval has elapsed
+	now = now.Add(time.Minute)
+	data, _ = c.resolve(context.TODO(), ref)
+	g.Expect(string(data["accessToken"])).To(gomega.Equal("token2"))
+	g.Expect(provider.calls).To(gomega.Equal(2))
+
+	// the cached secret is kept while the secret manager can't be reached
+	provider.err = errors.New("connection refused")
+	now = now.Add(time.Minute)
+	data, err = c.resolve(context.TODO(), ref)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(data["accessToken"])).To(gomega.Equal("token2"))
+
+	_, err = c.resolve(context.TODO(), &Reference{Provider: "fake", Ref: "other", RefreshInterval: time.Minute})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestVaultProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			login := map[string]string{}
+			_ = json.NewDecoder(r.Body).Decode(&login)
+
+			if login["role"] != "appsub" || login["jwt"] != "sa-token" {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "login-token"}}`))
+		case "/v1/secret/data/git":
+			if r.Header.Get("X-Vault-Token") != "login-token" {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"data": {"data": {"user": "admin", "accessToken": "token"}, "metadata": {"version": 2}}}`))
+		case "/v1/kv/git":
+			if r.Header.Get("X-Vault-Token") != "static-token" {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"data": {"user": "admin", "accessToken": "token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	g.Expect(os.WriteFile(tokenFile, []byte("sa-token\n"), 0600)).To(gomega.Succeed())
+
+	p := &vaultProvider{tokenFile: tokenFile}
+
+	// no address
+	t.Setenv("VAULT_ADDR", "")
+
+	_, err := p.GetSecret(context.TODO(), "secret/data/git")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// KV version 2 with the Kubernetes auth method
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("VAULT_ROLE", "appsub")
+
+	data, err := p.GetSecret(context.TODO(), "secret/data/git")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(data).To(gomega.Equal(map[string][]byte{"user": []byte("admin"), "accessToken": []byte("token")}))
+
+	// KV version 1 with a token
+	t.Setenv("VAULT_TOKEN", "static-token")
+
+	data, err = p.GetSecret(context.TODO(), "kv/git")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(data).To(gomega.Equal(map[string][]byte{"user": []byte("admin"), "accessToken": []byte("token")}))
+
+	_, err = p.GetSecret(context.TODO(), "secret/data/git")
+
+	var statusErr *StatusError
+	g.Expect(errors.As(err, &statusErr)).To(gomega.BeTrue())
+	g.Expect(statusErr.HTTPStatusCode()).To(gomega.Equal(http.StatusForbidden))
+}
+
+func TestAzureKeyVaultProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tenant/oauth2/v2.0/token":
+			_ = r.ParseForm()
+
+			if r.Form.Get("client_id") != "client" || r.Form.Get("client_secret") != "secret" ||
+				r.Form.Get("scope") != "https://vault.azure.net/.default" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"access_token": "azure-token"}`))
+		case "/secrets/git-credentials":
+			if r.Header.Get("Authorization") != "Bearer azure-token" || r.URL.Query().Get("api-version") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"value": "{\"user\": \"admin\", \"accessToken\": \"token\"}"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL)
+	t.Setenv("AZURE_KEYVAULT_URL", server.URL)
+
+	data, err := (&azureKeyVaultProvider{}).GetSecret(context.TODO(), "git-credentials")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(data).To(gomega.Equal(map[string][]byte{"user": []byte("admin"), "accessToken": []byte("token")}))
+}
+
+func TestAWSSecretsManagerProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/") {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		if string(body) != `{"SecretId":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:bucket"}` {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"SecretString": "{\"AccessKeyID\": \"key\", \"SecretAccessKey\": \"secret\"}"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", server.URL)
+
+	// the region of the ARN overrides the default region
+	data, err := (&awsSecretsManagerProvider{}).GetSecret(context.TODO(),
+		"arn:aws:secretsmanager:eu-west-1:123456789012:secret:bucket")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(data).To(gomega.Equal(map[string][]byte{"AccessKeyID": []byte("key"), "SecretAccessKey": []byte("secret")}))
+}
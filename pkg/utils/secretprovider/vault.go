@@ -0,0 +1,145 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// serviceAccountTokenFile is the service account token used to log in with the Vault Kubernetes auth method
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token" // #nosec G101
+
+	defaultVaultAuthPath = "kubernetes"
+)
+
+// vaultProvider reads the secrets of the Vault KV secrets engine, version 1 or 2. The reference is the API path of
+// the secret, like secret/data/git/github for the KV version 2 mounted at secret/. The Vault address is the VAULT_ADDR
+// environment variable. The Vault token is the VAULT_TOKEN
+// environment variable, or the token of the Kubernetes auth method login with the role of the VAULT_ROLE environment
+// variable.
+type vaultProvider struct {
+	tokenFile string
+}
+
+func (p *vaultProvider) GetSecret(ctx context.Context, ref string) (map[string][]byte, error) {
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		return nil, errors.New("no Vault address, set the VAULT_ADDR environment variable of the application manager")
+	}
+
+	address = strings.TrimSuffix(address, "/")
+
+	token, err := p.token(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+"/v1/"+strings.TrimPrefix(ref, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	setVaultHeaders(req)
+	req.Header.Set("X-Vault-Token", token)
+
+	secret := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+
+	if err := doJSON(req, &secret); err != nil {
+		return nil, err
+	}
+
+	values := secret.Data
+
+	// the KV version 2 nests the key/values in data.data, next to data.metadata
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		if _, ok := values["metadata"]; ok {
+			values = nested
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("the Vault secret %v has no data", ref)
+	}
+
+	return toSecretData(values), nil
+}
+
+// token returns the Vault token of the environment, or logs in with the Kubernetes auth method
+func (p *vaultProvider) token(ctx context.Context, address string) (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	role := os.Getenv("VAULT_ROLE")
+	if role == "" {
+		return "", errors.New("no Vault credentials, set the VAULT_TOKEN or the VAULT_ROLE environment variable")
+	}
+
+	jwt, err := os.ReadFile(p.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the service account token for the Vault login, err: %w", err)
+	}
+
+	authPath := os.Getenv("VAULT_AUTH_PATH")
+	if authPath == "" {
+		authPath = defaultVaultAuthPath
+	}
+
+	body, err := json.Marshal(map[string]string{"role": role, "jwt": strings.TrimSpace(string(jwt))})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		address+"/v1/auth/"+strings.Trim(authPath, "/")+"/login", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	setVaultHeaders(req)
+
+	login := struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}{}
+
+	if err := doJSON(req, &login); err != nil {
+		return "", fmt.Errorf("failed to log in to Vault with the role %v, err: %w", role, err)
+	}
+
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("the Vault login with the role %v returned no token", role)
+	}
+
+	return login.Auth.ClientToken, nil
+}
+
+// setVaultHeaders sets the Vault Enterprise namespace of the VAULT_NAMESPACE environment variable
+func setVaultHeaders(req *http.Request) {
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+}
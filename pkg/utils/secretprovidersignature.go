@@ -0,0 +1,104 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	releasev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils/secretprovider"
+)
+
+// SecretProviderKeySecretName is the Secret holding the key the subscriber signs the secret provider annotations of
+// the HelmReleases with
+const SecretProviderKeySecretName = "multicluster-operators-subscription-secret-provider-key" // #nosec G101
+
+// secretProviderKey is the key of the HelmRelease secret provider signatures, the secret provider annotations of the
+// HelmReleases are ignored without it
+var secretProviderKey []byte
+
+// SetSecretProviderKey sets the key of the HelmRelease secret provider signatures
+func SetSecretProviderKey(key []byte) {
+	secretProviderKey = key
+}
+
+// LoadSecretProviderKey returns the key of the HelmRelease secret provider signatures from its Secret in the
+// namespace, the Secret is created with a random key if it doesn't exist
+func LoadSecretProviderKey(ctx context.Context, clt client.Client, namespace string) ([]byte, error) {
+	return loadSigningKey(ctx, clt, types.NamespacedName{Namespace: namespace, Name: SecretProviderKeySecretName})
+}
+
+// getSecretProviderSignature returns the signature of the secret provider annotations of the HelmRelease, empty if
+// there is no key or no secret provider. The signature covers the HelmRelease namespace and name and the repo the
+// resolved credentials are sent to, so it can't be copied to another HelmRelease or repo.
+func getSecretProviderSignature(helmRelease *releasev1.HelmRelease) string {
+	annotations := helmRelease.GetAnnotations()
+
+	if len(secretProviderKey) == 0 || annotations[appv1.AnnotationSecretProvider] == "" {
+		return ""
+	}
+
+	repo, err := json.Marshal(struct {
+		Source             *releasev1.Source
+		ConfigMapRef       *corev1.ObjectReference
+		InsecureSkipVerify bool
+	}{helmRelease.Repo.Source, helmRelease.Repo.ConfigMapRef, helmRelease.Repo.InsecureSkipVerify})
+	if err != nil {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, secretProviderKey)
+	fmt.Fprintf(mac, "%s\n%s\n", helmRelease.GetNamespace(), helmRelease.GetName())
+
+	for _, key := range secretprovider.Annotations {
+		fmt.Fprintf(mac, "%s\n", annotations[key])
+	}
+
+	_, _ = mac.Write(repo)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signHelmReleaseSecretProvider sets the signature of the secret provider annotations of the HelmRelease
+func signHelmReleaseSecretProvider(helmRelease *releasev1.HelmRelease) {
+	annotations := helmRelease.GetAnnotations()
+
+	if signature := getSecretProviderSignature(helmRelease); signature != "" {
+		annotations[appv1.AnnotationSecretProviderSignature] = signature
+	} else {
+		delete(annotations, appv1.AnnotationSecretProviderSignature)
+	}
+
+	helmRelease.SetAnnotations(annotations)
+}
+
+// IsHelmReleaseSecretProviderSigned returns true if the secret provider annotations of the HelmRelease were set by the
+// subscriber for its repo
+func IsHelmReleaseSecretProviderSigned(helmRelease *releasev1.HelmRelease) bool {
+	signature := getSecretProviderSignature(helmRelease)
+
+	return signature != "" &&
+		hmac.Equal([]byte(signature), []byte(helmRelease.GetAnnotations()[appv1.AnnotationSecretProviderSignature]))
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+
+	releasev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/helmrelease/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestHelmReleaseSecretProviderSignature(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	channel := &chnv1.Channel{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-ch", Name: "helm", Annotations: map[string]string{
+		appv1.AnnotationSecretProvider:    "vault",
+		appv1.AnnotationSecretProviderRef: "secret/data/helm",
+	}}}
+
+	newHelmRelease := func() *releasev1.HelmRelease {
+		hr := &releasev1.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx"},
+			Repo: releasev1.HelmReleaseRepo{Source: &releasev1.Source{
+				SourceType: releasev1.HelmRepoSourceType,
+				HelmRepo:   &releasev1.HelmRepo{Urls: []string{"https://charts.example.com/nginx-1.0.0.tgz"}},
+			}},
+		}
+
+		setHelmReleaseSecretProvider(hr, channel)
+
+		return hr
+	}
+
+	// nothing is signed without a key
+	hr := newHelmRelease()
+	g.Expect(hr.GetAnnotations()).NotTo(gomega.HaveKey(appv1.AnnotationSecretProviderSignature))
+	g.Expect(IsHelmReleaseSecretProviderSigned(hr)).To(gomega.BeFalse())
+
+	SetSecretProviderKey([]byte("secret-provider-key"))
+	defer SetSecretProviderKey(nil)
+
+	hr = newHelmRelease()
+	g.Expect(hr.GetAnnotations()).To(gomega.HaveKey(appv1.AnnotationSecretProviderSignature))
+	g.Expect(IsHelmReleaseSecretProviderSigned(hr)).To(gomega.BeTrue())
+
+	// the credentials can't be sent to another repo
+	hr.Repo.Source.HelmRepo.Urls = []string{"https://attacker.example.com/nginx-1.0.0.tgz"}
+	g.Expect(IsHelmReleaseSecretProviderSigned(hr)).To(gomega.BeFalse())
+
+	// another secret can't be resolved
+	hr = newHelmRelease()
+	hr.Annotations[appv1.AnnotationSecretProviderRef] = "secret/data/other"
+	g.Expect(IsHelmReleaseSecretProviderSigned(hr)).To(gomega.BeFalse())
+
+	// the signature can't be copied to another HelmRelease
+	hr = newHelmRelease()
+	hr.Name = "other"
+	g.Expect(IsHelmReleaseSecretProviderSigned(hr)).To(gomega.BeFalse())
+
+	// the HelmReleases created by the users are not signed
+	hr = newHelmRelease()
+	delete(hr.Annotations, appv1.AnnotationSecretProviderSignature)
+	g.Expect(IsHelmReleaseSecretProviderSigned(hr)).To(gomega.BeFalse())
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+var (
+	// the shard reconciled by this hub controller and the number of shards
+	subscriptionShard      = 0
+	subscriptionShardCount = 1
+)
+
+// SetSubscriptionShard sets the shard of the subscriptions reconciled by the hub subscription controller
+func SetSubscriptionShard(shard, count int) error {
+	if count < 1 {
+		return fmt.Errorf("invalid number of shards %v, it must be at least 1", count)
+	}
+
+	if shard < 0 || shard >= count {
+		return fmt.Errorf("invalid shard %v, it must be between 0 and %v", shard, count-1)
+	}
+
+	subscriptionShard = shard
+	subscriptionShardCount = count
+
+	return nil
+}
+
+// GetSubscriptionShardCount returns the number of shards of the hub subscription controller
+func GetSubscriptionShardCount() int {
+	return subscriptionShardCount
+}
+
+// IsFirstSubscriptionShard checks if this hub controller is the first shard. The first shard keeps the leader election
+// lease of the unsharded controller, it runs the hub controllers that are not sharded.
+func IsFirstSubscriptionShard() bool {
+	return subscriptionShard == 0
+}
+
+// GetSubscriptionShard returns the shard of the subscription. The shard is the shard label of the subscription if it
+// is a valid shard, else the hash of the subscription namespace, so all the subscriptions of a namespace are
+// reconciled by the same shard.
+func GetSubscriptionShard(obj metav1.Object, count int) int {
+	if count <= 1 {
+		return 0
+	}
+
+	if label, ok := obj.GetLabels()[appv1.LabelSubscriptionShard]; ok {
+		if shard, err := strconv.Atoi(label); err == nil && shard >= 0 && shard < count {
+			return shard
+		}
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(obj.GetNamespace()))
+
+	return int(h.Sum32() % uint32(count))
+}
+
+// IsSubscriptionInShard checks if the subscription is reconciled by the shard of this hub controller
+func IsSubscriptionInShard(obj metav1.Object) bool {
+	return GetSubscriptionShard(obj, subscriptionShardCount) == subscriptionShard
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestSubscriptionShard(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer func() {
+		g.Expect(SetSubscriptionShard(0, 1)).To(gomega.Succeed())
+	}()
+
+	g.Expect(SetSubscriptionShard(0, 0)).NotTo(gomega.Succeed())
+	g.Expect(SetSubscriptionShard(3, 3)).NotTo(gomega.Succeed())
+	g.Expect(SetSubscriptionShard(-1, 3)).NotTo(gomega.Succeed())
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"}}
+	other := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-a"}}
+
+	// a single shard reconciles all the subscriptions
+	g.Expect(IsSubscriptionInShard(sub)).To(gomega.BeTrue())
+	g.Expect(IsFirstSubscriptionShard()).To(gomega.BeTrue())
+
+	// the subscriptions of a namespace are in the same shard
+	shard := GetSubscriptionShard(sub, 3)
+	g.Expect(shard).To(gomega.BeNumerically("<", 3))
+	g.Expect(GetSubscriptionShard(other, 3)).To(gomega.Equal(shard))
+
+	g.Expect(SetSubscriptionShard(shard, 3)).To(gomega.Succeed())
+	g.Expect(IsSubscriptionInShard(sub)).To(gomega.BeTrue())
+	g.Expect(IsFirstSubscriptionShard()).To(gomega.Equal(shard == 0))
+
+	// the shard label overrides the namespace hash
+	sub.Labels = map[string]string{appv1.LabelSubscriptionShard: "0"}
+	if shard == 0 {
+		sub.Labels[appv1.LabelSubscriptionShard] = "1"
+	}
+
+	g.Expect(IsSubscriptionInShard(sub)).To(gomega.BeFalse())
+
+	// an invalid shard label is ignored
+	sub.Labels[appv1.LabelSubscriptionShard] = "3"
+	g.Expect(IsSubscriptionInShard(sub)).To(gomega.BeTrue())
+}
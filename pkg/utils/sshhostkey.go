@@ -0,0 +1,257 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" // #nosec G505 the hashed known hosts use HMAC-SHA1
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+var (
+	// ErrSSHHostKeyMismatch is wrapped by the errors of the Git servers whose SSH host key doesn't match the known hosts
+	ErrSSHHostKeyMismatch = errors.New("SSH host key mismatch")
+	// ErrSSHKnownHostsRequired is wrapped by the errors of the SSH channels without known hosts
+	ErrSSHKnownHostsRequired = errors.New("SSH known hosts required")
+)
+
+const (
+	knownHostsKeyMismatch = "knownhosts: key mismatch"
+	knownHostsKeyUnknown  = "knownhosts: key is unknown"
+)
+
+// SetChannelSSHConfig sets the SSH known hosts of the channel connection from the knownHosts field of the channel config
+// map, and trusts the host keys scanned from the Git server if its sshStrictHostKeyChecking field is false
+func SetChannelSSHConfig(conn *ChannelConnectionCfg, configMap *corev1.ConfigMap) {
+	if conn == nil || configMap == nil {
+		return
+	}
+
+	conn.KnownHosts = strings.TrimSpace(configMap.Data[appv1.ChannelKnownHostsData])
+
+	if strict := configMap.Data[appv1.ChannelStrictHostKeyCheckingData]; strict != "" {
+		b, err := strconv.ParseBool(strict)
+		if err != nil {
+			klog.Warningf("Invalid %v %q in the channel config map %v/%v, the strict SSH host key checking is enabled",
+				appv1.ChannelStrictHostKeyCheckingData, strict, configMap.Namespace, configMap.Name)
+
+			return
+		}
+
+		conn.TrustScannedHostKeys = !b
+	}
+}
+
+// WriteSSHKnownHosts writes the known_hosts file verifying the SSH host key of the Git server of the channel connection.
+// It contains the known hosts of the channel, or the host keys scanned from the Git server if the strict host key
+// checking is disabled. An error is returned if the channel has no known hosts and the strict checking is enabled.
+func WriteSSHKnownHosts(conn *ChannelConnectionCfg, knownHostsFile string) error {
+	knownHosts, err := GetSSHKnownHosts(conn)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(knownHostsFile, knownHosts, 0600); err != nil {
+		klog.Error("failed to write known_hosts file: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetSSHKnownHosts returns the known hosts verifying the SSH host key of the Git server of the channel connection, like
+// WriteSSHKnownHosts without writing the known_hosts file
+func GetSSHKnownHosts(conn *ChannelConnectionCfg) ([]byte, error) {
+	if conn.KnownHosts != "" {
+		klog.Info("Using the SSH known hosts of the channel config map")
+
+		return []byte(conn.KnownHosts + "\n"), nil
+	}
+
+	if !conn.TrustScannedHostKeys {
+		return nil, fmt.Errorf("%w, the strict SSH host key checking is enabled and the channel has no known hosts: add "+
+			"the SSH host keys of %v to the %v field of the channel config map, or set its %v field to \"false\" to trust "+
+			"the host keys scanned from the Git server", ErrSSHKnownHostsRequired, RedactString(conn.RepoURL),
+			appv1.ChannelKnownHostsData, appv1.ChannelStrictHostKeyCheckingData)
+	}
+
+	klog.Warning("The strict SSH host key checking is disabled, trusting the host keys scanned from ", RedactString(conn.RepoURL))
+
+	return scanKnownHostFromURL(conn.RepoURL)
+}
+
+// knownHostsCallback returns the host key callback verifying the host keys with the known hosts, like the callback of
+// the knownhosts package reading a known_hosts file. The hashed host names, the wildcards and the negated patterns are
+// supported, the @cert-authority lines are ignored.
+func knownHostsCallback(knownHosts []byte) (ssh.HostKeyCallback, error) {
+	type knownHost struct {
+		revoked  bool
+		patterns []string
+		key      ssh.PublicKey
+		line     int
+	}
+
+	hosts := []knownHost{}
+	rest := knownHosts
+
+	for line := 1; len(rest) > 0; line++ {
+		var (
+			marker   string
+			patterns []string
+			key      ssh.PublicKey
+			err      error
+		)
+
+		marker, patterns, key, _, rest, err = ssh.ParseKnownHosts(rest)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid known hosts: %w", err)
+		}
+
+		if marker == "cert-authority" {
+			continue
+		}
+
+		hosts = append(hosts, knownHost{revoked: marker == "revoked", patterns: patterns, key: key, line: line})
+	}
+
+	return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		host := knownhosts.Normalize(hostname)
+		keyErr := &knownhosts.KeyError{}
+
+		for _, h := range hosts {
+			if h.revoked {
+				if bytes.Equal(h.key.Marshal(), key.Marshal()) {
+					return &knownhosts.RevokedError{Revoked: knownhosts.KnownKey{Key: h.key, Line: h.line}}
+				}
+
+				continue
+			}
+
+			if !matchKnownHost(h.patterns, host) {
+				continue
+			}
+
+			if h.key.Type() == key.Type() && bytes.Equal(h.key.Marshal(), key.Marshal()) {
+				return nil
+			}
+
+			keyErr.Want = append(keyErr.Want, knownhosts.KnownKey{Key: h.key, Line: h.line})
+		}
+
+		return keyErr
+	}, nil
+}
+
+// matchKnownHost checks if the normalized host matches the host patterns of a known hosts line
+func matchKnownHost(patterns []string, host string) bool {
+	matched := false
+
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		var ok bool
+
+		switch {
+		case strings.HasPrefix(pattern, "|1|"):
+			ok = matchHashedKnownHost(pattern, host)
+		case strings.ContainsAny(pattern, "*?"):
+			// the brackets of the [host]:port patterns are not character classes
+			ok, _ = path.Match(strings.NewReplacer("[", "\\[", "]", "\\]").Replace(pattern), host)
+		default:
+			ok = knownhosts.Normalize(pattern) == host
+		}
+
+		if ok && negated {
+			return false
+		}
+
+		matched = matched || ok
+	}
+
+	return matched
+}
+
+// matchHashedKnownHost checks if the host matches a |1|salt|hash hashed host name
+func matchHashedKnownHost(pattern, host string) bool {
+	parts := strings.Split(pattern, "|")
+	if len(parts) != 4 {
+		return false
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+// SSHHostKeyError returns an error wrapping ErrSSHHostKeyMismatch if the SSH connection failed because the host key of
+// the Git server doesn't match the known hosts, the error is returned as is otherwise
+func SSHHostKeyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch msg := err.Error(); {
+	case strings.Contains(msg, knownHostsKeyMismatch):
+		return fmt.Errorf("%w, the host key of the Git server doesn't match the channel known hosts: %v", ErrSSHHostKeyMismatch, err)
+	case strings.Contains(msg, knownHostsKeyUnknown):
+		return fmt.Errorf("%w, the host key of the Git server is not in the channel known hosts: %v", ErrSSHHostKeyMismatch, err)
+	default:
+		return err
+	}
+}
+
+// IsSSHHostKeyMismatch returns true if the failure message is an SSH host key mismatch, like a subscription status
+// reason
+func IsSSHHostKeyMismatch(msg string) bool {
+	return strings.Contains(msg, ErrSSHHostKeyMismatch.Error())
+}
+
+// IsSSHKnownHostsRequired returns true if the failure message is an SSH channel without known hosts, like a
+// subscription status reason
+func IsSSHKnownHostsRequired(msg string) bool {
+	return strings.Contains(msg, ErrSSHKnownHostsRequired.Error())
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	corev1 "k8s.io/api/core/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func TestSetChannelSSHConfig(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the strict host key checking is enabled by default
+	conn := &ChannelConnectionCfg{}
+	SetChannelSSHConfig(conn, &corev1.ConfigMap{})
+	g.Expect(conn.KnownHosts).To(gomega.BeEmpty())
+	g.Expect(conn.TrustScannedHostKeys).To(gomega.BeFalse())
+
+	conn = &ChannelConnectionCfg{}
+	SetChannelSSHConfig(conn, &corev1.ConfigMap{Data: map[string]string{
+		appv1.ChannelKnownHostsData:            "\ngithub.com ssh-ed25519 AAAA\n",
+		appv1.ChannelStrictHostKeyCheckingData: "false",
+	}})
+	g.Expect(conn.KnownHosts).To(gomega.Equal("github.com ssh-ed25519 AAAA"))
+	g.Expect(conn.TrustScannedHostKeys).To(gomega.BeTrue())
+
+	conn = &ChannelConnectionCfg{}
+	SetChannelSSHConfig(conn, &corev1.ConfigMap{Data: map[string]string{appv1.ChannelStrictHostKeyCheckingData: "no"}})
+	g.Expect(conn.TrustScannedHostKeys).To(gomega.BeFalse())
+
+	SetChannelSSHConfig(conn, nil)
+	g.Expect(conn.TrustScannedHostKeys).To(gomega.BeFalse())
+}
+
+func TestWriteSSHKnownHosts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	hostKey := newTestHostKey(t)
+	knownHosts := knownhosts.Line([]string{"github.com"}, hostKey)
+
+	conn := &ChannelConnectionCfg{RepoURL: "ssh://git@github.com/org/repo.git", KnownHosts: knownHosts}
+	g.Expect(WriteSSHKnownHosts(conn, knownHostsFile)).To(gomega.Succeed())
+
+	data, err := os.ReadFile(knownHostsFile)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(data)).To(gomega.Equal(knownHosts + "\n"))
+
+	// the channels without known hosts fail with the strict host key checking
+	err = WriteSSHKnownHosts(&ChannelConnectionCfg{RepoURL: "ssh://git@github.com/org/repo.git"}, knownHostsFile)
+	g.Expect(errors.Is(err, ErrSSHKnownHostsRequired)).To(gomega.BeTrue())
+	g.Expect(err.Error()).To(gomega.ContainSubstring(appv1.ChannelKnownHostsData))
+}
+
+func TestSSHHostKeyError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	err := os.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{"github.com"}, newTestHostKey(t))+"\n"), 0600)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	addr := &net.TCPAddr{IP: net.IPv4(140, 82, 112, 3), Port: 22}
+
+	// the known host offers another key
+	mismatchErr := hostKeyCallback("github.com:22", addr, newTestHostKey(t))
+	g.Expect(mismatchErr).To(gomega.HaveOccurred())
+
+	err = SSHHostKeyError(mismatchErr)
+	g.Expect(errors.Is(err, ErrSSHHostKeyMismatch)).To(gomega.BeTrue())
+	g.Expect(IsSSHHostKeyMismatch("failed to initialize Git connection, err: " + err.Error())).To(gomega.BeTrue())
+
+	// the host is not in the known hosts
+	unknownErr := hostKeyCallback("gitlab.com:22", addr, newTestHostKey(t))
+	g.Expect(unknownErr).To(gomega.HaveOccurred())
+	g.Expect(errors.Is(SSHHostKeyError(unknownErr), ErrSSHHostKeyMismatch)).To(gomega.BeTrue())
+
+	otherErr := errors.New("authentication required")
+	g.Expect(SSHHostKeyError(otherErr)).To(gomega.BeIdenticalTo(otherErr))
+	g.Expect(SSHHostKeyError(nil)).To(gomega.BeNil())
+	g.Expect(IsSSHHostKeyMismatch(otherErr.Error())).To(gomega.BeFalse())
+}
+
+func TestFailureConditionReason(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hostKeyErr := SSHHostKeyError(errors.New("ssh: handshake failed: knownhosts: key mismatch"))
+
+	g.Expect(FailureConditionReason(ConditionReasonFailed, hostKeyErr.Error())).To(gomega.Equal(ConditionReasonHostKeyMismatch))
+	g.Expect(FailureConditionReason(ConditionReasonPropagationFailed, "failed to initialize Git connection, err: "+
+		hostKeyErr.Error())).To(gomega.Equal(ConditionReasonHostKeyMismatch))
+	g.Expect(FailureConditionReason(ConditionReasonFailed, "authentication required")).To(gomega.Equal(ConditionReasonFailed))
+
+	// the SSH channels without known hosts point to the missing known hosts
+	_, knownHostsErr := GetSSHKnownHosts(&ChannelConnectionCfg{RepoURL: "ssh://git@github.com/org/repo.git"})
+
+	g.Expect(FailureConditionReason(ConditionReasonPropagationFailed, "failed to initialize Git connection, err: "+
+		knownHostsErr.Error())).To(gomega.Equal(ConditionReasonKnownHostsRequired))
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	githubKey := newTestHostKey(t)
+	gitlabKey := newTestHostKey(t)
+	portKey := newTestHostKey(t)
+	revokedKey := newTestHostKey(t)
+	addr := &net.TCPAddr{IP: net.IPv4(140, 82, 112, 3), Port: 22}
+
+	knownHosts := knownhosts.Line([]string{"github.com"}, githubKey) + "\n" +
+		knownhosts.Line([]string{knownhosts.HashHostname("gitlab.com")}, gitlabKey) + "\n" +
+		knownhosts.Line([]string{"[git.example.com]:2222"}, portKey) + "\n" +
+		knownhosts.Line([]string{"*.example.org", "!private.example.org"}, portKey) + "\n" +
+		"@revoked * " + string(ssh.MarshalAuthorizedKey(revokedKey))
+
+	hostKeyCallback, err := knownHostsCallback([]byte(knownHosts))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(hostKeyCallback("github.com:22", addr, githubKey)).To(gomega.Succeed())
+	g.Expect(hostKeyCallback("gitlab.com:22", addr, gitlabKey)).To(gomega.Succeed())
+	g.Expect(hostKeyCallback("git.example.com:2222", addr, portKey)).To(gomega.Succeed())
+	g.Expect(hostKeyCallback("git.example.org:22", addr, portKey)).To(gomega.Succeed())
+
+	// the known host offers another key
+	err = hostKeyCallback("github.com:22", addr, gitlabKey)
+	g.Expect(errors.Is(SSHHostKeyError(err), ErrSSHHostKeyMismatch)).To(gomega.BeTrue())
+	g.Expect(err.Error()).To(gomega.Equal(knownHostsKeyMismatch))
+
+	// the hosts are not in the known hosts
+	for _, host := range []string{"git.example.com:22", "private.example.org:22", "bitbucket.org:22"} {
+		err = hostKeyCallback(host, addr, portKey)
+		g.Expect(err).To(gomega.HaveOccurred())
+		g.Expect(err.Error()).To(gomega.Equal(knownHostsKeyUnknown))
+	}
+
+	// the revoked keys are rejected
+	err = hostKeyCallback("github.com:22", addr, revokedKey)
+	g.Expect(err).To(gomega.BeAssignableToTypeOf(&knownhosts.RevokedError{}))
+
+	_, err = knownHostsCallback([]byte("github.com ssh-ed25519 invalid\n"))
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestGetSSHKnownHosts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	knownHosts := knownhosts.Line([]string{"github.com"}, newTestHostKey(t))
+
+	data, err := GetSSHKnownHosts(&ChannelConnectionCfg{RepoURL: "ssh://git@github.com/org/repo.git", KnownHosts: knownHosts})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(data)).To(gomega.Equal(knownHosts + "\n"))
+
+	_, err = GetSSHKnownHosts(&ChannelConnectionCfg{RepoURL: "ssh://git@github.com/org/repo.git"})
+	g.Expect(errors.Is(err, ErrSSHKnownHostsRequired)).To(gomega.BeTrue())
+}
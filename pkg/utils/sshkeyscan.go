@@ -0,0 +1,106 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHPort    = "22"
+	sshKeyScanTimeout = 10 * time.Second
+)
+
+// errSSHHostKeyScanned stops the SSH handshake once the host key is received
+var errSSHHostKeyScanned = errors.New("SSH host key scanned")
+
+// sshKeyScanAlgorithms are the host key algorithms requested by the key scan, one key is scanned for each entry
+var sshKeyScanAlgorithms = [][]string{
+	{ssh.KeyAlgoED25519},
+	{ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521},
+	{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA},
+}
+
+// ScanSSHHostKeys returns the SSH host keys of the server in the known hosts format, like the ssh-keyscan command
+// without running it. The port is 22 if it is empty. The key types the server doesn't offer are skipped, it fails if
+// no key is scanned.
+func ScanSSHHostKeys(host, port string) ([]byte, error) {
+	if host == "" {
+		return nil, fmt.Errorf("the SSH host is empty")
+	}
+
+	if port == "" {
+		port = defaultSSHPort
+	}
+
+	addr := net.JoinHostPort(host, port)
+	lines := []string{}
+
+	var scanErr error
+
+	for _, algorithms := range sshKeyScanAlgorithms {
+		key, err := scanSSHHostKey(addr, algorithms)
+		if err != nil {
+			scanErr = err
+
+			continue
+		}
+
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(addr)}, key))
+	}
+
+	if len(lines) == 0 {
+		return nil, scanErr
+	}
+
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// scanSSHHostKey returns the host key of the server for the host key algorithms, the handshake is stopped before the
+// authentication
+func scanSSHHostKey(addr string, algorithms []string) (ssh.PublicKey, error) {
+	var hostKey ssh.PublicKey
+
+	config := &ssh.ClientConfig{
+		User:              "keyscan",
+		HostKeyAlgorithms: algorithms,
+		Timeout:           sshKeyScanTimeout,
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+
+			return errSSHHostKeyScanned
+		},
+	}
+
+	client, err := ssh.Dial("tcp", addr, config)
+	if err == nil {
+		client.Close()
+
+		err = fmt.Errorf("no %v host key received from %v", strings.Join(algorithms, ", "), addr)
+	}
+
+	if hostKey == nil {
+		return nil, err
+	}
+
+	return hostKey, nil
+}
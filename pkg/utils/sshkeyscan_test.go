@@ -0,0 +1,94 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startTestSSHServer starts an SSH server with an ed25519 host key, it only runs the handshakes
+func startTestSSHServer(t *testing.T) (string, ssh.PublicKey) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				_, _, _, _ = ssh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), signer.PublicKey()
+}
+
+func TestScanSSHHostKeys(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	addr, hostKey := startTestSSHServer(t)
+
+	host, port, err := net.SplitHostPort(addr)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// only the ed25519 key is offered by the server
+	knownHosts, err := ScanSSHHostKeys(host, port)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(strings.Split(strings.TrimSpace(string(knownHosts)), "\n")).To(gomega.Equal(
+		[]string{knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)}))
+
+	_, err = ScanSSHHostKeys("", "")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, closedPort, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	_, err = ScanSSHHostKeys("127.0.0.1", closedPort)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
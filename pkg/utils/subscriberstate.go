@@ -0,0 +1,235 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// SubscriberStateConfigMapName is the name of the ConfigMap persisting the subscriber states in the component namespace
+const SubscriberStateConfigMapName = "application-manager-subscriber-state"
+
+// SubscriberState is the state of a subscriber item persisted across the agent restarts
+type SubscriberState struct {
+	// Commit is the last commit applied
+	Commit string `json:"commit,omitempty"`
+	// ResourceHash is the hash of the subscription spec and of the resources last applied
+	ResourceHash string `json:"resourceHash,omitempty"`
+	// Successful is true if all the resources were applied successfully
+	Successful bool `json:"successful,omitempty"`
+}
+
+// SubscriberStateStore persists the subscriber states in a ConfigMap, one key per subscription. The ConfigMap is read
+// once, the states are then kept in memory and only written back when they change.
+type SubscriberStateStore struct {
+	client    client.Client
+	namespace string
+	lock      sync.Mutex
+	states    map[string]SubscriberState
+}
+
+// NewSubscriberStateStore returns a subscriber state store persisting the states in the component namespace
+func NewSubscriberStateStore(clt client.Client) *SubscriberStateStore {
+	return &SubscriberStateStore{
+		client:    clt,
+		namespace: GetComponentNamespace(),
+	}
+}
+
+// the namespace name doesn't contain dots, so the key is unique and a valid ConfigMap key
+func subscriberStateKey(key types.NamespacedName) string {
+	return key.Namespace + "." + key.Name
+}
+
+// load reads the persisted states the first time they are needed, the caller must hold the lock
+func (s *SubscriberStateStore) load() {
+	if s.states != nil {
+		return
+	}
+
+	s.states = map[string]SubscriberState{}
+
+	cm := &corev1.ConfigMap{}
+	if err := s.client.Get(context.TODO(), types.NamespacedName{Namespace: s.namespace, Name: SubscriberStateConfigMapName}, cm); err != nil {
+		if !kerrors.IsNotFound(err) {
+			klog.Errorf("Failed to get the subscriber states %v/%v, err: %v", s.namespace, SubscriberStateConfigMapName, err)
+		}
+
+		return
+	}
+
+	for key, data := range cm.Data {
+		state := SubscriberState{}
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			klog.Warningf("Ignoring the invalid subscriber state %v, err: %v", key, err)
+			continue
+		}
+
+		s.states[key] = state
+	}
+
+	klog.Infof("Loaded %v subscriber states from %v/%v", len(s.states), s.namespace, SubscriberStateConfigMapName)
+}
+
+// Get returns the persisted state of the subscription
+func (s *SubscriberStateStore) Get(key types.NamespacedName) (SubscriberState, bool) {
+	if s == nil || s.client == nil {
+		return SubscriberState{}, false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.load()
+
+	state, ok := s.states[subscriberStateKey(key)]
+
+	return state, ok
+}
+
+// Set persists the state of the subscription if it changed
+func (s *SubscriberStateStore) Set(key types.NamespacedName, state SubscriberState) error {
+	if s == nil || s.client == nil {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.load()
+
+	stateKey := subscriberStateKey(key)
+	if prev, ok := s.states[stateKey]; ok && prev == state {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := s.write(stateKey, string(data)); err != nil {
+		return err
+	}
+
+	s.states[stateKey] = state
+
+	return nil
+}
+
+// Delete removes the persisted state of the subscription
+func (s *SubscriberStateStore) Delete(key types.NamespacedName) error {
+	if s == nil || s.client == nil {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.load()
+
+	stateKey := subscriberStateKey(key)
+	if _, ok := s.states[stateKey]; !ok {
+		return nil
+	}
+
+	if err := s.write(stateKey, ""); err != nil {
+		return err
+	}
+
+	delete(s.states, stateKey)
+
+	return nil
+}
+
+// write sets or removes, if data is empty, a key of the ConfigMap, it is retried on conflict
+func (s *SubscriberStateStore) write(stateKey, data string) error {
+	retriable := func(err error) bool {
+		return kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err)
+	}
+
+	return retry.OnError(retry.DefaultBackoff, retriable, func() error {
+		cm := &corev1.ConfigMap{}
+
+		err := s.client.Get(context.TODO(), types.NamespacedName{Namespace: s.namespace, Name: SubscriberStateConfigMapName}, cm)
+		if err != nil {
+			if !kerrors.IsNotFound(err) || data == "" {
+				return client.IgnoreNotFound(err)
+			}
+
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      SubscriberStateConfigMapName,
+					Namespace: s.namespace,
+				},
+				Data: map[string]string{stateKey: data},
+			}
+
+			return s.client.Create(context.TODO(), cm)
+		}
+
+		if data == "" {
+			if _, ok := cm.Data[stateKey]; !ok {
+				return nil
+			}
+
+			delete(cm.Data, stateKey)
+		} else {
+			if cm.Data == nil {
+				cm.Data = map[string]string{}
+			}
+
+			cm.Data[stateKey] = data
+		}
+
+		return s.client.Update(context.TODO(), cm)
+	})
+}
+
+// HashSubscriberResources returns the hash of the subscription spec and of the resources to apply, so a subscriber can
+// tell if the resources changed since they were last applied
+func HashSubscriberResources(sub *appv1.Subscription, resources []*unstructured.Unstructured) string {
+	h := sha256.New()
+
+	if data, err := json.Marshal(sub.Spec); err == nil {
+		_, _ = h.Write(data)
+	}
+
+	for _, rsc := range resources {
+		data, err := json.Marshal(rsc.Object)
+		if err != nil {
+			return ""
+		}
+
+		_, _ = h.Write(data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestHashSubscriberResources(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app-ns"},
+		Spec:       appv1.SubscriptionSpec{Channel: "ch-ns/ch"},
+	}
+
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetName("cm")
+	cm.SetNamespace("app-ns")
+
+	hash := HashSubscriberResources(sub, []*unstructured.Unstructured{cm})
+	g.Expect(hash).NotTo(gomega.BeEmpty())
+	g.Expect(HashSubscriberResources(sub.DeepCopy(), []*unstructured.Unstructured{cm.DeepCopy()})).To(gomega.Equal(hash))
+
+	// a resource change
+	changedCm := cm.DeepCopy()
+	changedCm.SetLabels(map[string]string{"app": "test"})
+	g.Expect(HashSubscriberResources(sub, []*unstructured.Unstructured{changedCm})).NotTo(gomega.Equal(hash))
+
+	// a subscription spec change, e.g. a package override
+	changedSub := sub.DeepCopy()
+	changedSub.Spec.Package = "cm"
+	g.Expect(HashSubscriberResources(changedSub, []*unstructured.Unstructured{cm})).NotTo(gomega.Equal(hash))
+}
+
+func TestSubscriberStateStoreWithoutClient(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var store *SubscriberStateStore
+
+	key := types.NamespacedName{Namespace: "app-ns", Name: "app"}
+
+	g.Expect(store.Set(key, SubscriberState{Commit: "abc", Successful: true})).To(gomega.Succeed())
+
+	_, found := store.Get(key)
+	g.Expect(found).To(gomega.BeFalse())
+	g.Expect(store.Delete(key)).To(gomega.Succeed())
+
+	g.Expect(subscriberStateKey(key)).To(gomega.Equal("app-ns.app"))
+}
@@ -16,7 +16,6 @@ package utils
 
 import (
 	"context"
-	"crypto/sha1" // #nosec G505 Used only to generate random value to be used to generate hash string
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -46,6 +45,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
@@ -157,6 +157,33 @@ var AppSubSummaryPredicateFunc = predicate.TypedFuncs[*appsubReportV1alpha1.Subs
 	},
 }
 
+// AppSubReportFailedPredicateFunc filters cluster appsubReport updates reporting failed appsubs
+var AppSubReportFailedPredicateFunc = predicate.TypedFuncs[*appsubReportV1alpha1.SubscriptionReport]{
+	UpdateFunc: func(e event.TypedUpdateEvent[*appsubReportV1alpha1.SubscriptionReport]) bool {
+		if e.ObjectNew.ReportType != "Cluster" {
+			return false
+		}
+
+		return hasFailedResult(e.ObjectNew) && !equality.Semantic.DeepEqual(e.ObjectOld.Results, e.ObjectNew.Results)
+	},
+	CreateFunc: func(e event.TypedCreateEvent[*appsubReportV1alpha1.SubscriptionReport]) bool {
+		return e.Object.ReportType == "Cluster" && hasFailedResult(e.Object)
+	},
+	DeleteFunc: func(e event.TypedDeleteEvent[*appsubReportV1alpha1.SubscriptionReport]) bool {
+		return false
+	},
+}
+
+func hasFailedResult(appsubReport *appsubReportV1alpha1.SubscriptionReport) bool {
+	for _, result := range appsubReport.Results {
+		if result != nil && result.Result == "failed" {
+			return true
+		}
+	}
+
+	return false
+}
+
 // SubscriptionPredicateFunctions filters status update
 var SubscriptionPredicateFunctions = predicate.TypedFuncs[*appv1.Subscription]{
 	UpdateFunc: func(e event.TypedUpdateEvent[*appv1.Subscription]) bool {
@@ -176,6 +203,11 @@ func IsSubscriptionBasicChanged(o, n *appv1.Subscription) bool {
 		return true
 	}
 
+	// the deletion of a subscription held by a cleanup finalizer is only seen as an update
+	if fOsub.GetDeletionTimestamp().IsZero() != fNSub.GetDeletionTimestamp().IsZero() {
+		return true
+	}
+
 	// we care label change, pass it down
 	klog.Infof("fOsub_labels: %v", fOsub.GetLabels())
 	klog.Infof("fNSub_labels: %v", fNSub.GetLabels())
@@ -323,11 +355,19 @@ func IsHubRelatedStatusChanged(old, nnew *appv1.SubscriptionStatus) bool {
 		return true
 	}
 
+	if old.ObservedGeneration != nnew.ObservedGeneration {
+		return true
+	}
+
 	//care about the managed subscription status
 	if !isEqualSubClusterStatus(old.Statuses, nnew.Statuses) {
 		return true
 	}
 
+	if !isSameConditions(old.Conditions, nnew.Conditions) {
+		return true
+	}
+
 	return false
 }
 
@@ -628,33 +668,53 @@ func UpdateSubscriptionStatus(clt client.Client, subName, subNs string, phase ap
 	}
 }
 
-// OverrideResourceBySubscription alter the given template with overrides
+// OverrideResourceBySubscription alter the given template with the package overrides matching the package name or
+// the target of the overrides
 func OverrideResourceBySubscription(template *unstructured.Unstructured,
 	pkgName string, instance *appv1.Subscription) (*unstructured.Unstructured, error) {
-	ovs := prepareOverrides(pkgName, instance)
+	ovt := template.DeepCopy()
 
-	return OverrideTemplate(template, ovs)
-}
-
-func prepareOverrides(pkgName string, instance *appv1.Subscription) []appv1.ClusterOverride {
-	if instance == nil || instance.Spec.PackageOverrides == nil {
-		return nil
+	if template == nil || instance == nil {
+		return ovt, nil
 	}
 
-	var overrides []appv1.ClusterOverride
-
-	// go over clsuters to find matching override
 	for _, ov := range instance.Spec.PackageOverrides {
-		if ov.PackageName != pkgName {
+		if !isPackageOverrideMatched(ov, pkgName, ovt) {
 			continue
 		}
 
-		for _, pov := range ov.PackageOverrides {
-			overrides = append(overrides, appv1.ClusterOverride(pov))
+		var err error
+
+		ovt, err = PatchTemplate(ovt, ov)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return overrides
+	return ovt, nil
+}
+
+// isPackageOverrideMatched checks if the package overrides apply to the template. Without target, the package name
+// has to match. With target, the package name, if set, and all the target fields set have to match
+func isPackageOverrideMatched(ov *appv1.Overrides, pkgName string, template *unstructured.Unstructured) bool {
+	if ov == nil {
+		return false
+	}
+
+	if ov.Target == nil {
+		return ov.PackageName == pkgName
+	}
+
+	if ov.PackageName != "" && ov.PackageName != pkgName {
+		return false
+	}
+
+	target := ov.Target
+
+	return (target.APIVersion == "" || target.APIVersion == template.GetAPIVersion()) &&
+		(target.Kind == "" || target.Kind == template.GetKind()) &&
+		(target.Name == "" || target.Name == template.GetName()) &&
+		(target.Namespace == "" || target.Namespace == template.GetNamespace())
 }
 
 // KeywordsChecker Checks if the helm chart has at least 1 keyword from the packageFilter.Keywords array
@@ -680,20 +740,20 @@ func LabelsChecker(labelSelector *metav1.LabelSelector, ls map[string]string) bo
 // GetReleaseName alters the given name in a deterministic way if the length exceed the maximum character
 func GetReleaseName(base string) (string, error) {
 	if len(base) > maxNameLength {
-		h := sha1.New() // #nosec G401 Used only to generate random value to be used to generate hash string
+		h := NewNameHash()
 		_, err := h.Write([]byte(base))
 
 		if err != nil {
-			klog.Error("Failed to generate sha1 hash for: ", base, " error: ", err)
+			klog.Error("Failed to generate hash for: ", base, " error: ", err)
 			return "", err
 		}
 
-		sha1Hash := hex.EncodeToString(h.Sum(nil))
+		nameHash := hex.EncodeToString(h.Sum(nil))
 
 		//minus 1 because adding "-"
 		base = base[:maxGeneratedNameLength]
 
-		return fmt.Sprintf("%s-%s", base, sha1Hash[:randomLength]), nil
+		return fmt.Sprintf("%s-%s", base, nameHash[:randomLength]), nil
 	}
 
 	return base, nil
@@ -755,8 +815,7 @@ func IsResourceAllowed(resource unstructured.Unstructured, allowlist map[string]
 			return true
 		}
 
-		return (allowlist[resource.GetAPIVersion()][resource.GetKind()] != "" ||
-			allowlist[resource.GetAPIVersion()]["*"] != "")
+		return isResourceListed(resource, allowlist)
 	}
 
 	// If not subscription-admin, ignore the allow list and don't allow policy
@@ -773,51 +832,167 @@ func IsResourceDenied(resource unstructured.Unstructured, denyList map[string]ma
 			return false
 		}
 
-		return (denyList[resource.GetAPIVersion()][resource.GetKind()] != "" ||
-			denyList[resource.GetAPIVersion()]["*"] != "")
+		return isResourceListed(resource, denyList)
 	}
 
 	// If not subscription-admin, ignore the deny list
 	return false
 }
 
-// GetAllowDenyLists returns subscription's allow and deny lists as maps. It returns empty map if there is no list.
-func GetAllowDenyLists(subscription appv1.Subscription) (map[string]map[string]string, map[string]map[string]string) {
-	allowedGroupResources := make(map[string]map[string]string)
+// isResourceListed checks if the resource matches an API version and a kind of an allow or deny list map returned by
+// GetAllowDenyLists. The kinds restricted to a namespace are keyed by <namespace>/<kind>.
+func isResourceListed(resource unstructured.Unstructured, list map[string]map[string]string) bool {
+	for apiVersion, kinds := range list {
+		if !matchAPIVersion(apiVersion, resource.GetAPIVersion()) {
+			continue
+		}
 
-	if subscription.Spec.Allow != nil {
-		for _, allowGroup := range subscription.Spec.Allow {
-			for _, resource := range allowGroup.Kinds {
-				klog.Info("allowing to deploy resource " + allowGroup.APIVersion + "/" + resource)
+		for _, kind := range []string{resource.GetKind(), "*"} {
+			if kinds[kind] != "" {
+				return true
+			}
+
+			if resource.GetNamespace() != "" && kinds[resource.GetNamespace()+"/"+kind] != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchAPIVersion checks if the API version matches the API version pattern of an allow or deny list item. "*" matches
+// all the API versions, "<group>/*" all the versions of the group and "*.<group>" all the versions of the group and
+// its subgroups, the group itself may end with "/<version>" to match a single version.
+func matchAPIVersion(pattern, apiVersion string) bool {
+	if pattern == "*" || pattern == apiVersion {
+		return true
+	}
+
+	group, version := ParseAPIVersion(apiVersion)
+
+	patternGroup, patternVersion := pattern, "*"
+	if idx := strings.Index(pattern, "/"); idx >= 0 {
+		patternGroup, patternVersion = pattern[:idx], pattern[idx+1:]
+	} else if !strings.HasPrefix(pattern, "*.") {
+		// a core API version, like v1
+		return false
+	}
 
-				if allowedGroupResources[allowGroup.APIVersion] == nil {
-					allowedGroupResources[allowGroup.APIVersion] = make(map[string]string)
+	if patternVersion != "*" && patternVersion != version {
+		return false
+	}
+
+	if strings.HasPrefix(patternGroup, "*.") {
+		parent := strings.TrimPrefix(patternGroup, "*.")
+
+		return group == parent || strings.HasSuffix(group, "."+parent)
+	}
+
+	return patternGroup == group
+}
+
+// ValidateAllowDenyLists returns an error if an API version, a kind or a namespace of the subscription allow and deny
+// lists is invalid
+func ValidateAllowDenyLists(spec appv1.SubscriptionSpec) error {
+	lists := map[string][]*appv1.AllowDenyItem{"allow": spec.Allow, "deny": spec.Deny}
+
+	for _, name := range []string{"allow", "deny"} {
+		for _, item := range lists[name] {
+			if item == nil {
+				continue
+			}
+
+			if err := validateAPIVersionPattern(item.APIVersion); err != nil {
+				return fmt.Errorf("invalid %v list apiVersion %q: %w", name, item.APIVersion, err)
+			}
+
+			for _, kind := range item.Kinds {
+				if kind == "" || kind != "*" && strings.ContainsAny(kind, "*/") {
+					return fmt.Errorf("invalid %v list kind %q, expected a kind or *", name, kind)
 				}
+			}
 
-				allowedGroupResources[allowGroup.APIVersion][resource] = resource
+			for _, ns := range item.Namespaces {
+				if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+					return fmt.Errorf("invalid %v list namespace %q: %v", name, ns, strings.Join(errs, ", "))
+				}
 			}
 		}
 	}
 
+	return nil
+}
+
+func validateAPIVersionPattern(pattern string) error {
+	if pattern == "*" || !strings.Contains(pattern, "*") {
+		return nil
+	}
+
+	group, version := pattern, "*"
+	if idx := strings.Index(pattern, "/"); idx >= 0 {
+		group, version = pattern[:idx], pattern[idx+1:]
+	}
+
+	if version != "*" && strings.Contains(version, "*") {
+		return fmt.Errorf("the version wildcard must be the whole version, like batch/*")
+	}
+
+	if strings.Contains(strings.TrimPrefix(group, "*."), "*") {
+		return fmt.Errorf("the group wildcard must be a *. prefix, like *.apps")
+	}
+
+	return nil
+}
+
+// GetAllowDenyLists returns subscription's allow and deny lists as maps of API versions to kinds. The kinds of the
+// items restricted to namespaces are keyed by <namespace>/<kind>. It returns empty map if there is no list.
+func GetAllowDenyLists(subscription appv1.Subscription) (map[string]map[string]string, map[string]map[string]string) {
+	allowedGroupResources := make(map[string]map[string]string)
+
+	if subscription.Spec.Allow != nil {
+		for _, allowGroup := range subscription.Spec.Allow {
+			addAllowDenyItem(allowedGroupResources, allowGroup, "allowing")
+		}
+	}
+
 	deniedGroupResources := make(map[string]map[string]string)
 
 	if subscription.Spec.Deny != nil {
 		for _, denyGroup := range subscription.Spec.Deny {
-			for _, resource := range denyGroup.Kinds {
-				klog.Info("denying to deploy resource " + denyGroup.APIVersion + "/" + resource)
-
-				if deniedGroupResources[denyGroup.APIVersion] == nil {
-					deniedGroupResources[denyGroup.APIVersion] = make(map[string]string)
-				}
-
-				deniedGroupResources[denyGroup.APIVersion][resource] = resource
-			}
+			addAllowDenyItem(deniedGroupResources, denyGroup, "denying")
 		}
 	}
 
 	return allowedGroupResources, deniedGroupResources
 }
 
+func addAllowDenyItem(groupResources map[string]map[string]string, item *appv1.AllowDenyItem, action string) {
+	if item == nil {
+		return
+	}
+
+	for _, resource := range item.Kinds {
+		if groupResources[item.APIVersion] == nil {
+			groupResources[item.APIVersion] = make(map[string]string)
+		}
+
+		if len(item.Namespaces) == 0 {
+			klog.Info(action + " to deploy resource " + item.APIVersion + "/" + resource)
+
+			groupResources[item.APIVersion][resource] = resource
+
+			continue
+		}
+
+		for _, ns := range item.Namespaces {
+			klog.Info(action + " to deploy resource " + item.APIVersion + "/" + resource + " in namespace " + ns)
+
+			groupResources[item.APIVersion][ns+"/"+resource] = resource
+		}
+	}
+}
+
 // DeleteSubscriptionCRD deletes the Subscription CRD
 func DeleteSubscriptionCRD(runtimeClient client.Client, crdx *clientsetx.Clientset) {
 	sublist := &appv1.SubscriptionList{}
@@ -1066,7 +1241,7 @@ func CompareManifestWork(oldManifestWork, newManifestWork *manifestWorkV1.Manife
 			return false
 		}
 
-		klog.V(1).Infof("=====\n oldManifest: %#v", oldManifest)
+		klog.V(1).Infof("=====\n oldManifest: %#v", RedactObject(oldManifest))
 
 		err = json.Unmarshal(newManifestWork.Spec.Workload.Manifests[i].Raw, newManifest)
 		if err != nil {
@@ -1074,10 +1249,10 @@ func CompareManifestWork(oldManifestWork, newManifestWork *manifestWorkV1.Manife
 			return false
 		}
 
-		klog.V(1).Infof("=====\n newManifest: %#v", newManifest)
+		klog.V(1).Infof("=====\n newManifest: %#v", RedactObject(newManifest))
 
 		if !isSameUnstructured(oldManifest, newManifest) {
-			klog.V(1).Infof("old template: %#v, new template: %#v", oldManifest, newManifest)
+			klog.V(1).Infof("old template: %#v, new template: %#v", RedactObject(oldManifest), RedactObject(newManifest))
 			return false
 		}
 	}
@@ -1109,7 +1284,8 @@ func isSameUnstructured(obj1, obj2 *unstructured.Unstructured) bool {
 		return false
 	}
 
-	if !equality.Semantic.DeepEqual(obj1Copy.GetAnnotations(), obj2Copy.GetAnnotations()) {
+	// the trace context only links the spans of a deployment, it doesn't change the object
+	if !equality.Semantic.DeepEqual(withoutTraceAnnotations(obj1Copy.GetAnnotations()), withoutTraceAnnotations(obj2Copy.GetAnnotations())) {
 		return false
 	}
 
@@ -1122,6 +1298,64 @@ func isSameUnstructured(obj1, obj2 *unstructured.Unstructured) bool {
 	return equality.Semantic.DeepEqual(obj1Copy.Object, obj2Copy.Object)
 }
 
+func withoutTraceAnnotations(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		return nil
+	}
+
+	delete(annotations, appv1.AnnotationTraceParent)
+	delete(annotations, appv1.AnnotationTraceState)
+
+	return annotations
+}
+
+// GetAppsubCurrentCommit returns the commit currently deployed by the appsub, without the "-new" suffix
+// the hub git watcher appends to trigger a reconcile
+func GetAppsubCurrentCommit(appsub *appv1.Subscription) string {
+	return strings.TrimSuffix(appsub.GetAnnotations()[appv1.AnnotationGitCommit], "-new")
+}
+
+// SetAppsubRevision records the revision being applied by the subscriber in the in memory appsub
+func SetAppsubRevision(appsub *appv1.Subscription, revision string) {
+	annotations := appsub.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[appv1.AnnotationCurrentRevision] = revision
+	appsub.SetAnnotations(annotations)
+}
+
+// GetAppsubRevision returns the revision being applied by the subscriber
+func GetAppsubRevision(appsub *appv1.Subscription) string {
+	if appsub == nil {
+		return ""
+	}
+
+	return appsub.GetAnnotations()[appv1.AnnotationCurrentRevision]
+}
+
+// SetAppsubSource records the URL of the source of the revision being applied by the subscriber in the in memory
+// appsub, with its credentials redacted
+func SetAppsubSource(appsub *appv1.Subscription, source string) {
+	annotations := appsub.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[appv1.AnnotationCurrentSource] = RedactString(source)
+	appsub.SetAnnotations(annotations)
+}
+
+// GetAppsubSource returns the URL of the source of the revision being applied by the subscriber
+func GetAppsubSource(appsub *appv1.Subscription) string {
+	if appsub == nil {
+		return ""
+	}
+
+	return appsub.GetAnnotations()[appv1.AnnotationCurrentSource]
+}
+
 // IsHostingAppsub return true if contains hosting annotation
 func IsHostingAppsub(appsub *appv1.Subscription) bool {
 	if appsub == nil {
@@ -1166,20 +1400,10 @@ func ParseNamespacedName(namespacedName string) (string, string) {
 
 // FetchChannelReferences best-effort to return the channel secret and configmap if they exist
 func FetchChannelReferences(clt client.Client, chn chnv1.Channel) (sec *corev1.Secret, cm *corev1.ConfigMap) {
-	if chn.Spec.SecretRef != nil {
-		secret := &corev1.Secret{}
-
-		chnseckey := types.NamespacedName{
-			Name:      chn.Spec.SecretRef.Name,
-			Namespace: chn.GetNamespace(),
-		}
-
-		if chn.Spec.SecretRef.Namespace != "" {
-			chnseckey.Namespace = chn.Spec.SecretRef.Namespace
-		}
-
-		if err := clt.Get(context.TODO(), chnseckey, secret); err != nil {
-			klog.Warningf("failed to get reference secret from channel err: %v, chnseckey: %v", err, chnseckey)
+	if HasChannelSecret(&chn) {
+		secret, err := ResolveChannelSecret(clt, &chn)
+		if err != nil {
+			klog.Warningf("failed to get reference secret from channel %v/%v err: %v", chn.Namespace, chn.Name, err)
 		} else {
 			sec = secret
 		}
@@ -1188,14 +1412,7 @@ func FetchChannelReferences(clt client.Client, chn chnv1.Channel) (sec *corev1.S
 	if chn.Spec.ConfigMapRef != nil {
 		configMap := &corev1.ConfigMap{}
 
-		chncmkey := types.NamespacedName{
-			Name:      chn.Spec.ConfigMapRef.Name,
-			Namespace: chn.GetNamespace(),
-		}
-
-		if chn.Spec.ConfigMapRef.Namespace != "" {
-			chncmkey.Namespace = chn.Spec.ConfigMapRef.Namespace
-		}
+		chncmkey := GetChannelConfigMapKey(&chn)
 
 		if err := clt.Get(context.TODO(), chncmkey, configMap); err != nil {
 			klog.Warningf("failed to get reference configmap from channel err: %v, chnseckey: %v", err, chncmkey)
@@ -1252,6 +1469,24 @@ func IsReadyPlacementDecision(clReader client.Reader) bool {
 	return true
 }
 
+// IsReadyDeploymentWindow check if DeploymentWindow API is ready or not.
+func IsReadyDeploymentWindow(clReader client.Reader) bool {
+	dwList := &appv1.DeploymentWindowList{}
+
+	listopts := &client.ListOptions{}
+
+	err := clReader.List(context.TODO(), dwList, listopts)
+	if err != nil {
+		klog.Error("Deployment Window API NOT ready: ", err)
+
+		return false
+	}
+
+	klog.Info("Deployment Window API is ready")
+
+	return true
+}
+
 // IsReadySubscription check if Subscription API is ready or not.
 func IsReadySubscription(clReader client.Reader, hub bool) bool {
 	subList := appv1.SubscriptionList{}
@@ -932,6 +932,21 @@ func TestIsSubscriptionBasicChanged(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "deletion timestamp set",
+			expected: true,
+			oldIns: &appv1.Subscription{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers: []string{appv1.FinalizerResourceCleanup},
+				},
+			},
+			newIns: &appv1.Subscription{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers:        []string{appv1.FinalizerResourceCleanup},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1225,6 +1240,85 @@ func TestGetAllowDenyLists(t *testing.T) {
 	g.Expect(deniedResources).To(Equal(expectedDeniedResources))
 }
 
+func TestAllowDenyListWildcards(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sub := appv1.Subscription{}
+	sub.Spec.Allow = []*appv1.AllowDenyItem{
+		{APIVersion: "*.apps", Kinds: []string{"*"}},
+		{APIVersion: "batch/*", Kinds: []string{"Job"}},
+		{APIVersion: "v1", Kinds: []string{"ConfigMap"}, Namespaces: []string{"app-ns"}},
+	}
+	sub.Spec.Deny = []*appv1.AllowDenyItem{
+		{APIVersion: "*.open-cluster-management.io/v1", Kinds: []string{"Subscription"}},
+	}
+
+	allowlist, denyList := GetAllowDenyLists(sub)
+
+	newResource := func(apiVersion, kind, namespace string) unstructured.Unstructured {
+		resource := unstructured.Unstructured{}
+		resource.SetAPIVersion(apiVersion)
+		resource.SetKind(kind)
+		resource.SetNamespace(namespace)
+
+		return resource
+	}
+
+	tests := []struct {
+		resource unstructured.Unstructured
+		allowed  bool
+		denied   bool
+	}{
+		{newResource("apps/v1", "Deployment", "app-ns"), true, false},
+		{newResource("config.apps/v1beta1", "Config", ""), true, false},
+		{newResource("myapps/v1", "Deployment", "app-ns"), false, false},
+		{newResource("batch/v1", "Job", "app-ns"), true, false},
+		{newResource("batch/v1beta1", "CronJob", "app-ns"), false, false},
+		{newResource("v1", "ConfigMap", "app-ns"), true, false},
+		{newResource("v1", "ConfigMap", "other-ns"), false, false},
+		{newResource("apps.open-cluster-management.io/v1", "Subscription", "app-ns"), false, true},
+		{newResource("apps.open-cluster-management.io/v1beta1", "Subscription", "app-ns"), false, false},
+	}
+
+	for _, tt := range tests {
+		g.Expect(IsResourceAllowed(tt.resource, allowlist, true)).To(Equal(tt.allowed),
+			"allowed %v %v", tt.resource.GetAPIVersion(), tt.resource.GetKind())
+		g.Expect(IsResourceDenied(tt.resource, denyList, true)).To(Equal(tt.denied),
+			"denied %v %v", tt.resource.GetAPIVersion(), tt.resource.GetKind())
+	}
+
+	g.Expect(allowlist["v1"]).To(Equal(map[string]string{"app-ns/ConfigMap": "ConfigMap"}))
+}
+
+func TestValidateAllowDenyLists(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	valid := appv1.SubscriptionSpec{
+		Allow: []*appv1.AllowDenyItem{
+			{APIVersion: "*", Kinds: []string{"*"}},
+			{APIVersion: "*.apps", Kinds: []string{"Deployment"}},
+			{APIVersion: "batch/*", Kinds: []string{"Job"}, Namespaces: []string{"app-ns"}},
+		},
+		Deny: []*appv1.AllowDenyItem{{APIVersion: "v1", Kinds: []string{"Secret"}}},
+	}
+	g.Expect(ValidateAllowDenyLists(valid)).To(Succeed())
+
+	invalid := []*appv1.AllowDenyItem{
+		{APIVersion: "apps*/v1", Kinds: []string{"Deployment"}},
+		{APIVersion: "apps/v1*", Kinds: []string{"Deployment"}},
+		{APIVersion: "apps/v1", Kinds: []string{"Deploy*"}},
+		{APIVersion: "apps/v1", Kinds: []string{""}},
+		{APIVersion: "apps/v1", Kinds: []string{"Deployment"}, Namespaces: []string{"App_NS"}},
+	}
+
+	for _, item := range invalid {
+		g.Expect(ValidateAllowDenyLists(appv1.SubscriptionSpec{Allow: []*appv1.AllowDenyItem{item}})).NotTo(Succeed(),
+			"allow %v", *item)
+		g.Expect(ValidateAllowDenyLists(appv1.SubscriptionSpec{Deny: []*appv1.AllowDenyItem{item}})).NotTo(Succeed(),
+			"deny %v", *item)
+	}
+}
+
 func TestCompareManifestWork(t *testing.T) {
 	g := NewGomegaWithT(t)
 
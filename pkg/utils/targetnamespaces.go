@@ -0,0 +1,114 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+// ValidateTargetNamespaces returns the target namespaces of the subscription its creator is allowed to create
+// subscriptions in, with a SubjectAccessReview per namespace. Deploying to a namespace this way grants no more than a
+// subscription created in that namespace. The error lists the denied namespaces.
+func ValidateTargetNamespaces(ctx context.Context, clt client.Client, sub *appv1.Subscription) ([]string, error) {
+	if len(sub.Spec.TargetNamespaces) == 0 {
+		return nil, nil
+	}
+
+	annotations := sub.GetAnnotations()
+
+	user := Base64StringDecode(strings.Trim(annotations[appv1.AnnotationUserIdentity], ""))
+	if user == "" {
+		return nil, fmt.Errorf("the subscription %v/%v has no creator identity to check the access to its target namespaces",
+			sub.Namespace, sub.Name)
+	}
+
+	// the identity annotations set by the users while the webhook was not available can't be trusted
+	if !IsUserIdentitySigned(sub) {
+		return nil, fmt.Errorf("the creator identity of the subscription %v/%v was not set by the subscription mutating "+
+			"webhook, its target namespaces are not allowed", sub.Namespace, sub.Name)
+	}
+
+	groups := []string{}
+
+	if userGroups := Base64StringDecode(strings.Trim(annotations[appv1.AnnotationUserGroup], "")); userGroups != "" {
+		groups = strings.Split(userGroups, ",")
+	}
+
+	allowed := []string{}
+	denied := []string{}
+
+	for _, namespace := range sub.Spec.TargetNamespaces {
+		if namespace == "" || namespace == sub.Namespace {
+			continue
+		}
+
+		sar := &authv1.SubjectAccessReview{
+			Spec: authv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &authv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      "create",
+					Group:     appv1.SchemeGroupVersion.Group,
+					Resource:  "subscriptions",
+				},
+				User:   user,
+				Groups: groups,
+			},
+		}
+
+		if err := clt.Create(ctx, sar); err != nil {
+			return allowed, fmt.Errorf("failed to check the access of user %v to the target namespace %v, err: %w",
+				user, namespace, err)
+		}
+
+		if !sar.Status.Allowed {
+			denied = append(denied, namespace)
+
+			continue
+		}
+
+		allowed = append(allowed, namespace)
+	}
+
+	if len(denied) > 0 {
+		return allowed, fmt.Errorf("user %v is not allowed to create subscriptions in the target namespaces %v of the "+
+			"subscription %v/%v", user, strings.Join(denied, ","), sub.Namespace, sub.Name)
+	}
+
+	return allowed, nil
+}
+
+// GetRestrictedResourceNamespace returns the namespace a namespaced resource of a subscription that is not a cluster
+// admin is deployed to: its namespace if it is a validated target namespace of the subscription, or the subscription
+// namespace
+func GetRestrictedResourceNamespace(sub *appv1.Subscription, namespace string) string {
+	if namespace == "" || namespace == sub.Namespace {
+		return sub.Namespace
+	}
+
+	for _, target := range strings.Split(sub.GetAnnotations()[appv1.AnnotationTargetNamespaces], ",") {
+		if strings.TrimSpace(target) == namespace {
+			return namespace
+		}
+	}
+
+	return sub.Namespace
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/onsi/gomega"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestValidateTargetNamespaces(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+
+	SetUserIdentityKey([]byte("identity-key"))
+	defer SetUserIdentityKey(nil)
+
+	reviews := []authv1.SubjectAccessReviewSpec{}
+	clt := fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, clt client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			sar, ok := obj.(*authv1.SubjectAccessReview)
+			if !ok {
+				return clt.Create(ctx, obj, opts...)
+			}
+
+			reviews = append(reviews, sar.Spec)
+			sar.Status.Allowed = sar.Spec.ResourceAttributes.Namespace != "kube-system"
+
+			return nil
+		},
+	}).Build()
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "app"},
+		Spec:       appv1.SubscriptionSpec{TargetNamespaces: []string{"payments", "payments-db", "kube-system"}},
+	}
+
+	// the subscriptions without creator identity have no target namespace
+	_, err := ValidateTargetNamespaces(context.TODO(), clt, sub)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(reviews).To(gomega.BeEmpty())
+
+	sub.SetAnnotations(map[string]string{
+		appv1.AnnotationUserIdentity: base64.StdEncoding.EncodeToString([]byte("alice")),
+		appv1.AnnotationUserGroup:    base64.StdEncoding.EncodeToString([]byte("payments-team")),
+	})
+
+	// a forged creator identity, not signed by the mutating webhook, is not trusted
+	_, err = ValidateTargetNamespaces(context.TODO(), clt, sub)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("mutating webhook"))
+	g.Expect(reviews).To(gomega.BeEmpty())
+
+	sub.GetAnnotations()[appv1.AnnotationUserIdentitySignature] = "forged"
+
+	_, err = ValidateTargetNamespaces(context.TODO(), clt, sub)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(reviews).To(gomega.BeEmpty())
+
+	SignUserIdentity(sub)
+
+	allowed, err := ValidateTargetNamespaces(context.TODO(), clt, sub)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("kube-system"))
+	g.Expect(allowed).To(gomega.Equal([]string{"payments-db"}))
+
+	// the subscription namespace is not checked
+	g.Expect(reviews).To(gomega.HaveLen(2))
+	g.Expect(*reviews[0].ResourceAttributes).To(gomega.Equal(authv1.ResourceAttributes{
+		Namespace: "payments-db", Verb: "create", Group: "apps.open-cluster-management.io", Resource: "subscriptions",
+	}))
+	g.Expect(reviews[0].User).To(gomega.Equal("alice"))
+	g.Expect(reviews[0].Groups).To(gomega.Equal([]string{"payments-team"}))
+}
+
+func TestGetRestrictedResourceNamespace(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "app"}}
+
+	g.Expect(GetRestrictedResourceNamespace(sub, "")).To(gomega.Equal("payments"))
+	g.Expect(GetRestrictedResourceNamespace(sub, "payments-db")).To(gomega.Equal("payments"))
+
+	sub.SetAnnotations(map[string]string{appv1.AnnotationTargetNamespaces: "payments-db,payments-cache"})
+
+	g.Expect(GetRestrictedResourceNamespace(sub, "payments-db")).To(gomega.Equal("payments-db"))
+	g.Expect(GetRestrictedResourceNamespace(sub, "payments-cache")).To(gomega.Equal("payments-cache"))
+	g.Expect(GetRestrictedResourceNamespace(sub, "kube-system")).To(gomega.Equal("payments"))
+}
@@ -15,25 +15,38 @@
 package utils
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 	"time"
 
 	"k8s.io/klog"
 
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 )
 
 const (
 	//MIDNIGHT define the midnight format
-	MIDNIGHT        = "12:00AM"
-	originYear      = 0
-	originMonth     = 1
-	originDay       = 1
-	debuglevel      = klog.Level(5)
-	totaldaysofweek = 7
+	MIDNIGHT   = "12:00AM"
+	debuglevel = klog.Level(5)
+	// number of days after the current day the daily windows are computed for, a week plus the day
+	// a window spanning midnight ends on
+	dailyWindowHorizonDays = 8
+	// number of days after the current time the union of the overlapping cron windows is computed for
+	maxCronWindowUnionDays = 1
 )
 
+var weekdayNames = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
 // IsInWindow returns true if the give time is within a timewindow
 func IsInWindow(tw *appv1alpha1.TimeWindow, t time.Time) bool {
 	if tw == nil {
@@ -51,26 +64,32 @@ func NextStatusReconcile(tw *appv1alpha1.TimeWindow, t time.Time) time.Duration
 
 	uniCurTime := UnifyTimeZone(tw, t)
 
+	if wins := parseCronWindows(tw); len(wins) > 0 {
+		// the status changes at the end of the current cron window or at the start of the next one
+		if end, in := cronWindowEnd(wins, uniCurTime); in {
+			return end.Sub(uniCurTime) + 1*time.Minute
+		}
+
+		return nextCronWindowStart(wins, uniCurTime) + 1*time.Minute
+	}
+
 	if len(tw.Daysofweek) == 0 && len(tw.Hours) == 0 {
 		return time.Duration(0)
 	}
 
-	if IsInWindow(tw, uniCurTime) {
-		vHr := validateHourRange(tw.Hours, getLoc(tw.Location))
-		vdays, _ := validateDaysofweekSlice(tw.Daysofweek)
-		rvevHr := reverseRange(vHr, getLoc(tw.Location))
+	// the status changes at the end of the current daily window or at the start of the next one
+	dw := parseDailyWindow(tw, uniCurTime)
 
-		// If currently not blocked but the time window type is `blocked`, we need to get the next time
-		// it should be blocked.
-		if tw.WindowType != "" && (strings.EqualFold(tw.WindowType, "block") || strings.EqualFold(tw.WindowType, "blocked")) {
-			return generateNextPoint(vHr, vdays, uniCurTime, false) + 1*time.Minute
+	if slot, in := dw.slotAt(uniCurTime, !isBlockedWindowType(tw)); in {
+		// re-evaluate the status right after the window starts
+		if uniCurTime.Equal(slot.start) {
+			return 1 * time.Minute
 		}
 
-		// The window type is active so we need to get the next time it should be blocked.
-		return generateNextPoint(rvevHr, vdays, uniCurTime, false) + 1*time.Minute
+		return slot.end.Sub(uniCurTime) + 1*time.Minute
 	}
 
-	return NextStartPoint(tw, uniCurTime) + 1*time.Minute
+	return dw.nextStart(uniCurTime) + 1*time.Minute
 }
 
 // NextStartPoint will map the container's time to the location time specified by user
@@ -88,21 +107,44 @@ func NextStartPoint(tw *appv1alpha1.TimeWindow, t time.Time) time.Duration {
 
 	klog.V(debuglevel).Infof("Time window checking at %v", uniCurTime.String())
 
-	// valid hour ranges, meaning the each hour range's start time is earlier than the end time
-	// also there's no overlap between 2 ranges
-	vHr := validateHourRange(tw.Hours, getLoc(tw.Location))
+	if wins := parseCronWindows(tw); len(wins) > 0 {
+		end, in := cronWindowEnd(wins, uniCurTime)
+
+		if isBlockedWindowType(tw) {
+			if in {
+				return end.Sub(uniCurTime)
+			}
+
+			return time.Duration(0)
+		}
+
+		if in {
+			return time.Duration(0)
+		}
+
+		return nextCronWindowStart(wins, uniCurTime)
+	}
+
+	if len(tw.Daysofweek) == 0 && len(tw.Hours) == 0 {
+		return time.Duration(0)
+	}
+
+	dw := parseDailyWindow(tw, uniCurTime)
 
-	rDays, rveDays := validateDaysofweekSlice(tw.Daysofweek)
+	// the slots of a blocked window are the blocked times, the subscription is blocked until the end of the slot
+	if isBlockedWindowType(tw) {
+		if slot, in := dw.slotAt(uniCurTime, false); in {
+			return slot.end.Sub(uniCurTime)
+		}
 
-	if tw.WindowType != "" && (strings.EqualFold(tw.WindowType, "block") || strings.EqualFold(tw.WindowType, "blocked")) {
-		// reverse slots, the time slots are applicable only for blocked days of the week.
-		// If today is not one of the days specified, just return 0
-		rvevHr := reverseRange(vHr, getLoc(tw.Location))
-		return generateNextPoint(rvevHr, rveDays, uniCurTime, true)
+		return time.Duration(0)
 	}
 
-	// generate the duration for t
-	return generateNextPoint(vHr, rDays, uniCurTime, false)
+	if _, in := dw.slotAt(uniCurTime, true); in {
+		return time.Duration(0)
+	}
+
+	return dw.nextStart(uniCurTime)
 }
 
 // UnifyTimeZone convert a given time to the timewindow time zone, if the time window doesn't sepcifiy a
@@ -112,366 +154,362 @@ func UnifyTimeZone(tw *appv1alpha1.TimeWindow, t time.Time) time.Time {
 	return t.In(lptr)
 }
 
-func getLoc(loc string) *time.Location {
-	l, err := time.LoadLocation(loc)
-	if err != nil {
-		klog.Errorf("Error %v while parsing the location string \"%v\", will UTC as location reference %v ", err, loc, l)
-	}
-
-	return l
+func isBlockedWindowType(tw *appv1alpha1.TimeWindow) bool {
+	return tw.WindowType != "" && (strings.EqualFold(tw.WindowType, "block") || strings.EqualFold(tw.WindowType, "blocked"))
 }
 
-type hourRangesInTime struct {
-	start time.Time
-	end   time.Time
+type cronWindow struct {
+	schedule *CronSchedule
+	duration time.Duration
 }
 
-// return will be sorted and marged hour ranges
-func validateHourRange(rg []appv1alpha1.HourRange, loc *time.Location) []hourRangesInTime {
-	if len(rg) == 0 {
-		return []hourRangesInTime{}
-	}
+// parseCronWindows returns the valid cron windows of the time window, the invalid ones are logged and ignored
+func parseCronWindows(tw *appv1alpha1.TimeWindow) []cronWindow {
+	wins := []cronWindow{}
 
-	h := make([]hourRangesInTime, 0)
+	for _, s := range tw.Schedules {
+		sched, err := ParseCronSchedule(s.Schedule)
+		if err != nil {
+			klog.Errorf("Ignoring the time window schedule, err: %v", err)
 
-	for _, r := range rg {
-		s, e := parseTimeWithKitchenFormat(r.Start, loc), parseTimeWithKitchenFormat(r.End, loc)
-		klog.V(debuglevel).Infof("start time paresed as %v, end time %v", s.Format(time.Kitchen), e.Format(time.Kitchen))
+			continue
+		}
+
+		if s.Duration.Duration <= 0 {
+			klog.Errorf("Ignoring the time window schedule %q with the duration %v", s.Schedule, s.Duration.Duration)
 
-		// Support for specifying range in backwards order makes it difficult to determine is 12:00AM
-		// is the start time or the end time. For example, time slot: [12:00PM, 12:00AM] is currently restructured as
-		// slot for the morning half of the day, instead of the intention, which is the afternoon to midnight.
-		// Need special consideration for 12:00AM - if specified as the end time, it is the end of the day
-		if s.Before(e) || isMidnight(e) {
-			h = append(h, hourRangesInTime{start: s, end: e})
-		} else {
-			h = append(h, hourRangesInTime{start: e, end: s})
+			continue
 		}
+
+		wins = append(wins, cronWindow{schedule: sched, duration: s.Duration.Duration})
 	}
 
-	return mergeHourRanges(h)
+	return wins
 }
 
-func validateDaysofweekSlice(wds []string) (runDays, runDays) {
-	if len(wds) == 0 {
-		return runDays{}, runDays{}
-	}
+// cronWindowEnd returns true and the end of the cron windows union if t is within a cron window
+func cronWindowEnd(wins []cronWindow, t time.Time) (time.Time, bool) {
+	var end time.Time
 
-	vwds := runDays{}
+	for _, w := range wins {
+		// the window started by the first activation after t - duration contains t if it starts before t
+		start := w.schedule.Next(t.Add(-w.duration).Add(time.Nanosecond))
+		if start.IsZero() || start.After(t) {
+			continue
+		}
 
-	weekdayMap := map[string]int{
-		"sunday":    0,
-		"monday":    1,
-		"tuesday":   2,
-		"wednesday": 3,
-		"thursday":  4,
-		"friday":    5,
-		"saturday":  6,
+		if e := start.Add(w.duration); e.After(end) {
+			end = e
+		}
 	}
 
-	found := make(map[string]bool)
+	if end.IsZero() {
+		return end, false
+	}
 
-	for _, wd := range wds {
-		wd = strings.ToLower(wd)
+	// extend the end with the windows overlapping it. The union of windows overlapping each other, such as a window
+	// longer than its schedule period, never ends, it is bounded by a short horizon and evaluated again at its end.
+	horizon := t.AddDate(0, 0, maxCronWindowUnionDays)
+
+	for extended := true; extended && end.Before(horizon); {
+		extended = false
+
+		for _, w := range wins {
+			start := w.schedule.Next(end.Add(-w.duration).Add(time.Nanosecond))
+			if start.IsZero() || start.After(end) {
+				continue
+			}
 
-		if v, ok := weekdayMap[wd]; ok {
-			if _, ok := found[wd]; !ok {
-				vwds = append(vwds, time.Weekday(v))
-				found[wd] = true
+			if e := start.Add(w.duration); e.After(end) {
+				end = e
+				extended = true
 			}
 		}
 	}
 
-	rwds := make(runDays, 0)
+	return end, true
+}
+
+// nextCronWindowStart returns the duration till the start of the next cron window
+func nextCronWindowStart(wins []cronWindow, t time.Time) time.Duration {
+	next := time.Duration(maxCronSearchDays) * 24 * time.Hour
 
-	for k, v := range weekdayMap {
-		if _, ok := found[k]; !ok {
-			rwds = append(rwds, time.Weekday(v))
+	for _, w := range wins {
+		start := w.schedule.Next(t)
+		if !start.IsZero() && start.Sub(t) < next {
+			next = start.Sub(t)
 		}
 	}
 
-	return vwds, rwds
+	return next
 }
 
-func parseTimeWithKitchenFormat(tstr string, loc *time.Location) time.Time {
-	t, err := time.ParseInLocation(time.Kitchen, tstr, loc)
+func getLoc(loc string) *time.Location {
+	l, err := time.LoadLocation(loc)
 	if err != nil {
-		curTime := time.Now().UTC()
-		klog.Errorf("can't paser the time %v, err: %v, will use the current time %v instead", tstr, err, curTime.String())
-
-		return curTime
+		klog.Errorf("Error %v while parsing the location string \"%v\", will UTC as location reference %v ", err, loc, l)
 	}
 
-	return t
+	return l
 }
 
-// next time will be:
-// if current time is bigger than the last time point of the window, nextTime will be daysofweek offset + the hour offset
-// if current time is smaller than the lastSlot time point, nextTime will be a duration till next time
-// slot start point or a 0(if current time is within a time window)
-func generateNextPoint(slots []hourRangesInTime, rdays runDays, uniCurTime time.Time, blocked bool) time.Duration {
-	if len(slots) == 0 && len(rdays) == 0 {
-		return time.Duration(0)
+// clockRange is an hour range of the time window, in minutes since midnight
+type clockRange struct {
+	start int
+	end   int
+}
+
+// parseClockTime parses a time of the day in the kitchen format, for example 10:30PM, to minutes since midnight
+func parseClockTime(tstr string) (int, error) {
+	t, err := time.Parse(time.Kitchen, strings.TrimSpace(tstr))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected the kitchen format, for example 10:30PM", tstr)
 	}
 
-	if blocked {
-		// rdays is the list of week days that are not blocked. If today is one of these days, just return 0
-		// to indicate that it is in active window.
-		for _, runDay := range rdays {
-			if runDay == uniCurTime.Weekday() {
-				return time.Duration(0)
-			}
-		}
+	return t.Hour()*60 + t.Minute(), nil
+}
 
-		// current day is blocked, check with current day time slots
-		if len(slots) != 0 {
-			cdTime := tillNextSlotInCurrentDay(slots, uniCurTime)
-			if cdTime >= 0 {
-				return cdTime
-			}
-		}
+// parseHourRanges returns the valid hour ranges of the time window, the invalid ones are logged and ignored
+func parseHourRanges(hours []appv1alpha1.HourRange) []clockRange {
+	ranges := []clockRange{}
 
-		// Current day is blocked and no active slots today - calculate time to active slot in future day
-		nextActiveTime := timeLeftTillNextMidNight(uniCurTime)
+	for _, hr := range hours {
+		start, err := parseClockTime(hr.Start)
+		if err != nil {
+			klog.Errorf("Ignoring the time window hour range, err: %v", err)
 
-		if len(slots) != 0 {
-			// only need to check next day - it will either be an active day or have an active time slot
-			nextDay := uniCurTime.Add(time.Hour * 24)
-			if !rdays.isCurDayInDaysOfWeek(nextDay.Weekday()) {
-				nextActiveTime += tillNextSlotFromMidnight(slots, uniCurTime)
-			}
-		} else {
-			nextActiveTime += rdays.durationToNextRunableWeekday(slots, uniCurTime)
+			continue
 		}
 
-		return nextActiveTime
-	}
+		end, err := parseClockTime(hr.End)
+		if err != nil {
+			klog.Errorf("Ignoring the time window hour range, err: %v", err)
 
-	// handle active scheduled time
-	if len(slots) == 0 && len(rdays) != 0 {
-		if rdays.isCurDayInDaysOfWeek(uniCurTime.Weekday()) {
-			klog.Infof("Today is in valid Daysofweek time window. Today: %v, valid Daysofweek: %v\n", uniCurTime.Weekday(), rdays)
-			return time.Duration(0)
+			continue
 		}
 
-		return timeLeftTillNextMidNight(uniCurTime) + rdays.durationToNextRunableWeekday(slots, uniCurTime)
+		ranges = append(ranges, clockRange{start: start, end: end})
 	}
 
-	if len(slots) != 0 && len(rdays) == 0 {
-		return tillNextSlot(slots, uniCurTime)
-	}
+	return ranges
+}
+
+// parseDaysOfWeek returns the valid days of the week of the time window, all the days if there is no valid day
+func parseDaysOfWeek(days []string) map[time.Weekday]bool {
+	wds := map[time.Weekday]bool{}
 
-	if !rdays.isCurDayInDaysOfWeek(uniCurTime.Weekday()) {
-		// the current day is not suppose to be active so find the next available time
-		return timeLeftTillNextMidNight(uniCurTime) + tillNextSlotFromMidnight(slots, uniCurTime) + rdays.durationToNextRunableWeekday(slots, uniCurTime)
+	for _, d := range days {
+		if wd, ok := weekdayNames[strings.ToLower(strings.TrimSpace(d))]; ok {
+			wds[wd] = true
+		}
 	}
 
-	if rdays.durationToNextRunableWeekday(slots, uniCurTime) == 0 {
-		// There is another active window today
-		ns := tillNextSlotInCurrentDay(slots, uniCurTime)
-		if ns >= 0 {
-			return ns
+	if len(wds) == 0 {
+		for _, wd := range weekdayNames {
+			wds[wd] = true
 		}
 	}
 
-	return timeLeftTillNextMidNight(uniCurTime) + tillNextSlotFromMidnight(slots, uniCurTime) + rdays.durationToNextRunableWeekday(slots, uniCurTime)
+	return wds
 }
 
-func timeLeftTillNextMidNight(cur time.Time) time.Duration {
-	lastMidnight := parseTimeWithKitchenFormat(MIDNIGHT, cur.Location())
-	nextMidngiht := lastMidnight.Add(time.Hour * 24)
+// wallClock returns the time of the day in the location of day. A time skipped by a DST transition is moved to
+// the transition, a time repeated by a DST transition is its first occurrence.
+func wallClock(day time.Time, minutes int) time.Time {
+	h, m := minutes/60, minutes%60
 
-	gt := time.Date(originYear, originMonth, originDay, cur.Hour(), cur.Minute(), 0, 0, cur.Location())
+	t := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
 
-	return nextMidngiht.Sub(gt)
-}
+	if t.Hour() != h || t.Minute() != m {
+		if _, end := t.ZoneBounds(); !end.IsZero() && t.Before(end) {
+			return end
+		}
+	}
 
-func tillNextSlotFromMidnight(slots []hourRangesInTime, cur time.Time) time.Duration {
-	lastMidnight := parseTimeWithKitchenFormat(MIDNIGHT, cur.Location())
-	return tillNextSlot(slots, lastMidnight)
+	return t
 }
 
-func tillNextSlot(slots []hourRangesInTime, cur time.Time) time.Duration {
-	lastMidnight := parseTimeWithKitchenFormat(MIDNIGHT, cur.Location())
-	gt := time.Date(originYear, originMonth, originDay, cur.Hour(), cur.Minute(), 0, 0, cur.Location())
-	// t is greater than todays window
-	// eg t is 11pm
-	// slots [1, 3 pm]
-	lastSlot := slots[len(slots)-1].end
+// windowSlot is an occurrence of the daily window, in absolute time. The end time is part of the slot unless the
+// slot ends at midnight, the midnight time belongs to the next day
+type windowSlot struct {
+	start        time.Time
+	end          time.Time
+	endInclusive bool
+}
 
-	if lastSlot.Before(gt) {
-		nxtStart := slots[0].start
-		// Hour difference is from curret day to midnight, then midnight to slot[0]
-		// Current time to the next day's midnight
-		return timeLeftTillNextMidNight(gt) + nxtStart.Sub(lastMidnight)
-	}
+// dailyWindow is the sorted and merged occurrences of the daysofweek and hours window, from the day before the
+// current day until the horizon
+type dailyWindow struct {
+	slots   []windowSlot
+	horizon time.Time
+}
 
-	for _, slot := range slots {
-		st, ed := slot.start, slot.end
+// parseDailyWindow computes the occurrences of the daysofweek and hours window around t. Each hour range occurs on
+// the days of the week, a range ending before its start time spans midnight and ends on the next day. All the
+// times are wall clock times in the time window location, so the slots keep their wall clock times on the DST
+// transition days.
+func parseDailyWindow(tw *appv1alpha1.TimeWindow, t time.Time) dailyWindow {
+	days := parseDaysOfWeek(tw.Daysofweek)
 
-		if gt.Sub(st) <= 0 {
-			return st.Sub(gt)
-		} else if gt.Sub(st) > 0 && gt.Sub(ed) <= 0 {
-			return time.Duration(0)
-		}
+	ranges := parseHourRanges(tw.Hours)
+	if len(ranges) == 0 {
+		ranges = []clockRange{{start: 0, end: 0}}
 	}
 
-	return time.Duration(0)
-}
-
-func tillNextSlotInCurrentDay(slots []hourRangesInTime, cur time.Time) time.Duration {
-	gt := time.Date(originYear, originMonth, originDay, cur.Hour(), cur.Minute(), 0, 0, cur.Location())
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 
-	for _, slot := range slots {
-		klog.Infof("Time slot %v:%v - %v:%v", slot.start.Hour(), slot.start.Minute(), slot.end.Hour(), slot.end.Minute())
+	slots := []windowSlot{}
 
-		// overlapping time slots are merged and sorted
-		if gt.Sub(slot.start) > 0 && (gt.Sub(slot.end) <= 0 || isMidnight(slot.end)) {
-			// not blocked now
-			return time.Duration(0)
-		} else if gt.Sub(slot.start) <= 0 {
-			// there is available time slot later in current day
-			return slot.start.Sub(gt)
+	// a range starting the day before may span midnight
+	for i := -1; i <= dailyWindowHorizonDays; i++ {
+		day := today.AddDate(0, 0, i)
+		if !days[day.Weekday()] {
+			continue
 		}
-	}
 
-	// blocked now and no time slots available later in current day
-	return time.Duration(-1)
-}
-
-func isMidnight(t time.Time) bool {
-	b := t.Format(time.Kitchen) == MIDNIGHT
-
-	return b
-}
+		for _, r := range ranges {
+			end := wallClock(day, r.end)
+			if r.end <= r.start {
+				end = wallClock(day.AddDate(0, 0, 1), r.end)
+			}
 
-func maxHour(a, b time.Time) time.Time {
-	if a.Before(b) {
-		return b
+			slots = append(slots, windowSlot{start: wallClock(day, r.start), end: end, endInclusive: r.end != 0})
+		}
 	}
 
-	return a
+	return dailyWindow{slots: mergeWindowSlots(slots), horizon: today.AddDate(0, 0, dailyWindowHorizonDays+1)}
 }
 
-func mergeHourRanges(in []hourRangesInTime) []hourRangesInTime {
-	if len(in) < 2 {
-		return in
+func mergeWindowSlots(slots []windowSlot) []windowSlot {
+	if len(slots) < 2 {
+		return slots
 	}
 
-	sort.Slice(in, func(i int, j int) bool { return in[i].start.Before(in[j].start) })
+	sort.Slice(slots, func(i, j int) bool { return slots[i].start.Before(slots[j].start) })
 
-	out := make([]hourRangesInTime, 0)
+	out := []windowSlot{slots[0]}
 
-	if len(in) > 0 {
-		out = append(out, in[0]) //nolint
-	}
+	for _, s := range slots[1:] {
+		last := &out[len(out)-1]
 
-	l := 0
+		if s.start.After(last.end) {
+			out = append(out, s)
 
-	for i := 1; i < len(in); i++ {
-		ed := out[l].end
-		s := in[i].start
+			continue
+		}
 
-		if s.Before(ed) || s.Equal(ed) {
-			m := maxHour(ed, in[i].end)
-			out[l].end = m
-		} else {
-			out = append(out, in[i])
-			l++
+		switch {
+		case s.end.After(last.end):
+			last.end, last.endInclusive = s.end, s.endInclusive
+		case s.end.Equal(last.end):
+			last.endInclusive = last.endInclusive || s.endInclusive
 		}
 	}
 
 	return out
 }
 
-func isThereGap(st, ed time.Time) bool {
-	return ed.Sub(st) != 0
-}
+// slotAt returns the slot t is in. The slot bounds are included if closed is true, so an active window includes
+// its start and end times, while a blocked window doesn't block at its start and end times.
+func (dw dailyWindow) slotAt(t time.Time, closed bool) (windowSlot, bool) {
+	for _, s := range dw.slots {
+		if closed && !t.Before(s.start) && (t.Before(s.end) || (s.endInclusive && t.Equal(s.end))) {
+			return s, true
+		}
 
-func reverseRange(in []hourRangesInTime, loc *time.Location) []hourRangesInTime {
-	if len(in) == 0 {
-		return in
+		if !closed && t.After(s.start) && t.Before(s.end) {
+			return s, true
+		}
 	}
 
-	out := make([]hourRangesInTime, 0)
+	return windowSlot{}, false
+}
 
-	lastMidnight := parseTimeWithKitchenFormat(MIDNIGHT, loc)
+// nextStart returns the duration till the start of the next slot, or till the horizon if there is no slot
+func (dw dailyWindow) nextStart(t time.Time) time.Duration {
+	for _, s := range dw.slots {
+		if !s.start.Before(t) {
+			return s.start.Sub(t)
+		}
+	}
 
-	sp := lastMidnight
+	return dw.horizon.Sub(t)
+}
 
-	for _, slot := range in {
-		if isThereGap(sp, slot.start) {
-			out = append(out, hourRangesInTime{start: sp, end: slot.start})
-		}
+// ValidateTimeWindow returns an error if the time window has an unknown location, day of the week, hour or
+// cron schedule
+func ValidateTimeWindow(tw *appv1alpha1.TimeWindow) error {
+	if tw == nil {
+		return nil
+	}
 
-		sp = slot.end
+	if _, err := time.LoadLocation(tw.Location); err != nil {
+		return fmt.Errorf("invalid time window location %q, expected a TZ database name, for example America/Toronto", tw.Location)
 	}
 
-	if isMidnight(sp) { // Handle end time being midnight
-		sp = sp.Add(time.Hour * 24)
+	for _, d := range tw.Daysofweek {
+		if _, ok := weekdayNames[strings.ToLower(strings.TrimSpace(d))]; !ok {
+			return fmt.Errorf("invalid time window day of the week %q", d)
+		}
 	}
 
-	nextMidngith := lastMidnight.Add(time.Hour * 24)
+	for _, hr := range tw.Hours {
+		if _, err := parseClockTime(hr.Start); err != nil {
+			return fmt.Errorf("invalid time window hour range start: %w", err)
+		}
 
-	if isThereGap(sp, nextMidngith) {
-		out = append(out, hourRangesInTime{start: sp, end: nextMidngith})
+		if _, err := parseClockTime(hr.End); err != nil {
+			return fmt.Errorf("invalid time window hour range end: %w", err)
+		}
 	}
 
-	return out
-}
+	for _, s := range tw.Schedules {
+		if _, err := ParseCronSchedule(s.Schedule); err != nil {
+			return fmt.Errorf("invalid time window schedule: %w", err)
+		}
 
-// thie field is actually int
-type runDays []time.Weekday
+		if s.Duration.Duration <= 0 {
+			return fmt.Errorf("invalid time window schedule %q: the duration must be positive", s.Schedule)
+		}
+	}
 
-// type runDays []time.Weekday
-func (r runDays) durationToNextRunableWeekday(in []hourRangesInTime, cur time.Time) time.Duration {
-	// if daysofweek is sorted, we want the next day with is greater than the t.Weekday
-	// the daysofweek is loop such as [3, 4, 5], if t==6, we should return 3 aka 4 days
-	// if t == 2 then we should return 1
-	curWeekday := cur.Weekday()
+	return nil
+}
 
-	if len(r) == 0 {
-		// this mean you will wait for less than a day.
-		return time.Duration(0)
+// GetClusterTimezone returns the time zone of the managed cluster, from the timezone.open-cluster-management.io
+// annotation first, then from the cluster claim of the same name. An empty string is returned if the managed cluster
+// has no valid time zone
+func GetClusterTimezone(cluster *spokeClusterV1.ManagedCluster) string {
+	if cluster == nil {
+		return ""
 	}
 
-	sort.Slice(r, func(i, j int) bool { return r[i] < r[j] })
-
-	var days int
+	candidates := []string{}
 
-	//if the current weekday is later than the latest weekday in the time window,
-	//then we find the days left till first runable weekday
-	if curWeekday > r[len(r)-1] {
-		daysLeftOfThisWeek := totaldaysofweek - int(curWeekday)
-		mostRecentWeekday := int(r[0])
-		days = daysLeftOfThisWeek + mostRecentWeekday
-	} else {
-		for _, d := range r {
-			// need to take active time slots into consideration
-			// if past all active time slots today then use the next runnable day
-			if curWeekday == d && tillNextSlotInCurrentDay(in, cur) >= 0 {
-				return time.Duration(0)
-			}
+	if tz, ok := cluster.GetAnnotations()[appv1alpha1.AnnotationClusterTimezone]; ok {
+		candidates = append(candidates, tz)
+	}
 
-			if curWeekday < d {
-				days = int(d - curWeekday)
-				break
-			} else {
-				// Same day - past last available time slot, handle wrapping of weekdays
-				days = int(d-curWeekday) + 7
-			}
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == appv1alpha1.ClusterClaimTimezone {
+			candidates = append(candidates, claim.Value)
 		}
 	}
 
-	return time.Duration(days-1) * time.Hour * 24
-}
+	for _, tz := range candidates {
+		tz = strings.TrimSpace(tz)
+		if tz == "" {
+			continue
+		}
+
+		if _, err := time.LoadLocation(tz); err != nil {
+			klog.Warningf("Invalid time zone %q of managed cluster %v, err: %v", tz, cluster.GetName(), err)
 
-func (r runDays) isCurDayInDaysOfWeek(curWeekday time.Weekday) bool {
-	for _, d := range r {
-		if curWeekday == d {
-			return true
+			continue
 		}
+
+		return tz
 	}
 
-	return false
+	return ""
 }
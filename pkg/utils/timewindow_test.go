@@ -15,10 +15,12 @@
 package utils
 
 import (
-	"reflect"
 	"testing"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	spokeClusterV1 "open-cluster-management.io/api/cluster/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
 )
 
@@ -343,220 +345,6 @@ func TestTimeWindowDurationTillNextWindow2021(t *testing.T) {
 	}
 }
 
-func TestValidateHours(t *testing.T) {
-	f := func(ts, l string) time.Time {
-		t, _ := time.ParseInLocation(time.Kitchen, ts, getLoc(l))
-		return t
-	}
-
-	loc := "America/Toronto"
-
-	testCases := []struct {
-		desc     string
-		rg       []appv1alpha1.HourRange
-		location string
-		wanted   []hourRangesInTime
-	}{
-		{
-			desc: "time range without location",
-			rg: []appv1alpha1.HourRange{
-				{Start: "10:30AM", End: "11:30AM"},
-				{Start: "12:30PM", End: "1:30PM"},
-			},
-			wanted: []hourRangesInTime{
-				{start: f("10:30AM", ""), end: f("11:30AM", "")},
-				{start: f("12:30PM", ""), end: f("1:30PM", "")},
-			},
-		},
-		{
-			desc: "time overlapped with location",
-			rg: []appv1alpha1.HourRange{
-				{Start: "10:30AM", End: "11:30AM"},
-				{Start: "11:10AM", End: "1:30PM"},
-			},
-			location: loc,
-			wanted: []hourRangesInTime{
-				{start: f("10:30AM", loc), end: f("1:30PM", loc)},
-			},
-		},
-		{
-			desc: "time overlapped with location",
-			rg: []appv1alpha1.HourRange{
-				{Start: "10:30AM", End: "11:30AM"},
-				{Start: "11:30AM", End: "1:30PM"},
-			},
-			location: loc,
-
-			wanted: []hourRangesInTime{
-				{start: f("10:30AM", loc), end: f("1:30PM", loc)},
-			},
-		},
-		{
-			desc: "multi-timewindow with location",
-			rg: []appv1alpha1.HourRange{
-				{Start: "10:30AM", End: "11:30AM"},
-				{Start: "11:40AM", End: "5:30PM"},
-				{Start: "12:40AM", End: "4:30PM"},
-				{Start: "6:40PM", End: "6:30PM"},
-			},
-			location: loc,
-			wanted: []hourRangesInTime{
-				{start: f("12:40AM", loc), end: f("5:30PM", loc)},
-				{start: f("6:30PM", loc), end: f("6:40PM", loc)},
-			},
-		},
-	}
-
-	for _, tC := range testCases {
-		t.Run(tC.desc, func(t *testing.T) {
-			got := validateHourRange(tC.rg, getLoc(tC.location))
-			assertHourRangesInTime(t, got, tC.wanted)
-		})
-	}
-}
-
-func assertHourRangesInTime(t *testing.T, got, wanted []hourRangesInTime) {
-	if len(got) != len(wanted) {
-		t.Fatalf("validateHourRange length is wrong, got %v, wanted %v", len(got), len(wanted))
-	}
-
-	for i := range got {
-		if got[i].start.Equal(wanted[i].start) == false {
-			t.Errorf("item idx %v got %v, wanted %v", i, got[i].start.String(), wanted[i].start.String())
-		}
-
-		if got[i].end.Equal(wanted[i].end) == false {
-			t.Errorf("item idx %v got %v, wanted %v", i, got[i].end.String(), wanted[i].end.String())
-		}
-	}
-}
-
-func TestReverseRange(t *testing.T) {
-	loc := "America/Toronto"
-	f := func(ts, l string) time.Time {
-		t, _ := time.ParseInLocation(time.Kitchen, ts, getLoc(l))
-		return t
-	}
-
-	lastMidnight := parseTimeWithKitchenFormat(MIDNIGHT, getLoc(loc))
-	nextMidngith := lastMidnight.Add(time.Hour * 24)
-
-	testCases := []struct {
-		desc     string
-		location string
-		rg       []hourRangesInTime
-		wanted   []hourRangesInTime
-	}{
-		{
-			desc:     "",
-			location: loc,
-			rg: []hourRangesInTime{
-				{start: f("10:30AM", loc), end: f("11:30AM", loc)},
-				{start: f("11:40AM", loc), end: f("4:30PM", loc)},
-			},
-			wanted: []hourRangesInTime{
-				{start: f("12:00AM", loc), end: f("10:30AM", loc)},
-				{start: f("11:30AM", loc), end: f("11:40AM", loc)},
-				{start: f("4:30PM", loc), end: nextMidngith},
-			},
-		},
-
-		{
-			desc:     "",
-			location: loc,
-			rg: []hourRangesInTime{
-				{start: f("10:30AM", loc), end: f("11:30AM", loc)},
-			},
-			wanted: []hourRangesInTime{
-				{start: f("12:00AM", loc), end: f("10:30AM", loc)},
-				{start: f("11:30AM", loc), end: nextMidngith},
-			},
-		},
-	}
-	for _, tC := range testCases {
-		t.Run(tC.desc, func(t *testing.T) {
-			got := reverseRange(tC.rg, getLoc(tC.location))
-			if !reflect.DeepEqual(got, tC.wanted) {
-				t.Errorf("got %v, want %v", got, tC.wanted)
-			}
-		})
-	}
-}
-
-func TestNextWeekdayToRun(t *testing.T) {
-	testCases := []struct {
-		desc   string
-		rd     runDays
-		t      string
-		wanted time.Duration
-	}{
-		{
-			desc: "",
-			rd:   []time.Weekday{3, 2, 1, 5},
-			// 6
-			t:      "Sat Nov  2 14:00:00 UTC 2019",
-			wanted: time.Hour * 1 * 24,
-		},
-		{
-			desc: "",
-			rd:   []time.Weekday{3, 2, 1, 5, 0},
-			// 6
-			t:      "Sun Nov  3 14:00:00 UTC 2019",
-			wanted: time.Hour * 0 * 24,
-		},
-		{
-			desc: "",
-			rd:   []time.Weekday{},
-			// 6
-			t:      "Sat Nov  2 14:00:00 UTC 2019",
-			wanted: time.Hour * 0,
-		},
-	}
-	for _, tC := range testCases {
-		t.Run(tC.desc, func(t *testing.T) {
-			tt, _ := time.Parse(time.UnixDate, tC.t)
-			got := tC.rd.durationToNextRunableWeekday(nil, tt)
-
-			if got != tC.wanted {
-				t.Errorf("wanted %v, however got %v", tC.wanted, got)
-			}
-		})
-	}
-}
-
-func TestParseTimeWithKicthenFormat(t *testing.T) {
-	testCases := []struct {
-		desc   string
-		tstr   string
-		loc    string
-		wanted string
-	}{
-		{
-			desc:   "legal format parsed to UTC",
-			tstr:   "10:30AM",
-			loc:    "America/Toronto",
-			wanted: "Sat Jan  1 10:30:00 UTC 0000",
-		},
-		{
-			desc:   "illegal format",
-			tstr:   "10:30am",
-			wanted: time.Now().UTC().Format(time.UnixDate),
-		},
-	}
-
-	for _, tC := range testCases {
-		t.Run(tC.desc, func(t *testing.T) {
-			tt, _ := time.Parse(time.UnixDate, tC.wanted)
-			pTime := parseTimeWithKitchenFormat(tC.tstr, getLoc(tC.loc))
-			p, g := pTime.Format(time.Kitchen), tt.Format(time.Kitchen)
-
-			if p != g {
-				t.Errorf("parsed time %v, wanted %v", p, g)
-			}
-		})
-	}
-}
-
 func getTime(t string) time.Time {
 	tt, _ := time.Parse(time.UnixDate, t)
 	return tt
@@ -731,3 +519,336 @@ func TestNextStatusReconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestCronTimeWindow(t *testing.T) {
+	firstSaturday := []appv1alpha1.CronWindow{
+		{Schedule: "0 2 * * 6#1", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+	}
+
+	testCases := []struct {
+		desc          string
+		curTime       string
+		windows       *appv1alpha1.TimeWindow
+		wantNextStart time.Duration
+		wantReconcile time.Duration
+	}{
+		{
+			desc:          "active, before the first Saturday window",
+			curTime:       "Sat Jun  5 01:00:00 UTC 2021",
+			windows:       &appv1alpha1.TimeWindow{WindowType: "active", Schedules: firstSaturday},
+			wantNextStart: time.Hour,
+			wantReconcile: time.Hour + time.Minute,
+		},
+		{
+			desc:          "active, in the first Saturday window",
+			curTime:       "Sat Jun  5 03:00:00 UTC 2021",
+			windows:       &appv1alpha1.TimeWindow{WindowType: "active", Schedules: firstSaturday},
+			wantNextStart: 0,
+			wantReconcile: time.Hour + time.Minute,
+		},
+		{
+			desc:          "active, after the window, the next one is next month",
+			curTime:       "Sat Jun  5 04:00:00 UTC 2021",
+			windows:       &appv1alpha1.TimeWindow{WindowType: "active", Schedules: firstSaturday},
+			wantNextStart: 27*24*time.Hour + 22*time.Hour,
+			wantReconcile: 27*24*time.Hour + 22*time.Hour + time.Minute,
+		},
+		{
+			desc:    "active, the daysofweek and hours are ignored",
+			curTime: "Sat Jun 12 03:00:00 UTC 2021",
+			windows: &appv1alpha1.TimeWindow{WindowType: "active", Schedules: firstSaturday,
+				Daysofweek: []string{"saturday"}},
+			wantNextStart: 20*24*time.Hour + 23*time.Hour,
+			wantReconcile: 20*24*time.Hour + 23*time.Hour + time.Minute,
+		},
+		{
+			desc:          "blocked, in the window",
+			curTime:       "Sat Jun  5 02:30:00 UTC 2021",
+			windows:       &appv1alpha1.TimeWindow{WindowType: "blocked", Schedules: firstSaturday},
+			wantNextStart: 90 * time.Minute,
+			wantReconcile: 91 * time.Minute,
+		},
+		{
+			desc:          "blocked, outside the window",
+			curTime:       "Sat Jun  5 01:30:00 UTC 2021",
+			windows:       &appv1alpha1.TimeWindow{WindowType: "blocked", Schedules: firstSaturday},
+			wantNextStart: 0,
+			wantReconcile: 31 * time.Minute,
+		},
+		{
+			desc:    "blocked, overlapping windows are merged",
+			curTime: "Sat Jun  5 02:30:00 UTC 2021",
+			windows: &appv1alpha1.TimeWindow{WindowType: "blocked", Schedules: []appv1alpha1.CronWindow{
+				{Schedule: "0 2 * * 6#1", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+				{Schedule: "0 3 * * 6", Duration: metav1.Duration{Duration: 3 * time.Hour}},
+			}},
+			wantNextStart: 210 * time.Minute,
+			wantReconcile: 211 * time.Minute,
+		},
+		{
+			desc:    "blocked, a window longer than its schedule period is evaluated again after a day",
+			curTime: "Sat Jun  5 02:30:00 UTC 2021",
+			windows: &appv1alpha1.TimeWindow{WindowType: "blocked", Schedules: []appv1alpha1.CronWindow{
+				{Schedule: "* * * * *", Duration: metav1.Duration{Duration: 2 * time.Minute}},
+			}},
+			wantNextStart: 24 * time.Hour,
+			wantReconcile: 24*time.Hour + time.Minute,
+		},
+		{
+			desc:    "location of the time window",
+			curTime: "Sat Jun  5 06:00:00 UTC 2021",
+			windows: &appv1alpha1.TimeWindow{WindowType: "active", Location: "America/Toronto",
+				Schedules: firstSaturday},
+			wantNextStart: 0,
+			wantReconcile: 2*time.Hour + time.Minute,
+		},
+		{
+			desc:    "invalid schedules are ignored",
+			curTime: "Sat Jun  5 06:00:00 UTC 2021",
+			windows: &appv1alpha1.TimeWindow{WindowType: "active", Schedules: []appv1alpha1.CronWindow{
+				{Schedule: "0 2 * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+				{Schedule: "0 2 * * *", Duration: metav1.Duration{}},
+			}},
+			wantNextStart: 0,
+			wantReconcile: 0,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c, _ := time.Parse(time.UnixDate, tC.curTime)
+
+			if got := NextStartPoint(tC.windows, c); got != tC.wantNextStart {
+				t.Errorf("wanted next start point %v, got %v", tC.wantNextStart, got)
+			}
+
+			if got := NextStatusReconcile(tC.windows, c); got != tC.wantReconcile {
+				t.Errorf("wanted next status reconcile %v, got %v", tC.wantReconcile, got)
+			}
+		})
+	}
+}
+
+func TestDSTTimeWindow(t *testing.T) {
+	toronto := "America/Toronto"
+
+	testCases := []struct {
+		desc       string
+		curTime    string
+		windows    *appv1alpha1.TimeWindow
+		wantStart  time.Duration
+		wantStatus time.Duration
+	}{
+		{
+			desc:    "before a window on the spring forward day",
+			curTime: "2021-03-14T05:30:00Z", // 00:30AM EST
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   toronto,
+				Hours:      []appv1alpha1.HourRange{{Start: "1:00AM", End: "4:00AM"}},
+			},
+			wantStart:  30 * time.Minute,
+			wantStatus: 31 * time.Minute,
+		},
+		{
+			desc:    "in a window shortened by the spring forward transition",
+			curTime: "2021-03-14T06:30:00Z", // 01:30AM EST, the window ends at 04:00AM EDT
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   toronto,
+				Hours:      []appv1alpha1.HourRange{{Start: "1:00AM", End: "4:00AM"}},
+			},
+			wantStart:  0,
+			wantStatus: time.Hour*1 + time.Minute*31,
+		},
+		{
+			desc:    "window starting at a time skipped by the spring forward transition",
+			curTime: "2021-03-14T06:00:00Z", // 01:00AM EST, 02:30AM doesn't exist and the window starts at 03:00AM EDT
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   toronto,
+				Hours:      []appv1alpha1.HourRange{{Start: "2:30AM", End: "3:30AM"}},
+			},
+			wantStart:  time.Hour,
+			wantStatus: time.Hour + time.Minute,
+		},
+		{
+			desc:    "window spanning midnight lengthened by the fall back transition",
+			curTime: "2021-11-07T05:30:00Z", // Sunday 01:30AM EDT, the window ends at 02:00AM EST
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   toronto,
+				Daysofweek: []string{"Saturday"},
+				Hours:      []appv1alpha1.HourRange{{Start: "10:00PM", End: "2:00AM"}},
+			},
+			wantStart:  0,
+			wantStatus: time.Hour*1 + time.Minute*31,
+		},
+		{
+			desc:    "next day of the week after the spring forward transition",
+			curTime: "2021-03-14T05:00:00Z", // Sunday 00:00AM EST, Monday starts 23 hours later
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   toronto,
+				Daysofweek: []string{"Monday"},
+			},
+			wantStart:  23 * time.Hour,
+			wantStatus: 23*time.Hour + time.Minute,
+		},
+		{
+			desc:    "blocked by a window spanning midnight",
+			curTime: "2021-06-05T03:00:00Z", // Saturday
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "blocked",
+				Location:   "UTC",
+				Daysofweek: []string{"Friday"},
+				Hours:      []appv1alpha1.HourRange{{Start: "10:00PM", End: "6:00AM"}},
+			},
+			wantStart:  3 * time.Hour,
+			wantStatus: 3*time.Hour + time.Minute,
+		},
+		{
+			desc:    "in a window spanning midnight",
+			curTime: "2021-06-05T01:00:00Z", // Saturday
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   "UTC",
+				Daysofweek: []string{"Friday"},
+				Hours:      []appv1alpha1.HourRange{{Start: "10:00PM", End: "2:00AM"}},
+			},
+			wantStart:  0,
+			wantStatus: time.Hour + time.Minute,
+		},
+		{
+			desc:    "after a window spanning midnight",
+			curTime: "2021-06-06T01:00:00Z", // Sunday
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   "UTC",
+				Daysofweek: []string{"Friday"},
+				Hours:      []appv1alpha1.HourRange{{Start: "10:00PM", End: "2:00AM"}},
+			},
+			wantStart:  141 * time.Hour,
+			wantStatus: 141*time.Hour + time.Minute,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			c, _ := time.Parse(time.RFC3339, tC.curTime)
+
+			if got := NextStartPoint(tC.windows, c); got != tC.wantStart {
+				t.Errorf("wanted next start point %v, got %v", tC.wantStart, got)
+			}
+
+			if got := NextStatusReconcile(tC.windows, c); got != tC.wantStatus {
+				t.Errorf("wanted next status reconcile %v, got %v", tC.wantStatus, got)
+			}
+		})
+	}
+}
+
+func TestValidateTimeWindow(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		windows *appv1alpha1.TimeWindow
+		wantErr bool
+	}{
+		{
+			desc: "nil time window",
+		},
+		{
+			desc: "valid time window",
+			windows: &appv1alpha1.TimeWindow{
+				WindowType: "active",
+				Location:   "America/Toronto",
+				Daysofweek: []string{"Saturday", "sunday"},
+				Hours:      []appv1alpha1.HourRange{{Start: "10:00PM", End: "2:00AM"}},
+			},
+		},
+		{
+			desc:    "unknown location",
+			windows: &appv1alpha1.TimeWindow{Location: "America/Totonto"},
+			wantErr: true,
+		},
+		{
+			desc:    "unknown day of the week",
+			windows: &appv1alpha1.TimeWindow{Daysofweek: []string{"Sat"}},
+			wantErr: true,
+		},
+		{
+			desc:    "hour not in the kitchen format",
+			windows: &appv1alpha1.TimeWindow{Hours: []appv1alpha1.HourRange{{Start: "22:00", End: "2:00AM"}}},
+			wantErr: true,
+		},
+		{
+			desc: "invalid cron schedule",
+			windows: &appv1alpha1.TimeWindow{
+				Schedules: []appv1alpha1.CronWindow{{Schedule: "0 2 * *", Duration: metav1.Duration{Duration: time.Hour}}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if err := ValidateTimeWindow(tC.windows); (err != nil) != tC.wantErr {
+				t.Errorf("wanted error %v, got %v", tC.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetClusterTimezone(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		annotations map[string]string
+		claims      []spokeClusterV1.ManagedClusterClaim
+		want        string
+	}{
+		{
+			desc: "no time zone",
+			want: "",
+		},
+		{
+			desc:        "time zone annotation",
+			annotations: map[string]string{appv1alpha1.AnnotationClusterTimezone: "Asia/Tokyo"},
+			want:        "Asia/Tokyo",
+		},
+		{
+			desc:   "time zone claim",
+			claims: []spokeClusterV1.ManagedClusterClaim{{Name: appv1alpha1.ClusterClaimTimezone, Value: "Europe/Paris"}},
+			want:   "Europe/Paris",
+		},
+		{
+			desc:        "annotation takes precedence over the claim",
+			annotations: map[string]string{appv1alpha1.AnnotationClusterTimezone: "Asia/Tokyo"},
+			claims:      []spokeClusterV1.ManagedClusterClaim{{Name: appv1alpha1.ClusterClaimTimezone, Value: "Europe/Paris"}},
+			want:        "Asia/Tokyo",
+		},
+		{
+			desc:        "invalid annotation falls back to the claim",
+			annotations: map[string]string{appv1alpha1.AnnotationClusterTimezone: "Asia/Tokio"},
+			claims:      []spokeClusterV1.ManagedClusterClaim{{Name: appv1alpha1.ClusterClaimTimezone, Value: "Europe/Paris"}},
+			want:        "Europe/Paris",
+		},
+		{
+			desc:        "invalid time zone",
+			annotations: map[string]string{appv1alpha1.AnnotationClusterTimezone: "Mars/Olympus"},
+			want:        "",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			cluster := &spokeClusterV1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Annotations: tC.annotations},
+				Status:     spokeClusterV1.ManagedClusterStatus{ClusterClaims: tC.claims},
+			}
+
+			if got := GetClusterTimezone(cluster); got != tC.want {
+				t.Errorf("wanted %q, got %q", tC.want, got)
+			}
+		})
+	}
+}
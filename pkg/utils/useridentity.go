@@ -0,0 +1,149 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+const (
+	// UserIdentityKeySecretName is the Secret holding the key the subscription mutating webhook signs the user
+	// identity of the subscriptions with
+	UserIdentityKeySecretName = "multicluster-operators-subscription-identity-key"
+	userIdentityKeySecretKey  = "key"
+	userIdentityKeySize       = 32
+)
+
+// userIdentityKey is the key of the user identity signatures, the user identity of the subscriptions is not trusted
+// without it
+var userIdentityKey []byte
+
+// SetUserIdentityKey sets the key of the user identity signatures
+func SetUserIdentityKey(key []byte) {
+	userIdentityKey = key
+}
+
+// LoadUserIdentityKey returns the key of the user identity signatures from its Secret in the namespace, the Secret is
+// created with a random key if it doesn't exist
+func LoadUserIdentityKey(ctx context.Context, clt client.Client, namespace string) ([]byte, error) {
+	return loadSigningKey(ctx, clt, types.NamespacedName{Namespace: namespace, Name: UserIdentityKeySecretName})
+}
+
+// loadSigningKey returns the signing key of the Secret, the Secret is created with a random key if it doesn't exist
+func loadSigningKey(ctx context.Context, clt client.Client, secretKey types.NamespacedName) ([]byte, error) {
+	secret := &corev1.Secret{}
+
+	err := clt.Get(ctx, secretKey, secret)
+	if err == nil {
+		if key := secret.Data[userIdentityKeySecretKey]; len(key) > 0 {
+			return key, nil
+		}
+
+		return nil, fmt.Errorf("the signing key Secret %v has no %v key", secretKey.String(), userIdentityKeySecretKey)
+	}
+
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	key := make([]byte, userIdentityKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretKey.Namespace, Name: secretKey.Name},
+		Data:       map[string][]byte{userIdentityKeySecretKey: key},
+	}
+
+	if err := clt.Create(ctx, secret); err != nil {
+		// another replica created the key first
+		if k8serrors.IsAlreadyExists(err) {
+			return loadSigningKey(ctx, clt, secretKey)
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// getUserIdentitySignature returns the signature of the user identity and group annotations of the subscription,
+// empty if there is no key or no user identity. The signature covers the subscription namespace and name, so it can't
+// be copied to another subscription.
+func getUserIdentitySignature(sub *appv1.Subscription) string {
+	annotations := sub.GetAnnotations()
+
+	if len(userIdentityKey) == 0 || annotations[appv1.AnnotationUserIdentity] == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, userIdentityKey)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", sub.GetNamespace(), sub.GetName(), annotations[appv1.AnnotationUserIdentity],
+		annotations[appv1.AnnotationUserGroup])
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignUserIdentity sets the signature of the user identity and group annotations of the subscription. The subscriptions
+// created with a generated name are not signed, their name is not known on admission. It returns true if the
+// annotations are changed.
+func SignUserIdentity(sub *appv1.Subscription) bool {
+	annotations := sub.GetAnnotations()
+
+	signature := ""
+	if sub.GetName() != "" {
+		signature = getUserIdentitySignature(sub)
+	}
+
+	if annotations[appv1.AnnotationUserIdentitySignature] == signature {
+		return false
+	}
+
+	if signature == "" {
+		delete(annotations, appv1.AnnotationUserIdentitySignature)
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[appv1.AnnotationUserIdentitySignature] = signature
+	}
+
+	sub.SetAnnotations(annotations)
+
+	return true
+}
+
+// IsUserIdentitySigned returns true if the user identity and group annotations of the subscription were set by the
+// subscription mutating webhook
+func IsUserIdentitySigned(sub *appv1.Subscription) bool {
+	signature := getUserIdentitySignature(sub)
+
+	return signature != "" && hmac.Equal([]byte(signature), []byte(sub.GetAnnotations()[appv1.AnnotationUserIdentitySignature]))
+}
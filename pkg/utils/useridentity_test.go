@@ -0,0 +1,87 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func TestLoadUserIdentityKey(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	clt := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+
+	// the key is generated on the first load and kept
+	key, err := LoadUserIdentityKey(context.TODO(), clt, "ocm")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(key).To(gomega.HaveLen(userIdentityKeySize))
+
+	secret := &corev1.Secret{}
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Namespace: "ocm", Name: UserIdentityKeySecretName}, secret)).To(gomega.Succeed())
+
+	loaded, err := LoadUserIdentityKey(context.TODO(), clt, "ocm")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(loaded).To(gomega.Equal(key))
+}
+
+func TestSignUserIdentity(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	sub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "default", Annotations: map[string]string{
+			appv1.AnnotationUserIdentity: "Ym9i",
+			appv1.AnnotationUserGroup:    "ZGV2LXRlYW0=",
+		}},
+	}
+
+	// nothing is signed without a key
+	g.Expect(SignUserIdentity(sub)).To(gomega.BeFalse())
+	g.Expect(IsUserIdentitySigned(sub)).To(gomega.BeFalse())
+
+	SetUserIdentityKey([]byte("identity-key"))
+	defer SetUserIdentityKey(nil)
+
+	g.Expect(SignUserIdentity(sub)).To(gomega.BeTrue())
+	g.Expect(IsUserIdentitySigned(sub)).To(gomega.BeTrue())
+	g.Expect(SignUserIdentity(sub)).To(gomega.BeFalse())
+
+	// the signature can't be copied to another subscription
+	copied := sub.DeepCopy()
+	copied.SetName("other")
+	g.Expect(IsUserIdentitySigned(copied)).To(gomega.BeFalse())
+
+	// the signature is invalidated by a change of the identity
+	sub.Annotations[appv1.AnnotationUserGroup] = "cGxhdGZvcm0tdGVhbQ=="
+	g.Expect(IsUserIdentitySigned(sub)).To(gomega.BeFalse())
+
+	// the subscriptions with a generated name are not signed
+	generated := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "sub-", Namespace: "default", Annotations: map[string]string{
+			appv1.AnnotationUserIdentity: "Ym9i",
+		}},
+	}
+	g.Expect(SignUserIdentity(generated)).To(gomega.BeFalse())
+	g.Expect(generated.Annotations).NotTo(gomega.HaveKey(appv1.AnnotationUserIdentitySignature))
+}
@@ -38,7 +38,35 @@ const (
 )
 
 type BitBucketPayload struct {
-	Repository BitBucketRepository `json:"repository"`
+	Repository  BitBucketRepository  `json:"repository"`
+	Push        BitBucketPush        `json:"push"`
+	PullRequest BitBucketPullRequest `json:"pullrequest"`
+}
+
+// BitBucketPush is the push of a BitBucket cloud push event
+type BitBucketPush struct {
+	Changes []struct {
+		New *BitBucketRef `json:"new"`
+		Old *BitBucketRef `json:"old"`
+	} `json:"changes"`
+}
+
+// BitBucketRef is a branch or a tag of a BitBucket cloud push event
+type BitBucketRef struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// BitBucketPullRequest is the pull request of a BitBucket cloud or a BitBucket server merged event
+type BitBucketPullRequest struct {
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+	ToRef struct {
+		ID string `json:"id"`
+	} `json:"toRef"`
 }
 
 type BitBucketRepository struct {
@@ -136,9 +164,40 @@ func (listener *WebhookListener) processBitbucketEvent(sub appv1alpha1.Subscript
 	if chobj.Spec.Pathname == payload.Repository.FullName ||
 		chobj.Spec.Pathname == payload.Repository.Links.HTML.Href ||
 		strings.Contains(chobj.Spec.Pathname, payload.Repository.Links.HTML.Href) {
+		if ok, reason := payload.changes().matches(&sub); !ok {
+			klog.Infof("Skipping %s event from %s repository for subscription %s, %s", event, payload.Repository.FullName, sub.Name, reason)
+
+			return true
+		}
+
 		klog.Infof("Processing %s event from %s repository for subscription %s", event, payload.Repository.FullName, sub.Name)
-		listener.updateSubscription(sub)
+		listener.triggerSubscription(sub)
 	}
 
 	return true
 }
+
+// changes returns the refs changed by a BitBucket push or merged event, the payloads don't list the changed paths
+func (payload BitBucketPayload) changes() gitChanges {
+	refs := []string{}
+
+	for _, change := range payload.Push.Changes {
+		for _, ref := range []*BitBucketRef{change.New, change.Old} {
+			switch {
+			case ref == nil || ref.Name == "":
+			case ref.Type == "tag":
+				refs = append(refs, "refs/tags/"+ref.Name)
+			default:
+				refs = append(refs, "refs/heads/"+ref.Name)
+			}
+		}
+	}
+
+	if branch := payload.PullRequest.Destination.Branch.Name; branch != "" {
+		refs = append(refs, "refs/heads/"+branch)
+	}
+
+	refs = append(refs, payload.PullRequest.ToRef.ID)
+
+	return newGitChanges("", refs...)
+}
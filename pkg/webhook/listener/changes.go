@@ -0,0 +1,120 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// maxPushCommits is the number of commits listed by the push event payloads of GitLab and of the older GitHub servers,
+// the changed paths of a push with more commits are unknown
+const maxPushCommits = 20
+
+// gitChanges are the refs and the paths of a Git repo changed by a webhook event
+type gitChanges struct {
+	// refs are the changed refs, like refs/heads/main, refs/tags/v1 or refs/pull/1/head. The event matches the
+	// subscriptions of all the refs if empty
+	refs []string
+	// defaultBranch is the default branch of the repo, the branch of the subscriptions without a branch
+	defaultBranch string
+	// paths are the changed paths, nil if the event doesn't list all of them
+	paths []string
+}
+
+func newGitChanges(defaultBranch string, refs ...string) gitChanges {
+	c := gitChanges{defaultBranch: defaultBranch}
+
+	for _, ref := range refs {
+		if ref != "" && !slices.Contains(c.refs, ref) {
+			c.refs = append(c.refs, ref)
+		}
+	}
+
+	return c
+}
+
+// addCommitPaths adds the paths changed by the commits of a push event
+func (c *gitChanges) addCommitPaths(added, removed, modified []string) {
+	if c.paths == nil {
+		c.paths = []string{}
+	}
+
+	c.paths = append(c.paths, added...)
+	c.paths = append(c.paths, removed...)
+	c.paths = append(c.paths, modified...)
+}
+
+// matches returns true if the subscription deploys one of the changed refs and one of the changed paths is under its
+// git-path or its webhook paths, otherwise it returns the reason why the event is skipped
+func (c gitChanges) matches(sub *appv1alpha1.Subscription) (bool, string) {
+	if ref := c.subscriptionRef(sub); ref != "" && len(c.refs) > 0 && !slices.Contains(c.refs, ref) {
+		return false, "the subscription ref " + ref + " is not changed by " + strings.Join(c.refs, ",")
+	}
+
+	if c.paths == nil {
+		return true, ""
+	}
+
+	watched := []string{utils.GetGitPathAnnotation(sub.GetAnnotations())}
+
+	if paths := sub.GetAnnotations()[appv1alpha1.AnnotationWebhookPaths]; paths != "" {
+		watched = append(watched, strings.Split(paths, ",")...)
+	}
+
+	for _, w := range watched {
+		w = cleanGitPath(w)
+		if w == "" {
+			return true, ""
+		}
+
+		for _, p := range c.paths {
+			if p = cleanGitPath(p); p == w || strings.HasPrefix(p, w+"/") {
+				return true, ""
+			}
+		}
+	}
+
+	return false, "no changed path is under the subscription paths"
+}
+
+// subscriptionRef returns the ref deployed by the subscription, its tag, its branch or the default branch, empty if
+// it is unknown
+func (c gitChanges) subscriptionRef(sub *appv1alpha1.Subscription) string {
+	if tag := sub.GetAnnotations()[appv1alpha1.AnnotationGitTag]; tag != "" {
+		return "refs/tags/" + strings.TrimPrefix(tag, "refs/tags/")
+	}
+
+	if branch := utils.GetSubscriptionBranch(sub); branch != "" {
+		return branch.String()
+	}
+
+	if c.defaultBranch != "" {
+		return "refs/heads/" + c.defaultBranch
+	}
+
+	return ""
+}
+
+// cleanGitPath returns the path relative to the root of the repo, empty for the root
+func cleanGitPath(p string) string {
+	p = path.Clean("/" + strings.TrimSpace(p))
+
+	return strings.TrimPrefix(p, "/")
+}
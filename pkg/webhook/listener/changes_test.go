@@ -0,0 +1,143 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-github/v42/github"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+)
+
+func newChangesTestSubscription(annotations map[string]string) *appv1alpha1.Subscription {
+	return &appv1alpha1.Subscription{ObjectMeta: metav1.ObjectMeta{Name: "sub", Namespace: "ns", Annotations: annotations}}
+}
+
+func TestGitChangesMatches(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	c := newGitChanges("main", "refs/heads/dev")
+	c.addCommitPaths([]string{"apps/guestbook/deployment.yaml"}, nil, []string{"README.md"})
+
+	matches := func(annotations map[string]string) bool {
+		ok, _ := c.matches(newChangesTestSubscription(annotations))
+
+		return ok
+	}
+
+	// the branch and the path of the subscription
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitBranch: "dev"})).To(gomega.BeTrue())
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGithubBranch: "dev",
+		appv1alpha1.AnnotationGitPath: "./apps/guestbook/"})).To(gomega.BeTrue())
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitBranch: "dev",
+		appv1alpha1.AnnotationGitPath: "apps/guest"})).To(gomega.BeFalse())
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitBranch: "dev",
+		appv1alpha1.AnnotationGitPath: "apps/nginx"})).To(gomega.BeFalse())
+
+	// the webhook paths in addition to the git path
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitBranch: "dev", appv1alpha1.AnnotationGitPath: "apps/nginx",
+		appv1alpha1.AnnotationWebhookPaths: "base, apps/guestbook"})).To(gomega.BeTrue())
+
+	// another branch, the default branch, or a tag
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitBranch: "main"})).To(gomega.BeFalse())
+	g.Expect(matches(map[string]string{})).To(gomega.BeFalse())
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitBranch: "dev", appv1alpha1.AnnotationGitTag: "v1"})).To(gomega.BeFalse())
+
+	// the events that don't tell the refs or the paths match all the subscriptions
+	c = newGitChanges("")
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitPath: "apps/nginx"})).To(gomega.BeTrue())
+
+	c = newGitChanges("", "refs/heads/main")
+	g.Expect(matches(map[string]string{})).To(gomega.BeTrue())
+	g.Expect(matches(map[string]string{appv1alpha1.AnnotationGitBranch: "dev"})).To(gomega.BeFalse())
+}
+
+func TestGitHubChanges(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	push := &github.PushEvent{}
+	g.Expect(json.Unmarshal([]byte(`{
+		"ref": "refs/heads/main",
+		"repository": {"default_branch": "main"},
+		"commits": [
+			{"added": ["apps/a.yaml"], "removed": [], "modified": []},
+			{"added": [], "removed": ["apps/b.yaml"], "modified": ["docs/c.md"]}
+		]
+	}`), push)).To(gomega.Succeed())
+
+	c := githubPushChanges(push)
+	g.Expect(c.refs).To(gomega.Equal([]string{"refs/heads/main"}))
+	g.Expect(c.defaultBranch).To(gomega.Equal("main"))
+	g.Expect(c.paths).To(gomega.ConsistOf("apps/a.yaml", "apps/b.yaml", "docs/c.md"))
+
+	// the paths of a forced push are unknown
+	forced := true
+	push.Forced = &forced
+	g.Expect(githubPushChanges(push).paths).To(gomega.BeNil())
+
+	pr := &github.PullRequestEvent{}
+	g.Expect(json.Unmarshal([]byte(`{"number": 7, "pull_request": {"base": {"ref": "main"}}}`), pr)).To(gomega.Succeed())
+	g.Expect(githubPullRequestChanges(pr).refs).To(gomega.Equal([]string{"refs/heads/main", "refs/pull/7/head"}))
+}
+
+func TestGitLabChanges(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	payload := GitLabPayload{}
+	g.Expect(json.Unmarshal([]byte(`{
+		"ref": "refs/heads/main",
+		"project": {"default_branch": "main"},
+		"total_commits_count": 1,
+		"commits": [{"added": ["apps/a.yaml"], "modified": ["apps/b.yaml"], "removed": []}]
+	}`), &payload)).To(gomega.Succeed())
+
+	c := payload.changes(GitLabPushEvents)
+	g.Expect(c.refs).To(gomega.Equal([]string{"refs/heads/main"}))
+	g.Expect(c.paths).To(gomega.ConsistOf("apps/a.yaml", "apps/b.yaml"))
+
+	// the paths are unknown if the payload doesn't list all the commits
+	payload.TotalCommitsCount = 30
+	g.Expect(payload.changes(GitLabPushEvents).paths).To(gomega.BeNil())
+
+	mr := GitLabPayload{}
+	g.Expect(json.Unmarshal([]byte(`{"object_attributes": {"iid": 3, "target_branch": "main"}}`), &mr)).To(gomega.Succeed())
+	g.Expect(mr.changes(GitLabMergeRequestEvents).refs).To(gomega.Equal([]string{"refs/heads/main", "refs/merge-requests/3/head"}))
+}
+
+func TestBitbucketChanges(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	push := BitBucketPayload{}
+	g.Expect(json.Unmarshal([]byte(`{"push": {"changes": [
+		{"new": {"type": "branch", "name": "dev"}, "old": {"type": "branch", "name": "dev"}},
+		{"new": {"type": "tag", "name": "v1"}, "old": null}
+	]}}`), &push)).To(gomega.Succeed())
+
+	c := push.changes()
+	g.Expect(c.refs).To(gomega.Equal([]string{"refs/heads/dev", "refs/tags/v1"}))
+	g.Expect(c.paths).To(gomega.BeNil())
+
+	cloud := BitBucketPayload{}
+	g.Expect(json.Unmarshal([]byte(`{"pullrequest": {"destination": {"branch": {"name": "main"}}}}`), &cloud)).To(gomega.Succeed())
+	g.Expect(cloud.changes().refs).To(gomega.Equal([]string{"refs/heads/main"}))
+
+	server := BitBucketPayload{}
+	g.Expect(json.Unmarshal([]byte(`{"pullRequest": {"toRef": {"id": "refs/heads/release"}}}`), &server)).To(gomega.Succeed())
+	g.Expect(server.changes().refs).To(gomega.Equal([]string{"refs/heads/release"}))
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/google/go-github/v42/github"
@@ -34,8 +35,10 @@ import (
 )
 
 const (
-	payloadFormParam      = "payload"
-	githubSignatureHeader = "X-Hub-Signature"
+	payloadFormParam            = "payload"
+	githubSignatureHeader       = "X-Hub-Signature"
+	githubSHA256SignatureHeader = "X-Hub-Signature-256"
+	githubSHA1SignaturePrefix   = "sha1="
 )
 
 func (listener *WebhookListener) handleGithubWebhook(r *http.Request) error {
@@ -114,16 +117,28 @@ func (listener *WebhookListener) processSubscription(sub appv1alpha1.Subscriptio
 			chobj.Spec.Pathname == e.GetRepo().GetHTMLURL() ||
 			chobj.Spec.Pathname == e.GetRepo().GetURL() ||
 			strings.Contains(chobj.Spec.Pathname, e.GetRepo().GetFullName()) {
+			if ok, reason := githubPullRequestChanges(e).matches(&sub); !ok {
+				klog.Infof("Skipping the PR event from %s for subscription %s, %s", e.GetRepo().GetHTMLURL(), sub.Name, reason)
+
+				return true
+			}
+
 			klog.Info("Processing PR event from " + e.GetRepo().GetHTMLURL())
-			listener.updateSubscription(sub)
+			listener.triggerSubscription(sub)
 		}
 	case *github.PushEvent:
 		if chobj.Spec.Pathname == e.GetRepo().GetCloneURL() ||
 			chobj.Spec.Pathname == e.GetRepo().GetHTMLURL() ||
 			chobj.Spec.Pathname == e.GetRepo().GetURL() ||
 			strings.Contains(chobj.Spec.Pathname, e.GetRepo().GetFullName()) {
+			if ok, reason := githubPushChanges(e).matches(&sub); !ok {
+				klog.Infof("Skipping the PUSH event from %s for subscription %s, %s", e.GetRepo().GetHTMLURL(), sub.Name, reason)
+
+				return true
+			}
+
 			klog.Info("Processing PUSH event from " + e.GetRepo().GetHTMLURL())
-			listener.updateSubscription(sub)
+			listener.triggerSubscription(sub)
 		}
 	default:
 		klog.Infof("Unhandled webhook event type %s\n", eventType)
@@ -198,7 +213,11 @@ func (listener *WebhookListener) ParseRequest(r *http.Request) (body []byte, sig
 		}
 	}()
 
-	signature = r.Header.Get(githubSignatureHeader)
+	// prefer the HMAC SHA-256 signature, the HMAC SHA-1 signature is not allowed in FIPS mode
+	signature = r.Header.Get(githubSHA256SignatureHeader)
+	if signature == "" {
+		signature = r.Header.Get(githubSignatureHeader)
+	}
 
 	event, err = github.ParseWebHook(github.WebHookType(r), payload)
 	if err != nil {
@@ -209,15 +228,52 @@ func (listener *WebhookListener) ParseRequest(r *http.Request) (body []byte, sig
 	return body, signature, event, nil
 }
 
-func (listener *WebhookListener) validateSecret(signature string, annotations map[string]string, chNamespace string, body []byte) (ret bool) {
+func (listener *WebhookListener) validateSecret(signature string, annotations map[string]string, chNamespace string, body []byte) bool {
+	if utils.IsFIPSMode() && strings.HasPrefix(signature, githubSHA1SignaturePrefix) {
+		klog.Info("Failed to validate webhook event signature, the HMAC SHA-1 signature is not allowed in FIPS mode, " +
+			"the " + githubSHA256SignatureHeader + " header is required")
+
+		return false
+	}
+
 	secret := listener.getWebhookSecret(annotations[appv1alpha1.AnnotationWebhookSecret], chNamespace)
 
 	// Using the channel's webhook secret, validate it against the request's body
 	if err := github.ValidateSignature(signature, body, []byte(secret)); err != nil {
 		klog.Info("Failed to validate webhook event signature, error: ", err)
 		// If validation fails, this webhook event is not for this subscription. Skip.
-		ret = false
+		return false
+	}
+
+	return true
+}
+
+// githubPushChanges returns the changes of a GitHub push event, the paths of a forced push are unknown
+func githubPushChanges(e *github.PushEvent) gitChanges {
+	c := newGitChanges(e.GetRepo().GetDefaultBranch(), e.GetRef())
+
+	if e.GetForced() || e.GetDeleted() || len(e.Commits) == 0 || len(e.Commits) >= maxPushCommits {
+		return c
+	}
+
+	for _, commit := range e.Commits {
+		c.addCommitPaths(commit.Added, commit.Removed, commit.Modified)
+	}
+
+	return c
+}
+
+// githubPullRequestChanges returns the changes of a GitHub pull request event, its base branch and its head ref
+func githubPullRequestChanges(e *github.PullRequestEvent) gitChanges {
+	refs := []string{}
+
+	if base := e.GetPullRequest().GetBase().GetRef(); base != "" {
+		refs = append(refs, "refs/heads/"+base)
+	}
+
+	if e.GetNumber() != 0 {
+		refs = append(refs, "refs/pull/"+strconv.Itoa(e.GetNumber())+"/head")
 	}
 
-	return ret
+	return newGitChanges(e.GetRepo().GetDefaultBranch(), refs...)
 }
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -40,7 +41,28 @@ const (
 )
 
 type GitLabPayload struct {
-	Repository GitLabRepository `json:"repository"`
+	Repository        GitLabRepository       `json:"repository"`
+	Project           GitLabProject          `json:"project"`
+	Ref               string                 `json:"ref"`
+	TotalCommitsCount int                    `json:"total_commits_count"`
+	Commits           []GitLabCommit         `json:"commits"`
+	ObjectAttributes  GitLabObjectAttributes `json:"object_attributes"`
+}
+
+type GitLabProject struct {
+	DefaultBranch string `json:"default_branch"`
+}
+
+type GitLabCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+// GitLabObjectAttributes are the attributes of the merge request of a merge request event
+type GitLabObjectAttributes struct {
+	IID          int    `json:"iid"`
+	TargetBranch string `json:"target_branch"`
 }
 
 type GitLabRepository struct {
@@ -137,8 +159,14 @@ func (listener *WebhookListener) processGitLabEvent(sub appv1alpha1.Subscription
 		strings.Contains(chobj.Spec.Pathname, payload.Repository.Homepage)) &&
 		strings.TrimSpace(payload.Repository.Homepage) != "" &&
 		strings.EqualFold(channelSecret, hookSecret) {
+		if ok, reason := payload.changes(event).matches(&sub); !ok {
+			klog.Infof("Skipping %s event from %s repository for subscription %s, %s", event, payload.Repository.URL, sub.Name, reason)
+
+			return true
+		}
+
 		klog.Infof("Processing %s event from %s repository for subscription %s", event, payload.Repository.URL, sub.Name)
-		listener.updateSubscription(sub)
+		listener.triggerSubscription(sub)
 	}
 
 	return true
@@ -166,3 +194,33 @@ func (listener *WebhookListener) getWebhookSecret(channelSecret, channelNs strin
 
 	return secret
 }
+
+// changes returns the changes of a GitLab push or merge request event, the paths of a push are unknown if the payload
+// doesn't list all its commits
+func (payload GitLabPayload) changes(event string) gitChanges {
+	if strings.EqualFold(event, GitLabMergeRequestEvents) {
+		refs := []string{}
+
+		if payload.ObjectAttributes.TargetBranch != "" {
+			refs = append(refs, "refs/heads/"+payload.ObjectAttributes.TargetBranch)
+		}
+
+		if payload.ObjectAttributes.IID != 0 {
+			refs = append(refs, "refs/merge-requests/"+strconv.Itoa(payload.ObjectAttributes.IID)+"/head")
+		}
+
+		return newGitChanges(payload.Project.DefaultBranch, refs...)
+	}
+
+	c := newGitChanges(payload.Project.DefaultBranch, payload.Ref)
+
+	if len(payload.Commits) == 0 || len(payload.Commits) < payload.TotalCommitsCount {
+		return c
+	}
+
+	for _, commit := range payload.Commits {
+		c.addCommitPaths(commit.Added, commit.Removed, commit.Modified)
+	}
+
+	return c
+}
@@ -0,0 +1,307 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+const (
+	// CertProviderCertManager provisions the listener certificate with a cert-manager Certificate
+	CertProviderCertManager = "cert-manager"
+	// CertProviderOpenShiftServiceCA provisions the listener certificate with the OpenShift service CA
+	CertProviderOpenShiftServiceCA = "openshift-service-ca"
+
+	// certReloadInterval is the period of the listener certificate reloads
+	certReloadInterval = time.Minute
+)
+
+var certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// TLSOptions are the TLS options of the webhook listener
+type TLSOptions struct {
+	// CertProvider provisions the certificate of the listener service in the secret of the service name, the
+	// certificate files are used if it is empty
+	CertProvider string
+	// CertIssuer is the cert-manager issuer of the certificate, like ClusterIssuer/ca-issuer or Issuer/ca-issuer
+	CertIssuer string
+	// DNSNames are the names the certificate must be valid for, like the host of the listener route, in addition to
+	// the names of the listener service with a cert provider
+	DNSNames []string
+	// MinVersion is the minimum TLS version, 1.2 or 1.3
+	MinVersion string
+	// CipherSuites are the TLS 1.2 cipher suites, the Go defaults if empty
+	CipherSuites []string
+}
+
+var tlsOptions TLSOptions
+
+// SetTLSOptions validates and sets the TLS options of the webhook listener, before it is added to the manager
+func SetTLSOptions(opts TLSOptions) error {
+	switch opts.CertProvider {
+	case "", CertProviderOpenShiftServiceCA:
+	case CertProviderCertManager:
+		if _, _, err := parseCertIssuer(opts.CertIssuer); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown webhook listener cert provider %v, it must be %v or %v", opts.CertProvider,
+			CertProviderCertManager, CertProviderOpenShiftServiceCA)
+	}
+
+	if _, err := newTLSConfig(opts, nil); err != nil {
+		return err
+	}
+
+	tlsOptions = opts
+
+	return nil
+}
+
+// parseCertIssuer returns the kind and the name of a cert-manager issuer, ClusterIssuer/<name> or Issuer/<name>
+func parseCertIssuer(issuer string) (string, string, error) {
+	kind, name, found := strings.Cut(issuer, "/")
+	if !found || name == "" || (kind != "ClusterIssuer" && kind != "Issuer") {
+		return "", "", fmt.Errorf("invalid webhook listener cert issuer %q, it must be ClusterIssuer/<name> or Issuer/<name>",
+			issuer)
+	}
+
+	return kind, name, nil
+}
+
+// newTLSConfig returns the TLS config of the listener with the minimum version and the cipher suites of the options,
+// restricted in FIPS mode
+func newTLSConfig(opts TLSOptions, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:     appv1alpha1.TLSMinVersionInt, // #nosec G402 -- TLS 1.2 is required for FIPS
+		GetCertificate: getCertificate,
+	}
+
+	switch opts.MinVersion {
+	case "", appv1alpha1.TLSMinVersionString:
+	case "1.3":
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("invalid webhook listener TLS min version %v, it must be 1.2 or 1.3", opts.MinVersion)
+	}
+
+	if len(opts.CipherSuites) > 0 {
+		suites := map[string]uint16{}
+		for _, suite := range tls.CipherSuites() {
+			suites[suite.Name] = suite.ID
+		}
+
+		for _, name := range opts.CipherSuites {
+			id, ok := suites[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown or insecure webhook listener TLS cipher suite %v", name)
+			}
+
+			cfg.CipherSuites = append(cfg.CipherSuites, id)
+		}
+	}
+
+	return utils.SetFIPSTLSConfig(cfg), nil
+}
+
+// serviceDNSNames returns the DNS names of the listener service
+func serviceDNSNames(namespace string) []string {
+	return []string{
+		serviceName + "." + namespace + ".svc",
+		serviceName + "." + namespace + ".svc.cluster.local",
+	}
+}
+
+// certReloader serves the last valid certificate, and reloads it periodically so a rotated certificate is served
+// without a restart
+type certReloader struct {
+	load     func(ctx context.Context) (*tls.Certificate, error)
+	dnsNames []string
+	cert     atomic.Pointer[tls.Certificate]
+	now      func() time.Time
+}
+
+func newFileCertReloader(crtFile, keyFile string, dnsNames []string) *certReloader {
+	return &certReloader{
+		load: func(context.Context) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(crtFile, keyFile)
+
+			return &cert, err
+		},
+		dnsNames: dnsNames,
+		now:      time.Now,
+	}
+}
+
+func newSecretCertReloader(clt client.Client, key types.NamespacedName, dnsNames []string) *certReloader {
+	return &certReloader{
+		load: func(ctx context.Context) (*tls.Certificate, error) {
+			secret := &corev1.Secret{}
+			if err := clt.Get(ctx, key, secret); err != nil {
+				return nil, fmt.Errorf("failed to get the certificate secret %v, err: %w", key.String(), err)
+			}
+
+			cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+
+			return &cert, err
+		},
+		dnsNames: dnsNames,
+		now:      time.Now,
+	}
+}
+
+// reload loads the certificate, a certificate that is expired or not valid for the DNS names is rejected
+func (r *certReloader) reload(ctx context.Context) error {
+	cert, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(cert.Certificate) == 0 {
+		return errors.New("the webhook listener certificate is empty")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+
+	if r.now().After(leaf.NotAfter) {
+		return fmt.Errorf("the webhook listener certificate expired at %v", leaf.NotAfter)
+	}
+
+	for _, name := range r.dnsNames {
+		if err := leaf.VerifyHostname(name); err != nil {
+			return fmt.Errorf("the webhook listener certificate is not valid for %v, err: %w", name, err)
+		}
+	}
+
+	cert.Leaf = leaf
+
+	if previous := r.cert.Swap(cert); previous == nil || !bytes.Equal(previous.Certificate[0], cert.Certificate[0]) {
+		klog.Infof("Loaded the webhook listener certificate, DNS names: %v, expires at: %v", leaf.DNSNames, leaf.NotAfter)
+	}
+
+	return nil
+}
+
+// start reloads the certificate periodically until the context is done, the previous certificate is served if the
+// new one is rejected
+func (r *certReloader) start(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.reload(ctx); err != nil {
+			klog.Errorf("Failed to reload the webhook listener certificate, the previous certificate is served, err: %v", err)
+		}
+	}, certReloadInterval)
+}
+
+// GetCertificate returns the certificate served by the listener
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := r.cert.Load()
+	if cert == nil {
+		return nil, errors.New("no webhook listener certificate")
+	}
+
+	return cert, nil
+}
+
+// createOrUpdateWebhookListenerCertificate creates or updates the cert-manager Certificate of the listener service,
+// cert-manager issues and renews it in the secret of the service name
+func createOrUpdateWebhookListenerCertificate(clt client.Client, namespace string, opts TLSOptions) error {
+	issuerKind, issuerName, err := parseCertIssuer(opts.CertIssuer)
+	if err != nil {
+		return err
+	}
+
+	dnsNames := []interface{}{}
+	for _, name := range append(serviceDNSNames(namespace), opts.DNSNames...) {
+		dnsNames = append(dnsNames, name)
+	}
+
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(certificateGVK)
+	cert.SetName(serviceName)
+	cert.SetNamespace(namespace)
+	cert.SetLabels(map[string]string{"app": serviceName})
+
+	spec := map[string]interface{}{
+		"secretName": serviceName,
+		"dnsNames":   dnsNames,
+		"usages":     []interface{}{"server auth", "digital signature", "key encipherment"},
+		"issuerRef": map[string]interface{}{
+			"group": certificateGVK.Group,
+			"kind":  issuerKind,
+			"name":  issuerName,
+		},
+		"privateKey": map[string]interface{}{
+			"rotationPolicy": "Always",
+		},
+	}
+
+	if err := unstructured.SetNestedMap(cert.Object, spec, "spec"); err != nil {
+		return err
+	}
+
+	if deploymentName, err := findEnvVariable("DEPLOYMENT_LABEL"); err == nil {
+		owner := &appsv1.Deployment{}
+		if err := clt.Get(context.TODO(), types.NamespacedName{Name: deploymentName, Namespace: namespace}, owner); err == nil {
+			cert.SetOwnerReferences([]metav1.OwnerReference{
+				*metav1.NewControllerRef(owner, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})})
+		}
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(certificateGVK)
+
+	if err := clt.Get(context.TODO(), types.NamespacedName{Name: serviceName, Namespace: namespace}, existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		if err := clt.Create(context.TODO(), cert); err != nil {
+			return err
+		}
+
+		klog.Infof("Git webhook listener certificate created with the issuer %v", opts.CertIssuer)
+
+		return nil
+	}
+
+	cert.SetResourceVersion(existing.GetResourceVersion())
+
+	return clt.Update(context.TODO(), cert)
+}
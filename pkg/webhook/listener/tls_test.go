@@ -0,0 +1,170 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestCertificate(g *gomega.WithT, notAfter time.Time, dnsNames ...string) (crtPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: serviceName},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     dnsNames,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestSetTLSOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer func() { tlsOptions = TLSOptions{} }()
+
+	g.Expect(SetTLSOptions(TLSOptions{CertProvider: "vault"})).NotTo(gomega.Succeed())
+	g.Expect(SetTLSOptions(TLSOptions{CertProvider: CertProviderCertManager})).NotTo(gomega.Succeed())
+	g.Expect(SetTLSOptions(TLSOptions{CertProvider: CertProviderCertManager, CertIssuer: "Secret/ca"})).NotTo(gomega.Succeed())
+	g.Expect(SetTLSOptions(TLSOptions{MinVersion: "1.1"})).NotTo(gomega.Succeed())
+	g.Expect(SetTLSOptions(TLSOptions{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}})).NotTo(gomega.Succeed())
+
+	opts := TLSOptions{
+		CertProvider: CertProviderCertManager,
+		CertIssuer:   "ClusterIssuer/ca-issuer",
+		MinVersion:   "1.3",
+		CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	g.Expect(SetTLSOptions(opts)).To(gomega.Succeed())
+	g.Expect(tlsOptions).To(gomega.Equal(opts))
+
+	cfg, err := newTLSConfig(opts, nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cfg.MinVersion).To(gomega.Equal(uint16(tls.VersionTLS13)))
+	g.Expect(cfg.CipherSuites).To(gomega.Equal([]uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}))
+}
+
+func TestCertReloader(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	dnsNames := serviceDNSNames("open-cluster-management")
+	key := types.NamespacedName{Namespace: "open-cluster-management", Name: serviceName}
+
+	crt, crtKey := newTestCertificate(g, now.Add(time.Hour), dnsNames...)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Data:       map[string][]byte{corev1.TLSCertKey: crt, corev1.TLSPrivateKeyKey: crtKey},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+
+	r := newSecretCertReloader(clt, key, dnsNames)
+	r.now = func() time.Time { return now }
+
+	_, err := r.GetCertificate(nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	g.Expect(r.reload(context.TODO())).To(gomega.Succeed())
+
+	served, err := r.GetCertificate(nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(served.Leaf.DNSNames).To(gomega.Equal(dnsNames))
+
+	// a rotated certificate is served without a restart
+	rotated, rotatedKey := newTestCertificate(g, now.Add(2*time.Hour), dnsNames...)
+	secret.Data = map[string][]byte{corev1.TLSCertKey: rotated, corev1.TLSPrivateKeyKey: rotatedKey}
+	g.Expect(clt.Update(context.TODO(), secret)).To(gomega.Succeed())
+	g.Expect(r.reload(context.TODO())).To(gomega.Succeed())
+
+	served, _ = r.GetCertificate(nil)
+	g.Expect(served.Leaf.NotAfter).To(gomega.Equal(now.Add(2 * time.Hour)))
+
+	// a certificate that is not valid for the service names is rejected, the previous certificate is served
+	wrong, wrongKey := newTestCertificate(g, now.Add(3*time.Hour), "other.example.com")
+	secret.Data = map[string][]byte{corev1.TLSCertKey: wrong, corev1.TLSPrivateKeyKey: wrongKey}
+	g.Expect(clt.Update(context.TODO(), secret)).To(gomega.Succeed())
+	g.Expect(r.reload(context.TODO())).NotTo(gomega.Succeed())
+
+	served, _ = r.GetCertificate(nil)
+	g.Expect(served.Leaf.NotAfter).To(gomega.Equal(now.Add(2 * time.Hour)))
+
+	// an expired certificate is rejected
+	r.now = func() time.Time { return now.Add(4 * time.Hour) }
+	expired, expiredKey := newTestCertificate(g, now.Add(3*time.Hour), dnsNames...)
+	secret.Data = map[string][]byte{corev1.TLSCertKey: expired, corev1.TLSPrivateKeyKey: expiredKey}
+	g.Expect(clt.Update(context.TODO(), secret)).To(gomega.Succeed())
+	g.Expect(r.reload(context.TODO())).NotTo(gomega.Succeed())
+}
+
+func TestCreateOrUpdateWebhookListenerCertificate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	clt := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	opts := TLSOptions{
+		CertProvider: CertProviderCertManager,
+		CertIssuer:   "ClusterIssuer/ca-issuer",
+		DNSNames:     []string{"webhook.apps.example.com"},
+	}
+
+	g.Expect(createOrUpdateWebhookListenerCertificate(clt, "open-cluster-management", opts)).To(gomega.Succeed())
+
+	opts.CertIssuer = "Issuer/local-issuer"
+	g.Expect(createOrUpdateWebhookListenerCertificate(clt, "open-cluster-management", opts)).To(gomega.Succeed())
+
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(certificateGVK)
+	g.Expect(clt.Get(context.TODO(), types.NamespacedName{Namespace: "open-cluster-management", Name: serviceName},
+		cert)).To(gomega.Succeed())
+
+	secretName, _, _ := unstructured.NestedString(cert.Object, "spec", "secretName")
+	g.Expect(secretName).To(gomega.Equal(serviceName))
+
+	dnsNames, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	g.Expect(dnsNames).To(gomega.Equal(append(serviceDNSNames("open-cluster-management"), "webhook.apps.example.com")))
+
+	issuer, _, _ := unstructured.NestedStringMap(cert.Object, "spec", "issuerRef")
+	g.Expect(issuer).To(gomega.Equal(map[string]string{"group": "cert-manager.io", "kind": "Issuer", "name": "local-issuer"}))
+}
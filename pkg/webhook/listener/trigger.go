@@ -0,0 +1,163 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/metrics"
+)
+
+// TriggerOptions are the options of the subscription reconciles triggered by the webhook events
+type TriggerOptions struct {
+	// DebounceWindow coalesces the events of a subscription repository and branch received within the window into a
+	// single reconcile
+	DebounceWindow time.Duration
+	// MinInterval is the minimum interval between two reconciles of a subscription triggered by the webhook events
+	MinInterval time.Duration
+}
+
+var triggerOptions TriggerOptions
+
+// SetTriggerOptions validates and sets the trigger options of the webhook listener, before it is added to the manager.
+// The events trigger the reconciles immediately if both durations are zero.
+func SetTriggerOptions(opts TriggerOptions) error {
+	if opts.DebounceWindow < 0 {
+		return fmt.Errorf("invalid webhook debounce window %v, it must not be negative", opts.DebounceWindow)
+	}
+
+	if opts.MinInterval < 0 {
+		return fmt.Errorf("invalid webhook min trigger interval %v, it must not be negative", opts.MinInterval)
+	}
+
+	triggerOptions = opts
+
+	return nil
+}
+
+// subscriptionTrigger coalesces the webhook events of the subscriptions. The first event of a subscription schedules
+// its reconcile after the debounce window, or once the min interval since its previous reconcile has elapsed, the
+// events received until then are coalesced into the scheduled reconcile.
+type subscriptionTrigger struct {
+	opts    TriggerOptions
+	trigger func(key types.NamespacedName)
+
+	mu      sync.Mutex
+	pending map[types.NamespacedName]bool
+	last    map[types.NamespacedName]time.Time
+
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func())
+}
+
+// newSubscriptionTrigger returns the trigger of the options, nil if the events trigger the reconciles immediately
+func newSubscriptionTrigger(opts TriggerOptions, trigger func(key types.NamespacedName)) *subscriptionTrigger {
+	if opts.DebounceWindow <= 0 && opts.MinInterval <= 0 {
+		return nil
+	}
+
+	return &subscriptionTrigger{
+		opts:    opts,
+		trigger: trigger,
+		pending: map[types.NamespacedName]bool{},
+		last:    map[types.NamespacedName]time.Time{},
+		now:     time.Now,
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// enqueue schedules the reconcile of the subscription, it returns false if the event is coalesced into a reconcile
+// that is already scheduled
+func (t *subscriptionTrigger) enqueue(key types.NamespacedName) bool {
+	t.mu.Lock()
+
+	if t.pending[key] {
+		t.mu.Unlock()
+
+		klog.V(1).Infof("Coalesced the webhook event of the subscription %v into its scheduled reconcile", key.String())
+		metrics.GitWebhookTriggerCount.WithLabelValues(metrics.ResultCoalesced).Inc()
+
+		return false
+	}
+
+	now := t.now()
+	delay := t.opts.DebounceWindow
+
+	for k, last := range t.last {
+		if now.Sub(last) >= t.opts.MinInterval {
+			delete(t.last, k)
+
+			continue
+		}
+
+		if k == key && last.Add(t.opts.MinInterval).Sub(now) > delay {
+			delay = last.Add(t.opts.MinInterval).Sub(now)
+		}
+	}
+
+	t.pending[key] = true
+	t.mu.Unlock()
+
+	klog.V(1).Infof("Scheduled the reconcile of the subscription %v in %v", key.String(), delay)
+
+	t.afterFunc(delay, func() { t.fire(key) })
+
+	return true
+}
+
+func (t *subscriptionTrigger) fire(key types.NamespacedName) {
+	t.mu.Lock()
+	delete(t.pending, key)
+	t.last[key] = t.now()
+	t.mu.Unlock()
+
+	metrics.GitWebhookTriggerCount.WithLabelValues(metrics.ResultTriggered).Inc()
+
+	t.trigger(key)
+}
+
+// triggerSubscription triggers the reconcile of the subscription for a webhook event, immediately or coalesced with
+// the other events of the subscription by the trigger options
+func (listener *WebhookListener) triggerSubscription(sub appv1alpha1.Subscription) {
+	if listener.triggers == nil {
+		metrics.GitWebhookTriggerCount.WithLabelValues(metrics.ResultTriggered).Inc()
+		listener.updateSubscription(sub)
+
+		return
+	}
+
+	listener.triggers.enqueue(types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name})
+}
+
+// updateSubscriptionByKey updates the latest subscription, the events may have been received long before
+func (listener *WebhookListener) updateSubscriptionByKey(key types.NamespacedName) {
+	sub := &appv1alpha1.Subscription{}
+	if err := listener.LocalClient.Get(context.TODO(), key, sub); err != nil {
+		klog.Errorf("Failed to get the subscription %v triggered by the webhook events. error: %v", key.String(), err)
+
+		return
+	}
+
+	listener.updateSubscription(*sub)
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSetTriggerOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer func() { triggerOptions = TriggerOptions{} }()
+
+	g.Expect(SetTriggerOptions(TriggerOptions{DebounceWindow: -time.Second})).NotTo(gomega.Succeed())
+	g.Expect(SetTriggerOptions(TriggerOptions{MinInterval: -time.Second})).NotTo(gomega.Succeed())
+	g.Expect(SetTriggerOptions(TriggerOptions{DebounceWindow: time.Second, MinInterval: time.Minute})).To(gomega.Succeed())
+	g.Expect(triggerOptions).To(gomega.Equal(TriggerOptions{DebounceWindow: time.Second, MinInterval: time.Minute}))
+
+	g.Expect(newSubscriptionTrigger(TriggerOptions{}, nil)).To(gomega.BeNil())
+}
+
+func TestSubscriptionTrigger(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	type scheduled struct {
+		delay time.Duration
+		fire  func()
+	}
+
+	var timers []scheduled
+
+	triggered := map[types.NamespacedName]int{}
+
+	now := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	tr := newSubscriptionTrigger(TriggerOptions{DebounceWindow: 10 * time.Second, MinInterval: time.Minute},
+		func(key types.NamespacedName) { triggered[key]++ })
+	tr.now = func() time.Time { return now }
+	tr.afterFunc = func(d time.Duration, f func()) { timers = append(timers, scheduled{delay: d, fire: f}) }
+
+	sub1 := types.NamespacedName{Namespace: "ns", Name: "sub1"}
+	sub2 := types.NamespacedName{Namespace: "ns", Name: "sub2"}
+
+	// a burst of events is coalesced into a single reconcile after the debounce window
+	g.Expect(tr.enqueue(sub1)).To(gomega.BeTrue())
+	g.Expect(tr.enqueue(sub1)).To(gomega.BeFalse())
+	g.Expect(tr.enqueue(sub1)).To(gomega.BeFalse())
+	g.Expect(tr.enqueue(sub2)).To(gomega.BeTrue())
+	g.Expect(timers).To(gomega.HaveLen(2))
+	g.Expect(timers[0].delay).To(gomega.Equal(10 * time.Second))
+
+	now = now.Add(10 * time.Second)
+	timers[0].fire()
+	timers[1].fire()
+	g.Expect(triggered).To(gomega.Equal(map[types.NamespacedName]int{sub1: 1, sub2: 1}))
+
+	// the next reconcile waits for the min interval since the previous reconcile
+	now = now.Add(20 * time.Second)
+	g.Expect(tr.enqueue(sub1)).To(gomega.BeTrue())
+	g.Expect(tr.enqueue(sub1)).To(gomega.BeFalse())
+	g.Expect(timers).To(gomega.HaveLen(3))
+	g.Expect(timers[2].delay).To(gomega.Equal(40 * time.Second))
+
+	now = now.Add(40 * time.Second)
+	timers[2].fire()
+	g.Expect(triggered[sub1]).To(gomega.Equal(2))
+
+	// the reconciles older than the min interval are forgotten
+	now = now.Add(2 * time.Minute)
+	g.Expect(tr.enqueue(sub2)).To(gomega.BeTrue())
+	g.Expect(timers[3].delay).To(gomega.Equal(10 * time.Second))
+	g.Expect(tr.last).To(gomega.BeEmpty())
+}
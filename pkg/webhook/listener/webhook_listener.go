@@ -16,7 +16,6 @@ package listener
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -33,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
@@ -62,6 +62,8 @@ type WebhookListener struct {
 	DynamicClient dynamic.Interface
 	TLSKeyFile    string
 	TLSCrtFile    string
+	TLSOptions    TLSOptions
+	triggers      *subscriptionTrigger
 }
 
 var webhookListener *WebhookListener
@@ -70,7 +72,12 @@ var webhookListener *WebhookListener
 func Add(mgr manager.Manager, hubconfig *rest.Config, tlsKeyFile, tlsCrtFile string, disableTLS bool, createService bool) error {
 	klog.Info("Setting up webhook listener ...")
 
-	if !disableTLS {
+	opts := tlsOptions
+	if disableTLS {
+		opts.CertProvider = ""
+	}
+
+	if !disableTLS && opts.CertProvider == "" {
 		dir := filepath.Join(os.TempDir(), "github-webhook-server-certs")
 
 		if strings.EqualFold(tlsKeyFile, "") || strings.EqualFold(tlsCrtFile, "") {
@@ -95,6 +102,20 @@ func Add(mgr manager.Manager, hubconfig *rest.Config, tlsKeyFile, tlsCrtFile str
 		return err
 	}
 
+	webhookListener.TLSOptions = opts
+
+	if opts.CertProvider == CertProviderCertManager {
+		namespace, err := getOperatorNamespace()
+		if err != nil {
+			return err
+		}
+
+		if err := createOrUpdateWebhookListenerCertificate(webhookListener.LocalClient, namespace, opts); err != nil {
+			klog.Error("Failed to create the certificate of the Git webhook listener. error: ", err)
+			return err
+		}
+	}
+
 	return mgr.Add(webhookListener)
 }
 
@@ -108,19 +129,32 @@ func (listener *WebhookListener) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/webhook", listener.HandleWebhook)
 
-	if listener.TLSKeyFile != "" && listener.TLSCrtFile != "" {
-		klog.Info("Starting the WebHook listener on port 8443 with TLS key and cert files: " + listener.TLSKeyFile + " " + listener.TLSCrtFile)
+	reloader, err := listener.newCertReloader()
+	if err != nil {
+		return err
+	}
+
+	if reloader != nil {
+		if err := listener.loadCertificate(ctx, reloader); err != nil {
+			klog.Error("Failed to load the webhook listener certificate. error: ", err)
+			return err
+		}
+
+		go reloader.start(ctx)
+
+		tlsConfig, err := newTLSConfig(listener.TLSOptions, reloader.GetCertificate)
+		if err != nil {
+			return err
+		}
 
 		s := &http.Server{
 			Addr:              ":8443",
 			Handler:           mux,
 			ReadHeaderTimeout: 32 * time.Second,
-			TLSConfig: &tls.Config{
-				MinVersion: appv1alpha1.TLSMinVersionInt, // #nosec G402 -- TLS 1.2 is required for FIPS
-			},
+			TLSConfig:         tlsConfig,
 		}
 
-		klog.Fatal(s.ListenAndServeTLS(listener.TLSCrtFile, listener.TLSKeyFile))
+		klog.Fatal(s.ListenAndServeTLS("", ""))
 	} else {
 		klog.Info("Starting the WebHook listener on port 8443 with no TLS.")
 
@@ -138,6 +172,51 @@ func (listener *WebhookListener) Start(ctx context.Context) error {
 	return nil
 }
 
+// newCertReloader returns the reloader of the certificate of the cert provider secret or of the certificate files,
+// nil without TLS
+func (listener *WebhookListener) newCertReloader() (*certReloader, error) {
+	if listener.TLSOptions.CertProvider != "" {
+		namespace, err := getOperatorNamespace()
+		if err != nil {
+			return nil, err
+		}
+
+		key := types.NamespacedName{Name: serviceName, Namespace: namespace}
+
+		klog.Infof("Starting the WebHook listener on port 8443 with the %v certificate of the secret %v",
+			listener.TLSOptions.CertProvider, key.String())
+
+		return newSecretCertReloader(listener.LocalClient, key,
+			append(serviceDNSNames(namespace), listener.TLSOptions.DNSNames...)), nil
+	}
+
+	if listener.TLSKeyFile != "" && listener.TLSCrtFile != "" {
+		klog.Info("Starting the WebHook listener on port 8443 with TLS key and cert files: " + listener.TLSKeyFile + " " + listener.TLSCrtFile)
+
+		return newFileCertReloader(listener.TLSCrtFile, listener.TLSKeyFile, listener.TLSOptions.DNSNames), nil
+	}
+
+	return nil, nil
+}
+
+// loadCertificate loads the first certificate. The certificate of a cert provider is waited for, it is issued after
+// the listener service or certificate is created.
+func (listener *WebhookListener) loadCertificate(ctx context.Context, reloader *certReloader) error {
+	if listener.TLSOptions.CertProvider == "" {
+		return reloader.reload(ctx)
+	}
+
+	return wait.PollUntilContextCancel(ctx, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+		if err := reloader.reload(ctx); err != nil {
+			klog.Warningf("Waiting for the webhook listener certificate, err: %v", err)
+
+			return false, nil
+		}
+
+		return true, nil
+	})
+}
+
 // CreateWebhookListener creates a WebHook listener instance
 func CreateWebhookListener(config,
 	remoteConfig *rest.Config,
@@ -182,6 +261,8 @@ func CreateWebhookListener(config,
 		return nil, err
 	}
 
+	l.triggers = newSubscriptionTrigger(triggerOptions, l.updateSubscriptionByKey)
+
 	l.RemoteClient = l.LocalClient
 	if remoteConfig != nil {
 		l.RemoteClient, err = client.New(remoteConfig, client.Options{})
@@ -16,7 +16,12 @@ package listener
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha1" // #nosec G505 testing the rejection of the SHA-1 signatures
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"hash"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -31,6 +36,7 @@ import (
 
 	chnv1alpha1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	appv1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
@@ -396,6 +402,45 @@ func TestValidateSecret(t *testing.T) {
 	g.Expect(ret).To(gomega.BeFalse())
 }
 
+func TestValidateSecretSignatureAlgorithms(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	listener := &WebhookListener{}
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	sign := func(newHash func() hash.Hash, prefix string) string {
+		mac := hmac.New(newHash, []byte(""))
+		mac.Write(body)
+
+		return prefix + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	sha1Signature := sign(sha1.New, "sha1=")
+	sha256Signature := sign(sha256.New, "sha256=")
+
+	g.Expect(listener.validateSecret(sha256Signature, map[string]string{}, "default", body)).To(gomega.BeTrue())
+	g.Expect(listener.validateSecret(sha256Signature, map[string]string{}, "default", []byte("changed"))).To(gomega.BeFalse())
+
+	utils.SetFIPSMode(true)
+	defer utils.SetFIPSMode(false)
+
+	// the HMAC SHA-1 signatures are rejected in FIPS mode
+	g.Expect(listener.validateSecret(sha1Signature, map[string]string{}, "default", body)).To(gomega.BeFalse())
+	g.Expect(listener.validateSecret(sha256Signature, map[string]string{}, "default", body)).To(gomega.BeTrue())
+
+	// the SHA-256 signature header is preferred
+	req, err := http.NewRequest("POST", "/webhook", bytes.NewBuffer(body))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Github-Event", "push")
+	req.Header.Set(githubSignatureHeader, sha1Signature)
+	req.Header.Set(githubSHA256SignatureHeader, sha256Signature)
+
+	_, signature, _, err := listener.ParseRequest(req)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(signature).To(gomega.Equal(sha256Signature))
+}
+
 func TestValidateChannel(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
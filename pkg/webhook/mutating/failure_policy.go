@@ -0,0 +1,85 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// WebhookConfigurationName is the name of the subscription MutatingWebhookConfiguration
+	WebhookConfigurationName = "multicluster-operators-subscription"
+	// WebhookName is the name of the subscription mutating webhook in its configuration
+	WebhookName = "subscriptions.apps.open-cluster-management.io"
+)
+
+// AddFailClosed sets the failure policy of the subscription mutating webhook to Fail when the manager starts, so the
+// subscriptions are not admitted unchecked while the webhook is unavailable. It is required when the annotations
+// checked by the webhook, such as the cluster admin approval, are trusted by the controllers.
+func AddFailClosed(mgr manager.Manager) error {
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		return SetFailurePolicy(ctx, mgr.GetAPIReader(), mgr.GetClient(), admissionregistrationv1.Fail)
+	}))
+}
+
+// SetFailurePolicy sets the failure policy of the subscription mutating webhook
+func SetFailurePolicy(ctx context.Context, reader client.Reader, writer client.Writer,
+	policy admissionregistrationv1.FailurePolicyType) error {
+	config := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: WebhookConfigurationName}, config); err != nil {
+		return fmt.Errorf("failed to get the subscription mutating webhook configuration %v, err: %w",
+			WebhookConfigurationName, err)
+	}
+
+	found := false
+	updated := false
+
+	for i := range config.Webhooks {
+		if config.Webhooks[i].Name != WebhookName {
+			continue
+		}
+
+		found = true
+
+		if config.Webhooks[i].FailurePolicy == nil || *config.Webhooks[i].FailurePolicy != policy {
+			config.Webhooks[i].FailurePolicy = &policy
+			updated = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("the subscription mutating webhook %v is not found in the configuration %v", WebhookName,
+			WebhookConfigurationName)
+	}
+
+	if !updated {
+		return nil
+	}
+
+	if err := writer.Update(ctx, config); err != nil {
+		return fmt.Errorf("failed to set the failure policy of the subscription mutating webhook, err: %w", err)
+	}
+
+	klog.Infof("the failure policy of the subscription mutating webhook %v is set to %v", WebhookName, policy)
+
+	return nil
+}
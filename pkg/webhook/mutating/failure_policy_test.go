@@ -0,0 +1,52 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSetFailurePolicy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(admissionregistrationv1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	ignore := admissionregistrationv1.Ignore
+	config := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: WebhookConfigurationName},
+		Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: WebhookName, FailurePolicy: &ignore}},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(config).Build()
+
+	g.Expect(SetFailurePolicy(context.TODO(), c, c, admissionregistrationv1.Fail)).To(gomega.Succeed())
+
+	updated := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Name: WebhookConfigurationName}, updated)).To(gomega.Succeed())
+	g.Expect(*updated.Webhooks[0].FailurePolicy).To(gomega.Equal(admissionregistrationv1.Fail))
+
+	// the manager can't start fail closed without the webhook configuration
+	g.Expect(c.Delete(context.TODO(), updated)).To(gomega.Succeed())
+	g.Expect(SetFailurePolicy(context.TODO(), c, c, admissionregistrationv1.Fail)).NotTo(gomega.Succeed())
+}
@@ -0,0 +1,182 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutating
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// SubscriptionMutatorPath is the path the subscription mutating webhook is served on
+const SubscriptionMutatorPath = "/mutate-apps-open-cluster-management-io-v1-subscription"
+
+// SubscriptionMutator normalizes the annotations of subscriptions on admission, the subscriptions are validated by the
+// validating webhook
+type SubscriptionMutator struct {
+	decoder admission.Decoder
+}
+
+// Add registers the subscription mutating webhook on the manager webhook server
+func Add(mgr manager.Manager) error {
+	klog.Info("registering subscription mutating webhook on path: ", SubscriptionMutatorPath)
+
+	mgr.GetWebhookServer().Register(SubscriptionMutatorPath, &webhook.Admission{
+		Handler: &SubscriptionMutator{decoder: admission.NewDecoder(mgr.GetScheme())},
+	})
+
+	return nil
+}
+
+// Handle normalizes the annotations of the subscription in the admission request
+func (m *SubscriptionMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	appsub := &appv1.Subscription{}
+
+	if err := m.decoder.Decode(req, appsub); err != nil {
+		klog.Error("failed to decode subscription, err: ", err)
+
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var oldAppsub *appv1.Subscription
+
+	if req.Operation == admissionv1.Update {
+		oldAppsub = &appv1.Subscription{}
+		if err := m.decoder.DecodeRaw(req.OldObject, oldAppsub); err != nil {
+			klog.Error("failed to decode the old subscription, err: ", err)
+
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	approved := m.checkClusterAdminApproval(req, appsub, oldAppsub)
+
+	if !normalizeSubscription(appsub, oldAppsub, req.UserInfo) && !approved {
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(appsub)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	klog.V(1).Infof("normalized subscription annotations: %v/%v", appsub.Namespace, appsub.Name)
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// checkClusterAdminApproval sets the cluster admin approval annotations to the approver name and the signature of the
+// approved content if the approval is added or changed, or the subscription is changed, by a user of a cluster admin
+// approver group. The approval changes of the other users are denied by the validating webhook. It returns true if the
+// subscription is changed.
+func (m *SubscriptionMutator) checkClusterAdminApproval(req admission.Request, appsub, oldAppsub *appv1.Subscription) bool {
+	if appsub.GetAnnotations() == nil {
+		return false
+	}
+
+	approved, err := utils.CheckClusterAdminApproval(appsub, oldAppsub, req.UserInfo)
+	if err != nil {
+		klog.V(1).Infof("the approval of subscription %v/%v is not changed, err: %v", appsub.Namespace, appsub.Name, err)
+
+		return false
+	}
+
+	return approved
+}
+
+// userIdentityAnnotations are the annotations of the subscription creator identity
+var userIdentityAnnotations = []string{
+	appv1.AnnotationUserIdentity,
+	appv1.AnnotationUserGroup,
+	appv1.AnnotationUserIdentitySignature,
+}
+
+// normalizeSubscription moves legacy annotations to their current names, sets the user identity annotations on create,
+// keeps the user identity of the old subscription on update and defaults the reconcile option. The old subscription is
+// nil on create. It returns true if the subscription is changed.
+func normalizeSubscription(appsub, oldAppsub *appv1.Subscription, userInfo authenticationv1.UserInfo) bool {
+	annotations := appsub.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	updated := utils.NormalizeGitAnnotations(annotations)
+
+	// The hosting subscription is created by the hub controller, so the user identity of the
+	// original creator must be kept.
+	if oldAppsub == nil && userInfo.Username != "" && annotations[appv1.AnnotationHosting] == "" {
+		user := base64.StdEncoding.EncodeToString([]byte(userInfo.Username))
+		group := base64.StdEncoding.EncodeToString([]byte(strings.Join(userInfo.Groups, ",")))
+
+		if annotations[appv1.AnnotationUserIdentity] != user || annotations[appv1.AnnotationUserGroup] != group {
+			annotations[appv1.AnnotationUserIdentity] = user
+			annotations[appv1.AnnotationUserGroup] = group
+			updated = true
+		}
+
+		// the signature tells the controllers the identity was set by the webhook
+		appsub.SetAnnotations(annotations)
+
+		if utils.SignUserIdentity(appsub) {
+			annotations = appsub.GetAnnotations()
+			updated = true
+		}
+	}
+
+	// the user identity of the creator can't be changed by an update
+	if oldAppsub != nil && oldAppsub.GetAnnotations()[appv1.AnnotationUserIdentity] != "" {
+		for _, key := range userIdentityAnnotations {
+			oldValue, found := oldAppsub.GetAnnotations()[key]
+			if annotations[key] == oldValue {
+				continue
+			}
+
+			if found {
+				annotations[key] = oldValue
+			} else {
+				delete(annotations, key)
+			}
+
+			updated = true
+		}
+	}
+
+	if annotations[appv1.AnnotationResourceReconcileOption] == "" {
+		annotations[appv1.AnnotationResourceReconcileOption] = appv1.MergeReconcile
+		updated = true
+	}
+
+	if updated {
+		appsub.SetAnnotations(annotations)
+	}
+
+	return updated
+}
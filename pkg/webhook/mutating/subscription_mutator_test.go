@@ -0,0 +1,97 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutating
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+func TestNormalizeSubscription(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	utils.SetUserIdentityKey([]byte("identity-key"))
+	defer utils.SetUserIdentityKey(nil)
+
+	userInfo := authenticationv1.UserInfo{
+		Username: "kube:admin",
+		Groups:   []string{"system:authenticated", "system:cluster-admins"},
+	}
+
+	appsub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "appsub",
+			Namespace: "default",
+			Annotations: map[string]string{
+				appv1.AnnotationGithubPath:   "examples/git-simple-sub",
+				appv1.AnnotationGithubBranch: "main",
+			},
+		},
+	}
+
+	g.Expect(normalizeSubscription(appsub, nil, userInfo)).To(gomega.BeTrue())
+
+	annotations := appsub.GetAnnotations()
+	g.Expect(annotations).NotTo(gomega.HaveKey(appv1.AnnotationGithubPath))
+	g.Expect(annotations).NotTo(gomega.HaveKey(appv1.AnnotationGithubBranch))
+	g.Expect(annotations[appv1.AnnotationGitPath]).To(gomega.Equal("examples/git-simple-sub"))
+	g.Expect(annotations[appv1.AnnotationGitBranch]).To(gomega.Equal("main"))
+	g.Expect(annotations[appv1.AnnotationResourceReconcileOption]).To(gomega.Equal(appv1.MergeReconcile))
+
+	user, err := base64.StdEncoding.DecodeString(annotations[appv1.AnnotationUserIdentity])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(user)).To(gomega.Equal("kube:admin"))
+
+	group, err := base64.StdEncoding.DecodeString(annotations[appv1.AnnotationUserGroup])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(group)).To(gomega.Equal("system:authenticated,system:cluster-admins"))
+
+	// the identity is signed for the controllers
+	g.Expect(utils.IsUserIdentitySigned(appsub)).To(gomega.BeTrue())
+
+	// a normalized subscription is left untouched
+	g.Expect(normalizeSubscription(appsub, nil, userInfo)).To(gomega.BeFalse())
+
+	// the user identity is only set on create
+	created := appsub.DeepCopy()
+	updater := authenticationv1.UserInfo{Username: "developer"}
+	g.Expect(normalizeSubscription(appsub, created, updater)).To(gomega.BeFalse())
+	g.Expect(appsub.GetAnnotations()[appv1.AnnotationUserIdentity]).To(gomega.Equal(base64.StdEncoding.EncodeToString([]byte("kube:admin"))))
+
+	// and it can't be changed by an update
+	appsub.Annotations[appv1.AnnotationUserIdentity] = base64.StdEncoding.EncodeToString([]byte("developer"))
+	delete(appsub.Annotations, appv1.AnnotationUserIdentitySignature)
+	g.Expect(normalizeSubscription(appsub, created, updater)).To(gomega.BeTrue())
+	g.Expect(appsub.GetAnnotations()[appv1.AnnotationUserIdentity]).To(gomega.Equal(base64.StdEncoding.EncodeToString([]byte("kube:admin"))))
+	g.Expect(utils.IsUserIdentitySigned(appsub)).To(gomega.BeTrue())
+
+	// the git-path annotation takes precedence over the legacy github-path annotation
+	appsub.Annotations[appv1.AnnotationGithubPath] = "legacy"
+	g.Expect(normalizeSubscription(appsub, created, userInfo)).To(gomega.BeTrue())
+	g.Expect(appsub.GetAnnotations()[appv1.AnnotationGitPath]).To(gomega.Equal("examples/git-simple-sub"))
+	g.Expect(appsub.GetAnnotations()).NotTo(gomega.HaveKey(appv1.AnnotationGithubPath))
+
+	// an explicit reconcile option is kept
+	appsub.Annotations[appv1.AnnotationResourceReconcileOption] = appv1.ReplaceReconcile
+	g.Expect(normalizeSubscription(appsub, created, userInfo)).To(gomega.BeFalse())
+	g.Expect(appsub.GetAnnotations()[appv1.AnnotationResourceReconcileOption]).To(gomega.Equal(appv1.ReplaceReconcile))
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validating
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+// SubscriptionValidatorPath is the path the subscription validating webhook is served on
+const SubscriptionValidatorPath = "/validate-apps-open-cluster-management-io-v1-subscription"
+
+// SubscriptionValidator denies the subscriptions with an invalid time window, reconcile interval or allow and deny
+// lists, a channel not allowed by the channel policies, an approval change by a user who is not an approver, or
+// beyond the subscription quota. It runs after the mutating webhook, on the normalized subscriptions.
+type SubscriptionValidator struct {
+	client  client.Client
+	decoder admission.Decoder
+}
+
+// Add registers the subscription validating webhook on the manager webhook server
+func Add(mgr manager.Manager) error {
+	klog.Info("registering subscription validating webhook on path: ", SubscriptionValidatorPath)
+
+	mgr.GetWebhookServer().Register(SubscriptionValidatorPath, &webhook.Admission{
+		Handler: &SubscriptionValidator{client: mgr.GetClient(), decoder: admission.NewDecoder(mgr.GetScheme())},
+	})
+
+	return nil
+}
+
+// Handle validates the subscription in the admission request
+func (v *SubscriptionValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	appsub := &appv1.Subscription{}
+
+	if err := v.decoder.Decode(req, appsub); err != nil {
+		klog.Error("failed to decode subscription, err: ", err)
+
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	var oldAppsub *appv1.Subscription
+
+	if req.Operation == admissionv1.Update {
+		oldAppsub = &appv1.Subscription{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldAppsub); err != nil {
+			klog.Error("failed to decode the old subscription, err: ", err)
+
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	if err := v.validate(ctx, req, appsub, oldAppsub); err != nil {
+		klog.Infof("denied subscription %v/%v, err: %v", appsub.Namespace, appsub.Name, err)
+
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
+}
+
+// validate returns the reason the subscription is denied, oldAppsub is nil on create
+func (v *SubscriptionValidator) validate(ctx context.Context, req admission.Request,
+	appsub, oldAppsub *appv1.Subscription) error {
+	// an invalid time window location or hour would be evaluated in UTC or ignored by the controllers
+	if err := utils.ValidateTimeWindow(appsub.Spec.TimeWindow); err != nil {
+		return err
+	}
+
+	// a too short reconcile interval would overload the channel servers and the managed cluster agents
+	if err := utils.ValidateReconcileInterval(appsub.Spec.ReconcileInterval); err != nil {
+		return err
+	}
+
+	// an invalid allow or deny list pattern would never match and the resources would be silently deployed or skipped
+	if err := utils.ValidateAllowDenyLists(appsub.Spec); err != nil {
+		return err
+	}
+
+	// the channels not found are skipped, the hub controller reports the missing channels
+	if err := utils.CheckSubscriptionChannelPolicies(v.client, appsub); err != nil {
+		return err
+	}
+
+	// the approval set by an approver is already signed by the mutating webhook, the check is done on a copy so the
+	// admitted subscription is not changed
+	if appsub.GetAnnotations() != nil {
+		if _, err := utils.CheckClusterAdminApproval(appsub.DeepCopy(), oldAppsub, req.UserInfo); err != nil {
+			return err
+		}
+	}
+
+	if oldAppsub == nil {
+		return v.checkSubscriptionQuota(ctx, appsub)
+	}
+
+	return nil
+}
+
+// checkSubscriptionQuota denies the subscription if the namespace already has the maximum number of subscriptions
+func (v *SubscriptionValidator) checkSubscriptionQuota(ctx context.Context, appsub *appv1.Subscription) error {
+	quotas, err := utils.GetSubscriptionQuotas(v.client, appsub.Namespace)
+	if err != nil || len(quotas) == 0 {
+		return nil
+	}
+
+	subs := &appv1.SubscriptionList{}
+	if err := v.client.List(ctx, subs, client.InNamespace(appsub.Namespace)); err != nil {
+		return nil
+	}
+
+	return utils.CheckSubscriptionCountQuota(quotas, subs.Items, appsub)
+}
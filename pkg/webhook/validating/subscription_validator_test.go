@@ -0,0 +1,135 @@
+// Copyright 2021 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validating
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1"
+	appsubReportV1alpha1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/v1alpha1"
+	"open-cluster-management.io/multicloud-operators-subscription/pkg/utils"
+)
+
+func newRequest(g *gomega.WithT, op admissionv1.Operation, appsub, oldAppsub *appv1.Subscription,
+	userInfo authenticationv1.UserInfo) admission.Request {
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: op, UserInfo: userInfo}}
+
+	raw, err := json.Marshal(appsub)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	req.Object = runtime.RawExtension{Raw: raw}
+
+	if oldAppsub != nil {
+		raw, err := json.Marshal(oldAppsub)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+
+	return req
+}
+
+func TestSubscriptionValidator(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	utils.SetClusterAdminApproverGroups([]string{"platform-admins"})
+	defer utils.SetClusterAdminApproverGroups(nil)
+
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(appv1.SchemeBuilder.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(appsubReportV1alpha1.AddToScheme(scheme)).To(gomega.Succeed())
+	g.Expect(chnv1.AddToScheme(scheme)).To(gomega.Succeed())
+
+	existing := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "app-ns"},
+		Spec:       appv1.SubscriptionSpec{Channel: "ch-ns/ch"},
+	}
+	quota := &appsubReportV1alpha1.SubscriptionQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "app-ns"},
+		Spec:       appsubReportV1alpha1.SubscriptionQuotaSpec{MaxSubscriptions: ptr.To(int32(2))},
+	}
+
+	clt := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing, quota).Build()
+
+	v := &SubscriptionValidator{client: clt, decoder: admission.NewDecoder(scheme)}
+
+	user := authenticationv1.UserInfo{Username: "bob", Groups: []string{"system:authenticated"}}
+
+	appsub := &appv1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app-ns"},
+		Spec:       appv1.SubscriptionSpec{Channel: "ch-ns/ch"},
+	}
+
+	resp := v.Handle(context.TODO(), newRequest(g, admissionv1.Create, appsub, nil, user))
+	g.Expect(resp.Allowed).To(gomega.BeTrue())
+
+	// a too short reconcile interval
+	invalid := appsub.DeepCopy()
+	invalid.Spec.ReconcileInterval = &metav1.Duration{Duration: time.Second}
+
+	resp = v.Handle(context.TODO(), newRequest(g, admissionv1.Create, invalid, nil, user))
+	g.Expect(resp.Allowed).To(gomega.BeFalse())
+	g.Expect(resp.Result.Message).To(gomega.ContainSubstring("reconcileInterval"))
+
+	// an invalid time window location
+	invalid = appsub.DeepCopy()
+	invalid.Spec.TimeWindow = &appv1.TimeWindow{WindowType: "active", Location: "Nowhere/Invalid"}
+
+	resp = v.Handle(context.TODO(), newRequest(g, admissionv1.Update, invalid, appsub, user))
+	g.Expect(resp.Allowed).To(gomega.BeFalse())
+	g.Expect(resp.Result.Message).To(gomega.ContainSubstring("time window location"))
+
+	// a user who is not an approver can't approve the cluster admin access
+	approved := appsub.DeepCopy()
+	approved.SetAnnotations(map[string]string{appv1.AnnotationClusterAdminApprovedBy: "alice"})
+
+	resp = v.Handle(context.TODO(), newRequest(g, admissionv1.Update, approved, appsub, user))
+	g.Expect(resp.Allowed).To(gomega.BeFalse())
+	g.Expect(resp.Result.Message).To(gomega.ContainSubstring("not permitted to approve"))
+
+	approver := authenticationv1.UserInfo{Username: "alice", Groups: []string{"platform-admins"}}
+
+	resp = v.Handle(context.TODO(), newRequest(g, admissionv1.Update, approved, appsub, approver))
+	g.Expect(resp.Allowed).To(gomega.BeTrue())
+
+	// the namespace is limited to 2 subscriptions
+	g.Expect(clt.Create(context.TODO(), appsub.DeepCopy())).To(gomega.Succeed())
+
+	beyond := appsub.DeepCopy()
+	beyond.Name = "beyond"
+
+	resp = v.Handle(context.TODO(), newRequest(g, admissionv1.Create, beyond, nil, user))
+	g.Expect(resp.Allowed).To(gomega.BeFalse())
+	g.Expect(resp.Result.Message).To(gomega.ContainSubstring("quota"))
+
+	// the updates of the existing subscriptions are not checked against the quota
+	resp = v.Handle(context.TODO(), newRequest(g, admissionv1.Update, appsub, appsub, user))
+	g.Expect(resp.Allowed).To(gomega.BeTrue())
+}